@@ -6,8 +6,12 @@ package engine
 import (
 	"context"
 	"crypto/sha256"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
 	"go.opentelemetry.io/otel"
@@ -157,9 +161,34 @@ func (e *BuildEngine) stepResolve(ctx context.Context, build *model.Build) error
 	// the full dependency graph. For now, we simulate a successful resolution.
 	e.log(ctx, build.ID, "info", "resolve", "dependency tree resolved: 1 root package, 0 transitive dependencies")
 
+	for _, o := range build.Overrides {
+		e.log(ctx, build.ID, "info", "resolve", fmt.Sprintf("applying override for %s", describeOverride(o)))
+	}
+
 	return nil
 }
 
+// describeOverride summarises what an override changes for build logs.
+func describeOverride(o model.PackageOverride) string {
+	var changes []string
+	if o.Version != "" {
+		changes = append(changes, "version "+o.Version)
+	}
+	if o.Schema != "" {
+		changes = append(changes, "schema")
+	}
+	if o.UpstreamConfig != nil {
+		changes = append(changes, "upstream "+o.UpstreamConfig.URL)
+	}
+	if o.Runtime != nil {
+		changes = append(changes, "runtime")
+	}
+	if len(changes) == 0 {
+		return o.PackageName
+	}
+	return fmt.Sprintf("%s (%s)", o.PackageName, strings.Join(changes, ", "))
+}
+
 // stepCompose simulates composing subgraph schemas for GraphQL supergraphs.
 func (e *BuildEngine) stepCompose(ctx context.Context, build *model.Build) error {
 	_, span := tracer.Start(ctx, "compose.execute")
@@ -229,5 +258,96 @@ func (e *BuildEngine) stepBundle(ctx context.Context, build *model.Build) error
 
 	e.log(ctx, build.ID, "info", "bundle", fmt.Sprintf("produced %d artifacts", len(build.Artifacts)))
 
+	graph, err := assembleGraph(build)
+	if err != nil {
+		return err
+	}
+	build.Graph = graph
+	e.log(ctx, build.ID, "info", "bundle", fmt.Sprintf("assembled graph with %d components", len(graph.Components)))
+
 	return nil
 }
+
+// assembleGraph builds the deployable graph for a build: the root package
+// plus every package the environment overrides, with version pins, upstream
+// replacements and runtime overrides applied. Each component's artifact hash
+// covers everything that should cause it to be redeployed except its replica
+// count, which the operator tracks separately.
+func assembleGraph(build *model.Build) (*model.APIGraphSpec, error) {
+	graph := &model.APIGraphSpec{
+		EnvironmentID: build.EnvironmentID,
+		BuildID:       build.ID,
+		RootPackage:   build.RootPackageName,
+		Components: []model.DeployedComponent{{
+			PackageName:    build.RootPackageName,
+			PackageVersion: build.RootPackageVersion,
+			Runtime:        model.ComponentRuntime{Replicas: 1},
+		}},
+	}
+	schemas := make(map[string]string)
+
+	for _, o := range build.Overrides {
+		i := slices.IndexFunc(graph.Components, func(c model.DeployedComponent) bool {
+			return c.PackageName == o.PackageName
+		})
+		if i < 0 {
+			graph.Components = append(graph.Components, model.DeployedComponent{
+				PackageName: o.PackageName,
+				Runtime:     model.ComponentRuntime{Replicas: 1},
+			})
+			i = len(graph.Components) - 1
+		}
+		c := &graph.Components[i]
+
+		if o.Version != "" {
+			c.PackageVersion = o.Version
+		}
+		schemas[o.PackageName] = o.Schema
+		if o.UpstreamConfig != nil {
+			u := *o.UpstreamConfig
+			u.Headers = maps.Clone(u.Headers)
+			if u.Auth != nil {
+				auth := *u.Auth
+				auth.Scopes = slices.Clone(auth.Scopes)
+				u.Auth = &auth
+			}
+			c.Upstream = &u
+		}
+		if o.Runtime != nil {
+			if o.Runtime.Replicas != nil {
+				c.Runtime.Replicas = *o.Runtime.Replicas
+			}
+			if len(o.Runtime.Env) > 0 {
+				if c.Runtime.Env == nil {
+					c.Runtime.Env = make(map[string]string, len(o.Runtime.Env))
+				}
+				maps.Copy(c.Runtime.Env, o.Runtime.Env)
+			}
+			if len(o.Runtime.SecretEnv) > 0 {
+				if c.Runtime.SecretEnv == nil {
+					c.Runtime.SecretEnv = make(map[string]string, len(o.Runtime.SecretEnv))
+				}
+				maps.Copy(c.Runtime.SecretEnv, o.Runtime.SecretEnv)
+			}
+		}
+	}
+
+	for i := range graph.Components {
+		c := &graph.Components[i]
+		// json.Marshal sorts map keys, so the encoding is deterministic.
+		inputs, err := json.Marshal(struct {
+			EnvironmentID string                `json:"environmentId"`
+			PackageName   string                `json:"packageName"`
+			Version       string                `json:"version"`
+			Schema        string                `json:"schema"`
+			Upstream      *model.UpstreamConfig `json:"upstream"`
+			Env           map[string]string     `json:"env"`
+			SecretEnv     map[string]string     `json:"secretEnv"`
+		}{build.EnvironmentID, c.PackageName, c.PackageVersion, schemas[c.PackageName], c.Upstream, c.Runtime.Env, c.Runtime.SecretEnv})
+		if err != nil {
+			return nil, fmt.Errorf("hash component %s: %w", c.PackageName, err)
+		}
+		c.ArtifactHash = fmt.Sprintf("%x", sha256.Sum256(inputs))[:16]
+	}
+	return graph, nil
+}
@@ -198,3 +198,87 @@ func TestEngine_StepBundle(t *testing.T) {
 		t.Fatalf("expected 2 artifacts after bundle step, got %d", len(build.Artifacts))
 	}
 }
+
+func TestEngine_StepBundle_Graph(t *testing.T) {
+	eng, _, build := setupEngine(t)
+	ctx := context.Background()
+
+	replicas := int32(3)
+	build.Overrides = []model.PackageOverride{
+		{PackageName: "users", Version: "2.1.0", UpstreamConfig: &model.UpstreamConfig{URL: "http://dev.internal:4001"}},
+		{PackageName: "my-api", Runtime: &model.RuntimeOverride{Replicas: &replicas, Env: map[string]string{"LOG_LEVEL": "debug"}}},
+	}
+
+	if err := eng.stepBundle(ctx, build); err != nil {
+		t.Fatalf("stepBundle: %v", err)
+	}
+
+	graph := build.Graph
+	if graph == nil || graph.EnvironmentID != "env-test" || graph.BuildID != build.ID || graph.RootPackage != "my-api" {
+		t.Fatalf("unexpected graph: %+v", graph)
+	}
+	if len(graph.Components) != 2 {
+		t.Fatalf("expected root and users components, got %+v", graph.Components)
+	}
+
+	root := graph.Components[0]
+	if root.PackageName != "my-api" || root.PackageVersion != "1.0.0" || root.Runtime.Replicas != 3 || root.Runtime.Env["LOG_LEVEL"] != "debug" {
+		t.Fatalf("expected runtime override on the root component, got %+v", root)
+	}
+
+	users := graph.Components[1]
+	if users.PackageVersion != "2.1.0" || users.Runtime.Replicas != 1 {
+		t.Fatalf("expected users pinned to 2.1.0 with default replicas, got %+v", users)
+	}
+	if users.Upstream == nil || users.Upstream.URL != "http://dev.internal:4001" {
+		t.Fatalf("expected overridden upstream, got %+v", users.Upstream)
+	}
+	if users.ArtifactHash == "" || users.ArtifactHash == root.ArtifactHash {
+		t.Fatalf("expected distinct artifact hashes, got %q and %q", root.ArtifactHash, users.ArtifactHash)
+	}
+}
+
+func TestAssembleGraph_HashTracksInputs(t *testing.T) {
+	build := &model.Build{
+		ID:              "build-1",
+		EnvironmentID:   "env-1",
+		RootPackageName: "my-api",
+		Overrides:       []model.PackageOverride{{PackageName: "users", Schema: "type User { id: ID }"}},
+	}
+	first, err := assembleGraph(build)
+	if err != nil {
+		t.Fatalf("assembleGraph: %v", err)
+	}
+
+	// Replica changes are tracked by the operator, not the hash.
+	replicas := int32(4)
+	build.Overrides[0].Runtime = &model.RuntimeOverride{Replicas: &replicas}
+	scaled, err := assembleGraph(build)
+	if err != nil {
+		t.Fatalf("assembleGraph: %v", err)
+	}
+	if scaled.Components[1].ArtifactHash != first.Components[1].ArtifactHash {
+		t.Fatal("expected a replica change to keep the artifact hash")
+	}
+
+	build.Overrides[0].UpstreamConfig = &model.UpstreamConfig{URL: "http://localhost:4001"}
+	moved, err := assembleGraph(build)
+	if err != nil {
+		t.Fatalf("assembleGraph: %v", err)
+	}
+	if moved.Components[1].ArtifactHash == first.Components[1].ArtifactHash {
+		t.Fatal("expected an upstream change to change the artifact hash")
+	}
+
+	build.Overrides[0].Runtime.SecretEnv = map[string]string{"API_KEY": "api-key"}
+	keyed, err := assembleGraph(build)
+	if err != nil {
+		t.Fatalf("assembleGraph: %v", err)
+	}
+	if keyed.Components[1].Runtime.SecretEnv["API_KEY"] != "api-key" {
+		t.Fatalf("expected the secret env on the component, got %+v", keyed.Components[1].Runtime)
+	}
+	if keyed.Components[1].ArtifactHash == moved.Components[1].ArtifactHash {
+		t.Fatal("expected a secret env change to change the artifact hash")
+	}
+}
@@ -6,7 +6,10 @@ import (
 	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
+	"net/url"
+	"slices"
 	"time"
 
 	"github.com/lennyburdette/turbo-engine/services/builder/internal/engine"
@@ -38,6 +41,7 @@ func (h *BuilderHandler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("POST /v1/builds", h.CreateBuild)
 	mux.HandleFunc("GET /v1/builds/{buildId}", h.GetBuild)
 	mux.HandleFunc("GET /v1/builds/{buildId}/logs", h.StreamBuildLogs)
+	mux.HandleFunc("GET /v1/graphs", h.ListGraphs)
 }
 
 // CreateBuild handles POST /v1/builds.
@@ -52,6 +56,10 @@ func (h *BuilderHandler) CreateBuild(w http.ResponseWriter, r *http.Request) {
 		h.writeError(w, http.StatusBadRequest, "environmentId is required")
 		return
 	}
+	if err := validateOverrides(req.Overrides); err != nil {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 
 	build := &model.Build{
 		ID:                 h.nextID(),
@@ -61,6 +69,7 @@ func (h *BuilderHandler) CreateBuild(w http.ResponseWriter, r *http.Request) {
 		CreatedAt:          time.Now().UTC(),
 		RootPackageName:    req.RootPackageName,
 		RootPackageVersion: req.RootPackageVersion,
+		Overrides:          req.Overrides,
 	}
 
 	created, err := h.store.CreateBuild(r.Context(), build)
@@ -103,6 +112,31 @@ func (h *BuilderHandler) GetBuild(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, build)
 }
 
+// ListGraphs handles GET /v1/graphs. It returns the graph of the most recent
+// successful build for each environment, ordered by environment ID.
+func (h *BuilderHandler) ListGraphs(w http.ResponseWriter, r *http.Request) {
+	builds, err := h.store.ListBuilds(r.Context())
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to list builds", "error", err)
+		h.writeError(w, http.StatusInternalServerError, "failed to list graphs")
+		return
+	}
+
+	// Builds are listed oldest first, so later builds replace earlier ones.
+	latest := make(map[string]*model.APIGraphSpec)
+	for _, b := range builds {
+		if b.Status == model.BuildStatusSucceeded && b.Graph != nil {
+			latest[b.EnvironmentID] = b.Graph
+		}
+	}
+
+	graphs := make([]*model.APIGraphSpec, 0, len(latest))
+	for _, envID := range slices.Sorted(maps.Keys(latest)) {
+		graphs = append(graphs, latest[envID])
+	}
+	h.writeJSON(w, http.StatusOK, graphs)
+}
+
 // StreamBuildLogs handles GET /v1/builds/{buildId}/logs using Server-Sent Events.
 func (h *BuilderHandler) StreamBuildLogs(w http.ResponseWriter, r *http.Request) {
 	buildID := r.PathValue("buildId")
@@ -160,6 +194,32 @@ func (h *BuilderHandler) StreamBuildLogs(w http.ResponseWriter, r *http.Request)
 	}
 }
 
+// validateOverrides rejects overrides the bundle step cannot apply.
+func validateOverrides(overrides []model.PackageOverride) error {
+	seen := make(map[string]bool, len(overrides))
+	for _, o := range overrides {
+		switch {
+		case o.PackageName == "":
+			return errors.New("override packageName is required")
+		case seen[o.PackageName]:
+			return fmt.Errorf("override for %s: package overridden more than once", o.PackageName)
+		case o.Schema != "" && o.Version != "":
+			return fmt.Errorf("override for %s: only one of schema and version may be set", o.PackageName)
+		case o.Runtime != nil && o.Runtime.Replicas != nil && *o.Runtime.Replicas < 0:
+			return fmt.Errorf("override for %s: replicas must not be negative", o.PackageName)
+		}
+		if o.UpstreamConfig != nil {
+			u, err := url.Parse(o.UpstreamConfig.URL)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return fmt.Errorf("override for %s: upstream url %q must be an absolute http or https URL",
+					o.PackageName, o.UpstreamConfig.URL)
+			}
+		}
+		seen[o.PackageName] = true
+	}
+	return nil
+}
+
 // writeJSON writes a JSON response with the given status code.
 func (h *BuilderHandler) writeJSON(w http.ResponseWriter, status int, v any) {
 	w.Header().Set("Content-Type", "application/json")
@@ -273,3 +273,63 @@ func TestCreateBuild_FullLifecycle(t *testing.T) {
 		t.Fatal("expected at least one artifact")
 	}
 }
+
+func TestCreateBuild_InvalidOverride(t *testing.T) {
+	_, mux := newTestHandler()
+
+	for _, overrides := range []string{
+		`[{"schema":"type User { id: ID }"}]`,
+		`[{"packageName":"users","schema":"type User { id: ID }","version":"1.0.0"}]`,
+		`[{"packageName":"users","upstreamConfig":{"url":"localhost:4001"}}]`,
+		`[{"packageName":"users","runtime":{"replicas":-1}}]`,
+		`[{"packageName":"users","version":"1.0.0"},{"packageName":"users","version":"2.0.0"}]`,
+	} {
+		body := `{"environmentId": "env-1", "rootPackageName": "my-api", "overrides": ` + overrides + `}`
+		req := httptest.NewRequest(http.MethodPost, "/v1/builds", strings.NewReader(body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", overrides, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestListGraphs(t *testing.T) {
+	h, mux := newTestHandler()
+	ctx := context.Background()
+
+	now := time.Now().UTC()
+	for _, b := range []*model.Build{
+		{ID: "b-1", EnvironmentID: "env-b", Status: model.BuildStatusSucceeded, CreatedAt: now.Add(-3 * time.Minute)},
+		{ID: "b-2", EnvironmentID: "env-b", Status: model.BuildStatusSucceeded, CreatedAt: now.Add(-2 * time.Minute)},
+		{ID: "b-3", EnvironmentID: "env-b", Status: model.BuildStatusFailed, CreatedAt: now.Add(-time.Minute)},
+		{ID: "a-1", EnvironmentID: "env-a", Status: model.BuildStatusSucceeded, CreatedAt: now},
+		{ID: "c-1", EnvironmentID: "env-c", Status: model.BuildStatusRunning, CreatedAt: now},
+	} {
+		if b.Status == model.BuildStatusSucceeded {
+			b.Graph = &model.APIGraphSpec{EnvironmentID: b.EnvironmentID, BuildID: b.ID}
+		}
+		if _, err := h.store.CreateBuild(ctx, b); err != nil {
+			t.Fatalf("CreateBuild: %v", err)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/graphs", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var graphs []model.APIGraphSpec
+	if err := json.NewDecoder(w.Body).Decode(&graphs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	// env-b's latest build failed, so its last successful graph is served;
+	// env-c has not finished a build yet.
+	if len(graphs) != 2 || graphs[0].BuildID != "a-1" || graphs[1].BuildID != "b-2" {
+		t.Fatalf("expected graphs from a-1 and b-2, got %+v", graphs)
+	}
+}
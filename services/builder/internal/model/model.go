@@ -27,8 +27,12 @@ type Build struct {
 	CompletedAt   *time.Time  `json:"completedAt,omitempty"`
 
 	// Input fields (from the create request).
-	RootPackageName    string `json:"rootPackageName,omitempty"`
-	RootPackageVersion string `json:"rootPackageVersion,omitempty"`
+	RootPackageName    string            `json:"rootPackageName,omitempty"`
+	RootPackageVersion string            `json:"rootPackageVersion,omitempty"`
+	Overrides          []PackageOverride `json:"overrides,omitempty"`
+
+	// Graph is the deployable graph produced by the bundle step.
+	Graph *APIGraphSpec `json:"graph,omitempty"`
 }
 
 // Artifact represents a deployable artifact produced by a build.
@@ -49,7 +53,74 @@ type BuildLogEntry struct {
 
 // CreateBuildRequest is the payload for POST /v1/builds.
 type CreateBuildRequest struct {
-	EnvironmentID      string `json:"environmentId"`
-	RootPackageName    string `json:"rootPackageName"`
-	RootPackageVersion string `json:"rootPackageVersion"`
+	EnvironmentID      string            `json:"environmentId"`
+	RootPackageName    string            `json:"rootPackageName"`
+	RootPackageVersion string            `json:"rootPackageVersion"`
+	Overrides          []PackageOverride `json:"overrides,omitempty"`
+}
+
+// PackageOverride mirrors the Environment Manager's package override: a
+// schema replacement or version pin, an upstream replacement, and runtime
+// adjustments for one package in the tree.
+type PackageOverride struct {
+	PackageName    string           `json:"packageName"`
+	Schema         string           `json:"schema,omitempty"`
+	Version        string           `json:"version,omitempty"`
+	UpstreamConfig *UpstreamConfig  `json:"upstreamConfig,omitempty"`
+	Runtime        *RuntimeOverride `json:"runtime,omitempty"`
+}
+
+// UpstreamConfig holds the upstream URL, headers and auth for a package.
+type UpstreamConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Auth    *UpstreamAuth     `json:"auth,omitempty"`
+}
+
+// UpstreamAuth mirrors the Environment Manager's upstream auth. It names the
+// environment secrets to authenticate with, never their values.
+type UpstreamAuth struct {
+	Type                  string   `json:"type"`
+	TokenSecretRef        string   `json:"tokenSecretRef,omitempty"`
+	TokenEndpoint         string   `json:"tokenEndpoint,omitempty"`
+	ClientIDSecretRef     string   `json:"clientIdSecretRef,omitempty"`
+	ClientSecretSecretRef string   `json:"clientSecretSecretRef,omitempty"`
+	Scopes                []string `json:"scopes,omitempty"`
+	CertSecretRef         string   `json:"certSecretRef,omitempty"`
+	KeySecretRef          string   `json:"keySecretRef,omitempty"`
+	CASecretRef           string   `json:"caSecretRef,omitempty"`
+}
+
+// RuntimeOverride adjusts a deployed component without changing its package.
+// SecretEnv maps environment variables to the environment secrets they are
+// set from.
+type RuntimeOverride struct {
+	Replicas  *int32            `json:"replicas,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+	SecretEnv map[string]string `json:"secretEnv,omitempty"`
+}
+
+// APIGraphSpec mirrors the Operator's APIGraphSpec: the components a build
+// deploys for an environment.
+type APIGraphSpec struct {
+	EnvironmentID string              `json:"environmentId"`
+	BuildID       string              `json:"buildId"`
+	RootPackage   string              `json:"rootPackage"`
+	Components    []DeployedComponent `json:"components"`
+}
+
+// DeployedComponent mirrors the Operator's DeployedComponent.
+type DeployedComponent struct {
+	PackageName    string           `json:"packageName"`
+	PackageVersion string           `json:"packageVersion,omitempty"`
+	ArtifactHash   string           `json:"artifactHash"`
+	Upstream       *UpstreamConfig  `json:"upstream,omitempty"`
+	Runtime        ComponentRuntime `json:"runtime"`
+}
+
+// ComponentRuntime mirrors the Operator's ComponentRuntime.
+type ComponentRuntime struct {
+	Replicas  int32             `json:"replicas"`
+	Env       map[string]string `json:"env,omitempty"`
+	SecretEnv map[string]string `json:"secretEnv,omitempty"`
 }
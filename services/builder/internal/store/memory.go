@@ -3,6 +3,8 @@ package store
 import (
 	"context"
 	"errors"
+	"maps"
+	"sort"
 	"sync"
 
 	"github.com/lennyburdette/turbo-engine/services/builder/internal/model"
@@ -39,16 +41,10 @@ func (m *MemoryStore) CreateBuild(_ context.Context, build *model.Build) (*model
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	copied := *build
-	copied.Artifacts = make([]model.Artifact, len(build.Artifacts))
-	copy(copied.Artifacts, build.Artifacts)
-	m.builds[build.ID] = &copied
+	m.builds[build.ID] = cloneBuild(build)
 	m.logs[build.ID] = nil
 
-	ret := copied
-	ret.Artifacts = make([]model.Artifact, len(copied.Artifacts))
-	copy(ret.Artifacts, copied.Artifacts)
-	return &ret, nil
+	return cloneBuild(build), nil
 }
 
 // GetBuild retrieves a build by ID.
@@ -60,11 +56,26 @@ func (m *MemoryStore) GetBuild(_ context.Context, id string) (*model.Build, erro
 	if !ok {
 		return nil, ErrNotFound
 	}
-	copied := *b
-	// Deep-copy artifacts slice so the caller cannot mutate store state.
-	copied.Artifacts = make([]model.Artifact, len(b.Artifacts))
-	copy(copied.Artifacts, b.Artifacts)
-	return &copied, nil
+	// Deep-copy so the caller cannot mutate store state.
+	return cloneBuild(b), nil
+}
+
+// ListBuilds returns every build, oldest first.
+func (m *MemoryStore) ListBuilds(_ context.Context) ([]*model.Build, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	builds := make([]*model.Build, 0, len(m.builds))
+	for _, b := range m.builds {
+		builds = append(builds, cloneBuild(b))
+	}
+	sort.Slice(builds, func(i, j int) bool {
+		if builds[i].CreatedAt.Equal(builds[j].CreatedAt) {
+			return builds[i].ID < builds[j].ID
+		}
+		return builds[i].CreatedAt.Before(builds[j].CreatedAt)
+	})
+	return builds, nil
 }
 
 // UpdateBuild replaces the stored build.
@@ -75,18 +86,14 @@ func (m *MemoryStore) UpdateBuild(_ context.Context, build *model.Build) (*model
 	if _, ok := m.builds[build.ID]; !ok {
 		return nil, ErrNotFound
 	}
-	copied := *build
-	copied.Artifacts = make([]model.Artifact, len(build.Artifacts))
-	copy(copied.Artifacts, build.Artifacts)
-	m.builds[build.ID] = &copied
+	m.builds[build.ID] = cloneBuild(build)
 
 	// If the build is terminal, close all subscriber channels.
 	if build.Status == model.BuildStatusSucceeded || build.Status == model.BuildStatusFailed {
 		m.closeSubscribers(build.ID)
 	}
 
-	ret := copied
-	return &ret, nil
+	return cloneBuild(build), nil
 }
 
 // AppendLog adds a log entry and fans it out to subscribers.
@@ -194,3 +201,50 @@ func (m *MemoryStore) removeSubscriber(buildID string, target *subscriber) {
 		}
 	}
 }
+
+// cloneBuild deep-copies a build's slices, maps and pointers so the store and
+// its callers never share mutable state.
+func cloneBuild(b *model.Build) *model.Build {
+	copied := *b
+	copied.Artifacts = make([]model.Artifact, len(b.Artifacts))
+	copy(copied.Artifacts, b.Artifacts)
+	if b.CompletedAt != nil {
+		t := *b.CompletedAt
+		copied.CompletedAt = &t
+	}
+	if b.Overrides != nil {
+		copied.Overrides = make([]model.PackageOverride, len(b.Overrides))
+		for i, o := range b.Overrides {
+			if o.UpstreamConfig != nil {
+				u := *o.UpstreamConfig
+				u.Headers = maps.Clone(u.Headers)
+				o.UpstreamConfig = &u
+			}
+			if o.Runtime != nil {
+				r := *o.Runtime
+				if r.Replicas != nil {
+					n := *r.Replicas
+					r.Replicas = &n
+				}
+				r.Env = maps.Clone(r.Env)
+				o.Runtime = &r
+			}
+			copied.Overrides[i] = o
+		}
+	}
+	if b.Graph != nil {
+		g := *b.Graph
+		g.Components = make([]model.DeployedComponent, len(b.Graph.Components))
+		for i, c := range b.Graph.Components {
+			if c.Upstream != nil {
+				u := *c.Upstream
+				u.Headers = maps.Clone(u.Headers)
+				c.Upstream = &u
+			}
+			c.Runtime.Env = maps.Clone(c.Runtime.Env)
+			g.Components[i] = c
+		}
+		copied.Graph = &g
+	}
+	return &copied
+}
@@ -255,3 +255,43 @@ func TestCreateBuildIsolation(t *testing.T) {
 		t.Fatalf("store mutation leaked: got kind %q", got.Artifacts[0].Kind)
 	}
 }
+
+func TestListBuilds(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+
+	older := newTestBuild()
+	older.CreatedAt = time.Now().UTC().Add(-time.Minute)
+	newer := newTestBuild()
+	newer.ID = "build-2"
+	_, _ = s.CreateBuild(ctx, newer)
+	_, _ = s.CreateBuild(ctx, older)
+
+	builds, err := s.ListBuilds(ctx)
+	if err != nil {
+		t.Fatalf("ListBuilds: %v", err)
+	}
+	if len(builds) != 2 || builds[0].ID != "build-1" || builds[1].ID != "build-2" {
+		t.Fatalf("expected builds oldest first, got %+v", builds)
+	}
+}
+
+func TestUpdateBuildGraphIsolation(t *testing.T) {
+	s := NewMemoryStore()
+	ctx := context.Background()
+
+	b := newTestBuild()
+	_, _ = s.CreateBuild(ctx, b)
+	b.Graph = &model.APIGraphSpec{Components: []model.DeployedComponent{
+		{PackageName: "users", Runtime: model.ComponentRuntime{Env: map[string]string{"LOG_LEVEL": "info"}}},
+	}}
+	_, _ = s.UpdateBuild(ctx, b)
+
+	// Mutate the original — should not affect stored copy.
+	b.Graph.Components[0].Runtime.Env["LOG_LEVEL"] = "debug"
+
+	got, _ := s.GetBuild(ctx, b.ID)
+	if got.Graph.Components[0].Runtime.Env["LOG_LEVEL"] != "info" {
+		t.Fatalf("store mutation leaked: got %v", got.Graph.Components[0].Runtime.Env)
+	}
+}
@@ -16,6 +16,9 @@ type Store interface {
 	// does not exist.
 	GetBuild(ctx context.Context, id string) (*model.Build, error)
 
+	// ListBuilds returns every build, ordered by creation time, oldest first.
+	ListBuilds(ctx context.Context) ([]*model.Build, error)
+
 	// UpdateBuild replaces the stored build with the provided one.
 	UpdateBuild(ctx context.Context, build *model.Build) (*model.Build, error)
 
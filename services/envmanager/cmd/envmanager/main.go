@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
@@ -19,16 +20,28 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/builder"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/handler"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/operator"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/registry"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/secrets"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/smoketest"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/webhook"
 )
 
 const (
-	serviceName    = "envmanager"
-	defaultPort    = "8083"
-	shutdownTimeout = 10 * time.Second
+	serviceName        = "envmanager"
+	defaultPort        = "8083"
+	defaultRegistryURL = "http://localhost:8081"
+	defaultGitHubAPI   = "https://api.github.com"
+	defaultGitLabAPI   = "https://gitlab.com/api/v4"
+	shutdownTimeout    = 10 * time.Second
+
+	defaultReaperInterval = 5 * time.Minute
+	defaultSleepInterval  = time.Minute
 )
 
 func main() {
@@ -60,11 +73,99 @@ func main() {
 	}()
 
 	// --- Dependencies ---
-	memStore := store.NewMemoryStore()
-	builder := &stubBuilderClient{logger: logger}
-	operator := &stubOperatorClient{logger: logger}
-	orch := orchestrator.New(memStore, builder, operator, logger)
-	h := handler.New(orch, logger)
+	// STORE_PATH enables a file-backed store so in-flight work survives restarts.
+	var envStore store.Store = store.NewMemoryStore()
+	if path := os.Getenv("STORE_PATH"); path != "" {
+		fileStore, err := store.NewFileStore(path)
+		if err != nil {
+			logger.Error("failed to open store", slog.String("path", path), slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		envStore = fileStore
+	}
+	// BUILDER_URL points at the Builder service; unset uses a stub that
+	// reports every build as succeeded.
+	var builderClient orchestrator.BuilderClient = &stubBuilderClient{logger: logger}
+	if url := os.Getenv("BUILDER_URL"); url != "" {
+		builderClient = builder.New(url, nil)
+	}
+	// OPERATOR_URL points at the Operator service, which deploys the graphs
+	// the Builder produces, so it needs BUILDER_URL too. Unset uses a stub
+	// that reports every deploy as running.
+	var operatorClient orchestrator.OperatorClient = &stubOperatorClient{logger: logger}
+	if url := os.Getenv("OPERATOR_URL"); url != "" {
+		graphs, ok := builderClient.(operator.GraphSource)
+		if !ok {
+			logger.Error("OPERATOR_URL requires BUILDER_URL")
+			os.Exit(1)
+		}
+		operatorClient = operator.New(url, graphs, nil)
+	}
+	registryURL := os.Getenv("REGISTRY_URL")
+	if registryURL == "" {
+		registryURL = defaultRegistryURL
+	}
+	// IDLE_TIMEOUT (e.g. "168h") reaps environments with no builds or traffic
+	// for that long. Unset disables idle expiry; explicit TTLs still apply.
+	idleTimeout := durationEnv(logger, "IDLE_TIMEOUT", 0)
+	// IDLE_SLEEP (e.g. "2h") scales environments to zero after that long
+	// without activity, unless their sleep schedule sets its own idleAfter.
+	idleSleep := durationEnv(logger, "IDLE_SLEEP", 0)
+	// Quotas on environments per user and per team (the "team" label) and on
+	// each environment's size. Unset or zero is unlimited.
+	quotas := model.Quotas{
+		MaxEnvironmentsPerUser: intEnv(logger, "MAX_ENVIRONMENTS_PER_USER"),
+		MaxEnvironmentsPerTeam: intEnv(logger, "MAX_ENVIRONMENTS_PER_TEAM"),
+		MaxComponents:          intEnv(logger, "MAX_COMPONENTS_PER_ENVIRONMENT"),
+		MaxReplicas:            intEnv(logger, "MAX_REPLICAS_PER_ENVIRONMENT"),
+	}
+	opts := []orchestrator.Option{
+		orchestrator.WithRegistry(registry.New(registryURL, nil)),
+		orchestrator.WithIdleTimeout(idleTimeout),
+		orchestrator.WithIdleSleep(idleSleep),
+		orchestrator.WithQuotas(quotas),
+	}
+	if opt, ok := smokeTestOption(logger); ok {
+		opts = append(opts, opt)
+	}
+	if opt, ok := secretsOption(logger); ok {
+		opts = append(opts, opt)
+	}
+	// UNIQUE_ENVIRONMENT_NAMES makes creating an environment whose name and
+	// branch are taken return the existing one.
+	if boolEnv(logger, "UNIQUE_ENVIRONMENT_NAMES") {
+		opts = append(opts, orchestrator.WithUniqueNames())
+	}
+	orch := orchestrator.New(envStore, builderClient, operatorClient, logger, opts...)
+	handlerOpts := []handler.Option{webhookOption(orch, logger)}
+	// Responses to requests with an Idempotency-Key are kept alongside the
+	// environments for IDEMPOTENCY_RETENTION.
+	if s, ok := envStore.(store.IdempotencyStore); ok {
+		handlerOpts = append(handlerOpts, handler.WithIdempotency(s,
+			durationEnv(logger, "IDEMPOTENCY_RETENTION", handler.DefaultIdempotencyRetention)))
+	}
+	h := handler.New(orch, logger, handlerOpts...)
+
+	// Resume or compensate work interrupted by the last shutdown.
+	if err := orch.Recover(ctx); err != nil {
+		logger.Error("failed to recover in-flight work", slog.String("error", err.Error()))
+	}
+
+	// Periodically delete expired and idle environments.
+	reaperCtx, stopReaper := context.WithCancel(ctx)
+	reaperDone := make(chan struct{})
+	go func() {
+		defer close(reaperDone)
+		orch.RunReaper(reaperCtx, durationEnv(logger, "REAPER_INTERVAL", defaultReaperInterval))
+	}()
+
+	// Periodically put environments to sleep and wake them per their
+	// schedules.
+	sleepDone := make(chan struct{})
+	go func() {
+		defer close(sleepDone)
+		orch.RunSleepScheduler(reaperCtx, durationEnv(logger, "SLEEP_INTERVAL", defaultSleepInterval))
+	}()
 
 	// --- HTTP Server ---
 	mux := http.NewServeMux()
@@ -127,9 +228,140 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Stop the reaper, the sleep scheduler and background build/deploy
+	// workflows.
+	stopReaper()
+	<-reaperDone
+	<-sleepDone
+	orch.Shutdown()
+
 	logger.Info("server stopped")
 }
 
+// webhookOption serves GitHub and GitLab webhooks for the providers whose
+// secret is set in GITHUB_WEBHOOK_SECRET or GITLAB_WEBHOOK_SECRET. Changed
+// files are read through each provider's API, authenticated with GITHUB_TOKEN
+// or GITLAB_TOKEN; GITHUB_API_URL and GITLAB_API_URL point at self-hosted
+// instances.
+func webhookOption(orch *orchestrator.Orchestrator, logger *slog.Logger) handler.Option {
+	secrets := handler.WebhookSecrets{
+		GitHub: os.Getenv("GITHUB_WEBHOOK_SECRET"),
+		GitLab: os.Getenv("GITLAB_WEBHOOK_SECRET"),
+	}
+	receiver := webhook.New(orch, logger,
+		webhook.WithSource(webhook.ProviderGitHub,
+			webhook.NewGitHubSource(envOr("GITHUB_API_URL", defaultGitHubAPI), os.Getenv("GITHUB_TOKEN"), nil)),
+		webhook.WithSource(webhook.ProviderGitLab,
+			webhook.NewGitLabSource(envOr("GITLAB_API_URL", defaultGitLabAPI), os.Getenv("GITLAB_TOKEN"), nil)))
+	return handler.WithWebhooks(receiver, secrets)
+}
+
+// smokeTestOption configures post-deploy smoke tests from SMOKE_TESTS:
+// "report" (the default) stores each environment's results, "degrade" also
+// marks environments with failing tests degraded, and "off" disables them.
+func smokeTestOption(logger *slog.Logger) (orchestrator.Option, bool) {
+	switch mode := envOr("SMOKE_TESTS", "report"); mode {
+	case "off":
+		return nil, false
+	case "report", "degrade":
+		return orchestrator.WithSmokeTests(smoketest.New(nil), mode == "degrade"), true
+	default:
+		logger.Warn("invalid SMOKE_TESTS, using report", slog.String("value", mode))
+		return orchestrator.WithSmokeTests(smoketest.New(nil), false), true
+	}
+}
+
+// secretsOption enables environment secrets with the base64-encoded 32-byte
+// key in SECRETS_KEY, or in the file named by SECRETS_KEY_FILE. Without a key
+// secrets are disabled; an invalid key is fatal rather than silently
+// disabling them.
+func secretsOption(logger *slog.Logger) (orchestrator.Option, bool) {
+	encoded := os.Getenv("SECRETS_KEY")
+	if path := os.Getenv("SECRETS_KEY_FILE"); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			logger.Error("failed to read secrets key", slog.String("path", path), slog.String("error", err.Error()))
+			os.Exit(1)
+		}
+		encoded = string(data)
+	}
+	if encoded == "" {
+		logger.Info("SECRETS_KEY not set, environment secrets disabled")
+		return nil, false
+	}
+	key, err := secrets.ParseKey(encoded)
+	if err != nil {
+		logger.Error("invalid secrets key", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	c, err := secrets.NewCipher(key)
+	if err != nil {
+		logger.Error("invalid secrets key", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	return orchestrator.WithSecrets(c), true
+}
+
+// envOr returns the environment variable key, or def if it is unset.
+func envOr(key, def string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return def
+}
+
+// durationEnv parses the duration in environment variable key, returning def
+// if it is unset or invalid.
+func durationEnv(logger *slog.Logger, key string, def time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d < 0 {
+		logger.Warn("invalid duration, using default",
+			slog.String("key", key),
+			slog.String("value", v),
+			slog.String("default", def.String()))
+		return def
+	}
+	return d
+}
+
+// intEnv parses the non-negative integer in environment variable key,
+// returning 0 if it is unset or invalid.
+func intEnv(logger *slog.Logger, key string) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return 0
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil || n < 0 {
+		logger.Warn("invalid integer, ignoring",
+			slog.String("key", key),
+			slog.String("value", v))
+		return 0
+	}
+	return n
+}
+
+// boolEnv parses the boolean in environment variable key, returning false if
+// it is unset or invalid.
+func boolEnv(logger *slog.Logger, key string) bool {
+	v := os.Getenv(key)
+	if v == "" {
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		logger.Warn("invalid boolean, ignoring",
+			slog.String("key", key),
+			slog.String("value", v))
+		return false
+	}
+	return b
+}
+
 // initTracer sets up an OTLP trace exporter.
 // If OTEL_EXPORTER_OTLP_ENDPOINT is not set, it uses a no-op exporter.
 func initTracer(ctx context.Context) (*sdktrace.TracerProvider, error) {
@@ -183,7 +415,7 @@ func initTracer(ctx context.Context) (*sdktrace.TracerProvider, error) {
 }
 
 // --- Stub clients for builder and operator ---
-// These will be replaced with real HTTP/gRPC clients when those services exist.
+// The stubs are used when BUILDER_URL and OPERATOR_URL are unset.
 
 // stubBuilderClient is a no-op builder client for development.
 type stubBuilderClient struct {
@@ -197,20 +429,45 @@ func (s *stubBuilderClient) TriggerBuild(ctx context.Context, env model.Environm
 	return "stub-build-" + env.ID, nil
 }
 
+func (s *stubBuilderClient) GetBuild(_ context.Context, buildID string) (model.Build, error) {
+	return model.Build{ID: buildID, Status: model.BuildStatusSucceeded}, nil
+}
+
 // stubOperatorClient is a no-op operator client for development.
 type stubOperatorClient struct {
 	logger *slog.Logger
 }
 
-func (s *stubOperatorClient) Deploy(ctx context.Context, env model.Environment, buildID string) (string, error) {
+func (s *stubOperatorClient) Deploy(ctx context.Context, env model.Environment, buildID string, secrets map[string]string) (string, error) {
 	s.logger.InfoContext(ctx, "stub: deploying",
 		slog.String("envId", env.ID),
-		slog.String("buildId", buildID))
+		slog.String("buildId", buildID),
+		slog.Any("labels", env.Labels),
+		slog.Int("secrets", len(secrets)))
 	return "https://preview.localhost/" + env.ID, nil
 }
 
+func (s *stubOperatorClient) GetStatus(_ context.Context, envID string) (model.DeploymentStatus, error) {
+	return model.DeploymentStatus{
+		Phase:      model.DeploymentPhaseRunning,
+		PreviewURL: "https://preview.localhost/" + envID,
+	}, nil
+}
+
 func (s *stubOperatorClient) Teardown(ctx context.Context, envID string) error {
 	s.logger.InfoContext(ctx, "stub: tearing down",
 		slog.String("envId", envID))
 	return nil
 }
+
+func (s *stubOperatorClient) Sleep(ctx context.Context, envID string) error {
+	s.logger.InfoContext(ctx, "stub: scaling to zero",
+		slog.String("envId", envID))
+	return nil
+}
+
+func (s *stubOperatorClient) Wake(ctx context.Context, envID string) error {
+	s.logger.InfoContext(ctx, "stub: scaling back up",
+		slog.String("envId", envID))
+	return nil
+}
@@ -0,0 +1,139 @@
+// Package builder is an HTTP client for the Builder service.
+package builder
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+// Errors returned by Client.
+var (
+	// ErrNotFound is returned when the builder has no record of a build.
+	ErrNotFound = errors.New("build not found")
+
+	// ErrNoGraph is returned for a build that has not produced a graph to
+	// deploy, because it is unfinished or failed.
+	ErrNoGraph = errors.New("build has no graph")
+)
+
+// Client talks to the Builder service's REST API.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a Client for the builder at baseURL. If httpClient is nil, a
+// client with a 10s timeout and trace propagation is used.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		}
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// createBuildRequest is the Builder service's POST /v1/builds body.
+type createBuildRequest struct {
+	EnvironmentID      string                  `json:"environmentId"`
+	RootPackageName    string                  `json:"rootPackageName"`
+	RootPackageVersion string                  `json:"rootPackageVersion"`
+	Overrides          []model.PackageOverride `json:"overrides,omitempty"`
+}
+
+// TriggerBuild starts a build of the environment's base root package with
+// its overrides applied.
+func (c *Client) TriggerBuild(ctx context.Context, env model.Environment) (string, error) {
+	body := createBuildRequest{
+		EnvironmentID:      env.ID,
+		RootPackageName:    env.BaseRootPackage,
+		RootPackageVersion: env.BaseRootVersion,
+		Overrides:          env.Overrides,
+	}
+	var build model.Build
+	if err := c.do(ctx, http.MethodPost, "/v1/builds", body, http.StatusCreated, &build); err != nil {
+		return "", err
+	}
+	return build.ID, nil
+}
+
+// GetBuild returns the current state of a build.
+func (c *Client) GetBuild(ctx context.Context, buildID string) (model.Build, error) {
+	var build model.Build
+	err := c.do(ctx, http.MethodGet, "/v1/builds/"+url.PathEscape(buildID), nil, http.StatusOK, &build)
+	return build, err
+}
+
+// GetGraph returns the deployable graph a successful build produced, as the
+// operator's APIGraphSpec JSON.
+func (c *Client) GetGraph(ctx context.Context, buildID string) (json.RawMessage, error) {
+	var build struct {
+		Graph json.RawMessage `json:"graph"`
+	}
+	if err := c.do(ctx, http.MethodGet, "/v1/builds/"+url.PathEscape(buildID), nil, http.StatusOK, &build); err != nil {
+		return nil, err
+	}
+	if len(build.Graph) == 0 || string(build.Graph) == "null" {
+		return nil, fmt.Errorf("%s: %w", buildID, ErrNoGraph)
+	}
+	return build.Graph, nil
+}
+
+// do sends a JSON request and decodes the response into out when the
+// builder answers with want.
+func (c *Client) do(ctx context.Context, method, path string, in any, want int, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != want {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%s %s: %w", method, path, ErrNotFound)
+		}
+		return fmt.Errorf("%s %s: builder returned %d: %s", method, path, resp.StatusCode, apiErr.Error)
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
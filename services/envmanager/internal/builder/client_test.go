@@ -0,0 +1,96 @@
+package builder_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/builder"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+func TestClient(t *testing.T) {
+	var received struct {
+		EnvironmentID      string                  `json:"environmentId"`
+		RootPackageName    string                  `json:"rootPackageName"`
+		RootPackageVersion string                  `json:"rootPackageVersion"`
+		Overrides          []model.PackageOverride `json:"overrides"`
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/builds", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decode create request: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(`{"id":"build-1","status":"pending"}`))
+	})
+	mux.HandleFunc("GET /v1/builds/{buildId}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("buildId") != "build-1" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"build not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"id":"build-1","status":"failed","errorMessage":"compose failed"}`))
+	})
+	mux.HandleFunc("GET /v1/builds/build-2", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":"build-2","status":"succeeded","graph":{"environmentId":"env-1","buildId":"build-2"}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := builder.New(srv.URL, srv.Client())
+	ctx := context.Background()
+	replicas := int32(2)
+
+	id, err := c.TriggerBuild(ctx, model.Environment{
+		ID:              "env-1",
+		BaseRootPackage: "root-pkg",
+		BaseRootVersion: "1.0.0",
+		Overrides: []model.PackageOverride{{
+			PackageName:    "users",
+			Version:        "2.1.0",
+			UpstreamConfig: &model.UpstreamConfig{URL: "http://dev.internal:4001"},
+			Runtime:        &model.RuntimeOverride{Replicas: &replicas},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("TriggerBuild: %v", err)
+	}
+	if id != "build-1" {
+		t.Fatalf("expected build-1, got %q", id)
+	}
+	if received.EnvironmentID != "env-1" || received.RootPackageName != "root-pkg" || received.RootPackageVersion != "1.0.0" {
+		t.Fatalf("unexpected build request: %+v", received)
+	}
+	if len(received.Overrides) != 1 || received.Overrides[0].Version != "2.1.0" ||
+		received.Overrides[0].UpstreamConfig == nil || *received.Overrides[0].Runtime.Replicas != 2 {
+		t.Fatalf("expected overrides to be forwarded, got %+v", received.Overrides)
+	}
+
+	build, err := c.GetBuild(ctx, "build-1")
+	if err != nil {
+		t.Fatalf("GetBuild: %v", err)
+	}
+	if build.Status != model.BuildStatusFailed || build.ErrorMessage != "compose failed" {
+		t.Fatalf("unexpected build: %+v", build)
+	}
+
+	if _, err := c.GetBuild(ctx, "missing"); !errors.Is(err, builder.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	graph, err := c.GetGraph(ctx, "build-2")
+	if err != nil {
+		t.Fatalf("GetGraph: %v", err)
+	}
+	if string(graph) != `{"environmentId":"env-1","buildId":"build-2"}` {
+		t.Fatalf("unexpected graph: %s", graph)
+	}
+	if _, err := c.GetGraph(ctx, "build-1"); !errors.Is(err, builder.ErrNoGraph) {
+		t.Fatalf("expected ErrNoGraph for a failed build, got %v", err)
+	}
+}
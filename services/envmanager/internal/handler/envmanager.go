@@ -2,37 +2,112 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/labels"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/webhook"
 )
 
 // Handler holds the HTTP handler dependencies.
 type Handler struct {
-	orch   *orchestrator.Orchestrator
-	logger *slog.Logger
+	orch     *orchestrator.Orchestrator
+	logger   *slog.Logger
+	receiver *webhook.Receiver
+	secrets  WebhookSecrets
+
+	idempotency          store.IdempotencyStore
+	idempotencyRetention time.Duration
 }
 
+// Option configures a Handler.
+type Option func(*Handler)
+
 // New creates a new Handler.
-func New(orch *orchestrator.Orchestrator, logger *slog.Logger) *Handler {
-	return &Handler{orch: orch, logger: logger}
+func New(orch *orchestrator.Orchestrator, logger *slog.Logger, opts ...Option) *Handler {
+	h := &Handler{orch: orch, logger: logger, idempotencyRetention: DefaultIdempotencyRetention}
+	for _, opt := range opts {
+		opt(h)
+	}
+	if h.idempotency == nil {
+		h.idempotency = store.NewMemoryStore()
+	}
+	return h
 }
 
 // RegisterRoutes registers all environment manager routes on the given mux.
 // Uses Go 1.22+ method-aware routing patterns.
 func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
-	mux.HandleFunc("POST /v1/environments", h.CreateEnvironment)
+	mux.HandleFunc("POST /v1/environments", withActor(h.withIdempotency(h.CreateEnvironment)))
 	mux.HandleFunc("GET /v1/environments", h.ListEnvironments)
+	mux.HandleFunc("DELETE /v1/environments", withActor(h.BulkDelete))
 	mux.HandleFunc("GET /v1/environments/{id}", h.GetEnvironment)
-	mux.HandleFunc("DELETE /v1/environments/{id}", h.DeleteEnvironment)
-	mux.HandleFunc("POST /v1/environments/{id}/overrides", h.ApplyOverrides)
-	mux.HandleFunc("POST /v1/environments/{id}/promote", h.Promote)
+	mux.HandleFunc("PATCH /v1/environments/{id}", withActor(h.withForce(h.UpdateEnvironment)))
+	mux.HandleFunc("DELETE /v1/environments/{id}", withActor(h.withForce(h.DeleteEnvironment)))
+	mux.HandleFunc("POST /v1/environments/{id}/overrides", withActor(h.withForce(h.withIdempotency(h.ApplyOverrides))))
+	mux.HandleFunc("POST /v1/environments/{id}/promote", withActor(h.withForce(h.withIdempotency(h.Promote))))
+	mux.HandleFunc("PUT /v1/environments/{id}/lock", withActor(h.Lock))
+	mux.HandleFunc("DELETE /v1/environments/{id}/lock", withActor(h.Unlock))
+	mux.HandleFunc("GET /v1/environments/{id}/diff", h.Diff)
+	mux.HandleFunc("POST /v1/environments/{id}/activity", withActor(h.RecordActivity))
+	mux.HandleFunc("GET /v1/environments/{id}/lineage", h.Lineage)
+	mux.HandleFunc("POST /v1/environments/{id}/rebase", withActor(h.withForce(h.Rebase)))
+	mux.HandleFunc("GET /v1/environments/{id}/builds", h.ListBuilds)
+	mux.HandleFunc("POST /v1/environments/{id}/rollback", withActor(h.withForce(h.Rollback)))
+	mux.HandleFunc("GET /v1/environments/{id}/events", h.ListEvents)
+	mux.HandleFunc("GET /v1/environments/{id}/secrets", h.ListSecrets)
+	mux.HandleFunc("PUT /v1/environments/{id}/secrets/{name}", withActor(h.SetSecret))
+	mux.HandleFunc("DELETE /v1/environments/{id}/secrets/{name}", withActor(h.DeleteSecret))
+	mux.HandleFunc("POST /v1/environments/{id}/sleep", withActor(h.Sleep))
+	mux.HandleFunc("POST /v1/environments/{id}/wake", withActor(h.Wake))
+	mux.HandleFunc("POST /v1/environments/reap", withActor(h.Reap))
+	mux.HandleFunc("GET /v1/usage", h.Usage)
+	mux.HandleFunc("GET /v1/templates", h.ListTemplates)
+	mux.HandleFunc("GET /v1/templates/{name}", h.GetTemplate)
+	mux.HandleFunc("PUT /v1/templates/{name}", withActor(h.PutTemplate))
+	mux.HandleFunc("DELETE /v1/templates/{name}", withActor(h.DeleteTemplate))
+	h.registerWebhookRoutes(mux)
+}
+
+// withActor attributes the changes a request makes to the user the gateway
+// authenticated, passed through in the X-Forwarded-User header.
+func withActor(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if actor := r.Header.Get("X-Forwarded-User"); actor != "" {
+			r = r.WithContext(orchestrator.ContextWithActor(r.Context(), actor))
+		}
+		next(w, r)
+	}
+}
+
+// withForce lets the owner of an environment's lock override it by passing
+// force=true.
+func (h *Handler) withForce(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if v := r.URL.Query().Get("force"); v != "" {
+			force, err := strconv.ParseBool(v)
+			if err != nil {
+				h.writeError(w, r, http.StatusBadRequest, "invalid force")
+				return
+			}
+			if force {
+				r = r.WithContext(orchestrator.ContextWithForce(r.Context()))
+			}
+		}
+		next(w, r)
+	}
 }
 
 // CreateEnvironment handles POST /v1/environments.
@@ -43,19 +118,56 @@ func (h *Handler) CreateEnvironment(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if req.Name == "" || req.BaseRootPackage == "" {
-		h.writeError(w, r, http.StatusBadRequest, "name and baseRootPackage are required")
+	// A fork inherits its base from the parent environment, and an
+	// environment created from a template from the template.
+	if req.Name == "" || (req.BaseRootPackage == "" && req.ParentID == "" && req.Template == "") {
+		h.writeError(w, r, http.StatusBadRequest, "name and one of baseRootPackage, parentId or template are required")
+		return
+	}
+	if _, err := req.Expiry(time.Now()); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, err.Error())
 		return
 	}
 
 	env, err := h.orch.CreateEnvironment(r.Context(), req)
+	if errors.Is(err, orchestrator.ErrEnvironmentExists) {
+		h.writeJSON(w, r, http.StatusOK, env)
+		return
+	}
 	if err != nil {
+		if errors.Is(err, model.ErrInvalidOverride) || errors.Is(err, orchestrator.ErrInvalidParent) ||
+			errors.Is(err, labels.ErrInvalidLabel) || errors.Is(err, model.ErrInvalidSchedule) ||
+			errors.Is(err, model.ErrInvalidSecret) || errors.Is(err, model.ErrInvalidTemplate) {
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, orchestrator.ErrSecretsDisabled) {
+			h.writeError(w, r, http.StatusServiceUnavailable, err.Error())
+			return
+		}
+		if status, ok := quotaStatus(err); ok {
+			h.writeError(w, r, status, err.Error())
+			return
+		}
 		h.logger.ErrorContext(r.Context(), "create environment failed", slog.String("error", err.Error()))
 		h.writeError(w, r, http.StatusInternalServerError, "failed to create environment")
 		return
 	}
 
-	h.writeJSON(w, r, http.StatusCreated, env)
+	h.writeJSON(w, r, createdStatus(env), env)
+}
+
+// quotaStatus maps a quota error to its status: 429 when the caller is out of
+// environments and may retry after deleting one, 403 when the environment
+// itself is too big to ever be allowed.
+func quotaStatus(err error) (int, bool) {
+	switch {
+	case errors.Is(err, orchestrator.ErrQuotaExceeded):
+		return http.StatusTooManyRequests, true
+	case errors.Is(err, orchestrator.ErrLimitExceeded):
+		return http.StatusForbidden, true
+	}
+	return 0, false
 }
 
 // ListEnvironments handles GET /v1/environments.
@@ -72,12 +184,19 @@ func (h *Handler) ListEnvironments(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	filter := store.ListFilter{
-		Branch:    query.Get("branch"),
-		CreatedBy: query.Get("created_by"),
-		PageSize:  pageSize,
-		PageToken: query.Get("page_token"),
+	filter, err := listFilter(query)
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
 	}
+	order, err := store.ParseSort(query.Get("sort"))
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	filter.Sort = order
+	filter.PageSize = pageSize
+	filter.PageToken = query.Get("page_token")
 
 	result, err := h.orch.ListEnvironments(r.Context(), filter)
 	if err != nil {
@@ -98,6 +217,70 @@ func (h *Handler) ListEnvironments(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, r, http.StatusOK, resp)
 }
 
+// listFilter parses the branch, created_by, parent_id, template,
+// label_selector and status query parameters shared by listing and bulk
+// deleting environments.
+func listFilter(query url.Values) (store.ListFilter, error) {
+	selector, err := labels.Parse(query.Get("label_selector"))
+	if err != nil {
+		return store.ListFilter{}, err
+	}
+	var statuses []model.EnvironmentStatus
+	if st := query.Get("status"); st != "" {
+		for _, s := range strings.Split(st, ",") {
+			status := model.EnvironmentStatus(strings.TrimSpace(s))
+			if !model.ValidStatus(status) {
+				return store.ListFilter{}, fmt.Errorf("invalid status %q", status)
+			}
+			statuses = append(statuses, status)
+		}
+	}
+	return store.ListFilter{
+		Branch:    query.Get("branch"),
+		CreatedBy: query.Get("created_by"),
+		ParentID:  query.Get("parent_id"),
+		Template:  query.Get("template"),
+		Selector:  selector,
+		Statuses:  statuses,
+	}, nil
+}
+
+// BulkDelete handles DELETE /v1/environments. It deletes every environment
+// matching the list filters, at least one of which is required, skipping
+// protected and locked ones. With dry_run=true it only reports what it would
+// delete.
+func (h *Handler) BulkDelete(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	filter, err := listFilter(query)
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+	if filter.Branch == "" && filter.CreatedBy == "" && filter.ParentID == "" && filter.Template == "" &&
+		len(filter.Selector) == 0 && len(filter.Statuses) == 0 {
+		h.writeError(w, r, http.StatusBadRequest, "at least one of branch, created_by, parent_id, template, label_selector or status is required")
+		return
+	}
+	dryRun := false
+	if v := query.Get("dry_run"); v != "" {
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			h.writeError(w, r, http.StatusBadRequest, "invalid dry_run")
+			return
+		}
+	}
+
+	resp, err := h.orch.BulkDelete(r.Context(), filter, dryRun)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "bulk delete failed", slog.String("error", err.Error()))
+		h.writeError(w, r, http.StatusInternalServerError, "failed to delete environments")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, resp)
+}
+
 // GetEnvironment handles GET /v1/environments/{id}.
 func (h *Handler) GetEnvironment(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -134,6 +317,14 @@ func (h *Handler) DeleteEnvironment(w http.ResponseWriter, r *http.Request) {
 			h.writeError(w, r, http.StatusNotFound, "environment not found")
 			return
 		}
+		if errors.Is(err, model.ErrInvalidTransition) {
+			h.writeError(w, r, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, orchestrator.ErrLocked) {
+			h.writeError(w, r, http.StatusLocked, err.Error())
+			return
+		}
 		h.logger.ErrorContext(r.Context(), "delete environment failed", slog.String("error", err.Error()))
 		h.writeError(w, r, http.StatusInternalServerError, "failed to delete environment")
 		return
@@ -142,6 +333,43 @@ func (h *Handler) DeleteEnvironment(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
+// UpdateEnvironment handles PATCH /v1/environments/{id}.
+func (h *Handler) UpdateEnvironment(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID is required")
+		return
+	}
+
+	var req model.UpdateEnvironmentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	env, err := h.orch.UpdateEnvironment(r.Context(), id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.writeError(w, r, http.StatusNotFound, "environment not found")
+		case errors.Is(err, labels.ErrInvalidLabel), errors.Is(err, model.ErrInvalidSchedule):
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, model.ErrInvalidTransition):
+			h.writeError(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, orchestrator.ErrQuotaExceeded):
+			h.writeError(w, r, http.StatusTooManyRequests, err.Error())
+		case errors.Is(err, orchestrator.ErrLocked):
+			h.writeError(w, r, http.StatusLocked, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "update environment failed", slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "failed to update environment")
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, env)
+}
+
 // ApplyOverrides handles POST /v1/environments/{id}/overrides.
 func (h *Handler) ApplyOverrides(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
@@ -162,12 +390,32 @@ func (h *Handler) ApplyOverrides(w http.ResponseWriter, r *http.Request) {
 			h.writeError(w, r, http.StatusNotFound, "environment not found")
 			return
 		}
+		if errors.Is(err, model.ErrInvalidOverride) {
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+			return
+		}
+		if errors.Is(err, model.ErrInvalidTransition) {
+			h.writeError(w, r, http.StatusConflict, err.Error())
+			return
+		}
+		if errors.Is(err, orchestrator.ErrLocked) {
+			h.writeError(w, r, http.StatusLocked, err.Error())
+			return
+		}
+		if status, ok := quotaStatus(err); ok {
+			h.writeError(w, r, status, err.Error())
+			return
+		}
 		h.logger.ErrorContext(r.Context(), "apply overrides failed", slog.String("error", err.Error()))
 		h.writeError(w, r, http.StatusInternalServerError, "failed to apply overrides")
 		return
 	}
 
-	h.writeJSON(w, r, http.StatusOK, env)
+	status := http.StatusOK
+	if env.Status == model.StatusBuilding {
+		status = http.StatusAccepted
+	}
+	h.writeJSON(w, r, status, env)
 }
 
 // Promote handles POST /v1/environments/{id}/promote.
@@ -179,19 +427,476 @@ func (h *Handler) Promote(w http.ResponseWriter, r *http.Request) {
 	}
 
 	resp, err := h.orch.Promote(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.writeError(w, r, http.StatusNotFound, "environment not found")
+		case errors.Is(err, orchestrator.ErrNothingToPromote), errors.Is(err, orchestrator.ErrPromotionConflict):
+			h.writeError(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, orchestrator.ErrLocked):
+			h.writeError(w, r, http.StatusLocked, err.Error())
+		case errors.Is(err, orchestrator.ErrNoRegistry):
+			h.writeError(w, r, http.StatusServiceUnavailable, err.Error())
+		case errors.Is(err, orchestrator.ErrPublishFailed):
+			h.logger.ErrorContext(r.Context(), "promote failed", slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusBadGateway, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "promote failed", slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "failed to promote")
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, resp)
+}
+
+// Diff handles GET /v1/environments/{id}/diff.
+func (h *Handler) Diff(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID is required")
+		return
+	}
+
+	diff, err := h.orch.Diff(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.writeError(w, r, http.StatusNotFound, "environment not found")
+		case errors.Is(err, orchestrator.ErrNoBaseVersion):
+			h.writeError(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, orchestrator.ErrNoRegistry):
+			h.writeError(w, r, http.StatusServiceUnavailable, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "diff failed", slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "failed to diff environment")
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, diff)
+}
+
+// RecordActivity handles POST /v1/environments/{id}/activity.
+func (h *Handler) RecordActivity(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID is required")
+		return
+	}
+
+	if err := h.orch.RecordActivity(r.Context(), id); err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.writeError(w, r, http.StatusNotFound, "environment not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "record activity failed", slog.String("error", err.Error()))
+		h.writeError(w, r, http.StatusInternalServerError, "failed to record activity")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Lineage handles GET /v1/environments/{id}/lineage.
+func (h *Handler) Lineage(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID is required")
+		return
+	}
+
+	lineage, err := h.orch.Lineage(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.writeError(w, r, http.StatusNotFound, "environment not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "lineage failed", slog.String("error", err.Error()))
+		h.writeError(w, r, http.StatusInternalServerError, "failed to get lineage")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, lineage)
+}
+
+// Rebase handles POST /v1/environments/{id}/rebase. The request body is
+// optional.
+func (h *Handler) Rebase(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID is required")
+		return
+	}
+
+	var req model.RebaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.writeError(w, r, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	env, err := h.orch.Rebase(r.Context(), id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.writeError(w, r, http.StatusNotFound, "environment not found")
+		case errors.Is(err, model.ErrInvalidOverride):
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, orchestrator.ErrNoParent), errors.Is(err, model.ErrInvalidTransition):
+			h.writeError(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, orchestrator.ErrLocked):
+			h.writeError(w, r, http.StatusLocked, err.Error())
+		case errors.Is(err, orchestrator.ErrLimitExceeded):
+			h.writeError(w, r, http.StatusForbidden, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "rebase failed", slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "failed to rebase environment")
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if env.Status == model.StatusBuilding {
+		status = http.StatusAccepted
+	}
+	h.writeJSON(w, r, status, env)
+}
+
+// ListBuilds handles GET /v1/environments/{id}/builds.
+func (h *Handler) ListBuilds(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID is required")
+		return
+	}
+
+	builds, err := h.orch.ListBuilds(r.Context(), id)
 	if err != nil {
 		if errors.Is(err, store.ErrNotFound) {
 			h.writeError(w, r, http.StatusNotFound, "environment not found")
 			return
 		}
-		h.logger.ErrorContext(r.Context(), "promote failed", slog.String("error", err.Error()))
-		h.writeError(w, r, http.StatusInternalServerError, "failed to promote")
+		h.logger.ErrorContext(r.Context(), "list builds failed", slog.String("error", err.Error()))
+		h.writeError(w, r, http.StatusInternalServerError, "failed to list builds")
 		return
 	}
 
+	resp := model.ListBuildsResponse{Builds: builds}
+	if resp.Builds == nil {
+		resp.Builds = []model.BuildRecord{}
+	}
 	h.writeJSON(w, r, http.StatusOK, resp)
 }
 
+// Rollback handles POST /v1/environments/{id}/rollback. The request body is
+// optional; without a build ID the previous successful build is redeployed.
+func (h *Handler) Rollback(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID is required")
+		return
+	}
+
+	var req model.RollbackRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.writeError(w, r, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	env, err := h.orch.Rollback(r.Context(), id, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.writeError(w, r, http.StatusNotFound, "environment not found")
+		case errors.Is(err, orchestrator.ErrBuildNotFound):
+			h.writeError(w, r, http.StatusNotFound, err.Error())
+		case errors.Is(err, orchestrator.ErrNoRollbackTarget), errors.Is(err, model.ErrInvalidTransition):
+			h.writeError(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, orchestrator.ErrLocked):
+			h.writeError(w, r, http.StatusLocked, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "rollback failed", slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "failed to roll back environment")
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusAccepted, env)
+}
+
+// Lock handles PUT /v1/environments/{id}/lock.
+func (h *Handler) Lock(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID is required")
+		return
+	}
+
+	var req model.LockRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.writeError(w, r, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	env, err := h.orch.Lock(r.Context(), id, req)
+	if err != nil {
+		h.writeLockError(w, r, "lock", err)
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, env)
+}
+
+// Unlock handles DELETE /v1/environments/{id}/lock.
+func (h *Handler) Unlock(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID is required")
+		return
+	}
+
+	env, err := h.orch.Unlock(r.Context(), id)
+	if err != nil {
+		h.writeLockError(w, r, "unlock", err)
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, env)
+}
+
+func (h *Handler) writeLockError(w http.ResponseWriter, r *http.Request, action string, err error) {
+	switch {
+	case errors.Is(err, store.ErrNotFound):
+		h.writeError(w, r, http.StatusNotFound, "environment not found")
+	case errors.Is(err, model.ErrInvalidLock):
+		h.writeError(w, r, http.StatusBadRequest, err.Error())
+	case errors.Is(err, orchestrator.ErrLocked):
+		h.writeError(w, r, http.StatusLocked, err.Error())
+	case errors.Is(err, model.ErrInvalidTransition):
+		h.writeError(w, r, http.StatusConflict, err.Error())
+	default:
+		h.logger.ErrorContext(r.Context(), action+" failed", slog.String("error", err.Error()))
+		h.writeError(w, r, http.StatusInternalServerError, "failed to "+action+" environment")
+	}
+}
+
+// Sleep handles POST /v1/environments/{id}/sleep.
+func (h *Handler) Sleep(w http.ResponseWriter, r *http.Request) {
+	h.sleepOrWake(w, r, "sleep", h.orch.Sleep)
+}
+
+// Wake handles POST /v1/environments/{id}/wake.
+func (h *Handler) Wake(w http.ResponseWriter, r *http.Request) {
+	h.sleepOrWake(w, r, "wake", h.orch.Wake)
+}
+
+func (h *Handler) sleepOrWake(w http.ResponseWriter, r *http.Request, action string, fn func(context.Context, string) (model.Environment, error)) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID is required")
+		return
+	}
+
+	env, err := fn(r.Context(), id)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.writeError(w, r, http.StatusNotFound, "environment not found")
+		case errors.Is(err, model.ErrInvalidTransition):
+			h.writeError(w, r, http.StatusConflict, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), action+" failed", slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "failed to "+action+" environment")
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, env)
+}
+
+// ListEvents handles GET /v1/environments/{id}/events.
+func (h *Handler) ListEvents(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID is required")
+		return
+	}
+
+	query := r.URL.Query()
+	pageSize := 0
+	if ps := query.Get("page_size"); ps != "" {
+		var err error
+		pageSize, err = strconv.Atoi(ps)
+		if err != nil || pageSize < 0 {
+			h.writeError(w, r, http.StatusBadRequest, "invalid page_size")
+			return
+		}
+	}
+
+	page, err := h.orch.ListEvents(r.Context(), id, store.EventFilter{
+		PageSize:  pageSize,
+		PageToken: query.Get("page_token"),
+	})
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.writeError(w, r, http.StatusNotFound, "environment not found")
+		case errors.Is(err, store.ErrInvalidPageToken):
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "list events failed", slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "failed to list events")
+		}
+		return
+	}
+
+	resp := model.ListEventsResponse{
+		Events:        page.Events,
+		NextPageToken: page.NextPageToken,
+	}
+	if resp.Events == nil {
+		resp.Events = []model.Event{}
+	}
+	h.writeJSON(w, r, http.StatusOK, resp)
+}
+
+// ListSecrets handles GET /v1/environments/{id}/secrets. Only the secrets'
+// names are returned, never their values.
+func (h *Handler) ListSecrets(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if id == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID is required")
+		return
+	}
+
+	secrets, err := h.orch.ListSecrets(r.Context(), id)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			h.writeError(w, r, http.StatusNotFound, "environment not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "list secrets failed", slog.String("error", err.Error()))
+		h.writeError(w, r, http.StatusInternalServerError, "failed to list secrets")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, model.ListSecretsResponse{Secrets: secrets})
+}
+
+// SetSecret handles PUT /v1/environments/{id}/secrets/{name}.
+func (h *Handler) SetSecret(w http.ResponseWriter, r *http.Request) {
+	id, name := r.PathValue("id"), r.PathValue("name")
+	if id == "" || name == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID and secret name are required")
+		return
+	}
+
+	var req model.SetSecretRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		// The decoder's error can quote the body, so it isn't echoed.
+		h.writeError(w, r, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	secret, err := h.orch.SetSecret(r.Context(), id, name, req.Value)
+	if err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.writeError(w, r, http.StatusNotFound, "environment not found")
+		case errors.Is(err, model.ErrInvalidSecret):
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, model.ErrInvalidTransition):
+			h.writeError(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, orchestrator.ErrSecretsDisabled):
+			h.writeError(w, r, http.StatusServiceUnavailable, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "set secret failed", slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "failed to set secret")
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, secret)
+}
+
+// DeleteSecret handles DELETE /v1/environments/{id}/secrets/{name}.
+func (h *Handler) DeleteSecret(w http.ResponseWriter, r *http.Request) {
+	id, name := r.PathValue("id"), r.PathValue("name")
+	if id == "" || name == "" {
+		h.writeError(w, r, http.StatusBadRequest, "environment ID and secret name are required")
+		return
+	}
+
+	if err := h.orch.DeleteSecret(r.Context(), id, name); err != nil {
+		switch {
+		case errors.Is(err, store.ErrNotFound):
+			h.writeError(w, r, http.StatusNotFound, "environment not found")
+		case errors.Is(err, store.ErrSecretNotFound):
+			h.writeError(w, r, http.StatusNotFound, "secret not found")
+		case errors.Is(err, orchestrator.ErrSecretInUse):
+			h.writeError(w, r, http.StatusConflict, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "delete secret failed", slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "failed to delete secret")
+		}
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Usage handles GET /v1/usage. The user defaults to the caller named in
+// X-Forwarded-User.
+func (h *Handler) Usage(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	user := query.Get("user")
+	if user == "" {
+		user = r.Header.Get("X-Forwarded-User")
+	}
+
+	resp, err := h.orch.Usage(r.Context(), user, query.Get("team"))
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "usage failed", slog.String("error", err.Error()))
+		h.writeError(w, r, http.StatusInternalServerError, "failed to get usage")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, resp)
+}
+
+// Reap handles POST /v1/environments/reap. It defaults to a dry run, listing
+// the environments that would be reaped without deleting them; only
+// dry_run=false deletes them.
+func (h *Handler) Reap(w http.ResponseWriter, r *http.Request) {
+	dryRun := true
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			h.writeError(w, r, http.StatusBadRequest, "invalid dry_run")
+			return
+		}
+	}
+
+	resp, err := h.orch.Reap(r.Context(), dryRun)
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "reap failed", slog.String("error", err.Error()))
+		h.writeError(w, r, http.StatusInternalServerError, "failed to reap environments")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, resp)
+}
+
+// createdStatus returns 202 when the environment still has a build/deploy
+// workflow running in the background, and 201 otherwise.
+func createdStatus(env model.Environment) int {
+	if env.Status == model.StatusBuilding {
+		return http.StatusAccepted
+	}
+	return http.StatusCreated
+}
+
 // errorResponse is the standard error response body.
 type errorResponse struct {
 	Error string `json:"error"`
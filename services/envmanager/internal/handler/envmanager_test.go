@@ -7,12 +7,15 @@ import (
 	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/handler"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/registry"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
 )
 
@@ -23,23 +26,65 @@ func (b *handlerTestBuilder) TriggerBuild(_ context.Context, _ model.Environment
 	return "build-test", nil
 }
 
+func (b *handlerTestBuilder) GetBuild(_ context.Context, buildID string) (model.Build, error) {
+	return model.Build{ID: buildID, Status: model.BuildStatusSucceeded}, nil
+}
+
 // handlerTestOperator implements orchestrator.OperatorClient for handler tests.
 type handlerTestOperator struct{}
 
-func (o *handlerTestOperator) Deploy(_ context.Context, _ model.Environment, _ string) (string, error) {
+func (o *handlerTestOperator) Deploy(_ context.Context, _ model.Environment, _ string, _ map[string]string) (string, error) {
 	return "https://preview.test/env", nil
 }
 
+func (o *handlerTestOperator) GetStatus(_ context.Context, _ string) (model.DeploymentStatus, error) {
+	return model.DeploymentStatus{Phase: model.DeploymentPhaseRunning}, nil
+}
+
 func (o *handlerTestOperator) Teardown(_ context.Context, _ string) error {
 	return nil
 }
 
-func newTestHandler() (*handler.Handler, *http.ServeMux) {
+func (o *handlerTestOperator) Sleep(_ context.Context, _ string) error {
+	return nil
+}
+
+func (o *handlerTestOperator) Wake(_ context.Context, _ string) error {
+	return nil
+}
+
+// handlerTestRegistry implements orchestrator.RegistryClient for handler
+// tests. It holds root-pkg@1.0.0, which depends on users@1.0.0.
+type handlerTestRegistry struct{}
+
+func (r *handlerTestRegistry) GetPackage(_ context.Context, name, version string) (model.Package, error) {
+	if name != "root-pkg" || version != "1.0.0" {
+		return model.Package{}, registry.ErrNotFound
+	}
+	return model.Package{Name: "root-pkg", Kind: "graphql-supergraph", Version: "1.0.0", Dependencies: []model.Dependency{
+		{PackageName: "users", VersionConstraint: "1.0.0"},
+	}}, nil
+}
+
+func (r *handlerTestRegistry) ResolveDependencies(_ context.Context, _, _ string) ([]model.Package, error) {
+	return []model.Package{{Name: "users", Kind: "graphql-subgraph", Version: "1.0.0", Schema: "type User { id: ID }"}}, nil
+}
+
+func (r *handlerTestRegistry) Publish(_ context.Context, pkg model.Package) (model.Package, error) {
+	return pkg, nil
+}
+
+func (r *handlerTestRegistry) Yank(_ context.Context, _, _ string) error {
+	return nil
+}
+
+func newTestHandler(opts ...orchestrator.Option) (*handler.Handler, *http.ServeMux) {
 	s := store.NewMemoryStore()
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
 	b := &handlerTestBuilder{}
 	o := &handlerTestOperator{}
-	orch := orchestrator.New(s, b, o, logger)
+	opts = append([]orchestrator.Option{orchestrator.WithRegistry(&handlerTestRegistry{})}, opts...)
+	orch := orchestrator.New(s, b, o, logger, opts...)
 	h := handler.New(orch, logger)
 	mux := http.NewServeMux()
 	h.RegisterRoutes(mux)
@@ -72,6 +117,32 @@ func TestCreateEnvironment_Handler(t *testing.T) {
 	}
 }
 
+func TestCreateEnvironment_WithOverridesAccepted(t *testing.T) {
+	_, mux := newTestHandler()
+
+	body := `{"name":"test-env","baseRootPackage":"root-pkg","overrides":[{"packageName":"users"}]}`
+	req := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var env model.Environment
+	if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if env.Status != model.StatusBuilding {
+		t.Fatalf("expected status building, got %s", env.Status)
+	}
+	if len(env.Progress) != 3 {
+		t.Fatalf("expected 3 pending stages, got %d", len(env.Progress))
+	}
+}
+
 func TestCreateEnvironment_MissingFields(t *testing.T) {
 	_, mux := newTestHandler()
 
@@ -325,6 +396,28 @@ func TestPromote_NotFound(t *testing.T) {
 	}
 }
 
+func TestPromote_NothingToPromote(t *testing.T) {
+	_, mux := newTestHandler()
+
+	createBody := `{"name":"promote-env","baseRootPackage":"root-pkg","baseRootVersion":"1.0.0"}`
+	createReq := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(createBody))
+	createW := httptest.NewRecorder()
+	mux.ServeHTTP(createW, createReq)
+
+	var created model.Environment
+	if err := json.NewDecoder(createW.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/environments/"+created.ID+"/promote", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
 func TestListEnvironments_WithFilters(t *testing.T) {
 	_, mux := newTestHandler()
 
@@ -364,3 +457,717 @@ func TestListEnvironments_WithFilters(t *testing.T) {
 		t.Fatalf("expected branch main, got %s", resp.Environments[0].Branch)
 	}
 }
+
+func TestCreateEnvironment_InvalidTTL(t *testing.T) {
+	_, mux := newTestHandler()
+
+	for _, body := range []string{
+		`{"name":"ttl-env","baseRootPackage":"root-pkg","ttl":"3 days"}`,
+		`{"name":"ttl-env","baseRootPackage":"root-pkg","ttl":"-1h"}`,
+		`{"name":"ttl-env","baseRootPackage":"root-pkg","expiresAt":"2000-01-01T00:00:00Z"}`,
+		`{"name":"ttl-env","baseRootPackage":"root-pkg","ttl":"1h","expiresAt":"2999-01-01T00:00:00Z"}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestCreateEnvironment_InvalidOverride(t *testing.T) {
+	_, mux := newTestHandler()
+
+	for _, body := range []string{
+		`{"name":"o-env","baseRootPackage":"root-pkg","overrides":[{"packageName":"users","schema":"type User { id: ID }","version":"1.0.0"}]}`,
+		`{"name":"o-env","baseRootPackage":"root-pkg","overrides":[{"packageName":"users","upstreamConfig":{"url":"not a url"}}]}`,
+		`{"name":"o-env","baseRootPackage":"root-pkg","overrides":[{"packageName":"users","version":"9.9.9"}]}`,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", body, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestReap_DryRunHandler(t *testing.T) {
+	_, mux := newTestHandler()
+
+	createBody := `{"name":"ttl-env","baseRootPackage":"root-pkg","ttl":"1h"}`
+	createReq := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(createBody))
+	createW := httptest.NewRecorder()
+	mux.ServeHTTP(createW, createReq)
+	if createW.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", createW.Code, createW.Body.String())
+	}
+
+	// Reaping is a dry run unless dry_run=false says otherwise.
+	for query, wantDryRun := range map[string]bool{
+		"":               true,
+		"?dry_run=true":  true,
+		"?dry_run=false": false,
+	} {
+		req := httptest.NewRequest(http.MethodPost, "/v1/environments/reap"+query, nil)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+
+		if w.Code != http.StatusOK {
+			t.Fatalf("%q: expected 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+		var resp model.ReapResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if resp.DryRun != wantDryRun || len(resp.Environments) != 0 {
+			t.Fatalf("%q: expected an empty reap with dryRun %t, got %+v", query, wantDryRun, resp)
+		}
+	}
+}
+
+func TestRecordActivity_NotFound(t *testing.T) {
+	_, mux := newTestHandler()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/environments/nonexistent/activity", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestDiff_Handler(t *testing.T) {
+	_, mux := newTestHandler()
+
+	createBody := `{"name":"diff-env","baseRootPackage":"root-pkg","baseRootVersion":"1.0.0","overrides":[{"packageName":"users","schema":"type User { id: ID! }"}]}`
+	createReq := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(createBody))
+	createW := httptest.NewRecorder()
+	mux.ServeHTTP(createW, createReq)
+
+	var created model.Environment
+	if err := json.NewDecoder(createW.Body).Decode(&created); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/environments/"+created.ID+"/diff", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var diff model.EnvironmentDiff
+	if err := json.NewDecoder(w.Body).Decode(&diff); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(diff.Packages) != 1 || diff.Packages[0].Name != "users" || diff.Packages[0].Status != model.PackageChanged {
+		t.Fatalf("expected users to be changed, got %+v", diff.Packages)
+	}
+}
+
+func TestDiff_NotFound(t *testing.T) {
+	_, mux := newTestHandler()
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/environments/nonexistent/diff", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestForkAndLineage_Handler(t *testing.T) {
+	_, mux := newTestHandler()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(`{"name":"parent-env","baseRootPackage":"root-pkg"}`))
+	createW := httptest.NewRecorder()
+	mux.ServeHTTP(createW, createReq)
+	var parent model.Environment
+	if err := json.NewDecoder(createW.Body).Decode(&parent); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	forkBody := `{"name":"child-env","parentId":"` + parent.ID + `"}`
+	forkReq := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(forkBody))
+	forkW := httptest.NewRecorder()
+	mux.ServeHTTP(forkW, forkReq)
+	if forkW.Code != http.StatusCreated {
+		t.Fatalf("fork: expected 201, got %d: %s", forkW.Code, forkW.Body.String())
+	}
+	var child model.Environment
+	if err := json.NewDecoder(forkW.Body).Decode(&child); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if child.ParentID != parent.ID || child.BaseRootPackage != "root-pkg" {
+		t.Fatalf("expected a fork of %s, got %+v", parent.ID, child)
+	}
+
+	listReq := httptest.NewRequest(http.MethodGet, "/v1/environments?parent_id="+parent.ID, nil)
+	listW := httptest.NewRecorder()
+	mux.ServeHTTP(listW, listReq)
+	var list model.ListEnvironmentsResponse
+	if err := json.NewDecoder(listW.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Environments) != 1 || list.Environments[0].ID != child.ID {
+		t.Fatalf("expected only the child, got %+v", list.Environments)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/environments/"+parent.ID+"/lineage", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var lineage model.Lineage
+	if err := json.NewDecoder(w.Body).Decode(&lineage); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(lineage.Ancestors) != 0 || len(lineage.Children) != 1 || lineage.Children[0].ID != child.ID {
+		t.Fatalf("unexpected lineage: %+v", lineage)
+	}
+}
+
+func TestCreateEnvironment_InvalidParent(t *testing.T) {
+	_, mux := newTestHandler()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(`{"name":"child-env","parentId":"nonexistent"}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRebase_Handler(t *testing.T) {
+	_, mux := newTestHandler()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(`{"name":"parent-env","baseRootPackage":"root-pkg"}`))
+	createW := httptest.NewRecorder()
+	mux.ServeHTTP(createW, createReq)
+	var parent model.Environment
+	if err := json.NewDecoder(createW.Body).Decode(&parent); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	// An environment without a parent cannot be rebased.
+	req := httptest.NewRequest(http.MethodPost, "/v1/environments/"+parent.ID+"/rebase", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	forkReq := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(`{"name":"child-env","parentId":"`+parent.ID+`"}`))
+	forkW := httptest.NewRecorder()
+	mux.ServeHTTP(forkW, forkReq)
+	var child model.Environment
+	if err := json.NewDecoder(forkW.Body).Decode(&child); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	lockReq := httptest.NewRequest(http.MethodPut, "/v1/environments/"+child.ID+"/lock", bytes.NewBufferString(`{}`))
+	lockReq.Header.Set("X-Forwarded-User", "alice")
+	lockW := httptest.NewRecorder()
+	mux.ServeHTTP(lockW, lockReq)
+	if lockW.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", lockW.Code, lockW.Body.String())
+	}
+	req = httptest.NewRequest(http.MethodPost, "/v1/environments/"+child.ID+"/rebase", nil)
+	req.Header.Set("X-Forwarded-User", "bob")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusLocked {
+		t.Fatalf("expected 423 while locked, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/environments/"+child.ID+"/rebase?force=true", bytes.NewBufferString(`{"triggerBuild":true}`))
+	req.Header.Set("X-Forwarded-User", "alice")
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/environments/nonexistent/rebase", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestRollback_Handler(t *testing.T) {
+	_, mux := newTestHandler()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(`{"name":"test-env","baseRootPackage":"root-pkg"}`))
+	createW := httptest.NewRecorder()
+	mux.ServeHTTP(createW, createReq)
+	var env model.Environment
+	if err := json.NewDecoder(createW.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/environments/"+env.ID+"/builds", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var builds model.ListBuildsResponse
+	if err := json.NewDecoder(w.Body).Decode(&builds); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if builds.Builds == nil {
+		t.Fatal("expected an empty build list, got null")
+	}
+
+	// Nothing has been built, so there is nothing to roll back to.
+	req = httptest.NewRequest(http.MethodPost, "/v1/environments/"+env.ID+"/rollback", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/environments/"+env.ID+"/rollback", bytes.NewBufferString(`{"buildId":"build-9"}`))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/v1/environments/nonexistent/rollback", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestListEvents_Handler(t *testing.T) {
+	_, mux := newTestHandler()
+
+	createReq := httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(`{"name":"test-env","baseRootPackage":"root-pkg"}`))
+	createReq.Header.Set("X-Forwarded-User", "alice")
+	createW := httptest.NewRecorder()
+	mux.ServeHTTP(createW, createReq)
+	var env model.Environment
+	if err := json.NewDecoder(createW.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/v1/environments/"+env.ID+"/overrides",
+			bytes.NewBufferString(`{"overrides":[{"packageName":"users-subgraph","schema":"type User { id: ID! }"}]}`))
+		req.Header.Set("X-Forwarded-User", "bob")
+		mux.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/environments/"+env.ID+"/events?page_size=2", nil)
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var page model.ListEventsResponse
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(page.Events) != 2 || page.NextPageToken == "" {
+		t.Fatalf("expected a first page of 2 events, got %+v", page)
+	}
+	if page.Events[0].Type != model.EventOverridesApplied || page.Events[0].Actor != "bob" {
+		t.Fatalf("expected the latest override change by bob, got %+v", page.Events[0])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/environments/"+env.ID+"/events?page_size=2&page_token="+page.NextPageToken, nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	page = model.ListEventsResponse{}
+	if err := json.NewDecoder(w.Body).Decode(&page); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(page.Events) != 1 || page.NextPageToken != "" {
+		t.Fatalf("expected a last page of 1 event, got %+v", page)
+	}
+	if page.Events[0].Type != model.EventCreated || page.Events[0].Actor != "alice" {
+		t.Fatalf("expected the creation by alice, got %+v", page.Events[0])
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/environments/"+env.ID+"/events?page_size=-1", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", w.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/environments/"+env.ID+"/events?page_token=bogus", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown page token, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/environments/nonexistent/events", nil)
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLabels_Handler(t *testing.T) {
+	_, mux := newTestHandler()
+
+	create := func(body string) model.Environment {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(body)))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+		var env model.Environment
+		if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return env
+	}
+	list := func(query string) []string {
+		t.Helper()
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/environments?"+query, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", query, w.Code, w.Body.String())
+		}
+		var resp model.ListEnvironmentsResponse
+		if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		var names []string
+		for _, env := range resp.Environments {
+			names = append(names, env.Name)
+		}
+		return names
+	}
+
+	checkout := create(`{"name":"checkout","baseRootPackage":"root-pkg","labels":{"team":"payments","purpose":"feature"}}`)
+	create(`{"name":"billing-demo","baseRootPackage":"root-pkg","labels":{"team":"payments","purpose":"demo"}}`)
+
+	if got := list("label_selector=" + url.QueryEscape("team=payments,purpose!=demo")); len(got) != 1 || got[0] != "checkout" {
+		t.Fatalf("expected only checkout, got %v", got)
+	}
+	if got := list("status=ready&sort=name"); len(got) != 2 || got[0] != "billing-demo" {
+		t.Fatalf("expected both environments by name, got %v", got)
+	}
+	if got := list("status=failed"); len(got) != 0 {
+		t.Fatalf("expected no failed environments, got %v", got)
+	}
+
+	for _, query := range []string{"label_selector=team+in+(a", "status=sleepy", "sort=size"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/v1/environments?"+query, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", query, w.Code)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPatch, "/v1/environments/"+checkout.ID, bytes.NewBufferString(`{"labels":{"purpose":"demo","ticket":"PAY-1"}}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if got := list("label_selector=purpose%3Ddemo&sort=name"); len(got) != 2 {
+		t.Fatalf("expected both environments to be demos after the patch, got %v", got)
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/v1/environments/"+checkout.ID, bytes.NewBufferString(`{"labels":{"team":"not valid"}}`))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", w.Code, w.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodPatch, "/v1/environments/nonexistent", bytes.NewBufferString(`{"labels":{}}`))
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestQuotas_Handler(t *testing.T) {
+	_, mux := newTestHandler(orchestrator.WithQuotas(model.Quotas{
+		MaxEnvironmentsPerUser: 1,
+		MaxComponents:          2,
+	}))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("X-Forwarded-User", "alice")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/v1/environments", `{"name":"first","baseRootPackage":"root-pkg"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var env model.Environment
+	if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if env.CreatedBy != "alice" {
+		t.Fatalf("expected the environment to be attributed to alice, got %q", env.CreatedBy)
+	}
+
+	if w := do(http.MethodPost, "/v1/environments", `{"name":"second","baseRootPackage":"root-pkg"}`); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("expected 429 over the user quota, got %d: %s", w.Code, w.Body.String())
+	}
+
+	overrides := `{"overrides":[{"packageName":"a","schema":"type A { id: ID! }"},{"packageName":"b","schema":"type B { id: ID! }"}]}`
+	if w := do(http.MethodPost, "/v1/environments/"+env.ID+"/overrides", overrides); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 over the component limit, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = do(http.MethodGet, "/v1/usage", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var usage model.UsageResponse
+	if err := json.NewDecoder(w.Body).Decode(&usage); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if usage.User == nil || usage.User.Name != "alice" || usage.User.Environments != 1 || usage.User.Limit != 1 {
+		t.Fatalf("expected alice to have 1 of 1 environments, got %+v", usage.User)
+	}
+	if usage.Team != nil || usage.Quotas.MaxComponents != 2 {
+		t.Fatalf("unexpected usage %+v", usage)
+	}
+}
+
+func TestSleepAndWake_Handler(t *testing.T) {
+	_, mux := newTestHandler()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/v1/environments", `{"name":"test-env","baseRootPackage":"root-pkg","sleepSchedule":{"awakeFrom":"18:00","awakeUntil":"08:00"}}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid schedule, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w = do(http.MethodPost, "/v1/environments", `{"name":"test-env","baseRootPackage":"root-pkg","sleepSchedule":{"idleAfter":"2h"}}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var env model.Environment
+	if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if env.SleepSchedule == nil || env.SleepSchedule.IdleAfter != "2h" {
+		t.Fatalf("expected the schedule to be stored, got %+v", env.SleepSchedule)
+	}
+
+	if w := do(http.MethodPatch, "/v1/environments/"+env.ID, `{"sleepSchedule":{"days":["someday"],"awakeFrom":"08:00","awakeUntil":"18:00"}}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid schedule, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The environment is not sleeping, so there is nothing to wake.
+	if w := do(http.MethodPost, "/v1/environments/"+env.ID+"/wake", ""); w.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, "/v1/environments/nonexistent/sleep", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestLock_Handler(t *testing.T) {
+	_, mux := newTestHandler()
+
+	do := func(method, path, user, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		if user != "" {
+			req.Header.Set("X-Forwarded-User", user)
+		}
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/v1/environments", "alice", `{"name":"test-env","baseRootPackage":"root-pkg"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var env model.Environment
+	if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	path := "/v1/environments/" + env.ID
+
+	if w := do(http.MethodPut, path+"/lock", "", `{}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a caller, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPut, path+"/lock", "alice", `{"ttl":"forever"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid ttl, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPut, path+"/lock", "alice", `{"reason":"load test","ttl":"2h"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	if w := do(http.MethodDelete, path, "bob", ""); w.Code != http.StatusLocked {
+		t.Fatalf("expected 423, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodDelete, path+"?force=true", "bob", ""); w.Code != http.StatusLocked {
+		t.Fatalf("expected 423 when forced by another user, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodDelete, path+"/lock", "bob", ""); w.Code != http.StatusLocked {
+		t.Fatalf("expected 423 when another user unlocks, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPost, path+"/rollback?force=true", "bob", ""); w.Code != http.StatusLocked {
+		t.Fatalf("expected 423 for a rollback, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPatch, path+"?force=true", "bob", `{"protected":true}`); w.Code != http.StatusLocked {
+		t.Fatalf("expected 423 for a protection change, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPatch, path+"?force=true", "alice", `{"protected":true}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200 for a protection change forced by the owner, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodDelete, path+"?force=maybe", "alice", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid force, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodDelete, path+"?force=true", "alice", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 when forced by the owner, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+// gatedBuilder is a handlerTestBuilder whose builds run until release is
+// closed.
+type gatedBuilder struct {
+	handlerTestBuilder
+	release chan struct{}
+}
+
+func (b *gatedBuilder) GetBuild(_ context.Context, buildID string) (model.Build, error) {
+	select {
+	case <-b.release:
+		return model.Build{ID: buildID, Status: model.BuildStatusSucceeded}, nil
+	default:
+		return model.Build{ID: buildID, Status: model.BuildStatusRunning}, nil
+	}
+}
+
+func TestDeleteEnvironment_LockedKeepsWorkflow(t *testing.T) {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	b := &gatedBuilder{release: make(chan struct{})}
+	orch := orchestrator.New(store.NewMemoryStore(), b, &handlerTestOperator{}, logger,
+		orchestrator.WithRegistry(&handlerTestRegistry{}), orchestrator.WithPollInterval(time.Millisecond))
+	defer orch.Shutdown()
+	mux := http.NewServeMux()
+	handler.New(orch, logger).RegisterRoutes(mux)
+
+	do := func(method, path, user, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("X-Forwarded-User", user)
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	w := do(http.MethodPost, "/v1/environments", "alice", `{"name":"test-env","baseRootPackage":"root-pkg","overrides":[{"packageName":"users"}]}`)
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var env model.Environment
+	if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	path := "/v1/environments/" + env.ID
+	if w := do(http.MethodPut, path+"/lock", "alice", `{"reason":"demo"}`); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	// The rejected delete leaves the build running.
+	if w := do(http.MethodDelete, path, "bob", ""); w.Code != http.StatusLocked {
+		t.Fatalf("expected 423, got %d: %s", w.Code, w.Body.String())
+	}
+	close(b.release)
+	orch.Wait()
+
+	var got model.Environment
+	w = do(http.MethodGet, path, "alice", "")
+	if err := json.NewDecoder(w.Body).Decode(&got); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got.Status != model.StatusReady || got.Pending != nil {
+		t.Fatalf("expected the workflow to reach ready, got %s (pending %+v)", got.Status, got.Pending)
+	}
+}
+
+func TestBulkDelete_Handler(t *testing.T) {
+	_, mux := newTestHandler()
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	for _, body := range []string{
+		`{"name":"env-a","baseRootPackage":"root-pkg","branch":"feature"}`,
+		`{"name":"env-b","baseRootPackage":"root-pkg","branch":"feature","protected":true}`,
+		`{"name":"env-c","baseRootPackage":"root-pkg","branch":"main"}`,
+	} {
+		if w := do(http.MethodPost, "/v1/environments", body); w.Code != http.StatusCreated {
+			t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+		}
+	}
+
+	if w := do(http.MethodDelete, "/v1/environments", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a filter, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodDelete, "/v1/environments?status=bogus", ""); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid status, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := do(http.MethodDelete, "/v1/environments?branch=feature", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp model.BulkDeleteResponse
+	if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.Deleted) != 1 || resp.Deleted[0].Name != "env-a" ||
+		len(resp.Skipped) != 1 || resp.Skipped[0].Name != "env-b" {
+		t.Fatalf("expected env-a deleted and env-b skipped, got %+v", resp)
+	}
+
+	w = do(http.MethodGet, "/v1/environments", "")
+	var list model.ListEnvironmentsResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Environments) != 2 {
+		t.Fatalf("expected 2 environments left, got %d", len(list.Environments))
+	}
+}
@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+const (
+	// IdempotencyKeyHeader carries the client's key for a request that may be
+	// retried.
+	IdempotencyKeyHeader = "Idempotency-Key"
+	// IdempotentReplayedHeader is set on responses replayed from an earlier
+	// request with the same key.
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+
+	// DefaultIdempotencyRetention is how long responses are kept for replay
+	// unless WithIdempotency says otherwise.
+	DefaultIdempotencyRetention = 24 * time.Hour
+
+	// maxIdempotencyKey is the longest Idempotency-Key accepted.
+	maxIdempotencyKey = 255
+	// maxIdempotentBody is the largest request body hashed for an idempotent
+	// request.
+	maxIdempotentBody = 1 << 20
+)
+
+// WithIdempotency keeps the responses to requests made with an
+// Idempotency-Key in s for retention, replaying them to retries. Without it
+// they are kept in memory for DefaultIdempotencyRetention.
+func WithIdempotency(s store.IdempotencyStore, retention time.Duration) Option {
+	return func(h *Handler) {
+		h.idempotency = s
+		if retention > 0 {
+			h.idempotencyRetention = retention
+		}
+	}
+}
+
+// withIdempotency makes next safe to retry. A request with an Idempotency-Key
+// runs once; a retry with the same key and request gets the first response
+// again, with the Idempotent-Replayed header set. Reusing a key for a
+// different request is a 422, and retrying while the first request is still
+// running is a 409. Server errors are not kept, so the request can be retried.
+func (h *Handler) withIdempotency(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := r.Header.Get(IdempotencyKeyHeader)
+		if key == "" {
+			next(w, r)
+			return
+		}
+		if len(key) > maxIdempotencyKey {
+			h.writeError(w, r, http.StatusBadRequest, "Idempotency-Key must be at most "+strconv.Itoa(maxIdempotencyKey)+" characters")
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		if err != nil {
+			h.writeError(w, r, http.StatusBadRequest, "invalid request body: "+err.Error())
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are scoped to the caller so users can't replay each other's
+		// responses.
+		scoped := r.Header.Get("X-Forwarded-User") + "\x00" + key
+		hash := requestHash(r, body)
+		rec, reserved, err := h.idempotency.Reserve(r.Context(), store.IdempotencyRecord{
+			Key:         scoped,
+			RequestHash: hash,
+			ExpiresAt:   time.Now().Add(h.idempotencyRetention),
+		})
+		if err != nil {
+			h.logger.ErrorContext(r.Context(), "reserve idempotency key failed", slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "failed to check Idempotency-Key")
+			return
+		}
+		if !reserved {
+			switch {
+			case rec.RequestHash != hash:
+				h.writeError(w, r, http.StatusUnprocessableEntity, "Idempotency-Key was already used for a different request")
+			case !rec.Completed():
+				h.writeError(w, r, http.StatusConflict, "a request with this Idempotency-Key is still in progress")
+			default:
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set(IdempotentReplayedHeader, "true")
+				w.WriteHeader(rec.StatusCode)
+				_, _ = w.Write(rec.Body)
+			}
+			return
+		}
+
+		rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+		completed := false
+		defer func() {
+			if !completed {
+				if err := h.idempotency.Release(r.Context(), scoped); err != nil {
+					h.logger.ErrorContext(r.Context(), "release idempotency key failed", slog.String("error", err.Error()))
+				}
+			}
+		}()
+		next(rw, r)
+
+		if rw.status >= http.StatusInternalServerError {
+			return
+		}
+		if err := h.idempotency.Complete(r.Context(), scoped, rw.status, rw.body.Bytes()); err != nil {
+			h.logger.ErrorContext(r.Context(), "store idempotent response failed", slog.String("error", err.Error()))
+			return
+		}
+		completed = true
+	}
+}
+
+// requestHash fingerprints a request by its method, path, query and body, so
+// that a retry cannot change flags such as force.
+func requestHash(r *http.Request, body []byte) string {
+	sum := sha256.New()
+	io.WriteString(sum, r.Method+" "+r.URL.Path+"?"+r.URL.RawQuery+"\n")
+	sum.Write(body)
+	return hex.EncodeToString(sum.Sum(nil))
+}
+
+// recordingWriter passes a response through while keeping a copy of its
+// status and body.
+type recordingWriter struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	if !rw.wroteHeader {
+		rw.status = status
+		rw.wroteHeader = true
+	}
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.wroteHeader = true
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
@@ -0,0 +1,134 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/handler"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+)
+
+func idempotentRequest(method, path, body, key, user string) *http.Request {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(handler.IdempotencyKeyHeader, key)
+	if user != "" {
+		req.Header.Set("X-Forwarded-User", user)
+	}
+	return req
+}
+
+func TestIdempotency_CreateReplays(t *testing.T) {
+	_, mux := newTestHandler()
+	body := `{"name":"ci-env","baseRootPackage":"root-pkg","branch":"feature-x"}`
+
+	first := httptest.NewRecorder()
+	mux.ServeHTTP(first, idempotentRequest(http.MethodPost, "/v1/environments", body, "run-42", "ci"))
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", first.Code, first.Body.String())
+	}
+	if first.Header().Get(handler.IdempotentReplayedHeader) != "" {
+		t.Fatal("expected the first response not to be a replay")
+	}
+
+	retry := httptest.NewRecorder()
+	mux.ServeHTTP(retry, idempotentRequest(http.MethodPost, "/v1/environments", body, "run-42", "ci"))
+	if retry.Code != http.StatusCreated {
+		t.Fatalf("expected the replayed 201, got %d: %s", retry.Code, retry.Body.String())
+	}
+	if retry.Header().Get(handler.IdempotentReplayedHeader) != "true" {
+		t.Fatal("expected the retry to be marked as replayed")
+	}
+	if retry.Body.String() != first.Body.String() {
+		t.Fatalf("expected the same body, got %s and %s", first.Body.String(), retry.Body.String())
+	}
+
+	// Another caller's key is separate.
+	other := httptest.NewRecorder()
+	mux.ServeHTTP(other, idempotentRequest(http.MethodPost, "/v1/environments", body, "run-42", "bob"))
+	if other.Code != http.StatusCreated || other.Header().Get(handler.IdempotentReplayedHeader) != "" {
+		t.Fatalf("expected a new environment for another user, got %d", other.Code)
+	}
+
+	listW := httptest.NewRecorder()
+	mux.ServeHTTP(listW, httptest.NewRequest(http.MethodGet, "/v1/environments", nil))
+	var list model.ListEnvironmentsResponse
+	if err := json.NewDecoder(listW.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Environments) != 2 {
+		t.Fatalf("expected 2 environments, got %d", len(list.Environments))
+	}
+}
+
+func TestIdempotency_KeyReusedForDifferentRequest(t *testing.T) {
+	_, mux := newTestHandler()
+
+	first := httptest.NewRecorder()
+	mux.ServeHTTP(first, idempotentRequest(http.MethodPost, "/v1/environments",
+		`{"name":"one","baseRootPackage":"root-pkg"}`, "key-1", ""))
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", first.Code, first.Body.String())
+	}
+
+	reused := httptest.NewRecorder()
+	mux.ServeHTTP(reused, idempotentRequest(http.MethodPost, "/v1/environments",
+		`{"name":"two","baseRootPackage":"root-pkg"}`, "key-1", ""))
+	if reused.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", reused.Code, reused.Body.String())
+	}
+
+	// The query is part of the request, so a retry cannot add force=true.
+	promote := httptest.NewRecorder()
+	mux.ServeHTTP(promote, idempotentRequest(http.MethodPost, "/v1/environments/missing/promote", "", "key-2", ""))
+	if promote.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", promote.Code, promote.Body.String())
+	}
+	forced := httptest.NewRecorder()
+	mux.ServeHTTP(forced, idempotentRequest(http.MethodPost, "/v1/environments/missing/promote?force=true", "", "key-2", ""))
+	if forced.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", forced.Code, forced.Body.String())
+	}
+}
+
+func TestIdempotency_ClientErrorsReplay(t *testing.T) {
+	_, mux := newTestHandler()
+
+	for i, want := range []string{"", "true"} {
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, idempotentRequest(http.MethodPost, "/v1/environments/missing/promote", "", "promote-1", ""))
+		if w.Code != http.StatusNotFound {
+			t.Fatalf("attempt %d: expected 404, got %d: %s", i, w.Code, w.Body.String())
+		}
+		if got := w.Header().Get(handler.IdempotentReplayedHeader); got != want {
+			t.Fatalf("attempt %d: expected replayed header %q, got %q", i, want, got)
+		}
+	}
+}
+
+func TestCreateEnvironment_UniqueNames(t *testing.T) {
+	_, mux := newTestHandler(orchestrator.WithUniqueNames())
+	body := `{"name":"ci-env","baseRootPackage":"root-pkg","branch":"feature-x"}`
+
+	first := httptest.NewRecorder()
+	mux.ServeHTTP(first, httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(body)))
+	if first.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", first.Code, first.Body.String())
+	}
+	second := httptest.NewRecorder()
+	mux.ServeHTTP(second, httptest.NewRequest(http.MethodPost, "/v1/environments", bytes.NewBufferString(body)))
+	if second.Code != http.StatusOK {
+		t.Fatalf("expected 200 for an existing environment, got %d: %s", second.Code, second.Body.String())
+	}
+
+	var created, existing model.Environment
+	json.NewDecoder(first.Body).Decode(&created)
+	json.NewDecoder(second.Body).Decode(&existing)
+	if created.ID == "" || existing.ID != created.ID {
+		t.Fatalf("expected the existing environment %s, got %s", created.ID, existing.ID)
+	}
+}
@@ -0,0 +1,113 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/secrets"
+)
+
+func createTestEnvironment(t *testing.T, mux *http.ServeMux) model.Environment {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/v1/environments",
+		bytes.NewBufferString(`{"name":"secret-env","baseRootPackage":"root-pkg"}`))
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create: expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var env model.Environment
+	if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return env
+}
+
+func TestSecrets_Handler(t *testing.T) {
+	c, err := secrets.NewCipher(bytes.Repeat([]byte{1}, secrets.KeySize))
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	_, mux := newTestHandler(orchestrator.WithSecrets(c))
+	env := createTestEnvironment(t, mux)
+	path := "/v1/environments/" + env.ID + "/secrets"
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, path+"/api-key", bytes.NewBufferString(`{"value":"hunter2"}`)))
+	if w.Code != http.StatusOK {
+		t.Fatalf("set: expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if strings.Contains(w.Body.String(), "hunter2") {
+		t.Fatalf("set response echoes the value: %s", w.Body.String())
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), "hunter2") {
+		t.Fatalf("list: got %d: %s", w.Code, w.Body.String())
+	}
+	var list model.ListSecretsResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Secrets) != 1 || list.Secrets[0].Name != "api-key" {
+		t.Fatalf("expected api-key, got %+v", list.Secrets)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, path+"/bad%20name", bytes.NewBufferString(`{"value":"x"}`)))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("invalid name: expected 400, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/v1/environments/nope/secrets/api-key", bytes.NewBufferString(`{"value":"x"}`)))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unknown environment: expected 404, got %d", w.Code)
+	}
+
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path+"/api-key", nil))
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("delete: expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	w = httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, path+"/api-key", nil))
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("delete again: expected 404, got %d", w.Code)
+	}
+}
+
+func TestSecrets_HandlerInvalidBodyNotEchoed(t *testing.T) {
+	c, err := secrets.NewCipher(bytes.Repeat([]byte{1}, secrets.KeySize))
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	_, mux := newTestHandler(orchestrator.WithSecrets(c))
+	env := createTestEnvironment(t, mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/v1/environments/"+env.ID+"/secrets/token",
+		bytes.NewBufferString(`{"value":hunter2}`)))
+	if w.Code != http.StatusBadRequest || strings.Contains(w.Body.String(), "hunter2") {
+		t.Fatalf("expected a 400 that doesn't echo the body, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestSecrets_HandlerDisabled(t *testing.T) {
+	_, mux := newTestHandler()
+	env := createTestEnvironment(t, mux)
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/v1/environments/"+env.ID+"/secrets/token",
+		bytes.NewBufferString(`{"value":"x"}`)))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d: %s", w.Code, w.Body.String())
+	}
+}
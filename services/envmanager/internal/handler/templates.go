@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/labels"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+// ListTemplates handles GET /v1/templates.
+func (h *Handler) ListTemplates(w http.ResponseWriter, r *http.Request) {
+	templates, err := h.orch.ListTemplates(r.Context())
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "list templates failed", slog.String("error", err.Error()))
+		h.writeError(w, r, http.StatusInternalServerError, "failed to list templates")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, model.ListTemplatesResponse{Templates: templates})
+}
+
+// GetTemplate handles GET /v1/templates/{name}.
+func (h *Handler) GetTemplate(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		h.writeError(w, r, http.StatusBadRequest, "template name is required")
+		return
+	}
+
+	t, err := h.orch.GetTemplate(r.Context(), name)
+	if err != nil {
+		if errors.Is(err, store.ErrTemplateNotFound) {
+			h.writeError(w, r, http.StatusNotFound, "template not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "get template failed", slog.String("error", err.Error()))
+		h.writeError(w, r, http.StatusInternalServerError, "failed to get template")
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, t)
+}
+
+// PutTemplate handles PUT /v1/templates/{name}.
+func (h *Handler) PutTemplate(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		h.writeError(w, r, http.StatusBadRequest, "template name is required")
+		return
+	}
+
+	var spec model.TemplateSpec
+	if err := json.NewDecoder(r.Body).Decode(&spec); err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return
+	}
+
+	t, err := h.orch.PutTemplate(r.Context(), name, spec)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrInvalidTemplate), errors.Is(err, model.ErrInvalidOverride),
+			errors.Is(err, labels.ErrInvalidLabel), errors.Is(err, model.ErrInvalidSchedule):
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, orchestrator.ErrLimitExceeded):
+			h.writeError(w, r, http.StatusForbidden, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "put template failed", slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "failed to save template")
+		}
+		return
+	}
+
+	h.writeJSON(w, r, http.StatusOK, t)
+}
+
+// DeleteTemplate handles DELETE /v1/templates/{name}.
+func (h *Handler) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	name := r.PathValue("name")
+	if name == "" {
+		h.writeError(w, r, http.StatusBadRequest, "template name is required")
+		return
+	}
+
+	if err := h.orch.DeleteTemplate(r.Context(), name); err != nil {
+		if errors.Is(err, store.ErrTemplateNotFound) {
+			h.writeError(w, r, http.StatusNotFound, "template not found")
+			return
+		}
+		h.logger.ErrorContext(r.Context(), "delete template failed", slog.String("error", err.Error()))
+		h.writeError(w, r, http.StatusInternalServerError, "failed to delete template")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
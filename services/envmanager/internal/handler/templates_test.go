@@ -0,0 +1,91 @@
+package handler_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+)
+
+func TestTemplates_Handler(t *testing.T) {
+	_, mux := newTestHandler(orchestrator.WithQuotas(model.Quotas{MaxReplicas: 5}))
+
+	do := func(method, path, body string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+		req.Header.Set("X-Forwarded-User", "alice")
+		w := httptest.NewRecorder()
+		mux.ServeHTTP(w, req)
+		return w
+	}
+
+	if w := do(http.MethodPut, "/v1/templates/Bad_Name", `{"baseRootPackage":"root-pkg"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an invalid name, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodPut, "/v1/templates/checkout", `{"ttl":"72h"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without a base, got %d: %s", w.Code, w.Body.String())
+	}
+	big := `{"baseRootPackage":"root-pkg","overrides":[{"packageName":"root-pkg","runtime":{"replicas":6}}]}`
+	if w := do(http.MethodPut, "/v1/templates/checkout", big); w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403 for a template over the size limit, got %d: %s", w.Code, w.Body.String())
+	}
+
+	w := do(http.MethodPut, "/v1/templates/checkout", `{"baseRootPackage":"root-pkg","labels":{"team":"payments"},"ttl":"72h"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var tmpl model.Template
+	if err := json.NewDecoder(w.Body).Decode(&tmpl); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if tmpl.Name != "checkout" || tmpl.CreatedBy != "alice" || tmpl.TTL != "72h" {
+		t.Fatalf("unexpected template: %+v", tmpl)
+	}
+
+	w = do(http.MethodGet, "/v1/templates", "")
+	var list model.ListTemplatesResponse
+	if err := json.NewDecoder(w.Body).Decode(&list); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(list.Templates) != 1 || list.Templates[0].Name != "checkout" {
+		t.Fatalf("expected the checkout template, got %+v", list.Templates)
+	}
+
+	if w := do(http.MethodPost, "/v1/environments", `{"name":"env","template":"missing"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown template, got %d: %s", w.Code, w.Body.String())
+	}
+	w = do(http.MethodPost, "/v1/environments", `{"name":"env","template":"checkout"}`)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+	var env model.Environment
+	if err := json.NewDecoder(w.Body).Decode(&env); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if env.Template != "checkout" || env.BaseRootPackage != "root-pkg" || env.Labels["team"] != "payments" || env.ExpiresAt == nil {
+		t.Fatalf("expected an environment from the template, got %+v", env)
+	}
+
+	w = do(http.MethodGet, "/v1/environments?template=checkout", "")
+	var envs model.ListEnvironmentsResponse
+	if err := json.NewDecoder(w.Body).Decode(&envs); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(envs.Environments) != 1 || envs.Environments[0].ID != env.ID {
+		t.Fatalf("expected the environment listed by template, got %+v", envs.Environments)
+	}
+
+	if w := do(http.MethodDelete, "/v1/templates/checkout", ""); w.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodGet, "/v1/templates/checkout", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := do(http.MethodDelete, "/v1/templates/checkout", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d: %s", w.Code, w.Body.String())
+	}
+}
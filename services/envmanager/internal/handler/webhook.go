@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/webhook"
+)
+
+// maxWebhookBody is the largest webhook payload accepted. GitHub caps
+// deliveries at 25 MB.
+const maxWebhookBody = 25 << 20
+
+// WebhookSecrets holds the shared secret for each webhook provider. A
+// provider's endpoint is only served when its secret is set.
+type WebhookSecrets struct {
+	GitHub string
+	GitLab string
+}
+
+// WithWebhooks serves Git hosting webhooks, handled by receiver.
+func WithWebhooks(receiver *webhook.Receiver, secrets WebhookSecrets) Option {
+	return func(h *Handler) {
+		h.receiver = receiver
+		h.secrets = secrets
+	}
+}
+
+func (h *Handler) registerWebhookRoutes(mux *http.ServeMux) {
+	if h.receiver == nil {
+		return
+	}
+	if h.secrets.GitHub != "" {
+		mux.HandleFunc("POST /v1/webhooks/github", h.GitHubWebhook)
+	}
+	if h.secrets.GitLab != "" {
+		mux.HandleFunc("POST /v1/webhooks/gitlab", h.GitLabWebhook)
+	}
+}
+
+// GitHubWebhook handles POST /v1/webhooks/github.
+func (h *Handler) GitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readWebhookBody(w, r)
+	if !ok {
+		return
+	}
+	if err := webhook.VerifyGitHub(h.secrets.GitHub, body, r.Header.Get("X-Hub-Signature-256")); err != nil {
+		h.writeError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	ev, err := webhook.ParseGitHub(r.Header.Get("X-GitHub-Event"), body)
+	h.handleWebhook(w, r, ev, err)
+}
+
+// GitLabWebhook handles POST /v1/webhooks/gitlab.
+func (h *Handler) GitLabWebhook(w http.ResponseWriter, r *http.Request) {
+	body, ok := h.readWebhookBody(w, r)
+	if !ok {
+		return
+	}
+	if err := webhook.VerifyGitLab(h.secrets.GitLab, r.Header.Get("X-Gitlab-Token")); err != nil {
+		h.writeError(w, r, http.StatusUnauthorized, err.Error())
+		return
+	}
+	ev, err := webhook.ParseGitLab(r.Header.Get("X-Gitlab-Event"), body)
+	h.handleWebhook(w, r, ev, err)
+}
+
+func (h *Handler) readWebhookBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBody))
+	if err != nil {
+		h.writeError(w, r, http.StatusBadRequest, "invalid request body: "+err.Error())
+		return nil, false
+	}
+	return body, true
+}
+
+// handleWebhook applies a parsed event. Events that do not affect
+// environments are acknowledged so the provider does not retry them.
+func (h *Handler) handleWebhook(w http.ResponseWriter, r *http.Request, ev webhook.Event, parseErr error) {
+	if errors.Is(parseErr, webhook.ErrUnsupportedEvent) {
+		h.writeJSON(w, r, http.StatusOK, webhook.Result{Action: webhook.ActionIgnored, Reason: parseErr.Error()})
+		return
+	}
+	if parseErr != nil {
+		h.writeError(w, r, http.StatusBadRequest, parseErr.Error())
+		return
+	}
+
+	result, err := h.receiver.Handle(r.Context(), ev)
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrInvalidOverride):
+			h.writeError(w, r, http.StatusBadRequest, err.Error())
+		case errors.Is(err, model.ErrInvalidTransition):
+			h.writeError(w, r, http.StatusConflict, err.Error())
+		case errors.Is(err, orchestrator.ErrLocked):
+			h.writeError(w, r, http.StatusLocked, err.Error())
+		case errors.Is(err, orchestrator.ErrQuotaExceeded), errors.Is(err, orchestrator.ErrLimitExceeded):
+			status, _ := quotaStatus(err)
+			h.writeError(w, r, status, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "webhook failed",
+				slog.String("provider", string(ev.Provider)),
+				slog.String("repository", ev.Repository),
+				slog.String("branch", ev.Branch),
+				slog.String("error", err.Error()))
+			h.writeError(w, r, http.StatusInternalServerError, "failed to handle webhook")
+		}
+		return
+	}
+
+	status := http.StatusOK
+	if result.Action == webhook.ActionCreated || result.Action == webhook.ActionUpdated {
+		status = http.StatusAccepted
+	}
+	h.writeJSON(w, r, status, result)
+}
@@ -0,0 +1,149 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/handler"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/webhook"
+)
+
+const webhookSecret = "s3cret"
+
+// webhookTestSource serves a repository whose root manifest is root-pkg and
+// which has a users package, at every ref.
+type webhookTestSource struct{}
+
+func (webhookTestSource) ReadFile(_ context.Context, _, _, path string) ([]byte, error) {
+	switch path {
+	case "turbo-engine.yaml":
+		return []byte("name: root-pkg\nkind: graphql-supergraph\n"), nil
+	case "users/turbo-engine.yaml":
+		return []byte("name: users\nkind: graphql-subgraph\nschema: schema.graphql\n"), nil
+	case "users/schema.graphql":
+		return []byte("type User { id: ID! }"), nil
+	}
+	return nil, webhook.ErrFileNotFound
+}
+
+func newWebhookTestHandler() *http.ServeMux {
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	orch := orchestrator.New(store.NewMemoryStore(), &handlerTestBuilder{}, &handlerTestOperator{}, logger)
+	receiver := webhook.New(orch, logger,
+		webhook.WithSource(webhook.ProviderGitHub, webhookTestSource{}),
+		webhook.WithSource(webhook.ProviderGitLab, webhookTestSource{}))
+	h := handler.New(orch, logger, handler.WithWebhooks(receiver, handler.WebhookSecrets{
+		GitHub: webhookSecret,
+		GitLab: webhookSecret,
+	}))
+	mux := http.NewServeMux()
+	h.RegisterRoutes(mux)
+	return mux
+}
+
+const webhookPush = `{
+  "ref": "refs/heads/feature-x",
+  "before": "6113728f27ae82c7b1a177c8d03f9e96e0adf246",
+  "after": "9c2f1a4e5b8d7c3a0f6e1b2d4c8a7e9f3b5d1c6a",
+  "repository": {"full_name": "acme/api", "default_branch": "main"},
+  "sender": {"login": "alice"},
+  "commits": [{"added": [], "removed": [], "modified": ["users/schema.graphql"]}]
+}`
+
+func githubWebhookRequest(event, body, signature string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/v1/webhooks/github", bytes.NewBufferString(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-Hub-Signature-256", signature)
+	return req
+}
+
+func githubSignature(body string) string {
+	mac := hmac.New(sha256.New, []byte(webhookSecret))
+	mac.Write([]byte(body))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestGitHubWebhook_Push(t *testing.T) {
+	mux := newWebhookTestHandler()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, githubWebhookRequest("push", webhookPush, githubSignature(webhookPush)))
+
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("expected 202, got %d: %s", w.Code, w.Body.String())
+	}
+	var result webhook.Result
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if result.Action != webhook.ActionCreated || result.EnvironmentID == "" {
+		t.Fatalf("expected an environment to be created, got %+v", result)
+	}
+}
+
+func TestGitHubWebhook_InvalidSignature(t *testing.T) {
+	mux := newWebhookTestHandler()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, githubWebhookRequest("push", webhookPush, githubSignature(`{}`)))
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestGitHubWebhook_Ping(t *testing.T) {
+	mux := newWebhookTestHandler()
+
+	body := `{"zen":"Design for failure."}`
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, githubWebhookRequest("ping", body, githubSignature(body)))
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var result webhook.Result
+	if err := json.NewDecoder(w.Body).Decode(&result); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if result.Action != webhook.ActionIgnored {
+		t.Fatalf("expected the ping to be ignored, got %+v", result)
+	}
+}
+
+func TestGitLabWebhook_InvalidToken(t *testing.T) {
+	mux := newWebhookTestHandler()
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/webhooks/gitlab", bytes.NewBufferString(`{}`))
+	req.Header.Set("X-Gitlab-Event", "Push Hook")
+	req.Header.Set("X-Gitlab-Token", "wrong")
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, req)
+
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d: %s", w.Code, w.Body.String())
+	}
+}
+
+func TestWebhooks_NotConfigured(t *testing.T) {
+	_, mux := newTestHandler()
+
+	w := httptest.NewRecorder()
+	mux.ServeHTTP(w, githubWebhookRequest("push", webhookPush, githubSignature(webhookPush)))
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 without a webhook secret, got %d", w.Code)
+	}
+}
@@ -0,0 +1,72 @@
+// Package labels validates environment labels and parses and matches
+// Kubernetes-style label selectors such as "team=payments,purpose!=demo".
+package labels
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ReservedPrefix is the key prefix the operator uses for the labels it
+// manages on cluster resources. Environments may not set keys under it.
+const ReservedPrefix = "turboengine.io/"
+
+var (
+	// ErrInvalidLabel is returned for a label key or value Kubernetes would
+	// reject, or a key under ReservedPrefix.
+	ErrInvalidLabel = errors.New("invalid label")
+
+	// ErrInvalidSelector is returned for a selector that does not parse.
+	ErrInvalidSelector = errors.New("invalid label selector")
+)
+
+var (
+	namePattern   = regexp.MustCompile(`^[A-Za-z0-9]([-A-Za-z0-9_.]*[A-Za-z0-9])?$`)
+	prefixPattern = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)*$`)
+)
+
+// ValidateKey checks a label key: an optional DNS subdomain prefix and a
+// slash, then a name of at most 63 characters.
+func ValidateKey(key string) error {
+	name := key
+	if prefix, rest, ok := strings.Cut(key, "/"); ok {
+		if len(prefix) == 0 || len(prefix) > 253 || !prefixPattern.MatchString(prefix) {
+			return fmt.Errorf("%w: key %q has an invalid prefix", ErrInvalidLabel, key)
+		}
+		name = rest
+	}
+	if len(name) == 0 || len(name) > 63 || !namePattern.MatchString(name) {
+		return fmt.Errorf("%w: key %q must be at most 63 alphanumerics, '-', '_' or '.', starting and ending with an alphanumeric", ErrInvalidLabel, key)
+	}
+	return nil
+}
+
+// ValidateValue checks a label value: empty, or at most 63 characters of the
+// same form as a key's name.
+func ValidateValue(value string) error {
+	if value == "" {
+		return nil
+	}
+	if len(value) > 63 || !namePattern.MatchString(value) {
+		return fmt.Errorf("%w: value %q must be at most 63 alphanumerics, '-', '_' or '.', starting and ending with an alphanumeric", ErrInvalidLabel, value)
+	}
+	return nil
+}
+
+// Validate checks every label, and that none uses ReservedPrefix.
+func Validate(labels map[string]string) error {
+	for k, v := range labels {
+		if err := ValidateKey(k); err != nil {
+			return err
+		}
+		if strings.HasPrefix(k, ReservedPrefix) {
+			return fmt.Errorf("%w: key %q uses the reserved prefix %s", ErrInvalidLabel, k, ReservedPrefix)
+		}
+		if err := ValidateValue(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
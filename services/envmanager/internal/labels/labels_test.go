@@ -0,0 +1,95 @@
+package labels_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/labels"
+)
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		labels  map[string]string
+		wantErr bool
+	}{
+		{name: "simple", labels: map[string]string{"team": "payments", "ticket": "PAY-123"}},
+		{name: "prefixed key", labels: map[string]string{"example.com/owner": "alice"}},
+		{name: "empty value", labels: map[string]string{"preview": ""}},
+		{name: "empty key", labels: map[string]string{"": "x"}, wantErr: true},
+		{name: "bad key character", labels: map[string]string{"team name": "x"}, wantErr: true},
+		{name: "bad value character", labels: map[string]string{"team": "pay ments"}, wantErr: true},
+		{name: "value too long", labels: map[string]string{"team": string(make([]byte, 64))}, wantErr: true},
+		{name: "uppercase prefix", labels: map[string]string{"Example.com/owner": "alice"}, wantErr: true},
+		{name: "reserved prefix", labels: map[string]string{"turboengine.io/environment": "x"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := labels.Validate(tt.labels)
+			if tt.wantErr && !errors.Is(err, labels.ErrInvalidLabel) {
+				t.Fatalf("expected ErrInvalidLabel, got %v", err)
+			}
+			if !tt.wantErr && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+		})
+	}
+}
+
+func TestSelector(t *testing.T) {
+	payments := map[string]string{"team": "payments", "purpose": "feature"}
+	demo := map[string]string{"team": "payments", "purpose": "demo"}
+	search := map[string]string{"team": "search"}
+
+	tests := []struct {
+		selector string
+		want     []bool // payments, demo, search
+	}{
+		{"", []bool{true, true, true}},
+		{"team=payments", []bool{true, true, false}},
+		{"team==payments", []bool{true, true, false}},
+		{"team=payments,purpose!=demo", []bool{true, false, false}},
+		{"purpose!=demo", []bool{true, false, true}},
+		{"team in (search, payments)", []bool{true, true, true}},
+		{"purpose notin (demo,load-test)", []bool{true, false, true}},
+		{"purpose", []bool{true, true, false}},
+		{"!purpose", []bool{false, false, true}},
+		{" team = search ", []bool{false, false, true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			sel, err := labels.Parse(tt.selector)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			for i, l := range []map[string]string{payments, demo, search} {
+				if got := sel.Matches(l); got != tt.want[i] {
+					t.Fatalf("%v: expected %v, got %v", l, tt.want[i], got)
+				}
+			}
+
+			// The formatted selector parses to the same requirements.
+			again, err := labels.Parse(sel.String())
+			if err != nil || again.String() != sel.String() {
+				t.Fatalf("round trip of %q gave %q, %v", sel.String(), again.String(), err)
+			}
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, s := range []string{
+		"team=payments,",
+		"team in (a,b",
+		"team between (a,b)",
+		"team=pay ments",
+		"=payments",
+		"!",
+	} {
+		t.Run(s, func(t *testing.T) {
+			if _, err := labels.Parse(s); !errors.Is(err, labels.ErrInvalidSelector) {
+				t.Fatalf("expected ErrInvalidSelector, got %v", err)
+			}
+		})
+	}
+}
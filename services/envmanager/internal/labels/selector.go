@@ -0,0 +1,162 @@
+package labels
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Operator is how a requirement compares a label.
+type Operator string
+
+const (
+	OpEquals       Operator = "="
+	OpNotEquals    Operator = "!="
+	OpIn           Operator = "in"
+	OpNotIn        Operator = "notin"
+	OpExists       Operator = "exists"
+	OpDoesNotExist Operator = "!"
+)
+
+// Requirement is one comma-separated term of a selector.
+type Requirement struct {
+	Key      string
+	Operator Operator
+	Values   []string
+}
+
+// Matches reports whether labels satisfy the requirement. As in Kubernetes,
+// != and notin also match labels without the key.
+func (r Requirement) Matches(labels map[string]string) bool {
+	v, ok := labels[r.Key]
+	switch r.Operator {
+	case OpEquals, OpIn:
+		return ok && slices.Contains(r.Values, v)
+	case OpNotEquals, OpNotIn:
+		return !ok || !slices.Contains(r.Values, v)
+	case OpExists:
+		return ok
+	case OpDoesNotExist:
+		return !ok
+	}
+	return false
+}
+
+// Selector matches labels that satisfy all of its requirements. The zero
+// Selector matches everything.
+type Selector []Requirement
+
+// Matches reports whether labels satisfy every requirement.
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		if !r.Matches(labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// Parse parses a Kubernetes-style selector: comma-separated requirements of
+// the forms key=value, key==value, key!=value, key in (a,b),
+// key notin (a,b), key and !key. An empty string is the empty Selector.
+func Parse(s string) (Selector, error) {
+	var sel Selector
+	for _, term := range splitTerms(s) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			return nil, fmt.Errorf("%w: empty requirement in %q", ErrInvalidSelector, s)
+		}
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, err
+		}
+		sel = append(sel, r)
+	}
+	return sel, nil
+}
+
+// splitTerms splits a selector on the commas that are not inside a set.
+func splitTerms(s string) []string {
+	if strings.TrimSpace(s) == "" {
+		return nil
+	}
+	var (
+		terms []string
+		depth int
+		start int
+	)
+	for i, c := range s {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, s[start:])
+}
+
+func parseRequirement(term string) (Requirement, error) {
+	var r Requirement
+	switch {
+	case strings.HasPrefix(term, "!"):
+		r = Requirement{Key: strings.TrimSpace(term[1:]), Operator: OpDoesNotExist}
+	case strings.Contains(term, "("):
+		key, rest, _ := strings.Cut(term, " ")
+		rest = strings.TrimSpace(rest)
+		op, set, _ := strings.Cut(rest, "(")
+		r = Requirement{Key: key, Operator: Operator(strings.TrimSpace(op))}
+		if r.Operator != OpIn && r.Operator != OpNotIn {
+			return Requirement{}, fmt.Errorf("%w: %q: expected in or notin", ErrInvalidSelector, term)
+		}
+		set, ok := strings.CutSuffix(strings.TrimSpace(set), ")")
+		if !ok {
+			return Requirement{}, fmt.Errorf("%w: %q: unterminated set", ErrInvalidSelector, term)
+		}
+		for _, v := range strings.Split(set, ",") {
+			r.Values = append(r.Values, strings.TrimSpace(v))
+		}
+	case strings.Contains(term, "!="):
+		key, value, _ := strings.Cut(term, "!=")
+		r = Requirement{Key: strings.TrimSpace(key), Operator: OpNotEquals, Values: []string{strings.TrimSpace(value)}}
+	case strings.Contains(term, "="):
+		key, value, _ := strings.Cut(term, "=")
+		value = strings.TrimPrefix(value, "=")
+		r = Requirement{Key: strings.TrimSpace(key), Operator: OpEquals, Values: []string{strings.TrimSpace(value)}}
+	default:
+		r = Requirement{Key: term, Operator: OpExists}
+	}
+
+	if err := ValidateKey(r.Key); err != nil {
+		return Requirement{}, fmt.Errorf("%w: %w", ErrInvalidSelector, err)
+	}
+	for _, v := range r.Values {
+		if err := ValidateValue(v); err != nil {
+			return Requirement{}, fmt.Errorf("%w: %w", ErrInvalidSelector, err)
+		}
+	}
+	return r, nil
+}
+
+// String formats the selector in the syntax Parse accepts.
+func (s Selector) String() string {
+	terms := make([]string, len(s))
+	for i, r := range s {
+		switch r.Operator {
+		case OpEquals, OpNotEquals:
+			terms[i] = r.Key + string(r.Operator) + r.Values[0]
+		case OpIn, OpNotIn:
+			terms[i] = fmt.Sprintf("%s %s (%s)", r.Key, r.Operator, strings.Join(r.Values, ","))
+		case OpExists:
+			terms[i] = r.Key
+		case OpDoesNotExist:
+			terms[i] = "!" + r.Key
+		}
+	}
+	return strings.Join(terms, ",")
+}
@@ -0,0 +1,114 @@
+package model
+
+import "time"
+
+// EventType identifies what happened to an environment.
+type EventType string
+
+const (
+	EventCreated          EventType = "created"
+	EventOverridesApplied EventType = "overrides_applied"
+	EventLabelsUpdated    EventType = "labels_updated"
+	EventRebased          EventType = "rebased"
+	EventRolledBack       EventType = "rolled_back"
+	EventBuildTriggered   EventType = "build_triggered"
+	EventBuildFailed      EventType = "build_failed"
+	EventDeploySucceeded  EventType = "deploy_succeeded"
+	EventDeployFailed     EventType = "deploy_failed"
+	EventSmokeTestsPassed EventType = "smoke_tests_passed"
+	EventSmokeTestsFailed EventType = "smoke_tests_failed"
+	EventPromoted         EventType = "promoted"
+	EventSlept            EventType = "slept"
+	EventWoke             EventType = "woke"
+	EventSecretSet        EventType = "secret_set"
+	EventSecretDeleted    EventType = "secret_deleted"
+	EventLocked           EventType = "locked"
+	EventUnlocked         EventType = "unlocked"
+	EventProtected        EventType = "protected"
+	EventUnprotected      EventType = "unprotected"
+	EventDeleted          EventType = "deleted"
+)
+
+// Event is one entry in an environment's activity timeline. Events are only
+// ever appended, and outlive the environment they describe.
+type Event struct {
+	ID            string    `json:"id"`
+	EnvironmentID string    `json:"environmentId"`
+	Type          EventType `json:"type"`
+	// Actor is who caused the event: the authenticated user, the webhook
+	// sender or the environment's creator. Empty for system events.
+	Actor   string `json:"actor,omitempty"`
+	Message string `json:"message,omitempty"`
+	BuildID string `json:"buildId,omitempty"`
+	// Status is the environment's status after the event.
+	Status EnvironmentStatus `json:"status,omitempty"`
+	// Overrides is the change to the environment's overrides, if any.
+	Overrides *OverridesChange `json:"overrides,omitempty"`
+	// Stage and Error explain a failure.
+	Stage WorkflowStage `json:"stage,omitempty"`
+	Error string        `json:"error,omitempty"`
+	Time  time.Time     `json:"time"`
+}
+
+// Clone returns a deep copy of the event.
+func (e Event) Clone() Event {
+	if e.Overrides != nil {
+		c := e.Overrides.Clone()
+		e.Overrides = &c
+	}
+	return e
+}
+
+// OverridesChange lists the packages whose overrides were added, removed or
+// changed.
+type OverridesChange struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// Clone returns a deep copy of the change.
+func (c OverridesChange) Clone() OverridesChange {
+	return OverridesChange{
+		Added:   append([]string(nil), c.Added...),
+		Removed: append([]string(nil), c.Removed...),
+		Changed: append([]string(nil), c.Changed...),
+	}
+}
+
+// Empty reports whether nothing changed.
+func (c OverridesChange) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0
+}
+
+// DiffOverrides compares two sets of overrides by package name.
+func DiffOverrides(before, after []PackageOverride) OverridesChange {
+	var c OverridesChange
+	old := make(map[string]PackageOverride, len(before))
+	for _, o := range before {
+		old[o.PackageName] = o
+	}
+	seen := make(map[string]bool, len(after))
+	for _, o := range after {
+		seen[o.PackageName] = true
+		prev, ok := old[o.PackageName]
+		switch {
+		case !ok:
+			c.Added = append(c.Added, o.PackageName)
+		case !prev.Equal(o):
+			c.Changed = append(c.Changed, o.PackageName)
+		}
+	}
+	for _, o := range before {
+		if !seen[o.PackageName] {
+			c.Removed = append(c.Removed, o.PackageName)
+		}
+	}
+	return c
+}
+
+// ListEventsResponse is a page of an environment's events, newest first.
+type ListEventsResponse struct {
+	Events        []Event `json:"events"`
+	NextPageToken string  `json:"nextPageToken,omitempty"`
+}
@@ -0,0 +1,121 @@
+package model
+
+import "time"
+
+// MaxBuildHistory is how many builds an environment remembers. Older records
+// are dropped as new builds are deployed.
+const MaxBuildHistory = 20
+
+// BuildOutcome is the result of deploying one build to an environment.
+type BuildOutcome string
+
+const (
+	// BuildOutcomeRunning is a build still being built, deployed or rolled out.
+	BuildOutcomeRunning BuildOutcome = "running"
+	// BuildOutcomeSucceeded is a build that was deployed and rolled out.
+	BuildOutcomeSucceeded BuildOutcome = "succeeded"
+	// BuildOutcomeFailed is a build that failed to build, deploy or roll out.
+	BuildOutcomeFailed BuildOutcome = "failed"
+	// BuildOutcomeCancelled is a build superseded by a newer one before it
+	// finished.
+	BuildOutcomeCancelled BuildOutcome = "cancelled"
+)
+
+// BuildSource says where a deployed build came from.
+type BuildSource string
+
+const (
+	// BuildSourceBuild is a new build of the environment's overrides.
+	BuildSourceBuild BuildSource = "build"
+	// BuildSourceParent is the parent's build, deployed to a new fork.
+	BuildSourceParent BuildSource = "parent"
+	// BuildSourceRollback is an earlier build, redeployed by a rollback.
+	BuildSourceRollback BuildSource = "rollback"
+)
+
+// BuildRecord is one entry in an environment's build history.
+type BuildRecord struct {
+	BuildID string      `json:"buildId"`
+	Source  BuildSource `json:"source"`
+	// Overrides are the environment's overrides when the build was deployed.
+	Overrides   []PackageOverride `json:"overrides,omitempty"`
+	Outcome     BuildOutcome      `json:"outcome"`
+	Error       string            `json:"error,omitempty"`
+	StartedAt   time.Time         `json:"startedAt"`
+	CompletedAt *time.Time        `json:"completedAt,omitempty"`
+}
+
+// Clone returns a deep copy of the record.
+func (r BuildRecord) Clone() BuildRecord {
+	r.Overrides = CloneOverrides(r.Overrides)
+	if r.CompletedAt != nil {
+		t := *r.CompletedAt
+		r.CompletedAt = &t
+	}
+	return r
+}
+
+// RecordBuild starts a build history entry for deploying buildID with the
+// environment's current overrides, dropping the oldest entries beyond
+// MaxBuildHistory.
+func (e *Environment) RecordBuild(buildID string, source BuildSource, now time.Time) {
+	e.BuildHistory = append(e.BuildHistory, BuildRecord{
+		BuildID:   buildID,
+		Source:    source,
+		Overrides: CloneOverrides(e.Overrides),
+		Outcome:   BuildOutcomeRunning,
+		StartedAt: now,
+	})
+	if n := len(e.BuildHistory) - MaxBuildHistory; n > 0 {
+		e.BuildHistory = append([]BuildRecord(nil), e.BuildHistory[n:]...)
+	}
+}
+
+// FinishBuild records the outcome of the running build history entries.
+// Starting a workflow cancels any entry left running by the one it
+// supersedes, so normally only the newest entry is running.
+func (e *Environment) FinishBuild(outcome BuildOutcome, errMsg string, now time.Time) {
+	for i := range e.BuildHistory {
+		rec := &e.BuildHistory[i]
+		if rec.Outcome != BuildOutcomeRunning {
+			continue
+		}
+		rec.Outcome = outcome
+		rec.Error = errMsg
+		rec.CompletedAt = &now
+	}
+}
+
+// FindBuild returns the newest successful build history entry for buildID,
+// or if it never succeeded, its newest entry.
+func (e Environment) FindBuild(buildID string) (BuildRecord, bool) {
+	var found *BuildRecord
+	for i := len(e.BuildHistory) - 1; i >= 0; i-- {
+		rec := &e.BuildHistory[i]
+		if rec.BuildID != buildID {
+			continue
+		}
+		if rec.Outcome == BuildOutcomeSucceeded {
+			return *rec, true
+		}
+		if found == nil {
+			found = rec
+		}
+	}
+	if found == nil {
+		return BuildRecord{}, false
+	}
+	return *found, true
+}
+
+// PreviousBuild returns the newest successful build history entry for a build
+// other than the current one.
+func (e Environment) PreviousBuild() (BuildRecord, bool) {
+	for i := len(e.BuildHistory) - 1; i >= 0; i-- {
+		rec := e.BuildHistory[i]
+		if rec.Outcome == BuildOutcomeSucceeded && rec.BuildID != e.CurrentBuildID {
+			return rec, true
+		}
+	}
+	return BuildRecord{}, false
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidTransition is returned when an environment is asked to move to a
+// status that is not reachable from its current status.
+var ErrInvalidTransition = errors.New("invalid environment status transition")
+
+// transitions lists, for each status, the statuses it may move to.
+// Deletion is terminal: a deleting environment is removed from the store once
+// teardown completes, so it never transitions anywhere else.
+var transitions = map[EnvironmentStatus][]EnvironmentStatus{
+	StatusCreating:  {StatusReady, StatusBuilding, StatusFailed, StatusDeleting},
+	StatusBuilding:  {StatusBuilding, StatusDeploying, StatusFailed, StatusDeleting},
+	StatusDeploying: {StatusBuilding, StatusDeploying, StatusReady, StatusFailed, StatusDeleting},
+	StatusReady:     {StatusBuilding, StatusSleeping, StatusDegraded, StatusDeleting},
+	StatusFailed:    {StatusBuilding, StatusDeleting},
+	StatusSleeping:  {StatusReady, StatusBuilding, StatusDeleting},
+	StatusDegraded:  {StatusBuilding, StatusDeleting},
+	StatusDeleting:  {StatusDeleting},
+}
+
+// ValidStatus reports whether s is a known environment status.
+func ValidStatus(s EnvironmentStatus) bool {
+	_, ok := transitions[s]
+	return ok
+}
+
+// TransitionError describes a rejected status transition.
+type TransitionError struct {
+	From EnvironmentStatus
+	To   EnvironmentStatus
+}
+
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("cannot move environment from %q to %q", e.From, e.To)
+}
+
+// Is reports whether target is ErrInvalidTransition.
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// ValidateTransition returns a *TransitionError if from cannot move to to.
+func ValidateTransition(from, to EnvironmentStatus) error {
+	for _, allowed := range transitions[from] {
+		if allowed == to {
+			return nil
+		}
+	}
+	return &TransitionError{From: from, To: to}
+}
+
+// OperationType names a unit of long-running work owned by envmanager.
+type OperationType string
+
+const (
+	// OperationBuildDeploy is the asynchronous build/deploy workflow.
+	OperationBuildDeploy OperationType = "build-deploy"
+	// OperationTeardown tears down deployed resources and deletes the environment.
+	OperationTeardown OperationType = "teardown"
+)
+
+// PendingOperation records in-flight work on an environment. It is persisted
+// alongside the environment so that work interrupted by a restart can be
+// resumed or compensated on startup.
+type PendingOperation struct {
+	Type      OperationType `json:"type"`
+	StartedAt time.Time     `json:"startedAt"`
+	// Attempts counts how many times the operation has been started,
+	// including resumptions after a restart.
+	Attempts int `json:"attempts"`
+}
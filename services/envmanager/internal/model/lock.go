@@ -0,0 +1,88 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrInvalidLock is returned for a lock request that cannot be applied.
+var ErrInvalidLock = errors.New("invalid lock")
+
+// Lock reserves an environment for its owner: while it is held, changing the
+// environment's overrides, promoting it or deleting it is rejected unless
+// the owner forces it.
+type Lock struct {
+	Owner    string    `json:"owner"`
+	Reason   string    `json:"reason,omitempty"`
+	LockedAt time.Time `json:"lockedAt"`
+	// ExpiresAt, if set, is when the lock lapses on its own.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+}
+
+// Held reports whether the lock is in force at now. A nil lock is not.
+func (l *Lock) Held(now time.Time) bool {
+	return l != nil && (l.ExpiresAt == nil || now.Before(*l.ExpiresAt))
+}
+
+// Clone returns a deep copy of the lock.
+func (l *Lock) Clone() *Lock {
+	if l == nil {
+		return nil
+	}
+	c := *l
+	if l.ExpiresAt != nil {
+		t := *l.ExpiresAt
+		c.ExpiresAt = &t
+	}
+	return &c
+}
+
+// LockRequest is the request body for locking an environment. The lock's
+// owner is the caller. ExpiresAt and TTL optionally bound it; at most one
+// may be set.
+type LockRequest struct {
+	Reason    string     `json:"reason,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	TTL       string     `json:"ttl,omitempty"`
+}
+
+// Expiry returns the absolute expiry time requested, if any, relative to now.
+func (r LockRequest) Expiry(now time.Time) (*time.Time, error) {
+	switch {
+	case r.ExpiresAt != nil && r.TTL != "":
+		return nil, fmt.Errorf("%w: only one of expiresAt and ttl may be set", ErrInvalidLock)
+	case r.ExpiresAt != nil:
+		if !r.ExpiresAt.After(now) {
+			return nil, fmt.Errorf("%w: expiresAt must be in the future", ErrInvalidLock)
+		}
+		t := r.ExpiresAt.UTC()
+		return &t, nil
+	case r.TTL != "":
+		ttl, err := time.ParseDuration(r.TTL)
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("%w: ttl %q must be a positive duration such as \"8h\"", ErrInvalidLock, r.TTL)
+		}
+		t := now.Add(ttl).UTC()
+		return &t, nil
+	}
+	return nil, nil
+}
+
+// BulkDeleteResult is one environment matched by a bulk delete.
+type BulkDeleteResult struct {
+	EnvironmentID string `json:"environmentId"`
+	Name          string `json:"name"`
+	Branch        string `json:"branch,omitempty"`
+	// Reason says why a skipped environment was not deleted.
+	Reason string `json:"reason,omitempty"`
+}
+
+// BulkDeleteResponse lists the environments a bulk delete deleted, or would
+// delete in a dry run, and those it skipped because they are protected,
+// locked or could not be deleted.
+type BulkDeleteResponse struct {
+	DryRun  bool               `json:"dryRun"`
+	Deleted []BulkDeleteResult `json:"deleted"`
+	Skipped []BulkDeleteResult `json:"skipped"`
+}
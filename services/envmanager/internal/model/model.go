@@ -1,19 +1,62 @@
 // Package model defines the core domain types for the Environment Manager service.
 package model
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/schemadiff"
+)
 
 // EnvironmentStatus represents the lifecycle state of an environment.
 type EnvironmentStatus string
 
 const (
-	StatusCreating EnvironmentStatus = "creating"
-	StatusReady    EnvironmentStatus = "ready"
-	StatusBuilding EnvironmentStatus = "building"
-	StatusFailed   EnvironmentStatus = "failed"
-	StatusDeleting EnvironmentStatus = "deleting"
+	StatusCreating  EnvironmentStatus = "creating"
+	StatusReady     EnvironmentStatus = "ready"
+	StatusBuilding  EnvironmentStatus = "building"
+	StatusDeploying EnvironmentStatus = "deploying"
+	StatusFailed    EnvironmentStatus = "failed"
+	StatusDeleting  EnvironmentStatus = "deleting"
+	// StatusSleeping is a deployed environment scaled to zero replicas.
+	StatusSleeping EnvironmentStatus = "sleeping"
+	// StatusDegraded is a deployed environment whose smoke tests failed.
+	StatusDegraded EnvironmentStatus = "degraded"
 )
 
+// WorkflowStage names one step of the asynchronous build/deploy workflow.
+type WorkflowStage string
+
+const (
+	// StageBuild triggers a build and waits for the builder to report success.
+	StageBuild WorkflowStage = "build"
+	// StageDeploy hands the finished build to the operator.
+	StageDeploy WorkflowStage = "deploy"
+	// StageRollout waits for the operator to report the environment as Running.
+	StageRollout WorkflowStage = "rollout"
+)
+
+// StageStatus is the state of a single workflow stage.
+type StageStatus string
+
+const (
+	StageStatusPending   StageStatus = "pending"
+	StageStatusRunning   StageStatus = "running"
+	StageStatusSucceeded StageStatus = "succeeded"
+	StageStatusFailed    StageStatus = "failed"
+)
+
+// StageProgress records the timing and outcome of one workflow stage.
+type StageProgress struct {
+	Stage       WorkflowStage `json:"stage"`
+	Status      StageStatus   `json:"status"`
+	StartedAt   *time.Time    `json:"startedAt,omitempty"`
+	CompletedAt *time.Time    `json:"completedAt,omitempty"`
+	Error       string        `json:"error,omitempty"`
+}
+
 // Environment represents an isolated fork of a base dependency tree
 // used for testing proposed changes (like Netlify preview deploys).
 type Environment struct {
@@ -23,19 +66,106 @@ type Environment struct {
 	BaseRootVersion string            `json:"baseRootVersion,omitempty"`
 	Branch          string            `json:"branch,omitempty"`
 	CreatedBy       string            `json:"createdBy,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
 	Status          EnvironmentStatus `json:"status"`
 	Overrides       []PackageOverride `json:"overrides,omitempty"`
 	CurrentBuildID  string            `json:"currentBuildId,omitempty"`
 	PreviewURL      string            `json:"previewUrl,omitempty"`
-	CreatedAt       time.Time         `json:"createdAt"`
-	UpdatedAt       time.Time         `json:"updatedAt"`
+	Progress        []StageProgress   `json:"progress,omitempty"`
+	LastError       string            `json:"lastError,omitempty"`
+	Pending         *PendingOperation `json:"pendingOperation,omitempty"`
+	// ExpiresAt, if set, is when the reaper will delete the environment.
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	// LastActivityAt is the last time the environment was built, changed or
+	// reported traffic. The reaper deletes environments idle for too long.
+	LastActivityAt time.Time `json:"lastActivityAt"`
+	// ParentID is the environment this one was forked from, if any.
+	ParentID string `json:"parentId,omitempty"`
+	// InheritedOverrides are the parent's overrides as of the fork or the
+	// last rebase. Overrides equal to one of them were inherited rather than
+	// set on this environment.
+	InheritedOverrides []PackageOverride `json:"inheritedOverrides,omitempty"`
+	// Template is the template the environment was created from, if any.
+	Template string `json:"template,omitempty"`
+	// BuildHistory lists the builds deployed to the environment, oldest
+	// first, capped at MaxBuildHistory entries.
+	BuildHistory []BuildRecord `json:"buildHistory,omitempty"`
+	// SleepSchedule, if set, decides when the environment sleeps.
+	SleepSchedule *SleepSchedule `json:"sleepSchedule,omitempty"`
+	// SleepReason says why a sleeping environment was put to sleep.
+	SleepReason SleepReason `json:"sleepReason,omitempty"`
+	// SmokeTests is the outcome of the smoke tests run after the latest
+	// deploy, if any ran.
+	SmokeTests *SmokeTestReport `json:"smokeTests,omitempty"`
+	// Lock, if held, reserves the environment for its owner.
+	Lock *Lock `json:"lock,omitempty"`
+	// Protected environments are never reaped or bulk deleted.
+	Protected bool      `json:"protected,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Clone returns a deep copy of the environment so callers can mutate it
+// without aliasing slices held by a store.
+func (e Environment) Clone() Environment {
+	e.Labels = maps.Clone(e.Labels)
+	e.Overrides = CloneOverrides(e.Overrides)
+	e.InheritedOverrides = CloneOverrides(e.InheritedOverrides)
+	if e.BuildHistory != nil {
+		history := make([]BuildRecord, len(e.BuildHistory))
+		for i, rec := range e.BuildHistory {
+			history[i] = rec.Clone()
+		}
+		e.BuildHistory = history
+	}
+	e.Progress = append([]StageProgress(nil), e.Progress...)
+	e.SleepSchedule = e.SleepSchedule.Clone()
+	e.SmokeTests = e.SmokeTests.Clone()
+	e.Lock = e.Lock.Clone()
+	if e.Pending != nil {
+		p := *e.Pending
+		e.Pending = &p
+	}
+	if e.ExpiresAt != nil {
+		t := *e.ExpiresAt
+		e.ExpiresAt = &t
+	}
+	return e
+}
+
+// LastActive returns when the environment was last active, falling back to
+// its creation time for environments stored before activity was tracked.
+func (e Environment) LastActive() time.Time {
+	if e.LastActivityAt.IsZero() {
+		return e.CreatedAt
+	}
+	return e.LastActivityAt
 }
 
 // PackageOverride specifies a package-level override within an environment.
-// For example, "use my modified schema for the users subgraph."
+// For example, "use my modified schema for the users subgraph", "pin the
+// orders subgraph to 2.1.0" or "run two replicas of the gateway".
 type PackageOverride struct {
 	PackageName string `json:"packageName"`
-	Schema      string `json:"schema,omitempty"`
+	// Schema replaces the package's schema. It may not be combined with Version.
+	Schema string `json:"schema,omitempty"`
+	// Version pins the package to a published registry version in place of
+	// the one the base tree resolves.
+	Version string `json:"version,omitempty"`
+	// UpstreamConfig replaces the package's upstream, for example to point a
+	// subgraph at a developer's own server.
+	UpstreamConfig *UpstreamConfig `json:"upstreamConfig,omitempty"`
+	// Runtime overrides how the package's component is run.
+	Runtime *RuntimeOverride `json:"runtime,omitempty"`
+}
+
+// RuntimeOverride adjusts a deployed component without changing its package.
+type RuntimeOverride struct {
+	Replicas *int32            `json:"replicas,omitempty"`
+	Env      map[string]string `json:"env,omitempty"`
+	// SecretEnv sets environment variables, by name, to the values of the
+	// environment's secrets, by name.
+	SecretEnv map[string]string `json:"secretEnv,omitempty"`
 }
 
 // CreateEnvironmentRequest is the request body for creating a new environment.
@@ -45,7 +175,51 @@ type CreateEnvironmentRequest struct {
 	BaseRootVersion string            `json:"baseRootVersion,omitempty"`
 	Branch          string            `json:"branch,omitempty"`
 	CreatedBy       string            `json:"createdBy,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
 	Overrides       []PackageOverride `json:"overrides,omitempty"`
+	// ParentID forks the new environment from an existing one: it inherits
+	// the parent's base and overrides, with Overrides applied on top, and
+	// reuses the parent's current build if it adds no overrides of its own.
+	ParentID string `json:"parentId,omitempty"`
+	// Template creates the environment from the named template: it starts
+	// with the template's base, overrides, labels, TTL and sleep schedule,
+	// with the request's own applied on top. It may not be combined with
+	// ParentID.
+	Template string `json:"template,omitempty"`
+	// ExpiresAt and TTL optionally bound the environment's lifetime. At most
+	// one may be set; TTL is a Go duration such as "72h".
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	TTL       string     `json:"ttl,omitempty"`
+	// SleepSchedule, if set, scales the environment to zero outside its
+	// awake hours or once it is idle.
+	SleepSchedule *SleepSchedule `json:"sleepSchedule,omitempty"`
+	// Secrets sets the environment's secrets, by name, so that Overrides
+	// can reference them. A fork also inherits its parent's secrets.
+	Secrets map[string]string `json:"secrets,omitempty"`
+	// Protected keeps the environment from being reaped or bulk deleted.
+	Protected bool `json:"protected,omitempty"`
+}
+
+// Expiry returns the absolute expiry time requested, if any, relative to now.
+func (r CreateEnvironmentRequest) Expiry(now time.Time) (*time.Time, error) {
+	switch {
+	case r.ExpiresAt != nil && r.TTL != "":
+		return nil, errors.New("only one of expiresAt and ttl may be set")
+	case r.ExpiresAt != nil:
+		if !r.ExpiresAt.After(now) {
+			return nil, errors.New("expiresAt must be in the future")
+		}
+		t := r.ExpiresAt.UTC()
+		return &t, nil
+	case r.TTL != "":
+		ttl, err := time.ParseDuration(r.TTL)
+		if err != nil || ttl <= 0 {
+			return nil, fmt.Errorf("invalid ttl %q: must be a positive duration such as \"72h\"", r.TTL)
+		}
+		t := now.Add(ttl).UTC()
+		return &t, nil
+	}
+	return nil, nil
 }
 
 // ApplyOverridesRequest is the request body for applying overrides to an environment.
@@ -54,6 +228,18 @@ type ApplyOverridesRequest struct {
 	TriggerBuild bool              `json:"triggerBuild"`
 }
 
+// UpdateEnvironmentRequest is the request body for PATCH
+// /v1/environments/{id}. Labels are merged into the environment's labels as
+// in a JSON merge patch: a null value removes the label.
+type UpdateEnvironmentRequest struct {
+	Labels map[string]*string `json:"labels,omitempty"`
+	// SleepSchedule replaces the environment's schedule. An empty schedule
+	// removes it.
+	SleepSchedule *SleepSchedule `json:"sleepSchedule,omitempty"`
+	// Protected, if set, protects or unprotects the environment.
+	Protected *bool `json:"protected,omitempty"`
+}
+
 // ListEnvironmentsResponse is the response for listing environments.
 type ListEnvironmentsResponse struct {
 	Environments  []Environment `json:"environments"`
@@ -64,9 +250,192 @@ type ListEnvironmentsResponse struct {
 type PromotedPackage struct {
 	Name    string `json:"name"`
 	Version string `json:"version"`
+	// PreviousVersion is the base version the promoted version replaces.
+	PreviousVersion string `json:"previousVersion,omitempty"`
+	// Breaking is set when the promoted version contains breaking changes
+	// (and was therefore given a new major version).
+	Breaking bool `json:"breaking"`
 }
 
 // PromoteResponse is the response for promoting environment overrides to the base.
 type PromoteResponse struct {
 	PromotedPackages []PromotedPackage `json:"promotedPackages"`
+	// RootPackage is the new version of the environment's base root package,
+	// which pins the promoted packages.
+	RootPackage PromotedPackage `json:"rootPackage"`
+}
+
+// Package mirrors the Registry service's package version.
+type Package struct {
+	ID             string            `json:"id,omitempty"`
+	Name           string            `json:"name"`
+	Namespace      string            `json:"namespace,omitempty"`
+	Kind           string            `json:"kind"`
+	Version        string            `json:"version"`
+	Schema         string            `json:"schema"`
+	UpstreamConfig *UpstreamConfig   `json:"upstreamConfig,omitempty"`
+	Dependencies   []Dependency      `json:"dependencies,omitempty"`
+	Metadata       map[string]string `json:"metadata,omitempty"`
+	Yanked         bool              `json:"yanked,omitempty"`
+}
+
+// Dependency is a registry package dependency. The registry resolves
+// VersionConstraint as an exact version.
+type Dependency struct {
+	PackageName       string `json:"packageName"`
+	VersionConstraint string `json:"versionConstraint"`
+}
+
+// UpstreamConfig holds the upstream URL and headers for a package.
+type UpstreamConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	// Auth, if set, authenticates to the upstream with the environment's
+	// secrets.
+	Auth *UpstreamAuth `json:"auth,omitempty"`
+}
+
+// PackageDiffStatus says how a package differs between an environment and
+// its base.
+type PackageDiffStatus string
+
+const (
+	PackageAdded   PackageDiffStatus = "added"
+	PackageRemoved PackageDiffStatus = "removed"
+	PackageChanged PackageDiffStatus = "changed"
+)
+
+// PackageDiff is the difference in one package between an environment and
+// its base.
+type PackageDiff struct {
+	Name        string            `json:"name"`
+	Kind        string            `json:"kind,omitempty"`
+	Status      PackageDiffStatus `json:"status"`
+	BaseVersion string            `json:"baseVersion,omitempty"`
+	// Version is the version the environment pins, if it differs from
+	// BaseVersion.
+	Version string `json:"version,omitempty"`
+	// UpstreamConfig is the environment's upstream, if it differs from the
+	// base's.
+	UpstreamConfig *UpstreamConfig `json:"upstreamConfig,omitempty"`
+	// Breaking is set if any schema or dependency change would break clients.
+	Breaking            bool                `json:"breaking"`
+	SchemaChanges       []schemadiff.Change `json:"schemaChanges"`
+	AddedDependencies   []Dependency        `json:"addedDependencies,omitempty"`
+	RemovedDependencies []Dependency        `json:"removedDependencies,omitempty"`
+}
+
+// EnvironmentDiff is the response for diffing an environment against its base.
+type EnvironmentDiff struct {
+	EnvironmentID   string        `json:"environmentId"`
+	BaseRootPackage string        `json:"baseRootPackage"`
+	BaseRootVersion string        `json:"baseRootVersion"`
+	Breaking        bool          `json:"breaking"`
+	Packages        []PackageDiff `json:"packages"`
+}
+
+// ReapReason explains why an environment is due to be reaped.
+type ReapReason string
+
+const (
+	// ReapReasonExpired means the environment is past its ExpiresAt.
+	ReapReasonExpired ReapReason = "expired"
+	// ReapReasonIdle means the environment has had no activity for longer
+	// than the configured idle timeout.
+	ReapReasonIdle ReapReason = "idle"
+)
+
+// ReapCandidate is an environment the reaper has selected for deletion.
+type ReapCandidate struct {
+	EnvironmentID  string     `json:"environmentId"`
+	Name           string     `json:"name"`
+	Branch         string     `json:"branch,omitempty"`
+	Reason         ReapReason `json:"reason"`
+	ExpiresAt      *time.Time `json:"expiresAt,omitempty"`
+	LastActivityAt time.Time  `json:"lastActivityAt"`
+}
+
+// ReapResponse lists the environments reaped, or that would be reaped in a
+// dry run.
+type ReapResponse struct {
+	DryRun       bool            `json:"dryRun"`
+	Environments []ReapCandidate `json:"environments"`
+}
+
+// RebaseRequest is the request body for rebasing an environment onto its
+// parent's current base and overrides.
+type RebaseRequest struct {
+	TriggerBuild bool `json:"triggerBuild"`
+}
+
+// RollbackRequest is the body of POST /v1/environments/{id}/rollback.
+type RollbackRequest struct {
+	// BuildID is the build to redeploy. If empty, the newest successful build
+	// other than the current one is used.
+	BuildID string `json:"buildId,omitempty"`
+}
+
+// ListBuildsResponse is the response of GET /v1/environments/{id}/builds.
+type ListBuildsResponse struct {
+	// Builds are newest first.
+	Builds []BuildRecord `json:"builds"`
+}
+
+// EnvironmentRef identifies a related environment in a lineage.
+type EnvironmentRef struct {
+	ID     string            `json:"id"`
+	Name   string            `json:"name"`
+	Status EnvironmentStatus `json:"status"`
+	// Stale is set when the environment's parent has changed its base or
+	// overrides since the environment was forked or last rebased.
+	Stale bool `json:"stale"`
+}
+
+// Lineage is the response describing where an environment was forked from
+// and which environments have been forked from it.
+type Lineage struct {
+	EnvironmentID string `json:"environmentId"`
+	ParentID      string `json:"parentId,omitempty"`
+	// Stale is set when the parent has changed since the fork or last rebase.
+	Stale bool `json:"stale"`
+	// Ancestors lists the parent first, then its parent, and so on. The list
+	// ends early at an ancestor that has since been deleted.
+	Ancestors []EnvironmentRef `json:"ancestors"`
+	Children  []EnvironmentRef `json:"children"`
+}
+
+// BuildStatus mirrors the Builder service's build status values.
+type BuildStatus string
+
+const (
+	BuildStatusPending   BuildStatus = "pending"
+	BuildStatusRunning   BuildStatus = "running"
+	BuildStatusSucceeded BuildStatus = "succeeded"
+	BuildStatusFailed    BuildStatus = "failed"
+)
+
+// Build is the envmanager's view of a build running in the Builder service.
+type Build struct {
+	ID           string      `json:"id"`
+	Status       BuildStatus `json:"status"`
+	ErrorMessage string      `json:"errorMessage,omitempty"`
+}
+
+// DeploymentPhase mirrors the Operator's APIGraphStatus phase values.
+type DeploymentPhase string
+
+const (
+	DeploymentPhasePending   DeploymentPhase = "Pending"
+	DeploymentPhaseDeploying DeploymentPhase = "Deploying"
+	DeploymentPhaseRunning   DeploymentPhase = "Running"
+	DeploymentPhaseDegraded  DeploymentPhase = "Degraded"
+	DeploymentPhaseFailed    DeploymentPhase = "Failed"
+	DeploymentPhaseSleeping  DeploymentPhase = "Sleeping"
+)
+
+// DeploymentStatus is the Operator's observed state for an environment.
+type DeploymentStatus struct {
+	Phase      DeploymentPhase `json:"phase"`
+	PreviewURL string          `json:"previewUrl,omitempty"`
+	Message    string          `json:"message,omitempty"`
 }
@@ -0,0 +1,152 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+)
+
+// ErrInvalidOverride is returned when a package override is malformed or
+// refers to a package version that cannot be used.
+var ErrInvalidOverride = errors.New("invalid package override")
+
+// Clone returns a deep copy of the override.
+func (p PackageOverride) Clone() PackageOverride {
+	if p.UpstreamConfig != nil {
+		u := *p.UpstreamConfig
+		u.Headers = maps.Clone(u.Headers)
+		if u.Auth != nil {
+			a := *u.Auth
+			a.Scopes = slices.Clone(a.Scopes)
+			u.Auth = &a
+		}
+		p.UpstreamConfig = &u
+	}
+	if p.Runtime != nil {
+		r := *p.Runtime
+		if r.Replicas != nil {
+			n := *r.Replicas
+			r.Replicas = &n
+		}
+		r.Env = maps.Clone(r.Env)
+		r.SecretEnv = maps.Clone(r.SecretEnv)
+		p.Runtime = &r
+	}
+	return p
+}
+
+// CloneOverrides returns a deep copy of overrides.
+func CloneOverrides(overrides []PackageOverride) []PackageOverride {
+	if overrides == nil {
+		return nil
+	}
+	out := make([]PackageOverride, len(overrides))
+	for i, o := range overrides {
+		out[i] = o.Clone()
+	}
+	return out
+}
+
+// Equal reports whether two overrides make the same change.
+func (p PackageOverride) Equal(q PackageOverride) bool {
+	if p.PackageName != q.PackageName || p.Schema != q.Schema || p.Version != q.Version {
+		return false
+	}
+	if (p.UpstreamConfig == nil) != (q.UpstreamConfig == nil) || (p.Runtime == nil) != (q.Runtime == nil) {
+		return false
+	}
+	if p.UpstreamConfig != nil && !p.UpstreamConfig.Equal(q.UpstreamConfig) {
+		return false
+	}
+	if p.Runtime != nil {
+		pr, qr := p.Runtime.Replicas, q.Runtime.Replicas
+		if (pr == nil) != (qr == nil) || (pr != nil && *pr != *qr) || !maps.Equal(p.Runtime.Env, q.Runtime.Env) ||
+			!maps.Equal(p.Runtime.SecretEnv, q.Runtime.SecretEnv) {
+			return false
+		}
+	}
+	return true
+}
+
+// ChangesPackage reports whether the override replaces the package itself,
+// by schema or version, as opposed to only how it is reached or run.
+func (p PackageOverride) ChangesPackage() bool {
+	return p.Schema != "" || p.Version != ""
+}
+
+// Validate checks the override on its own, without consulting the registry.
+func (p PackageOverride) Validate() error {
+	if p.PackageName == "" {
+		return fmt.Errorf("%w: packageName is required", ErrInvalidOverride)
+	}
+	if p.Schema != "" && p.Version != "" {
+		return fmt.Errorf("%w: %s: only one of schema and version may be set", ErrInvalidOverride, p.PackageName)
+	}
+	if p.UpstreamConfig != nil {
+		u, err := url.Parse(p.UpstreamConfig.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%w: %s: upstream url %q must be an absolute http or https URL",
+				ErrInvalidOverride, p.PackageName, p.UpstreamConfig.URL)
+		}
+		if err := p.UpstreamConfig.Auth.Validate(); err != nil {
+			return fmt.Errorf("%w: %s: %w", ErrInvalidOverride, p.PackageName, err)
+		}
+	}
+	if p.Runtime != nil && p.Runtime.Replicas != nil && *p.Runtime.Replicas < 0 {
+		return fmt.Errorf("%w: %s: replicas must not be negative", ErrInvalidOverride, p.PackageName)
+	}
+	if p.Runtime != nil {
+		for env, secret := range p.Runtime.SecretEnv {
+			if env == "" {
+				return fmt.Errorf("%w: %s: secretEnv variable names must not be empty", ErrInvalidOverride, p.PackageName)
+			}
+			if err := ValidateSecretName(secret); err != nil {
+				return fmt.Errorf("%w: %s: secretEnv %s: %w", ErrInvalidOverride, p.PackageName, env, err)
+			}
+		}
+	}
+	return nil
+}
+
+// SecretRefs returns the names of the secrets the override references,
+// sorted and without duplicates.
+func (p PackageOverride) SecretRefs() []string {
+	var refs []string
+	if p.UpstreamConfig != nil && p.UpstreamConfig.Auth != nil {
+		refs = append(refs, p.UpstreamConfig.Auth.secretRefs()...)
+	}
+	if p.Runtime != nil {
+		refs = append(refs, slices.Collect(maps.Values(p.Runtime.SecretEnv))...)
+	}
+	slices.Sort(refs)
+	return slices.Compact(refs)
+}
+
+// SecretRefs returns the names of the secrets any of overrides reference,
+// sorted and without duplicates.
+func SecretRefs(overrides []PackageOverride) []string {
+	var refs []string
+	for _, o := range overrides {
+		refs = append(refs, o.SecretRefs()...)
+	}
+	slices.Sort(refs)
+	return slices.Compact(refs)
+}
+
+// ValidateOverrides validates each override and rejects more than one
+// override for the same package.
+func ValidateOverrides(overrides []PackageOverride) error {
+	seen := make(map[string]bool, len(overrides))
+	for _, o := range overrides {
+		if err := o.Validate(); err != nil {
+			return err
+		}
+		if seen[o.PackageName] {
+			return fmt.Errorf("%w: %s: overridden more than once", ErrInvalidOverride, o.PackageName)
+		}
+		seen[o.PackageName] = true
+	}
+	return nil
+}
@@ -0,0 +1,59 @@
+package model
+
+// TeamLabel is the label that assigns an environment to a team for quotas.
+const TeamLabel = "team"
+
+// Quotas limits what users and teams may deploy. A zero field is unlimited.
+type Quotas struct {
+	// MaxEnvironmentsPerUser caps the environments one CreatedBy may have
+	// at once. Environments being deleted do not count.
+	MaxEnvironmentsPerUser int `json:"maxEnvironmentsPerUser,omitempty"`
+	// MaxEnvironmentsPerTeam caps the environments with the same TeamLabel.
+	MaxEnvironmentsPerTeam int `json:"maxEnvironmentsPerTeam,omitempty"`
+	// MaxComponents caps the components deployed for one environment.
+	MaxComponents int `json:"maxComponents,omitempty"`
+	// MaxReplicas caps the replicas of all of one environment's components.
+	MaxReplicas int `json:"maxReplicas,omitempty"`
+}
+
+// Footprint is what an environment deploys.
+type Footprint struct {
+	Components int `json:"components"`
+	Replicas   int `json:"replicas"`
+}
+
+// FootprintOf computes the footprint of an environment based on rootPackage
+// with overrides, the same way the builder assembles its graph: one component
+// for the root and one for each other overridden package, each with one
+// replica unless an override says otherwise.
+func FootprintOf(rootPackage string, overrides []PackageOverride) Footprint {
+	replicas := map[string]int{rootPackage: 1}
+	for _, o := range overrides {
+		if _, ok := replicas[o.PackageName]; !ok {
+			replicas[o.PackageName] = 1
+		}
+		if o.Runtime != nil && o.Runtime.Replicas != nil {
+			replicas[o.PackageName] = int(*o.Runtime.Replicas)
+		}
+	}
+	f := Footprint{Components: len(replicas)}
+	for _, n := range replicas {
+		f.Replicas += n
+	}
+	return f
+}
+
+// EnvironmentUsage is how many environments a user or team has against its
+// limit. A zero Limit is unlimited.
+type EnvironmentUsage struct {
+	Name         string `json:"name"`
+	Environments int    `json:"environments"`
+	Limit        int    `json:"limit,omitempty"`
+}
+
+// UsageResponse is the response of GET /v1/usage.
+type UsageResponse struct {
+	User   *EnvironmentUsage `json:"user,omitempty"`
+	Team   *EnvironmentUsage `json:"team,omitempty"`
+	Quotas Quotas            `json:"quotas"`
+}
@@ -0,0 +1,159 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// ErrInvalidSecret is returned for a secret with a malformed name or value.
+var ErrInvalidSecret = errors.New("invalid secret")
+
+// maxSecretName is the longest secret name allowed, so that every name can
+// be used as a Kubernetes Secret key.
+const maxSecretName = 253
+
+// ValidateSecretName checks that name can be used as a Kubernetes Secret key:
+// letters, digits, '-', '_' and '.', and not "." or "..".
+func ValidateSecretName(name string) error {
+	if name == "" || len(name) > maxSecretName || name == "." || name == ".." {
+		return fmt.Errorf("%w: name %q must be 1-%d characters and not . or ..", ErrInvalidSecret, name, maxSecretName)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return fmt.Errorf("%w: name %q may only contain letters, digits, '-', '_' and '.'", ErrInvalidSecret, name)
+		}
+	}
+	return nil
+}
+
+// Secret is an environment secret as stored: its value is only ever held
+// encrypted, and is never returned by the API.
+type Secret struct {
+	Name       string    `json:"name"`
+	Ciphertext string    `json:"ciphertext"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+}
+
+// Metadata describes the secret without its value.
+func (s Secret) Metadata() SecretMetadata {
+	return SecretMetadata{Name: s.Name, CreatedAt: s.CreatedAt, UpdatedAt: s.UpdatedAt}
+}
+
+// SecretMetadata is what the API returns for a secret.
+type SecretMetadata struct {
+	Name      string    `json:"name"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// SetSecretRequest is the request body for setting a secret.
+type SetSecretRequest struct {
+	Value string `json:"value"`
+}
+
+// ListSecretsResponse lists an environment's secrets, by name.
+type ListSecretsResponse struct {
+	Secrets []SecretMetadata `json:"secrets"`
+}
+
+// UpstreamAuthType is how a component authenticates to its upstream.
+type UpstreamAuthType string
+
+const (
+	UpstreamAuthBearer                  UpstreamAuthType = "bearer"
+	UpstreamAuthOAuth2ClientCredentials UpstreamAuthType = "oauth2-client-credentials"
+	UpstreamAuthMTLS                    UpstreamAuthType = "mtls"
+)
+
+// UpstreamAuth authenticates a component to its upstream, mirroring a
+// package manifest's upstream auth. Each *SecretRef names one of the
+// environment's secrets.
+type UpstreamAuth struct {
+	Type UpstreamAuthType `json:"type"`
+
+	// TokenSecretRef is the bearer token.
+	TokenSecretRef string `json:"tokenSecretRef,omitempty"`
+
+	// TokenEndpoint, ClientIDSecretRef, ClientSecretSecretRef and Scopes
+	// configure an OAuth2 client credentials grant.
+	TokenEndpoint         string   `json:"tokenEndpoint,omitempty"`
+	ClientIDSecretRef     string   `json:"clientIdSecretRef,omitempty"`
+	ClientSecretSecretRef string   `json:"clientSecretSecretRef,omitempty"`
+	Scopes                []string `json:"scopes,omitempty"`
+
+	// CertSecretRef, KeySecretRef and the optional CASecretRef are the
+	// client certificate, its key and the CA bundle to trust for mTLS.
+	CertSecretRef string `json:"certSecretRef,omitempty"`
+	KeySecretRef  string `json:"keySecretRef,omitempty"`
+	CASecretRef   string `json:"caSecretRef,omitempty"`
+}
+
+// Validate checks that the fields a's type needs are set and that it
+// references secrets by valid names. A nil UpstreamAuth is valid.
+func (a *UpstreamAuth) Validate() error {
+	if a == nil {
+		return nil
+	}
+	var required map[string]string
+	switch a.Type {
+	case UpstreamAuthBearer:
+		required = map[string]string{"tokenSecretRef": a.TokenSecretRef}
+	case UpstreamAuthOAuth2ClientCredentials:
+		required = map[string]string{
+			"clientIdSecretRef":     a.ClientIDSecretRef,
+			"clientSecretSecretRef": a.ClientSecretSecretRef,
+		}
+		if u, err := url.Parse(a.TokenEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("auth tokenEndpoint %q must be an absolute http or https URL", a.TokenEndpoint)
+		}
+	case UpstreamAuthMTLS:
+		required = map[string]string{"certSecretRef": a.CertSecretRef, "keySecretRef": a.KeySecretRef}
+	default:
+		return fmt.Errorf("auth type %q must be one of bearer, oauth2-client-credentials or mtls", a.Type)
+	}
+	for _, field := range slices.Sorted(maps.Keys(required)) {
+		if required[field] == "" {
+			return fmt.Errorf("%s auth requires %s", a.Type, field)
+		}
+	}
+	for _, ref := range a.secretRefs() {
+		if err := ValidateSecretName(ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// secretRefs returns the secrets a references.
+func (a *UpstreamAuth) secretRefs() []string {
+	var refs []string
+	for _, ref := range []string{a.TokenSecretRef, a.ClientIDSecretRef, a.ClientSecretSecretRef, a.CertSecretRef, a.KeySecretRef, a.CASecretRef} {
+		if ref != "" {
+			refs = append(refs, ref)
+		}
+	}
+	return refs
+}
+
+// Equal reports whether two upstream configs are the same.
+func (u *UpstreamConfig) Equal(v *UpstreamConfig) bool {
+	if u == nil || v == nil {
+		return u == v
+	}
+	if u.URL != v.URL || !maps.Equal(u.Headers, v.Headers) || (u.Auth == nil) != (v.Auth == nil) {
+		return false
+	}
+	if u.Auth == nil {
+		return true
+	}
+	a, b := u.Auth, v.Auth
+	return a.Type == b.Type && a.TokenSecretRef == b.TokenSecretRef &&
+		a.TokenEndpoint == b.TokenEndpoint && a.ClientIDSecretRef == b.ClientIDSecretRef &&
+		a.ClientSecretSecretRef == b.ClientSecretSecretRef && slices.Equal(a.Scopes, b.Scopes) &&
+		a.CertSecretRef == b.CertSecretRef && a.KeySecretRef == b.KeySecretRef && a.CASecretRef == b.CASecretRef
+}
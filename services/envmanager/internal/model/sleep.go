@@ -0,0 +1,173 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
+// ErrInvalidSchedule is returned for a sleep schedule that does not parse.
+var ErrInvalidSchedule = errors.New("invalid sleep schedule")
+
+// SleepReason records why an environment was put to sleep.
+type SleepReason string
+
+const (
+	// SleepReasonManual is a sleep requested through the API.
+	SleepReasonManual SleepReason = "manual"
+	// SleepReasonSchedule is a sleep outside the schedule's awake hours. The
+	// scheduler wakes the environment when its awake hours begin again.
+	SleepReasonSchedule SleepReason = "schedule"
+	// SleepReasonIdle is a sleep after the environment saw no activity.
+	SleepReasonIdle SleepReason = "idle"
+)
+
+// SleepSchedule decides when an environment's components are scaled to zero.
+// Awake hours put it to sleep outside a daily window; IdleAfter puts it to
+// sleep once it has been idle that long. Either may be used alone.
+type SleepSchedule struct {
+	// AwakeFrom and AwakeUntil bound the daily awake window as "HH:MM" in
+	// Timezone. Both or neither must be set.
+	AwakeFrom  string `json:"awakeFrom,omitempty"`
+	AwakeUntil string `json:"awakeUntil,omitempty"`
+	// Days are the days the window applies, as "mon" through "sun". The
+	// environment sleeps all day on other days. Empty means every day.
+	Days []string `json:"days,omitempty"`
+	// Timezone is an IANA time zone name. Empty means UTC.
+	Timezone string `json:"timezone,omitempty"`
+	// IdleAfter is a Go duration such as "2h".
+	IdleAfter string `json:"idleAfter,omitempty"`
+}
+
+// Clone returns a deep copy of the schedule.
+func (s *SleepSchedule) Clone() *SleepSchedule {
+	if s == nil {
+		return nil
+	}
+	c := *s
+	c.Days = slices.Clone(s.Days)
+	return &c
+}
+
+// IsZero reports whether the schedule never puts the environment to sleep.
+func (s *SleepSchedule) IsZero() bool {
+	return s == nil || (s.AwakeFrom == "" && s.AwakeUntil == "" && s.IdleAfter == "")
+}
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// Validate checks that every field of the schedule parses.
+func (s *SleepSchedule) Validate() error {
+	if s == nil {
+		return nil
+	}
+	if (s.AwakeFrom == "") != (s.AwakeUntil == "") {
+		return fmt.Errorf("%w: awakeFrom and awakeUntil must be set together", ErrInvalidSchedule)
+	}
+	if s.AwakeFrom != "" {
+		from, err := clock(s.AwakeFrom)
+		if err != nil {
+			return err
+		}
+		until, err := clock(s.AwakeUntil)
+		if err != nil {
+			return err
+		}
+		if until <= from {
+			return fmt.Errorf("%w: awakeUntil must be later than awakeFrom", ErrInvalidSchedule)
+		}
+	}
+	for _, d := range s.Days {
+		if _, ok := weekdays[d]; !ok {
+			return fmt.Errorf("%w: unknown day %q, expected mon through sun", ErrInvalidSchedule, d)
+		}
+	}
+	if _, err := time.LoadLocation(s.Timezone); err != nil {
+		return fmt.Errorf("%w: unknown timezone %q", ErrInvalidSchedule, s.Timezone)
+	}
+	if _, err := s.idleAfter(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// IdleTimeout returns the schedule's idle timeout, or zero if it has none.
+// The schedule must be valid.
+func (s *SleepSchedule) IdleTimeout() time.Duration {
+	d, _ := s.idleAfter()
+	return d
+}
+
+func (s *SleepSchedule) idleAfter() (time.Duration, error) {
+	if s == nil || s.IdleAfter == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s.IdleAfter)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("%w: idleAfter %q must be a positive duration such as \"2h\"", ErrInvalidSchedule, s.IdleAfter)
+	}
+	return d, nil
+}
+
+// OffHoursSince reports whether t is outside the schedule's awake hours and,
+// if so, when those off hours began. A schedule without awake hours is never
+// off hours. The schedule must be valid.
+func (s *SleepSchedule) OffHoursSince(t time.Time) (time.Time, bool) {
+	if s == nil || s.AwakeFrom == "" {
+		return time.Time{}, false
+	}
+	loc, _ := time.LoadLocation(s.Timezone)
+	from, _ := clock(s.AwakeFrom)
+	until, _ := clock(s.AwakeUntil)
+
+	t = t.In(loc)
+	// at is the wall clock time offset from midnight on day, which differs
+	// from midnight plus offset on days the clocks change.
+	at := func(day time.Time, offset time.Duration) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(),
+			int(offset/time.Hour), int(offset%time.Hour/time.Minute), 0, 0, loc)
+	}
+	// Walk back through the last week to the most recent awake window that
+	// has started.
+	for i := 0; i <= 7; i++ {
+		day := t.AddDate(0, 0, -i)
+		if !s.awakeOn(day.Weekday()) {
+			continue
+		}
+		start, end := at(day, from), at(day, until)
+		if t.Before(start) {
+			continue
+		}
+		if t.Before(end) {
+			return time.Time{}, false
+		}
+		return end, true
+	}
+	// No awake day in the last week; the environment has always been off.
+	return time.Time{}, true
+}
+
+func (s *SleepSchedule) awakeOn(d time.Weekday) bool {
+	if len(s.Days) == 0 {
+		return true
+	}
+	for _, name := range s.Days {
+		if weekdays[name] == d {
+			return true
+		}
+	}
+	return false
+}
+
+// clock parses "HH:MM" as an offset from midnight.
+func clock(s string) (time.Duration, error) {
+	t, err := time.Parse("15:04", s)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q is not a time of day such as \"08:30\"", ErrInvalidSchedule, s)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
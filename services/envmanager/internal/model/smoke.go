@@ -0,0 +1,83 @@
+package model
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// Package kinds that are run as smoke tests after a deploy.
+const (
+	KindPostmanCollection = "postman-collection"
+	KindGraphQLOperations = "graphql-operations"
+)
+
+// IsSmokeTestKind reports whether packages of kind are run as smoke tests.
+func IsSmokeTestKind(kind string) bool {
+	return kind == KindPostmanCollection || kind == KindGraphQLOperations
+}
+
+// SmokeTestOutcome is the result of one smoke test request.
+type SmokeTestOutcome string
+
+const (
+	SmokeTestPassed  SmokeTestOutcome = "passed"
+	SmokeTestFailed  SmokeTestOutcome = "failed"
+	SmokeTestSkipped SmokeTestOutcome = "skipped"
+)
+
+// SmokeTestResult is the outcome of one request from a postman-collection
+// or one operation from a graphql-operations package.
+type SmokeTestResult struct {
+	// Package is the package the request came from.
+	Package string           `json:"package"`
+	Name    string           `json:"name"`
+	Method  string           `json:"method,omitempty"`
+	URL     string           `json:"url,omitempty"`
+	Outcome SmokeTestOutcome `json:"outcome"`
+	// StatusCode is the HTTP status of the response, if one arrived.
+	StatusCode int `json:"statusCode,omitempty"`
+	// Error says why the request failed or was skipped.
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"durationMs"`
+}
+
+// SmokeTestReport is the outcome of running an environment's smoke tests
+// against its preview URL after a deploy.
+type SmokeTestReport struct {
+	BuildID     string            `json:"buildId,omitempty"`
+	Passed      int               `json:"passed"`
+	Failed      int               `json:"failed"`
+	Skipped     int               `json:"skipped"`
+	Results     []SmokeTestResult `json:"results"`
+	StartedAt   time.Time         `json:"startedAt"`
+	CompletedAt time.Time         `json:"completedAt"`
+}
+
+// Add appends a result and counts its outcome.
+func (r *SmokeTestReport) Add(res SmokeTestResult) {
+	switch res.Outcome {
+	case SmokeTestPassed:
+		r.Passed++
+	case SmokeTestFailed:
+		r.Failed++
+	case SmokeTestSkipped:
+		r.Skipped++
+	}
+	r.Results = append(r.Results, res)
+}
+
+// Summary describes the counts, e.g. "3 passed, 1 failed, 0 skipped".
+func (r *SmokeTestReport) Summary() string {
+	return fmt.Sprintf("%d passed, %d failed, %d skipped", r.Passed, r.Failed, r.Skipped)
+}
+
+// Clone returns a deep copy of the report.
+func (r *SmokeTestReport) Clone() *SmokeTestReport {
+	if r == nil {
+		return nil
+	}
+	c := *r
+	c.Results = slices.Clone(r.Results)
+	return &c
+}
@@ -0,0 +1,105 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"time"
+)
+
+// ErrInvalidTemplate is returned for a malformed template, or for an
+// environment that cannot be created from the template it names.
+var ErrInvalidTemplate = errors.New("invalid template")
+
+// maxTemplateName is the longest template name allowed, so that every name
+// is a valid DNS label.
+const maxTemplateName = 63
+
+// ValidateTemplateName checks that name is a DNS label: lowercase letters,
+// digits and '-', not starting or ending with '-'.
+func ValidateTemplateName(name string) error {
+	if name == "" || len(name) > maxTemplateName || name[0] == '-' || name[len(name)-1] == '-' {
+		return fmt.Errorf("%w: name %q must be 1-%d characters and not start or end with '-'", ErrInvalidTemplate, name, maxTemplateName)
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-') {
+			return fmt.Errorf("%w: name %q may only contain lowercase letters, digits and '-'", ErrInvalidTemplate, name)
+		}
+	}
+	return nil
+}
+
+// TemplateSpec is what an environment created from a template starts with.
+// It is also the request body for creating or replacing a template.
+type TemplateSpec struct {
+	Description     string            `json:"description,omitempty"`
+	BaseRootPackage string            `json:"baseRootPackage"`
+	BaseRootVersion string            `json:"baseRootVersion,omitempty"`
+	Overrides       []PackageOverride `json:"overrides,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	// TTL is the lifetime of environments created from the template, as a
+	// Go duration such as "72h", unless the create request sets its own.
+	TTL           string         `json:"ttl,omitempty"`
+	SleepSchedule *SleepSchedule `json:"sleepSchedule,omitempty"`
+	// Quotas, if set, limit the environments created from the template on
+	// top of the service-wide quotas.
+	Quotas *TemplateQuotas `json:"quotas,omitempty"`
+}
+
+// TemplateQuotas limits the environments created from a template. A zero
+// field is unlimited.
+type TemplateQuotas struct {
+	// MaxEnvironments caps how many environments created from the template
+	// may exist at once. Environments being deleted do not count.
+	MaxEnvironments int `json:"maxEnvironments,omitempty"`
+	// MaxComponents and MaxReplicas cap what one environment created from
+	// the template may deploy.
+	MaxComponents int `json:"maxComponents,omitempty"`
+	MaxReplicas   int `json:"maxReplicas,omitempty"`
+}
+
+// Validate checks the parts of s that need no other service: that it has a
+// base, and that its TTL, sleep schedule and quotas are well formed.
+func (s TemplateSpec) Validate() error {
+	if s.BaseRootPackage == "" {
+		return fmt.Errorf("%w: baseRootPackage is required", ErrInvalidTemplate)
+	}
+	if s.TTL != "" {
+		if ttl, err := time.ParseDuration(s.TTL); err != nil || ttl <= 0 {
+			return fmt.Errorf("%w: ttl %q must be a positive duration such as \"72h\"", ErrInvalidTemplate, s.TTL)
+		}
+	}
+	if err := s.SleepSchedule.Validate(); err != nil {
+		return err
+	}
+	if q := s.Quotas; q != nil && (q.MaxEnvironments < 0 || q.MaxComponents < 0 || q.MaxReplicas < 0) {
+		return fmt.Errorf("%w: quotas must not be negative", ErrInvalidTemplate)
+	}
+	return nil
+}
+
+// Template is a named, reusable starting point for environments.
+type Template struct {
+	Name string `json:"name"`
+	TemplateSpec
+	CreatedBy string    `json:"createdBy,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// Clone returns a deep copy of the template.
+func (t Template) Clone() Template {
+	t.Overrides = CloneOverrides(t.Overrides)
+	t.Labels = maps.Clone(t.Labels)
+	t.SleepSchedule = t.SleepSchedule.Clone()
+	if t.Quotas != nil {
+		q := *t.Quotas
+		t.Quotas = &q
+	}
+	return t
+}
+
+// ListTemplatesResponse lists templates, sorted by name.
+type ListTemplatesResponse struct {
+	Templates []Template `json:"templates"`
+}
@@ -0,0 +1,169 @@
+// Package operator is an HTTP client for the Operator service.
+package operator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+// ErrNotFound is returned when the operator is not tracking an environment.
+var ErrNotFound = errors.New("environment not found by operator")
+
+// GraphSource returns the deployable graph a build produced. In production
+// this is the Builder service.
+type GraphSource interface {
+	GetGraph(ctx context.Context, buildID string) (json.RawMessage, error)
+}
+
+// Client talks to the Operator service's REST API.
+type Client struct {
+	baseURL    string
+	graphs     GraphSource
+	httpClient *http.Client
+}
+
+// New creates a Client for the operator at baseURL that deploys the graphs
+// graphs returns. If httpClient is nil, a client with a 30s timeout and
+// trace propagation is used.
+func New(baseURL string, graphs GraphSource, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   30 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		}
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		graphs:     graphs,
+		httpClient: httpClient,
+	}
+}
+
+// reconcileRequest is the Operator service's POST /v1/reconcile body. Spec
+// is the build's graph, which the operator models in full; only the fields
+// the environment manager sets are decoded.
+type reconcileRequest struct {
+	Spec    map[string]json.RawMessage `json:"spec"`
+	Secrets map[string]string          `json:"secrets,omitempty"`
+}
+
+// statusResponse is the part of the operator's reconcile and status
+// responses the environment manager reads.
+type statusResponse struct {
+	Status model.DeploymentStatus `json:"status"`
+}
+
+// Deploy reconciles the environment onto the graph buildID produced, labelled
+// with the environment's labels, and returns its preview URL. The secret
+// values travel only in the request body.
+func (c *Client) Deploy(ctx context.Context, env model.Environment, buildID string, secrets map[string]string) (string, error) {
+	graph, err := c.graphs.GetGraph(ctx, buildID)
+	if err != nil {
+		return "", fmt.Errorf("get graph: %w", err)
+	}
+	var spec map[string]json.RawMessage
+	if err := json.Unmarshal(graph, &spec); err != nil {
+		return "", fmt.Errorf("decode graph: %w", err)
+	}
+	for key, value := range map[string]any{
+		"environmentId": env.ID,
+		"buildId":       buildID,
+		"labels":        env.Labels,
+	} {
+		if spec[key], err = json.Marshal(value); err != nil {
+			return "", fmt.Errorf("encode %s: %w", key, err)
+		}
+	}
+
+	var resp statusResponse
+	if err := c.do(ctx, http.MethodPost, "/v1/reconcile", reconcileRequest{Spec: spec, Secrets: secrets}, &resp, http.StatusOK); err != nil {
+		return "", err
+	}
+	return resp.Status.PreviewURL, nil
+}
+
+// GetStatus returns the operator's observed state of an environment.
+func (c *Client) GetStatus(ctx context.Context, envID string) (model.DeploymentStatus, error) {
+	var resp statusResponse
+	err := c.do(ctx, http.MethodGet, "/v1/status/"+url.PathEscape(envID), nil, &resp, http.StatusOK)
+	return resp.Status, err
+}
+
+// Teardown deletes an environment's resources. The operator accepts it while
+// the resources are still being deleted and finishes in the background.
+func (c *Client) Teardown(ctx context.Context, envID string) error {
+	return c.do(ctx, http.MethodDelete, environmentPath(envID), nil, nil, http.StatusOK, http.StatusAccepted)
+}
+
+// Sleep scales an environment's components to zero.
+func (c *Client) Sleep(ctx context.Context, envID string) error {
+	return c.do(ctx, http.MethodPost, environmentPath(envID)+"/sleep", nil, nil, http.StatusOK)
+}
+
+// Wake scales a sleeping environment's components back up.
+func (c *Client) Wake(ctx context.Context, envID string) error {
+	return c.do(ctx, http.MethodPost, environmentPath(envID)+"/wake", nil, nil, http.StatusOK)
+}
+
+func environmentPath(envID string) string {
+	return "/v1/environments/" + url.PathEscape(envID)
+}
+
+// do sends a JSON request and, when the operator answers with one of want,
+// decodes the response into out if it is non-nil.
+func (c *Client) do(ctx context.Context, method, path string, in, out any, want ...int) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if !slices.Contains(want, resp.StatusCode) {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		if resp.StatusCode == http.StatusNotFound {
+			return fmt.Errorf("%s %s: %w", method, path, ErrNotFound)
+		}
+		return fmt.Errorf("%s %s: operator returned %d: %s", method, path, resp.StatusCode, apiErr.Error)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package operator_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/operator"
+)
+
+// graphs serves canned build graphs.
+type graphs map[string]string
+
+func (g graphs) GetGraph(_ context.Context, buildID string) (json.RawMessage, error) {
+	graph, ok := g[buildID]
+	if !ok {
+		return nil, errors.New("build has no graph")
+	}
+	return json.RawMessage(graph), nil
+}
+
+func TestClient(t *testing.T) {
+	var (
+		received struct {
+			Spec struct {
+				EnvironmentID string            `json:"environmentId"`
+				BuildID       string            `json:"buildId"`
+				RootPackage   string            `json:"rootPackage"`
+				Components    []map[string]any  `json:"components"`
+				Labels        map[string]string `json:"labels"`
+			} `json:"spec"`
+			Secrets map[string]string `json:"secrets"`
+		}
+		calls []string
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/reconcile", func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(&received); err != nil {
+			t.Errorf("decode reconcile request: %v", err)
+		}
+		_, _ = w.Write([]byte(`{"actions":[],"status":{"phase":"Deploying","previewUrl":"http://env-1.preview.local"}}`))
+	})
+	mux.HandleFunc("GET /v1/status/{environmentId}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("environmentId") != "env-1" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"environment not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"environmentId":"env-1","status":{"phase":"Running","previewUrl":"http://env-1.preview.local"}}`))
+	})
+	mux.HandleFunc("POST /v1/environments/{environmentId}/{action}", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, r.PathValue("action")+" "+r.PathValue("environmentId"))
+		if r.PathValue("environmentId") != "env-1" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"environment not found"}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"actions":[],"status":{"phase":"Sleeping"}}`))
+	})
+	mux.HandleFunc("DELETE /v1/environments/{environmentId}", func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, "delete "+r.PathValue("environmentId"))
+		w.WriteHeader(http.StatusAccepted)
+		_, _ = w.Write([]byte(`{"environmentId":"env-1","status":{"phase":"Deleting"}}`))
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := operator.New(srv.URL, graphs{
+		"build-1": `{"environmentId":"","buildId":"build-1","rootPackage":"root-pkg","components":[{"packageName":"users","artifactHash":"abc123"}]}`,
+	}, srv.Client())
+	ctx := context.Background()
+
+	env := model.Environment{ID: "env-1", Labels: map[string]string{"team": "payments"}}
+	url, err := c.Deploy(ctx, env, "build-1", map[string]string{"api-key": "s3cret"})
+	if err != nil {
+		t.Fatalf("Deploy: %v", err)
+	}
+	if url != "http://env-1.preview.local" {
+		t.Fatalf("expected the preview URL, got %q", url)
+	}
+	spec := received.Spec
+	if spec.EnvironmentID != "env-1" || spec.BuildID != "build-1" || spec.RootPackage != "root-pkg" ||
+		len(spec.Components) != 1 || spec.Components[0]["artifactHash"] != "abc123" {
+		t.Fatalf("expected the build's graph for env-1, got %+v", spec)
+	}
+	if spec.Labels["team"] != "payments" {
+		t.Fatalf("expected the environment's labels, got %v", spec.Labels)
+	}
+	if received.Secrets["api-key"] != "s3cret" {
+		t.Fatalf("expected the secrets forwarded, got %v", received.Secrets)
+	}
+	if _, err := c.Deploy(ctx, env, "build-9", nil); err == nil {
+		t.Fatal("expected an error for a build without a graph")
+	}
+
+	status, err := c.GetStatus(ctx, "env-1")
+	if err != nil {
+		t.Fatalf("GetStatus: %v", err)
+	}
+	if status.Phase != model.DeploymentPhaseRunning || status.PreviewURL != "http://env-1.preview.local" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+	if _, err := c.GetStatus(ctx, "missing"); !errors.Is(err, operator.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	if err := c.Sleep(ctx, "env-1"); err != nil {
+		t.Fatalf("Sleep: %v", err)
+	}
+	if err := c.Wake(ctx, "env-1"); err != nil {
+		t.Fatalf("Wake: %v", err)
+	}
+	if err := c.Sleep(ctx, "missing"); !errors.Is(err, operator.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if err := c.Teardown(ctx, "env-1"); err != nil {
+		t.Fatalf("Teardown: %v", err)
+	}
+	want := []string{"sleep env-1", "wake env-1", "sleep missing", "delete env-1"}
+	if !slices.Equal(calls, want) {
+		t.Fatalf("expected calls %v, got %v", want, calls)
+	}
+}
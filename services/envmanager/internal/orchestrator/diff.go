@@ -0,0 +1,187 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/schemadiff"
+)
+
+var (
+	// ErrNoRegistry is returned when an operation needs the registry but
+	// none is configured.
+	ErrNoRegistry = errors.New("no registry configured")
+
+	// ErrNoBaseVersion is returned when an operation needs the environment's
+	// base tree but the environment does not pin a base root version.
+	ErrNoBaseVersion = errors.New("environment has no base root version")
+)
+
+// Diff compares an environment with its base: the base root package's
+// dependency tree, and the same tree with the environment's overrides
+// applied. It reports a structural schema diff for each package that differs
+// along with any dependencies added or removed, pinned versions and replaced
+// upstreams.
+func (o *Orchestrator) Diff(ctx context.Context, id string) (model.EnvironmentDiff, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.Diff",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	env, err := o.store.Get(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return model.EnvironmentDiff{}, err
+	}
+	if o.registry == nil {
+		return model.EnvironmentDiff{}, ErrNoRegistry
+	}
+	if env.BaseRootVersion == "" {
+		return model.EnvironmentDiff{}, ErrNoBaseVersion
+	}
+
+	_, base, err := o.resolveBaseTree(ctx, env)
+	if err != nil {
+		span.RecordError(err)
+		return model.EnvironmentDiff{}, err
+	}
+	overridden, err := o.applyOverrides(ctx, base, env.Overrides)
+	if err != nil {
+		span.RecordError(err)
+		return model.EnvironmentDiff{}, err
+	}
+
+	names := make([]string, 0, len(base)+len(overridden))
+	for name := range base {
+		names = append(names, name)
+	}
+	for name := range overridden {
+		if _, ok := base[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	result := model.EnvironmentDiff{
+		EnvironmentID:   env.ID,
+		BaseRootPackage: env.BaseRootPackage,
+		BaseRootVersion: env.BaseRootVersion,
+		Packages:        []model.PackageDiff{},
+	}
+	for _, name := range names {
+		oldPkg, inBase := base[name]
+		newPkg, inEnv := overridden[name]
+
+		d := model.PackageDiff{Name: name, Kind: oldPkg.Kind, BaseVersion: oldPkg.Version}
+		switch {
+		case !inEnv:
+			d.Status = model.PackageRemoved
+			d.Breaking = true
+		case !inBase:
+			d.Status = model.PackageAdded
+			d.Kind = newPkg.Kind
+		default:
+			d.Status = model.PackageChanged
+		}
+
+		schemaDiff, err := schemadiff.Diff(d.Kind, oldPkg.Schema, newPkg.Schema)
+		if err != nil {
+			span.RecordError(err)
+			return model.EnvironmentDiff{}, fmt.Errorf("diff %s: %w", name, err)
+		}
+		d.SchemaChanges = schemaDiff.Changes
+		if d.SchemaChanges == nil {
+			d.SchemaChanges = []schemadiff.Change{}
+		}
+		d.Breaking = d.Breaking || schemaDiff.Breaking()
+		d.AddedDependencies = subtractDependencies(newPkg.Dependencies, oldPkg.Dependencies)
+		d.RemovedDependencies = subtractDependencies(oldPkg.Dependencies, newPkg.Dependencies)
+		if inEnv && newPkg.Version != oldPkg.Version {
+			d.Version = newPkg.Version
+		}
+		if inEnv && !oldPkg.UpstreamConfig.Equal(newPkg.UpstreamConfig) {
+			d.UpstreamConfig = newPkg.UpstreamConfig
+		}
+
+		if d.Status == model.PackageChanged && len(d.SchemaChanges) == 0 &&
+			len(d.AddedDependencies) == 0 && len(d.RemovedDependencies) == 0 &&
+			d.Version == "" && d.UpstreamConfig == nil {
+			continue
+		}
+		result.Breaking = result.Breaking || d.Breaking
+		result.Packages = append(result.Packages, d)
+	}
+	return result, nil
+}
+
+// resolveBaseTree fetches an environment's base root package and its
+// transitive dependencies, keyed by package name.
+func (o *Orchestrator) resolveBaseTree(ctx context.Context, env model.Environment) (model.Package, map[string]model.Package, error) {
+	root, err := o.registry.GetPackage(ctx, env.BaseRootPackage, env.BaseRootVersion)
+	if err != nil {
+		return model.Package{}, nil, fmt.Errorf("get base root %s@%s: %w", env.BaseRootPackage, env.BaseRootVersion, err)
+	}
+	deps, err := o.registry.ResolveDependencies(ctx, root.Name, root.Version)
+	if err != nil {
+		return model.Package{}, nil, fmt.Errorf("resolve base dependencies: %w", err)
+	}
+
+	tree := map[string]model.Package{root.Name: root}
+	for _, dep := range deps {
+		if _, ok := tree[dep.Name]; !ok {
+			tree[dep.Name] = dep
+		}
+	}
+	return root, tree, nil
+}
+
+// applyOverrides returns a copy of tree with the overrides in place: pinned
+// versions are fetched from the registry, and schemas and upstreams are
+// replaced. Overrides that add a schema or version for a package outside the
+// tree add it. Runtime overrides do not change packages and are ignored.
+func (o *Orchestrator) applyOverrides(ctx context.Context, tree map[string]model.Package, overrides []model.PackageOverride) (map[string]model.Package, error) {
+	result := make(map[string]model.Package, len(tree))
+	for name, pkg := range tree {
+		result[name] = pkg
+	}
+	for _, override := range overrides {
+		pkg, ok := result[override.PackageName]
+		switch {
+		case override.Version != "":
+			pinned, err := o.pinnedPackage(ctx, override)
+			if err != nil {
+				return nil, err
+			}
+			pkg = pinned
+		case override.Schema != "":
+			if !ok {
+				pkg = model.Package{Name: override.PackageName}
+			}
+			pkg.Schema = override.Schema
+		case !ok:
+			continue
+		}
+		if override.UpstreamConfig != nil {
+			pkg.UpstreamConfig = override.UpstreamConfig
+		}
+		result[override.PackageName] = pkg
+	}
+	return result, nil
+}
+
+// subtractDependencies returns the dependencies in a that are not in b.
+func subtractDependencies(a, b []model.Dependency) []model.Dependency {
+	var out []model.Dependency
+	for _, dep := range a {
+		if !slices.Contains(b, dep) {
+			out = append(out, dep)
+		}
+	}
+	return out
+}
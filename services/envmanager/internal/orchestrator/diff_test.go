@@ -0,0 +1,76 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+)
+
+func TestDiff(t *testing.T) {
+	orch, _, env := newPromoteFixture(t,
+		model.PackageOverride{PackageName: "users-subgraph", Schema: "type User { email: String }"},
+		model.PackageOverride{PackageName: "orders-subgraph", Schema: "type Order { id: ID! }"},
+	)
+
+	diff, err := orch.Diff(context.Background(), env.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff.BaseRootPackage != "root-pkg" || diff.BaseRootVersion != "1.0.0" {
+		t.Fatalf("unexpected base %s@%s", diff.BaseRootPackage, diff.BaseRootVersion)
+	}
+	if !diff.Breaking {
+		t.Fatal("expected the diff to be breaking")
+	}
+
+	// Unchanged packages (root-pkg, gateway) are omitted; the rest are sorted by name.
+	if len(diff.Packages) != 2 {
+		t.Fatalf("expected 2 package diffs, got %+v", diff.Packages)
+	}
+
+	orders := diff.Packages[0]
+	if orders.Name != "orders-subgraph" || orders.Status != model.PackageAdded || orders.Breaking {
+		t.Fatalf("unexpected orders-subgraph diff: %+v", orders)
+	}
+
+	users := diff.Packages[1]
+	if users.Name != "users-subgraph" || users.Status != model.PackageChanged || users.BaseVersion != "1.0.0" {
+		t.Fatalf("unexpected users-subgraph diff: %+v", users)
+	}
+	if !users.Breaking || len(users.SchemaChanges) != 2 {
+		t.Fatalf("expected User.id removed and User.email added, got %+v", users.SchemaChanges)
+	}
+	if users.SchemaChanges[0].Path != "User.email" || users.SchemaChanges[1].Path != "User.id" {
+		t.Fatalf("expected changes sorted by path, got %+v", users.SchemaChanges)
+	}
+}
+
+func TestDiff_NoOverrides(t *testing.T) {
+	orch, _, env := newPromoteFixture(t)
+
+	diff, err := orch.Diff(context.Background(), env.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if diff.Breaking || len(diff.Packages) != 0 {
+		t.Fatalf("expected an empty diff, got %+v", diff)
+	}
+}
+
+func TestDiff_RequiresBaseVersion(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{}, orchestrator.WithRegistry(newFakeRegistry()))
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "unpinned",
+		BaseRootPackage: "root-pkg",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if _, err := orch.Diff(context.Background(), env.ID); !errors.Is(err, orchestrator.ErrNoBaseVersion) {
+		t.Fatalf("expected ErrNoBaseVersion, got %v", err)
+	}
+}
@@ -0,0 +1,71 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+type actorKey struct{}
+
+// ContextWithActor returns a context that attributes the changes made with it
+// to actor in environment events.
+func ContextWithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor set by ContextWithActor, if any.
+func ActorFromContext(ctx context.Context) string {
+	actor, _ := ctx.Value(actorKey{}).(string)
+	return actor
+}
+
+// WithEventLog sets where environment events are recorded. By default they
+// go to the store if it is also a store.EventLog, or are kept in memory.
+func WithEventLog(l store.EventLog) Option {
+	return func(o *Orchestrator) { o.events = l }
+}
+
+// record appends an event to an environment's timeline, attributing it to the
+// context's actor unless it names one. Failing to record an event is logged
+// rather than failing the change it describes.
+func (o *Orchestrator) record(ctx context.Context, ev model.Event) {
+	if ev.Actor == "" {
+		ev.Actor = ActorFromContext(ctx)
+	}
+	ev.Time = time.Now().UTC()
+	if _, err := o.events.Append(ctx, ev); err != nil {
+		o.logger.ErrorContext(ctx, "record event failed",
+			slog.String("id", ev.EnvironmentID),
+			slog.String("type", string(ev.Type)),
+			slog.String("error", err.Error()))
+	}
+}
+
+// ListEvents returns a page of an environment's events, newest first. Events
+// of a deleted environment are still returned; an ID that never had any
+// events is store.ErrNotFound.
+func (o *Orchestrator) ListEvents(ctx context.Context, id string, filter store.EventFilter) (store.EventPage, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.ListEvents",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	page, err := o.events.ListEvents(ctx, id, filter)
+	if err != nil {
+		span.RecordError(err)
+		return store.EventPage{}, err
+	}
+	if len(page.Events) == 0 && filter.PageToken == "" {
+		if _, err := o.store.Get(ctx, id); err != nil {
+			span.RecordError(err)
+			return store.EventPage{}, err
+		}
+	}
+	return page, nil
+}
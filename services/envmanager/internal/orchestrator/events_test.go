@@ -0,0 +1,140 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+// timeline returns all of an environment's events, oldest first.
+func timeline(t *testing.T, orch *orchestrator.Orchestrator, id string) []model.Event {
+	t.Helper()
+	page, err := orch.ListEvents(context.Background(), id, store.EventFilter{PageSize: 100})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	slices.Reverse(page.Events)
+	return page.Events
+}
+
+func eventTypes(events []model.Event) []model.EventType {
+	types := make([]model.EventType, len(events))
+	for i, ev := range events {
+		types[i] = ev.Type
+	}
+	return types
+}
+
+func TestEvents_Lifecycle(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{buildID: "build-1"}, &mockOperator{previewURL: "https://preview.example.com/env"})
+	ctx := orchestrator.ContextWithActor(context.Background(), "bob")
+
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "test-env",
+		BaseRootPackage: "root-pkg",
+		CreatedBy:       "alice",
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := orch.ApplyOverrides(ctx, env.ID, model.ApplyOverridesRequest{
+		Overrides:    []model.PackageOverride{usersOverride},
+		TriggerBuild: true,
+	}); err != nil {
+		t.Fatalf("apply overrides: %v", err)
+	}
+	waitForEnvironment(t, orch, env.ID)
+	if err := orch.DeleteEnvironment(ctx, env.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	events := timeline(t, orch, env.ID)
+	want := []model.EventType{
+		model.EventCreated,
+		model.EventOverridesApplied,
+		model.EventBuildTriggered,
+		model.EventDeploySucceeded,
+		model.EventDeleted,
+	}
+	if !slices.Equal(eventTypes(events), want) {
+		t.Fatalf("expected %v, got %v", want, eventTypes(events))
+	}
+
+	// Without an actor on the context, creation is attributed to CreatedBy.
+	if events[0].Actor != "alice" {
+		t.Fatalf("expected creation by alice, got %q", events[0].Actor)
+	}
+	// The workflow keeps the actor of the request that started it.
+	for _, ev := range events[1:] {
+		if ev.Actor != "bob" {
+			t.Fatalf("expected %s by bob, got %q", ev.Type, ev.Actor)
+		}
+	}
+	if change := events[1].Overrides; change == nil || !slices.Equal(change.Added, []string{"users-subgraph"}) {
+		t.Fatalf("expected users-subgraph to be added, got %+v", change)
+	}
+	if events[2].BuildID != "build-1" || events[3].BuildID != "build-1" {
+		t.Fatalf("expected build-1 on the build events, got %+v", events[2:4])
+	}
+	if events[3].Status != model.StatusReady {
+		t.Fatalf("expected ready after deploy, got %s", events[3].Status)
+	}
+}
+
+func TestEvents_Failure(t *testing.T) {
+	o := &mockOperator{deployErr: errors.New("cluster unavailable")}
+	orch, _ := newTestOrchestrator(&mockBuilder{buildID: "build-1"}, o)
+
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "test-env",
+		BaseRootPackage: "root-pkg",
+		Overrides:       []model.PackageOverride{usersOverride},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	waitForEnvironment(t, orch, env.ID)
+
+	events := timeline(t, orch, env.ID)
+	last := events[len(events)-1]
+	if last.Type != model.EventDeployFailed || last.Stage != model.StageDeploy ||
+		last.Status != model.StatusFailed || last.Error == "" {
+		t.Fatalf("expected a deploy failure with its cause, got %+v", last)
+	}
+}
+
+func TestEvents_OverridesChange(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{buildID: "build-1"}, &mockOperator{})
+	env := createParent(t, orch, usersOverride, ordersOverride)
+
+	users := model.PackageOverride{PackageName: "users-subgraph", Schema: "type User { id: ID! name: String }"}
+	products := model.PackageOverride{PackageName: "products-subgraph", Schema: "type Product { id: ID! }"}
+	if _, err := orch.ApplyOverrides(context.Background(), env.ID, model.ApplyOverridesRequest{
+		Overrides: []model.PackageOverride{users, products},
+	}); err != nil {
+		t.Fatalf("apply overrides: %v", err)
+	}
+
+	events := timeline(t, orch, env.ID)
+	change := events[len(events)-1].Overrides
+	if change == nil ||
+		!slices.Equal(change.Added, []string{"products-subgraph"}) ||
+		!slices.Equal(change.Removed, []string{"orders-subgraph"}) ||
+		!slices.Equal(change.Changed, []string{"users-subgraph"}) {
+		t.Fatalf("unexpected overrides change %+v", change)
+	}
+}
+
+func TestListEvents_NotFound(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+
+	_, err := orch.ListEvents(context.Background(), "nonexistent", store.EventFilter{})
+	if !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,264 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+var (
+	// ErrInvalidParent is returned when an environment cannot be forked from
+	// the requested parent.
+	ErrInvalidParent = errors.New("invalid parent environment")
+
+	// ErrNoParent is returned when rebasing an environment that was not
+	// forked from another, or whose parent has been deleted.
+	ErrNoParent = errors.New("environment has no parent")
+)
+
+// forkParent fetches the parent named by req and folds it into req: the
+// request inherits the parent's base, and its overrides and labels are
+// layered on top of the parent's.
+func (o *Orchestrator) forkParent(ctx context.Context, req *model.CreateEnvironmentRequest) (model.Environment, error) {
+	parent, err := o.store.Get(ctx, req.ParentID)
+	if errors.Is(err, store.ErrNotFound) {
+		return model.Environment{}, fmt.Errorf("%w: %s not found", ErrInvalidParent, req.ParentID)
+	}
+	if err != nil {
+		return model.Environment{}, fmt.Errorf("get parent environment: %w", err)
+	}
+	if parent.Status == model.StatusDeleting {
+		return model.Environment{}, fmt.Errorf("%w: %s is being deleted", ErrInvalidParent, parent.ID)
+	}
+	if req.BaseRootPackage != "" && req.BaseRootPackage != parent.BaseRootPackage ||
+		req.BaseRootVersion != "" && req.BaseRootVersion != parent.BaseRootVersion {
+		return model.Environment{}, fmt.Errorf("%w: base must match the parent's %s@%s",
+			ErrInvalidParent, parent.BaseRootPackage, parent.BaseRootVersion)
+	}
+
+	req.BaseRootPackage = parent.BaseRootPackage
+	req.BaseRootVersion = parent.BaseRootVersion
+	req.Overrides = mergeOverrides(parent.Overrides, req.Overrides)
+	if len(parent.Labels) > 0 {
+		merged := maps.Clone(parent.Labels)
+		maps.Copy(merged, req.Labels)
+		req.Labels = merged
+	}
+	return parent, nil
+}
+
+// Rebase moves an environment onto its parent's current base and overrides,
+// keeping the overrides it set itself. Overrides it inherited are replaced by
+// the parent's current ones; an inherited override the environment has
+// changed counts as its own and takes precedence. With TriggerBuild set it
+// also starts the build/deploy workflow.
+func (o *Orchestrator) Rebase(ctx context.Context, id string, req model.RebaseRequest) (model.Environment, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.Rebase",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	env, err := o.store.Get(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, err
+	}
+	if env.ParentID == "" {
+		return model.Environment{}, ErrNoParent
+	}
+	parent, err := o.store.Get(ctx, env.ParentID)
+	if errors.Is(err, store.ErrNotFound) {
+		return model.Environment{}, fmt.Errorf("%w: parent %s no longer exists", ErrNoParent, env.ParentID)
+	}
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("get parent environment: %w", err)
+	}
+
+	overrides := mergeOverrides(parent.Overrides, ownOverrides(env))
+	if err := o.validateOverrides(ctx, parent.BaseRootPackage, parent.BaseRootVersion, overrides); err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("rebase: %w", err)
+	}
+	// A parent that has grown since the fork may take the environment over
+	// its limits.
+	if err := o.checkFootprint(parent.BaseRootPackage, overrides); err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("rebase: %w", err)
+	}
+	tmpl, err := o.environmentTemplate(ctx, env)
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("rebase: %w", err)
+	}
+	if err := checkTemplateFootprint(tmpl, parent.BaseRootPackage, overrides); err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("rebase: %w", err)
+	}
+
+	var change model.OverridesChange
+	env, err = o.mutate(ctx, id, func(cur *model.Environment) error {
+		if cur.Status == model.StatusDeleting {
+			return fmt.Errorf("%w: environment is being deleted", model.ErrInvalidTransition)
+		}
+		if err := checkLock(ctx, *cur, time.Now().UTC()); err != nil {
+			return err
+		}
+		if req.TriggerBuild {
+			if err := model.ValidateTransition(cur.Status, model.StatusBuilding); err != nil {
+				return err
+			}
+		}
+		cur.BaseRootPackage = parent.BaseRootPackage
+		cur.BaseRootVersion = parent.BaseRootVersion
+		change = model.DiffOverrides(cur.Overrides, overrides)
+		cur.Overrides = overrides
+		cur.InheritedOverrides = model.CloneOverrides(parent.Overrides)
+		cur.LastActivityAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("rebase: %w", err)
+	}
+
+	o.logger.InfoContext(ctx, "environment rebased",
+		slog.String("id", id),
+		slog.String("parentId", parent.ID),
+		slog.Int("overrideCount", len(overrides)))
+	o.record(ctx, model.Event{
+		EnvironmentID: id,
+		Type:          model.EventRebased,
+		Message:       "rebased onto " + parent.ID,
+		Status:        env.Status,
+		Overrides:     &change,
+	})
+
+	if req.TriggerBuild {
+		building, err := o.startWorkflow(ctx, id)
+		if err != nil {
+			span.RecordError(err)
+			return model.Environment{}, fmt.Errorf("start build: %w", err)
+		}
+		return building, nil
+	}
+	return env, nil
+}
+
+// Lineage reports an environment's ancestors and children, and whether each
+// is stale with respect to its own parent.
+func (o *Orchestrator) Lineage(ctx context.Context, id string) (model.Lineage, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.Lineage",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	env, err := o.store.Get(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return model.Lineage{}, err
+	}
+
+	// Walk up the chain. Parents always exist before their children, so the
+	// chain cannot loop, but a corrupted store should not hang the request.
+	chain := []model.Environment{env}
+	seen := map[string]bool{env.ID: true}
+	for cur := env; cur.ParentID != "" && !seen[cur.ParentID]; {
+		parent, err := o.store.Get(ctx, cur.ParentID)
+		if errors.Is(err, store.ErrNotFound) {
+			break
+		}
+		if err != nil {
+			span.RecordError(err)
+			return model.Lineage{}, fmt.Errorf("get ancestor %s: %w", cur.ParentID, err)
+		}
+		chain = append(chain, parent)
+		seen[parent.ID] = true
+		cur = parent
+	}
+
+	lineage := model.Lineage{
+		EnvironmentID: env.ID,
+		ParentID:      env.ParentID,
+		Ancestors:     []model.EnvironmentRef{},
+		Children:      []model.EnvironmentRef{},
+	}
+	if len(chain) > 1 {
+		lineage.Stale = parentChanged(env, chain[1])
+	}
+	for i := 1; i < len(chain); i++ {
+		ref := environmentRef(chain[i])
+		if i+1 < len(chain) {
+			ref.Stale = parentChanged(chain[i], chain[i+1])
+		}
+		lineage.Ancestors = append(lineage.Ancestors, ref)
+	}
+
+	filter := store.ListFilter{ParentID: env.ID, PageSize: 100}
+	for {
+		result, err := o.store.List(ctx, filter)
+		if err != nil {
+			span.RecordError(err)
+			return model.Lineage{}, fmt.Errorf("list children: %w", err)
+		}
+		for _, child := range result.Environments {
+			ref := environmentRef(child)
+			ref.Stale = parentChanged(child, env)
+			lineage.Children = append(lineage.Children, ref)
+		}
+		if result.NextPageToken == "" {
+			return lineage, nil
+		}
+		filter.PageToken = result.NextPageToken
+	}
+}
+
+func environmentRef(env model.Environment) model.EnvironmentRef {
+	return model.EnvironmentRef{ID: env.ID, Name: env.Name, Status: env.Status}
+}
+
+// parentChanged reports whether parent has moved to a different base or
+// changed its overrides since child was forked from it or last rebased.
+func parentChanged(child, parent model.Environment) bool {
+	return child.BaseRootPackage != parent.BaseRootPackage ||
+		child.BaseRootVersion != parent.BaseRootVersion ||
+		!slices.EqualFunc(child.InheritedOverrides, parent.Overrides, model.PackageOverride.Equal)
+}
+
+// mergeOverrides layers overrides on top of base: an override replaces the
+// base override for the same package, and overrides for other packages are
+// appended in order.
+func mergeOverrides(base, overrides []model.PackageOverride) []model.PackageOverride {
+	merged := model.CloneOverrides(base)
+	for _, override := range overrides {
+		i := slices.IndexFunc(merged, func(o model.PackageOverride) bool {
+			return o.PackageName == override.PackageName
+		})
+		if i >= 0 {
+			merged[i] = override.Clone()
+		} else {
+			merged = append(merged, override.Clone())
+		}
+	}
+	return merged
+}
+
+// ownOverrides returns the overrides env set itself, as opposed to those it
+// inherited unchanged from its parent.
+func ownOverrides(env model.Environment) []model.PackageOverride {
+	var own []model.PackageOverride
+	for _, override := range env.Overrides {
+		if !slices.ContainsFunc(env.InheritedOverrides, override.Equal) {
+			own = append(own, override)
+		}
+	}
+	return own
+}
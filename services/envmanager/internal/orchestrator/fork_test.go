@@ -0,0 +1,366 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+)
+
+var (
+	usersOverride  = model.PackageOverride{PackageName: "users-subgraph", Schema: "type User { id: ID! }"}
+	ordersOverride = model.PackageOverride{PackageName: "orders-subgraph", Schema: "type Order { id: ID! }"}
+)
+
+// createParent creates a ready environment with overrides for the fork tests
+// to branch from.
+func createParent(t *testing.T, orch *orchestrator.Orchestrator, overrides ...model.PackageOverride) model.Environment {
+	t.Helper()
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "parent-env",
+		BaseRootPackage: "root-pkg",
+		Overrides:       overrides,
+	})
+	if err != nil {
+		t.Fatalf("create parent: %v", err)
+	}
+	return waitForEnvironment(t, orch, env.ID)
+}
+
+func TestCreateEnvironment_ForkReusesParentBuild(t *testing.T) {
+	b := &mockBuilder{buildID: "build-123"}
+	o := &mockOperator{previewURL: "https://preview.example.com/env"}
+	orch, _ := newTestOrchestrator(b, o)
+	parent := createParent(t, orch, usersOverride)
+
+	child, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:     "child-env",
+		ParentID: parent.ID,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if child.ParentID != parent.ID || child.BaseRootPackage != "root-pkg" {
+		t.Fatalf("expected child of %s on root-pkg, got parent %q base %q", parent.ID, child.ParentID, child.BaseRootPackage)
+	}
+	if len(child.Overrides) != 1 || !child.Overrides[0].Equal(usersOverride) {
+		t.Fatalf("expected the parent's overrides, got %+v", child.Overrides)
+	}
+	if len(child.InheritedOverrides) != 1 {
+		t.Fatalf("expected inherited overrides to be recorded, got %+v", child.InheritedOverrides)
+	}
+
+	child = waitForEnvironment(t, orch, child.ID)
+	if child.Status != model.StatusReady || child.CurrentBuildID != "build-123" {
+		t.Fatalf("expected ready on build-123, got %s on %q", child.Status, child.CurrentBuildID)
+	}
+	if b.called != 1 {
+		t.Fatalf("expected only the parent to be built, got %d builds", b.called)
+	}
+	if o.deployCalls != 2 {
+		t.Fatalf("expected both environments deployed, got %d deploys", o.deployCalls)
+	}
+}
+
+func TestCreateEnvironment_ForkWithOverrides(t *testing.T) {
+	b := &mockBuilder{buildID: "build-123"}
+	orch, _ := newTestOrchestrator(b, &mockOperator{})
+	parent := createParent(t, orch, usersOverride)
+
+	users := model.PackageOverride{PackageName: "users-subgraph", Schema: "type User { id: ID! name: String }"}
+	child, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:      "child-env",
+		ParentID:  parent.ID,
+		Overrides: []model.PackageOverride{ordersOverride, users},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if child.Status != model.StatusBuilding {
+		t.Fatalf("expected status building, got %s", child.Status)
+	}
+
+	// The child's users override wins; orders is added after it.
+	if len(child.Overrides) != 2 || !child.Overrides[0].Equal(users) || !child.Overrides[1].Equal(ordersOverride) {
+		t.Fatalf("unexpected merged overrides: %+v", child.Overrides)
+	}
+
+	waitForEnvironment(t, orch, child.ID)
+	if b.called != 2 {
+		t.Fatalf("expected the child to be built, got %d builds", b.called)
+	}
+}
+
+func TestCreateEnvironment_InvalidParent(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	parent := createParent(t, orch)
+
+	tests := []struct {
+		name string
+		req  model.CreateEnvironmentRequest
+	}{
+		{
+			name: "missing parent",
+			req:  model.CreateEnvironmentRequest{Name: "child-env", ParentID: "nonexistent"},
+		},
+		{
+			name: "different base",
+			req:  model.CreateEnvironmentRequest{Name: "child-env", ParentID: parent.ID, BaseRootPackage: "other-pkg"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := orch.CreateEnvironment(context.Background(), tt.req)
+			if !errors.Is(err, orchestrator.ErrInvalidParent) {
+				t.Fatalf("expected ErrInvalidParent, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRebase(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{buildID: "build-123"}, &mockOperator{})
+	ctx := context.Background()
+	parent := createParent(t, orch, usersOverride)
+
+	child, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name:      "child-env",
+		ParentID:  parent.ID,
+		Overrides: []model.PackageOverride{ordersOverride},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForEnvironment(t, orch, child.ID)
+
+	// The parent moves on: users changes and gateway is added.
+	users := model.PackageOverride{PackageName: "users-subgraph", Schema: "type User { id: ID! email: String }"}
+	gateway := model.PackageOverride{PackageName: "gateway", Runtime: &model.RuntimeOverride{Env: map[string]string{"LOG_LEVEL": "debug"}}}
+	if _, err := orch.ApplyOverrides(ctx, parent.ID, model.ApplyOverridesRequest{
+		Overrides: []model.PackageOverride{users, gateway},
+	}); err != nil {
+		t.Fatalf("apply parent overrides: %v", err)
+	}
+
+	lineage, err := orch.Lineage(ctx, child.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !lineage.Stale {
+		t.Fatal("expected child to be stale after the parent changed")
+	}
+
+	rebased, err := orch.Rebase(ctx, child.ID, model.RebaseRequest{TriggerBuild: true})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rebased.Status != model.StatusBuilding {
+		t.Fatalf("expected status building, got %s", rebased.Status)
+	}
+	want := []model.PackageOverride{users, gateway, ordersOverride}
+	if len(rebased.Overrides) != len(want) {
+		t.Fatalf("expected %d overrides, got %+v", len(want), rebased.Overrides)
+	}
+	for i := range want {
+		if !rebased.Overrides[i].Equal(want[i]) {
+			t.Fatalf("override %d: expected %+v, got %+v", i, want[i], rebased.Overrides[i])
+		}
+	}
+	waitForEnvironment(t, orch, child.ID)
+
+	lineage, err = orch.Lineage(ctx, child.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lineage.Stale {
+		t.Fatal("expected child to be up to date after rebasing")
+	}
+}
+
+func TestRebase_Footprint(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{buildID: "build-123"}, &mockOperator{},
+		orchestrator.WithQuotas(model.Quotas{MaxComponents: 3}))
+	ctx := context.Background()
+	parent := createParent(t, orch, usersOverride)
+
+	child, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name:      "child-env",
+		ParentID:  parent.ID,
+		Overrides: []model.PackageOverride{ordersOverride},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	child = waitForEnvironment(t, orch, child.ID)
+
+	// The parent grows to three components, which with the child's own
+	// override would be four.
+	gateway := model.PackageOverride{PackageName: "gateway", Schema: "type Query { ok: Boolean }"}
+	if _, err := orch.ApplyOverrides(ctx, parent.ID, model.ApplyOverridesRequest{
+		Overrides: []model.PackageOverride{usersOverride, gateway},
+	}); err != nil {
+		t.Fatalf("apply parent overrides: %v", err)
+	}
+
+	if _, err := orch.Rebase(ctx, child.ID, model.RebaseRequest{}); !errors.Is(err, orchestrator.ErrLimitExceeded) {
+		t.Fatalf("expected ErrLimitExceeded, got %v", err)
+	}
+	got, err := orch.GetEnvironment(ctx, child.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Overrides) != len(child.Overrides) {
+		t.Fatalf("expected the overrides unchanged, got %+v", got.Overrides)
+	}
+}
+
+func TestRebase_KeepsChangedInheritedOverride(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{buildID: "build-123"}, &mockOperator{})
+	ctx := context.Background()
+	parent := createParent(t, orch, usersOverride)
+
+	child, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "child-env", ParentID: parent.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForEnvironment(t, orch, child.ID)
+
+	// The child edits the override it inherited, so it becomes its own.
+	users := model.PackageOverride{PackageName: "users-subgraph", Schema: "type User { id: ID! nickname: String }"}
+	if _, err := orch.ApplyOverrides(ctx, child.ID, model.ApplyOverridesRequest{
+		Overrides: []model.PackageOverride{users},
+	}); err != nil {
+		t.Fatalf("apply child overrides: %v", err)
+	}
+
+	rebased, err := orch.Rebase(ctx, child.ID, model.RebaseRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rebased.Status != model.StatusReady {
+		t.Fatalf("expected status ready without a build, got %s", rebased.Status)
+	}
+	if len(rebased.Overrides) != 1 || !rebased.Overrides[0].Equal(users) {
+		t.Fatalf("expected the child's users override to be kept, got %+v", rebased.Overrides)
+	}
+}
+
+func TestRebase_NoParent(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	ctx := context.Background()
+	env := createParent(t, orch)
+
+	if _, err := orch.Rebase(ctx, env.ID, model.RebaseRequest{}); !errors.Is(err, orchestrator.ErrNoParent) {
+		t.Fatalf("expected ErrNoParent, got %v", err)
+	}
+
+	child, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "child-env", ParentID: env.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := orch.DeleteEnvironment(ctx, env.ID); err != nil {
+		t.Fatalf("delete parent: %v", err)
+	}
+	if _, err := orch.Rebase(ctx, child.ID, model.RebaseRequest{}); !errors.Is(err, orchestrator.ErrNoParent) {
+		t.Fatalf("expected ErrNoParent after the parent was deleted, got %v", err)
+	}
+}
+
+func TestRebase_Locked(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{buildID: "build-123"}, &mockOperator{})
+	ctx := context.Background()
+	parent := createParent(t, orch, usersOverride)
+
+	child, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "child-env", ParentID: parent.ID})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	waitForEnvironment(t, orch, child.ID)
+	if _, err := orch.ApplyOverrides(ctx, parent.ID, model.ApplyOverridesRequest{
+		Overrides: []model.PackageOverride{ordersOverride},
+	}); err != nil {
+		t.Fatalf("apply parent overrides: %v", err)
+	}
+
+	if _, err := orch.Lock(asActor("alice"), child.ID, model.LockRequest{}); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if _, err := orch.Rebase(asActor("bob"), child.ID, model.RebaseRequest{}); !errors.Is(err, orchestrator.ErrLocked) {
+		t.Fatalf("expected ErrLocked, got %v", err)
+	}
+	lineage, err := orch.Lineage(ctx, child.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !lineage.Stale {
+		t.Fatal("expected the locked child not to be rebased")
+	}
+
+	if _, err := orch.Rebase(orchestrator.ContextWithForce(asActor("alice")), child.ID, model.RebaseRequest{}); err != nil {
+		t.Fatalf("forced rebase: %v", err)
+	}
+}
+
+func TestLineage(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{buildID: "build-123"}, &mockOperator{})
+	ctx := context.Background()
+	root := createParent(t, orch, usersOverride)
+
+	fork := func(parentID, name string) model.Environment {
+		t.Helper()
+		env, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: name, ParentID: parentID})
+		if err != nil {
+			t.Fatalf("fork %s: %v", name, err)
+		}
+		return waitForEnvironment(t, orch, env.ID)
+	}
+	middle := fork(root.ID, "middle-env")
+	leaf := fork(middle.ID, "leaf-env")
+	sibling := fork(middle.ID, "sibling-env")
+
+	// Changing the root makes middle stale, but not middle's children.
+	if _, err := orch.ApplyOverrides(ctx, root.ID, model.ApplyOverridesRequest{
+		Overrides: []model.PackageOverride{ordersOverride},
+	}); err != nil {
+		t.Fatalf("apply root overrides: %v", err)
+	}
+
+	lineage, err := orch.Lineage(ctx, middle.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if lineage.ParentID != root.ID || !lineage.Stale {
+		t.Fatalf("expected stale child of %s, got %+v", root.ID, lineage)
+	}
+	if len(lineage.Ancestors) != 1 || lineage.Ancestors[0].ID != root.ID {
+		t.Fatalf("expected root as the only ancestor, got %+v", lineage.Ancestors)
+	}
+	if len(lineage.Children) != 2 {
+		t.Fatalf("expected 2 children, got %+v", lineage.Children)
+	}
+	for _, child := range lineage.Children {
+		if child.ID != leaf.ID && child.ID != sibling.ID {
+			t.Fatalf("unexpected child %+v", child)
+		}
+		if child.Stale {
+			t.Fatalf("expected child %s to be up to date", child.Name)
+		}
+	}
+
+	lineage, err = orch.Lineage(ctx, leaf.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(lineage.Ancestors) != 2 || lineage.Ancestors[0].ID != middle.ID || lineage.Ancestors[1].ID != root.ID {
+		t.Fatalf("expected ancestors middle then root, got %+v", lineage.Ancestors)
+	}
+	if lineage.Stale || !lineage.Ancestors[0].Stale {
+		t.Fatalf("expected only middle to be stale, got %+v", lineage)
+	}
+	if len(lineage.Children) != 0 {
+		t.Fatalf("expected no children, got %+v", lineage.Children)
+	}
+}
@@ -0,0 +1,89 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"maps"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/labels"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+func ptr(s string) *string { return &s }
+
+func TestCreateEnvironment_Labels(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "test-env",
+		BaseRootPackage: "root-pkg",
+		Labels:          map[string]string{"team": "payments", "ticket": "PAY-123"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.Labels["team"] != "payments" || env.Labels["ticket"] != "PAY-123" {
+		t.Fatalf("expected labels to be stored, got %v", env.Labels)
+	}
+
+	// A fork inherits its parent's labels unless it sets its own.
+	child, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:     "child-env",
+		ParentID: env.ID,
+		Labels:   map[string]string{"ticket": "PAY-456"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]string{"team": "payments", "ticket": "PAY-456"}; !maps.Equal(child.Labels, want) {
+		t.Fatalf("expected %v, got %v", want, child.Labels)
+	}
+
+	_, err = orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "bad-env",
+		BaseRootPackage: "root-pkg",
+		Labels:          map[string]string{"turboengine.io/environment": "x"},
+	})
+	if !errors.Is(err, labels.ErrInvalidLabel) {
+		t.Fatalf("expected ErrInvalidLabel, got %v", err)
+	}
+}
+
+func TestUpdateEnvironment_Labels(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "test-env",
+		BaseRootPackage: "root-pkg",
+		Labels:          map[string]string{"team": "payments", "purpose": "demo"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated, err := orch.UpdateEnvironment(context.Background(), env.ID, model.UpdateEnvironmentRequest{
+		Labels: map[string]*string{"purpose": nil, "ticket": ptr("PAY-123")},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := map[string]string{"team": "payments", "ticket": "PAY-123"}; !maps.Equal(updated.Labels, want) {
+		t.Fatalf("expected %v, got %v", want, updated.Labels)
+	}
+
+	events := timeline(t, orch, env.ID)
+	last := events[len(events)-1]
+	if last.Type != model.EventLabelsUpdated || last.Message != "-purpose, ticket=PAY-123" {
+		t.Fatalf("expected the label change to be recorded, got %+v", last)
+	}
+
+	_, err = orch.UpdateEnvironment(context.Background(), env.ID, model.UpdateEnvironmentRequest{
+		Labels: map[string]*string{"team": ptr("not valid")},
+	})
+	if !errors.Is(err, labels.ErrInvalidLabel) {
+		t.Fatalf("expected ErrInvalidLabel, got %v", err)
+	}
+	if got, _ := orch.GetEnvironment(context.Background(), env.ID); got.Labels["team"] != "payments" {
+		t.Fatalf("expected a rejected patch to change nothing, got %v", got.Labels)
+	}
+}
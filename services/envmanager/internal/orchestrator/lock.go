@@ -0,0 +1,198 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+// ErrLocked is returned when changing, promoting or deleting an environment
+// someone else has locked, or that is locked and the change wasn't forced.
+var ErrLocked = errors.New("environment is locked")
+
+// ErrProtected is returned when a bulk delete or the reaper reaches an
+// environment that was protected after it was selected.
+var ErrProtected = errors.New("environment is protected")
+
+type forceKey struct{}
+
+// ContextWithForce returns a context whose changes override the
+// environment's lock, provided its actor is the lock's owner.
+func ContextWithForce(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forceKey{}, true)
+}
+
+func forced(ctx context.Context) bool {
+	force, _ := ctx.Value(forceKey{}).(bool)
+	return force
+}
+
+// checkLock returns ErrLocked if env's lock is held at now, unless the
+// context's actor owns it and forces the change.
+func checkLock(ctx context.Context, env model.Environment, now time.Time) error {
+	lock := env.Lock
+	if !lock.Held(now) {
+		return nil
+	}
+	if forced(ctx) && ActorFromContext(ctx) == lock.Owner {
+		return nil
+	}
+	msg := fmt.Sprintf("%s by %s", ErrLocked, lock.Owner)
+	if lock.Reason != "" {
+		msg += ": " + lock.Reason
+	}
+	if forced(ctx) {
+		msg += " (only the lock owner can force changes)"
+	}
+	return &lockedError{msg: msg}
+}
+
+// lockedError is an ErrLocked naming the lock's owner and reason.
+type lockedError struct{ msg string }
+
+func (e *lockedError) Error() string        { return e.msg }
+func (e *lockedError) Is(target error) bool { return target == ErrLocked }
+
+// Lock locks an environment for the context's actor, or renews the lock they
+// already hold with a new reason and expiry.
+func (o *Orchestrator) Lock(ctx context.Context, id string, req model.LockRequest) (model.Environment, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.Lock",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	owner := ActorFromContext(ctx)
+	if owner == "" {
+		return model.Environment{}, fmt.Errorf("%w: the caller must be identified to hold a lock", model.ErrInvalidLock)
+	}
+	now := time.Now().UTC()
+	expiresAt, err := req.Expiry(now)
+	if err != nil {
+		return model.Environment{}, err
+	}
+
+	env, err := o.mutate(ctx, id, func(env *model.Environment) error {
+		if env.Status == model.StatusDeleting {
+			return fmt.Errorf("%w: environment is being deleted", model.ErrInvalidTransition)
+		}
+		if env.Lock.Held(now) && env.Lock.Owner != owner {
+			return checkLock(ctx, *env, now)
+		}
+		env.Lock = &model.Lock{Owner: owner, Reason: req.Reason, LockedAt: now, ExpiresAt: expiresAt}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, err
+	}
+
+	o.logger.InfoContext(ctx, "environment locked", slog.String("id", id), slog.String("owner", owner))
+	o.record(ctx, model.Event{EnvironmentID: id, Type: model.EventLocked, Message: req.Reason, Status: env.Status})
+	return env, nil
+}
+
+// Unlock releases an environment's lock. Only its owner may release a lock
+// that is still held; unlocking an unlocked environment does nothing.
+func (o *Orchestrator) Unlock(ctx context.Context, id string) (model.Environment, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.Unlock",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	now := time.Now().UTC()
+	released := false
+	env, err := o.mutate(ctx, id, func(env *model.Environment) error {
+		if env.Lock == nil {
+			return nil
+		}
+		if env.Lock.Held(now) && env.Lock.Owner != ActorFromContext(ctx) {
+			return checkLock(ctx, *env, now)
+		}
+		env.Lock = nil
+		released = true
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, err
+	}
+
+	if released {
+		o.logger.InfoContext(ctx, "environment unlocked", slog.String("id", id))
+		o.record(ctx, model.Event{EnvironmentID: id, Type: model.EventUnlocked, Status: env.Status})
+	}
+	return env, nil
+}
+
+// BulkDelete tears down and deletes every environment matching filter,
+// skipping protected and locked ones. With dryRun set it only reports what
+// it would delete.
+func (o *Orchestrator) BulkDelete(ctx context.Context, filter store.ListFilter, dryRun bool) (model.BulkDeleteResponse, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.BulkDelete",
+		trace.WithAttributes(attribute.Bool("delete.dry_run", dryRun)))
+	defer span.End()
+
+	resp := model.BulkDeleteResponse{DryRun: dryRun, Deleted: []model.BulkDeleteResult{}, Skipped: []model.BulkDeleteResult{}}
+	filter.PageSize, filter.PageToken = 100, ""
+	var matched []model.Environment
+	for {
+		result, err := o.store.List(ctx, filter)
+		if err != nil {
+			span.RecordError(err)
+			return model.BulkDeleteResponse{}, fmt.Errorf("list environments: %w", err)
+		}
+		matched = append(matched, result.Environments...)
+		if result.NextPageToken == "" {
+			break
+		}
+		filter.PageToken = result.NextPageToken
+	}
+
+	for _, env := range matched {
+		if env.Status == model.StatusDeleting {
+			continue
+		}
+		result := model.BulkDeleteResult{EnvironmentID: env.ID, Name: env.Name, Branch: env.Branch}
+		if reason, skip := skipBulkDelete(env, time.Now().UTC()); skip {
+			result.Reason = reason
+			resp.Skipped = append(resp.Skipped, result)
+			continue
+		}
+		if !dryRun {
+			// Deletes made in bulk never override a lock.
+			if err := o.deleteEnvironment(context.WithValue(ctx, forceKey{}, false), env.ID, "bulk delete", true); err != nil {
+				o.logger.ErrorContext(ctx, "bulk delete failed",
+					slog.String("id", env.ID),
+					slog.String("error", err.Error()))
+				result.Reason = err.Error()
+				resp.Skipped = append(resp.Skipped, result)
+				continue
+			}
+		}
+		resp.Deleted = append(resp.Deleted, result)
+	}
+
+	o.logger.InfoContext(ctx, "bulk delete complete",
+		slog.Bool("dryRun", dryRun),
+		slog.Int("deleted", len(resp.Deleted)),
+		slog.Int("skipped", len(resp.Skipped)))
+	return resp, nil
+}
+
+// skipBulkDelete reports whether env must be left out of a bulk delete at
+// now, and why.
+func skipBulkDelete(env model.Environment, now time.Time) (string, bool) {
+	switch {
+	case env.Protected:
+		return "environment is protected", true
+	case env.Lock.Held(now):
+		return "environment is locked by " + env.Lock.Owner, true
+	}
+	return "", false
+}
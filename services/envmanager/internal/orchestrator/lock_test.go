@@ -0,0 +1,334 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+func asActor(actor string) context.Context {
+	return orchestrator.ContextWithActor(context.Background(), actor)
+}
+
+func TestLock_BlocksChangesUnlessOwnerForces(t *testing.T) {
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(&mockBuilder{}, o, orchestrator.WithRegistry(newFakeRegistry()))
+	seedEnvironment(t, s, model.Environment{ID: "env-1", Status: model.StatusReady})
+
+	env, err := orch.Lock(asActor("alice"), "env-1", model.LockRequest{Reason: "demo at 3pm"})
+	if err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if env.Lock == nil || env.Lock.Owner != "alice" || env.Lock.Reason != "demo at 3pm" {
+		t.Fatalf("expected a lock held by alice, got %+v", env.Lock)
+	}
+
+	overrides := model.ApplyOverridesRequest{Overrides: []model.PackageOverride{{PackageName: "pkg-a", Schema: "type Query { a: String }"}}}
+	for _, ctx := range []context.Context{
+		asActor("alice"),
+		asActor("bob"),
+		orchestrator.ContextWithForce(asActor("bob")),
+	} {
+		_, err := orch.ApplyOverrides(ctx, "env-1", overrides)
+		if !errors.Is(err, orchestrator.ErrLocked) {
+			t.Fatalf("overrides: expected ErrLocked, got %v", err)
+		}
+		if !strings.Contains(err.Error(), "alice") || !strings.Contains(err.Error(), "demo at 3pm") {
+			t.Fatalf("expected the error to name the owner and reason, got %q", err)
+		}
+		if _, err := orch.Promote(ctx, "env-1"); !errors.Is(err, orchestrator.ErrLocked) {
+			t.Fatalf("promote: expected ErrLocked, got %v", err)
+		}
+		if err := orch.DeleteEnvironment(ctx, "env-1"); !errors.Is(err, orchestrator.ErrLocked) {
+			t.Fatalf("delete: expected ErrLocked, got %v", err)
+		}
+	}
+	if len(o.teardownIDs) != 0 {
+		t.Fatalf("expected no teardowns, got %v", o.teardownIDs)
+	}
+
+	forced := orchestrator.ContextWithForce(asActor("alice"))
+	if _, err := orch.ApplyOverrides(forced, "env-1", overrides); err != nil {
+		t.Fatalf("forced overrides: %v", err)
+	}
+	if _, err := orch.Promote(forced, "env-1"); errors.Is(err, orchestrator.ErrLocked) {
+		t.Fatalf("forced promote: expected the lock to be overridden, got %v", err)
+	}
+	if err := orch.DeleteEnvironment(forced, "env-1"); err != nil {
+		t.Fatalf("forced delete: %v", err)
+	}
+	if _, err := s.Get(context.Background(), "env-1"); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("expected env-1 deleted, got %v", err)
+	}
+}
+
+func TestLock_Ownership(t *testing.T) {
+	orch, s := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	seedEnvironment(t, s, model.Environment{ID: "env-1", Status: model.StatusReady})
+
+	if _, err := orch.Lock(context.Background(), "env-1", model.LockRequest{}); !errors.Is(err, model.ErrInvalidLock) {
+		t.Fatalf("expected ErrInvalidLock without an actor, got %v", err)
+	}
+	if _, err := orch.Lock(asActor("alice"), "env-1", model.LockRequest{TTL: "soon"}); !errors.Is(err, model.ErrInvalidLock) {
+		t.Fatalf("expected ErrInvalidLock for a bad ttl, got %v", err)
+	}
+	if _, err := orch.Lock(asActor("alice"), "env-1", model.LockRequest{TTL: "1h"}); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	if _, err := orch.Lock(asActor("bob"), "env-1", model.LockRequest{}); !errors.Is(err, orchestrator.ErrLocked) {
+		t.Fatalf("expected bob unable to take alice's lock, got %v", err)
+	}
+	if _, err := orch.Unlock(asActor("bob"), "env-1"); !errors.Is(err, orchestrator.ErrLocked) {
+		t.Fatalf("expected bob unable to release alice's lock, got %v", err)
+	}
+
+	env, err := orch.Unlock(asActor("alice"), "env-1")
+	if err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if env.Lock != nil {
+		t.Fatalf("expected the lock released, got %+v", env.Lock)
+	}
+
+	events, err := orch.ListEvents(context.Background(), "env-1", store.EventFilter{})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	var types []model.EventType
+	for _, e := range events.Events {
+		types = append(types, e.Type)
+	}
+	if !slices.Contains(types, model.EventLocked) || !slices.Contains(types, model.EventUnlocked) {
+		t.Fatalf("expected locked and unlocked events, got %v", types)
+	}
+}
+
+func TestLock_Expired(t *testing.T) {
+	orch, s := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	past := time.Now().UTC().Add(-time.Minute)
+	seedEnvironment(t, s, model.Environment{ID: "env-1", Status: model.StatusReady,
+		Lock: &model.Lock{Owner: "alice", LockedAt: past.Add(-time.Hour), ExpiresAt: &past}})
+
+	if _, err := orch.Lock(asActor("bob"), "env-1", model.LockRequest{}); err != nil {
+		t.Fatalf("expected an expired lock to be taken over, got %v", err)
+	}
+	if _, err := orch.Unlock(asActor("bob"), "env-1"); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	if err := orch.DeleteEnvironment(asActor("carol"), "env-1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+}
+
+func TestUpdateEnvironment_Protected(t *testing.T) {
+	orch, s := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	seedEnvironment(t, s, model.Environment{ID: "env-1", Status: model.StatusReady})
+
+	protected := true
+	env, err := orch.UpdateEnvironment(context.Background(), "env-1", model.UpdateEnvironmentRequest{Protected: &protected})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if !env.Protected {
+		t.Fatal("expected the environment protected")
+	}
+
+	events, err := orch.ListEvents(context.Background(), "env-1", store.EventFilter{})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	if len(events.Events) != 1 || events.Events[0].Type != model.EventProtected {
+		t.Fatalf("expected one protected event, got %+v", events.Events)
+	}
+}
+
+func TestUpdateEnvironment_ProtectionRespectsLock(t *testing.T) {
+	orch, s := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	seedEnvironment(t, s, model.Environment{ID: "env-1", Status: model.StatusReady, Protected: true})
+	if _, err := orch.Lock(asActor("alice"), "env-1", model.LockRequest{}); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+
+	unprotect := false
+	for _, ctx := range []context.Context{
+		asActor("alice"),
+		asActor("bob"),
+		orchestrator.ContextWithForce(asActor("bob")),
+	} {
+		_, err := orch.UpdateEnvironment(ctx, "env-1", model.UpdateEnvironmentRequest{Protected: &unprotect})
+		if !errors.Is(err, orchestrator.ErrLocked) {
+			t.Fatalf("unprotect: expected ErrLocked, got %v", err)
+		}
+	}
+
+	// Other changes are not held up by the lock.
+	team := "payments"
+	if _, err := orch.UpdateEnvironment(asActor("bob"), "env-1", model.UpdateEnvironmentRequest{
+		Labels: map[string]*string{model.TeamLabel: &team},
+	}); err != nil {
+		t.Fatalf("update labels: %v", err)
+	}
+
+	env, err := orch.UpdateEnvironment(orchestrator.ContextWithForce(asActor("alice")), "env-1",
+		model.UpdateEnvironmentRequest{Protected: &unprotect})
+	if err != nil {
+		t.Fatalf("forced unprotect: %v", err)
+	}
+	if env.Protected {
+		t.Fatal("expected the environment unprotected")
+	}
+}
+
+func TestReap_SkipsProtectedAndLocked(t *testing.T) {
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(&mockBuilder{}, o)
+	past := time.Now().UTC().Add(-time.Hour)
+	seedEnvironment(t, s, model.Environment{ID: "env-protected", Status: model.StatusReady, ExpiresAt: &past, Protected: true})
+	seedEnvironment(t, s, model.Environment{ID: "env-locked", Status: model.StatusReady, ExpiresAt: &past,
+		Lock: &model.Lock{Owner: "alice", LockedAt: past}})
+	seedEnvironment(t, s, model.Environment{ID: "env-expired", Status: model.StatusReady, ExpiresAt: &past})
+
+	resp, err := orch.Reap(context.Background(), false)
+	if err != nil {
+		t.Fatalf("reap: %v", err)
+	}
+	if len(resp.Environments) != 1 || resp.Environments[0].EnvironmentID != "env-expired" {
+		t.Fatalf("expected only env-expired reaped, got %+v", resp.Environments)
+	}
+	for _, id := range []string{"env-protected", "env-locked"} {
+		if _, err := s.Get(context.Background(), id); err != nil {
+			t.Fatalf("expected %s kept, got %v", id, err)
+		}
+	}
+}
+
+func TestBulkDelete(t *testing.T) {
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(&mockBuilder{}, o)
+	seedEnvironment(t, s, model.Environment{ID: "env-a", Branch: "feature", Status: model.StatusReady})
+	seedEnvironment(t, s, model.Environment{ID: "env-protected", Branch: "feature", Status: model.StatusReady, Protected: true})
+	seedEnvironment(t, s, model.Environment{ID: "env-locked", Branch: "feature", Status: model.StatusReady,
+		Lock: &model.Lock{Owner: "alice", LockedAt: time.Now().UTC()}})
+	seedEnvironment(t, s, model.Environment{ID: "env-other", Branch: "main", Status: model.StatusReady})
+
+	// Even the lock's owner can't force a bulk delete through their lock.
+	ctx := orchestrator.ContextWithForce(asActor("alice"))
+	filter := store.ListFilter{Branch: "feature"}
+
+	dry, err := orch.BulkDelete(ctx, filter, true)
+	if err != nil {
+		t.Fatalf("dry run: %v", err)
+	}
+	if !dry.DryRun || len(dry.Deleted) != 1 || dry.Deleted[0].EnvironmentID != "env-a" || len(dry.Skipped) != 2 {
+		t.Fatalf("expected a dry run deleting env-a and skipping 2, got %+v", dry)
+	}
+	if len(o.teardownIDs) != 0 {
+		t.Fatalf("expected no teardowns in a dry run, got %v", o.teardownIDs)
+	}
+
+	resp, err := orch.BulkDelete(ctx, filter, false)
+	if err != nil {
+		t.Fatalf("bulk delete: %v", err)
+	}
+	if len(resp.Deleted) != 1 || resp.Deleted[0].EnvironmentID != "env-a" {
+		t.Fatalf("expected env-a deleted, got %+v", resp)
+	}
+	skipped := make(map[string]string)
+	for _, r := range resp.Skipped {
+		skipped[r.EnvironmentID] = r.Reason
+	}
+	if skipped["env-protected"] != "environment is protected" || !strings.Contains(skipped["env-locked"], "alice") {
+		t.Fatalf("expected env-protected and env-locked skipped with reasons, got %+v", resp.Skipped)
+	}
+	if _, err := s.Get(context.Background(), "env-a"); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("expected env-a deleted, got %v", err)
+	}
+	for _, id := range []string{"env-protected", "env-locked", "env-other"} {
+		if _, err := s.Get(context.Background(), id); err != nil {
+			t.Fatalf("expected %s kept, got %v", id, err)
+		}
+	}
+}
+
+// protectingStore protects an environment right after the first read of
+// the given kind, as a PATCH landing between a bulk delete or reap selecting
+// the environment and deleting it would.
+type protectingStore struct {
+	*store.MemoryStore
+	id    string
+	after string // "List" or "Get"
+}
+
+func (s *protectingStore) List(ctx context.Context, filter store.ListFilter) (store.ListResult, error) {
+	result, err := s.MemoryStore.List(ctx, filter)
+	s.protect(ctx, "List")
+	return result, err
+}
+
+func (s *protectingStore) Get(ctx context.Context, id string) (model.Environment, error) {
+	env, err := s.MemoryStore.Get(ctx, id)
+	s.protect(ctx, "Get")
+	return env, err
+}
+
+func (s *protectingStore) protect(ctx context.Context, read string) {
+	if s.after != read {
+		return
+	}
+	s.after = ""
+	env, err := s.MemoryStore.Get(ctx, s.id)
+	if err != nil {
+		return
+	}
+	env.Protected = true
+	_, _ = s.MemoryStore.Update(ctx, env)
+}
+
+func TestBulkDeleteAndReap_ProtectedAfterSelection(t *testing.T) {
+	past := time.Now().UTC().Add(-time.Hour)
+	for _, tc := range []struct {
+		name   string
+		after  string
+		delete func(orch *orchestrator.Orchestrator) (int, error)
+	}{
+		{"bulk delete", "List", func(orch *orchestrator.Orchestrator) (int, error) {
+			resp, err := orch.BulkDelete(context.Background(), store.ListFilter{}, false)
+			return len(resp.Deleted), err
+		}},
+		{"reap", "Get", func(orch *orchestrator.Orchestrator) (int, error) {
+			resp, err := orch.Reap(context.Background(), false)
+			return len(resp.Environments), err
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := &protectingStore{MemoryStore: store.NewMemoryStore(), id: "env-1"}
+			seedEnvironment(t, s, model.Environment{ID: "env-1", Status: model.StatusReady, ExpiresAt: &past})
+			s.after = tc.after
+			orch := orchestrator.New(s, &mockBuilder{}, &mockOperator{}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			deleted, err := tc.delete(orch)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if deleted != 0 {
+				t.Fatalf("expected nothing deleted, got %d", deleted)
+			}
+			env, err := s.MemoryStore.Get(context.Background(), "env-1")
+			if err != nil {
+				t.Fatalf("expected env-1 kept, got %v", err)
+			}
+			if env.Status != model.StatusReady {
+				t.Fatalf("expected env-1 still ready, got %s", env.Status)
+			}
+		})
+	}
+}
@@ -1,20 +1,31 @@
 // Package orchestrator implements the fork workflow:
 // create env -> apply overrides -> call builder -> call operator -> provide preview URL.
+//
+// Builds and deploys run asynchronously: API calls persist the environment in
+// the building state and return immediately, while a background workflow
+// drives it through the build, deploy and rollout stages.
 package orchestrator
 
 import (
 	"context"
 	"crypto/rand"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/labels"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/secrets"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
 )
 
@@ -25,34 +36,161 @@ var tracer = otel.Tracer("envmanager/orchestrator")
 type BuilderClient interface {
 	// TriggerBuild starts a build for the given environment and returns a build ID.
 	TriggerBuild(ctx context.Context, env model.Environment) (buildID string, err error)
+
+	// GetBuild returns the current state of a previously triggered build.
+	GetBuild(ctx context.Context, buildID string) (model.Build, error)
 }
 
 // OperatorClient is the interface for deploying built artifacts.
 // In production this calls the Operator service; in tests it is mocked.
 type OperatorClient interface {
-	// Deploy deploys the given build and returns a preview URL.
-	Deploy(ctx context.Context, env model.Environment, buildID string) (previewURL string, err error)
+	// Deploy deploys the given build and returns a preview URL. secrets
+	// holds the values of the secrets env's overrides reference, by name,
+	// for the operator to materialize; they must never be logged.
+	Deploy(ctx context.Context, env model.Environment, buildID string, secrets map[string]string) (previewURL string, err error)
+
+	// GetStatus returns the operator's observed deployment state for an environment.
+	GetStatus(ctx context.Context, envID string) (model.DeploymentStatus, error)
 
 	// Teardown removes deployed resources for an environment.
 	Teardown(ctx context.Context, envID string) error
+
+	// Sleep scales all of an environment's components to zero replicas.
+	Sleep(ctx context.Context, envID string) error
+
+	// Wake scales a sleeping environment's components back to their
+	// deployed replica counts.
+	Wake(ctx context.Context, envID string) error
+}
+
+// RegistryClient is the interface for reading and publishing packages.
+// In production this calls the Registry service; in tests it is mocked.
+type RegistryClient interface {
+	// GetPackage returns a single package version.
+	GetPackage(ctx context.Context, name, version string) (model.Package, error)
+
+	// ResolveDependencies returns the transitive dependencies of a package
+	// version, excluding the package itself.
+	ResolveDependencies(ctx context.Context, name, version string) ([]model.Package, error)
+
+	// Publish creates a new package version.
+	Publish(ctx context.Context, pkg model.Package) (model.Package, error)
+
+	// Yank withdraws a published package version.
+	Yank(ctx context.Context, name, version string) error
 }
 
+// Default timings for the asynchronous build/deploy workflow.
+const (
+	defaultPollInterval   = 2 * time.Second
+	defaultBuildTimeout   = 10 * time.Minute
+	defaultRolloutTimeout = 5 * time.Minute
+)
+
 // Orchestrator coordinates the fork workflow using a store, builder, and operator.
 type Orchestrator struct {
 	store    store.Store
 	builder  BuilderClient
 	operator OperatorClient
+	registry RegistryClient
+	events   store.EventLog
 	logger   *slog.Logger
+
+	// cipher encrypts secrets, which are kept in secretStore. Secrets are
+	// disabled without a cipher.
+	cipher      *secrets.Cipher
+	secretStore store.SecretStore
+
+	templateStore store.TemplateStore
+
+	pollInterval   time.Duration
+	buildTimeout   time.Duration
+	rolloutTimeout time.Duration
+	idleTimeout    time.Duration
+	quotas         model.Quotas
+	idleSleep      time.Duration
+	smokeTester    SmokeTester
+	degradeOnSmoke bool
+	uniqueNames    bool
+
+	// mu serialises read-modify-write cycles on stored environments so the
+	// background workflow and API calls don't clobber each other's updates.
+	mu sync.Mutex
+
+	// workflows tracks the in-flight workflow for each environment ID.
+	workflowsMu sync.Mutex
+	workflows   map[string]*workflowRun
+	wg          sync.WaitGroup
+}
+
+// Option configures optional Orchestrator behaviour.
+type Option func(*Orchestrator)
+
+// WithPollInterval sets how often the workflow polls the builder and operator.
+func WithPollInterval(d time.Duration) Option {
+	return func(o *Orchestrator) { o.pollInterval = d }
+}
+
+// WithBuildTimeout bounds how long the workflow waits for a build to finish.
+func WithBuildTimeout(d time.Duration) Option {
+	return func(o *Orchestrator) { o.buildTimeout = d }
+}
+
+// WithRolloutTimeout bounds how long the workflow waits for the operator to
+// report the environment as Running.
+func WithRolloutTimeout(d time.Duration) Option {
+	return func(o *Orchestrator) { o.rolloutTimeout = d }
+}
+
+// WithIdleTimeout makes environments with no activity for d eligible for
+// reaping. Zero, the default, disables idle expiry.
+func WithIdleTimeout(d time.Duration) Option {
+	return func(o *Orchestrator) { o.idleTimeout = d }
+}
+
+// WithRegistry sets the registry that Promote publishes to. Without one,
+// Promote fails.
+func WithRegistry(r RegistryClient) Option {
+	return func(o *Orchestrator) { o.registry = r }
 }
 
 // New creates a new Orchestrator.
-func New(s store.Store, b BuilderClient, o OperatorClient, logger *slog.Logger) *Orchestrator {
-	return &Orchestrator{
-		store:    s,
-		builder:  b,
-		operator: o,
-		logger:   logger,
+func New(s store.Store, b BuilderClient, o OperatorClient, logger *slog.Logger, opts ...Option) *Orchestrator {
+	orch := &Orchestrator{
+		store:          s,
+		builder:        b,
+		operator:       o,
+		logger:         logger,
+		pollInterval:   defaultPollInterval,
+		buildTimeout:   defaultBuildTimeout,
+		rolloutTimeout: defaultRolloutTimeout,
+		workflows:      make(map[string]*workflowRun),
 	}
+	for _, opt := range opts {
+		opt(orch)
+	}
+	if orch.events == nil {
+		if l, ok := s.(store.EventLog); ok {
+			orch.events = l
+		} else {
+			orch.events = store.NewMemoryStore()
+		}
+	}
+	if orch.secretStore == nil {
+		if ss, ok := s.(store.SecretStore); ok {
+			orch.secretStore = ss
+		} else {
+			orch.secretStore = store.NewMemoryStore()
+		}
+	}
+	if orch.templateStore == nil {
+		if ts, ok := s.(store.TemplateStore); ok {
+			orch.templateStore = ts
+		} else {
+			orch.templateStore = store.NewMemoryStore()
+		}
+	}
+	return orch
 }
 
 // generateID produces a random hex ID.
@@ -65,27 +203,122 @@ func generateID() string {
 }
 
 // CreateEnvironment creates a new environment and persists it.
-// If overrides are provided and triggerBuild is implicit, it also triggers a build.
+// If overrides are provided, it also starts the asynchronous build/deploy
+// workflow and returns the environment in the building state.
+//
+// With ParentID set, the environment is forked from another environment: it
+// inherits the parent's base and overrides, and if it adds no overrides of its
+// own it deploys the parent's current build instead of building again.
+//
+// With Template set, the environment starts from the template's base,
+// overrides, labels, TTL and sleep schedule, and counts against its quotas.
+//
+// With unique names enforced, creating an environment whose name and branch
+// are taken returns the existing environment and ErrEnvironmentExists.
 func (o *Orchestrator) CreateEnvironment(ctx context.Context, req model.CreateEnvironmentRequest) (model.Environment, error) {
 	ctx, span := tracer.Start(ctx, "Orchestrator.CreateEnvironment",
 		trace.WithAttributes(attribute.String("env.name", req.Name)))
 	defer span.End()
 
+	var tmpl model.Template
+	if req.Template != "" {
+		var err error
+		if tmpl, err = o.applyTemplate(ctx, &req); err != nil {
+			span.RecordError(err)
+			return model.Environment{}, fmt.Errorf("create environment: %w", err)
+		}
+	}
 	now := time.Now().UTC()
+	expiresAt, err := req.Expiry(now)
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("create environment: %w", err)
+	}
+	ownOverrideCount := len(req.Overrides)
+	var parent model.Environment
+	if req.ParentID != "" {
+		if parent, err = o.forkParent(ctx, &req); err != nil {
+			span.RecordError(err)
+			return model.Environment{}, fmt.Errorf("create environment: %w", err)
+		}
+	}
+	if err := o.validateOverrides(ctx, req.BaseRootPackage, req.BaseRootVersion, req.Overrides); err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("create environment: %w", err)
+	}
+	if err := labels.Validate(req.Labels); err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("create environment: %w", err)
+	}
+	if err := o.checkFootprint(req.BaseRootPackage, req.Overrides); err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("create environment: %w", err)
+	}
+	if err := checkTemplateFootprint(tmpl, req.BaseRootPackage, req.Overrides); err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("create environment: %w", err)
+	}
+	if err := req.SleepSchedule.Validate(); err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("create environment: %w", err)
+	}
+	if req.SleepSchedule.IsZero() {
+		req.SleepSchedule = nil
+	}
+	secretNames, err := o.initialSecrets(ctx, parent.ID, req.Secrets)
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("create environment: %w", err)
+	}
+	if err := checkSecretRefs(req.Overrides, secretNames); err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("create environment: %w", err)
+	}
+	// Quotas are counted against the creator, so an authenticated actor
+	// takes precedence over whatever the body claims.
+	if actor := ActorFromContext(ctx); actor != "" {
+		req.CreatedBy = actor
+	}
 	env := model.Environment{
-		ID:              generateID(),
-		Name:            req.Name,
-		BaseRootPackage: req.BaseRootPackage,
-		BaseRootVersion: req.BaseRootVersion,
-		Branch:          req.Branch,
-		CreatedBy:       req.CreatedBy,
-		Status:          model.StatusCreating,
-		Overrides:       req.Overrides,
-		CreatedAt:       now,
-		UpdatedAt:       now,
-	}
-
-	created, err := o.store.Create(ctx, env)
+		ID:                 generateID(),
+		Name:               req.Name,
+		BaseRootPackage:    req.BaseRootPackage,
+		BaseRootVersion:    req.BaseRootVersion,
+		Branch:             req.Branch,
+		CreatedBy:          req.CreatedBy,
+		Labels:             req.Labels,
+		Status:             model.StatusCreating,
+		Overrides:          req.Overrides,
+		ParentID:           parent.ID,
+		InheritedOverrides: model.CloneOverrides(parent.Overrides),
+		Template:           tmpl.Name,
+		SleepSchedule:      req.SleepSchedule,
+		ExpiresAt:          expiresAt,
+		Protected:          req.Protected,
+		LastActivityAt:     now,
+		CreatedAt:          now,
+		UpdatedAt:          now,
+	}
+
+	// Store the secrets first so the environment never exists without them.
+	if len(secretNames) > 0 {
+		if err := o.storeInitialSecrets(ctx, env.ID, parent.ID, req.Secrets, now); err != nil {
+			o.discardSecrets(ctx, env.ID)
+			span.RecordError(err)
+			return model.Environment{}, fmt.Errorf("create environment: %w", err)
+		}
+	}
+	created, err := o.createWithinQuota(ctx, env, tmpl)
+	if err != nil && len(secretNames) > 0 {
+		o.discardSecrets(ctx, env.ID)
+	}
+	if errors.Is(err, ErrEnvironmentExists) {
+		o.logger.InfoContext(ctx, "environment already exists",
+			slog.String("id", created.ID),
+			slog.String("name", created.Name),
+			slog.String("branch", created.Branch))
+		return created, fmt.Errorf("create environment: %w", err)
+	}
 	if err != nil {
 		span.RecordError(err)
 		return model.Environment{}, fmt.Errorf("create environment: %w", err)
@@ -93,31 +326,50 @@ func (o *Orchestrator) CreateEnvironment(ctx context.Context, req model.CreateEn
 
 	o.logger.InfoContext(ctx, "environment created",
 		slog.String("id", created.ID),
-		slog.String("name", created.Name))
+		slog.String("name", created.Name),
+		slog.String("parentId", created.ParentID))
+	createdEvent := model.Event{
+		EnvironmentID: created.ID,
+		Type:          model.EventCreated,
+		Actor:         ActorFromContext(ctx),
+		Status:        created.Status,
+	}
+	if createdEvent.Actor == "" {
+		createdEvent.Actor = created.CreatedBy
+	}
+	if created.ParentID != "" {
+		createdEvent.Message = "forked from " + created.ParentID
+	}
+	if created.Template != "" {
+		createdEvent.Message = "created from template " + created.Template
+	}
+	if change := model.DiffOverrides(nil, created.Overrides); !change.Empty() {
+		createdEvent.Overrides = &change
+	}
+	o.record(ctx, createdEvent)
+
+	// A fork that changes nothing can run its parent's current build.
+	if ownOverrideCount == 0 && parent.Status == model.StatusReady && parent.CurrentBuildID != "" {
+		building, err := o.startWorkflowFromBuild(ctx, created.ID, parent.CurrentBuildID, model.BuildSourceParent)
+		if err != nil {
+			span.RecordError(err)
+			return model.Environment{}, fmt.Errorf("start deploy: %w", err)
+		}
+		return building, nil
+	}
 
 	// If overrides were provided at creation time, kick off a build.
 	if len(req.Overrides) > 0 {
-		built, err := o.buildAndDeploy(ctx, created)
+		building, err := o.startWorkflow(ctx, created.ID)
 		if err != nil {
-			o.logger.ErrorContext(ctx, "initial build failed",
-				slog.String("id", created.ID),
-				slog.String("error", err.Error()))
-			// Mark as failed but still return the environment.
-			created.Status = model.StatusFailed
-			created.UpdatedAt = time.Now().UTC()
-			if _, updateErr := o.store.Update(ctx, created); updateErr != nil {
-				o.logger.ErrorContext(ctx, "failed to update status after build failure",
-					slog.String("error", updateErr.Error()))
-			}
-			return created, nil
+			span.RecordError(err)
+			return model.Environment{}, fmt.Errorf("start build: %w", err)
 		}
-		return built, nil
+		return building, nil
 	}
 
 	// No overrides yet; mark as ready.
-	created.Status = model.StatusReady
-	created.UpdatedAt = time.Now().UTC()
-	created, err = o.store.Update(ctx, created)
+	created, err = o.transition(ctx, created.ID, model.StatusReady, nil)
 	if err != nil {
 		span.RecordError(err)
 		return model.Environment{}, fmt.Errorf("update environment status: %w", err)
@@ -126,6 +378,32 @@ func (o *Orchestrator) CreateEnvironment(ctx context.Context, req model.CreateEn
 	return created, nil
 }
 
+// createWithinQuota stores env unless its user, team or template tmpl is out
+// of quota. When unique names are enforced and env's name and branch are
+// taken, it returns the existing environment and ErrEnvironmentExists
+// instead.
+func (o *Orchestrator) createWithinQuota(ctx context.Context, env model.Environment, tmpl model.Template) (model.Environment, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if o.uniqueNames {
+		existing, ok, err := o.findByNameAndBranch(ctx, env.Name, env.Branch)
+		if err != nil {
+			return model.Environment{}, err
+		}
+		if ok {
+			return existing, ErrEnvironmentExists
+		}
+	}
+	if err := o.checkEnvironmentQuotas(ctx, env); err != nil {
+		return model.Environment{}, err
+	}
+	if err := o.checkTemplateQuota(ctx, tmpl); err != nil {
+		return model.Environment{}, err
+	}
+	return o.store.Create(ctx, env)
+}
+
 // GetEnvironment retrieves an environment by ID.
 func (o *Orchestrator) GetEnvironment(ctx context.Context, id string) (model.Environment, error) {
 	ctx, span := tracer.Start(ctx, "Orchestrator.GetEnvironment",
@@ -150,58 +428,125 @@ func (o *Orchestrator) ListEnvironments(ctx context.Context, filter store.ListFi
 
 // DeleteEnvironment tears down and deletes an environment.
 func (o *Orchestrator) DeleteEnvironment(ctx context.Context, id string) error {
+	return o.deleteEnvironment(ctx, id, "", false)
+}
+
+// deleteEnvironment is DeleteEnvironment, recording reason on the deleted
+// event. With spareProtected set a protected environment is not deleted but
+// is ErrProtected, checked as it moves to deleting so that protecting it
+// after it was selected for deletion still counts.
+func (o *Orchestrator) deleteEnvironment(ctx context.Context, id, reason string, spareProtected bool) error {
 	ctx, span := tracer.Start(ctx, "Orchestrator.DeleteEnvironment",
 		trace.WithAttributes(attribute.String("env.id", id)))
 	defer span.End()
 
-	env, err := o.store.Get(ctx, id)
-	if err != nil {
+	if _, err := o.store.Get(ctx, id); err != nil {
 		span.RecordError(err)
 		return err
 	}
 
-	// Mark as deleting.
-	env.Status = model.StatusDeleting
-	env.UpdatedAt = time.Now().UTC()
-	if _, err := o.store.Update(ctx, env); err != nil {
+	// Mark as deleting and record the teardown so a restart can finish it.
+	if _, err := o.transition(ctx, id, model.StatusDeleting, func(env *model.Environment) error {
+		if spareProtected && env.Protected {
+			return ErrProtected
+		}
+		if err := checkLock(ctx, *env, time.Now().UTC()); err != nil {
+			return err
+		}
+		env.Pending = newPendingOperation(model.OperationTeardown)
+		return nil
+	}); err != nil {
 		span.RecordError(err)
 		return fmt.Errorf("update environment status: %w", err)
 	}
 
-	// Teardown deployed resources.
+	// Only now that the delete is accepted, stop any in-flight build/deploy
+	// before tearing down. Once deleting, the workflow can no longer change
+	// the environment's status.
+	o.cancelWorkflow(id)
+
+	if err := o.teardown(ctx, id, reason); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// teardown removes deployed resources and deletes an environment that is
+// already in the deleting state, recording reason on the deleted event.
+func (o *Orchestrator) teardown(ctx context.Context, id, reason string) error {
 	if err := o.operator.Teardown(ctx, id); err != nil {
 		o.logger.ErrorContext(ctx, "teardown failed",
 			slog.String("id", id),
 			slog.String("error", err.Error()))
-		span.RecordError(err)
 		// Continue with deletion even if teardown fails.
 	}
 
 	if err := o.store.Delete(ctx, id); err != nil {
-		span.RecordError(err)
 		return fmt.Errorf("delete environment: %w", err)
 	}
+	o.discardSecrets(ctx, id)
 
 	o.logger.InfoContext(ctx, "environment deleted", slog.String("id", id))
+	o.record(ctx, model.Event{EnvironmentID: id, Type: model.EventDeleted, Message: reason})
 	return nil
 }
 
-// ApplyOverrides applies package overrides to an environment and optionally triggers a build.
+// ApplyOverrides applies package overrides to an environment and optionally
+// starts the asynchronous build/deploy workflow.
 func (o *Orchestrator) ApplyOverrides(ctx context.Context, id string, req model.ApplyOverridesRequest) (model.Environment, error) {
 	ctx, span := tracer.Start(ctx, "Orchestrator.ApplyOverrides",
 		trace.WithAttributes(attribute.String("env.id", id)))
 	defer span.End()
 
-	env, err := o.store.Get(ctx, id)
+	current, err := o.store.Get(ctx, id)
 	if err != nil {
 		span.RecordError(err)
 		return model.Environment{}, err
 	}
+	if err := o.validateOverrides(ctx, current.BaseRootPackage, current.BaseRootVersion, req.Overrides); err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("update overrides: %w", err)
+	}
+	if err := o.checkFootprint(current.BaseRootPackage, req.Overrides); err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("update overrides: %w", err)
+	}
+	tmpl, err := o.environmentTemplate(ctx, current)
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("update overrides: %w", err)
+	}
+	if err := checkTemplateFootprint(tmpl, current.BaseRootPackage, req.Overrides); err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("update overrides: %w", err)
+	}
 
-	env.Overrides = req.Overrides
-	env.UpdatedAt = time.Now().UTC()
-
-	env, err = o.store.Update(ctx, env)
+	var change model.OverridesChange
+	env, err := o.mutate(ctx, id, func(env *model.Environment) error {
+		if env.Status == model.StatusDeleting {
+			return fmt.Errorf("%w: environment is being deleted", model.ErrInvalidTransition)
+		}
+		if err := checkLock(ctx, *env, time.Now().UTC()); err != nil {
+			return err
+		}
+		if req.TriggerBuild {
+			if err := model.ValidateTransition(env.Status, model.StatusBuilding); err != nil {
+				return err
+			}
+		}
+		names, err := o.secretNames(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := checkSecretRefs(req.Overrides, names); err != nil {
+			return err
+		}
+		change = model.DiffOverrides(env.Overrides, req.Overrides)
+		env.Overrides = req.Overrides
+		env.LastActivityAt = time.Now().UTC()
+		return nil
+	})
 	if err != nil {
 		span.RecordError(err)
 		return model.Environment{}, fmt.Errorf("update overrides: %w", err)
@@ -210,112 +555,163 @@ func (o *Orchestrator) ApplyOverrides(ctx context.Context, id string, req model.
 	o.logger.InfoContext(ctx, "overrides applied",
 		slog.String("id", id),
 		slog.Int("overrideCount", len(req.Overrides)))
+	o.record(ctx, model.Event{
+		EnvironmentID: id,
+		Type:          model.EventOverridesApplied,
+		Status:        env.Status,
+		Overrides:     &change,
+	})
 
 	if req.TriggerBuild {
-		built, err := o.buildAndDeploy(ctx, env)
+		building, err := o.startWorkflow(ctx, id)
 		if err != nil {
-			o.logger.ErrorContext(ctx, "build after override failed",
-				slog.String("id", id),
-				slog.String("error", err.Error()))
-			env.Status = model.StatusFailed
-			env.UpdatedAt = time.Now().UTC()
-			if _, updateErr := o.store.Update(ctx, env); updateErr != nil {
-				o.logger.ErrorContext(ctx, "failed to update status after build failure",
-					slog.String("error", updateErr.Error()))
-			}
-			return env, nil
+			span.RecordError(err)
+			return model.Environment{}, fmt.Errorf("start build: %w", err)
 		}
-		return built, nil
+		return building, nil
 	}
 
 	return env, nil
 }
 
-// Promote promotes overrides from a fork environment back to the base.
-// Returns the list of promoted packages.
-func (o *Orchestrator) Promote(ctx context.Context, id string) (model.PromoteResponse, error) {
-	ctx, span := tracer.Start(ctx, "Orchestrator.Promote",
+// UpdateEnvironment merges req's labels into the environment's, removing
+// those set to null, replaces its sleep schedule if req has one and protects
+// or unprotects it. Changing protection is subject to the environment's
+// lock, and moving the environment to another team to that team's quota. Labels reach the operator's resources on the next
+// deploy.
+func (o *Orchestrator) UpdateEnvironment(ctx context.Context, id string, req model.UpdateEnvironmentRequest) (model.Environment, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.UpdateEnvironment",
 		trace.WithAttributes(attribute.String("env.id", id)))
 	defer span.End()
 
-	env, err := o.store.Get(ctx, id)
-	if err != nil {
+	if err := req.SleepSchedule.Validate(); err != nil {
 		span.RecordError(err)
-		return model.PromoteResponse{}, err
+		return model.Environment{}, fmt.Errorf("update environment: %w", err)
 	}
 
-	// Build the promote response from current overrides.
-	promoted := make([]model.PromotedPackage, 0, len(env.Overrides))
-	for _, override := range env.Overrides {
-		promoted = append(promoted, model.PromotedPackage{
-			Name:    override.PackageName,
-			Version: env.BaseRootVersion,
-		})
-	}
-
-	// Clear overrides after promotion.
-	env.Overrides = nil
-	env.UpdatedAt = time.Now().UTC()
-	if _, err := o.store.Update(ctx, env); err != nil {
+	protectionChanged := false
+	env, err := o.mutate(ctx, id, func(env *model.Environment) error {
+		if env.Status == model.StatusDeleting {
+			return fmt.Errorf("%w: environment is being deleted", model.ErrInvalidTransition)
+		}
+		merged := maps.Clone(env.Labels)
+		if merged == nil {
+			merged = make(map[string]string, len(req.Labels))
+		}
+		for k, v := range req.Labels {
+			if v == nil {
+				delete(merged, k)
+			} else {
+				merged[k] = *v
+			}
+		}
+		if err := labels.Validate(merged); err != nil {
+			return err
+		}
+		if team := merged[model.TeamLabel]; team != env.Labels[model.TeamLabel] {
+			if err := o.checkTeamQuota(ctx, team); err != nil {
+				return err
+			}
+		}
+		if len(merged) == 0 {
+			merged = nil
+		}
+		env.Labels = merged
+		if req.SleepSchedule != nil {
+			env.SleepSchedule = nil
+			if !req.SleepSchedule.IsZero() {
+				env.SleepSchedule = req.SleepSchedule.Clone()
+			}
+		}
+		if req.Protected != nil && *req.Protected != env.Protected {
+			// Otherwise anyone could unprotect a locked environment and
+			// then bulk delete it.
+			if err := checkLock(ctx, *env, time.Now().UTC()); err != nil {
+				return err
+			}
+			env.Protected = *req.Protected
+			protectionChanged = true
+		}
+		return nil
+	})
+	if err != nil {
 		span.RecordError(err)
-		return model.PromoteResponse{}, fmt.Errorf("clear overrides after promote: %w", err)
+		return model.Environment{}, fmt.Errorf("update environment: %w", err)
 	}
 
-	o.logger.InfoContext(ctx, "environment promoted",
+	o.logger.InfoContext(ctx, "environment updated",
 		slog.String("id", id),
-		slog.Int("promotedCount", len(promoted)))
-
-	return model.PromoteResponse{PromotedPackages: promoted}, nil
+		slog.Int("labelCount", len(env.Labels)))
+	if len(req.Labels) > 0 {
+		o.record(ctx, model.Event{
+			EnvironmentID: id,
+			Type:          model.EventLabelsUpdated,
+			Message:       labelChanges(req.Labels),
+			Status:        env.Status,
+		})
+	}
+	if protectionChanged {
+		eventType := model.EventUnprotected
+		if env.Protected {
+			eventType = model.EventProtected
+		}
+		o.record(ctx, model.Event{EnvironmentID: id, Type: eventType, Status: env.Status})
+	}
+	return env, nil
 }
 
-// buildAndDeploy triggers a build and then deploys the result.
-func (o *Orchestrator) buildAndDeploy(ctx context.Context, env model.Environment) (model.Environment, error) {
-	ctx, span := tracer.Start(ctx, "Orchestrator.buildAndDeploy",
-		trace.WithAttributes(attribute.String("env.id", env.ID)))
-	defer span.End()
-
-	// Mark as building.
-	env.Status = model.StatusBuilding
-	env.UpdatedAt = time.Now().UTC()
-	env, err := o.store.Update(ctx, env)
-	if err != nil {
-		return model.Environment{}, fmt.Errorf("update status to building: %w", err)
+// labelChanges describes a label patch, such as "team=payments, -purpose".
+func labelChanges(patch map[string]*string) string {
+	changes := make([]string, 0, len(patch))
+	for _, k := range slices.Sorted(maps.Keys(patch)) {
+		if v := patch[k]; v != nil {
+			changes = append(changes, k+"="+*v)
+		} else {
+			changes = append(changes, "-"+k)
+		}
 	}
+	return strings.Join(changes, ", ")
+}
 
-	// Call the builder.
-	buildID, err := o.builder.TriggerBuild(ctx, env)
-	if err != nil {
-		span.RecordError(err)
-		return model.Environment{}, fmt.Errorf("trigger build: %w", err)
-	}
+// mutate applies fn to the stored environment and persists the result,
+// bumping UpdatedAt. It is the single read-modify-write path shared by API
+// calls and background workflows.
+func (o *Orchestrator) mutate(ctx context.Context, id string, fn func(env *model.Environment) error) (model.Environment, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
 
-	env.CurrentBuildID = buildID
-	env.UpdatedAt = time.Now().UTC()
-	env, err = o.store.Update(ctx, env)
+	env, err := o.store.Get(ctx, id)
 	if err != nil {
-		return model.Environment{}, fmt.Errorf("update build ID: %w", err)
+		return model.Environment{}, err
 	}
-
-	// Call the operator to deploy.
-	previewURL, err := o.operator.Deploy(ctx, env, buildID)
-	if err != nil {
-		span.RecordError(err)
-		return model.Environment{}, fmt.Errorf("deploy: %w", err)
+	if err := fn(&env); err != nil {
+		return model.Environment{}, err
 	}
-
-	// Mark as ready with preview URL.
-	env.Status = model.StatusReady
-	env.PreviewURL = previewURL
 	env.UpdatedAt = time.Now().UTC()
-	env, err = o.store.Update(ctx, env)
-	if err != nil {
-		return model.Environment{}, fmt.Errorf("update after deploy: %w", err)
-	}
+	return o.store.Update(ctx, env)
+}
 
-	o.logger.InfoContext(ctx, "build and deploy complete",
-		slog.String("id", env.ID),
-		slog.String("buildId", buildID),
-		slog.String("previewUrl", previewURL))
+// transition moves an environment to status to, rejecting the change with a
+// *model.TransitionError if the state machine does not allow it. fn, if
+// non-nil, may make further changes in the same write.
+func (o *Orchestrator) transition(ctx context.Context, id string, to model.EnvironmentStatus, fn func(env *model.Environment) error) (model.Environment, error) {
+	return o.mutate(ctx, id, func(env *model.Environment) error {
+		if err := model.ValidateTransition(env.Status, to); err != nil {
+			return err
+		}
+		env.Status = to
+		if fn != nil {
+			return fn(env)
+		}
+		return nil
+	})
+}
 
-	return env, nil
+// newPendingOperation records the first attempt at an operation.
+func newPendingOperation(typ model.OperationType) *model.PendingOperation {
+	return &model.PendingOperation{
+		Type:      typ,
+		StartedAt: time.Now().UTC(),
+		Attempts:  1,
+	}
 }
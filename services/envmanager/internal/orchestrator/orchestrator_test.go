@@ -5,7 +5,11 @@ import (
 	"errors"
 	"log/slog"
 	"os"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
@@ -15,42 +19,134 @@ import (
 // --- Mock Builder ---
 
 type mockBuilder struct {
+	mu      sync.Mutex
 	buildID string
 	err     error
 	called  int
+
+	// statuses is returned by successive GetBuild calls; the last entry
+	// repeats. Defaults to an immediately succeeded build.
+	statuses []model.BuildStatus
+	polls    int
 }
 
 func (m *mockBuilder) TriggerBuild(_ context.Context, _ model.Environment) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.called++
 	return m.buildID, m.err
 }
 
+func (m *mockBuilder) GetBuild(_ context.Context, buildID string) (model.Build, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	status := model.BuildStatusSucceeded
+	if len(m.statuses) > 0 {
+		idx := m.polls
+		if idx >= len(m.statuses) {
+			idx = len(m.statuses) - 1
+		}
+		status = m.statuses[idx]
+	}
+	m.polls++
+	return model.Build{ID: buildID, Status: status, ErrorMessage: "compose failed"}, nil
+}
+
 // --- Mock Operator ---
 
 type mockOperator struct {
+	mu          sync.Mutex
 	previewURL  string
 	deployErr   error
 	teardownErr error
 	deployCalls int
-	teardownIDs []string
+	// deploySecrets are the secrets passed to the last Deploy.
+	deploySecrets map[string]string
+	teardownIDs   []string
+	sleepErr      error
+	sleepIDs      []string
+	wakeIDs       []string
+
+	// phase is reported by GetStatus. Defaults to Running.
+	phase model.DeploymentPhase
 }
 
-func (m *mockOperator) Deploy(_ context.Context, _ model.Environment, _ string) (string, error) {
+func (m *mockOperator) Deploy(_ context.Context, _ model.Environment, _ string, secrets map[string]string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.deployCalls++
+	m.deploySecrets = secrets
 	return m.previewURL, m.deployErr
 }
 
+func (m *mockOperator) GetStatus(_ context.Context, _ string) (model.DeploymentStatus, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	phase := m.phase
+	if phase == "" {
+		phase = model.DeploymentPhaseRunning
+	}
+	return model.DeploymentStatus{Phase: phase, Message: "pods crashing"}, nil
+}
+
 func (m *mockOperator) Teardown(_ context.Context, envID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
 	m.teardownIDs = append(m.teardownIDs, envID)
 	return m.teardownErr
 }
 
+func (m *mockOperator) Sleep(_ context.Context, envID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sleepIDs = append(m.sleepIDs, envID)
+	return m.sleepErr
+}
+
+func (m *mockOperator) Wake(_ context.Context, envID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.wakeIDs = append(m.wakeIDs, envID)
+	return nil
+}
+
 // --- Helpers ---
 
-func newTestOrchestrator(b orchestrator.BuilderClient, o orchestrator.OperatorClient) (*orchestrator.Orchestrator, store.Store) {
+func newTestOrchestrator(b orchestrator.BuilderClient, o orchestrator.OperatorClient, opts ...orchestrator.Option) (*orchestrator.Orchestrator, store.Store) {
 	s := store.NewMemoryStore()
 	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
-	return orchestrator.New(s, b, o, logger), s
+	opts = append([]orchestrator.Option{
+		orchestrator.WithPollInterval(time.Millisecond),
+		orchestrator.WithBuildTimeout(time.Second),
+		orchestrator.WithRolloutTimeout(time.Second),
+	}, opts...)
+	orch := orchestrator.New(s, b, o, logger, opts...)
+	return orch, s
+}
+
+// waitForEnvironment waits for background workflows and returns the stored environment.
+func waitForEnvironment(t *testing.T, orch *orchestrator.Orchestrator, id string) model.Environment {
+	t.Helper()
+	orch.Wait()
+	env, err := orch.GetEnvironment(context.Background(), id)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return env
+}
+
+// seedEnvironment writes an environment straight into the store, bypassing
+// the orchestrator, to simulate state left behind by a previous process.
+func seedEnvironment(t *testing.T, s store.Store, env model.Environment) {
+	t.Helper()
+	now := time.Now().UTC()
+	env.Name = "seeded-" + env.ID
+	env.BaseRootPackage = "root-pkg"
+	env.CreatedAt = now
+	env.UpdatedAt = now
+	if _, err := s.Create(context.Background(), env); err != nil {
+		t.Fatalf("seed environment: %v", err)
+	}
 }
 
 // --- Tests ---
@@ -94,6 +190,11 @@ func TestCreateEnvironment_WithOverrides_BuildAndDeploy(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if env.Status != model.StatusBuilding {
+		t.Fatalf("expected status building, got %s", env.Status)
+	}
+
+	env = waitForEnvironment(t, orch, env.ID)
 	if env.Status != model.StatusReady {
 		t.Fatalf("expected status ready, got %s", env.Status)
 	}
@@ -109,6 +210,147 @@ func TestCreateEnvironment_WithOverrides_BuildAndDeploy(t *testing.T) {
 	if o.deployCalls != 1 {
 		t.Fatalf("expected operator deploy called once, got %d", o.deployCalls)
 	}
+	if len(env.Progress) != 3 {
+		t.Fatalf("expected 3 stages of progress, got %d", len(env.Progress))
+	}
+	for _, p := range env.Progress {
+		if p.Status != model.StageStatusSucceeded {
+			t.Fatalf("expected stage %s succeeded, got %s", p.Stage, p.Status)
+		}
+		if p.StartedAt == nil || p.CompletedAt == nil {
+			t.Fatalf("expected stage %s to have timestamps", p.Stage)
+		}
+	}
+}
+
+func TestCreateEnvironment_WaitsForBuildBeforeDeploy(t *testing.T) {
+	b := &mockBuilder{
+		buildID:  "build-123",
+		statuses: []model.BuildStatus{model.BuildStatusPending, model.BuildStatusRunning, model.BuildStatusSucceeded},
+	}
+	o := &mockOperator{previewURL: "https://preview.example.com/env-1"}
+	orch, _ := newTestOrchestrator(b, o)
+
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "test-env",
+		BaseRootPackage: "root-pkg",
+		Overrides:       []model.PackageOverride{{PackageName: "users-subgraph"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	env = waitForEnvironment(t, orch, env.ID)
+	if env.Status != model.StatusReady {
+		t.Fatalf("expected status ready, got %s", env.Status)
+	}
+	if b.polls < 3 {
+		t.Fatalf("expected builder polled at least 3 times, got %d", b.polls)
+	}
+}
+
+func TestCreateEnvironment_BuildReportsFailure(t *testing.T) {
+	b := &mockBuilder{buildID: "build-123", statuses: []model.BuildStatus{model.BuildStatusFailed}}
+	o := &mockOperator{}
+	orch, _ := newTestOrchestrator(b, o)
+
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "test-env",
+		BaseRootPackage: "root-pkg",
+		Overrides:       []model.PackageOverride{{PackageName: "users-subgraph"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	env = waitForEnvironment(t, orch, env.ID)
+	if env.Status != model.StatusFailed {
+		t.Fatalf("expected status failed, got %s", env.Status)
+	}
+	if o.deployCalls != 0 {
+		t.Fatalf("expected no deploy after failed build, got %d", o.deployCalls)
+	}
+	if env.Progress[0].Status != model.StageStatusFailed || env.Progress[0].Error == "" {
+		t.Fatalf("expected build stage failed with error, got %+v", env.Progress[0])
+	}
+	if env.Progress[1].Status != model.StageStatusPending {
+		t.Fatalf("expected deploy stage pending, got %s", env.Progress[1].Status)
+	}
+	if env.LastError == "" {
+		t.Fatal("expected lastError to be set")
+	}
+}
+
+func TestCreateEnvironment_RolloutFailure(t *testing.T) {
+	b := &mockBuilder{buildID: "build-123"}
+	o := &mockOperator{previewURL: "https://preview.example.com", phase: model.DeploymentPhaseFailed}
+	orch, _ := newTestOrchestrator(b, o)
+
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "test-env",
+		BaseRootPackage: "root-pkg",
+		Overrides:       []model.PackageOverride{{PackageName: "users-subgraph"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	env = waitForEnvironment(t, orch, env.ID)
+	if env.Status != model.StatusFailed {
+		t.Fatalf("expected status failed, got %s", env.Status)
+	}
+	if env.Progress[2].Stage != model.StageRollout || env.Progress[2].Status != model.StageStatusFailed {
+		t.Fatalf("expected rollout stage failed, got %+v", env.Progress[2])
+	}
+}
+
+func TestCreateEnvironment_RolloutDegraded(t *testing.T) {
+	b := &mockBuilder{buildID: "build-123"}
+	o := &mockOperator{previewURL: "https://preview.example.com", phase: model.DeploymentPhaseDegraded}
+	orch, _ := newTestOrchestrator(b, o)
+
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "test-env",
+		BaseRootPackage: "root-pkg",
+		Overrides:       []model.PackageOverride{{PackageName: "users-subgraph"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The rollout fails with the operator's reason rather than timing out.
+	env = waitForEnvironment(t, orch, env.ID)
+	if env.Status != model.StatusFailed {
+		t.Fatalf("expected status failed, got %s", env.Status)
+	}
+	if !strings.Contains(env.LastError, "rollout degraded: pods crashing") {
+		t.Fatalf("expected the degraded reason, got %q", env.LastError)
+	}
+}
+
+func TestCreateEnvironment_RolloutTimeout(t *testing.T) {
+	b := &mockBuilder{buildID: "build-123"}
+	o := &mockOperator{phase: model.DeploymentPhaseDeploying}
+	s := store.NewMemoryStore()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	orch := orchestrator.New(s, b, o, logger,
+		orchestrator.WithPollInterval(time.Millisecond),
+		orchestrator.WithRolloutTimeout(20*time.Millisecond),
+	)
+
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "test-env",
+		BaseRootPackage: "root-pkg",
+		Overrides:       []model.PackageOverride{{PackageName: "users-subgraph"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	env = waitForEnvironment(t, orch, env.ID)
+	if env.Status != model.StatusFailed {
+		t.Fatalf("expected status failed, got %s", env.Status)
+	}
 }
 
 func TestCreateEnvironment_BuildFailure(t *testing.T) {
@@ -126,6 +368,8 @@ func TestCreateEnvironment_BuildFailure(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+
+	env = waitForEnvironment(t, orch, env.ID)
 	if env.Status != model.StatusFailed {
 		t.Fatalf("expected status failed, got %s", env.Status)
 	}
@@ -214,6 +458,11 @@ func TestApplyOverrides_WithBuild(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
+	if updated.Status != model.StatusBuilding {
+		t.Fatalf("expected status building, got %s", updated.Status)
+	}
+
+	updated = waitForEnvironment(t, orch, created.ID)
 	if updated.Status != model.StatusReady {
 		t.Fatalf("expected status ready, got %s", updated.Status)
 	}
@@ -258,14 +507,22 @@ func TestApplyOverrides_WithoutBuild(t *testing.T) {
 func TestPromote(t *testing.T) {
 	b := &mockBuilder{buildID: "build-789"}
 	o := &mockOperator{previewURL: "https://preview.example.com"}
-	orch, _ := newTestOrchestrator(b, o)
+	r := newFakeRegistry(
+		model.Package{Name: "root-pkg", Kind: "graphql-supergraph", Version: "1.0.0", Dependencies: []model.Dependency{
+			{PackageName: "users-subgraph", VersionConstraint: "1.0.0"},
+			{PackageName: "products-subgraph", VersionConstraint: "1.2.0"},
+		}},
+		model.Package{Name: "users-subgraph", Kind: "graphql-subgraph", Version: "1.0.0", Schema: "type User { id: ID! }"},
+		model.Package{Name: "products-subgraph", Kind: "graphql-subgraph", Version: "1.2.0", Schema: "type Product { id: ID! name: String }"},
+	)
+	orch, _ := newTestOrchestrator(b, o, orchestrator.WithRegistry(r))
 
 	created, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
 		Name:            "test-env",
 		BaseRootPackage: "root-pkg",
 		BaseRootVersion: "1.0.0",
 		Overrides: []model.PackageOverride{
-			{PackageName: "users-subgraph", Schema: "type User { id: ID! }"},
+			{PackageName: "users-subgraph", Schema: "type User { id: ID! email: String }"},
 			{PackageName: "products-subgraph", Schema: "type Product { id: ID! }"},
 		},
 	})
@@ -273,6 +530,8 @@ func TestPromote(t *testing.T) {
 		t.Fatalf("unexpected error: %v", err)
 	}
 
+	orch.Wait()
+
 	resp, err := orch.Promote(context.Background(), created.ID)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -284,7 +543,33 @@ func TestPromote(t *testing.T) {
 		t.Fatalf("expected first promoted package users-subgraph, got %s", resp.PromotedPackages[0].Name)
 	}
 
-	// Verify overrides are cleared after promotion.
+	// An added field is a minor bump; a removed field is breaking.
+	if got := resp.PromotedPackages[0]; got.Version != "1.1.0" || got.PreviousVersion != "1.0.0" || got.Breaking {
+		t.Fatalf("unexpected users-subgraph promotion: %+v", got)
+	}
+	if got := resp.PromotedPackages[1]; got.Version != "2.0.0" || !got.Breaking {
+		t.Fatalf("unexpected products-subgraph promotion: %+v", got)
+	}
+	if resp.RootPackage.Version != "2.0.0" {
+		t.Fatalf("expected root version 2.0.0, got %s", resp.RootPackage.Version)
+	}
+
+	root, ok := r.get("root-pkg", "2.0.0")
+	if !ok {
+		t.Fatal("expected root-pkg@2.0.0 to be published")
+	}
+	want := []model.Dependency{
+		{PackageName: "users-subgraph", VersionConstraint: "1.1.0"},
+		{PackageName: "products-subgraph", VersionConstraint: "2.0.0"},
+	}
+	if !slices.Equal(root.Dependencies, want) {
+		t.Fatalf("expected root dependencies %v, got %v", want, root.Dependencies)
+	}
+	if users, _ := r.get("users-subgraph", "1.1.0"); users.Schema != "type User { id: ID! email: String }" {
+		t.Fatalf("expected promoted schema to be published, got %q", users.Schema)
+	}
+
+	// Verify overrides are cleared and the environment re-based after promotion.
 	env, err := orch.GetEnvironment(context.Background(), created.ID)
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
@@ -292,6 +577,9 @@ func TestPromote(t *testing.T) {
 	if len(env.Overrides) != 0 {
 		t.Fatalf("expected overrides cleared after promote, got %d", len(env.Overrides))
 	}
+	if env.BaseRootVersion != "2.0.0" {
+		t.Fatalf("expected base root version 2.0.0, got %s", env.BaseRootVersion)
+	}
 }
 
 func TestListEnvironments(t *testing.T) {
@@ -318,3 +606,19 @@ func TestListEnvironments(t *testing.T) {
 		t.Fatalf("expected 3, got %d", len(result.Environments))
 	}
 }
+
+func TestApplyOverrides_RejectedWhileDeleting(t *testing.T) {
+	b := &mockBuilder{}
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(b, o)
+
+	seedEnvironment(t, s, model.Environment{ID: "env-deleting", Status: model.StatusDeleting})
+
+	_, err := orch.ApplyOverrides(context.Background(), "env-deleting", model.ApplyOverridesRequest{
+		Overrides:    []model.PackageOverride{{PackageName: "users"}},
+		TriggerBuild: true,
+	})
+	if !errors.Is(err, model.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition, got %v", err)
+	}
+}
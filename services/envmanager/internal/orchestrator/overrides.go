@@ -0,0 +1,77 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/registry"
+)
+
+// validateOverrides checks overrides for an environment based on rootName at
+// rootVersion. Malformed overrides are always rejected. When a registry is
+// configured, version pins must name a published, unyanked version, and when
+// the base root version is known, overrides that only change how a package is
+// reached or run must target a package in the base tree.
+func (o *Orchestrator) validateOverrides(ctx context.Context, rootName, rootVersion string, overrides []model.PackageOverride) error {
+	if err := model.ValidateOverrides(overrides); err != nil {
+		return err
+	}
+	for _, override := range overrides {
+		if override.Version != "" && override.PackageName == rootName {
+			return fmt.Errorf("%w: %s: pin the base root with baseRootVersion instead",
+				model.ErrInvalidOverride, override.PackageName)
+		}
+	}
+	if o.registry == nil {
+		return nil
+	}
+
+	for _, override := range overrides {
+		if override.Version == "" {
+			continue
+		}
+		if _, err := o.pinnedPackage(ctx, override); err != nil {
+			return err
+		}
+	}
+
+	// Only overrides that leave the package itself alone need checking
+	// against the base tree; the rest may introduce new packages.
+	needsTree := false
+	for _, override := range overrides {
+		needsTree = needsTree || !override.ChangesPackage()
+	}
+	if rootVersion == "" || !needsTree {
+		return nil
+	}
+	_, tree, err := o.resolveBaseTree(ctx, model.Environment{BaseRootPackage: rootName, BaseRootVersion: rootVersion})
+	if err != nil {
+		return err
+	}
+	for _, override := range overrides {
+		if _, ok := tree[override.PackageName]; !ok && !override.ChangesPackage() {
+			return fmt.Errorf("%w: %s: package is not part of %s@%s",
+				model.ErrInvalidOverride, override.PackageName, rootName, rootVersion)
+		}
+	}
+	return nil
+}
+
+// pinnedPackage fetches the package version an override pins.
+func (o *Orchestrator) pinnedPackage(ctx context.Context, override model.PackageOverride) (model.Package, error) {
+	pkg, err := o.registry.GetPackage(ctx, override.PackageName, override.Version)
+	if errors.Is(err, registry.ErrNotFound) {
+		return model.Package{}, fmt.Errorf("%w: %s@%s is not published",
+			model.ErrInvalidOverride, override.PackageName, override.Version)
+	}
+	if err != nil {
+		return model.Package{}, fmt.Errorf("get pinned package %s@%s: %w", override.PackageName, override.Version, err)
+	}
+	if pkg.Yanked {
+		return model.Package{}, fmt.Errorf("%w: %s@%s has been yanked",
+			model.ErrInvalidOverride, override.PackageName, override.Version)
+	}
+	return pkg, nil
+}
@@ -0,0 +1,172 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+// pinnablePackages are users-subgraph versions published alongside the
+// promote fixture's base tree.
+var pinnablePackages = []model.Package{
+	{Name: "users-subgraph", Kind: "graphql-subgraph", Version: "1.1.0", Schema: "type User { id: ID name: String }"},
+	{Name: "users-subgraph", Kind: "graphql-subgraph", Version: "0.9.0", Schema: "type User { id: ID }", Yanked: true},
+}
+
+func TestApplyOverrides_Invalid(t *testing.T) {
+	negative := int32(-1)
+	tests := []struct {
+		name     string
+		override model.PackageOverride
+	}{
+		{
+			name:     "missing package name",
+			override: model.PackageOverride{Schema: "type User { id: ID }"},
+		},
+		{
+			name:     "schema and version",
+			override: model.PackageOverride{PackageName: "users-subgraph", Schema: "type User { id: ID }", Version: "1.1.0"},
+		},
+		{
+			name:     "relative upstream url",
+			override: model.PackageOverride{PackageName: "users-subgraph", UpstreamConfig: &model.UpstreamConfig{URL: "/graphql"}},
+		},
+		{
+			name:     "negative replicas",
+			override: model.PackageOverride{PackageName: "users-subgraph", Runtime: &model.RuntimeOverride{Replicas: &negative}},
+		},
+		{
+			name:     "unpublished version",
+			override: model.PackageOverride{PackageName: "users-subgraph", Version: "3.0.0"},
+		},
+		{
+			name:     "yanked version",
+			override: model.PackageOverride{PackageName: "users-subgraph", Version: "0.9.0"},
+		},
+		{
+			name:     "pinned base root",
+			override: model.PackageOverride{PackageName: "root-pkg", Version: "1.0.0"},
+		},
+		{
+			name:     "upstream for a package outside the base",
+			override: model.PackageOverride{PackageName: "orders-subgraph", UpstreamConfig: &model.UpstreamConfig{URL: "http://localhost:4002"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			orch, _, env := newRegistryFixture(t, pinnablePackages)
+
+			_, err := orch.ApplyOverrides(context.Background(), env.ID, model.ApplyOverridesRequest{
+				Overrides: []model.PackageOverride{tt.override},
+			})
+			if !errors.Is(err, model.ErrInvalidOverride) {
+				t.Fatalf("expected ErrInvalidOverride, got %v", err)
+			}
+		})
+	}
+}
+
+func TestApplyOverrides_Duplicate(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+
+	_, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "dup-env",
+		BaseRootPackage: "root-pkg",
+		Overrides: []model.PackageOverride{
+			{PackageName: "users-subgraph", Schema: "type User { id: ID }"},
+			{PackageName: "users-subgraph", UpstreamConfig: &model.UpstreamConfig{URL: "http://localhost:4001"}},
+		},
+	})
+	if !errors.Is(err, model.ErrInvalidOverride) {
+		t.Fatalf("expected ErrInvalidOverride, got %v", err)
+	}
+}
+
+func TestDiff_PinAndUpstream(t *testing.T) {
+	upstream := &model.UpstreamConfig{URL: "http://localhost:4001", Headers: map[string]string{"x-dev": "alice"}}
+	orch, _, env := newRegistryFixture(t, pinnablePackages,
+		model.PackageOverride{PackageName: "users-subgraph", Version: "1.1.0", UpstreamConfig: upstream},
+		model.PackageOverride{PackageName: "gateway", Runtime: &model.RuntimeOverride{Env: map[string]string{"LOG_LEVEL": "debug"}}},
+	)
+
+	diff, err := orch.Diff(context.Background(), env.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The gateway's runtime override does not change its package.
+	if len(diff.Packages) != 1 {
+		t.Fatalf("expected only users-subgraph to differ, got %+v", diff.Packages)
+	}
+	users := diff.Packages[0]
+	if users.BaseVersion != "1.0.0" || users.Version != "1.1.0" {
+		t.Fatalf("expected users-subgraph pinned from 1.0.0 to 1.1.0, got %+v", users)
+	}
+	if users.UpstreamConfig == nil || users.UpstreamConfig.URL != upstream.URL {
+		t.Fatalf("expected the overridden upstream, got %+v", users.UpstreamConfig)
+	}
+	if users.Breaking || len(users.SchemaChanges) != 1 || users.SchemaChanges[0].Path != "User.name" {
+		t.Fatalf("expected User.name added, got %+v", users.SchemaChanges)
+	}
+}
+
+func TestPromote_Pin(t *testing.T) {
+	replicas := int32(3)
+	orch, r, env := newRegistryFixture(t, pinnablePackages,
+		model.PackageOverride{
+			PackageName: "users-subgraph",
+			Version:     "1.1.0",
+			Runtime:     &model.RuntimeOverride{Replicas: &replicas},
+		},
+	)
+
+	resp, err := orch.Promote(context.Background(), env.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// The pin adds a field, so its dependents get a minor bump.
+	if len(resp.PromotedPackages) != 2 {
+		t.Fatalf("expected users-subgraph and gateway, got %+v", resp.PromotedPackages)
+	}
+	if got := resp.PromotedPackages[0]; got.Name != "users-subgraph" || got.Version != "1.1.0" || got.PreviousVersion != "1.0.0" {
+		t.Fatalf("unexpected users-subgraph promotion: %+v", got)
+	}
+	if resp.RootPackage.Version != "1.1.0" {
+		t.Fatalf("expected root version 1.1.0, got %s", resp.RootPackage.Version)
+	}
+
+	gateway, ok := r.get("gateway", "0.4.0")
+	if !ok {
+		t.Fatal("expected gateway@0.4.0 to be published")
+	}
+	if dep := gateway.Dependencies[0]; dep.VersionConstraint != "1.1.0" {
+		t.Fatalf("expected gateway to pin users-subgraph@1.1.0, got %s", dep.VersionConstraint)
+	}
+
+	got, err := orch.GetEnvironment(context.Background(), env.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got.Overrides) != 1 || got.Overrides[0].Version != "" || got.Overrides[0].Runtime == nil {
+		t.Fatalf("expected only the runtime override to remain, got %+v", got.Overrides)
+	}
+}
+
+func TestCreateEnvironment_PinWithoutRegistry(t *testing.T) {
+	// Without a registry, pins cannot be checked and are accepted as given.
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+
+	_, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "pinned-env",
+		BaseRootPackage: "root-pkg",
+		BaseRootVersion: "1.0.0",
+		Overrides:       []model.PackageOverride{{PackageName: "users-subgraph", Version: "9.9.9"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
@@ -0,0 +1,398 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/registry"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/schemadiff"
+)
+
+// Errors returned by Promote.
+var (
+	// ErrNothingToPromote is returned when no override changes a published schema.
+	ErrNothingToPromote = errors.New("nothing to promote")
+
+	// ErrPromotionConflict is returned when the environment's overrides cannot
+	// be promoted onto its current base.
+	ErrPromotionConflict = errors.New("promotion conflict")
+
+	// ErrPublishFailed is returned when the registry rejects or fails a
+	// publish. Anything already published by the promotion has been yanked.
+	ErrPublishFailed = errors.New("publish to registry failed")
+)
+
+// promotedFromKey is the metadata key recording which environment produced
+// a promoted package version.
+const promotedFromKey = "turboengine.io/promoted-from"
+
+// bump is a semantic version increment.
+type bump int
+
+const (
+	bumpNone bump = iota
+	bumpPatch
+	bumpMinor
+	bumpMajor
+)
+
+// plannedPackage is one package version to publish during a promotion.
+// Pinned packages are already published; the promotion only moves the base
+// onto them.
+type plannedPackage struct {
+	base   model.Package
+	next   model.Package
+	bump   bump
+	pinned bool
+}
+
+// Promote publishes an environment's schema overrides and version pins to
+// the registry and re-bases the environment onto the result.
+//
+// Each overridden package is published as a new version whose semver bump is
+// chosen by diffing it against the base: major for breaking changes, minor
+// for additions, patch otherwise. Every package that depends on a promoted
+// package, up to and including the base root, is republished with its
+// dependency pins moved to the new versions and inherits the largest bump
+// among them. A pinned package is not republished, but its dependents are
+// bumped as if its schema had been overridden. If any publish fails, the
+// versions already published are yanked so the base is left unchanged, and
+// a retry publishes the next versions after them.
+//
+// Upstream and runtime overrides are specific to the environment: they are
+// not published and remain in place after the promotion.
+func (o *Orchestrator) Promote(ctx context.Context, id string) (model.PromoteResponse, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.Promote",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	if o.registry == nil {
+		return model.PromoteResponse{}, ErrNoRegistry
+	}
+
+	env, err := o.store.Get(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return model.PromoteResponse{}, err
+	}
+	if err := checkLock(ctx, env, time.Now().UTC()); err != nil {
+		return model.PromoteResponse{}, err
+	}
+	if env.Status != model.StatusReady {
+		return model.PromoteResponse{}, fmt.Errorf("%w: environment is %s, not ready", ErrPromotionConflict, env.Status)
+	}
+	if env.BaseRootVersion == "" {
+		return model.PromoteResponse{}, fmt.Errorf("%w: %w", ErrPromotionConflict, ErrNoBaseVersion)
+	}
+
+	plan, err := o.planPromotion(ctx, env)
+	if err != nil {
+		span.RecordError(err)
+		return model.PromoteResponse{}, err
+	}
+
+	published := make([]model.Package, 0, len(plan))
+	for _, p := range plan {
+		if p.pinned {
+			continue
+		}
+		if _, err := o.registry.Publish(ctx, p.next); err != nil {
+			span.RecordError(err)
+			o.yankAll(ctx, published)
+			return model.PromoteResponse{}, fmt.Errorf("%w: %s@%s: %w", ErrPublishFailed, p.next.Name, p.next.Version, err)
+		}
+		published = append(published, p.next)
+	}
+
+	// The root is always last: it depends on everything else in the plan.
+	root := plan[len(plan)-1]
+	_, err = o.mutate(ctx, id, func(cur *model.Environment) error {
+		// Re-basing onto overrides other than the ones just published would
+		// silently drop changes, so back out if anything moved underneath us.
+		if !cur.UpdatedAt.Equal(env.UpdatedAt) {
+			return fmt.Errorf("%w: environment changed during promotion", ErrPromotionConflict)
+		}
+		cur.BaseRootVersion = root.next.Version
+		cur.Overrides = localOverrides(cur.Overrides)
+		cur.LastActivityAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		o.yankAll(ctx, published)
+		return model.PromoteResponse{}, fmt.Errorf("re-base environment: %w", err)
+	}
+
+	resp := model.PromoteResponse{
+		PromotedPackages: make([]model.PromotedPackage, 0, len(plan)-1),
+		RootPackage:      promotedPackage(root),
+	}
+	for _, p := range plan[:len(plan)-1] {
+		resp.PromotedPackages = append(resp.PromotedPackages, promotedPackage(p))
+	}
+
+	o.logger.InfoContext(ctx, "environment promoted",
+		slog.String("id", id),
+		slog.String("rootVersion", root.next.Version),
+		slog.Int("promotedCount", len(resp.PromotedPackages)))
+	o.record(ctx, model.Event{
+		EnvironmentID: id,
+		Type:          model.EventPromoted,
+		Message:       fmt.Sprintf("promoted %d packages; base is now %s@%s", len(resp.PromotedPackages), root.next.Name, root.next.Version),
+		Status:        env.Status,
+	})
+
+	return resp, nil
+}
+
+// planPromotion works out which package versions to publish, ordered so that
+// every package comes after its dependencies and the root comes last.
+func (o *Orchestrator) planPromotion(ctx context.Context, env model.Environment) ([]plannedPackage, error) {
+	root, tree, err := o.resolveBaseTree(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	bumps := make(map[string]bump)
+	schemas := make(map[string]string)
+	pins := make(map[string]model.Package)
+	for _, override := range env.Overrides {
+		if !override.ChangesPackage() {
+			continue
+		}
+		base, ok := tree[override.PackageName]
+		if !ok {
+			return nil, fmt.Errorf("%w: package %q is not part of %s@%s",
+				ErrPromotionConflict, override.PackageName, root.Name, root.Version)
+		}
+
+		if override.Version != "" {
+			if override.Version == base.Version {
+				continue
+			}
+			pinned, err := o.pinnedPackage(ctx, override)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %w", ErrPromotionConflict, err)
+			}
+			diff, err := schemadiff.Diff(base.Kind, base.Schema, pinned.Schema)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %w", ErrPromotionConflict, override.PackageName, err)
+			}
+			bumps[base.Name] = bumpFor(diff)
+			pins[base.Name] = pinned
+			continue
+		}
+
+		diff, err := schemadiff.Diff(base.Kind, base.Schema, override.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrPromotionConflict, override.PackageName, err)
+		}
+		if len(diff.Changes) == 0 {
+			continue
+		}
+		bumps[base.Name] = bumpFor(diff)
+		schemas[base.Name] = override.Schema
+	}
+	if len(bumps) == 0 {
+		return nil, ErrNothingToPromote
+	}
+
+	order := dependencyOrder(root.Name, tree)
+
+	// Propagate bumps from dependencies to their dependents. Walking in
+	// dependency order means each package's dependencies are final by the
+	// time it is visited.
+	for _, name := range order {
+		for _, dep := range tree[name].Dependencies {
+			if b := bumps[dep.PackageName]; b > bumps[name] {
+				bumps[name] = b
+			}
+		}
+	}
+
+	var plan []plannedPackage
+	versions := make(map[string]string)
+	for _, name := range order {
+		b := bumps[name]
+		if b == bumpNone {
+			continue
+		}
+		base := tree[name]
+		if pinned, ok := pins[name]; ok {
+			versions[name] = pinned.Version
+			plan = append(plan, plannedPackage{base: base, next: pinned, bump: b, pinned: true})
+			continue
+		}
+		version, err := bumpVersion(base.Version, b)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %w", ErrPromotionConflict, name, err)
+		}
+		if version, err = o.skipYanked(ctx, name, version); err != nil {
+			return nil, err
+		}
+		versions[name] = version
+
+		next := base
+		next.ID = ""
+		next.Version = version
+		next.Yanked = false
+		if schema, ok := schemas[name]; ok {
+			next.Schema = schema
+		}
+		next.Dependencies = make([]model.Dependency, len(base.Dependencies))
+		for i, dep := range base.Dependencies {
+			if v, ok := versions[dep.PackageName]; ok {
+				dep.VersionConstraint = v
+			}
+			next.Dependencies[i] = dep
+		}
+		next.Metadata = maps.Clone(base.Metadata)
+		if next.Metadata == nil {
+			next.Metadata = make(map[string]string)
+		}
+		next.Metadata[promotedFromKey] = env.ID
+
+		plan = append(plan, plannedPackage{base: base, next: next, bump: b})
+	}
+	return plan, nil
+}
+
+// skipYanked returns version, or if it was published and has since been
+// yanked, the first later patch version that hasn't been. Versions yanked by
+// an earlier failed promotion can't be published again, so without this a
+// retry would conflict with them forever.
+func (o *Orchestrator) skipYanked(ctx context.Context, name, version string) (string, error) {
+	for {
+		pkg, err := o.registry.GetPackage(ctx, name, version)
+		if errors.Is(err, registry.ErrNotFound) {
+			return version, nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("look up %s@%s: %w", name, version, err)
+		}
+		if !pkg.Yanked {
+			return version, nil
+		}
+		if version, err = bumpVersion(version, bumpPatch); err != nil {
+			return "", fmt.Errorf("%w: %s: %w", ErrPromotionConflict, name, err)
+		}
+	}
+}
+
+// dependencyOrder returns the packages reachable from root in post-order, so
+// dependencies precede their dependents and root is last. Dependencies that
+// are not in tree (for example, hosted in another registry) are skipped.
+func dependencyOrder(root string, tree map[string]model.Package) []string {
+	var order []string
+	visited := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+		pkg, ok := tree[name]
+		if !ok {
+			return
+		}
+		for _, dep := range pkg.Dependencies {
+			visit(dep.PackageName)
+		}
+		order = append(order, name)
+	}
+	visit(root)
+	return order
+}
+
+// yankAll withdraws packages published by a failed promotion, newest first.
+// It runs even if ctx has been cancelled so a disconnected caller cannot
+// leave half a promotion behind.
+func (o *Orchestrator) yankAll(ctx context.Context, pkgs []model.Package) {
+	ctx = context.WithoutCancel(ctx)
+	for i := len(pkgs) - 1; i >= 0; i-- {
+		pkg := pkgs[i]
+		if err := o.registry.Yank(ctx, pkg.Name, pkg.Version); err != nil {
+			o.logger.ErrorContext(ctx, "failed to yank package after failed promotion",
+				slog.String("package", pkg.Name),
+				slog.String("version", pkg.Version),
+				slog.String("error", err.Error()))
+		}
+	}
+}
+
+// localOverrides returns the parts of overrides that a promotion leaves in
+// place: upstream and runtime overrides, without their schemas or pins.
+func localOverrides(overrides []model.PackageOverride) []model.PackageOverride {
+	var kept []model.PackageOverride
+	for _, override := range overrides {
+		if override.UpstreamConfig == nil && override.Runtime == nil {
+			continue
+		}
+		override.Schema = ""
+		override.Version = ""
+		kept = append(kept, override)
+	}
+	return kept
+}
+
+func promotedPackage(p plannedPackage) model.PromotedPackage {
+	return model.PromotedPackage{
+		Name:            p.next.Name,
+		Version:         p.next.Version,
+		PreviousVersion: p.base.Version,
+		Breaking:        p.bump == bumpMajor,
+	}
+}
+
+// bumpFor chooses the semver increment for a schema change.
+func bumpFor(diff schemadiff.Result) bump {
+	switch {
+	case diff.Breaking():
+		return bumpMajor
+	case diff.HasAdditions():
+		return bumpMinor
+	default:
+		return bumpPatch
+	}
+}
+
+// bumpVersion increments a MAJOR.MINOR.PATCH version, preserving a leading
+// "v" if present.
+func bumpVersion(version string, b bump) (string, error) {
+	prefix := ""
+	if strings.HasPrefix(version, "v") {
+		prefix = "v"
+	}
+	parts := strings.Split(strings.TrimPrefix(version, prefix), ".")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("version %q is not MAJOR.MINOR.PATCH", version)
+	}
+	var n [3]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(part)
+		if err != nil || v < 0 {
+			return "", fmt.Errorf("version %q is not MAJOR.MINOR.PATCH", version)
+		}
+		n[i] = v
+	}
+
+	switch b {
+	case bumpMajor:
+		n = [3]int{n[0] + 1, 0, 0}
+	case bumpMinor:
+		n = [3]int{n[0], n[1] + 1, 0}
+	case bumpPatch:
+		n[2]++
+	}
+	return fmt.Sprintf("%s%d.%d.%d", prefix, n[0], n[1], n[2]), nil
+}
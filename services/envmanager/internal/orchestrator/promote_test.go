@@ -0,0 +1,255 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/registry"
+)
+
+// --- Fake Registry ---
+
+// fakeRegistry is an in-memory registry that resolves dependency
+// constraints as exact versions, like the Registry service.
+type fakeRegistry struct {
+	mu       sync.Mutex
+	packages map[string]model.Package
+	yanked   []string
+
+	// failPublish makes Publish fail for the named package.
+	failPublish string
+}
+
+func newFakeRegistry(pkgs ...model.Package) *fakeRegistry {
+	r := &fakeRegistry{packages: make(map[string]model.Package)}
+	for _, pkg := range pkgs {
+		r.packages[pkg.Name+"@"+pkg.Version] = pkg
+	}
+	return r
+}
+
+func (r *fakeRegistry) get(name, version string) (model.Package, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	pkg, ok := r.packages[name+"@"+version]
+	return pkg, ok
+}
+
+func (r *fakeRegistry) GetPackage(_ context.Context, name, version string) (model.Package, error) {
+	pkg, ok := r.get(name, version)
+	if !ok {
+		return model.Package{}, fmt.Errorf("%s@%s: %w", name, version, registry.ErrNotFound)
+	}
+	return pkg, nil
+}
+
+func (r *fakeRegistry) ResolveDependencies(ctx context.Context, name, version string) ([]model.Package, error) {
+	root, err := r.GetPackage(ctx, name, version)
+	if err != nil {
+		return nil, err
+	}
+	var resolved []model.Package
+	queue := root.Dependencies
+	for len(queue) > 0 {
+		dep := queue[0]
+		queue = queue[1:]
+		if pkg, ok := r.get(dep.PackageName, dep.VersionConstraint); ok {
+			resolved = append(resolved, pkg)
+			queue = append(queue, pkg.Dependencies...)
+		}
+	}
+	return resolved, nil
+}
+
+func (r *fakeRegistry) Publish(_ context.Context, pkg model.Package) (model.Package, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if pkg.Name == r.failPublish {
+		return model.Package{}, errors.New("registry unavailable")
+	}
+	key := pkg.Name + "@" + pkg.Version
+	if _, exists := r.packages[key]; exists {
+		return model.Package{}, fmt.Errorf("%s already exists", key)
+	}
+	r.packages[key] = pkg
+	return pkg, nil
+}
+
+func (r *fakeRegistry) Yank(_ context.Context, name, version string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.yanked = append(r.yanked, name+"@"+version)
+	if pkg, ok := r.packages[name+"@"+version]; ok {
+		pkg.Yanked = true
+		r.packages[name+"@"+version] = pkg
+	}
+	return nil
+}
+
+// newPromoteFixture returns a registry holding root-pkg@1.0.0, which depends
+// on a gateway that in turn depends on the users subgraph, and a ready
+// environment based on it.
+func newPromoteFixture(t *testing.T, overrides ...model.PackageOverride) (*orchestrator.Orchestrator, *fakeRegistry, model.Environment) {
+	t.Helper()
+	return newRegistryFixture(t, nil, overrides...)
+}
+
+// newRegistryFixture is newPromoteFixture with extra packages published
+// alongside the base tree.
+func newRegistryFixture(t *testing.T, extra []model.Package, overrides ...model.PackageOverride) (*orchestrator.Orchestrator, *fakeRegistry, model.Environment) {
+	t.Helper()
+	r := newFakeRegistry(append([]model.Package{
+		{Name: "root-pkg", Kind: "graphql-supergraph", Version: "1.0.0", Dependencies: []model.Dependency{
+			{PackageName: "gateway", VersionConstraint: "0.3.1"},
+		}},
+		{Name: "gateway", Kind: "ingress", Version: "0.3.1", Dependencies: []model.Dependency{
+			{PackageName: "users-subgraph", VersionConstraint: "1.0.0"},
+		}},
+		{Name: "users-subgraph", Kind: "graphql-subgraph", Version: "1.0.0", Schema: "type User { id: ID }",
+			UpstreamConfig: &model.UpstreamConfig{URL: "http://users.default.svc:4001"}},
+	}, extra...)...)
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{}, orchestrator.WithRegistry(r))
+
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "promote-env",
+		BaseRootPackage: "root-pkg",
+		BaseRootVersion: "1.0.0",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	env, err = orch.ApplyOverrides(context.Background(), env.ID, model.ApplyOverridesRequest{Overrides: overrides})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return orch, r, env
+}
+
+// --- Tests ---
+
+func TestPromote_RepublishesDependents(t *testing.T) {
+	// Making an output field non-null is compatible and has no additions: a patch.
+	orch, r, env := newPromoteFixture(t, model.PackageOverride{PackageName: "users-subgraph", Schema: "type User { id: ID! }"})
+
+	resp, err := orch.Promote(context.Background(), env.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(resp.PromotedPackages) != 2 {
+		t.Fatalf("expected users-subgraph and gateway to be promoted, got %+v", resp.PromotedPackages)
+	}
+	if got := resp.PromotedPackages[0]; got.Name != "users-subgraph" || got.Version != "1.0.1" {
+		t.Fatalf("unexpected users-subgraph promotion: %+v", got)
+	}
+	if got := resp.PromotedPackages[1]; got.Name != "gateway" || got.Version != "0.3.2" {
+		t.Fatalf("unexpected gateway promotion: %+v", got)
+	}
+	if resp.RootPackage.Version != "1.0.1" {
+		t.Fatalf("expected root version 1.0.1, got %s", resp.RootPackage.Version)
+	}
+
+	gateway, ok := r.get("gateway", "0.3.2")
+	if !ok {
+		t.Fatal("expected gateway@0.3.2 to be published")
+	}
+	if dep := gateway.Dependencies[0]; dep.VersionConstraint != "1.0.1" {
+		t.Fatalf("expected gateway to pin users-subgraph@1.0.1, got %s", dep.VersionConstraint)
+	}
+	if gateway.Metadata["turboengine.io/promoted-from"] != env.ID {
+		t.Fatalf("expected promoted-from metadata, got %v", gateway.Metadata)
+	}
+}
+
+func TestPromote_YanksOnPublishFailure(t *testing.T) {
+	orch, r, env := newPromoteFixture(t, model.PackageOverride{PackageName: "users-subgraph", Schema: "type User { id: ID! }"})
+	r.failPublish = "root-pkg"
+
+	_, err := orch.Promote(context.Background(), env.ID)
+	if !errors.Is(err, orchestrator.ErrPublishFailed) {
+		t.Fatalf("expected ErrPublishFailed, got %v", err)
+	}
+
+	// Everything published before the failure is yanked, newest first.
+	want := []string{"gateway@0.3.2", "users-subgraph@1.0.1"}
+	if fmt.Sprint(r.yanked) != fmt.Sprint(want) {
+		t.Fatalf("expected yanked %v, got %v", want, r.yanked)
+	}
+
+	got, err := orch.GetEnvironment(context.Background(), env.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.BaseRootVersion != "1.0.0" || len(got.Overrides) != 1 {
+		t.Fatalf("expected environment unchanged, got base %s with %d overrides", got.BaseRootVersion, len(got.Overrides))
+	}
+
+	// A retry publishes past the yanked versions.
+	r.failPublish = ""
+	resp, err := orch.Promote(context.Background(), env.ID)
+	if err != nil {
+		t.Fatalf("retry: %v", err)
+	}
+	if got := resp.PromotedPackages[0]; got.Name != "users-subgraph" || got.Version != "1.0.2" || got.PreviousVersion != "1.0.0" {
+		t.Fatalf("unexpected users-subgraph promotion: %+v", got)
+	}
+	if resp.RootPackage.Version != "1.0.1" {
+		t.Fatalf("expected root version 1.0.1, got %s", resp.RootPackage.Version)
+	}
+	gateway, ok := r.get("gateway", "0.3.3")
+	if !ok {
+		t.Fatal("expected gateway@0.3.3 to be published")
+	}
+	if dep := gateway.Dependencies[0]; dep.VersionConstraint != "1.0.2" {
+		t.Fatalf("expected gateway to pin users-subgraph@1.0.2, got %s", dep.VersionConstraint)
+	}
+}
+
+func TestPromote_NothingToPromote(t *testing.T) {
+	orch, _, env := newPromoteFixture(t, model.PackageOverride{PackageName: "users-subgraph", Schema: "type User { id: ID }"})
+
+	_, err := orch.Promote(context.Background(), env.ID)
+	if !errors.Is(err, orchestrator.ErrNothingToPromote) {
+		t.Fatalf("expected ErrNothingToPromote, got %v", err)
+	}
+}
+
+func TestPromote_UnknownPackage(t *testing.T) {
+	orch, r, env := newPromoteFixture(t, model.PackageOverride{PackageName: "orders-subgraph", Schema: "type Order { id: ID! }"})
+
+	_, err := orch.Promote(context.Background(), env.ID)
+	if !errors.Is(err, orchestrator.ErrPromotionConflict) {
+		t.Fatalf("expected ErrPromotionConflict, got %v", err)
+	}
+	if len(r.packages) != 3 {
+		t.Fatalf("expected nothing published, registry has %d packages", len(r.packages))
+	}
+}
+
+func TestPromote_RequiresReadyEnvironment(t *testing.T) {
+	b := &mockBuilder{buildID: "build-1", statuses: []model.BuildStatus{model.BuildStatusFailed}}
+	orch, _ := newTestOrchestrator(b, &mockOperator{}, orchestrator.WithRegistry(newFakeRegistry()))
+
+	created, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "failed-env",
+		BaseRootPackage: "root-pkg",
+		BaseRootVersion: "1.0.0",
+		Overrides:       []model.PackageOverride{{PackageName: "users-subgraph", Schema: "type User { id: ID! }"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env := waitForEnvironment(t, orch, created.ID); env.Status != model.StatusFailed {
+		t.Fatalf("expected failed, got %s", env.Status)
+	}
+
+	_, err = orch.Promote(context.Background(), created.ID)
+	if !errors.Is(err, orchestrator.ErrPromotionConflict) {
+		t.Fatalf("expected ErrPromotionConflict, got %v", err)
+	}
+}
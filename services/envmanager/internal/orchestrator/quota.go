@@ -0,0 +1,135 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/labels"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+var (
+	// ErrQuotaExceeded is returned when creating an environment would take
+	// its user or team over their environment quota. Deleting an environment
+	// makes room.
+	ErrQuotaExceeded = errors.New("environment quota exceeded")
+
+	// ErrLimitExceeded is returned when an environment would deploy more
+	// components or replicas than any environment may.
+	ErrLimitExceeded = errors.New("environment size limit exceeded")
+)
+
+// WithQuotas enforces q on create and when overrides are applied.
+func WithQuotas(q model.Quotas) Option {
+	return func(o *Orchestrator) { o.quotas = q }
+}
+
+// checkFootprint returns ErrLimitExceeded if an environment based on
+// rootPackage with overrides is bigger than the quotas allow.
+func (o *Orchestrator) checkFootprint(rootPackage string, overrides []model.PackageOverride) error {
+	f := model.FootprintOf(rootPackage, overrides)
+	if limit := o.quotas.MaxComponents; limit > 0 && f.Components > limit {
+		return fmt.Errorf("%w: %d components, at most %d allowed", ErrLimitExceeded, f.Components, limit)
+	}
+	if limit := o.quotas.MaxReplicas; limit > 0 && f.Replicas > limit {
+		return fmt.Errorf("%w: %d replicas, at most %d allowed", ErrLimitExceeded, f.Replicas, limit)
+	}
+	return nil
+}
+
+// checkEnvironmentQuotas returns ErrQuotaExceeded if env's user or team
+// already has as many environments as it may. The caller must hold o.mu so
+// that concurrent creates cannot both take the last slot.
+func (o *Orchestrator) checkEnvironmentQuotas(ctx context.Context, env model.Environment) error {
+	if limit := o.quotas.MaxEnvironmentsPerUser; limit > 0 && env.CreatedBy != "" {
+		n, err := o.countEnvironments(ctx, store.ListFilter{CreatedBy: env.CreatedBy})
+		if err != nil {
+			return err
+		}
+		if n >= limit {
+			return fmt.Errorf("%w: %s has %d of %d environments", ErrQuotaExceeded, env.CreatedBy, n, limit)
+		}
+	}
+	return o.checkTeamQuota(ctx, env.Labels[model.TeamLabel])
+}
+
+// checkTeamQuota returns ErrQuotaExceeded if team already has as many
+// environments as it may, so that one more cannot join it. The caller must
+// hold o.mu.
+func (o *Orchestrator) checkTeamQuota(ctx context.Context, team string) error {
+	limit := o.quotas.MaxEnvironmentsPerTeam
+	if limit <= 0 || team == "" {
+		return nil
+	}
+	n, err := o.countEnvironments(ctx, teamFilter(team))
+	if err != nil {
+		return err
+	}
+	if n >= limit {
+		return fmt.Errorf("%w: team %s has %d of %d environments", ErrQuotaExceeded, team, n, limit)
+	}
+	return nil
+}
+
+// countEnvironments counts the environments matching filter that are not
+// being deleted.
+func (o *Orchestrator) countEnvironments(ctx context.Context, filter store.ListFilter) (int, error) {
+	n := 0
+	filter.PageSize = 100
+	for {
+		result, err := o.store.List(ctx, filter)
+		if err != nil {
+			return 0, fmt.Errorf("list environments: %w", err)
+		}
+		for _, env := range result.Environments {
+			if env.Status != model.StatusDeleting {
+				n++
+			}
+		}
+		if result.NextPageToken == "" {
+			return n, nil
+		}
+		filter.PageToken = result.NextPageToken
+	}
+}
+
+// teamFilter lists a team's environments.
+func teamFilter(team string) store.ListFilter {
+	return store.ListFilter{Selector: labels.Selector{{
+		Key:      model.TeamLabel,
+		Operator: labels.OpEquals,
+		Values:   []string{team},
+	}}}
+}
+
+// Usage reports how many environments user and team have against their
+// quotas. Either may be empty to leave it out.
+func (o *Orchestrator) Usage(ctx context.Context, user, team string) (model.UsageResponse, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.Usage",
+		trace.WithAttributes(attribute.String("user", user), attribute.String("team", team)))
+	defer span.End()
+
+	resp := model.UsageResponse{Quotas: o.quotas}
+	if user != "" {
+		n, err := o.countEnvironments(ctx, store.ListFilter{CreatedBy: user})
+		if err != nil {
+			span.RecordError(err)
+			return model.UsageResponse{}, err
+		}
+		resp.User = &model.EnvironmentUsage{Name: user, Environments: n, Limit: o.quotas.MaxEnvironmentsPerUser}
+	}
+	if team != "" {
+		n, err := o.countEnvironments(ctx, teamFilter(team))
+		if err != nil {
+			span.RecordError(err)
+			return model.UsageResponse{}, err
+		}
+		resp.Team = &model.EnvironmentUsage{Name: team, Environments: n, Limit: o.quotas.MaxEnvironmentsPerTeam}
+	}
+	return resp, nil
+}
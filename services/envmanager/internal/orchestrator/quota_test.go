@@ -0,0 +1,164 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+)
+
+func TestQuotas_EnvironmentsPerUserAndTeam(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{}, orchestrator.WithQuotas(model.Quotas{
+		MaxEnvironmentsPerUser: 2,
+		MaxEnvironmentsPerTeam: 1,
+	}))
+	ctx := context.Background()
+	create := func(name, user, team string) (model.Environment, error) {
+		req := model.CreateEnvironmentRequest{Name: name, BaseRootPackage: "root-pkg", CreatedBy: user}
+		if team != "" {
+			req.Labels = map[string]string{model.TeamLabel: team}
+		}
+		return orch.CreateEnvironment(ctx, req)
+	}
+
+	first, err := create("first", "alice", "payments")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := create("second", "bob", "payments"); !errors.Is(err, orchestrator.ErrQuotaExceeded) {
+		t.Fatalf("expected the team quota to be exceeded, got %v", err)
+	}
+	if _, err := create("second", "alice", ""); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := create("third", "alice", "search"); !errors.Is(err, orchestrator.ErrQuotaExceeded) {
+		t.Fatalf("expected the user quota to be exceeded, got %v", err)
+	}
+
+	// Deleting an environment frees its slot.
+	if err := orch.DeleteEnvironment(ctx, first.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if _, err := create("third", "alice", "payments"); err != nil {
+		t.Fatalf("expected room after a delete, got %v", err)
+	}
+
+	usage, err := orch.Usage(ctx, "alice", "payments")
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	if usage.User.Environments != 2 || usage.User.Limit != 2 || usage.Team.Environments != 1 || usage.Team.Limit != 1 {
+		t.Fatalf("unexpected usage %+v %+v", usage.User, usage.Team)
+	}
+}
+
+func TestQuotas_CountedAgainstTheActor(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{}, orchestrator.WithQuotas(model.Quotas{
+		MaxEnvironmentsPerUser: 1,
+	}))
+	ctx := orchestrator.ContextWithActor(context.Background(), "bob")
+
+	env, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "first", BaseRootPackage: "root-pkg"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if env.CreatedBy != "bob" {
+		t.Fatalf("expected bob as the creator, got %q", env.CreatedBy)
+	}
+
+	// Claiming another creator in the body does not escape bob's quota.
+	_, err = orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name: "second", BaseRootPackage: "root-pkg", CreatedBy: "alice",
+	})
+	if !errors.Is(err, orchestrator.ErrQuotaExceeded) {
+		t.Fatalf("expected bob's quota to be exceeded, got %v", err)
+	}
+	usage, err := orch.Usage(ctx, "alice", "")
+	if err != nil {
+		t.Fatalf("usage: %v", err)
+	}
+	if usage.User.Environments != 0 {
+		t.Fatalf("expected no environments counted against alice, got %d", usage.User.Environments)
+	}
+}
+
+func TestQuotas_TeamLabelChange(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{}, orchestrator.WithQuotas(model.Quotas{
+		MaxEnvironmentsPerTeam: 1,
+	}))
+	ctx := context.Background()
+	label := func(value string) *string { return &value }
+
+	if _, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name: "first", BaseRootPackage: "root-pkg", Labels: map[string]string{model.TeamLabel: "payments"},
+	}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	env, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "second", BaseRootPackage: "root-pkg"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// Labelling an environment into a full team is refused like creating one.
+	_, err = orch.UpdateEnvironment(ctx, env.ID, model.UpdateEnvironmentRequest{
+		Labels: map[string]*string{model.TeamLabel: label("payments")},
+	})
+	if !errors.Is(err, orchestrator.ErrQuotaExceeded) {
+		t.Fatalf("expected the team quota to be exceeded, got %v", err)
+	}
+	got, err := orch.GetEnvironment(ctx, env.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.Labels[model.TeamLabel] != "" {
+		t.Fatalf("expected the team label unchanged, got %v", got.Labels)
+	}
+
+	if _, err := orch.UpdateEnvironment(ctx, env.ID, model.UpdateEnvironmentRequest{
+		Labels: map[string]*string{model.TeamLabel: label("search")},
+	}); err != nil {
+		t.Fatalf("expected room in another team, got %v", err)
+	}
+	// Updating other labels leaves an environment in its team.
+	if _, err := orch.UpdateEnvironment(ctx, env.ID, model.UpdateEnvironmentRequest{
+		Labels: map[string]*string{"owner": label("bob")},
+	}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+}
+
+func TestQuotas_Footprint(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{buildID: "build-1"}, &mockOperator{}, orchestrator.WithQuotas(model.Quotas{
+		MaxComponents: 2,
+		MaxReplicas:   4,
+	}))
+	ctx := context.Background()
+	replicas := func(n int32) *model.RuntimeOverride { return &model.RuntimeOverride{Replicas: &n} }
+
+	_, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name:            "too-many-components",
+		BaseRootPackage: "root-pkg",
+		Overrides:       []model.PackageOverride{usersOverride, ordersOverride},
+	})
+	if !errors.Is(err, orchestrator.ErrLimitExceeded) {
+		t.Fatalf("expected ErrLimitExceeded for three components, got %v", err)
+	}
+
+	env := createParent(t, orch, usersOverride)
+	scaled := usersOverride
+	scaled.Runtime = replicas(4)
+	if _, err := orch.ApplyOverrides(ctx, env.ID, model.ApplyOverridesRequest{
+		Overrides: []model.PackageOverride{scaled},
+	}); !errors.Is(err, orchestrator.ErrLimitExceeded) {
+		t.Fatalf("expected ErrLimitExceeded for five replicas, got %v", err)
+	}
+
+	scaled.Runtime = replicas(3)
+	if _, err := orch.ApplyOverrides(ctx, env.ID, model.ApplyOverridesRequest{
+		Overrides: []model.PackageOverride{scaled},
+	}); err != nil {
+		t.Fatalf("expected four replicas to fit, got %v", err)
+	}
+}
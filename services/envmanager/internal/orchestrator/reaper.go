@@ -0,0 +1,142 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+// RecordActivity marks an environment as in use, postponing idle expiry.
+// Gateways call it to report preview traffic.
+func (o *Orchestrator) RecordActivity(ctx context.Context, id string) error {
+	ctx, span := tracer.Start(ctx, "Orchestrator.RecordActivity",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	_, err := o.mutate(ctx, id, func(env *model.Environment) error {
+		env.LastActivityAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+	return nil
+}
+
+// Reap tears down and deletes every environment that has passed its
+// ExpiresAt or been idle for longer than the idle timeout. With dryRun set it
+// only reports what it would delete.
+func (o *Orchestrator) Reap(ctx context.Context, dryRun bool) (model.ReapResponse, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.Reap",
+		trace.WithAttributes(attribute.Bool("reap.dry_run", dryRun)))
+	defer span.End()
+
+	candidates, err := o.reapCandidates(ctx, time.Now().UTC())
+	if err != nil {
+		span.RecordError(err)
+		return model.ReapResponse{}, err
+	}
+	if dryRun {
+		return model.ReapResponse{DryRun: true, Environments: candidates}, nil
+	}
+
+	reaped := make([]model.ReapCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		// The environment may have seen activity since it was listed.
+		env, err := o.store.Get(ctx, c.EnvironmentID)
+		if err != nil {
+			continue
+		}
+		if _, ok := o.reapReason(env, time.Now().UTC()); !ok {
+			continue
+		}
+
+		if err := o.deleteEnvironment(ctx, c.EnvironmentID, "reaped: "+string(c.Reason), true); err != nil {
+			span.RecordError(err)
+			o.logger.ErrorContext(ctx, "failed to reap environment",
+				slog.String("id", c.EnvironmentID),
+				slog.String("error", err.Error()))
+			continue
+		}
+		o.logger.InfoContext(ctx, "environment reaped",
+			slog.String("id", c.EnvironmentID),
+			slog.String("reason", string(c.Reason)))
+		reaped = append(reaped, c)
+	}
+	return model.ReapResponse{Environments: reaped}, nil
+}
+
+// RunReaper calls Reap every interval until ctx is cancelled. A non-positive
+// interval disables it.
+func (o *Orchestrator) RunReaper(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := o.Reap(ctx, false); err != nil {
+				o.logger.ErrorContext(ctx, "reaper run failed", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// reapCandidates lists the environments due to be reaped at now.
+func (o *Orchestrator) reapCandidates(ctx context.Context, now time.Time) ([]model.ReapCandidate, error) {
+	candidates := []model.ReapCandidate{}
+	filter := store.ListFilter{PageSize: 100}
+	for {
+		result, err := o.store.List(ctx, filter)
+		if err != nil {
+			return nil, fmt.Errorf("list environments: %w", err)
+		}
+		for _, env := range result.Environments {
+			reason, ok := o.reapReason(env, now)
+			if !ok {
+				continue
+			}
+			candidates = append(candidates, model.ReapCandidate{
+				EnvironmentID:  env.ID,
+				Name:           env.Name,
+				Branch:         env.Branch,
+				Reason:         reason,
+				ExpiresAt:      env.ExpiresAt,
+				LastActivityAt: env.LastActive(),
+			})
+		}
+		if result.NextPageToken == "" {
+			return candidates, nil
+		}
+		filter.PageToken = result.NextPageToken
+	}
+}
+
+// reapReason reports whether env should be reaped at now, and why.
+// Environments already being deleted are left to their teardown, protected
+// and locked environments are never reaped, and an environment with a build
+// or deploy in flight is never idle.
+func (o *Orchestrator) reapReason(env model.Environment, now time.Time) (model.ReapReason, bool) {
+	switch {
+	case env.Status == model.StatusDeleting, env.Protected, env.Lock.Held(now):
+		return "", false
+	case env.ExpiresAt != nil && !now.Before(*env.ExpiresAt):
+		return model.ReapReasonExpired, true
+	case o.idleTimeout > 0 && env.Pending == nil && now.Sub(env.LastActive()) >= o.idleTimeout:
+		return model.ReapReasonIdle, true
+	}
+	return "", false
+}
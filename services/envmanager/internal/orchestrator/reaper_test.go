@@ -0,0 +1,129 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+// seedReapFixture stores one expired, one idle, one busy-but-idle and one
+// active environment.
+func seedReapFixture(t *testing.T, s store.Store) {
+	t.Helper()
+	past := time.Now().UTC().Add(-time.Hour)
+	future := time.Now().UTC().Add(time.Hour)
+	longAgo := time.Now().UTC().Add(-48 * time.Hour)
+
+	seedEnvironment(t, s, model.Environment{ID: "env-expired", Status: model.StatusReady, ExpiresAt: &past})
+	seedEnvironment(t, s, model.Environment{ID: "env-idle", Status: model.StatusReady, ExpiresAt: &future, LastActivityAt: longAgo})
+	seedEnvironment(t, s, model.Environment{ID: "env-building", Status: model.StatusBuilding, LastActivityAt: longAgo,
+		Pending: &model.PendingOperation{Type: model.OperationBuildDeploy, Attempts: 1}})
+	seedEnvironment(t, s, model.Environment{ID: "env-active", Status: model.StatusReady, ExpiresAt: &future})
+}
+
+func TestReap(t *testing.T) {
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(&mockBuilder{}, o, orchestrator.WithIdleTimeout(24*time.Hour))
+	seedReapFixture(t, s)
+
+	resp, err := orch.Reap(context.Background(), false)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if resp.DryRun {
+		t.Fatal("expected a real run")
+	}
+	reasons := make(map[string]model.ReapReason)
+	for _, c := range resp.Environments {
+		reasons[c.EnvironmentID] = c.Reason
+	}
+	if len(reasons) != 2 || reasons["env-expired"] != model.ReapReasonExpired || reasons["env-idle"] != model.ReapReasonIdle {
+		t.Fatalf("expected env-expired (expired) and env-idle (idle), got %+v", resp.Environments)
+	}
+
+	if len(o.teardownIDs) != 2 {
+		t.Fatalf("expected 2 teardowns, got %v", o.teardownIDs)
+	}
+	for _, id := range []string{"env-expired", "env-idle"} {
+		if _, err := s.Get(context.Background(), id); !errors.Is(err, store.ErrNotFound) {
+			t.Fatalf("expected %s deleted, got %v", id, err)
+		}
+	}
+	for _, id := range []string{"env-building", "env-active"} {
+		if _, err := s.Get(context.Background(), id); err != nil {
+			t.Fatalf("expected %s kept, got %v", id, err)
+		}
+	}
+}
+
+func TestReap_DryRun(t *testing.T) {
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(&mockBuilder{}, o, orchestrator.WithIdleTimeout(24*time.Hour))
+	seedReapFixture(t, s)
+
+	resp, err := orch.Reap(context.Background(), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !resp.DryRun || len(resp.Environments) != 2 {
+		t.Fatalf("expected a dry run listing 2 environments, got %+v", resp)
+	}
+	if len(o.teardownIDs) != 0 {
+		t.Fatalf("expected no teardowns in a dry run, got %v", o.teardownIDs)
+	}
+	if _, err := s.Get(context.Background(), "env-expired"); err != nil {
+		t.Fatalf("expected env-expired kept in a dry run, got %v", err)
+	}
+}
+
+func TestReap_IdleExpiryDisabledByDefault(t *testing.T) {
+	orch, s := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	seedReapFixture(t, s)
+
+	resp, err := orch.Reap(context.Background(), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Environments) != 1 || resp.Environments[0].EnvironmentID != "env-expired" {
+		t.Fatalf("expected only env-expired, got %+v", resp.Environments)
+	}
+}
+
+func TestRecordActivity_PostponesIdleExpiry(t *testing.T) {
+	orch, s := newTestOrchestrator(&mockBuilder{}, &mockOperator{}, orchestrator.WithIdleTimeout(24*time.Hour))
+	seedEnvironment(t, s, model.Environment{ID: "env-idle", Status: model.StatusReady, LastActivityAt: time.Now().UTC().Add(-48 * time.Hour)})
+
+	if err := orch.RecordActivity(context.Background(), "env-idle"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	resp, err := orch.Reap(context.Background(), true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resp.Environments) != 0 {
+		t.Fatalf("expected nothing to reap, got %+v", resp.Environments)
+	}
+}
+
+func TestCreateEnvironment_TTL(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+
+	before := time.Now().UTC()
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "ttl-env",
+		BaseRootPackage: "root-pkg",
+		TTL:             "72h",
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.ExpiresAt == nil || env.ExpiresAt.Before(before.Add(72*time.Hour)) {
+		t.Fatalf("expected expiresAt about 72h from now, got %v", env.ExpiresAt)
+	}
+}
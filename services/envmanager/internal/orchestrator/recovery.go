@@ -0,0 +1,118 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+// maxResumeAttempts caps how many times an interrupted build/deploy is
+// resumed before it is given up on, so a workflow that crashes the service
+// cannot put it into a restart loop.
+const maxResumeAttempts = 3
+
+// Recover resumes or compensates work that was in flight when the service
+// last stopped. It should be called once at startup, before serving traffic:
+//
+//   - environments with a pending build/deploy are resumed from their last
+//     completed stage, or failed once they exceed maxResumeAttempts;
+//   - environments with a pending teardown have Teardown re-issued and are
+//     then deleted;
+//   - environments left in a transitional status with no recorded pending
+//     work are settled into a terminal status.
+func (o *Orchestrator) Recover(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "Orchestrator.Recover")
+	defer span.End()
+
+	var envs []model.Environment
+	filter := store.ListFilter{PageSize: 100}
+	for {
+		result, err := o.store.List(ctx, filter)
+		if err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("list environments: %w", err)
+		}
+		envs = append(envs, result.Environments...)
+		if result.NextPageToken == "" {
+			break
+		}
+		filter.PageToken = result.NextPageToken
+	}
+
+	for _, env := range envs {
+		if err := o.recoverEnvironment(ctx, env); err != nil {
+			span.RecordError(err)
+			o.logger.ErrorContext(ctx, "failed to recover environment",
+				slog.String("id", env.ID),
+				slog.String("status", string(env.Status)),
+				slog.String("error", err.Error()))
+		}
+	}
+	return nil
+}
+
+func (o *Orchestrator) recoverEnvironment(ctx context.Context, env model.Environment) error {
+	switch {
+	case env.Status == model.StatusDeleting:
+		o.logger.InfoContext(ctx, "resuming teardown", slog.String("id", env.ID))
+		if _, err := o.mutate(ctx, env.ID, func(env *model.Environment) error {
+			if env.Pending == nil || env.Pending.Type != model.OperationTeardown {
+				env.Pending = newPendingOperation(model.OperationTeardown)
+			} else {
+				env.Pending.Attempts++
+			}
+			return nil
+		}); err != nil {
+			return err
+		}
+		return o.teardown(ctx, env.ID, "teardown resumed after restart")
+
+	case env.Pending != nil && env.Pending.Type == model.OperationBuildDeploy:
+		if env.Pending.Attempts >= maxResumeAttempts {
+			o.failWorkflow(ctx, env.ID, currentStage(env),
+				fmt.Errorf("interrupted %d times; giving up", env.Pending.Attempts))
+			return nil
+		}
+		o.logger.InfoContext(ctx, "resuming build and deploy",
+			slog.String("id", env.ID),
+			slog.String("stage", string(currentStage(env))),
+			slog.Int("attempt", env.Pending.Attempts+1))
+		if _, err := o.mutate(ctx, env.ID, func(env *model.Environment) error {
+			env.Pending.Attempts++
+			return nil
+		}); err != nil {
+			return err
+		}
+		o.launchWorkflow(ctx, env.ID)
+		return nil
+
+	case env.Status == model.StatusCreating && len(env.Overrides) > 0:
+		// Crashed between persisting the environment and starting its build.
+		_, err := o.startWorkflow(ctx, env.ID)
+		return err
+
+	case env.Status == model.StatusCreating:
+		_, err := o.transition(ctx, env.ID, model.StatusReady, nil)
+		return err
+
+	case env.Status == model.StatusBuilding || env.Status == model.StatusDeploying:
+		// Transitional status without recorded work: nothing to resume from.
+		o.failWorkflow(ctx, env.ID, currentStage(env),
+			fmt.Errorf("interrupted with no pending operation recorded"))
+		return nil
+	}
+	return nil
+}
+
+// currentStage returns the first workflow stage that has not succeeded.
+func currentStage(env model.Environment) model.WorkflowStage {
+	for _, p := range env.Progress {
+		if p.Status != model.StageStatusSucceeded {
+			return p.Stage
+		}
+	}
+	return model.StageBuild
+}
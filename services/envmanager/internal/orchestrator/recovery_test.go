@@ -0,0 +1,124 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+func TestRecover_ReissuesTeardown(t *testing.T) {
+	b := &mockBuilder{}
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(b, o)
+
+	seedEnvironment(t, s, model.Environment{
+		ID:      "env-deleting",
+		Status:  model.StatusDeleting,
+		Pending: &model.PendingOperation{Type: model.OperationTeardown, Attempts: 1},
+	})
+
+	if err := orch.Recover(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if len(o.teardownIDs) != 1 || o.teardownIDs[0] != "env-deleting" {
+		t.Fatalf("expected teardown re-issued for env-deleting, got %v", o.teardownIDs)
+	}
+	if _, err := s.Get(context.Background(), "env-deleting"); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("expected environment deleted, got %v", err)
+	}
+}
+
+func TestRecover_ResumesFromLastCompletedStage(t *testing.T) {
+	b := &mockBuilder{buildID: "build-new"}
+	o := &mockOperator{previewURL: "https://preview.example.com"}
+	orch, s := newTestOrchestrator(b, o)
+
+	started := time.Now().UTC()
+	seedEnvironment(t, s, model.Environment{
+		ID:             "env-building",
+		Status:         model.StatusBuilding,
+		CurrentBuildID: "build-old",
+		Overrides:      []model.PackageOverride{{PackageName: "users"}},
+		Pending:        &model.PendingOperation{Type: model.OperationBuildDeploy, Attempts: 1},
+		Progress: []model.StageProgress{
+			{Stage: model.StageBuild, Status: model.StageStatusSucceeded, StartedAt: &started, CompletedAt: &started},
+			{Stage: model.StageDeploy, Status: model.StageStatusRunning, StartedAt: &started},
+			{Stage: model.StageRollout, Status: model.StageStatusPending},
+		},
+	})
+
+	if err := orch.Recover(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	env := waitForEnvironment(t, orch, "env-building")
+	if env.Status != model.StatusReady {
+		t.Fatalf("expected status ready, got %s (%s)", env.Status, env.LastError)
+	}
+	if b.called != 0 {
+		t.Fatalf("expected completed build not re-triggered, got %d builds", b.called)
+	}
+	if o.deployCalls != 1 {
+		t.Fatalf("expected deploy resumed once, got %d", o.deployCalls)
+	}
+	if env.CurrentBuildID != "build-old" {
+		t.Fatalf("expected original build ID kept, got %s", env.CurrentBuildID)
+	}
+	if env.Pending != nil {
+		t.Fatalf("expected pending operation cleared, got %+v", env.Pending)
+	}
+}
+
+func TestRecover_GivesUpAfterMaxAttempts(t *testing.T) {
+	b := &mockBuilder{buildID: "build-1"}
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(b, o)
+
+	seedEnvironment(t, s, model.Environment{
+		ID:      "env-looping",
+		Status:  model.StatusBuilding,
+		Pending: &model.PendingOperation{Type: model.OperationBuildDeploy, Attempts: 3},
+		Progress: []model.StageProgress{
+			{Stage: model.StageBuild, Status: model.StageStatusRunning},
+			{Stage: model.StageDeploy, Status: model.StageStatusPending},
+			{Stage: model.StageRollout, Status: model.StageStatusPending},
+		},
+	})
+
+	if err := orch.Recover(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	env := waitForEnvironment(t, orch, "env-looping")
+	if env.Status != model.StatusFailed {
+		t.Fatalf("expected status failed, got %s", env.Status)
+	}
+	if b.called != 0 {
+		t.Fatalf("expected no build triggered, got %d", b.called)
+	}
+}
+
+func TestRecover_SettlesTransitionalStatusWithoutPendingWork(t *testing.T) {
+	b := &mockBuilder{}
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(b, o)
+
+	seedEnvironment(t, s, model.Environment{ID: "env-orphan", Status: model.StatusDeploying})
+	seedEnvironment(t, s, model.Environment{ID: "env-creating", Status: model.StatusCreating})
+
+	if err := orch.Recover(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if env := waitForEnvironment(t, orch, "env-orphan"); env.Status != model.StatusFailed {
+		t.Fatalf("expected orphaned deploy to fail, got %s", env.Status)
+	}
+	if env := waitForEnvironment(t, orch, "env-creating"); env.Status != model.StatusReady {
+		t.Fatalf("expected interrupted create to become ready, got %s", env.Status)
+	}
+}
@@ -0,0 +1,103 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+var (
+	// ErrBuildNotFound is returned when a rollback names a build that is not
+	// in the environment's build history.
+	ErrBuildNotFound = errors.New("build not found in environment history")
+
+	// ErrNoRollbackTarget is returned when a rollback names a build that never
+	// deployed successfully, or there is no earlier successful build.
+	ErrNoRollbackTarget = errors.New("no successful build to roll back to")
+)
+
+// Rollback redeploys an earlier successful build of the environment through
+// the operator, without rebuilding. The environment's overrides are restored
+// to those the build was deployed with.
+func (o *Orchestrator) Rollback(ctx context.Context, id string, req model.RollbackRequest) (model.Environment, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.Rollback",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	var (
+		target model.BuildRecord
+		change model.OverridesChange
+	)
+	_, err := o.mutate(ctx, id, func(env *model.Environment) error {
+		if err := checkLock(ctx, *env, time.Now().UTC()); err != nil {
+			return err
+		}
+		var ok bool
+		if req.BuildID == "" {
+			if target, ok = env.PreviousBuild(); !ok {
+				return ErrNoRollbackTarget
+			}
+		} else {
+			if target, ok = env.FindBuild(req.BuildID); !ok {
+				return fmt.Errorf("%w: %s", ErrBuildNotFound, req.BuildID)
+			}
+			if target.Outcome != model.BuildOutcomeSucceeded {
+				return fmt.Errorf("%w: build %s %s", ErrNoRollbackTarget, target.BuildID, target.Outcome)
+			}
+		}
+		if err := model.ValidateTransition(env.Status, model.StatusBuilding); err != nil {
+			return err
+		}
+		change = model.DiffOverrides(env.Overrides, target.Overrides)
+		env.Overrides = model.CloneOverrides(target.Overrides)
+		env.LastActivityAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("rollback: %w", err)
+	}
+
+	o.logger.InfoContext(ctx, "rolling back environment",
+		slog.String("id", id),
+		slog.String("buildId", target.BuildID))
+
+	o.record(ctx, model.Event{
+		EnvironmentID: id,
+		Type:          model.EventRolledBack,
+		BuildID:       target.BuildID,
+		Status:        model.StatusBuilding,
+		Overrides:     &change,
+	})
+
+	env, err := o.startWorkflowFromBuild(ctx, id, target.BuildID, model.BuildSourceRollback)
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, fmt.Errorf("start deploy: %w", err)
+	}
+	return env, nil
+}
+
+// ListBuilds returns an environment's build history, newest first.
+func (o *Orchestrator) ListBuilds(ctx context.Context, id string) ([]model.BuildRecord, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.ListBuilds",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	env, err := o.store.Get(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	builds := env.BuildHistory
+	slices.Reverse(builds)
+	return builds, nil
+}
@@ -0,0 +1,211 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+)
+
+// deployTwice creates an environment built as build-1 with the users
+// override, then rebuilds it as build-2 with the orders override.
+func deployTwice(t *testing.T, orch *orchestrator.Orchestrator, b *mockBuilder) model.Environment {
+	t.Helper()
+	b.buildID = "build-1"
+	env := createParent(t, orch, usersOverride)
+
+	b.buildID = "build-2"
+	if _, err := orch.ApplyOverrides(context.Background(), env.ID, model.ApplyOverridesRequest{
+		Overrides:    []model.PackageOverride{ordersOverride},
+		TriggerBuild: true,
+	}); err != nil {
+		t.Fatalf("apply overrides: %v", err)
+	}
+	return waitForEnvironment(t, orch, env.ID)
+}
+
+func TestBuildHistory_RecordsOutcomes(t *testing.T) {
+	b := &mockBuilder{}
+	o := &mockOperator{}
+	orch, _ := newTestOrchestrator(b, o)
+	env := deployTwice(t, orch, b)
+
+	o.deployErr = errors.New("cluster unavailable")
+	b.buildID = "build-3"
+	if _, err := orch.ApplyOverrides(context.Background(), env.ID, model.ApplyOverridesRequest{
+		Overrides:    []model.PackageOverride{usersOverride, ordersOverride},
+		TriggerBuild: true,
+	}); err != nil {
+		t.Fatalf("apply overrides: %v", err)
+	}
+	waitForEnvironment(t, orch, env.ID)
+
+	builds, err := orch.ListBuilds(context.Background(), env.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []struct {
+		id      string
+		outcome model.BuildOutcome
+	}{
+		{"build-3", model.BuildOutcomeFailed},
+		{"build-2", model.BuildOutcomeSucceeded},
+		{"build-1", model.BuildOutcomeSucceeded},
+	}
+	if len(builds) != len(want) {
+		t.Fatalf("expected %d builds, got %+v", len(want), builds)
+	}
+	for i, w := range want {
+		if builds[i].BuildID != w.id || builds[i].Outcome != w.outcome || builds[i].CompletedAt == nil {
+			t.Fatalf("build %d: expected %s %s, got %+v", i, w.id, w.outcome, builds[i])
+		}
+	}
+	if builds[0].Error == "" {
+		t.Fatal("expected the failed build to record its error")
+	}
+	if len(builds[2].Overrides) != 1 || !builds[2].Overrides[0].Equal(usersOverride) {
+		t.Fatalf("expected build-1 to snapshot its overrides, got %+v", builds[2].Overrides)
+	}
+}
+
+func TestRollback_PreviousBuild(t *testing.T) {
+	b := &mockBuilder{}
+	o := &mockOperator{}
+	orch, _ := newTestOrchestrator(b, o)
+	env := deployTwice(t, orch, b)
+
+	rolling, err := orch.Rollback(context.Background(), env.ID, model.RollbackRequest{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if rolling.Status != model.StatusBuilding || rolling.CurrentBuildID != "build-1" {
+		t.Fatalf("expected a deploy of build-1, got %s on %q", rolling.Status, rolling.CurrentBuildID)
+	}
+
+	env = waitForEnvironment(t, orch, env.ID)
+	if env.Status != model.StatusReady || env.CurrentBuildID != "build-1" {
+		t.Fatalf("expected ready on build-1, got %s on %q", env.Status, env.CurrentBuildID)
+	}
+	if len(env.Overrides) != 1 || !env.Overrides[0].Equal(usersOverride) {
+		t.Fatalf("expected build-1's overrides to be restored, got %+v", env.Overrides)
+	}
+	if b.called != 2 {
+		t.Fatalf("expected the rollback not to rebuild, got %d builds", b.called)
+	}
+	if o.deployCalls != 3 {
+		t.Fatalf("expected the rollback to deploy, got %d deploys", o.deployCalls)
+	}
+
+	builds, err := orch.ListBuilds(context.Background(), env.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if builds[0].BuildID != "build-1" || builds[0].Source != model.BuildSourceRollback ||
+		builds[0].Outcome != model.BuildOutcomeSucceeded {
+		t.Fatalf("expected the rollback to be recorded, got %+v", builds[0])
+	}
+
+	// Rolling back again returns to build-2.
+	if _, err := orch.Rollback(context.Background(), env.ID, model.RollbackRequest{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env = waitForEnvironment(t, orch, env.ID); env.CurrentBuildID != "build-2" {
+		t.Fatalf("expected build-2, got %q", env.CurrentBuildID)
+	}
+}
+
+func TestRollback_NamedBuild(t *testing.T) {
+	b := &mockBuilder{}
+	orch, _ := newTestOrchestrator(b, &mockOperator{})
+	env := deployTwice(t, orch, b)
+
+	if _, err := orch.Rollback(context.Background(), env.ID, model.RollbackRequest{BuildID: "build-1"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env = waitForEnvironment(t, orch, env.ID); env.CurrentBuildID != "build-1" {
+		t.Fatalf("expected build-1, got %q", env.CurrentBuildID)
+	}
+}
+
+func TestRollback_Errors(t *testing.T) {
+	b := &mockBuilder{}
+	o := &mockOperator{}
+	orch, _ := newTestOrchestrator(b, o)
+	env := deployTwice(t, orch, b)
+
+	o.deployErr = errors.New("cluster unavailable")
+	b.buildID = "build-3"
+	if _, err := orch.ApplyOverrides(context.Background(), env.ID, model.ApplyOverridesRequest{
+		Overrides:    []model.PackageOverride{usersOverride},
+		TriggerBuild: true,
+	}); err != nil {
+		t.Fatalf("apply overrides: %v", err)
+	}
+	waitForEnvironment(t, orch, env.ID)
+
+	tests := []struct {
+		name    string
+		buildID string
+		want    error
+	}{
+		{name: "unknown build", buildID: "build-9", want: orchestrator.ErrBuildNotFound},
+		{name: "failed build", buildID: "build-3", want: orchestrator.ErrNoRollbackTarget},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := orch.Rollback(context.Background(), env.ID, model.RollbackRequest{BuildID: tt.buildID})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	fresh := createParent(t, orch)
+	if _, err := orch.Rollback(context.Background(), fresh.ID, model.RollbackRequest{}); !errors.Is(err, orchestrator.ErrNoRollbackTarget) {
+		t.Fatalf("expected ErrNoRollbackTarget without an earlier build, got %v", err)
+	}
+}
+
+func TestRollback_Locked(t *testing.T) {
+	b := &mockBuilder{}
+	o := &mockOperator{}
+	orch, _ := newTestOrchestrator(b, o)
+	env := deployTwice(t, orch, b)
+
+	if _, err := orch.Lock(asActor("alice"), env.ID, model.LockRequest{Reason: "demo"}); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	for _, ctx := range []context.Context{asActor("bob"), orchestrator.ContextWithForce(asActor("bob"))} {
+		if _, err := orch.Rollback(ctx, env.ID, model.RollbackRequest{}); !errors.Is(err, orchestrator.ErrLocked) {
+			t.Fatalf("expected ErrLocked, got %v", err)
+		}
+	}
+	got, err := orch.GetEnvironment(context.Background(), env.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got.CurrentBuildID != "build-2" {
+		t.Fatalf("expected the environment left on build-2, got %q", got.CurrentBuildID)
+	}
+
+	forced := orchestrator.ContextWithForce(asActor("alice"))
+	if _, err := orch.Rollback(forced, env.ID, model.RollbackRequest{}); err != nil {
+		t.Fatalf("forced rollback: %v", err)
+	}
+	if env = waitForEnvironment(t, orch, env.ID); env.CurrentBuildID != "build-1" {
+		t.Fatalf("expected build-1, got %q", env.CurrentBuildID)
+	}
+}
+
+func TestBuildHistory_Capped(t *testing.T) {
+	env := model.Environment{}
+	for i := 0; i < model.MaxBuildHistory+5; i++ {
+		env.RecordBuild("build", model.BuildSourceBuild, time.Now())
+	}
+	if len(env.BuildHistory) != model.MaxBuildHistory {
+		t.Fatalf("expected %d builds, got %d", model.MaxBuildHistory, len(env.BuildHistory))
+	}
+}
@@ -0,0 +1,276 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/secrets"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+var (
+	// ErrSecretsDisabled is returned when secrets are set or deployed
+	// without an encryption key configured.
+	ErrSecretsDisabled = errors.New("secrets are not enabled: no encryption key configured")
+
+	// ErrSecretInUse is returned when deleting a secret the environment's
+	// overrides still reference.
+	ErrSecretInUse = errors.New("secret is referenced by an override")
+)
+
+// WithSecrets enables environment secrets, encrypting their values with c.
+func WithSecrets(c *secrets.Cipher) Option {
+	return func(o *Orchestrator) { o.cipher = c }
+}
+
+// WithSecretStore sets where encrypted secrets are kept. By default they go
+// to the store if it is also a store.SecretStore, or are kept in memory.
+func WithSecretStore(s store.SecretStore) Option {
+	return func(o *Orchestrator) { o.secretStore = s }
+}
+
+// secretBinding ties a secret's ciphertext to the environment and name it
+// was set for.
+func secretBinding(envID, name string) string {
+	return envID + "/" + name
+}
+
+// SetSecret creates or replaces one of an environment's secrets. The value is
+// encrypted before it is stored and reaches the environment's components on
+// its next deploy.
+func (o *Orchestrator) SetSecret(ctx context.Context, id, name, value string) (model.SecretMetadata, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.SetSecret",
+		trace.WithAttributes(attribute.String("env.id", id), attribute.String("secret.name", name)))
+	defer span.End()
+
+	if o.cipher == nil {
+		return model.SecretMetadata{}, ErrSecretsDisabled
+	}
+	if err := model.ValidateSecretName(name); err != nil {
+		return model.SecretMetadata{}, err
+	}
+	env, err := o.store.Get(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return model.SecretMetadata{}, err
+	}
+	if env.Status == model.StatusDeleting {
+		return model.SecretMetadata{}, fmt.Errorf("%w: environment is being deleted", model.ErrInvalidTransition)
+	}
+
+	existing, err := o.secretStore.ListSecrets(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return model.SecretMetadata{}, fmt.Errorf("list secrets: %w", err)
+	}
+	now := time.Now().UTC()
+	secret := model.Secret{Name: name, CreatedAt: now}
+	if i := slices.IndexFunc(existing, func(s model.Secret) bool { return s.Name == name }); i >= 0 {
+		secret.CreatedAt = existing[i].CreatedAt
+	}
+	if err := o.putSecret(ctx, id, secret, value, now); err != nil {
+		span.RecordError(err)
+		return model.SecretMetadata{}, err
+	}
+
+	o.logger.InfoContext(ctx, "secret set", slog.String("id", id), slog.String("secret", name))
+	o.record(ctx, model.Event{EnvironmentID: id, Type: model.EventSecretSet, Message: name, Status: env.Status})
+	return secret.Metadata(), nil
+}
+
+// putSecret encrypts value and stores it as secret, updated at now.
+func (o *Orchestrator) putSecret(ctx context.Context, envID string, secret model.Secret, value string, now time.Time) error {
+	ciphertext, err := o.cipher.Encrypt([]byte(value), secretBinding(envID, secret.Name))
+	if err != nil {
+		return fmt.Errorf("encrypt secret %s: %w", secret.Name, err)
+	}
+	secret.Ciphertext = ciphertext
+	secret.UpdatedAt = now
+	if err := o.secretStore.PutSecret(ctx, envID, secret); err != nil {
+		return fmt.Errorf("store secret %s: %w", secret.Name, err)
+	}
+	return nil
+}
+
+// ListSecrets returns the names of an environment's secrets, never their
+// values.
+func (o *Orchestrator) ListSecrets(ctx context.Context, id string) ([]model.SecretMetadata, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.ListSecrets",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	if _, err := o.store.Get(ctx, id); err != nil {
+		span.RecordError(err)
+		return nil, err
+	}
+	stored, err := o.secretStore.ListSecrets(ctx, id)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("list secrets: %w", err)
+	}
+	out := make([]model.SecretMetadata, len(stored))
+	for i, s := range stored {
+		out[i] = s.Metadata()
+	}
+	return out, nil
+}
+
+// DeleteSecret removes one of an environment's secrets, unless its overrides
+// still reference it.
+func (o *Orchestrator) DeleteSecret(ctx context.Context, id, name string) error {
+	ctx, span := tracer.Start(ctx, "Orchestrator.DeleteSecret",
+		trace.WithAttributes(attribute.String("env.id", id), attribute.String("secret.name", name)))
+	defer span.End()
+
+	// Hold o.mu so overrides referencing the secret can't be applied between
+	// the check and the delete.
+	o.mu.Lock()
+	env, err := o.store.Get(ctx, id)
+	if err == nil && slices.Contains(model.SecretRefs(env.Overrides), name) {
+		err = fmt.Errorf("%w: %s", ErrSecretInUse, name)
+	}
+	if err == nil {
+		err = o.secretStore.DeleteSecret(ctx, id, name)
+	}
+	o.mu.Unlock()
+	if err != nil {
+		span.RecordError(err)
+		return err
+	}
+
+	o.logger.InfoContext(ctx, "secret deleted", slog.String("id", id), slog.String("secret", name))
+	o.record(ctx, model.Event{EnvironmentID: id, Type: model.EventSecretDeleted, Message: name, Status: env.Status})
+	return nil
+}
+
+// checkSecretRefs returns model.ErrInvalidOverride if overrides reference a
+// secret that is not among names.
+func checkSecretRefs(overrides []model.PackageOverride, names []string) error {
+	for _, ref := range model.SecretRefs(overrides) {
+		if !slices.Contains(names, ref) {
+			return fmt.Errorf("%w: references secret %q, which is not set", model.ErrInvalidOverride, ref)
+		}
+	}
+	return nil
+}
+
+// secretNames returns the names of an environment's secrets.
+func (o *Orchestrator) secretNames(ctx context.Context, envID string) ([]string, error) {
+	stored, err := o.secretStore.ListSecrets(ctx, envID)
+	if err != nil {
+		return nil, fmt.Errorf("list secrets: %w", err)
+	}
+	names := make([]string, len(stored))
+	for i, s := range stored {
+		names[i] = s.Name
+	}
+	return names, nil
+}
+
+// initialSecrets validates the secrets an environment is created with,
+// returning the names the new environment will have: its own plus, for a
+// fork, its parent's.
+func (o *Orchestrator) initialSecrets(ctx context.Context, parentID string, values map[string]string) ([]string, error) {
+	if len(values) > 0 && o.cipher == nil {
+		return nil, ErrSecretsDisabled
+	}
+	names := slices.Collect(maps.Keys(values))
+	for _, name := range names {
+		if err := model.ValidateSecretName(name); err != nil {
+			return nil, err
+		}
+	}
+	if parentID != "" {
+		inherited, err := o.secretNames(ctx, parentID)
+		if err != nil {
+			return nil, err
+		}
+		if len(inherited) > 0 && o.cipher == nil {
+			return nil, ErrSecretsDisabled
+		}
+		names = append(names, inherited...)
+	}
+	return names, nil
+}
+
+// storeInitialSecrets stores a new environment's secrets: a copy of its
+// parent's, if it is a fork, overlaid with values.
+func (o *Orchestrator) storeInitialSecrets(ctx context.Context, envID, parentID string, values map[string]string, now time.Time) error {
+	if parentID != "" {
+		inherited, err := o.secretStore.ListSecrets(ctx, parentID)
+		if err != nil {
+			return fmt.Errorf("list parent secrets: %w", err)
+		}
+		for _, secret := range inherited {
+			if _, ok := values[secret.Name]; ok {
+				continue
+			}
+			// Ciphertexts are bound to their environment, so re-encrypt.
+			value, err := o.cipher.Decrypt(secret.Ciphertext, secretBinding(parentID, secret.Name))
+			if err != nil {
+				return fmt.Errorf("copy parent secret %s: %w", secret.Name, err)
+			}
+			if err := o.putSecret(ctx, envID, model.Secret{Name: secret.Name, CreatedAt: now}, string(value), now); err != nil {
+				return err
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(values)) {
+		if err := o.putSecret(ctx, envID, model.Secret{Name: name, CreatedAt: now}, values[name], now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// discardSecrets deletes all of an environment's secrets, logging rather
+// than returning a failure.
+func (o *Orchestrator) discardSecrets(ctx context.Context, envID string) {
+	if err := o.secretStore.DeleteSecrets(ctx, envID); err != nil {
+		o.logger.ErrorContext(ctx, "delete secrets failed",
+			slog.String("id", envID),
+			slog.String("error", err.Error()))
+	}
+}
+
+// deploySecrets decrypts the secrets env's overrides reference, for the
+// operator to materialize. The values must never be logged.
+func (o *Orchestrator) deploySecrets(ctx context.Context, env model.Environment) (map[string]string, error) {
+	refs := model.SecretRefs(env.Overrides)
+	if len(refs) == 0 {
+		return nil, nil
+	}
+	if o.cipher == nil {
+		return nil, ErrSecretsDisabled
+	}
+	stored, err := o.secretStore.ListSecrets(ctx, env.ID)
+	if err != nil {
+		return nil, fmt.Errorf("list secrets: %w", err)
+	}
+	values := make(map[string]string, len(refs))
+	for _, secret := range stored {
+		if !slices.Contains(refs, secret.Name) {
+			continue
+		}
+		value, err := o.cipher.Decrypt(secret.Ciphertext, secretBinding(env.ID, secret.Name))
+		if err != nil {
+			return nil, fmt.Errorf("secret %s: %w", secret.Name, err)
+		}
+		values[secret.Name] = string(value)
+	}
+	for _, ref := range refs {
+		if _, ok := values[ref]; !ok {
+			return nil, fmt.Errorf("secret %s is referenced by an override but not set", ref)
+		}
+	}
+	return values, nil
+}
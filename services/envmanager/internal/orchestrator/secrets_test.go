@@ -0,0 +1,195 @@
+package orchestrator_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/secrets"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+func testCipher(t *testing.T) *secrets.Cipher {
+	t.Helper()
+	c, err := secrets.NewCipher(bytes.Repeat([]byte{1}, secrets.KeySize))
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	return c
+}
+
+// bearerOverride points users at an upstream authenticated with the token
+// secret.
+func bearerOverride() model.PackageOverride {
+	return model.PackageOverride{
+		PackageName: "users",
+		UpstreamConfig: &model.UpstreamConfig{
+			URL:  "https://users.dev.example.com/graphql",
+			Auth: &model.UpstreamAuth{Type: model.UpstreamAuthBearer, TokenSecretRef: "users-token"},
+		},
+		Runtime: &model.RuntimeOverride{SecretEnv: map[string]string{"API_KEY": "api-key"}},
+	}
+}
+
+func TestSecrets_DeployedWithReferencingOverrides(t *testing.T) {
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(&mockBuilder{}, o, orchestrator.WithSecrets(testCipher(t)))
+	ctx := context.Background()
+
+	env, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name:            "secret-env",
+		BaseRootPackage: "root-pkg",
+		Secrets:         map[string]string{"users-token": "t0ken", "api-key": "k3y", "unused": "x"},
+		Overrides:       []model.PackageOverride{bearerOverride()},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	env = waitForEnvironment(t, orch, env.ID)
+	if env.Status != model.StatusReady {
+		t.Fatalf("expected ready, got %s", env.Status)
+	}
+	if len(o.deploySecrets) != 2 || o.deploySecrets["users-token"] != "t0ken" || o.deploySecrets["api-key"] != "k3y" {
+		t.Fatalf("expected the referenced secrets to be deployed, got %d", len(o.deploySecrets))
+	}
+
+	// Values are encrypted at rest and never returned.
+	stored, err := s.(store.SecretStore).ListSecrets(ctx, env.ID)
+	if err != nil {
+		t.Fatalf("list stored secrets: %v", err)
+	}
+	for _, secret := range stored {
+		if strings.Contains(secret.Ciphertext, "t0ken") || strings.Contains(secret.Ciphertext, "k3y") {
+			t.Fatalf("secret %s is stored in plaintext", secret.Name)
+		}
+	}
+	listed, err := orch.ListSecrets(ctx, env.ID)
+	if err != nil {
+		t.Fatalf("list secrets: %v", err)
+	}
+	if len(listed) != 3 || listed[0].Name != "api-key" {
+		t.Fatalf("expected 3 secrets sorted by name, got %+v", listed)
+	}
+
+	// A referenced secret can't be deleted; an unreferenced one can.
+	if err := orch.DeleteSecret(ctx, env.ID, "users-token"); !errors.Is(err, orchestrator.ErrSecretInUse) {
+		t.Fatalf("expected ErrSecretInUse, got %v", err)
+	}
+	if err := orch.DeleteSecret(ctx, env.ID, "unused"); err != nil {
+		t.Fatalf("delete secret: %v", err)
+	}
+	if err := orch.DeleteSecret(ctx, env.ID, "unused"); !errors.Is(err, store.ErrSecretNotFound) {
+		t.Fatalf("expected ErrSecretNotFound, got %v", err)
+	}
+
+	// Deleting the environment deletes its secrets.
+	if err := orch.DeleteEnvironment(ctx, env.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if stored, _ := s.(store.SecretStore).ListSecrets(ctx, env.ID); len(stored) != 0 {
+		t.Fatalf("expected secrets to be deleted with the environment, got %d", len(stored))
+	}
+}
+
+func TestSecrets_OverridesMustReferenceSetSecrets(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{}, orchestrator.WithSecrets(testCipher(t)))
+	ctx := context.Background()
+
+	_, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name:            "missing",
+		BaseRootPackage: "root-pkg",
+		Overrides:       []model.PackageOverride{bearerOverride()},
+	})
+	if !errors.Is(err, model.ErrInvalidOverride) {
+		t.Fatalf("expected ErrInvalidOverride on create, got %v", err)
+	}
+
+	env, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "later", BaseRootPackage: "root-pkg"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	req := model.ApplyOverridesRequest{Overrides: []model.PackageOverride{bearerOverride()}}
+	if _, err := orch.ApplyOverrides(ctx, env.ID, req); !errors.Is(err, model.ErrInvalidOverride) {
+		t.Fatalf("expected ErrInvalidOverride on apply, got %v", err)
+	}
+	for name, value := range map[string]string{"users-token": "t0ken", "api-key": "k3y"} {
+		if _, err := orch.SetSecret(ctx, env.ID, name, value); err != nil {
+			t.Fatalf("set secret: %v", err)
+		}
+	}
+	if _, err := orch.ApplyOverrides(ctx, env.ID, req); err != nil {
+		t.Fatalf("apply after setting secrets: %v", err)
+	}
+}
+
+func TestSecrets_ForkInheritsParentSecrets(t *testing.T) {
+	o := &mockOperator{}
+	orch, _ := newTestOrchestrator(&mockBuilder{}, o, orchestrator.WithSecrets(testCipher(t)))
+	ctx := context.Background()
+
+	parent, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name:            "parent",
+		BaseRootPackage: "root-pkg",
+		Secrets:         map[string]string{"users-token": "parent-token", "api-key": "parent-key"},
+		Overrides:       []model.PackageOverride{bearerOverride()},
+	})
+	if err != nil {
+		t.Fatalf("create parent: %v", err)
+	}
+	waitForEnvironment(t, orch, parent.ID)
+
+	child, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name:     "child",
+		ParentID: parent.ID,
+		Secrets:  map[string]string{"api-key": "child-key"},
+		Overrides: []model.PackageOverride{{
+			PackageName: "products",
+			Runtime:     &model.RuntimeOverride{SecretEnv: map[string]string{"TOKEN": "users-token"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("create child: %v", err)
+	}
+	waitForEnvironment(t, orch, child.ID)
+	if o.deploySecrets["users-token"] != "parent-token" || o.deploySecrets["api-key"] != "child-key" {
+		t.Fatal("expected the child to deploy its parent's secrets overlaid with its own")
+	}
+}
+
+func TestSecrets_Disabled(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	ctx := context.Background()
+
+	env, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "env", BaseRootPackage: "root-pkg"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := orch.SetSecret(ctx, env.ID, "token", "value"); !errors.Is(err, orchestrator.ErrSecretsDisabled) {
+		t.Fatalf("expected ErrSecretsDisabled, got %v", err)
+	}
+	_, err = orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name: "with-secrets", BaseRootPackage: "root-pkg", Secrets: map[string]string{"token": "value"},
+	})
+	if !errors.Is(err, orchestrator.ErrSecretsDisabled) {
+		t.Fatalf("expected ErrSecretsDisabled on create, got %v", err)
+	}
+}
+
+func TestSecrets_InvalidName(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{}, orchestrator.WithSecrets(testCipher(t)))
+	ctx := context.Background()
+
+	env, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "env", BaseRootPackage: "root-pkg"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	for _, name := range []string{"has space", "..", "slash/name"} {
+		if _, err := orch.SetSecret(ctx, env.ID, name, "value"); !errors.Is(err, model.ErrInvalidSecret) {
+			t.Fatalf("%q: expected ErrInvalidSecret, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+// WithIdleSleep puts ready environments without an idle timeout of their own
+// to sleep once they have been idle for d. Zero, the default, disables it.
+func WithIdleSleep(d time.Duration) Option {
+	return func(o *Orchestrator) { o.idleSleep = d }
+}
+
+// Sleep scales a ready environment to zero replicas until it is woken.
+func (o *Orchestrator) Sleep(ctx context.Context, id string) (model.Environment, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.Sleep",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	env, err := o.sleep(ctx, id, model.SleepReasonManual)
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, err
+	}
+	return env, nil
+}
+
+// sleep moves the environment to sleeping and asks the operator to scale it
+// down. If the operator fails the environment is left ready.
+func (o *Orchestrator) sleep(ctx context.Context, id string, reason model.SleepReason) (model.Environment, error) {
+	env, err := o.transition(ctx, id, model.StatusSleeping, func(env *model.Environment) error {
+		env.SleepReason = reason
+		return nil
+	})
+	if err != nil {
+		return model.Environment{}, err
+	}
+
+	if err := o.operator.Sleep(ctx, id); err != nil {
+		if _, rerr := o.transition(ctx, id, model.StatusReady, func(env *model.Environment) error {
+			env.SleepReason = ""
+			return nil
+		}); rerr != nil {
+			o.logger.ErrorContext(ctx, "failed to restore environment after failed sleep",
+				slog.String("id", id),
+				slog.String("error", rerr.Error()))
+		}
+		return model.Environment{}, fmt.Errorf("scale to zero: %w", err)
+	}
+
+	o.logger.InfoContext(ctx, "environment sleeping",
+		slog.String("id", id),
+		slog.String("reason", string(reason)))
+	o.record(ctx, model.Event{
+		EnvironmentID: id,
+		Type:          model.EventSlept,
+		Message:       string(reason),
+		Status:        env.Status,
+	})
+	return env, nil
+}
+
+// Wake scales a sleeping environment back up and marks it ready. Waking
+// counts as activity, so the environment stays awake until its schedule next
+// puts it to sleep.
+func (o *Orchestrator) Wake(ctx context.Context, id string) (model.Environment, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.Wake",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	env, err := o.wake(ctx, id, "")
+	if err != nil {
+		span.RecordError(err)
+		return model.Environment{}, err
+	}
+	return env, nil
+}
+
+// wake is Wake, recording reason on the woke event.
+func (o *Orchestrator) wake(ctx context.Context, id, reason string) (model.Environment, error) {
+	current, err := o.store.Get(ctx, id)
+	if err != nil {
+		return model.Environment{}, err
+	}
+	if current.Status != model.StatusSleeping {
+		return model.Environment{}, &model.TransitionError{From: current.Status, To: model.StatusReady}
+	}
+
+	if err := o.operator.Wake(ctx, id); err != nil {
+		return model.Environment{}, fmt.Errorf("scale up: %w", err)
+	}
+
+	env, err := o.mutate(ctx, id, func(env *model.Environment) error {
+		if env.Status != model.StatusSleeping {
+			return &model.TransitionError{From: env.Status, To: model.StatusReady}
+		}
+		env.Status = model.StatusReady
+		env.SleepReason = ""
+		env.LastActivityAt = time.Now().UTC()
+		return nil
+	})
+	if err != nil {
+		return model.Environment{}, err
+	}
+
+	o.logger.InfoContext(ctx, "environment woken", slog.String("id", id))
+	o.record(ctx, model.Event{
+		EnvironmentID: id,
+		Type:          model.EventWoke,
+		Message:       reason,
+		Status:        env.Status,
+	})
+	return env, nil
+}
+
+// ApplySleepSchedules puts ready environments to sleep when they are outside
+// their awake hours or idle, and wakes the environments their schedule put
+// to sleep once their awake hours begin again. An environment with activity
+// since its off hours began stays awake until the next off hours.
+func (o *Orchestrator) ApplySleepSchedules(ctx context.Context, now time.Time) error {
+	ctx, span := tracer.Start(ctx, "Orchestrator.ApplySleepSchedules")
+	defer span.End()
+
+	var envs []model.Environment
+	filter := store.ListFilter{
+		Statuses: []model.EnvironmentStatus{model.StatusReady, model.StatusSleeping},
+		PageSize: 100,
+	}
+	for {
+		result, err := o.store.List(ctx, filter)
+		if err != nil {
+			span.RecordError(err)
+			return fmt.Errorf("list environments: %w", err)
+		}
+		envs = append(envs, result.Environments...)
+		if result.NextPageToken == "" {
+			break
+		}
+		filter.PageToken = result.NextPageToken
+	}
+
+	for _, env := range envs {
+		var err error
+		switch {
+		case env.Status == model.StatusSleeping && o.wakeDue(env, now):
+			_, err = o.wake(ctx, env.ID, "awake hours began")
+		case env.Status == model.StatusReady:
+			if reason, ok := o.sleepDue(env, now); ok {
+				_, err = o.sleep(ctx, env.ID, reason)
+			}
+		}
+		if err != nil {
+			span.RecordError(err)
+			o.logger.ErrorContext(ctx, "failed to apply sleep schedule",
+				slog.String("id", env.ID),
+				slog.String("error", err.Error()))
+		}
+	}
+	return nil
+}
+
+// RunSleepScheduler calls ApplySleepSchedules every interval until ctx is
+// cancelled. A non-positive interval disables it.
+func (o *Orchestrator) RunSleepScheduler(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := o.ApplySleepSchedules(ctx, time.Now()); err != nil {
+				o.logger.ErrorContext(ctx, "sleep scheduler run failed", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// sleepDue reports whether a ready environment should go to sleep at now,
+// and why.
+func (o *Orchestrator) sleepDue(env model.Environment, now time.Time) (model.SleepReason, bool) {
+	if since, off := env.SleepSchedule.OffHoursSince(now); off && env.LastActive().Before(since) {
+		return model.SleepReasonSchedule, true
+	}
+	idle := env.SleepSchedule.IdleTimeout()
+	if idle == 0 {
+		idle = o.idleSleep
+	}
+	if idle > 0 && now.Sub(env.LastActive()) >= idle {
+		return model.SleepReasonIdle, true
+	}
+	return "", false
+}
+
+// wakeDue reports whether a sleeping environment's schedule wakes it at now.
+// Environments put to sleep by hand or for idling stay asleep until woken.
+func (o *Orchestrator) wakeDue(env model.Environment, now time.Time) bool {
+	if env.SleepReason != model.SleepReasonSchedule {
+		return false
+	}
+	_, off := env.SleepSchedule.OffHoursSince(now)
+	return !off
+}
@@ -0,0 +1,256 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+)
+
+// monday is a Monday at noon UTC.
+var monday = time.Date(2026, time.March, 2, 12, 0, 0, 0, time.UTC)
+
+func officeHours() *model.SleepSchedule {
+	return &model.SleepSchedule{
+		AwakeFrom:  "08:00",
+		AwakeUntil: "18:00",
+		Days:       []string{"mon", "tue", "wed", "thu", "fri"},
+	}
+}
+
+func TestSleepSchedule_OffHoursSince(t *testing.T) {
+	s := officeHours()
+	tests := []struct {
+		name      string
+		at        time.Time
+		wantOff   bool
+		wantSince time.Time
+	}{
+		{name: "working hours", at: monday},
+		{name: "evening", at: monday.Add(7 * time.Hour), wantOff: true, wantSince: monday.Add(6 * time.Hour)},
+		{name: "early morning", at: monday.Add(-6 * time.Hour), wantOff: true, wantSince: monday.Add(-66 * time.Hour)},
+		{name: "saturday", at: monday.AddDate(0, 0, 5), wantOff: true, wantSince: monday.AddDate(0, 0, 4).Add(6 * time.Hour)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			since, off := s.OffHoursSince(tt.at)
+			if off != tt.wantOff || !since.Equal(tt.wantSince) {
+				t.Fatalf("expected (%v, %v), got (%v, %v)", tt.wantSince, tt.wantOff, since, off)
+			}
+		})
+	}
+
+	// Awake hours are wall clock times in the schedule's time zone.
+	ny := officeHours()
+	ny.Timezone = "America/New_York"
+	if _, off := ny.OffHoursSince(monday); !off {
+		t.Fatal("expected 07:00 in New York to be off hours")
+	} else if _, off := ny.OffHoursSince(monday.Add(2 * time.Hour)); off {
+		t.Fatal("expected 09:00 in New York to be awake hours")
+	}
+}
+
+func TestSleepSchedule_Validate(t *testing.T) {
+	for _, s := range []model.SleepSchedule{
+		{AwakeFrom: "08:00"},
+		{AwakeFrom: "18:00", AwakeUntil: "08:00"},
+		{AwakeFrom: "8am", AwakeUntil: "6pm"},
+		{AwakeFrom: "08:00", AwakeUntil: "18:00", Days: []string{"monday"}},
+		{AwakeFrom: "08:00", AwakeUntil: "18:00", Timezone: "Mars/Olympus_Mons"},
+		{IdleAfter: "soon"},
+		{IdleAfter: "-1h"},
+	} {
+		if err := s.Validate(); !errors.Is(err, model.ErrInvalidSchedule) {
+			t.Errorf("%+v: expected ErrInvalidSchedule, got %v", s, err)
+		}
+	}
+	if err := officeHours().Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
+
+func TestSleepAndWake(t *testing.T) {
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(&mockBuilder{}, o)
+	seedEnvironment(t, s, model.Environment{ID: "env-1", Status: model.StatusReady})
+
+	env, err := orch.Sleep(context.Background(), "env-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.Status != model.StatusSleeping || env.SleepReason != model.SleepReasonManual {
+		t.Fatalf("expected sleeping (manual), got %s (%s)", env.Status, env.SleepReason)
+	}
+	if !slices.Equal(o.sleepIDs, []string{"env-1"}) {
+		t.Fatalf("expected the operator to scale env-1 to zero, got %v", o.sleepIDs)
+	}
+	if _, err := orch.Sleep(context.Background(), "env-1"); !errors.Is(err, model.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition sleeping twice, got %v", err)
+	}
+
+	env, err = orch.Wake(context.Background(), "env-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.Status != model.StatusReady || env.SleepReason != "" {
+		t.Fatalf("expected ready, got %s (%s)", env.Status, env.SleepReason)
+	}
+	if !slices.Equal(o.wakeIDs, []string{"env-1"}) {
+		t.Fatalf("expected the operator to scale env-1 up, got %v", o.wakeIDs)
+	}
+	if _, err := orch.Wake(context.Background(), "env-1"); !errors.Is(err, model.ErrInvalidTransition) {
+		t.Fatalf("expected ErrInvalidTransition waking a ready environment, got %v", err)
+	}
+
+	types := eventTypes(timeline(t, orch, "env-1"))
+	if !slices.Equal(types, []model.EventType{model.EventSlept, model.EventWoke}) {
+		t.Fatalf("expected slept and woke events, got %v", types)
+	}
+}
+
+func TestSleep_OperatorFailure(t *testing.T) {
+	o := &mockOperator{sleepErr: errors.New("operator unavailable")}
+	orch, s := newTestOrchestrator(&mockBuilder{}, o)
+	seedEnvironment(t, s, model.Environment{ID: "env-1", Status: model.StatusReady})
+
+	if _, err := orch.Sleep(context.Background(), "env-1"); err == nil {
+		t.Fatal("expected an error")
+	}
+	env, _ := orch.GetEnvironment(context.Background(), "env-1")
+	if env.Status != model.StatusReady || env.SleepReason != "" {
+		t.Fatalf("expected the environment left ready, got %s (%s)", env.Status, env.SleepReason)
+	}
+}
+
+func TestApplySleepSchedules(t *testing.T) {
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(&mockBuilder{}, o)
+	seedEnvironment(t, s, model.Environment{ID: "env-scheduled", Status: model.StatusReady,
+		LastActivityAt: monday, SleepSchedule: officeHours()})
+	seedEnvironment(t, s, model.Environment{ID: "env-unscheduled", Status: model.StatusReady,
+		LastActivityAt: monday})
+	ctx := context.Background()
+
+	// Nothing sleeps during awake hours.
+	if err := orch.ApplySleepSchedules(ctx, monday.Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(o.sleepIDs) != 0 {
+		t.Fatalf("expected nothing to sleep, got %v", o.sleepIDs)
+	}
+
+	// After hours the scheduled environment sleeps.
+	if err := orch.ApplySleepSchedules(ctx, monday.Add(7*time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	env, _ := orch.GetEnvironment(ctx, "env-scheduled")
+	if env.Status != model.StatusSleeping || env.SleepReason != model.SleepReasonSchedule {
+		t.Fatalf("expected sleeping (schedule), got %s (%s)", env.Status, env.SleepReason)
+	}
+	if !slices.Equal(o.sleepIDs, []string{"env-scheduled"}) {
+		t.Fatalf("expected only env-scheduled to sleep, got %v", o.sleepIDs)
+	}
+
+	// It wakes when the next awake hours begin.
+	tuesday := monday.AddDate(0, 0, 1).Add(-3 * time.Hour)
+	if err := orch.ApplySleepSchedules(ctx, tuesday); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env, _ := orch.GetEnvironment(ctx, "env-scheduled"); env.Status != model.StatusReady {
+		t.Fatalf("expected ready, got %s", env.Status)
+	}
+}
+
+func TestApplySleepSchedules_ManualWakeStaysAwake(t *testing.T) {
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(&mockBuilder{}, o)
+	seedEnvironment(t, s, model.Environment{ID: "env-1", Status: model.StatusReady,
+		LastActivityAt: monday, SleepSchedule: officeHours()})
+	ctx := context.Background()
+	evening := monday.Add(7 * time.Hour)
+
+	if err := orch.ApplySleepSchedules(ctx, evening); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := orch.Wake(ctx, "env-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Waking is activity after the off hours began, so the scheduler leaves
+	// the environment awake.
+	if err := orch.ApplySleepSchedules(ctx, evening.Add(time.Hour)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env, _ := orch.GetEnvironment(ctx, "env-1"); env.Status != model.StatusReady {
+		t.Fatalf("expected ready, got %s", env.Status)
+	}
+}
+
+func TestApplySleepSchedules_Idle(t *testing.T) {
+	o := &mockOperator{}
+	orch, s := newTestOrchestrator(&mockBuilder{}, o, orchestrator.WithIdleSleep(2*time.Hour))
+	seedEnvironment(t, s, model.Environment{ID: "env-idle", Status: model.StatusReady,
+		LastActivityAt: monday.Add(-3 * time.Hour)})
+	seedEnvironment(t, s, model.Environment{ID: "env-active", Status: model.StatusReady,
+		LastActivityAt: monday.Add(-time.Hour)})
+	// A schedule's own idleAfter wins over the default.
+	seedEnvironment(t, s, model.Environment{ID: "env-patient", Status: model.StatusReady,
+		LastActivityAt: monday.Add(-3 * time.Hour), SleepSchedule: &model.SleepSchedule{IdleAfter: "4h"}})
+	ctx := context.Background()
+
+	if err := orch.ApplySleepSchedules(ctx, monday); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !slices.Equal(o.sleepIDs, []string{"env-idle"}) {
+		t.Fatalf("expected only env-idle to sleep, got %v", o.sleepIDs)
+	}
+	env, _ := orch.GetEnvironment(ctx, "env-idle")
+	if env.SleepReason != model.SleepReasonIdle {
+		t.Fatalf("expected sleep reason idle, got %q", env.SleepReason)
+	}
+
+	// Idle environments stay asleep until someone wakes them.
+	if err := orch.ApplySleepSchedules(ctx, monday.Add(time.Minute)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(o.wakeIDs) != 0 {
+		t.Fatalf("expected no wakes, got %v", o.wakeIDs)
+	}
+}
+
+func TestUpdateEnvironment_SleepSchedule(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	ctx := context.Background()
+	env, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name:            "test-env",
+		BaseRootPackage: "root-pkg",
+		SleepSchedule:   officeHours(),
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.SleepSchedule == nil || env.SleepSchedule.AwakeFrom != "08:00" {
+		t.Fatalf("expected the schedule to be stored, got %+v", env.SleepSchedule)
+	}
+
+	if _, err := orch.UpdateEnvironment(ctx, env.ID, model.UpdateEnvironmentRequest{
+		SleepSchedule: &model.SleepSchedule{IdleAfter: "never"},
+	}); !errors.Is(err, model.ErrInvalidSchedule) {
+		t.Fatalf("expected ErrInvalidSchedule, got %v", err)
+	}
+
+	// An empty schedule removes it.
+	updated, err := orch.UpdateEnvironment(ctx, env.ID, model.UpdateEnvironmentRequest{
+		SleepSchedule: &model.SleepSchedule{},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if updated.SleepSchedule != nil {
+		t.Fatalf("expected the schedule removed, got %+v", updated.SleepSchedule)
+	}
+}
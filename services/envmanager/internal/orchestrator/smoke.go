@@ -0,0 +1,115 @@
+package orchestrator
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+// SmokeTester runs smoke test packages against a preview URL. In production
+// this is a smoketest.Runner.
+type SmokeTester interface {
+	Run(ctx context.Context, baseURL string, pkgs []model.Package) model.SmokeTestReport
+}
+
+// WithSmokeTests runs each environment's postman-collection and
+// graphql-operations packages with t after every successful deploy. If
+// degrade is set, an environment with a failing test is marked degraded.
+// Smoke tests need a registry to find the packages.
+func WithSmokeTests(t SmokeTester, degrade bool) Option {
+	return func(o *Orchestrator) {
+		o.smokeTester = t
+		o.degradeOnSmoke = degrade
+	}
+}
+
+// runSmokeTests runs the smoke tests in env's package tree against its
+// preview URL and stores the report on the environment. Environments
+// without a preview URL, a base root version or smoke test packages are
+// left alone.
+func (o *Orchestrator) runSmokeTests(ctx context.Context, env model.Environment) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.runSmokeTests",
+		trace.WithAttributes(attribute.String("env.id", env.ID)))
+	defer span.End()
+
+	if o.registry == nil || env.BaseRootVersion == "" || env.PreviewURL == "" {
+		return
+	}
+	pkgs, err := o.smokeTestPackages(ctx, env)
+	if err != nil {
+		span.RecordError(err)
+		o.logger.WarnContext(ctx, "failed to find smoke tests",
+			slog.String("id", env.ID),
+			slog.String("error", err.Error()))
+		return
+	}
+	if len(pkgs) == 0 {
+		return
+	}
+
+	report := o.smokeTester.Run(ctx, env.PreviewURL, pkgs)
+	report.BuildID = env.CurrentBuildID
+	// A superseded workflow's results describe a build that is gone.
+	if ctx.Err() != nil {
+		return
+	}
+
+	updated, err := o.mutate(ctx, env.ID, func(env *model.Environment) error {
+		env.SmokeTests = &report
+		if o.degradeOnSmoke && report.Failed > 0 &&
+			model.ValidateTransition(env.Status, model.StatusDegraded) == nil {
+			env.Status = model.StatusDegraded
+		}
+		return nil
+	})
+	if err != nil {
+		span.RecordError(err)
+		o.logger.ErrorContext(ctx, "failed to store smoke test results",
+			slog.String("id", env.ID),
+			slog.String("error", err.Error()))
+		return
+	}
+
+	typ := model.EventSmokeTestsPassed
+	if report.Failed > 0 {
+		typ = model.EventSmokeTestsFailed
+	}
+	o.logger.InfoContext(ctx, "smoke tests complete",
+		slog.String("id", env.ID),
+		slog.String("result", report.Summary()))
+	o.record(ctx, model.Event{
+		EnvironmentID: env.ID,
+		Type:          typ,
+		Message:       report.Summary(),
+		BuildID:       report.BuildID,
+		Status:        updated.Status,
+	})
+}
+
+// smokeTestPackages returns the smoke test packages in env's tree with its
+// overrides applied, ordered by name.
+func (o *Orchestrator) smokeTestPackages(ctx context.Context, env model.Environment) ([]model.Package, error) {
+	_, base, err := o.resolveBaseTree(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	tree, err := o.applyOverrides(ctx, base, env.Overrides)
+	if err != nil {
+		return nil, err
+	}
+
+	var pkgs []model.Package
+	for _, pkg := range tree {
+		if model.IsSmokeTestKind(pkg.Kind) {
+			pkgs = append(pkgs, pkg)
+		}
+	}
+	slices.SortFunc(pkgs, func(a, b model.Package) int { return strings.Compare(a.Name, b.Name) })
+	return pkgs, nil
+}
@@ -0,0 +1,134 @@
+package orchestrator_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+)
+
+// fakeSmokeTester records what it was asked to run and fails the requests
+// named in fail.
+type fakeSmokeTester struct {
+	mu       sync.Mutex
+	baseURL  string
+	packages []string
+	fail     map[string]bool
+}
+
+func (f *fakeSmokeTester) Run(_ context.Context, baseURL string, pkgs []model.Package) model.SmokeTestReport {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.baseURL = baseURL
+	var report model.SmokeTestReport
+	for _, pkg := range pkgs {
+		f.packages = append(f.packages, pkg.Name)
+		outcome := model.SmokeTestPassed
+		if f.fail[pkg.Name] {
+			outcome = model.SmokeTestFailed
+		}
+		report.Add(model.SmokeTestResult{Package: pkg.Name, Name: "request", Outcome: outcome})
+	}
+	return report
+}
+
+// newSmokeFixture deploys an environment whose base tree holds a postman
+// collection and a graphql-operations package, with smoke tests run by st.
+func newSmokeFixture(t *testing.T, st *fakeSmokeTester, degrade bool) (*orchestrator.Orchestrator, model.Environment) {
+	t.Helper()
+	r := newFakeRegistry(
+		model.Package{Name: "root-pkg", Kind: "graphql-supergraph", Version: "1.0.0", Dependencies: []model.Dependency{
+			{PackageName: "users-subgraph", VersionConstraint: "1.0.0"},
+			{PackageName: "petstore/client", VersionConstraint: "1.0.0"},
+			{PackageName: "petstore/operations", VersionConstraint: "1.0.0"},
+		}},
+		model.Package{Name: "users-subgraph", Kind: "graphql-subgraph", Version: "1.0.0", Schema: "type User { id: ID }"},
+		model.Package{Name: "petstore/client", Kind: model.KindPostmanCollection, Version: "1.0.0", Schema: `{"item":[]}`},
+		model.Package{Name: "petstore/operations", Kind: model.KindGraphQLOperations, Version: "1.0.0", Schema: "query Q { a }"},
+	)
+	orch, _ := newTestOrchestrator(&mockBuilder{buildID: "build-1"}, &mockOperator{previewURL: "https://preview.example.com/env"},
+		orchestrator.WithRegistry(r), orchestrator.WithSmokeTests(st, degrade))
+
+	env, err := orch.CreateEnvironment(context.Background(), model.CreateEnvironmentRequest{
+		Name:            "smoke-env",
+		BaseRootPackage: "root-pkg",
+		BaseRootVersion: "1.0.0",
+		Overrides:       []model.PackageOverride{{PackageName: "users-subgraph", Schema: "type User { id: ID! }"}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return orch, waitForEnvironment(t, orch, env.ID)
+}
+
+func TestSmokeTests_Passing(t *testing.T) {
+	st := &fakeSmokeTester{}
+	orch, env := newSmokeFixture(t, st, true)
+
+	if env.Status != model.StatusReady {
+		t.Fatalf("expected ready, got %s", env.Status)
+	}
+	if st.baseURL != "https://preview.example.com/env" {
+		t.Fatalf("expected tests to run against the preview URL, got %q", st.baseURL)
+	}
+	if len(st.packages) != 2 || st.packages[0] != "petstore/client" || st.packages[1] != "petstore/operations" {
+		t.Fatalf("expected only the test packages to run, got %v", st.packages)
+	}
+	if env.SmokeTests == nil || env.SmokeTests.Passed != 2 || env.SmokeTests.BuildID != "build-1" {
+		t.Fatalf("expected the report to be stored, got %+v", env.SmokeTests)
+	}
+
+	events := timeline(t, orch, env.ID)
+	if last := events[len(events)-1]; last.Type != model.EventSmokeTestsPassed || last.Message != "2 passed, 0 failed, 0 skipped" {
+		t.Fatalf("expected a smoke_tests_passed event, got %+v", last)
+	}
+}
+
+func TestSmokeTests_Failing(t *testing.T) {
+	for _, degrade := range []bool{false, true} {
+		st := &fakeSmokeTester{fail: map[string]bool{"petstore/operations": true}}
+		orch, env := newSmokeFixture(t, st, degrade)
+
+		want := model.StatusReady
+		if degrade {
+			want = model.StatusDegraded
+		}
+		if env.Status != want {
+			t.Fatalf("degrade=%v: expected %s, got %s", degrade, want, env.Status)
+		}
+		if env.SmokeTests == nil || env.SmokeTests.Failed != 1 {
+			t.Fatalf("degrade=%v: expected one failure, got %+v", degrade, env.SmokeTests)
+		}
+		events := timeline(t, orch, env.ID)
+		if last := events[len(events)-1]; last.Type != model.EventSmokeTestsFailed || last.Status != want {
+			t.Fatalf("degrade=%v: expected a smoke_tests_failed event, got %+v", degrade, last)
+		}
+	}
+}
+
+func TestSmokeTests_ClearedOnRedeploy(t *testing.T) {
+	st := &fakeSmokeTester{fail: map[string]bool{"petstore/client": true}}
+	orch, env := newSmokeFixture(t, st, true)
+
+	// A degraded environment can be rebuilt, which discards the old report
+	// until the new deploy's tests run.
+	st.mu.Lock()
+	st.fail = nil
+	st.mu.Unlock()
+	env, err := orch.ApplyOverrides(context.Background(), env.ID, model.ApplyOverridesRequest{
+		Overrides:    []model.PackageOverride{{PackageName: "users-subgraph", Schema: "type User { id: ID! name: String }"}},
+		TriggerBuild: true,
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.SmokeTests != nil {
+		t.Fatalf("expected the old report to be cleared, got %+v", env.SmokeTests)
+	}
+	env = waitForEnvironment(t, orch, env.ID)
+	if env.Status != model.StatusReady || env.SmokeTests == nil || env.SmokeTests.Failed != 0 {
+		t.Fatalf("expected a ready environment with passing tests, got %s %+v", env.Status, env.SmokeTests)
+	}
+}
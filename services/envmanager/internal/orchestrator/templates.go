@@ -0,0 +1,212 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/labels"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+// WithTemplateStore sets where templates are kept. By default they are kept
+// in the environment store if it is a store.TemplateStore, and in memory
+// otherwise.
+func WithTemplateStore(s store.TemplateStore) Option {
+	return func(o *Orchestrator) { o.templateStore = s }
+}
+
+// PutTemplate creates or replaces the template called name. Its overrides
+// are checked against the registry the same way an environment's are.
+func (o *Orchestrator) PutTemplate(ctx context.Context, name string, spec model.TemplateSpec) (model.Template, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.PutTemplate",
+		trace.WithAttributes(attribute.String("template.name", name)))
+	defer span.End()
+
+	if err := o.validateTemplate(ctx, name, spec); err != nil {
+		span.RecordError(err)
+		return model.Template{}, fmt.Errorf("put template: %w", err)
+	}
+	if spec.SleepSchedule.IsZero() {
+		spec.SleepSchedule = nil
+	}
+
+	now := time.Now().UTC()
+	t := model.Template{
+		Name:         name,
+		TemplateSpec: spec,
+		CreatedBy:    ActorFromContext(ctx),
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+	existing, err := o.templateStore.GetTemplate(ctx, name)
+	switch {
+	case err == nil:
+		t.CreatedBy, t.CreatedAt = existing.CreatedBy, existing.CreatedAt
+	case !errors.Is(err, store.ErrTemplateNotFound):
+		span.RecordError(err)
+		return model.Template{}, fmt.Errorf("get template: %w", err)
+	}
+	if err := o.templateStore.PutTemplate(ctx, t); err != nil {
+		span.RecordError(err)
+		return model.Template{}, fmt.Errorf("put template: %w", err)
+	}
+
+	o.logger.InfoContext(ctx, "template saved", slog.String("name", name))
+	return t, nil
+}
+
+// validateTemplate checks that spec is a well-formed template whose
+// overrides apply to its base and fit within its and the service's quotas.
+func (o *Orchestrator) validateTemplate(ctx context.Context, name string, spec model.TemplateSpec) error {
+	if err := model.ValidateTemplateName(name); err != nil {
+		return err
+	}
+	if err := spec.Validate(); err != nil {
+		return err
+	}
+	if err := labels.Validate(spec.Labels); err != nil {
+		return err
+	}
+	if err := o.validateOverrides(ctx, spec.BaseRootPackage, spec.BaseRootVersion, spec.Overrides); err != nil {
+		return err
+	}
+	if err := o.checkFootprint(spec.BaseRootPackage, spec.Overrides); err != nil {
+		return err
+	}
+	return checkTemplateFootprint(model.Template{Name: name, TemplateSpec: spec}, spec.BaseRootPackage, spec.Overrides)
+}
+
+// GetTemplate returns a template by name.
+func (o *Orchestrator) GetTemplate(ctx context.Context, name string) (model.Template, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.GetTemplate",
+		trace.WithAttributes(attribute.String("template.name", name)))
+	defer span.End()
+
+	t, err := o.templateStore.GetTemplate(ctx, name)
+	if err != nil {
+		span.RecordError(err)
+		return model.Template{}, err
+	}
+	return t, nil
+}
+
+// ListTemplates returns every template, sorted by name.
+func (o *Orchestrator) ListTemplates(ctx context.Context) ([]model.Template, error) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.ListTemplates")
+	defer span.End()
+
+	templates, err := o.templateStore.ListTemplates(ctx)
+	if err != nil {
+		span.RecordError(err)
+		return nil, fmt.Errorf("list templates: %w", err)
+	}
+	return templates, nil
+}
+
+// DeleteTemplate deletes a template. Environments created from it are kept
+// and no longer limited by its quotas.
+func (o *Orchestrator) DeleteTemplate(ctx context.Context, name string) error {
+	ctx, span := tracer.Start(ctx, "Orchestrator.DeleteTemplate",
+		trace.WithAttributes(attribute.String("template.name", name)))
+	defer span.End()
+
+	if err := o.templateStore.DeleteTemplate(ctx, name); err != nil {
+		span.RecordError(err)
+		return err
+	}
+	o.logger.InfoContext(ctx, "template deleted", slog.String("name", name))
+	return nil
+}
+
+// applyTemplate fetches the template named by req and folds it into req: the
+// request starts from the template's base, overrides, labels, TTL and sleep
+// schedule, with its own layered on top.
+func (o *Orchestrator) applyTemplate(ctx context.Context, req *model.CreateEnvironmentRequest) (model.Template, error) {
+	if req.ParentID != "" {
+		return model.Template{}, fmt.Errorf("%w: template and parentId may not be combined", model.ErrInvalidTemplate)
+	}
+	t, err := o.templateStore.GetTemplate(ctx, req.Template)
+	if errors.Is(err, store.ErrTemplateNotFound) {
+		return model.Template{}, fmt.Errorf("%w: %s not found", model.ErrInvalidTemplate, req.Template)
+	}
+	if err != nil {
+		return model.Template{}, fmt.Errorf("get template: %w", err)
+	}
+	if req.BaseRootPackage != "" && req.BaseRootPackage != t.BaseRootPackage {
+		return model.Template{}, fmt.Errorf("%w: base must be the template's %s", model.ErrInvalidTemplate, t.BaseRootPackage)
+	}
+
+	req.BaseRootPackage = t.BaseRootPackage
+	if req.BaseRootVersion == "" {
+		req.BaseRootVersion = t.BaseRootVersion
+	}
+	req.Overrides = mergeOverrides(t.Overrides, req.Overrides)
+	if len(t.Labels) > 0 {
+		merged := maps.Clone(t.Labels)
+		maps.Copy(merged, req.Labels)
+		req.Labels = merged
+	}
+	if req.TTL == "" && req.ExpiresAt == nil {
+		req.TTL = t.TTL
+	}
+	if req.SleepSchedule == nil {
+		req.SleepSchedule = t.SleepSchedule.Clone()
+	}
+	return t, nil
+}
+
+// checkTemplateFootprint returns ErrLimitExceeded if an environment created
+// from t, based on rootPackage with overrides, is bigger than t allows.
+func checkTemplateFootprint(t model.Template, rootPackage string, overrides []model.PackageOverride) error {
+	if t.Quotas == nil {
+		return nil
+	}
+	f := model.FootprintOf(rootPackage, overrides)
+	if limit := t.Quotas.MaxComponents; limit > 0 && f.Components > limit {
+		return fmt.Errorf("%w: %d components, template %s allows at most %d", ErrLimitExceeded, f.Components, t.Name, limit)
+	}
+	if limit := t.Quotas.MaxReplicas; limit > 0 && f.Replicas > limit {
+		return fmt.Errorf("%w: %d replicas, template %s allows at most %d", ErrLimitExceeded, f.Replicas, t.Name, limit)
+	}
+	return nil
+}
+
+// checkTemplateQuota returns ErrQuotaExceeded if t already has as many
+// environments as it may. The caller must hold o.mu.
+func (o *Orchestrator) checkTemplateQuota(ctx context.Context, t model.Template) error {
+	if t.Quotas == nil || t.Quotas.MaxEnvironments == 0 {
+		return nil
+	}
+	n, err := o.countEnvironments(ctx, store.ListFilter{Template: t.Name})
+	if err != nil {
+		return err
+	}
+	if limit := t.Quotas.MaxEnvironments; n >= limit {
+		return fmt.Errorf("%w: template %s has %d of %d environments", ErrQuotaExceeded, t.Name, n, limit)
+	}
+	return nil
+}
+
+// environmentTemplate returns the template env was created from, or the
+// zero Template if there is none or it has been deleted.
+func (o *Orchestrator) environmentTemplate(ctx context.Context, env model.Environment) (model.Template, error) {
+	if env.Template == "" {
+		return model.Template{}, nil
+	}
+	t, err := o.templateStore.GetTemplate(ctx, env.Template)
+	if errors.Is(err, store.ErrTemplateNotFound) {
+		return model.Template{}, nil
+	}
+	if err != nil {
+		return model.Template{}, fmt.Errorf("get template: %w", err)
+	}
+	return t, nil
+}
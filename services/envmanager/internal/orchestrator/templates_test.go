@@ -0,0 +1,215 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+// checkoutTemplate is a template with overrides, labels, a TTL and a sleep
+// schedule for the template tests.
+func checkoutTemplate() model.TemplateSpec {
+	return model.TemplateSpec{
+		Description:     "Checkout flow with the new users schema",
+		BaseRootPackage: "root-pkg",
+		BaseRootVersion: "1.0.0",
+		Overrides:       []model.PackageOverride{usersOverride},
+		Labels:          map[string]string{"team": "payments", "purpose": "demo"},
+		TTL:             "48h",
+		SleepSchedule:   &model.SleepSchedule{IdleAfter: "2h"},
+	}
+}
+
+func TestCreateEnvironment_FromTemplate(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{buildID: "build-1"}, &mockOperator{})
+	ctx := context.Background()
+	if _, err := orch.PutTemplate(ctx, "checkout", checkoutTemplate()); err != nil {
+		t.Fatalf("put template: %v", err)
+	}
+
+	newUsers := model.PackageOverride{PackageName: "users-subgraph", Schema: "type User { id: ID! email: String }"}
+	env, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name:      "from-template",
+		Template:  "checkout",
+		Labels:    map[string]string{"purpose": "qa"},
+		Overrides: []model.PackageOverride{newUsers, ordersOverride},
+	})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	env = waitForEnvironment(t, orch, env.ID)
+
+	if env.Template != "checkout" || env.BaseRootPackage != "root-pkg" || env.BaseRootVersion != "1.0.0" {
+		t.Fatalf("expected the template's base, got %+v", env)
+	}
+	// The request's override for users replaces the template's.
+	if len(env.Overrides) != 2 || !env.Overrides[0].Equal(newUsers) || !env.Overrides[1].Equal(ordersOverride) {
+		t.Fatalf("expected the request's overrides merged over the template's, got %+v", env.Overrides)
+	}
+	if env.Labels["team"] != "payments" || env.Labels["purpose"] != "qa" {
+		t.Fatalf("expected the request's labels layered over the template's, got %v", env.Labels)
+	}
+	if env.ExpiresAt == nil || env.ExpiresAt.Before(time.Now().Add(47*time.Hour)) {
+		t.Fatalf("expected the template's 48h TTL, got %v", env.ExpiresAt)
+	}
+	if env.SleepSchedule == nil || env.SleepSchedule.IdleAfter != "2h" {
+		t.Fatalf("expected the template's sleep schedule, got %+v", env.SleepSchedule)
+	}
+
+	events, err := orch.ListEvents(ctx, env.ID, store.EventFilter{})
+	if err != nil {
+		t.Fatalf("list events: %v", err)
+	}
+	created := events.Events[len(events.Events)-1]
+	if created.Type != model.EventCreated || created.Message != "created from template checkout" {
+		t.Fatalf("expected a created event naming the template, got %+v", created)
+	}
+
+	// A request's own TTL wins over the template's.
+	short, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "short", Template: "checkout", TTL: "1h"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if short.ExpiresAt == nil || short.ExpiresAt.After(time.Now().Add(time.Hour)) {
+		t.Fatalf("expected the request's 1h TTL, got %v", short.ExpiresAt)
+	}
+	orch.Wait()
+}
+
+func TestCreateEnvironment_FromTemplateInvalid(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	ctx := context.Background()
+	if _, err := orch.PutTemplate(ctx, "checkout", checkoutTemplate()); err != nil {
+		t.Fatalf("put template: %v", err)
+	}
+	parent, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "parent", BaseRootPackage: "root-pkg"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		req  model.CreateEnvironmentRequest
+	}{
+		{"unknown template", model.CreateEnvironmentRequest{Name: "env", Template: "missing"}},
+		{"different base", model.CreateEnvironmentRequest{Name: "env", Template: "checkout", BaseRootPackage: "other-root"}},
+		{"with parent", model.CreateEnvironmentRequest{Name: "env", Template: "checkout", ParentID: parent.ID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := orch.CreateEnvironment(ctx, tt.req); !errors.Is(err, model.ErrInvalidTemplate) {
+				t.Fatalf("expected ErrInvalidTemplate, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTemplateQuotas(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{buildID: "build-1"}, &mockOperator{})
+	ctx := context.Background()
+	replicas := func(n int32) *model.RuntimeOverride { return &model.RuntimeOverride{Replicas: &n} }
+
+	spec := model.TemplateSpec{
+		BaseRootPackage: "root-pkg",
+		Quotas:          &model.TemplateQuotas{MaxEnvironments: 1, MaxReplicas: 3},
+	}
+	if _, err := orch.PutTemplate(ctx, "small", spec); err != nil {
+		t.Fatalf("put template: %v", err)
+	}
+
+	_, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name:      "too-big",
+		Template:  "small",
+		Overrides: []model.PackageOverride{{PackageName: "users-subgraph", Runtime: replicas(3)}},
+	})
+	if !errors.Is(err, orchestrator.ErrLimitExceeded) {
+		t.Fatalf("expected ErrLimitExceeded for four replicas, got %v", err)
+	}
+
+	env, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "first", Template: "small"})
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if _, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "second", Template: "small"}); !errors.Is(err, orchestrator.ErrQuotaExceeded) {
+		t.Fatalf("expected the template's quota to be exceeded, got %v", err)
+	}
+	// Environments not created from the template don't count against it.
+	if _, err := orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: "plain", BaseRootPackage: "root-pkg"}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	_, err = orch.ApplyOverrides(ctx, env.ID, model.ApplyOverridesRequest{
+		Overrides: []model.PackageOverride{{PackageName: "root-pkg", Runtime: replicas(4)}},
+	})
+	if !errors.Is(err, orchestrator.ErrLimitExceeded) {
+		t.Fatalf("expected the template's replica limit to apply to later overrides, got %v", err)
+	}
+
+	// Once the template is gone, its limits no longer apply.
+	if err := orch.DeleteTemplate(ctx, "small"); err != nil {
+		t.Fatalf("delete template: %v", err)
+	}
+	if _, err := orch.ApplyOverrides(ctx, env.ID, model.ApplyOverridesRequest{
+		Overrides: []model.PackageOverride{{PackageName: "root-pkg", Runtime: replicas(4)}},
+	}); err != nil {
+		t.Fatalf("apply overrides: %v", err)
+	}
+}
+
+func TestPutTemplate(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	ctx := orchestrator.ContextWithActor(context.Background(), "alice")
+
+	first, err := orch.PutTemplate(ctx, "checkout", checkoutTemplate())
+	if err != nil {
+		t.Fatalf("put template: %v", err)
+	}
+	if first.Name != "checkout" || first.CreatedBy != "alice" {
+		t.Fatalf("unexpected template: %+v", first)
+	}
+
+	spec := checkoutTemplate()
+	spec.TTL = "24h"
+	replaced, err := orch.PutTemplate(orchestrator.ContextWithActor(context.Background(), "bob"), "checkout", spec)
+	if err != nil {
+		t.Fatalf("replace template: %v", err)
+	}
+	if replaced.TTL != "24h" || replaced.CreatedBy != "alice" || !replaced.CreatedAt.Equal(first.CreatedAt) {
+		t.Fatalf("expected the replacement to keep its creator, got %+v", replaced)
+	}
+
+	invalid := []struct {
+		name string
+		tmpl string
+		spec func(*model.TemplateSpec)
+		want error
+	}{
+		{"bad name", "Checkout!", func(*model.TemplateSpec) {}, model.ErrInvalidTemplate},
+		{"no base", "checkout", func(s *model.TemplateSpec) { s.BaseRootPackage = "" }, model.ErrInvalidTemplate},
+		{"bad ttl", "checkout", func(s *model.TemplateSpec) { s.TTL = "forever" }, model.ErrInvalidTemplate},
+		{"negative quota", "checkout", func(s *model.TemplateSpec) { s.Quotas = &model.TemplateQuotas{MaxEnvironments: -1} }, model.ErrInvalidTemplate},
+		{"bad schedule", "checkout", func(s *model.TemplateSpec) { s.SleepSchedule = &model.SleepSchedule{IdleAfter: "soon"} }, model.ErrInvalidSchedule},
+		{"bad override", "checkout", func(s *model.TemplateSpec) {
+			s.Overrides = []model.PackageOverride{{PackageName: "root-pkg", Version: "2.0.0"}}
+		}, model.ErrInvalidOverride},
+	}
+	for _, tt := range invalid {
+		t.Run(tt.name, func(t *testing.T) {
+			spec := checkoutTemplate()
+			tt.spec(&spec)
+			if _, err := orch.PutTemplate(ctx, tt.tmpl, spec); !errors.Is(err, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, err)
+			}
+		})
+	}
+
+	got, err := orch.GetTemplate(ctx, "checkout")
+	if err != nil || got.TTL != "24h" {
+		t.Fatalf("expected invalid templates not to be saved, got %+v %v", got, err)
+	}
+}
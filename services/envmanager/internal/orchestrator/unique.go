@@ -0,0 +1,45 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+// ErrEnvironmentExists is returned by CreateEnvironment, along with the
+// existing environment, when unique names are enforced and an environment
+// with the same name and branch already exists.
+var ErrEnvironmentExists = errors.New("environment with this name and branch already exists")
+
+// WithUniqueNames makes (name, branch) unique among environments that are not
+// being deleted, so a retried create returns the environment the first
+// attempt made instead of a duplicate.
+func WithUniqueNames() Option {
+	return func(o *Orchestrator) { o.uniqueNames = true }
+}
+
+// findByNameAndBranch returns the environment named name on branch that is
+// not being deleted, if there is one. The caller must hold o.mu so that a
+// concurrent create cannot slip in between the lookup and its own write.
+func (o *Orchestrator) findByNameAndBranch(ctx context.Context, name, branch string) (model.Environment, bool, error) {
+	filter := store.ListFilter{Branch: branch, PageSize: 100}
+	for {
+		result, err := o.store.List(ctx, filter)
+		if err != nil {
+			return model.Environment{}, false, fmt.Errorf("list environments: %w", err)
+		}
+		for _, env := range result.Environments {
+			// An empty filter branch matches every branch.
+			if env.Name == name && env.Branch == branch && env.Status != model.StatusDeleting {
+				return env, true, nil
+			}
+		}
+		if result.NextPageToken == "" {
+			return model.Environment{}, false, nil
+		}
+		filter.PageToken = result.NextPageToken
+	}
+}
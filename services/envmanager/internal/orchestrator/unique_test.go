@@ -0,0 +1,71 @@
+package orchestrator_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+)
+
+func TestUniqueNames(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{}, orchestrator.WithUniqueNames())
+	ctx := context.Background()
+	create := func(name, branch string) (model.Environment, error) {
+		return orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{Name: name, BaseRootPackage: "root-pkg", Branch: branch})
+	}
+
+	first, err := create("preview", "feature-x")
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	again, err := create("preview", "feature-x")
+	if !errors.Is(err, orchestrator.ErrEnvironmentExists) {
+		t.Fatalf("expected ErrEnvironmentExists, got %v", err)
+	}
+	if again.ID != first.ID {
+		t.Fatalf("expected the existing environment %s, got %s", first.ID, again.ID)
+	}
+
+	// The same name on another branch, or no branch, is a different environment.
+	for _, branch := range []string{"feature-y", ""} {
+		other, err := create("preview", branch)
+		if err != nil {
+			t.Fatalf("create on branch %q: %v", branch, err)
+		}
+		if other.ID == first.ID {
+			t.Fatalf("expected a new environment on branch %q", branch)
+		}
+	}
+
+	// Once the environment is deleted its name is free again.
+	if err := orch.DeleteEnvironment(ctx, first.ID); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	recreated, err := create("preview", "feature-x")
+	if err != nil {
+		t.Fatalf("create after delete: %v", err)
+	}
+	if recreated.ID == first.ID {
+		t.Fatal("expected a new environment after delete")
+	}
+}
+
+func TestUniqueNames_Disabled(t *testing.T) {
+	orch, _ := newTestOrchestrator(&mockBuilder{}, &mockOperator{})
+	ctx := context.Background()
+	req := model.CreateEnvironmentRequest{Name: "preview", BaseRootPackage: "root-pkg", Branch: "feature-x"}
+
+	first, err := orch.CreateEnvironment(ctx, req)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	second, err := orch.CreateEnvironment(ctx, req)
+	if err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	if second.ID == first.ID {
+		t.Fatal("expected duplicate names to be allowed by default")
+	}
+}
@@ -0,0 +1,397 @@
+package orchestrator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+// workflowRun is a handle on one in-flight build/deploy workflow.
+type workflowRun struct {
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// startWorkflow marks the environment as building, resets its progress and
+// launches the build/deploy workflow in the background. Any workflow already
+// running for the environment is cancelled first, so the newest overrides win.
+func (o *Orchestrator) startWorkflow(ctx context.Context, id string) (model.Environment, error) {
+	return o.startWorkflowFromBuild(ctx, id, "", model.BuildSourceBuild)
+}
+
+// startWorkflowFromBuild is startWorkflow for an environment that can reuse
+// an existing build. If buildID is set, the build stage is recorded as done,
+// the build is added to the environment's history as coming from source, and
+// the workflow starts by deploying it.
+func (o *Orchestrator) startWorkflowFromBuild(ctx context.Context, id, buildID string, source model.BuildSource) (model.Environment, error) {
+	o.cancelWorkflow(id)
+
+	buildStatus := model.StageStatusPending
+	if buildID != "" {
+		buildStatus = model.StageStatusSucceeded
+	}
+	env, err := o.transition(ctx, id, model.StatusBuilding, func(env *model.Environment) error {
+		now := time.Now().UTC()
+		env.FinishBuild(model.BuildOutcomeCancelled, "", now)
+		if buildID != "" {
+			env.RecordBuild(buildID, source, now)
+		}
+		env.LastError = ""
+		// Deploying the build wakes a sleeping environment.
+		env.SleepReason = ""
+		env.SmokeTests = nil
+		env.CurrentBuildID = buildID
+		env.LastActivityAt = now
+		env.Pending = newPendingOperation(model.OperationBuildDeploy)
+		env.Progress = []model.StageProgress{
+			{Stage: model.StageBuild, Status: buildStatus},
+			{Stage: model.StageDeploy, Status: model.StageStatusPending},
+			{Stage: model.StageRollout, Status: model.StageStatusPending},
+		}
+		return nil
+	})
+	if err != nil {
+		return model.Environment{}, fmt.Errorf("update status to building: %w", err)
+	}
+
+	o.launchWorkflow(ctx, id)
+	return env, nil
+}
+
+// launchWorkflow runs the workflow for an environment in the background,
+// picking up from whatever progress is already recorded on it.
+func (o *Orchestrator) launchWorkflow(ctx context.Context, id string) {
+	// The workflow outlives the request that started it, but keeps its trace.
+	runCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	run := &workflowRun{cancel: cancel, done: make(chan struct{})}
+
+	o.workflowsMu.Lock()
+	o.workflows[id] = run
+	o.workflowsMu.Unlock()
+
+	o.wg.Add(1)
+	go func() {
+		defer o.wg.Done()
+		defer close(run.done)
+		defer cancel()
+		o.runWorkflow(runCtx, id)
+
+		o.workflowsMu.Lock()
+		if o.workflows[id] == run {
+			delete(o.workflows, id)
+		}
+		o.workflowsMu.Unlock()
+	}()
+}
+
+// cancelWorkflow stops the in-flight workflow for an environment, if any, and
+// waits for it to exit so it cannot write stale state afterwards.
+func (o *Orchestrator) cancelWorkflow(id string) {
+	o.workflowsMu.Lock()
+	run, ok := o.workflows[id]
+	delete(o.workflows, id)
+	o.workflowsMu.Unlock()
+
+	if !ok {
+		return
+	}
+	run.cancel()
+	<-run.done
+}
+
+// Wait blocks until every in-flight workflow has finished.
+func (o *Orchestrator) Wait() {
+	o.wg.Wait()
+}
+
+// Shutdown cancels all in-flight workflows and waits for them to exit.
+func (o *Orchestrator) Shutdown() {
+	o.workflowsMu.Lock()
+	for _, run := range o.workflows {
+		run.cancel()
+	}
+	o.workflowsMu.Unlock()
+	o.wg.Wait()
+}
+
+// runWorkflow drives an environment through the build, deploy and rollout
+// stages, recording each stage's progress on the stored environment. Stages
+// that already succeeded (before a restart, say) are skipped.
+func (o *Orchestrator) runWorkflow(ctx context.Context, id string) {
+	ctx, span := tracer.Start(ctx, "Orchestrator.runWorkflow",
+		trace.WithAttributes(attribute.String("env.id", id)))
+	defer span.End()
+
+	env, err := o.store.Get(ctx, id)
+	if err != nil {
+		o.logWorkflowError(ctx, id, model.StageBuild, err)
+		return
+	}
+	completed := make(map[model.WorkflowStage]bool, len(env.Progress))
+	for _, p := range env.Progress {
+		completed[p.Stage] = p.Status == model.StageStatusSucceeded
+	}
+
+	stages := []struct {
+		stage model.WorkflowStage
+		fn    func(ctx context.Context, id string) error
+	}{
+		{model.StageBuild, o.stageBuild},
+		{model.StageDeploy, o.stageDeploy},
+		{model.StageRollout, o.stageRollout},
+	}
+
+	for _, st := range stages {
+		if completed[st.stage] {
+			continue
+		}
+		if err := o.setStage(ctx, id, st.stage, model.StageStatusRunning, ""); err != nil {
+			o.logWorkflowError(ctx, id, st.stage, err)
+			return
+		}
+
+		if err := st.fn(ctx, id); err != nil {
+			// A cancelled workflow has been superseded or the service is
+			// shutting down; whoever cancelled it owns the environment now.
+			if ctx.Err() != nil {
+				o.logger.InfoContext(ctx, "workflow cancelled",
+					slog.String("id", id),
+					slog.String("stage", string(st.stage)))
+				return
+			}
+			span.RecordError(err)
+			o.logWorkflowError(ctx, id, st.stage, err)
+			o.failWorkflow(ctx, id, st.stage, err)
+			return
+		}
+
+		if err := o.setStage(ctx, id, st.stage, model.StageStatusSucceeded, ""); err != nil {
+			o.logWorkflowError(ctx, id, st.stage, err)
+			return
+		}
+	}
+
+	env, err = o.transition(ctx, id, model.StatusReady, func(env *model.Environment) error {
+		env.Pending = nil
+		env.FinishBuild(model.BuildOutcomeSucceeded, "", time.Now().UTC())
+		return nil
+	})
+	if err != nil {
+		o.logWorkflowError(ctx, id, model.StageRollout, err)
+		return
+	}
+
+	o.logger.InfoContext(ctx, "build and deploy complete",
+		slog.String("id", env.ID),
+		slog.String("buildId", env.CurrentBuildID),
+		slog.String("previewUrl", env.PreviewURL))
+	o.record(ctx, model.Event{
+		EnvironmentID: id,
+		Type:          model.EventDeploySucceeded,
+		Message:       env.PreviewURL,
+		BuildID:       env.CurrentBuildID,
+		Status:        env.Status,
+	})
+
+	if o.smokeTester != nil {
+		o.runSmokeTests(ctx, env)
+	}
+}
+
+// stageBuild triggers a build and polls the builder until it finishes.
+// If a build was already triggered for this run, it is polled rather than
+// triggered again.
+func (o *Orchestrator) stageBuild(ctx context.Context, id string) error {
+	env, err := o.store.Get(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	buildID := env.CurrentBuildID
+	if buildID == "" {
+		buildID, err = o.builder.TriggerBuild(ctx, env)
+		if err != nil {
+			return fmt.Errorf("trigger build: %w", err)
+		}
+
+		if _, err := o.mutate(ctx, id, func(env *model.Environment) error {
+			env.CurrentBuildID = buildID
+			env.RecordBuild(buildID, model.BuildSourceBuild, time.Now().UTC())
+			return nil
+		}); err != nil {
+			return fmt.Errorf("update build ID: %w", err)
+		}
+		o.record(ctx, model.Event{
+			EnvironmentID: id,
+			Type:          model.EventBuildTriggered,
+			BuildID:       buildID,
+			Status:        model.StatusBuilding,
+		})
+	}
+
+	return o.poll(ctx, o.buildTimeout, func() (bool, error) {
+		build, err := o.builder.GetBuild(ctx, buildID)
+		if err != nil {
+			return false, fmt.Errorf("get build %s: %w", buildID, err)
+		}
+		switch build.Status {
+		case model.BuildStatusSucceeded:
+			return true, nil
+		case model.BuildStatusFailed:
+			return false, fmt.Errorf("build %s failed: %s", buildID, build.ErrorMessage)
+		default:
+			return false, nil
+		}
+	})
+}
+
+// stageDeploy hands the finished build to the operator.
+func (o *Orchestrator) stageDeploy(ctx context.Context, id string) error {
+	env, err := o.transition(ctx, id, model.StatusDeploying, nil)
+	if err != nil {
+		return fmt.Errorf("update status to deploying: %w", err)
+	}
+
+	secretValues, err := o.deploySecrets(ctx, env)
+	if err != nil {
+		return fmt.Errorf("deploy: %w", err)
+	}
+	previewURL, err := o.operator.Deploy(ctx, env, env.CurrentBuildID, secretValues)
+	if err != nil {
+		return fmt.Errorf("deploy: %w", err)
+	}
+
+	if _, err := o.mutate(ctx, id, func(env *model.Environment) error {
+		env.PreviewURL = previewURL
+		return nil
+	}); err != nil {
+		return fmt.Errorf("update after deploy: %w", err)
+	}
+	return nil
+}
+
+// stageRollout polls the operator until the environment reports Running. A
+// Failed or Degraded rollout fails the stage with the operator's message.
+func (o *Orchestrator) stageRollout(ctx context.Context, id string) error {
+	return o.poll(ctx, o.rolloutTimeout, func() (bool, error) {
+		status, err := o.operator.GetStatus(ctx, id)
+		if err != nil {
+			return false, fmt.Errorf("get deployment status: %w", err)
+		}
+		switch status.Phase {
+		case model.DeploymentPhaseRunning:
+			if status.PreviewURL != "" {
+				if _, err := o.mutate(ctx, id, func(env *model.Environment) error {
+					env.PreviewURL = status.PreviewURL
+					return nil
+				}); err != nil {
+					return false, err
+				}
+			}
+			return true, nil
+		case model.DeploymentPhaseFailed:
+			return false, fmt.Errorf("rollout failed: %s", status.Message)
+		case model.DeploymentPhaseDegraded:
+			// Some components are failing; waiting out the rollout timeout
+			// would only hide why.
+			return false, fmt.Errorf("rollout degraded: %s", status.Message)
+		default:
+			return false, nil
+		}
+	})
+}
+
+// errPollTimeout is returned when a polled condition is not met in time.
+var errPollTimeout = errors.New("timed out")
+
+// poll calls check immediately and then every pollInterval until it reports
+// done, returns an error, the timeout elapses or ctx is cancelled.
+func (o *Orchestrator) poll(ctx context.Context, timeout time.Duration, check func() (bool, error)) error {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	ticker := time.NewTicker(o.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := check()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-deadline.C:
+			return fmt.Errorf("%w after %s", errPollTimeout, timeout)
+		case <-ticker.C:
+		}
+	}
+}
+
+// setStage records a stage transition on the stored environment.
+func (o *Orchestrator) setStage(ctx context.Context, id string, stage model.WorkflowStage, status model.StageStatus, errMsg string) error {
+	_, err := o.mutate(ctx, id, func(env *model.Environment) error {
+		now := time.Now().UTC()
+		for i := range env.Progress {
+			p := &env.Progress[i]
+			if p.Stage != stage {
+				continue
+			}
+			p.Status = status
+			p.Error = errMsg
+			if status == model.StageStatusRunning {
+				p.StartedAt = &now
+			} else {
+				p.CompletedAt = &now
+			}
+		}
+		return nil
+	})
+	return err
+}
+
+// failWorkflow marks the stage and the environment as failed.
+func (o *Orchestrator) failWorkflow(ctx context.Context, id string, stage model.WorkflowStage, cause error) {
+	if err := o.setStage(ctx, id, stage, model.StageStatusFailed, cause.Error()); err != nil {
+		o.logWorkflowError(ctx, id, stage, err)
+	}
+	if _, err := o.transition(ctx, id, model.StatusFailed, func(env *model.Environment) error {
+		env.LastError = fmt.Sprintf("%s: %s", stage, cause)
+		env.Pending = nil
+		env.FinishBuild(model.BuildOutcomeFailed, env.LastError, time.Now().UTC())
+		return nil
+	}); err != nil {
+		o.logger.ErrorContext(ctx, "failed to update status after workflow failure",
+			slog.String("id", id),
+			slog.String("error", err.Error()))
+	}
+
+	typ := model.EventDeployFailed
+	if stage == model.StageBuild {
+		typ = model.EventBuildFailed
+	}
+	o.record(ctx, model.Event{
+		EnvironmentID: id,
+		Type:          typ,
+		Status:        model.StatusFailed,
+		Stage:         stage,
+		Error:         cause.Error(),
+	})
+}
+
+func (o *Orchestrator) logWorkflowError(ctx context.Context, id string, stage model.WorkflowStage, err error) {
+	o.logger.ErrorContext(ctx, "build and deploy failed",
+		slog.String("id", id),
+		slog.String("stage", string(stage)),
+		slog.String("error", err.Error()))
+}
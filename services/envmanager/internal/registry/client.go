@@ -0,0 +1,138 @@
+// Package registry is an HTTP client for the Package Registry service.
+package registry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+// Sentinel errors mapped from registry responses.
+var (
+	ErrNotFound      = errors.New("package not found")
+	ErrAlreadyExists = errors.New("package version already exists")
+)
+
+// defaultNamespace is the namespace the registry assumes when none is given.
+const defaultNamespace = "default"
+
+// Client talks to the Registry service's REST API. All requests use the
+// registry's default namespace.
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// New creates a Client for the registry at baseURL. If httpClient is nil, a
+// client with a 10s timeout and trace propagation is used.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		}
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: httpClient,
+	}
+}
+
+// GetPackage fetches a single package version.
+func (c *Client) GetPackage(ctx context.Context, name, version string) (model.Package, error) {
+	var pkg model.Package
+	err := c.do(ctx, http.MethodGet, versionPath(name, version), nil, http.StatusOK, &pkg)
+	return pkg, err
+}
+
+// ResolveDependencies returns the transitive dependencies of a package
+// version, excluding the package itself.
+func (c *Client) ResolveDependencies(ctx context.Context, name, version string) ([]model.Package, error) {
+	var resp struct {
+		Packages []model.Package `json:"packages"`
+	}
+	err := c.do(ctx, http.MethodGet, versionPath(name, version)+"/dependencies", nil, http.StatusOK, &resp)
+	return resp.Packages, err
+}
+
+// Publish creates a new package version.
+func (c *Client) Publish(ctx context.Context, pkg model.Package) (model.Package, error) {
+	if pkg.Namespace == "" {
+		pkg.Namespace = defaultNamespace
+	}
+	body := struct {
+		Package model.Package `json:"package"`
+	}{Package: pkg}
+	var published model.Package
+	err := c.do(ctx, http.MethodPost, "/v1/packages", body, http.StatusCreated, &published)
+	return published, err
+}
+
+// Yank marks a package version as yanked so it is no longer resolved.
+func (c *Client) Yank(ctx context.Context, name, version string) error {
+	return c.do(ctx, http.MethodDelete, versionPath(name, version), nil, http.StatusNoContent, nil)
+}
+
+func versionPath(name, version string) string {
+	return fmt.Sprintf("/v1/packages/%s/versions/%s", url.PathEscape(name), url.PathEscape(version))
+}
+
+// do sends a JSON request and decodes the response into out when the
+// registry answers with want.
+func (c *Client) do(ctx context.Context, method, path string, in any, want int, out any) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return fmt.Errorf("encode request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return fmt.Errorf("create request: %w", err)
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s: %w", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != want {
+		var apiErr struct {
+			Error string `json:"error"`
+		}
+		_ = json.NewDecoder(resp.Body).Decode(&apiErr)
+		switch resp.StatusCode {
+		case http.StatusNotFound:
+			return fmt.Errorf("%s %s: %w", method, path, ErrNotFound)
+		case http.StatusConflict:
+			return fmt.Errorf("%s %s: %w", method, path, ErrAlreadyExists)
+		}
+		return fmt.Errorf("%s %s: registry returned %d: %s", method, path, resp.StatusCode, apiErr.Error)
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package registry_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/registry"
+)
+
+func TestClient(t *testing.T) {
+	var published model.Package
+	var yanked string
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/packages/{name}/versions/{version}", func(w http.ResponseWriter, r *http.Request) {
+		if r.PathValue("name") != "@acme/users" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error":"package not found"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(model.Package{Name: r.PathValue("name"), Version: r.PathValue("version")})
+	})
+	mux.HandleFunc("GET /v1/packages/{name}/versions/{version}/dependencies", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"packages":[{"name":"users","version":"1.0.0"}]}`))
+	})
+	mux.HandleFunc("POST /v1/packages", func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Package model.Package `json:"package"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode publish request: %v", err)
+		}
+		if req.Package.Version == "1.0.0" {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error":"package version already exists"}`))
+			return
+		}
+		published = req.Package
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(req.Package)
+	})
+	mux.HandleFunc("DELETE /v1/packages/{name}/versions/{version}", func(w http.ResponseWriter, r *http.Request) {
+		yanked = r.PathValue("name") + "@" + r.PathValue("version")
+		w.WriteHeader(http.StatusNoContent)
+	})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	c := registry.New(srv.URL+"/", srv.Client())
+	ctx := context.Background()
+
+	pkg, err := c.GetPackage(ctx, "@acme/users", "1.0.0")
+	if err != nil {
+		t.Fatalf("GetPackage: %v", err)
+	}
+	if pkg.Name != "@acme/users" || pkg.Version != "1.0.0" {
+		t.Fatalf("unexpected package %+v", pkg)
+	}
+
+	if _, err := c.GetPackage(ctx, "missing", "1.0.0"); !errors.Is(err, registry.ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	deps, err := c.ResolveDependencies(ctx, "root", "1.0.0")
+	if err != nil {
+		t.Fatalf("ResolveDependencies: %v", err)
+	}
+	if len(deps) != 1 || deps[0].Name != "users" {
+		t.Fatalf("unexpected dependencies %+v", deps)
+	}
+
+	if _, err := c.Publish(ctx, model.Package{Name: "users", Version: "1.1.0"}); err != nil {
+		t.Fatalf("Publish: %v", err)
+	}
+	if published.Namespace != "default" {
+		t.Fatalf("expected publish to default namespace, got %q", published.Namespace)
+	}
+	if _, err := c.Publish(ctx, model.Package{Name: "users", Version: "1.0.0"}); !errors.Is(err, registry.ErrAlreadyExists) {
+		t.Fatalf("expected ErrAlreadyExists, got %v", err)
+	}
+
+	if err := c.Yank(ctx, "users", "1.1.0"); err != nil {
+		t.Fatalf("Yank: %v", err)
+	}
+	if yanked != "users@1.1.0" {
+		t.Fatalf("expected users@1.1.0 yanked, got %q", yanked)
+	}
+}
@@ -0,0 +1,473 @@
+package schemadiff
+
+import (
+	"fmt"
+	"strings"
+)
+
+// gqlType is the parts of a GraphQL type definition that matter to clients.
+type gqlType struct {
+	Kind   string // type, interface, input, enum, union, scalar
+	Fields map[string]gqlField
+	// Values holds enum values or union members.
+	Values map[string]bool
+}
+
+// gqlField is a field (or input field) with its type and arguments.
+type gqlField struct {
+	Type       string
+	HasDefault bool
+	Args       map[string]gqlField
+}
+
+// diffGraphQL compares two GraphQL SDL documents.
+func diffGraphQL(oldSDL, newSDL string) ([]Change, error) {
+	oldTypes, err := parseSDL(oldSDL)
+	if err != nil {
+		return nil, fmt.Errorf("old schema: %w", err)
+	}
+	newTypes, err := parseSDL(newSDL)
+	if err != nil {
+		return nil, fmt.Errorf("new schema: %w", err)
+	}
+
+	var changes []Change
+	for name, ot := range oldTypes {
+		nt, ok := newTypes[name]
+		if !ok {
+			changes = append(changes, Change{Type: ChangeRemoved, Path: name, Breaking: true,
+				Description: fmt.Sprintf("%s %s removed", ot.Kind, name)})
+			continue
+		}
+		if ot.Kind != nt.Kind {
+			changes = append(changes, Change{Type: ChangeChanged, Path: name, Breaking: true,
+				Description: fmt.Sprintf("%s changed from %s to %s", name, ot.Kind, nt.Kind)})
+			continue
+		}
+		changes = append(changes, diffGQLType(name, ot, nt)...)
+	}
+	for name, nt := range newTypes {
+		if _, ok := oldTypes[name]; !ok {
+			changes = append(changes, Change{Type: ChangeAdded, Path: name,
+				Description: fmt.Sprintf("%s %s added", nt.Kind, name)})
+		}
+	}
+	return changes, nil
+}
+
+func diffGQLType(name string, ot, nt gqlType) []Change {
+	var changes []Change
+	input := ot.Kind == "input"
+
+	for fname, of := range ot.Fields {
+		path := name + "." + fname
+		nf, ok := nt.Fields[fname]
+		if !ok {
+			changes = append(changes, Change{Type: ChangeRemoved, Path: path, Breaking: true,
+				Description: fmt.Sprintf("field %s removed", path)})
+			continue
+		}
+		if of.Type != nf.Type {
+			changes = append(changes, Change{Type: ChangeChanged, Path: path,
+				Breaking:    !safeTypeChange(of.Type, nf.Type, input),
+				Description: fmt.Sprintf("field %s changed type from %s to %s", path, of.Type, nf.Type)})
+		}
+		changes = append(changes, diffGQLArgs(path, of.Args, nf.Args)...)
+	}
+	for fname, nf := range nt.Fields {
+		if _, ok := ot.Fields[fname]; ok {
+			continue
+		}
+		path := name + "." + fname
+		changes = append(changes, Change{Type: ChangeAdded, Path: path,
+			Breaking:    input && required(nf),
+			Description: fmt.Sprintf("field %s added", path)})
+	}
+
+	// Removing an enum value or union member breaks clients that use it.
+	what := "enum value"
+	if ot.Kind == "union" {
+		what = "union member"
+	}
+	for v := range ot.Values {
+		if !nt.Values[v] {
+			changes = append(changes, Change{Type: ChangeRemoved, Path: name + "." + v, Breaking: true,
+				Description: fmt.Sprintf("%s %s removed from %s", what, v, name)})
+		}
+	}
+	for v := range nt.Values {
+		if !ot.Values[v] {
+			changes = append(changes, Change{Type: ChangeAdded, Path: name + "." + v,
+				Description: fmt.Sprintf("%s %s added to %s", what, v, name)})
+		}
+	}
+	return changes
+}
+
+func diffGQLArgs(fieldPath string, oldArgs, newArgs map[string]gqlField) []Change {
+	var changes []Change
+	for aname, oa := range oldArgs {
+		path := fmt.Sprintf("%s(%s)", fieldPath, aname)
+		na, ok := newArgs[aname]
+		if !ok {
+			changes = append(changes, Change{Type: ChangeRemoved, Path: path, Breaking: true,
+				Description: fmt.Sprintf("argument %s removed", path)})
+			continue
+		}
+		if oa.Type != na.Type {
+			changes = append(changes, Change{Type: ChangeChanged, Path: path,
+				Breaking:    !safeTypeChange(oa.Type, na.Type, true),
+				Description: fmt.Sprintf("argument %s changed type from %s to %s", path, oa.Type, na.Type)})
+		}
+	}
+	for aname, na := range newArgs {
+		if _, ok := oldArgs[aname]; ok {
+			continue
+		}
+		path := fmt.Sprintf("%s(%s)", fieldPath, aname)
+		changes = append(changes, Change{Type: ChangeAdded, Path: path,
+			Breaking:    required(na),
+			Description: fmt.Sprintf("argument %s added", path)})
+	}
+	return changes
+}
+
+// safeTypeChange reports whether changing a type reference from oldType to
+// newType is backwards compatible. Output positions may become non-null;
+// input positions may become nullable.
+func safeTypeChange(oldType, newType string, input bool) bool {
+	if input {
+		return oldType == newType+"!"
+	}
+	return newType == oldType+"!"
+}
+
+// required reports whether an input field or argument must be supplied.
+func required(f gqlField) bool {
+	return strings.HasSuffix(f.Type, "!") && !f.HasDefault
+}
+
+// --- SDL parsing ---
+
+// parseSDL extracts type definitions from a GraphQL SDL document. It
+// understands enough of the grammar to compare schemas and ignores
+// descriptions, directives and schema/directive definitions.
+func parseSDL(sdl string) (map[string]gqlType, error) {
+	p := &sdlParser{tokens: tokenizeSDL(sdl)}
+	types := make(map[string]gqlType)
+
+	for !p.done() {
+		tok := p.next()
+		if tok == "extend" {
+			tok = p.next()
+		}
+		switch tok {
+		case "schema":
+			p.skipDirectives()
+			p.skipBlock("{", "}")
+		case "directive":
+			p.skipDirectiveDefinition()
+		case "scalar":
+			name := p.next()
+			p.skipDirectives()
+			mergeType(types, name, gqlType{Kind: "scalar"})
+		case "type", "interface", "input":
+			name := p.next()
+			p.skipImplements()
+			p.skipDirectives()
+			fields, err := p.parseFields()
+			if err != nil {
+				return nil, fmt.Errorf("%s %s: %w", tok, name, err)
+			}
+			mergeType(types, name, gqlType{Kind: tok, Fields: fields})
+		case "enum":
+			name := p.next()
+			p.skipDirectives()
+			values := make(map[string]bool)
+			if p.peek() == "{" {
+				p.next()
+				for !p.done() && p.peek() != "}" {
+					values[p.next()] = true
+					p.skipDirectives()
+				}
+				p.next()
+			}
+			mergeType(types, name, gqlType{Kind: "enum", Values: values})
+		case "union":
+			name := p.next()
+			p.skipDirectives()
+			members := make(map[string]bool)
+			if p.peek() == "=" {
+				p.next()
+				for !p.done() {
+					if p.peek() == "|" {
+						p.next()
+					}
+					members[p.next()] = true
+					if p.peek() != "|" {
+						break
+					}
+				}
+			}
+			mergeType(types, name, gqlType{Kind: "union", Values: members})
+		default:
+			return nil, fmt.Errorf("unexpected token %q", tok)
+		}
+	}
+	return types, nil
+}
+
+// mergeType adds def to types, merging with any earlier definition or
+// extension of the same name.
+func mergeType(types map[string]gqlType, name string, def gqlType) {
+	existing, ok := types[name]
+	if !ok {
+		if def.Fields == nil {
+			def.Fields = make(map[string]gqlField)
+		}
+		if def.Values == nil {
+			def.Values = make(map[string]bool)
+		}
+		types[name] = def
+		return
+	}
+	for k, v := range def.Fields {
+		existing.Fields[k] = v
+	}
+	for k, v := range def.Values {
+		existing.Values[k] = v
+	}
+}
+
+type sdlParser struct {
+	tokens []string
+	pos    int
+}
+
+func (p *sdlParser) done() bool { return p.pos >= len(p.tokens) }
+
+func (p *sdlParser) peek() string {
+	if p.done() {
+		return ""
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *sdlParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+// skipBlock skips a balanced open...close group if one starts here.
+func (p *sdlParser) skipBlock(open, close string) {
+	if p.peek() != open {
+		return
+	}
+	depth := 0
+	for !p.done() {
+		switch p.next() {
+		case open:
+			depth++
+		case close:
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+}
+
+func (p *sdlParser) skipDirectives() {
+	for p.peek() == "@" {
+		p.next()
+		p.next()
+		p.skipBlock("(", ")")
+	}
+}
+
+func (p *sdlParser) skipImplements() {
+	if p.peek() != "implements" {
+		return
+	}
+	p.next()
+	for !p.done() {
+		if p.peek() == "&" {
+			p.next()
+		}
+		p.next()
+		if p.peek() != "&" {
+			return
+		}
+	}
+}
+
+// skipDirectiveDefinition skips "@name(args) repeatable on A | B".
+func (p *sdlParser) skipDirectiveDefinition() {
+	p.next() // @
+	p.next() // name
+	p.skipBlock("(", ")")
+	if p.peek() == "repeatable" {
+		p.next()
+	}
+	if p.peek() == "on" {
+		p.next()
+	}
+	for !p.done() {
+		if p.peek() == "|" {
+			p.next()
+		}
+		p.next()
+		if p.peek() != "|" {
+			return
+		}
+	}
+}
+
+// parseFields parses an optional { field(args): Type ... } block.
+func (p *sdlParser) parseFields() (map[string]gqlField, error) {
+	fields := make(map[string]gqlField)
+	if p.peek() != "{" {
+		return fields, nil
+	}
+	p.next()
+	for !p.done() && p.peek() != "}" {
+		f, name, err := p.parseInputValue()
+		if err != nil {
+			return nil, err
+		}
+		fields[name] = f
+	}
+	if p.next() != "}" {
+		return nil, fmt.Errorf("unterminated field block")
+	}
+	return fields, nil
+}
+
+// parseInputValue parses "name(args): Type = default @directives".
+func (p *sdlParser) parseInputValue() (gqlField, string, error) {
+	name := p.next()
+	f := gqlField{}
+	if p.peek() == "(" {
+		p.next()
+		f.Args = make(map[string]gqlField)
+		for !p.done() && p.peek() != ")" {
+			arg, argName, err := p.parseInputValue()
+			if err != nil {
+				return gqlField{}, "", err
+			}
+			f.Args[argName] = arg
+		}
+		p.next()
+	}
+	if tok := p.next(); tok != ":" {
+		return gqlField{}, "", fmt.Errorf("expected ':' after %s, got %q", name, tok)
+	}
+	f.Type = p.parseTypeRef()
+	if p.peek() == "=" {
+		p.next()
+		f.HasDefault = true
+		p.skipValue()
+	}
+	p.skipDirectives()
+	return f, name, nil
+}
+
+// parseTypeRef reads a type reference such as [String!]! into a string.
+func (p *sdlParser) parseTypeRef() string {
+	var b strings.Builder
+	depth := 0
+	for !p.done() {
+		tok := p.next()
+		b.WriteString(tok)
+		switch tok {
+		case "[":
+			depth++
+			continue
+		case "]":
+			depth--
+		}
+		if p.peek() == "!" {
+			b.WriteString(p.next())
+		}
+		if depth == 0 {
+			break
+		}
+	}
+	return b.String()
+}
+
+// skipValue skips a default value, which may be a list or object literal.
+func (p *sdlParser) skipValue() {
+	switch p.peek() {
+	case "[":
+		p.skipBlock("[", "]")
+	case "{":
+		p.skipBlock("{", "}")
+	default:
+		p.next()
+	}
+}
+
+// tokenizeSDL splits SDL into names, punctuation and literal values,
+// dropping comments, commas and string descriptions.
+func tokenizeSDL(sdl string) []string {
+	var tokens []string
+	for i := 0; i < len(sdl); {
+		c := sdl[i]
+		switch {
+		case c == '#':
+			for i < len(sdl) && sdl[i] != '\n' {
+				i++
+			}
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '"':
+			// Strings are descriptions or default values. Descriptions are
+			// dropped; a default value must still occupy a token.
+			start := i
+			i = skipString(sdl, i)
+			if len(tokens) > 0 && tokens[len(tokens)-1] == "=" {
+				tokens = append(tokens, sdl[start:i])
+			}
+		case strings.HasPrefix(sdl[i:], "..."):
+			tokens = append(tokens, "...")
+			i += 3
+		case strings.IndexByte("{}()[]:!=@|&$", c) >= 0:
+			tokens = append(tokens, string(c))
+			i++
+		case isNameByte(c):
+			start := i
+			for i < len(sdl) && isNameByte(sdl[i]) {
+				i++
+			}
+			tokens = append(tokens, sdl[start:i])
+		default:
+			i++
+		}
+	}
+	return tokens
+}
+
+// skipString returns the index just past the string literal starting at i.
+func skipString(sdl string, i int) int {
+	if strings.HasPrefix(sdl[i:], `"""`) {
+		end := strings.Index(sdl[i+3:], `"""`)
+		if end < 0 {
+			return len(sdl)
+		}
+		return i + 3 + end + 3
+	}
+	for i++; i < len(sdl) && sdl[i] != '"' && sdl[i] != '\n'; i++ {
+		if sdl[i] == '\\' {
+			i++
+		}
+	}
+	return min(i+1, len(sdl))
+}
+
+// isNameByte reports whether c can appear in a name or number literal.
+func isNameByte(c byte) bool {
+	return c == '_' || c == '-' || c == '.' || c == '+' ||
+		('a' <= c && c <= 'z') || ('A' <= c && c <= 'Z') || ('0' <= c && c <= '9')
+}
@@ -0,0 +1,222 @@
+package schemadiff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// httpMethods are the operation keys of an OpenAPI path item.
+var httpMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// openAPIDoc is the subset of an OpenAPI document that affects clients.
+type openAPIDoc struct {
+	Paths      map[string]map[string]openAPIOperation `yaml:"paths"`
+	Components struct {
+		Schemas map[string]openAPISchema `yaml:"schemas"`
+	} `yaml:"components"`
+}
+
+type openAPIOperation struct {
+	Parameters []openAPIParameter `yaml:"parameters"`
+	Responses  map[string]any     `yaml:"responses"`
+}
+
+type openAPIParameter struct {
+	Name     string `yaml:"name"`
+	In       string `yaml:"in"`
+	Required bool   `yaml:"required"`
+}
+
+type openAPISchema struct {
+	Type       any                      `yaml:"type"`
+	Required   []string                 `yaml:"required"`
+	Properties map[string]openAPISchema `yaml:"properties"`
+	Ref        string                   `yaml:"$ref"`
+	Items      *openAPISchema           `yaml:"items"`
+}
+
+// diffOpenAPI compares two OpenAPI documents (YAML or JSON).
+func diffOpenAPI(oldDoc, newDoc string) ([]Change, error) {
+	var o, n openAPIDoc
+	if err := yaml.Unmarshal([]byte(oldDoc), &o); err != nil {
+		return nil, fmt.Errorf("old schema: %w", err)
+	}
+	if err := yaml.Unmarshal([]byte(newDoc), &n); err != nil {
+		return nil, fmt.Errorf("new schema: %w", err)
+	}
+
+	var changes []Change
+	changes = append(changes, diffOperations(o.Paths, n.Paths)...)
+	changes = append(changes, diffSchemas(o.Components.Schemas, n.Components.Schemas)...)
+	return changes, nil
+}
+
+func diffOperations(oldPaths, newPaths map[string]map[string]openAPIOperation) []Change {
+	oldOps := flattenOperations(oldPaths)
+	newOps := flattenOperations(newPaths)
+
+	var changes []Change
+	for key, oop := range oldOps {
+		nop, ok := newOps[key]
+		if !ok {
+			changes = append(changes, Change{Type: ChangeRemoved, Path: key, Breaking: true,
+				Description: fmt.Sprintf("operation %s removed", key)})
+			continue
+		}
+		changes = append(changes, diffParameters(key, oop.Parameters, nop.Parameters)...)
+		for code := range oop.Responses {
+			if _, ok := nop.Responses[code]; !ok {
+				changes = append(changes, Change{Type: ChangeRemoved, Path: key + " " + code, Breaking: true,
+					Description: fmt.Sprintf("response %s removed from %s", code, key)})
+			}
+		}
+		for code := range nop.Responses {
+			if _, ok := oop.Responses[code]; !ok {
+				changes = append(changes, Change{Type: ChangeAdded, Path: key + " " + code,
+					Description: fmt.Sprintf("response %s added to %s", code, key)})
+			}
+		}
+	}
+	for key := range newOps {
+		if _, ok := oldOps[key]; !ok {
+			changes = append(changes, Change{Type: ChangeAdded, Path: key,
+				Description: fmt.Sprintf("operation %s added", key)})
+		}
+	}
+	return changes
+}
+
+// flattenOperations keys every operation as "METHOD /path".
+func flattenOperations(paths map[string]map[string]openAPIOperation) map[string]openAPIOperation {
+	ops := make(map[string]openAPIOperation)
+	for path, item := range paths {
+		for _, method := range httpMethods {
+			if op, ok := item[method]; ok {
+				ops[strings.ToUpper(method)+" "+path] = op
+			}
+		}
+	}
+	return ops
+}
+
+func diffParameters(opKey string, oldParams, newParams []openAPIParameter) []Change {
+	key := func(p openAPIParameter) string { return p.In + ":" + p.Name }
+	oldByKey := make(map[string]openAPIParameter, len(oldParams))
+	for _, p := range oldParams {
+		oldByKey[key(p)] = p
+	}
+	newByKey := make(map[string]openAPIParameter, len(newParams))
+	for _, p := range newParams {
+		newByKey[key(p)] = p
+	}
+
+	var changes []Change
+	for k, np := range newByKey {
+		path := fmt.Sprintf("%s [%s]", opKey, k)
+		op, ok := oldByKey[k]
+		switch {
+		case !ok:
+			changes = append(changes, Change{Type: ChangeAdded, Path: path, Breaking: np.Required,
+				Description: fmt.Sprintf("parameter %s added to %s", np.Name, opKey)})
+		case np.Required && !op.Required:
+			changes = append(changes, Change{Type: ChangeChanged, Path: path, Breaking: true,
+				Description: fmt.Sprintf("parameter %s of %s is now required", np.Name, opKey)})
+		}
+	}
+	for k, op := range oldByKey {
+		if _, ok := newByKey[k]; !ok {
+			changes = append(changes, Change{Type: ChangeRemoved, Path: fmt.Sprintf("%s [%s]", opKey, k),
+				Description: fmt.Sprintf("parameter %s removed from %s", op.Name, opKey)})
+		}
+	}
+	return changes
+}
+
+func diffSchemas(oldSchemas, newSchemas map[string]openAPISchema) []Change {
+	var changes []Change
+	for name, os := range oldSchemas {
+		path := "#/components/schemas/" + name
+		ns, ok := newSchemas[name]
+		if !ok {
+			changes = append(changes, Change{Type: ChangeRemoved, Path: path, Breaking: true,
+				Description: fmt.Sprintf("schema %s removed", name)})
+			continue
+		}
+		changes = append(changes, diffSchemaProperties(path, name, os, ns)...)
+	}
+	for name := range newSchemas {
+		if _, ok := oldSchemas[name]; !ok {
+			changes = append(changes, Change{Type: ChangeAdded, Path: "#/components/schemas/" + name,
+				Description: fmt.Sprintf("schema %s added", name)})
+		}
+	}
+	return changes
+}
+
+func diffSchemaProperties(path, name string, os, ns openAPISchema) []Change {
+	var changes []Change
+	if t1, t2 := schemaType(os), schemaType(ns); t1 != t2 {
+		changes = append(changes, Change{Type: ChangeChanged, Path: path, Breaking: true,
+			Description: fmt.Sprintf("schema %s changed type from %s to %s", name, t1, t2)})
+	}
+
+	oldRequired := toSet(os.Required)
+	newRequired := toSet(ns.Required)
+	for prop, op := range os.Properties {
+		ppath := path + "/" + prop
+		np, ok := ns.Properties[prop]
+		if !ok {
+			changes = append(changes, Change{Type: ChangeRemoved, Path: ppath, Breaking: true,
+				Description: fmt.Sprintf("property %s.%s removed", name, prop)})
+			continue
+		}
+		if t1, t2 := schemaType(op), schemaType(np); t1 != t2 {
+			changes = append(changes, Change{Type: ChangeChanged, Path: ppath, Breaking: true,
+				Description: fmt.Sprintf("property %s.%s changed type from %s to %s", name, prop, t1, t2)})
+		}
+		if newRequired[prop] && !oldRequired[prop] {
+			changes = append(changes, Change{Type: ChangeChanged, Path: ppath, Breaking: true,
+				Description: fmt.Sprintf("property %s.%s is now required", name, prop)})
+		}
+	}
+	props := make([]string, 0, len(ns.Properties))
+	for prop := range ns.Properties {
+		props = append(props, prop)
+	}
+	sort.Strings(props)
+	for _, prop := range props {
+		if _, ok := os.Properties[prop]; !ok {
+			changes = append(changes, Change{Type: ChangeAdded, Path: path + "/" + prop,
+				Breaking:    newRequired[prop],
+				Description: fmt.Sprintf("property %s.%s added", name, prop)})
+		}
+	}
+	return changes
+}
+
+// schemaType renders a schema's type for comparison, following $ref and
+// array item types.
+func schemaType(s openAPISchema) string {
+	if s.Ref != "" {
+		return s.Ref
+	}
+	t := fmt.Sprint(s.Type)
+	if s.Type == nil {
+		t = "object"
+	}
+	if s.Items != nil {
+		t += "<" + schemaType(*s.Items) + ">"
+	}
+	return t
+}
+
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		set[item] = true
+	}
+	return set
+}
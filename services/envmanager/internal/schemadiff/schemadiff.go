@@ -0,0 +1,99 @@
+// Package schemadiff compares two versions of a package schema and reports
+// the individual changes between them, flagging the ones that would break
+// existing clients. GraphQL SDL and OpenAPI documents are understood
+// structurally; other package kinds fall back to a whole-document comparison.
+package schemadiff
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Package kinds with structural diff support. These match the kind strings
+// used in turbo-engine.yaml manifests and stored in the registry.
+const (
+	KindGraphQLSubgraph   = "graphql-subgraph"
+	KindGraphQLSupergraph = "graphql-supergraph"
+	KindOpenAPIService    = "openapi-service"
+)
+
+// ChangeType classifies a single schema change.
+type ChangeType string
+
+const (
+	ChangeAdded   ChangeType = "added"
+	ChangeRemoved ChangeType = "removed"
+	ChangeChanged ChangeType = "changed"
+)
+
+// Change is one difference between two schema versions.
+type Change struct {
+	Type ChangeType `json:"type"`
+	// Path locates the change, e.g. "User.email" or "GET /pets/{petId}".
+	Path        string `json:"path"`
+	Breaking    bool   `json:"breaking"`
+	Description string `json:"description"`
+}
+
+// Result is the outcome of comparing two schemas.
+type Result struct {
+	Changes []Change `json:"changes"`
+}
+
+// Breaking reports whether any change would break existing clients.
+func (r Result) Breaking() bool {
+	for _, c := range r.Changes {
+		if c.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// HasAdditions reports whether the new schema adds anything.
+func (r Result) HasAdditions() bool {
+	for _, c := range r.Changes {
+		if c.Type == ChangeAdded {
+			return true
+		}
+	}
+	return false
+}
+
+// Diff compares oldSchema with newSchema for a package of the given kind.
+func Diff(kind, oldSchema, newSchema string) (Result, error) {
+	var (
+		changes []Change
+		err     error
+	)
+	switch kind {
+	case KindGraphQLSubgraph, KindGraphQLSupergraph:
+		changes, err = diffGraphQL(oldSchema, newSchema)
+	case KindOpenAPIService:
+		changes, err = diffOpenAPI(oldSchema, newSchema)
+	default:
+		changes = diffText(oldSchema, newSchema)
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("diff %s schema: %w", kind, err)
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return Result{Changes: changes}, nil
+}
+
+// diffText treats the schema as an opaque document.
+func diffText(oldSchema, newSchema string) []Change {
+	switch {
+	case oldSchema == newSchema:
+		return nil
+	case oldSchema == "":
+		return []Change{{Type: ChangeAdded, Description: "schema added"}}
+	case newSchema == "":
+		return []Change{{Type: ChangeRemoved, Breaking: true, Description: "schema removed"}}
+	default:
+		return []Change{{Type: ChangeChanged, Description: "schema changed"}}
+	}
+}
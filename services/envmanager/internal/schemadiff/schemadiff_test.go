@@ -0,0 +1,275 @@
+package schemadiff_test
+
+import (
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/schemadiff"
+)
+
+func TestDiff_GraphQL(t *testing.T) {
+	base := `
+"""A user of the system."""
+type User @key(fields: "id") {
+  id: ID!
+  name: String
+  # deprecated soon
+  email: String @deprecated(reason: "use contact")
+  posts(first: Int = 10, after: String): [Post!]!
+}
+
+type Post { id: ID! }
+
+enum Role { ADMIN USER }
+
+union SearchResult = User | Post
+
+input UserFilter { role: Role, name: String }
+
+extend type Query {
+  users(filter: UserFilter): [User]
+}
+`
+	tests := []struct {
+		name      string
+		schema    string
+		breaking  bool
+		additions bool
+	}{
+		{
+			name:   "identical",
+			schema: base,
+		},
+		{
+			name:      "added field",
+			schema:    base + "extend type User { age: Int }",
+			additions: true,
+		},
+		{
+			name: "removed field",
+			schema: `type User @key(fields: "id") { id: ID! name: String posts(first: Int = 10, after: String): [Post!]! }
+type Post { id: ID! } enum Role { ADMIN USER } union SearchResult = User | Post
+input UserFilter { role: Role, name: String } extend type Query { users(filter: UserFilter): [User] }`,
+			breaking: true,
+		},
+		{
+			name: "output field made non-null",
+			schema: `type User @key(fields: "id") { id: ID! name: String! email: String posts(first: Int = 10, after: String): [Post!]! }
+type Post { id: ID! } enum Role { ADMIN USER } union SearchResult = User | Post
+input UserFilter { role: Role, name: String } extend type Query { users(filter: UserFilter): [User] }`,
+		},
+		{
+			name: "input field made non-null",
+			schema: `type User @key(fields: "id") { id: ID! name: String email: String posts(first: Int = 10, after: String): [Post!]! }
+type Post { id: ID! } enum Role { ADMIN USER } union SearchResult = User | Post
+input UserFilter { role: Role!, name: String } extend type Query { users(filter: UserFilter): [User] }`,
+			breaking: true,
+		},
+		{
+			name: "required argument added",
+			schema: `type User @key(fields: "id") { id: ID! name: String email: String posts(first: Int = 10, after: String, order: String!): [Post!]! }
+type Post { id: ID! } enum Role { ADMIN USER } union SearchResult = User | Post
+input UserFilter { role: Role, name: String } extend type Query { users(filter: UserFilter): [User] }`,
+			breaking:  true,
+			additions: true,
+		},
+		{
+			name: "optional argument added",
+			schema: `type User @key(fields: "id") { id: ID! name: String email: String posts(first: Int = 10, after: String, order: String! = "asc"): [Post!]! }
+type Post { id: ID! } enum Role { ADMIN USER } union SearchResult = User | Post
+input UserFilter { role: Role, name: String } extend type Query { users(filter: UserFilter): [User] }`,
+			additions: true,
+		},
+		{
+			name: "enum value removed",
+			schema: `type User @key(fields: "id") { id: ID! name: String email: String posts(first: Int = 10, after: String): [Post!]! }
+type Post { id: ID! } enum Role { ADMIN } union SearchResult = User | Post
+input UserFilter { role: Role, name: String } extend type Query { users(filter: UserFilter): [User] }`,
+			breaking: true,
+		},
+		{
+			name: "union member added",
+			schema: base + `type Comment { id: ID! }
+extend union SearchResult = Comment`,
+			additions: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := schemadiff.Diff(schemadiff.KindGraphQLSubgraph, base, tt.schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Breaking() != tt.breaking {
+				t.Errorf("expected breaking=%v, got changes %+v", tt.breaking, result.Changes)
+			}
+			if result.HasAdditions() != tt.additions {
+				t.Errorf("expected additions=%v, got changes %+v", tt.additions, result.Changes)
+			}
+		})
+	}
+}
+
+func TestDiff_OpenAPI(t *testing.T) {
+	base := `
+openapi: 3.0.3
+paths:
+  /pets:
+    get:
+      parameters:
+        - name: limit
+          in: query
+      responses:
+        "200": {description: ok}
+  /pets/{petId}:
+    get:
+      parameters:
+        - name: petId
+          in: path
+          required: true
+      responses:
+        "200": {description: ok}
+        "404": {description: not found}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [id]
+      properties:
+        id: {type: integer}
+        name: {type: string}
+`
+	tests := []struct {
+		name      string
+		schema    string
+		breaking  bool
+		additions bool
+	}{
+		{
+			name:   "identical",
+			schema: base,
+		},
+		{
+			name: "operation added",
+			schema: `
+paths:
+  /pets:
+    get:
+      parameters: [{name: limit, in: query}]
+      responses: {"200": {}}
+    post:
+      responses: {"201": {}}
+  /pets/{petId}:
+    get:
+      parameters: [{name: petId, in: path, required: true}]
+      responses: {"200": {}, "404": {}}
+components:
+  schemas:
+    Pet: {type: object, required: [id], properties: {id: {type: integer}, name: {type: string}}}
+`,
+			additions: true,
+		},
+		{
+			name: "operation removed",
+			schema: `
+paths:
+  /pets/{petId}:
+    get:
+      parameters: [{name: petId, in: path, required: true}]
+      responses: {"200": {}, "404": {}}
+components:
+  schemas:
+    Pet: {type: object, required: [id], properties: {id: {type: integer}, name: {type: string}}}
+`,
+			breaking: true,
+		},
+		{
+			name: "parameter made required",
+			schema: `
+paths:
+  /pets:
+    get:
+      parameters: [{name: limit, in: query, required: true}]
+      responses: {"200": {}}
+  /pets/{petId}:
+    get:
+      parameters: [{name: petId, in: path, required: true}]
+      responses: {"200": {}, "404": {}}
+components:
+  schemas:
+    Pet: {type: object, required: [id], properties: {id: {type: integer}, name: {type: string}}}
+`,
+			breaking: true,
+		},
+		{
+			name: "property added and response removed",
+			schema: `
+paths:
+  /pets:
+    get:
+      parameters: [{name: limit, in: query}]
+      responses: {"200": {}}
+  /pets/{petId}:
+    get:
+      parameters: [{name: petId, in: path, required: true}]
+      responses: {"200": {}}
+components:
+  schemas:
+    Pet: {type: object, required: [id], properties: {id: {type: integer}, name: {type: string}, tag: {type: string}}}
+`,
+			breaking:  true,
+			additions: true,
+		},
+		{
+			name: "property type changed",
+			schema: `
+paths:
+  /pets:
+    get:
+      parameters: [{name: limit, in: query}]
+      responses: {"200": {}}
+  /pets/{petId}:
+    get:
+      parameters: [{name: petId, in: path, required: true}]
+      responses: {"200": {}, "404": {}}
+components:
+  schemas:
+    Pet: {type: object, required: [id], properties: {id: {type: string}, name: {type: string}}}
+`,
+			breaking: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := schemadiff.Diff(schemadiff.KindOpenAPIService, base, tt.schema)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result.Breaking() != tt.breaking {
+				t.Errorf("expected breaking=%v, got changes %+v", tt.breaking, result.Changes)
+			}
+			if result.HasAdditions() != tt.additions {
+				t.Errorf("expected additions=%v, got changes %+v", tt.additions, result.Changes)
+			}
+		})
+	}
+}
+
+func TestDiff_OtherKinds(t *testing.T) {
+	result, err := schemadiff.Diff("postman-collection", `{"item":[]}`, `{"item":[{}]}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Changes) != 1 || result.Breaking() {
+		t.Fatalf("expected a single non-breaking change, got %+v", result.Changes)
+	}
+
+	result, err = schemadiff.Diff("postman-collection", `{"item":[]}`, `{"item":[]}`)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Changes) != 0 {
+		t.Fatalf("expected no changes, got %+v", result.Changes)
+	}
+}
@@ -0,0 +1,81 @@
+// Package secrets encrypts environment secrets at rest.
+package secrets
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeySize is the size of an encryption key, in bytes: secrets are sealed with
+// AES-256-GCM.
+const KeySize = 32
+
+// ErrDecrypt is returned when a ciphertext cannot be opened, because it is
+// corrupt, was sealed with another key or belongs to another secret.
+var ErrDecrypt = errors.New("decrypt secret")
+
+// ParseKey decodes a base64-encoded KeySize-byte key, as generated by
+// `openssl rand -base64 32`.
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(s))
+	if err != nil {
+		return nil, fmt.Errorf("decode key: %w", err)
+	}
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key is %d bytes, want %d", len(key), KeySize)
+	}
+	return key, nil
+}
+
+// Cipher seals and opens secret values.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// NewCipher creates a Cipher from a KeySize-byte key.
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("key is %d bytes, want %d", len(key), KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt seals plaintext, returning it base64-encoded with its nonce.
+// Binding is authenticated but not encrypted; the ciphertext only opens with
+// the same binding, so one secret's ciphertext cannot be passed off as
+// another's.
+func (c *Cipher) Encrypt(plaintext []byte, binding string) (string, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plaintext)+c.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := c.aead.Seal(nonce, nonce, plaintext, []byte(binding))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// Decrypt opens a ciphertext made by Encrypt with the same binding.
+func (c *Cipher) Decrypt(ciphertext, binding string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil || len(sealed) < c.aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+	nonce, sealed := sealed[:c.aead.NonceSize()], sealed[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, []byte(binding))
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,84 @@
+package secrets_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/secrets"
+)
+
+func newCipher(t *testing.T, fill byte) *secrets.Cipher {
+	t.Helper()
+	c, err := secrets.NewCipher(bytes.Repeat([]byte{fill}, secrets.KeySize))
+	if err != nil {
+		t.Fatalf("NewCipher: %v", err)
+	}
+	return c
+}
+
+func TestCipher_RoundTrip(t *testing.T) {
+	c := newCipher(t, 1)
+
+	ciphertext, err := c.Encrypt([]byte("s3cret-token"), "env-1/token")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+	if strings.Contains(ciphertext, "s3cret") {
+		t.Fatalf("ciphertext %q contains the plaintext", ciphertext)
+	}
+	again, _ := c.Encrypt([]byte("s3cret-token"), "env-1/token")
+	if again == ciphertext {
+		t.Fatal("expected a fresh nonce for every encryption")
+	}
+
+	plaintext, err := c.Decrypt(ciphertext, "env-1/token")
+	if err != nil {
+		t.Fatalf("Decrypt: %v", err)
+	}
+	if string(plaintext) != "s3cret-token" {
+		t.Fatalf("Decrypt: got %q", plaintext)
+	}
+}
+
+func TestCipher_DecryptRejects(t *testing.T) {
+	c := newCipher(t, 1)
+	ciphertext, err := c.Encrypt([]byte("value"), "env-1/token")
+	if err != nil {
+		t.Fatalf("Encrypt: %v", err)
+	}
+
+	tests := map[string]struct {
+		cipher     *secrets.Cipher
+		ciphertext string
+		binding    string
+	}{
+		"other binding": {c, ciphertext, "env-2/token"},
+		"other key":     {newCipher(t, 2), ciphertext, "env-1/token"},
+		"not base64":    {c, "%%%", "env-1/token"},
+		"too short":     {c, base64.StdEncoding.EncodeToString([]byte("x")), "env-1/token"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := tt.cipher.Decrypt(tt.ciphertext, tt.binding); !errors.Is(err, secrets.ErrDecrypt) {
+				t.Fatalf("expected ErrDecrypt, got %v", err)
+			}
+		})
+	}
+}
+
+func TestParseKey(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, secrets.KeySize)
+	got, err := secrets.ParseKey(base64.StdEncoding.EncodeToString(key) + "\n")
+	if err != nil || !bytes.Equal(got, key) {
+		t.Fatalf("ParseKey: got %v, %v", got, err)
+	}
+	if _, err := secrets.ParseKey(base64.StdEncoding.EncodeToString(key[:16])); err == nil {
+		t.Fatal("ParseKey: expected an error for a short key")
+	}
+	if _, err := secrets.ParseKey("not base64!"); err == nil {
+		t.Fatal("ParseKey: expected an error for invalid base64")
+	}
+}
@@ -0,0 +1,273 @@
+package smoketest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+// operation is one executable definition in a graphql-operations document.
+type operation struct {
+	Type string // query, mutation or subscription
+	Name string
+	// RequiredVariables are the variables with a non-null type and no
+	// default, which a smoke test has no values for.
+	RequiredVariables []string
+}
+
+// runOperations sends every query in a graphql-operations package to the
+// preview URL's GraphQL endpoint. Mutations and subscriptions are skipped so
+// that smoke tests never change the environment's data, as are queries that
+// need variables.
+func (r *Runner) runOperations(ctx context.Context, baseURL string, pkg model.Package, report *model.SmokeTestReport) {
+	ops, err := parseOperations(pkg.Schema)
+	if err != nil {
+		report.Add(model.SmokeTestResult{
+			Package: pkg.Name,
+			Outcome: model.SmokeTestFailed,
+			Error:   fmt.Sprintf("parse operations: %v", err),
+		})
+		return
+	}
+
+	endpoint := baseURL + GraphQLPath
+	for _, op := range ops {
+		res := model.SmokeTestResult{Package: pkg.Name, Name: op.Name, Method: http.MethodPost, URL: endpoint}
+		switch {
+		case op.Type != "query":
+			res.Outcome = model.SmokeTestSkipped
+			res.Error = op.Type + "s are not run"
+		case len(op.RequiredVariables) > 0:
+			res.Outcome = model.SmokeTestSkipped
+			res.Error = "requires variables: $" + strings.Join(op.RequiredVariables, ", $")
+		default:
+			r.runOperation(ctx, pkg.Schema, op, &res)
+		}
+		report.Add(res)
+	}
+}
+
+// runOperation sends one query from document, which passes if the server
+// answers 200 with no GraphQL errors.
+func (r *Runner) runOperation(ctx context.Context, document string, op operation, res *model.SmokeTestResult) {
+	payload := map[string]any{"query": document}
+	if op.Name != "" {
+		payload["operationName"] = op.Name
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		res.Outcome = model.SmokeTestFailed
+		res.Error = err.Error()
+		return
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, res.URL, bytes.NewReader(data))
+	if err != nil {
+		res.Outcome = model.SmokeTestFailed
+		res.Error = err.Error()
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	body := r.send(req, res)
+	if res.Outcome == model.SmokeTestFailed {
+		return
+	}
+	if res.StatusCode != http.StatusOK {
+		res.Outcome = model.SmokeTestFailed
+		res.Error = fmt.Sprintf("got status %d", res.StatusCode)
+		return
+	}
+	var resp struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		res.Outcome = model.SmokeTestFailed
+		res.Error = "response is not JSON"
+		return
+	}
+	if len(resp.Errors) > 0 {
+		res.Outcome = model.SmokeTestFailed
+		res.Error = resp.Errors[0].Message
+		return
+	}
+	res.Outcome = model.SmokeTestPassed
+}
+
+// parseOperations lists the operations in a GraphQL executable document.
+// Only operation headers are parsed; selection sets are skipped.
+func parseOperations(doc string) ([]operation, error) {
+	toks, err := tokenize(doc)
+	if err != nil {
+		return nil, err
+	}
+	var ops []operation
+	for i := 0; i < len(toks); {
+		switch t := toks[i]; {
+		case t == "{":
+			// The query shorthand: an anonymous query with no variables.
+			ops = append(ops, operation{Type: "query"})
+			if i, err = skipBlock(toks, i); err != nil {
+				return nil, err
+			}
+		case t == "query" || t == "mutation" || t == "subscription":
+			op := operation{Type: t}
+			i++
+			if i < len(toks) && isName(toks[i]) {
+				op.Name = toks[i]
+				i++
+			}
+			if i < len(toks) && toks[i] == "(" {
+				if op.RequiredVariables, i, err = parseVariables(toks, i); err != nil {
+					return nil, err
+				}
+			}
+			if i, err = skipToBlock(toks, i); err != nil {
+				return nil, err
+			}
+			if i, err = skipBlock(toks, i); err != nil {
+				return nil, err
+			}
+			ops = append(ops, op)
+		case t == `"`:
+			// A description, as operations packages may document their
+			// operations the way schemas do.
+			i++
+		case t == "fragment":
+			if i, err = skipToBlock(toks, i); err != nil {
+				return nil, err
+			}
+			if i, err = skipBlock(toks, i); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unexpected %q at top level", t)
+		}
+	}
+	return ops, nil
+}
+
+// parseVariables reads variable definitions starting at the "(" at toks[i]
+// and returns the required variables and the index after the ")".
+func parseVariables(toks []string, i int) ([]string, int, error) {
+	var required []string
+	i++ // (
+	for i < len(toks) && toks[i] != ")" {
+		if toks[i] != "$" || i+2 >= len(toks) || toks[i+2] != ":" {
+			return nil, 0, fmt.Errorf("malformed variable definition near %q", toks[i])
+		}
+		name := toks[i+1]
+		i += 3
+		// The type runs until a default, a directive, the next variable or
+		// the end of the definitions.
+		last := ""
+		for i < len(toks) && toks[i] != "=" && toks[i] != "@" && toks[i] != "$" && toks[i] != ")" {
+			last = toks[i]
+			i++
+		}
+		hasDefault := i < len(toks) && toks[i] == "="
+		for i < len(toks) && toks[i] != "$" && toks[i] != ")" {
+			i++
+		}
+		if last == "!" && !hasDefault {
+			required = append(required, name)
+		}
+	}
+	if i >= len(toks) {
+		return nil, 0, fmt.Errorf("unterminated variable definitions")
+	}
+	return required, i + 1, nil
+}
+
+// skipToBlock returns the index of the next "{".
+func skipToBlock(toks []string, i int) (int, error) {
+	for ; i < len(toks); i++ {
+		if toks[i] == "{" {
+			return i, nil
+		}
+	}
+	return 0, fmt.Errorf("missing selection set")
+}
+
+// skipBlock returns the index after the "}" matching the "{" at toks[i].
+func skipBlock(toks []string, i int) (int, error) {
+	depth := 0
+	for ; i < len(toks); i++ {
+		switch toks[i] {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				return i + 1, nil
+			}
+		}
+	}
+	return 0, fmt.Errorf("unbalanced braces")
+}
+
+// tokenize splits a GraphQL document into names, numbers and punctuators.
+// Comments and commas are dropped and strings become a single "\"" token.
+func tokenize(doc string) ([]string, error) {
+	var toks []string
+	for i := 0; i < len(doc); {
+		c := doc[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			i++
+		case c == '#':
+			for i < len(doc) && doc[i] != '\n' {
+				i++
+			}
+		case strings.HasPrefix(doc[i:], `"""`):
+			end := strings.Index(doc[i+3:], `"""`)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated block string")
+			}
+			i += end + 6
+			toks = append(toks, `"`)
+		case c == '"':
+			i++
+			for i < len(doc) && doc[i] != '"' && doc[i] != '\n' {
+				if doc[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(doc) || doc[i] != '"' {
+				return nil, fmt.Errorf("unterminated string")
+			}
+			i++
+			toks = append(toks, `"`)
+		case strings.HasPrefix(doc[i:], "..."):
+			toks = append(toks, "...")
+			i += 3
+		case isNameStart(c) || c == '-' || (c >= '0' && c <= '9'):
+			j := i + 1
+			for j < len(doc) && (isNameStart(doc[j]) || doc[j] == '.' || (doc[j] >= '0' && doc[j] <= '9')) {
+				j++
+			}
+			toks = append(toks, doc[i:j])
+			i = j
+		default:
+			toks = append(toks, string(c))
+			i++
+		}
+	}
+	return toks, nil
+}
+
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isName(tok string) bool {
+	return tok != "" && isNameStart(tok[0])
+}
@@ -0,0 +1,210 @@
+package smoketest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+// collection is the part of a Postman v2.1 collection that smoke tests use.
+type collection struct {
+	Variable []keyValue `json:"variable"`
+	Item     []item     `json:"item"`
+}
+
+// item is a request or, if it has items of its own, a folder.
+type item struct {
+	Name    string   `json:"name"`
+	Item    []item   `json:"item"`
+	Request *request `json:"request"`
+	// Response holds saved example responses. A request passes if its
+	// status matches one of them, or is below 400 if there are none.
+	Response []struct {
+		Code int `json:"code"`
+	} `json:"response"`
+}
+
+type request struct {
+	Method string          `json:"method"`
+	URL    json.RawMessage `json:"url"`
+	Header []keyValue      `json:"header"`
+	Body   *struct {
+		Mode string `json:"mode"`
+		Raw  string `json:"raw"`
+	} `json:"body"`
+}
+
+type keyValue struct {
+	Key      string `json:"key"`
+	Value    string `json:"value"`
+	Disabled bool   `json:"disabled"`
+}
+
+// requestURL is a Postman URL, which is either a string or an object.
+type requestURL struct {
+	Raw   string     `json:"raw"`
+	Host  []string   `json:"host"`
+	Path  []string   `json:"path"`
+	Query []keyValue `json:"query"`
+}
+
+// variablePattern matches a {{variable}} reference.
+var variablePattern = regexp.MustCompile(`{{\s*([^{}]+?)\s*}}`)
+
+// runCollection sends every request in a postman-collection package.
+func (r *Runner) runCollection(ctx context.Context, baseURL string, pkg model.Package, report *model.SmokeTestReport) {
+	var c collection
+	if err := json.Unmarshal([]byte(pkg.Schema), &c); err != nil {
+		report.Add(model.SmokeTestResult{
+			Package: pkg.Name,
+			Outcome: model.SmokeTestFailed,
+			Error:   fmt.Sprintf("parse collection: %v", err),
+		})
+		return
+	}
+
+	// The collection's own baseUrl points at wherever it was written
+	// against; the environment's preview URL replaces it.
+	vars := make(map[string]string, len(c.Variable)+1)
+	for _, v := range c.Variable {
+		if !v.Disabled {
+			vars[v.Key] = v.Value
+		}
+	}
+	vars["baseUrl"] = baseURL
+
+	var walk func(items []item, prefix string)
+	walk = func(items []item, prefix string) {
+		for _, it := range items {
+			name := prefix + it.Name
+			if it.Request == nil {
+				walk(it.Item, name+" / ")
+				continue
+			}
+			report.Add(r.runRequest(ctx, pkg.Name, name, it, vars))
+		}
+	}
+	walk(c.Item, "")
+}
+
+// runRequest sends one collection request.
+func (r *Runner) runRequest(ctx context.Context, pkgName, name string, it item, vars map[string]string) model.SmokeTestResult {
+	res := model.SmokeTestResult{Package: pkgName, Name: name, Method: strings.ToUpper(it.Request.Method)}
+	if res.Method == "" {
+		res.Method = http.MethodGet
+	}
+
+	var unresolved []string
+	expand := func(s string) string {
+		return variablePattern.ReplaceAllStringFunc(s, func(ref string) string {
+			key := variablePattern.FindStringSubmatch(ref)[1]
+			v, ok := vars[key]
+			if !ok {
+				unresolved = append(unresolved, key)
+				return ref
+			}
+			return v
+		})
+	}
+
+	rawURL, err := it.Request.url()
+	if err != nil {
+		res.Outcome = model.SmokeTestFailed
+		res.Error = err.Error()
+		return res
+	}
+	res.URL = expand(rawURL)
+	var body string
+	if b := it.Request.Body; b != nil && b.Mode == "raw" {
+		body = expand(b.Raw)
+	}
+	headers := make(http.Header)
+	for _, h := range it.Request.Header {
+		if !h.Disabled {
+			headers.Add(h.Key, expand(h.Value))
+		}
+	}
+	if len(unresolved) > 0 {
+		slices.Sort(unresolved)
+		res.Outcome = model.SmokeTestSkipped
+		res.Error = "unresolved variables: " + strings.Join(slices.Compact(unresolved), ", ")
+		return res
+	}
+
+	req, err := http.NewRequestWithContext(ctx, res.Method, res.URL, strings.NewReader(body))
+	if err != nil {
+		res.Outcome = model.SmokeTestFailed
+		res.Error = err.Error()
+		return res
+	}
+	req.Header = headers
+
+	r.send(req, &res)
+	if res.Outcome == model.SmokeTestFailed {
+		return res
+	}
+
+	res.Outcome = model.SmokeTestPassed
+	if len(it.Response) > 0 {
+		codes := make([]int, len(it.Response))
+		for i, example := range it.Response {
+			codes[i] = example.Code
+		}
+		if !slices.Contains(codes, res.StatusCode) {
+			res.Outcome = model.SmokeTestFailed
+			res.Error = fmt.Sprintf("expected status %s, got %d", joinInts(codes), res.StatusCode)
+		}
+	} else if res.StatusCode >= 400 {
+		res.Outcome = model.SmokeTestFailed
+		res.Error = fmt.Sprintf("got status %d", res.StatusCode)
+	}
+	return res
+}
+
+// url returns the request's URL as a string, assembling it from its parts
+// if it has no raw form.
+func (req *request) url() (string, error) {
+	if len(req.URL) == 0 {
+		return "", fmt.Errorf("request has no URL")
+	}
+	var s string
+	if err := json.Unmarshal(req.URL, &s); err == nil {
+		return s, nil
+	}
+	var u requestURL
+	if err := json.Unmarshal(req.URL, &u); err != nil {
+		return "", fmt.Errorf("parse URL: %w", err)
+	}
+	if u.Raw != "" {
+		return u.Raw, nil
+	}
+	s = strings.Join(u.Host, ".")
+	if len(u.Path) > 0 {
+		s += "/" + strings.Join(u.Path, "/")
+	}
+	q := url.Values{}
+	for _, kv := range u.Query {
+		if !kv.Disabled {
+			q.Add(kv.Key, kv.Value)
+		}
+	}
+	if len(q) > 0 {
+		s += "?" + q.Encode()
+	}
+	return s, nil
+}
+
+func joinInts(ns []int) string {
+	s := make([]string, len(ns))
+	for i, n := range ns {
+		s[i] = fmt.Sprint(n)
+	}
+	return strings.Join(s, " or ")
+}
@@ -0,0 +1,101 @@
+// Package smoketest runs an environment's postman-collection and
+// graphql-operations packages against its preview URL after a deploy.
+package smoketest
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+var tracer = otel.Tracer("envmanager/smoketest")
+
+// GraphQLPath is where graphql-operations are sent, relative to the
+// preview URL.
+const GraphQLPath = "/graphql"
+
+// maxResponseBody caps how much of a response is read.
+const maxResponseBody = 1 << 20
+
+// Runner sends smoke test requests.
+type Runner struct {
+	httpClient *http.Client
+}
+
+// New creates a Runner. If httpClient is nil, a client with a 10s timeout per
+// request and trace propagation is used.
+func New(httpClient *http.Client) *Runner {
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		}
+	}
+	return &Runner{httpClient: httpClient}
+}
+
+// Run sends every request in pkgs to baseURL, in order, and reports each
+// one's outcome. Packages of other kinds are ignored. A package that does
+// not parse is reported as a single failed result.
+func (r *Runner) Run(ctx context.Context, baseURL string, pkgs []model.Package) model.SmokeTestReport {
+	ctx, span := tracer.Start(ctx, "smoketest.Run",
+		trace.WithAttributes(attribute.String("base_url", baseURL)))
+	defer span.End()
+
+	baseURL = strings.TrimRight(baseURL, "/")
+	report := model.SmokeTestReport{
+		Results:   []model.SmokeTestResult{},
+		StartedAt: time.Now().UTC(),
+	}
+	for _, pkg := range pkgs {
+		switch pkg.Kind {
+		case model.KindPostmanCollection:
+			r.runCollection(ctx, baseURL, pkg, &report)
+		case model.KindGraphQLOperations:
+			r.runOperations(ctx, baseURL, pkg, &report)
+		}
+	}
+	report.CompletedAt = time.Now().UTC()
+
+	span.SetAttributes(
+		attribute.Int("smoketest.passed", report.Passed),
+		attribute.Int("smoketest.failed", report.Failed),
+		attribute.Int("smoketest.skipped", report.Skipped),
+	)
+	return report
+}
+
+// send performs req and fills in res's status, duration and, if the request
+// could not be sent, its error. It returns the response body, or nil if
+// there was no response.
+func (r *Runner) send(req *http.Request, res *model.SmokeTestResult) []byte {
+	start := time.Now()
+	defer func() { res.DurationMs = time.Since(start).Milliseconds() }()
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		res.Outcome = model.SmokeTestFailed
+		res.Error = err.Error()
+		return nil
+	}
+	defer resp.Body.Close()
+	res.StatusCode = resp.StatusCode
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxResponseBody))
+	if err != nil {
+		res.Outcome = model.SmokeTestFailed
+		res.Error = fmt.Sprintf("read response: %v", err)
+		return nil
+	}
+	return body
+}
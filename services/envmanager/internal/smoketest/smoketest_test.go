@@ -0,0 +1,190 @@
+package smoketest_test
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/smoketest"
+)
+
+// petstoreMockDir is hack/e2e/petstore-mock, the upstream of the end-to-end
+// tests, relative to this package.
+const petstoreMockDir = "../../../../hack/e2e/petstore-mock"
+
+// startPetstoreMock builds and starts hack/e2e/petstore-mock and returns its
+// base URL. The mock is its own module, outside the workspace.
+func startPetstoreMock(t *testing.T) string {
+	t.Helper()
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go tool not found")
+	}
+
+	bin := filepath.Join(t.TempDir(), "petstore-mock")
+	build := exec.Command(goTool, "build", "-o", bin, ".")
+	build.Dir = petstoreMockDir
+	build.Env = append(os.Environ(), "GOWORK=off")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build petstore-mock: %v\n%s", err, out)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("find a free port: %v", err)
+	}
+	port := l.Addr().(*net.TCPAddr).Port
+	l.Close()
+
+	mock := exec.Command(bin)
+	mock.Env = append(os.Environ(),
+		"PORT="+strconv.Itoa(port),
+		// Nothing collects the mock's spans.
+		"OTEL_EXPORTER_OTLP_ENDPOINT=http://127.0.0.1:1",
+	)
+	if err := mock.Start(); err != nil {
+		t.Fatalf("start petstore-mock: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = mock.Process.Kill()
+		_ = mock.Wait()
+	})
+
+	baseURL := "http://127.0.0.1:" + strconv.Itoa(port)
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		resp, err := http.Get(baseURL + "/healthz")
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				return baseURL
+			}
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("petstore-mock did not become healthy: %v", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+const petstoreCollection = `{
+  "info": {"name": "Petstore"},
+  "variable": [{"key": "baseUrl", "value": "https://petstore.example.com"}],
+  "item": [
+    {"name": "Health", "request": {"method": "GET", "url": "{{baseUrl}}/healthz"}},
+    {
+      "name": "Pets",
+      "item": [
+        {"name": "List pets", "request": {"method": "GET", "url": {"raw": "{{baseUrl}}/pets?status=available", "host": ["{{baseUrl}}"], "path": ["pets"]}}},
+        {"name": "Get a pet", "request": {"method": "GET", "url": {"host": ["{{baseUrl}}"], "path": ["pets", "1"]}}},
+        {"name": "Get a missing pet", "request": {"method": "GET", "url": "{{baseUrl}}/pets/99"}},
+        {"name": "Missing pet contract", "request": {"method": "GET", "url": "{{baseUrl}}/pets/99"}, "response": [{"code": 404}]},
+        {"name": "Authenticated", "request": {"method": "GET", "url": "{{baseUrl}}/pets", "header": [{"key": "Authorization", "value": "Bearer {{token}}"}]}}
+      ]
+    }
+  ]
+}`
+
+const operations = `
+# Queries
+"""List everything."""
+query ListPets($limit: Int = 20) {
+  pets(limit: $limit) { id name ...PetFields }
+}
+
+query GetPet($id: ID!) { pet(id: $id) { id } }
+
+mutation AdoptPet { adopt(id: "1") { id } }
+
+fragment PetFields on Pet { species }
+`
+
+func TestRun_AgainstPetstoreMock(t *testing.T) {
+	baseURL := startPetstoreMock(t)
+	runner := smoketest.New(http.DefaultClient)
+
+	report := runner.Run(context.Background(), baseURL+"/", []model.Package{
+		{Name: "petstore/client", Kind: model.KindPostmanCollection, Schema: petstoreCollection},
+		{Name: "petstore/operations", Kind: model.KindGraphQLOperations, Schema: operations},
+		{Name: "petstore/api", Kind: "openapi-service", Schema: "openapi: 3.0.0"},
+	})
+
+	want := []struct {
+		name    string
+		outcome model.SmokeTestOutcome
+		status  int
+	}{
+		{"Health", model.SmokeTestPassed, 200},
+		{"Pets / List pets", model.SmokeTestPassed, 200},
+		{"Pets / Get a pet", model.SmokeTestPassed, 200},
+		{"Pets / Get a missing pet", model.SmokeTestFailed, 404},
+		{"Pets / Missing pet contract", model.SmokeTestPassed, 404},
+		{"Pets / Authenticated", model.SmokeTestSkipped, 0},
+		{"ListPets", model.SmokeTestPassed, 200},
+		{"GetPet", model.SmokeTestSkipped, 0},
+		{"AdoptPet", model.SmokeTestSkipped, 0},
+	}
+	if len(report.Results) != len(want) {
+		t.Fatalf("expected %d results, got %+v", len(want), report.Results)
+	}
+	for i, w := range want {
+		got := report.Results[i]
+		if got.Name != w.name || got.Outcome != w.outcome || got.StatusCode != w.status {
+			t.Errorf("result %d: expected %s %s (%d), got %s %s (%d): %s",
+				i, w.name, w.outcome, w.status, got.Name, got.Outcome, got.StatusCode, got.Error)
+		}
+	}
+	if report.Passed != 5 || report.Failed != 1 || report.Skipped != 3 {
+		t.Fatalf("unexpected counts: %s", report.Summary())
+	}
+	if got := report.Results[1].URL; got != baseURL+"/pets?status=available" {
+		t.Fatalf("expected baseUrl to be replaced by the preview URL, got %s", got)
+	}
+	if got := report.Results[6].URL; got != baseURL+smoketest.GraphQLPath {
+		t.Fatalf("expected operations to be sent to the GraphQL endpoint, got %s", got)
+	}
+}
+
+func TestRun_GraphQLErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			OperationName string `json:"operationName"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if req.OperationName == "Broken" {
+			_, _ = w.Write([]byte(`{"errors":[{"message":"Cannot query field \"nope\""}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"data":{"ok":true}}`))
+	}))
+	defer srv.Close()
+
+	report := smoketest.New(srv.Client()).Run(context.Background(), srv.URL, []model.Package{
+		{Name: "ops", Kind: model.KindGraphQLOperations, Schema: `query Works { ok } query Broken { nope }`},
+	})
+	if report.Passed != 1 || report.Failed != 1 {
+		t.Fatalf("expected 1 passed and 1 failed, got %+v", report.Results)
+	}
+	if got := report.Results[1].Error; got != `Cannot query field "nope"` {
+		t.Fatalf("expected the GraphQL error, got %q", got)
+	}
+}
+
+func TestRun_InvalidPackage(t *testing.T) {
+	report := smoketest.New(nil).Run(context.Background(), "http://localhost", []model.Package{
+		{Name: "bad-collection", Kind: model.KindPostmanCollection, Schema: "not json"},
+		{Name: "bad-operations", Kind: model.KindGraphQLOperations, Schema: "query { unclosed"},
+	})
+	if report.Failed != 2 {
+		t.Fatalf("expected both packages to fail, got %+v", report.Results)
+	}
+}
@@ -0,0 +1,300 @@
+package store
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+// FileStore is a MemoryStore that snapshots its contents to a JSON file after
+// every write, so environments, their pending operations, their encrypted
+// secrets, templates and completed idempotent requests survive restarts.
+// Events are only ever added, so rather than rewriting them with every
+// snapshot each one is appended as a line of a JSON Lines file alongside it.
+// It is intended for single-replica deployments.
+type FileStore struct {
+	*MemoryStore
+
+	path       string
+	eventsPath string
+	// mu serialises snapshot writes and event appends.
+	mu sync.Mutex
+}
+
+// fileSnapshot is the on-disk format of a FileStore. Events are written to
+// the event log; snapshots from before it existed may still hold some.
+type fileSnapshot struct {
+	Environments []model.Environment       `json:"environments"`
+	Events       []model.Event             `json:"events,omitempty"`
+	Secrets      map[string][]model.Secret `json:"secrets,omitempty"`
+	Templates    []model.Template          `json:"templates,omitempty"`
+	Idempotency  []IdempotencyRecord       `json:"idempotency,omitempty"`
+}
+
+// NewFileStore opens (or creates) a file-backed store at path. Its events are
+// kept in path with its extension replaced by ".events.jsonl".
+func NewFileStore(path string) (*FileStore, error) {
+	fs := &FileStore{
+		MemoryStore: NewMemoryStore(),
+		path:        path,
+		eventsPath:  strings.TrimSuffix(path, filepath.Ext(path)) + ".events.jsonl",
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return fs, fs.loadEvents()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read store file: %w", err)
+	}
+
+	var snap fileSnapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return nil, fmt.Errorf("decode store file: %w", err)
+	}
+	for _, env := range snap.Environments {
+		if _, err := fs.MemoryStore.Create(context.Background(), env); err != nil {
+			return nil, fmt.Errorf("load environment %s: %w", env.ID, err)
+		}
+	}
+	for envID, secrets := range snap.Secrets {
+		for _, secret := range secrets {
+			if err := fs.MemoryStore.PutSecret(context.Background(), envID, secret); err != nil {
+				return nil, fmt.Errorf("load secret %s/%s: %w", envID, secret.Name, err)
+			}
+		}
+	}
+	for _, t := range snap.Templates {
+		if err := fs.MemoryStore.PutTemplate(context.Background(), t); err != nil {
+			return nil, fmt.Errorf("load template %s: %w", t.Name, err)
+		}
+	}
+	for _, rec := range snap.Idempotency {
+		fs.MemoryStore.restoreIdempotency(rec)
+	}
+
+	for _, ev := range snap.Events {
+		fs.MemoryStore.restoreEvent(ev)
+	}
+	if err := fs.loadEvents(); err != nil {
+		return nil, err
+	}
+	if len(snap.Events) > 0 {
+		// Move the events of an older snapshot to the event log. The log is
+		// replaced before the snapshot, so if this is interrupted it is
+		// simply done again on the next open.
+		if err := fs.rewriteEvents(); err != nil {
+			return nil, err
+		}
+		if err := fs.flush(); err != nil {
+			return nil, err
+		}
+	}
+	return fs, nil
+}
+func (f *FileStore) Create(ctx context.Context, env model.Environment) (model.Environment, error) {
+	created, err := f.MemoryStore.Create(ctx, env)
+	if err != nil {
+		return model.Environment{}, err
+	}
+	return created, f.flush()
+}
+
+func (f *FileStore) Update(ctx context.Context, env model.Environment) (model.Environment, error) {
+	updated, err := f.MemoryStore.Update(ctx, env)
+	if err != nil {
+		return model.Environment{}, err
+	}
+	return updated, f.flush()
+}
+
+func (f *FileStore) Delete(ctx context.Context, id string) error {
+	if err := f.MemoryStore.Delete(ctx, id); err != nil {
+		return err
+	}
+	return f.flush()
+}
+
+// Append records an event and adds it to the end of the event log. The
+// snapshot is not rewritten.
+func (f *FileStore) Append(ctx context.Context, ev model.Event) (model.Event, error) {
+	// Hold mu across both so that the log is in ID order.
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	appended, err := f.MemoryStore.Append(ctx, ev)
+	if err != nil {
+		return model.Event{}, err
+	}
+	return appended, f.writeEvents([]model.Event{appended})
+}
+
+func (f *FileStore) PutSecret(ctx context.Context, envID string, secret model.Secret) error {
+	if err := f.MemoryStore.PutSecret(ctx, envID, secret); err != nil {
+		return err
+	}
+	return f.flush()
+}
+
+func (f *FileStore) DeleteSecret(ctx context.Context, envID, name string) error {
+	if err := f.MemoryStore.DeleteSecret(ctx, envID, name); err != nil {
+		return err
+	}
+	return f.flush()
+}
+
+func (f *FileStore) DeleteSecrets(ctx context.Context, envID string) error {
+	if err := f.MemoryStore.DeleteSecrets(ctx, envID); err != nil {
+		return err
+	}
+	return f.flush()
+}
+
+func (f *FileStore) PutTemplate(ctx context.Context, t model.Template) error {
+	if err := f.MemoryStore.PutTemplate(ctx, t); err != nil {
+		return err
+	}
+	return f.flush()
+}
+
+func (f *FileStore) DeleteTemplate(ctx context.Context, name string) error {
+	if err := f.MemoryStore.DeleteTemplate(ctx, name); err != nil {
+		return err
+	}
+	return f.flush()
+}
+
+func (f *FileStore) Complete(ctx context.Context, key string, statusCode int, body []byte) error {
+	if err := f.MemoryStore.Complete(ctx, key, statusCode, body); err != nil {
+		return err
+	}
+	return f.flush()
+}
+
+// flush writes the current contents, other than events, to disk atomically
+// via a temp file.
+func (f *FileStore) flush() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	data, err := json.Marshal(fileSnapshot{
+		Environments: f.MemoryStore.snapshot(),
+		Secrets:      f.MemoryStore.secretSnapshot(),
+		Templates:    f.MemoryStore.templateSnapshot(),
+		Idempotency:  f.MemoryStore.idempotencySnapshot(),
+	})
+	if err != nil {
+		return fmt.Errorf("encode store file: %w", err)
+	}
+	return writeFileAtomic(f.path, data, "store file")
+}
+
+// writeFileAtomic replaces the file at path with data via a temp file. what
+// names the file in errors.
+func writeFileAtomic(path string, data []byte, what string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("write %s: %w", what, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("write %s: %w", what, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("write %s: %w", what, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("write %s: %w", what, err)
+	}
+	return nil
+}
+
+// loadEvents restores the events in the event log, skipping any the store
+// already has. A final line left incomplete by a crash mid-append is
+// truncated away so that later appends start on a line of their own.
+func (f *FileStore) loadEvents() error {
+	file, err := os.OpenFile(f.eventsPath, os.O_RDWR, 0)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read event log: %w", err)
+	}
+	defer file.Close()
+
+	dec := json.NewDecoder(file)
+	for {
+		var ev model.Event
+		err := dec.Decode(&ev)
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			if err := file.Truncate(dec.InputOffset()); err != nil {
+				return fmt.Errorf("truncate event log: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("decode event log: %w", err)
+		}
+		if seq, err := strconv.ParseUint(ev.ID, 10, 64); err == nil && seq <= f.MemoryStore.lastEventSeq() {
+			continue
+		}
+		f.MemoryStore.restoreEvent(ev)
+	}
+}
+
+// writeEvents appends events to the event log.
+func (f *FileStore) writeEvents(events []model.Event) error {
+	data, err := encodeEvents(events)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.eventsPath, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("write event log: %w", err)
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return fmt.Errorf("write event log: %w", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("write event log: %w", err)
+	}
+	return nil
+}
+
+// rewriteEvents replaces the event log with every event in the store.
+func (f *FileStore) rewriteEvents() error {
+	data, err := encodeEvents(f.MemoryStore.eventSnapshot())
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(f.eventsPath, data, "event log")
+}
+
+// encodeEvents encodes events as JSON Lines, one event per line.
+func encodeEvents(events []model.Event) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, ev := range events {
+		if err := enc.Encode(ev); err != nil {
+			return nil, fmt.Errorf("encode event: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
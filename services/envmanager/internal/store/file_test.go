@@ -0,0 +1,212 @@
+package store_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+func TestFileStore_PersistsAcrossReopen(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "envs.json")
+
+	s, err := store.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore: unexpected error: %v", err)
+	}
+
+	env := newEnv("env-1", "test-env")
+	env.Status = model.StatusDeleting
+	env.Pending = &model.PendingOperation{Type: model.OperationTeardown, Attempts: 1}
+	if _, err := s.Create(ctx, env); err != nil {
+		t.Fatalf("Create: unexpected error: %v", err)
+	}
+	if _, err := s.Create(ctx, newEnv("env-2", "other-env")); err != nil {
+		t.Fatalf("Create: unexpected error: %v", err)
+	}
+	if err := s.Delete(ctx, "env-2"); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+	if _, err := s.Append(ctx, model.Event{EnvironmentID: "env-2", Type: model.EventDeleted}); err != nil {
+		t.Fatalf("Append: unexpected error: %v", err)
+	}
+	rec := store.IdempotencyRecord{Key: "key-1", RequestHash: "hash-1", ExpiresAt: time.Now().Add(time.Hour)}
+	if _, _, err := s.Reserve(ctx, rec); err != nil {
+		t.Fatalf("Reserve: unexpected error: %v", err)
+	}
+	if err := s.Complete(ctx, "key-1", 201, []byte(`{}`)); err != nil {
+		t.Fatalf("Complete: unexpected error: %v", err)
+	}
+	if err := s.PutSecret(ctx, "env-1", model.Secret{Name: "token", Ciphertext: "sealed"}); err != nil {
+		t.Fatalf("PutSecret: unexpected error: %v", err)
+	}
+	tmpl := model.Template{Name: "checkout", TemplateSpec: model.TemplateSpec{BaseRootPackage: "root-pkg", TTL: "72h"}}
+	if err := s.PutTemplate(ctx, tmpl); err != nil {
+		t.Fatalf("PutTemplate: unexpected error: %v", err)
+	}
+
+	reopened, err := store.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore (reopen): unexpected error: %v", err)
+	}
+
+	got, err := reopened.Get(ctx, "env-1")
+	if err != nil {
+		t.Fatalf("Get: unexpected error: %v", err)
+	}
+	if got.Status != model.StatusDeleting {
+		t.Fatalf("Get: expected status deleting, got %s", got.Status)
+	}
+	if got.Pending == nil || got.Pending.Type != model.OperationTeardown {
+		t.Fatalf("Get: expected pending teardown, got %+v", got.Pending)
+	}
+
+	if _, err := reopened.Get(ctx, "env-2"); !errors.Is(err, store.ErrNotFound) {
+		t.Fatalf("Get deleted: expected ErrNotFound, got %v", err)
+	}
+
+	events, err := reopened.ListEvents(ctx, "env-2", store.EventFilter{})
+	if err != nil {
+		t.Fatalf("ListEvents: unexpected error: %v", err)
+	}
+	if len(events.Events) != 1 || events.Events[0].Type != model.EventDeleted {
+		t.Fatalf("ListEvents: expected the deleted event, got %+v", events.Events)
+	}
+
+	replay, reserved, err := reopened.Reserve(ctx, rec)
+	if err != nil || reserved || replay.StatusCode != 201 {
+		t.Fatalf("Reserve: expected the completed request, got %+v %v %v", replay, reserved, err)
+	}
+
+	secrets, err := reopened.ListSecrets(ctx, "env-1")
+	if err != nil || len(secrets) != 1 || secrets[0].Ciphertext != "sealed" {
+		t.Fatalf("ListSecrets: expected the stored secret, got %+v %v", secrets, err)
+	}
+
+	if got, err := reopened.GetTemplate(ctx, "checkout"); err != nil || got.TTL != "72h" {
+		t.Fatalf("GetTemplate: expected the stored template, got %+v %v", got, err)
+	}
+
+	// New events continue the sequence rather than reusing IDs.
+	appended, err := reopened.Append(ctx, model.Event{EnvironmentID: "env-1", Type: model.EventCreated})
+	if err != nil {
+		t.Fatalf("Append: unexpected error: %v", err)
+	}
+	if appended.ID == events.Events[0].ID {
+		t.Fatalf("Append: reused event ID %s", appended.ID)
+	}
+}
+
+func TestFileStore_MissingFileStartsEmpty(t *testing.T) {
+	s, err := store.NewFileStore(filepath.Join(t.TempDir(), "missing.json"))
+	if err != nil {
+		t.Fatalf("NewFileStore: unexpected error: %v", err)
+	}
+
+	result, err := s.List(context.Background(), store.ListFilter{})
+	if err != nil {
+		t.Fatalf("List: unexpected error: %v", err)
+	}
+	if len(result.Environments) != 0 {
+		t.Fatalf("List: expected 0 environments, got %d", len(result.Environments))
+	}
+}
+
+func TestFileStore_AppendsEventsToLog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "envs.json")
+	logPath := filepath.Join(dir, "envs.events.jsonl")
+
+	s, err := store.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore: unexpected error: %v", err)
+	}
+	if _, err := s.Create(ctx, newEnv("env-1", "test-env")); err != nil {
+		t.Fatalf("Create: unexpected error: %v", err)
+	}
+	snapshot, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ReadFile: unexpected error: %v", err)
+	}
+	for _, typ := range []model.EventType{model.EventCreated, model.EventBuildTriggered} {
+		if _, err := s.Append(ctx, model.Event{EnvironmentID: "env-1", Type: typ}); err != nil {
+			t.Fatalf("Append: unexpected error: %v", err)
+		}
+	}
+
+	// Appending an event leaves the snapshot alone.
+	if after, _ := os.ReadFile(path); !bytes.Equal(after, snapshot) {
+		t.Fatalf("Append: rewrote the snapshot: %s", after)
+	}
+	log, err := os.ReadFile(logPath)
+	if err != nil {
+		t.Fatalf("ReadFile: unexpected error: %v", err)
+	}
+	if n := bytes.Count(log, []byte("\n")); n != 2 {
+		t.Fatalf("expected 2 lines in the event log, got %d: %s", n, log)
+	}
+
+	// A line cut short by a crash mid-append is dropped, and later events
+	// start on a line of their own.
+	torn := append(log, []byte(`{"id":"3","environmentId":"env-1","ty`)...)
+	if err := os.WriteFile(logPath, torn, 0o600); err != nil {
+		t.Fatalf("WriteFile: unexpected error: %v", err)
+	}
+	reopened, err := store.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore (reopen): unexpected error: %v", err)
+	}
+	if _, err := reopened.Append(ctx, model.Event{EnvironmentID: "env-1", Type: model.EventDeploySucceeded}); err != nil {
+		t.Fatalf("Append: unexpected error: %v", err)
+	}
+	reopened, err = store.NewFileStore(path)
+	if err != nil {
+		t.Fatalf("NewFileStore (reopen): unexpected error: %v", err)
+	}
+	page, err := reopened.ListEvents(ctx, "env-1", store.EventFilter{})
+	if err != nil {
+		t.Fatalf("ListEvents: unexpected error: %v", err)
+	}
+	if len(page.Events) != 3 || page.Events[0].Type != model.EventDeploySucceeded || page.Events[0].ID != "3" {
+		t.Fatalf("ListEvents: expected the three complete events, got %+v", page.Events)
+	}
+}
+
+func TestFileStore_MovesSnapshotEventsToLog(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "envs.json")
+
+	// A snapshot written before events had a log of their own.
+	legacy := `{"environments":[],"events":[` +
+		`{"id":"1","environmentId":"env-1","type":"created"},` +
+		`{"id":"2","environmentId":"env-1","type":"deleted"}]}`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatalf("WriteFile: unexpected error: %v", err)
+	}
+
+	for range 2 {
+		s, err := store.NewFileStore(path)
+		if err != nil {
+			t.Fatalf("NewFileStore: unexpected error: %v", err)
+		}
+		page, err := s.ListEvents(ctx, "env-1", store.EventFilter{})
+		if err != nil {
+			t.Fatalf("ListEvents: unexpected error: %v", err)
+		}
+		if len(page.Events) != 2 || page.Events[0].Type != model.EventDeleted {
+			t.Fatalf("ListEvents: expected the snapshot's events once, got %+v", page.Events)
+		}
+	}
+	if snapshot, _ := os.ReadFile(path); bytes.Contains(snapshot, []byte(`"events"`)) {
+		t.Fatalf("expected the events moved out of the snapshot, got %s", snapshot)
+	}
+}
@@ -1,26 +1,51 @@
 package store
 
 import (
+	"cmp"
 	"context"
+	"fmt"
+	"maps"
+	"slices"
 	"sort"
+	"strconv"
 	"sync"
+	"time"
 
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
 )
 
-// MemoryStore is a thread-safe in-memory implementation of Store.
-// Suitable for development and testing.
+// MemoryStore is a thread-safe in-memory implementation of Store, EventLog,
+// SecretStore, TemplateStore and IdempotencyStore. Suitable for development
+// and testing.
 type MemoryStore struct {
 	mu   sync.RWMutex
 	envs map[string]model.Environment
 	// order preserves insertion order for deterministic listing.
 	order []string
+
+	// events holds each environment's events, oldest first. eventSeq is the
+	// ID of the most recently appended event.
+	events   map[string][]model.Event
+	eventSeq uint64
+
+	// secrets holds each environment's secrets by name.
+	secrets map[string]map[string]model.Secret
+
+	// templates holds templates by name.
+	templates map[string]model.Template
+
+	// idempotency holds idempotency records by key.
+	idempotency map[string]IdempotencyRecord
 }
 
 // NewMemoryStore creates a new empty in-memory store.
 func NewMemoryStore() *MemoryStore {
 	return &MemoryStore{
-		envs: make(map[string]model.Environment),
+		envs:        make(map[string]model.Environment),
+		events:      make(map[string][]model.Event),
+		secrets:     make(map[string]map[string]model.Secret),
+		templates:   make(map[string]model.Template),
+		idempotency: make(map[string]IdempotencyRecord),
 	}
 }
 
@@ -32,7 +57,7 @@ func (m *MemoryStore) Create(_ context.Context, env model.Environment) (model.En
 		return model.Environment{}, ErrAlreadyExists
 	}
 
-	m.envs[env.ID] = env
+	m.envs[env.ID] = env.Clone()
 	m.order = append(m.order, env.ID)
 	return env, nil
 }
@@ -45,7 +70,7 @@ func (m *MemoryStore) Get(_ context.Context, id string) (model.Environment, erro
 	if !ok {
 		return model.Environment{}, ErrNotFound
 	}
-	return env, nil
+	return env.Clone(), nil
 }
 
 func (m *MemoryStore) List(_ context.Context, filter ListFilter) (ListResult, error) {
@@ -62,13 +87,22 @@ func (m *MemoryStore) List(_ context.Context, filter ListFilter) (ListResult, er
 		if filter.CreatedBy != "" && env.CreatedBy != filter.CreatedBy {
 			continue
 		}
-		all = append(all, env)
+		if filter.ParentID != "" && env.ParentID != filter.ParentID {
+			continue
+		}
+		if filter.Template != "" && env.Template != filter.Template {
+			continue
+		}
+		if len(filter.Statuses) > 0 && !slices.Contains(filter.Statuses, env.Status) {
+			continue
+		}
+		if !filter.Selector.Matches(env.Labels) {
+			continue
+		}
+		all = append(all, env.Clone())
 	}
 
-	// Sort by creation time descending (newest first).
-	sort.Slice(all, func(i, j int) bool {
-		return all[i].CreatedAt.After(all[j].CreatedAt)
-	})
+	sortEnvironments(all, filter.Sort)
 
 	// Apply pagination.
 	pageSize := filter.PageSize
@@ -104,6 +138,34 @@ func (m *MemoryStore) List(_ context.Context, filter ListFilter) (ListResult, er
 	}, nil
 }
 
+// sortEnvironments orders envs by s, breaking ties by ID so pagination is
+// stable. The zero Sort is newest first.
+func sortEnvironments(envs []model.Environment, s Sort) {
+	if s.Field == "" {
+		s = Sort{Field: SortByCreatedAt, Descending: true}
+	}
+	slices.SortStableFunc(envs, func(a, b model.Environment) int {
+		var c int
+		switch s.Field {
+		case SortByCreatedAt:
+			c = a.CreatedAt.Compare(b.CreatedAt)
+		case SortByUpdatedAt:
+			c = a.UpdatedAt.Compare(b.UpdatedAt)
+		case SortByName:
+			c = cmp.Compare(a.Name, b.Name)
+		case SortByStatus:
+			c = cmp.Compare(a.Status, b.Status)
+		}
+		if s.Descending {
+			c = -c
+		}
+		if c == 0 {
+			c = cmp.Compare(a.ID, b.ID)
+		}
+		return c
+	})
+}
+
 func (m *MemoryStore) Update(_ context.Context, env model.Environment) (model.Environment, error) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
@@ -112,7 +174,7 @@ func (m *MemoryStore) Update(_ context.Context, env model.Environment) (model.En
 		return model.Environment{}, ErrNotFound
 	}
 
-	m.envs[env.ID] = env
+	m.envs[env.ID] = env.Clone()
 	return env, nil
 }
 
@@ -134,3 +196,268 @@ func (m *MemoryStore) Delete(_ context.Context, id string) error {
 	}
 	return nil
 }
+
+// snapshot returns copies of all environments in insertion order.
+func (m *MemoryStore) snapshot() []model.Environment {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	all := make([]model.Environment, 0, len(m.order))
+	for _, id := range m.order {
+		all = append(all, m.envs[id].Clone())
+	}
+	return all
+}
+
+func (m *MemoryStore) Append(_ context.Context, ev model.Event) (model.Event, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.eventSeq++
+	ev.ID = strconv.FormatUint(m.eventSeq, 10)
+	m.events[ev.EnvironmentID] = append(m.events[ev.EnvironmentID], ev.Clone())
+	return ev, nil
+}
+
+func (m *MemoryStore) ListEvents(_ context.Context, envID string, filter EventFilter) (EventPage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	events := m.events[envID]
+	pageSize := filter.PageSize
+	if pageSize <= 0 {
+		pageSize = 50
+	}
+
+	// Walk backwards from the newest event, or from just before the token.
+	start := len(events) - 1
+	if filter.PageToken != "" {
+		i := slices.IndexFunc(events, func(ev model.Event) bool { return ev.ID == filter.PageToken })
+		if i < 0 {
+			return EventPage{}, fmt.Errorf("%w %q", ErrInvalidPageToken, filter.PageToken)
+		}
+		start = i - 1
+	}
+
+	page := make([]model.Event, 0, min(pageSize, start+1))
+	for i := start; i >= 0 && len(page) < pageSize; i-- {
+		page = append(page, events[i].Clone())
+	}
+
+	var nextToken string
+	if len(page) > 0 && start+1 > len(page) {
+		nextToken = page[len(page)-1].ID
+	}
+	return EventPage{Events: page, NextPageToken: nextToken}, nil
+}
+
+// eventSnapshot returns copies of all events in the order they were appended.
+func (m *MemoryStore) eventSnapshot() []model.Event {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var all []model.Event
+	for _, events := range m.events {
+		for _, ev := range events {
+			all = append(all, ev.Clone())
+		}
+	}
+	sort.Slice(all, func(i, j int) bool {
+		a, _ := strconv.ParseUint(all[i].ID, 10, 64)
+		b, _ := strconv.ParseUint(all[j].ID, 10, 64)
+		return a < b
+	})
+	return all
+}
+
+// lastEventSeq returns the ID of the most recently appended event, or 0.
+func (m *MemoryStore) lastEventSeq() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.eventSeq
+}
+
+// restoreEvent adds a previously appended event, keeping its ID.
+func (m *MemoryStore) restoreEvent(ev model.Event) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.events[ev.EnvironmentID] = append(m.events[ev.EnvironmentID], ev.Clone())
+	if seq, err := strconv.ParseUint(ev.ID, 10, 64); err == nil && seq > m.eventSeq {
+		m.eventSeq = seq
+	}
+}
+
+func (m *MemoryStore) PutSecret(_ context.Context, envID string, secret model.Secret) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.secrets[envID] == nil {
+		m.secrets[envID] = make(map[string]model.Secret)
+	}
+	m.secrets[envID][secret.Name] = secret
+	return nil
+}
+
+func (m *MemoryStore) ListSecrets(_ context.Context, envID string) ([]model.Secret, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	secrets := slices.Collect(maps.Values(m.secrets[envID]))
+	slices.SortFunc(secrets, func(a, b model.Secret) int { return cmp.Compare(a.Name, b.Name) })
+	return secrets, nil
+}
+
+func (m *MemoryStore) DeleteSecret(_ context.Context, envID, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.secrets[envID][name]; !ok {
+		return ErrSecretNotFound
+	}
+	delete(m.secrets[envID], name)
+	if len(m.secrets[envID]) == 0 {
+		delete(m.secrets, envID)
+	}
+	return nil
+}
+
+func (m *MemoryStore) DeleteSecrets(_ context.Context, envID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.secrets, envID)
+	return nil
+}
+
+// secretSnapshot returns copies of every environment's secrets.
+func (m *MemoryStore) secretSnapshot() map[string][]model.Secret {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	all := make(map[string][]model.Secret, len(m.secrets))
+	for envID, secrets := range m.secrets {
+		list := slices.Collect(maps.Values(secrets))
+		slices.SortFunc(list, func(a, b model.Secret) int { return cmp.Compare(a.Name, b.Name) })
+		all[envID] = list
+	}
+	return all
+}
+
+func (m *MemoryStore) PutTemplate(_ context.Context, t model.Template) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.templates[t.Name] = t.Clone()
+	return nil
+}
+
+func (m *MemoryStore) GetTemplate(_ context.Context, name string) (model.Template, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	t, ok := m.templates[name]
+	if !ok {
+		return model.Template{}, ErrTemplateNotFound
+	}
+	return t.Clone(), nil
+}
+
+func (m *MemoryStore) ListTemplates(_ context.Context) ([]model.Template, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	templates := make([]model.Template, 0, len(m.templates))
+	for _, name := range slices.Sorted(maps.Keys(m.templates)) {
+		templates = append(templates, m.templates[name].Clone())
+	}
+	return templates, nil
+}
+
+func (m *MemoryStore) DeleteTemplate(_ context.Context, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.templates[name]; !ok {
+		return ErrTemplateNotFound
+	}
+	delete(m.templates, name)
+	return nil
+}
+
+// templateSnapshot returns copies of every template, sorted by name.
+func (m *MemoryStore) templateSnapshot() []model.Template {
+	templates, _ := m.ListTemplates(context.Background())
+	return templates
+}
+
+func (m *MemoryStore) Reserve(_ context.Context, rec IdempotencyRecord) (IdempotencyRecord, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for key, r := range m.idempotency {
+		if !now.Before(r.ExpiresAt) {
+			delete(m.idempotency, key)
+		}
+	}
+	if existing, ok := m.idempotency[rec.Key]; ok {
+		return cloneRecord(existing), false, nil
+	}
+	rec.StatusCode, rec.Body = 0, nil
+	m.idempotency[rec.Key] = rec
+	return rec, true, nil
+}
+
+func (m *MemoryStore) Complete(_ context.Context, key string, statusCode int, body []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rec, ok := m.idempotency[key]
+	if !ok {
+		return fmt.Errorf("idempotency key %q is not reserved", key)
+	}
+	rec.StatusCode = statusCode
+	rec.Body = slices.Clone(body)
+	m.idempotency[key] = rec
+	return nil
+}
+
+func (m *MemoryStore) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.idempotency, key)
+	return nil
+}
+
+// idempotencySnapshot returns copies of the completed, unexpired idempotency
+// records. In-flight requests are left out: after a restart nothing is
+// running them.
+func (m *MemoryStore) idempotencySnapshot() []IdempotencyRecord {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	now := time.Now()
+	var all []IdempotencyRecord
+	for _, rec := range m.idempotency {
+		if rec.Completed() && now.Before(rec.ExpiresAt) {
+			all = append(all, cloneRecord(rec))
+		}
+	}
+	slices.SortFunc(all, func(a, b IdempotencyRecord) int { return cmp.Compare(a.Key, b.Key) })
+	return all
+}
+
+// restoreIdempotency adds a previously completed idempotency record.
+func (m *MemoryStore) restoreIdempotency(rec IdempotencyRecord) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.idempotency[rec.Key] = cloneRecord(rec)
+}
+
+func cloneRecord(rec IdempotencyRecord) IdempotencyRecord {
+	rec.Body = slices.Clone(rec.Body)
+	return rec
+}
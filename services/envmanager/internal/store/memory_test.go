@@ -2,9 +2,12 @@ package store_test
 
 import (
 	"context"
+	"errors"
+	"slices"
 	"testing"
 	"time"
 
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/labels"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
 )
@@ -43,6 +46,31 @@ func TestMemoryStore_CreateAndGet(t *testing.T) {
 	}
 }
 
+func TestMemoryStore_GetReturnsCopy(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+
+	env := newEnv("env-1", "test-env")
+	env.Overrides = []model.PackageOverride{{PackageName: "users"}}
+	if _, err := s.Create(ctx, env); err != nil {
+		t.Fatalf("Create: unexpected error: %v", err)
+	}
+
+	got, err := s.Get(ctx, "env-1")
+	if err != nil {
+		t.Fatalf("Get: unexpected error: %v", err)
+	}
+	got.Overrides[0].PackageName = "mutated"
+
+	again, err := s.Get(ctx, "env-1")
+	if err != nil {
+		t.Fatalf("Get: unexpected error: %v", err)
+	}
+	if again.Overrides[0].PackageName != "users" {
+		t.Fatalf("Get: expected stored override to be unaffected, got %s", again.Overrides[0].PackageName)
+	}
+}
+
 func TestMemoryStore_CreateDuplicate(t *testing.T) {
 	ctx := context.Background()
 	s := store.NewMemoryStore()
@@ -184,6 +212,84 @@ func TestMemoryStore_List(t *testing.T) {
 	}
 }
 
+func TestMemoryStore_ListLabelsStatusAndSort(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+
+	now := time.Now()
+	envs := []struct {
+		name   string
+		status model.EnvironmentStatus
+		labels map[string]string
+	}{
+		{"checkout", model.StatusReady, map[string]string{"team": "payments", "purpose": "feature"}},
+		{"billing-demo", model.StatusReady, map[string]string{"team": "payments", "purpose": "demo"}},
+		{"search", model.StatusFailed, map[string]string{"team": "search"}},
+		{"unlabelled", model.StatusBuilding, nil},
+	}
+	for i, e := range envs {
+		env := newEnv("env-"+string(rune('a'+i)), e.name)
+		env.Status = e.status
+		env.Labels = e.labels
+		env.CreatedAt = now.Add(time.Duration(i) * time.Second)
+		if _, err := s.Create(ctx, env); err != nil {
+			t.Fatalf("Create: unexpected error: %v", err)
+		}
+	}
+
+	names := func(filter store.ListFilter) []string {
+		t.Helper()
+		result, err := s.List(ctx, filter)
+		if err != nil {
+			t.Fatalf("List: unexpected error: %v", err)
+		}
+		var names []string
+		for _, env := range result.Environments {
+			names = append(names, env.Name)
+		}
+		return names
+	}
+	selector := func(sel string) labels.Selector {
+		t.Helper()
+		parsed, err := labels.Parse(sel)
+		if err != nil {
+			t.Fatalf("Parse: unexpected error: %v", err)
+		}
+		return parsed
+	}
+
+	tests := []struct {
+		name   string
+		filter store.ListFilter
+		want   []string
+	}{
+		{"default newest first", store.ListFilter{}, []string{"unlabelled", "search", "billing-demo", "checkout"}},
+		{"selector", store.ListFilter{Selector: selector("team=payments,purpose!=demo")}, []string{"checkout"}},
+		{"not in", store.ListFilter{Selector: selector("team notin (payments)")}, []string{"unlabelled", "search"}},
+		{"statuses", store.ListFilter{Statuses: []model.EnvironmentStatus{model.StatusFailed, model.StatusBuilding}}, []string{"unlabelled", "search"}},
+		{"by name", store.ListFilter{Sort: store.Sort{Field: store.SortByName}}, []string{"billing-demo", "checkout", "search", "unlabelled"}},
+		{"oldest first", store.ListFilter{Sort: store.Sort{Field: store.SortByCreatedAt}}, []string{"checkout", "billing-demo", "search", "unlabelled"}},
+		{"by status then ID", store.ListFilter{Sort: store.Sort{Field: store.SortByStatus, Descending: true}}, []string{"checkout", "billing-demo", "search", "unlabelled"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := names(tt.filter); !slices.Equal(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseSort(t *testing.T) {
+	got, err := store.ParseSort("-updated_at")
+	if err != nil || got != (store.Sort{Field: store.SortByUpdatedAt, Descending: true}) {
+		t.Fatalf("ParseSort: got %+v, %v", got, err)
+	}
+	if _, err := store.ParseSort("size"); !errors.Is(err, store.ErrInvalidSort) {
+		t.Fatalf("ParseSort: expected ErrInvalidSort, got %v", err)
+	}
+}
+
 func TestMemoryStore_ListPagination(t *testing.T) {
 	ctx := context.Background()
 	s := store.NewMemoryStore()
@@ -236,3 +342,183 @@ func TestMemoryStore_ListPagination(t *testing.T) {
 		t.Fatalf("List page 3: expected empty next page token, got %s", result.NextPageToken)
 	}
 }
+
+func TestMemoryStore_Events(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	if _, err := s.Create(ctx, newEnv("env-1", "test-env")); err != nil {
+		t.Fatalf("Create: unexpected error: %v", err)
+	}
+
+	types := []model.EventType{model.EventCreated, model.EventOverridesApplied, model.EventBuildTriggered, model.EventDeploySucceeded, model.EventDeleted}
+	for _, typ := range types {
+		if _, err := s.Append(ctx, model.Event{EnvironmentID: "env-1", Type: typ}); err != nil {
+			t.Fatalf("Append: unexpected error: %v", err)
+		}
+	}
+	if _, err := s.Append(ctx, model.Event{EnvironmentID: "env-2", Type: model.EventCreated}); err != nil {
+		t.Fatalf("Append: unexpected error: %v", err)
+	}
+	// Events outlive the environment.
+	if err := s.Delete(ctx, "env-1"); err != nil {
+		t.Fatalf("Delete: unexpected error: %v", err)
+	}
+
+	var got []model.EventType
+	token := ""
+	for pages := 0; ; pages++ {
+		page, err := s.ListEvents(ctx, "env-1", store.EventFilter{PageSize: 2, PageToken: token})
+		if err != nil {
+			t.Fatalf("ListEvents: unexpected error: %v", err)
+		}
+		if len(page.Events) > 2 || pages > 3 {
+			t.Fatalf("ListEvents: unexpected page %+v", page)
+		}
+		for _, ev := range page.Events {
+			got = append(got, ev.Type)
+		}
+		if page.NextPageToken == "" {
+			break
+		}
+		token = page.NextPageToken
+	}
+
+	if len(got) != len(types) {
+		t.Fatalf("ListEvents: expected %d events, got %v", len(types), got)
+	}
+	for i, typ := range got {
+		if want := types[len(types)-1-i]; typ != want {
+			t.Fatalf("ListEvents: expected newest first, got %v", got)
+		}
+	}
+
+	// A token that is not one of the environment's events does not restart
+	// from the newest event.
+	for _, token := range []string{"bogus", "6"} {
+		if _, err := s.ListEvents(ctx, "env-1", store.EventFilter{PageToken: token}); !errors.Is(err, store.ErrInvalidPageToken) {
+			t.Fatalf("ListEvents(%q): expected ErrInvalidPageToken, got %v", token, err)
+		}
+	}
+}
+
+func TestMemoryStore_Idempotency(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+	rec := store.IdempotencyRecord{Key: "key-1", RequestHash: "hash-1", ExpiresAt: time.Now().Add(time.Hour)}
+
+	if _, reserved, err := s.Reserve(ctx, rec); err != nil || !reserved {
+		t.Fatalf("Reserve: expected a reservation, got %v %v", reserved, err)
+	}
+	existing, reserved, err := s.Reserve(ctx, rec)
+	if err != nil || reserved {
+		t.Fatalf("Reserve again: expected the existing record, got %v %v", reserved, err)
+	}
+	if existing.Completed() {
+		t.Fatalf("Reserve again: expected an in-flight record, got %+v", existing)
+	}
+
+	if err := s.Complete(ctx, "key-1", 201, []byte(`{"id":"env-1"}`)); err != nil {
+		t.Fatalf("Complete: unexpected error: %v", err)
+	}
+	existing, _, _ = s.Reserve(ctx, rec)
+	if existing.StatusCode != 201 || string(existing.Body) != `{"id":"env-1"}` {
+		t.Fatalf("Reserve after Complete: expected the response, got %+v", existing)
+	}
+	if err := s.Complete(ctx, "missing", 200, nil); err == nil {
+		t.Fatal("Complete: expected an error for an unreserved key")
+	}
+
+	// Released and expired keys can be reserved again.
+	if err := s.Release(ctx, "key-1"); err != nil {
+		t.Fatalf("Release: unexpected error: %v", err)
+	}
+	if _, reserved, _ := s.Reserve(ctx, rec); !reserved {
+		t.Fatal("Reserve after Release: expected a reservation")
+	}
+	expired := store.IdempotencyRecord{Key: "key-2", RequestHash: "hash-2", ExpiresAt: time.Now().Add(-time.Second)}
+	s.Reserve(ctx, expired)
+	if _, reserved, _ := s.Reserve(ctx, expired); !reserved {
+		t.Fatal("Reserve after expiry: expected a reservation")
+	}
+}
+
+func TestMemoryStore_Secrets(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+
+	for _, name := range []string{"token", "api-key"} {
+		if err := s.PutSecret(ctx, "env-1", model.Secret{Name: name, Ciphertext: "sealed-" + name}); err != nil {
+			t.Fatalf("PutSecret: unexpected error: %v", err)
+		}
+	}
+	if err := s.PutSecret(ctx, "env-1", model.Secret{Name: "token", Ciphertext: "resealed"}); err != nil {
+		t.Fatalf("PutSecret (replace): unexpected error: %v", err)
+	}
+
+	got, err := s.ListSecrets(ctx, "env-1")
+	if err != nil {
+		t.Fatalf("ListSecrets: unexpected error: %v", err)
+	}
+	if len(got) != 2 || got[0].Name != "api-key" || got[1].Ciphertext != "resealed" {
+		t.Fatalf("ListSecrets: expected 2 secrets sorted by name, got %+v", got)
+	}
+	if other, _ := s.ListSecrets(ctx, "env-2"); len(other) != 0 {
+		t.Fatalf("ListSecrets: expected none for another environment, got %+v", other)
+	}
+
+	if err := s.DeleteSecret(ctx, "env-1", "token"); err != nil {
+		t.Fatalf("DeleteSecret: unexpected error: %v", err)
+	}
+	if err := s.DeleteSecret(ctx, "env-1", "token"); !errors.Is(err, store.ErrSecretNotFound) {
+		t.Fatalf("DeleteSecret again: expected ErrSecretNotFound, got %v", err)
+	}
+	if err := s.DeleteSecrets(ctx, "env-1"); err != nil {
+		t.Fatalf("DeleteSecrets: unexpected error: %v", err)
+	}
+	if got, _ := s.ListSecrets(ctx, "env-1"); len(got) != 0 {
+		t.Fatalf("DeleteSecrets: expected no secrets, got %+v", got)
+	}
+}
+
+func TestMemoryStore_Templates(t *testing.T) {
+	ctx := context.Background()
+	s := store.NewMemoryStore()
+
+	for _, name := range []string{"checkout", "api-only"} {
+		tmpl := model.Template{Name: name, TemplateSpec: model.TemplateSpec{
+			BaseRootPackage: "root-pkg",
+			Labels:          map[string]string{"team": "payments"},
+		}}
+		if err := s.PutTemplate(ctx, tmpl); err != nil {
+			t.Fatalf("PutTemplate: unexpected error: %v", err)
+		}
+	}
+
+	got, err := s.GetTemplate(ctx, "checkout")
+	if err != nil {
+		t.Fatalf("GetTemplate: unexpected error: %v", err)
+	}
+	// The store keeps its own copy.
+	got.Labels["team"] = "changed"
+	if again, _ := s.GetTemplate(ctx, "checkout"); again.Labels["team"] != "payments" {
+		t.Fatalf("GetTemplate: expected the stored labels unchanged, got %v", again.Labels)
+	}
+
+	list, err := s.ListTemplates(ctx)
+	if err != nil {
+		t.Fatalf("ListTemplates: unexpected error: %v", err)
+	}
+	if len(list) != 2 || list[0].Name != "api-only" || list[1].Name != "checkout" {
+		t.Fatalf("ListTemplates: expected 2 templates sorted by name, got %+v", list)
+	}
+
+	if err := s.DeleteTemplate(ctx, "checkout"); err != nil {
+		t.Fatalf("DeleteTemplate: unexpected error: %v", err)
+	}
+	if err := s.DeleteTemplate(ctx, "checkout"); !errors.Is(err, store.ErrTemplateNotFound) {
+		t.Fatalf("DeleteTemplate again: expected ErrTemplateNotFound, got %v", err)
+	}
+	if _, err := s.GetTemplate(ctx, "checkout"); !errors.Is(err, store.ErrTemplateNotFound) {
+		t.Fatalf("GetTemplate deleted: expected ErrTemplateNotFound, got %v", err)
+	}
+}
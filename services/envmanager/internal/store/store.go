@@ -4,24 +4,72 @@ package store
 import (
 	"context"
 	"errors"
+	"fmt"
+	"strings"
+	"time"
 
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/labels"
 	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
 )
 
 // Common errors returned by Store implementations.
 var (
-	ErrNotFound      = errors.New("environment not found")
-	ErrAlreadyExists = errors.New("environment already exists")
+	ErrNotFound       = errors.New("environment not found")
+	ErrAlreadyExists  = errors.New("environment already exists")
+	ErrInvalidSort    = errors.New("invalid sort")
+	ErrSecretNotFound = errors.New("secret not found")
+
+	ErrInvalidPageToken = errors.New("invalid page token")
+
+	ErrTemplateNotFound = errors.New("template not found")
 )
 
 // ListFilter holds optional filters for listing environments.
 type ListFilter struct {
 	Branch    string
 	CreatedBy string
+	ParentID  string
+	// Template keeps environments created from the named template.
+	Template string
+	// Selector keeps environments whose labels match it.
+	Selector labels.Selector
+	// Statuses, if set, keeps environments in any of these statuses.
+	Statuses  []model.EnvironmentStatus
+	Sort      Sort
 	PageSize  int
 	PageToken string
 }
 
+// SortField is a field environments can be listed in order of.
+type SortField string
+
+const (
+	SortByCreatedAt SortField = "created_at"
+	SortByUpdatedAt SortField = "updated_at"
+	SortByName      SortField = "name"
+	SortByStatus    SortField = "status"
+)
+
+// Sort orders listed environments. The zero value lists newest first.
+type Sort struct {
+	Field      SortField
+	Descending bool
+}
+
+// ParseSort parses a sort field, prefixed with "-" for descending order,
+// such as "name" or "-updated_at". An empty string is the zero Sort.
+func ParseSort(s string) (Sort, error) {
+	if s == "" {
+		return Sort{}, nil
+	}
+	field, desc := strings.CutPrefix(s, "-")
+	switch f := SortField(field); f {
+	case SortByCreatedAt, SortByUpdatedAt, SortByName, SortByStatus:
+		return Sort{Field: f, Descending: desc}, nil
+	}
+	return Sort{}, fmt.Errorf("%w %q: must be one of created_at, updated_at, name or status, optionally prefixed with -", ErrInvalidSort, s)
+}
+
 // ListResult holds the result of a list operation, including pagination.
 type ListResult struct {
 	Environments  []model.Environment
@@ -45,3 +93,97 @@ type Store interface {
 	// Delete removes an environment by ID. Returns ErrNotFound if it does not exist.
 	Delete(ctx context.Context, id string) error
 }
+
+// EventFilter selects a page of an environment's events.
+type EventFilter struct {
+	PageSize  int
+	PageToken string
+}
+
+// EventPage is a page of events, newest first.
+type EventPage struct {
+	Events        []model.Event
+	NextPageToken string
+}
+
+// EventLog is the append-only activity log of environments. Events are kept
+// after the environment they describe is deleted.
+type EventLog interface {
+	// Append records an event, assigning its ID.
+	Append(ctx context.Context, ev model.Event) (model.Event, error)
+
+	// ListEvents returns a page of an environment's events, newest first. A
+	// page token that is not one of the environment's events is
+	// ErrInvalidPageToken.
+	ListEvents(ctx context.Context, envID string, filter EventFilter) (EventPage, error)
+}
+
+// SecretStore holds environments' encrypted secrets. Secrets are stored
+// apart from environments so that their ciphertexts are never returned with
+// them.
+type SecretStore interface {
+	// PutSecret creates or replaces one of an environment's secrets.
+	PutSecret(ctx context.Context, envID string, secret model.Secret) error
+
+	// ListSecrets returns an environment's secrets, sorted by name.
+	ListSecrets(ctx context.Context, envID string) ([]model.Secret, error)
+
+	// DeleteSecret removes one of an environment's secrets. Returns
+	// ErrSecretNotFound if it does not exist.
+	DeleteSecret(ctx context.Context, envID, name string) error
+
+	// DeleteSecrets removes all of an environment's secrets.
+	DeleteSecrets(ctx context.Context, envID string) error
+}
+
+// TemplateStore holds environment templates.
+type TemplateStore interface {
+	// PutTemplate creates or replaces a template.
+	PutTemplate(ctx context.Context, t model.Template) error
+
+	// GetTemplate returns a template by name. Returns ErrTemplateNotFound
+	// if it does not exist.
+	GetTemplate(ctx context.Context, name string) (model.Template, error)
+
+	// ListTemplates returns every template, sorted by name.
+	ListTemplates(ctx context.Context) ([]model.Template, error)
+
+	// DeleteTemplate removes a template. Returns ErrTemplateNotFound if it
+	// does not exist.
+	DeleteTemplate(ctx context.Context, name string) error
+}
+
+// IdempotencyRecord is a request made with an Idempotency-Key and, once it
+// has completed, the response it got.
+type IdempotencyRecord struct {
+	// Key identifies the request. It is scoped by the caller, so two users
+	// may use the same Idempotency-Key.
+	Key string `json:"key"`
+	// RequestHash fingerprints the request, so a key reused for a different
+	// request can be told apart from a retry.
+	RequestHash string `json:"requestHash"`
+	// StatusCode and Body are the response. StatusCode is zero while the
+	// request is in flight.
+	StatusCode int       `json:"statusCode,omitempty"`
+	Body       []byte    `json:"body,omitempty"`
+	ExpiresAt  time.Time `json:"expiresAt"`
+}
+
+// Completed reports whether the request has a response to replay.
+func (r IdempotencyRecord) Completed() bool { return r.StatusCode != 0 }
+
+// IdempotencyStore remembers the responses to requests made with an
+// Idempotency-Key until they expire, so retries can be answered without
+// repeating the request.
+type IdempotencyStore interface {
+	// Reserve records rec as in flight and returns it with true, unless an
+	// unexpired record with the same key exists, in which case it returns
+	// that record and false.
+	Reserve(ctx context.Context, rec IdempotencyRecord) (IdempotencyRecord, bool, error)
+
+	// Complete stores the response to a reserved request.
+	Complete(ctx context.Context, key string, statusCode int, body []byte) error
+
+	// Release forgets a reserved request so it can be tried again.
+	Release(ctx context.Context, key string) error
+}
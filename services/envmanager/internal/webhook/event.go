@@ -0,0 +1,79 @@
+// Package webhook turns Git hosting webhooks into environment changes: a push
+// to a branch creates or updates that branch's environment with overrides for
+// the packages it touched, and closing the branch's pull request tears the
+// environment down.
+package webhook
+
+import (
+	"errors"
+	"strings"
+)
+
+// Provider is a Git hosting service that sends webhooks.
+type Provider string
+
+const (
+	ProviderGitHub Provider = "github"
+	ProviderGitLab Provider = "gitlab"
+)
+
+// EventKind is the kind of repository event a webhook reports.
+type EventKind string
+
+const (
+	EventPush              EventKind = "push"
+	EventPullRequestOpened EventKind = "pull_request_opened"
+	EventPullRequestClosed EventKind = "pull_request_closed"
+)
+
+var (
+	// ErrInvalidSignature is returned when a webhook's signature or token
+	// does not match the shared secret.
+	ErrInvalidSignature = errors.New("invalid webhook signature")
+
+	// ErrUnsupportedEvent is returned for webhook events that do not affect
+	// environments, such as pings, tag pushes or pull request comments.
+	ErrUnsupportedEvent = errors.New("unsupported webhook event")
+)
+
+// Event is a provider-neutral repository event.
+type Event struct {
+	Provider Provider
+	Kind     EventKind
+
+	// Repository is the repository's full path, e.g. "acme/api".
+	Repository    string
+	DefaultBranch string
+
+	// Branch is the pushed branch, or the pull request's source branch.
+	Branch string
+
+	// Before and After are the commits the branch moved between. For pull
+	// request events only After, the head commit, is set.
+	Before string
+	After  string
+
+	// Sender is the user who triggered the event.
+	Sender string
+
+	// Changed lists files added or modified by a push, and Removed the files
+	// it deleted, as repository-relative paths.
+	Changed []string
+	Removed []string
+
+	// Deleted reports whether a push deleted the branch.
+	Deleted bool
+}
+
+// branchFromRef returns the branch named by a fully qualified ref, or false
+// if the ref is not a branch.
+func branchFromRef(ref string) (string, bool) {
+	branch, ok := strings.CutPrefix(ref, "refs/heads/")
+	return branch, ok && branch != ""
+}
+
+// isZeroCommit reports whether sha is the all-zero commit providers send for
+// a branch that was created or deleted.
+func isZeroCommit(sha string) bool {
+	return sha != "" && strings.Trim(sha, "0") == ""
+}
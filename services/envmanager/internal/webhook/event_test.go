@@ -0,0 +1,196 @@
+package webhook_test
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/webhook"
+)
+
+func readFixture(t *testing.T, name string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("read fixture: %v", err)
+	}
+	return data
+}
+
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name      string
+		parse     func(string, []byte) (webhook.Event, error)
+		eventType string
+		fixture   string
+		want      webhook.Event
+	}{
+		{
+			name:      "github push",
+			parse:     webhook.ParseGitHub,
+			eventType: "push",
+			fixture:   "github_push.json",
+			want: webhook.Event{
+				Provider:      webhook.ProviderGitHub,
+				Kind:          webhook.EventPush,
+				Repository:    "acme/storefront",
+				DefaultBranch: "main",
+				Branch:        "feature/reviews",
+				Before:        "6113728f27ae82c7b1a177c8d03f9e96e0adf246",
+				After:         "9c2f1a4e5b8d7c3a0f6e1b2d4c8a7e9f3b5d1c6a",
+				Sender:        "srivera",
+				Changed:       []string{"packages/reviews/schema.graphql", "docs/ratings.md", "README.md"},
+			},
+		},
+		{
+			name:      "github branch deleted",
+			parse:     webhook.ParseGitHub,
+			eventType: "push",
+			fixture:   "github_push_deleted.json",
+			want: webhook.Event{
+				Provider:      webhook.ProviderGitHub,
+				Kind:          webhook.EventPush,
+				Repository:    "acme/storefront",
+				DefaultBranch: "main",
+				Branch:        "feature/reviews",
+				Before:        "9c2f1a4e5b8d7c3a0f6e1b2d4c8a7e9f3b5d1c6a",
+				After:         "0000000000000000000000000000000000000000",
+				Sender:        "srivera",
+				Deleted:       true,
+			},
+		},
+		{
+			name:      "github pull request opened",
+			parse:     webhook.ParseGitHub,
+			eventType: "pull_request",
+			fixture:   "github_pull_request_opened.json",
+			want: webhook.Event{
+				Provider:      webhook.ProviderGitHub,
+				Kind:          webhook.EventPullRequestOpened,
+				Repository:    "acme/storefront",
+				DefaultBranch: "main",
+				Branch:        "feature/reviews",
+				After:         "9c2f1a4e5b8d7c3a0f6e1b2d4c8a7e9f3b5d1c6a",
+				Sender:        "srivera",
+			},
+		},
+		{
+			name:      "github pull request merged",
+			parse:     webhook.ParseGitHub,
+			eventType: "pull_request",
+			fixture:   "github_pull_request_closed.json",
+			want: webhook.Event{
+				Provider:      webhook.ProviderGitHub,
+				Kind:          webhook.EventPullRequestClosed,
+				Repository:    "acme/storefront",
+				DefaultBranch: "main",
+				Branch:        "feature/reviews",
+				After:         "9c2f1a4e5b8d7c3a0f6e1b2d4c8a7e9f3b5d1c6a",
+				Sender:        "mkim",
+			},
+		},
+		{
+			name:      "gitlab push",
+			parse:     webhook.ParseGitLab,
+			eventType: "Push Hook",
+			fixture:   "gitlab_push.json",
+			want: webhook.Event{
+				Provider:      webhook.ProviderGitLab,
+				Kind:          webhook.EventPush,
+				Repository:    "acme/storefront",
+				DefaultBranch: "main",
+				Branch:        "feature/reviews",
+				Before:        "6113728f27ae82c7b1a177c8d03f9e96e0adf246",
+				After:         "9c2f1a4e5b8d7c3a0f6e1b2d4c8a7e9f3b5d1c6a",
+				Sender:        "srivera",
+				Changed:       []string{"packages/reviews/schema.graphql", "docs/ratings.md", "README.md"},
+			},
+		},
+		{
+			name:      "gitlab merge request merged",
+			parse:     webhook.ParseGitLab,
+			eventType: "Merge Request Hook",
+			fixture:   "gitlab_merge_request_merge.json",
+			want: webhook.Event{
+				Provider:      webhook.ProviderGitLab,
+				Kind:          webhook.EventPullRequestClosed,
+				Repository:    "acme/storefront",
+				DefaultBranch: "main",
+				Branch:        "feature/reviews",
+				After:         "9c2f1a4e5b8d7c3a0f6e1b2d4c8a7e9f3b5d1c6a",
+				Sender:        "mkim",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.parse(tt.eventType, readFixture(t, tt.fixture))
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParse_Unsupported(t *testing.T) {
+	tests := []struct {
+		name      string
+		parse     func(string, []byte) (webhook.Event, error)
+		eventType string
+		body      string
+	}{
+		{"github ping", webhook.ParseGitHub, "ping", `{"zen":"Keep it logically awesome."}`},
+		{"github tag push", webhook.ParseGitHub, "push", `{"ref":"refs/tags/v1.0.0"}`},
+		{"github pull request edited", webhook.ParseGitHub, "pull_request", `{"action":"edited"}`},
+		{"gitlab tag push", webhook.ParseGitLab, "Tag Push Hook", `{"ref":"refs/tags/v1.0.0"}`},
+		{"gitlab merge request updated", webhook.ParseGitLab, "Merge Request Hook", `{"object_attributes":{"action":"update"}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.parse(tt.eventType, []byte(tt.body))
+			if !errors.Is(err, webhook.ErrUnsupportedEvent) {
+				t.Fatalf("expected ErrUnsupportedEvent, got %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyGitHub(t *testing.T) {
+	body := readFixture(t, "github_push.json")
+
+	if err := webhook.VerifyGitHub("s3cret", body, sign("s3cret", body)); err != nil {
+		t.Fatalf("expected a valid signature, got %v", err)
+	}
+	for _, sig := range []string{"", sign("other", body), "sha1=abc", "sha256=zz"} {
+		if err := webhook.VerifyGitHub("s3cret", body, sig); !errors.Is(err, webhook.ErrInvalidSignature) {
+			t.Fatalf("%q: expected ErrInvalidSignature, got %v", sig, err)
+		}
+	}
+}
+
+func TestVerifyGitLab(t *testing.T) {
+	if err := webhook.VerifyGitLab("s3cret", "s3cret"); err != nil {
+		t.Fatalf("expected a valid token, got %v", err)
+	}
+	for _, token := range []string{"", "other"} {
+		if err := webhook.VerifyGitLab("s3cret", token); !errors.Is(err, webhook.ErrInvalidSignature) {
+			t.Fatalf("%q: expected ErrInvalidSignature, got %v", token, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// VerifyGitHub checks the X-Hub-Signature-256 header GitHub sends with each
+// delivery: "sha256=" followed by the hex HMAC-SHA256 of the body keyed with
+// the webhook secret.
+func VerifyGitHub(secret string, body []byte, signature string) error {
+	sig, ok := strings.CutPrefix(signature, "sha256=")
+	if !ok {
+		return ErrInvalidSignature
+	}
+	got, err := hex.DecodeString(sig)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	if !hmac.Equal(got, mac.Sum(nil)) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+type githubRepository struct {
+	FullName      string `json:"full_name"`
+	DefaultBranch string `json:"default_branch"`
+}
+
+type githubUser struct {
+	Login string `json:"login"`
+}
+
+type githubCommit struct {
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+type githubPush struct {
+	Ref        string           `json:"ref"`
+	Before     string           `json:"before"`
+	After      string           `json:"after"`
+	Deleted    bool             `json:"deleted"`
+	Commits    []githubCommit   `json:"commits"`
+	Repository githubRepository `json:"repository"`
+	Sender     githubUser       `json:"sender"`
+}
+
+type githubPullRequest struct {
+	Action      string `json:"action"`
+	PullRequest struct {
+		Head struct {
+			Ref string `json:"ref"`
+			SHA string `json:"sha"`
+		} `json:"head"`
+	} `json:"pull_request"`
+	Repository githubRepository `json:"repository"`
+	Sender     githubUser       `json:"sender"`
+}
+
+// ParseGitHub parses a GitHub webhook delivery. eventType is the value of the
+// X-GitHub-Event header. Push and pull_request events are supported; others
+// return ErrUnsupportedEvent.
+func ParseGitHub(eventType string, body []byte) (Event, error) {
+	switch eventType {
+	case "push":
+		var p githubPush
+		if err := json.Unmarshal(body, &p); err != nil {
+			return Event{}, fmt.Errorf("decode push event: %w", err)
+		}
+		branch, ok := branchFromRef(p.Ref)
+		if !ok {
+			return Event{}, fmt.Errorf("%w: push to %s", ErrUnsupportedEvent, p.Ref)
+		}
+		ev := Event{
+			Provider:      ProviderGitHub,
+			Kind:          EventPush,
+			Repository:    p.Repository.FullName,
+			DefaultBranch: p.Repository.DefaultBranch,
+			Branch:        branch,
+			Before:        p.Before,
+			After:         p.After,
+			Sender:        p.Sender.Login,
+			Deleted:       p.Deleted,
+		}
+		for _, c := range p.Commits {
+			ev.Changed = append(ev.Changed, c.Added...)
+			ev.Changed = append(ev.Changed, c.Modified...)
+			ev.Removed = append(ev.Removed, c.Removed...)
+		}
+		return ev, nil
+
+	case "pull_request":
+		var p githubPullRequest
+		if err := json.Unmarshal(body, &p); err != nil {
+			return Event{}, fmt.Errorf("decode pull_request event: %w", err)
+		}
+		ev := Event{
+			Provider:      ProviderGitHub,
+			Repository:    p.Repository.FullName,
+			DefaultBranch: p.Repository.DefaultBranch,
+			Branch:        p.PullRequest.Head.Ref,
+			After:         p.PullRequest.Head.SHA,
+			Sender:        p.Sender.Login,
+		}
+		switch p.Action {
+		case "opened", "reopened":
+			ev.Kind = EventPullRequestOpened
+		case "closed":
+			ev.Kind = EventPullRequestClosed
+		default:
+			return Event{}, fmt.Errorf("%w: pull_request %s", ErrUnsupportedEvent, p.Action)
+		}
+		return ev, nil
+
+	default:
+		return Event{}, fmt.Errorf("%w: %s", ErrUnsupportedEvent, eventType)
+	}
+}
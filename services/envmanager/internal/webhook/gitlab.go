@@ -0,0 +1,113 @@
+package webhook
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+)
+
+// VerifyGitLab checks the X-Gitlab-Token header GitLab sends with each
+// delivery, which carries the webhook's secret token verbatim.
+func VerifyGitLab(secret, token string) error {
+	if token == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(token)) != 1 {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+type gitlabProject struct {
+	PathWithNamespace string `json:"path_with_namespace"`
+	DefaultBranch     string `json:"default_branch"`
+}
+
+type gitlabCommit struct {
+	Added    []string `json:"added"`
+	Modified []string `json:"modified"`
+	Removed  []string `json:"removed"`
+}
+
+type gitlabPush struct {
+	Ref          string         `json:"ref"`
+	Before       string         `json:"before"`
+	After        string         `json:"after"`
+	UserUsername string         `json:"user_username"`
+	Project      gitlabProject  `json:"project"`
+	Commits      []gitlabCommit `json:"commits"`
+}
+
+type gitlabMergeRequest struct {
+	User struct {
+		Username string `json:"username"`
+	} `json:"user"`
+	Project          gitlabProject `json:"project"`
+	ObjectAttributes struct {
+		Action       string `json:"action"`
+		SourceBranch string `json:"source_branch"`
+		LastCommit   struct {
+			ID string `json:"id"`
+		} `json:"last_commit"`
+	} `json:"object_attributes"`
+}
+
+// ParseGitLab parses a GitLab webhook delivery. eventType is the value of the
+// X-Gitlab-Event header. Push and merge request hooks are supported; others
+// return ErrUnsupportedEvent.
+//
+// GitLab lists at most 20 commits in a push hook, so files changed only by
+// earlier commits of a larger push are not reported.
+func ParseGitLab(eventType string, body []byte) (Event, error) {
+	switch eventType {
+	case "Push Hook":
+		var p gitlabPush
+		if err := json.Unmarshal(body, &p); err != nil {
+			return Event{}, fmt.Errorf("decode push hook: %w", err)
+		}
+		branch, ok := branchFromRef(p.Ref)
+		if !ok {
+			return Event{}, fmt.Errorf("%w: push to %s", ErrUnsupportedEvent, p.Ref)
+		}
+		ev := Event{
+			Provider:      ProviderGitLab,
+			Kind:          EventPush,
+			Repository:    p.Project.PathWithNamespace,
+			DefaultBranch: p.Project.DefaultBranch,
+			Branch:        branch,
+			Before:        p.Before,
+			After:         p.After,
+			Sender:        p.UserUsername,
+			Deleted:       isZeroCommit(p.After),
+		}
+		for _, c := range p.Commits {
+			ev.Changed = append(ev.Changed, c.Added...)
+			ev.Changed = append(ev.Changed, c.Modified...)
+			ev.Removed = append(ev.Removed, c.Removed...)
+		}
+		return ev, nil
+
+	case "Merge Request Hook":
+		var p gitlabMergeRequest
+		if err := json.Unmarshal(body, &p); err != nil {
+			return Event{}, fmt.Errorf("decode merge request hook: %w", err)
+		}
+		ev := Event{
+			Provider:      ProviderGitLab,
+			Repository:    p.Project.PathWithNamespace,
+			DefaultBranch: p.Project.DefaultBranch,
+			Branch:        p.ObjectAttributes.SourceBranch,
+			After:         p.ObjectAttributes.LastCommit.ID,
+			Sender:        p.User.Username,
+		}
+		switch p.ObjectAttributes.Action {
+		case "open", "reopen":
+			ev.Kind = EventPullRequestOpened
+		case "close", "merge":
+			ev.Kind = EventPullRequestClosed
+		default:
+			return Event{}, fmt.Errorf("%w: merge request %s", ErrUnsupportedEvent, p.ObjectAttributes.Action)
+		}
+		return ev, nil
+
+	default:
+		return Event{}, fmt.Errorf("%w: %s", ErrUnsupportedEvent, eventType)
+	}
+}
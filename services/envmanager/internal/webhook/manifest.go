@@ -0,0 +1,204 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"slices"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+)
+
+// manifestNames are the file names a package manifest may have, in order of
+// preference.
+var manifestNames = []string{"turbo-engine.yaml", "turbo-engine.yml"}
+
+// ErrNoManifest is returned when a repository has no manifest at its root,
+// so there is no base root package to build environments from.
+var ErrNoManifest = errors.New("repository has no root turbo-engine.yaml")
+
+// manifest is the subset of a turbo-engine.yaml package manifest needed to
+// map repository files to packages.
+type manifest struct {
+	Name   string `yaml:"name"`
+	Kind   string `yaml:"kind"`
+	Schema string `yaml:"schema"`
+}
+
+// manifestIndex finds the manifests that own repository paths at one
+// commit, reading each directory's manifest at most once.
+type manifestIndex struct {
+	source Source
+	repo   string
+	ref    string
+	dirs   map[string]*manifest
+}
+
+func newManifestIndex(source Source, repo, ref string) *manifestIndex {
+	return &manifestIndex{source: source, repo: repo, ref: ref, dirs: make(map[string]*manifest)}
+}
+
+// at returns the manifest in dir, or nil if it has none.
+func (idx *manifestIndex) at(ctx context.Context, dir string) (*manifest, error) {
+	if m, ok := idx.dirs[dir]; ok {
+		return m, nil
+	}
+	var found *manifest
+	for _, name := range manifestNames {
+		data, err := idx.source.ReadFile(ctx, idx.repo, idx.ref, path.Join(dir, name))
+		if errors.Is(err, ErrFileNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		var m manifest
+		if err := yaml.Unmarshal(data, &m); err != nil {
+			return nil, fmt.Errorf("parse %s: %w", path.Join(dir, name), err)
+		}
+		if m.Name == "" {
+			return nil, fmt.Errorf("%s: manifest has no name", path.Join(dir, name))
+		}
+		found = &m
+		break
+	}
+	idx.dirs[dir] = found
+	return found, nil
+}
+
+// owner returns the directory of the nearest manifest at or above file, or
+// false if no manifest owns it.
+func (idx *manifestIndex) owner(ctx context.Context, file string) (string, bool, error) {
+	dir := path.Dir(file)
+	for {
+		m, err := idx.at(ctx, dir)
+		if err != nil {
+			return "", false, err
+		}
+		if m != nil {
+			return dir, true, nil
+		}
+		if dir == "." {
+			return "", false, nil
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// packageChanges describes how a push changes the packages in a repository.
+type packageChanges struct {
+	// root is the repository's root package, which environments are based on.
+	root string
+
+	// overrides replace the schemas of packages whose files changed.
+	overrides []model.PackageOverride
+
+	// removed lists packages whose manifest or schema was deleted.
+	removed []string
+}
+
+// changesFor maps the files an event touched to the packages that own them.
+// Each package whose files changed is overridden with its schema at the
+// pushed commit. Changes owned by the root manifest are not overrides: they
+// change the base itself.
+func changesFor(ctx context.Context, source Source, ev Event) (packageChanges, error) {
+	after := newManifestIndex(source, ev.Repository, ev.After)
+	root, err := after.at(ctx, ".")
+	if err != nil {
+		return packageChanges{}, err
+	}
+	if root == nil {
+		return packageChanges{}, ErrNoManifest
+	}
+
+	// Removed files may belong to a manifest that was removed with them, so
+	// they are looked up at the previous commit too.
+	var before *manifestIndex
+	if ev.Before != "" && !isZeroCommit(ev.Before) {
+		before = newManifestIndex(source, ev.Repository, ev.Before)
+	}
+
+	var dirs []string
+	addOwner := func(idx *manifestIndex, file string) error {
+		dir, ok, err := idx.owner(ctx, file)
+		if err != nil || !ok || dir == "." || slices.Contains(dirs, dir) {
+			return err
+		}
+		dirs = append(dirs, dir)
+		return nil
+	}
+	for _, file := range ev.Changed {
+		if err := addOwner(after, file); err != nil {
+			return packageChanges{}, err
+		}
+	}
+	for _, file := range ev.Removed {
+		idx := after
+		if before != nil {
+			idx = before
+		}
+		if err := addOwner(idx, file); err != nil {
+			return packageChanges{}, err
+		}
+	}
+
+	changes := packageChanges{root: root.Name}
+	for _, dir := range dirs {
+		m, err := after.at(ctx, dir)
+		if err != nil {
+			return packageChanges{}, err
+		}
+		if m == nil {
+			old, err := before.at(ctx, dir)
+			if err != nil {
+				return packageChanges{}, err
+			}
+			changes.removed = append(changes.removed, old.Name)
+			continue
+		}
+		if m.Schema == "" {
+			continue
+		}
+		schema, err := source.ReadFile(ctx, ev.Repository, ev.After, path.Join(dir, m.Schema))
+		if errors.Is(err, ErrFileNotFound) {
+			changes.removed = append(changes.removed, m.Name)
+			continue
+		}
+		if err != nil {
+			return packageChanges{}, err
+		}
+		changes.overrides = append(changes.overrides, model.PackageOverride{
+			PackageName: m.Name,
+			Schema:      string(schema),
+		})
+	}
+	return changes, nil
+}
+
+// apply returns overrides updated with the changes. An existing override for
+// a changed package keeps its upstream and runtime settings but takes the new
+// schema; overrides for removed packages are dropped.
+func (c packageChanges) apply(overrides []model.PackageOverride) []model.PackageOverride {
+	out := make([]model.PackageOverride, 0, len(overrides)+len(c.overrides))
+	for _, o := range overrides {
+		if slices.Contains(c.removed, o.PackageName) {
+			continue
+		}
+		out = append(out, o.Clone())
+	}
+	for _, changed := range c.overrides {
+		i := slices.IndexFunc(out, func(o model.PackageOverride) bool {
+			return o.PackageName == changed.PackageName
+		})
+		if i < 0 {
+			out = append(out, changed)
+			continue
+		}
+		out[i].Schema = changed.Schema
+		out[i].Version = ""
+	}
+	return out
+}
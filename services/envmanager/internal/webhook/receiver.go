@@ -0,0 +1,245 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+)
+
+var tracer = otel.Tracer("envmanager/webhook")
+
+// Action is what a Receiver did in response to an event.
+type Action string
+
+const (
+	ActionCreated   Action = "created"
+	ActionUpdated   Action = "updated"
+	ActionDeleted   Action = "deleted"
+	ActionUnchanged Action = "unchanged"
+	ActionIgnored   Action = "ignored"
+)
+
+// Result reports how a Receiver handled an event.
+type Result struct {
+	Action        Action `json:"action"`
+	EnvironmentID string `json:"environmentId,omitempty"`
+	Reason        string `json:"reason,omitempty"`
+}
+
+// Receiver manages one environment per repository branch in response to
+// webhook events.
+type Receiver struct {
+	orch    *orchestrator.Orchestrator
+	sources map[Provider]Source
+	logger  *slog.Logger
+}
+
+// Option configures a Receiver.
+type Option func(*Receiver)
+
+// WithSource sets where the Receiver reads files from for events sent by
+// provider. Push events from a provider without a source are ignored.
+func WithSource(provider Provider, source Source) Option {
+	return func(r *Receiver) { r.sources[provider] = source }
+}
+
+// New creates a Receiver.
+func New(orch *orchestrator.Orchestrator, logger *slog.Logger, opts ...Option) *Receiver {
+	r := &Receiver{
+		orch:    orch,
+		sources: make(map[Provider]Source),
+		logger:  logger,
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// EnvironmentName is the name of the environment a Receiver manages for a
+// repository branch.
+func EnvironmentName(repo, branch string) string {
+	return repo + ":" + branch
+}
+
+// Handle applies an event:
+//   - a push to a branch other than the default creates the branch's
+//     environment, or updates its overrides and rebuilds it;
+//   - a push deleting the branch, or closing its pull request, deletes the
+//     environment;
+//   - opening a pull request creates the environment if it does not exist.
+//     Its overrides arrive with the branch's next push.
+func (r *Receiver) Handle(ctx context.Context, ev Event) (Result, error) {
+	ctx, span := tracer.Start(ctx, "Receiver.Handle", trace.WithAttributes(
+		attribute.String("webhook.provider", string(ev.Provider)),
+		attribute.String("webhook.event", string(ev.Kind)),
+		attribute.String("webhook.repository", ev.Repository),
+		attribute.String("webhook.branch", ev.Branch)))
+	defer span.End()
+
+	if ev.Branch == "" || ev.Branch == ev.DefaultBranch {
+		return Result{Action: ActionIgnored, Reason: "default branch"}, nil
+	}
+	if ev.Sender != "" {
+		ctx = orchestrator.ContextWithActor(ctx, ev.Sender)
+	}
+
+	env, found, err := r.findEnvironment(ctx, ev)
+	if err != nil {
+		span.RecordError(err)
+		return Result{}, err
+	}
+
+	var result Result
+	switch {
+	case ev.Kind == EventPullRequestClosed || (ev.Kind == EventPush && ev.Deleted):
+		result, err = r.teardown(ctx, env, found)
+	case ev.Kind == EventPullRequestOpened:
+		result, err = r.ensure(ctx, ev, env, found)
+	case ev.Kind == EventPush:
+		result, err = r.push(ctx, ev, env, found)
+	default:
+		return Result{}, fmt.Errorf("%w: %s", ErrUnsupportedEvent, ev.Kind)
+	}
+	if err != nil {
+		span.RecordError(err)
+		return Result{}, err
+	}
+
+	r.logger.InfoContext(ctx, "webhook handled",
+		slog.String("provider", string(ev.Provider)),
+		slog.String("event", string(ev.Kind)),
+		slog.String("repository", ev.Repository),
+		slog.String("branch", ev.Branch),
+		slog.String("action", string(result.Action)),
+		slog.String("environmentId", result.EnvironmentID))
+	return result, nil
+}
+
+// push creates or updates the branch's environment with overrides for the
+// packages the push changed.
+func (r *Receiver) push(ctx context.Context, ev Event, env model.Environment, found bool) (Result, error) {
+	source, ok := r.sources[ev.Provider]
+	if !ok {
+		return Result{Action: ActionIgnored, Reason: fmt.Sprintf("no source configured for %s", ev.Provider)}, nil
+	}
+	changes, err := changesFor(ctx, source, ev)
+	if errors.Is(err, ErrNoManifest) {
+		return Result{Action: ActionIgnored, Reason: err.Error()}, nil
+	}
+	if err != nil {
+		return Result{}, fmt.Errorf("map changed files to packages: %w", err)
+	}
+
+	if !found {
+		created, err := r.orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+			Name:            EnvironmentName(ev.Repository, ev.Branch),
+			BaseRootPackage: changes.root,
+			Branch:          ev.Branch,
+			CreatedBy:       ev.Sender,
+			Overrides:       changes.overrides,
+		})
+		if err != nil {
+			return Result{}, err
+		}
+		return Result{Action: ActionCreated, EnvironmentID: created.ID}, nil
+	}
+
+	overrides := changes.apply(env.Overrides)
+	if slices.EqualFunc(overrides, env.Overrides, model.PackageOverride.Equal) {
+		return Result{Action: ActionUnchanged, EnvironmentID: env.ID, Reason: "no package changes"}, nil
+	}
+	_, err = r.orch.ApplyOverrides(ctx, env.ID, model.ApplyOverridesRequest{
+		Overrides:    overrides,
+		TriggerBuild: true,
+	})
+	if errors.Is(err, orchestrator.ErrLocked) {
+		return Result{Action: ActionIgnored, EnvironmentID: env.ID, Reason: err.Error()}, nil
+	}
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Action: ActionUpdated, EnvironmentID: env.ID}, nil
+}
+
+// ensure creates the branch's environment from the repository's root package
+// if it does not exist yet.
+func (r *Receiver) ensure(ctx context.Context, ev Event, env model.Environment, found bool) (Result, error) {
+	if found {
+		return Result{Action: ActionUnchanged, EnvironmentID: env.ID, Reason: "environment exists"}, nil
+	}
+	source, ok := r.sources[ev.Provider]
+	if !ok {
+		return Result{Action: ActionIgnored, Reason: fmt.Sprintf("no source configured for %s", ev.Provider)}, nil
+	}
+	root, err := newManifestIndex(source, ev.Repository, ev.After).at(ctx, ".")
+	if err != nil {
+		return Result{}, fmt.Errorf("read root manifest: %w", err)
+	}
+	if root == nil {
+		return Result{Action: ActionIgnored, Reason: ErrNoManifest.Error()}, nil
+	}
+
+	created, err := r.orch.CreateEnvironment(ctx, model.CreateEnvironmentRequest{
+		Name:            EnvironmentName(ev.Repository, ev.Branch),
+		BaseRootPackage: root.Name,
+		Branch:          ev.Branch,
+		CreatedBy:       ev.Sender,
+	})
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Action: ActionCreated, EnvironmentID: created.ID}, nil
+}
+
+// teardown deletes the branch's environment, if there is one. Protected and
+// locked environments outlive their branch until deleted by hand.
+func (r *Receiver) teardown(ctx context.Context, env model.Environment, found bool) (Result, error) {
+	if !found {
+		return Result{Action: ActionIgnored, Reason: "no environment for branch"}, nil
+	}
+	if env.Protected {
+		return Result{Action: ActionIgnored, EnvironmentID: env.ID, Reason: "environment is protected"}, nil
+	}
+	err := r.orch.DeleteEnvironment(ctx, env.ID)
+	if errors.Is(err, orchestrator.ErrLocked) {
+		return Result{Action: ActionIgnored, EnvironmentID: env.ID, Reason: err.Error()}, nil
+	}
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		return Result{}, err
+	}
+	return Result{Action: ActionDeleted, EnvironmentID: env.ID}, nil
+}
+
+// findEnvironment returns the environment managed for the event's branch.
+// Environments already being deleted are skipped, so a new push after a
+// teardown starts afresh.
+func (r *Receiver) findEnvironment(ctx context.Context, ev Event) (model.Environment, bool, error) {
+	name := EnvironmentName(ev.Repository, ev.Branch)
+	filter := store.ListFilter{Branch: ev.Branch, PageSize: 100}
+	for {
+		result, err := r.orch.ListEnvironments(ctx, filter)
+		if err != nil {
+			return model.Environment{}, false, fmt.Errorf("list environments: %w", err)
+		}
+		for _, env := range result.Environments {
+			if env.Name == name && env.Status != model.StatusDeleting {
+				return env, true, nil
+			}
+		}
+		if result.NextPageToken == "" {
+			return model.Environment{}, false, nil
+		}
+		filter.PageToken = result.NextPageToken
+	}
+}
@@ -0,0 +1,325 @@
+package webhook_test
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/orchestrator"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/store"
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/webhook"
+)
+
+const (
+	beforeSHA = "6113728f27ae82c7b1a177c8d03f9e96e0adf246"
+	afterSHA  = "9c2f1a4e5b8d7c3a0f6e1b2d4c8a7e9f3b5d1c6a"
+)
+
+// fakeSource serves repository files from memory, keyed by ref then path.
+type fakeSource map[string]map[string]string
+
+func (s fakeSource) ReadFile(_ context.Context, _, ref, path string) ([]byte, error) {
+	data, ok := s[ref][path]
+	if !ok {
+		return nil, fmt.Errorf("read %s@%s: %w", path, ref, webhook.ErrFileNotFound)
+	}
+	return []byte(data), nil
+}
+
+// storefront is the acme/storefront repository the fixtures push to, before
+// and after the push.
+func storefront() fakeSource {
+	files := func(reviewsSchema string) map[string]string {
+		return map[string]string{
+			"turbo-engine.yaml":                   "name: storefront\nkind: graphql-supergraph\n",
+			"packages/users/turbo-engine.yaml":    "name: storefront/users\nkind: graphql-subgraph\nschema: schema.graphql\n",
+			"packages/users/schema.graphql":       "type User { id: ID! }",
+			"packages/reviews/turbo-engine.yaml":  "name: storefront/reviews\nkind: graphql-subgraph\nschema: schema.graphql\n",
+			"packages/reviews/schema.graphql":     reviewsSchema,
+			"packages/reviews/resolvers/index.ts": "export {}",
+			"README.md":                           "# Storefront",
+		}
+	}
+	return fakeSource{
+		beforeSHA: files("type Review { id: ID! }"),
+		afterSHA:  files("type Review { id: ID! rating: Int }"),
+	}
+}
+
+type stubBuilder struct{}
+
+func (stubBuilder) TriggerBuild(_ context.Context, _ model.Environment) (string, error) {
+	return "build-1", nil
+}
+
+func (stubBuilder) GetBuild(_ context.Context, buildID string) (model.Build, error) {
+	return model.Build{ID: buildID, Status: model.BuildStatusSucceeded}, nil
+}
+
+type stubOperator struct{}
+
+func (stubOperator) Deploy(_ context.Context, _ model.Environment, _ string, _ map[string]string) (string, error) {
+	return "https://preview.test/env", nil
+}
+
+func (stubOperator) GetStatus(_ context.Context, _ string) (model.DeploymentStatus, error) {
+	return model.DeploymentStatus{Phase: model.DeploymentPhaseRunning}, nil
+}
+
+func (stubOperator) Teardown(_ context.Context, _ string) error {
+	return nil
+}
+
+func (stubOperator) Sleep(_ context.Context, _ string) error {
+	return nil
+}
+
+func (stubOperator) Wake(_ context.Context, _ string) error {
+	return nil
+}
+
+func newTestReceiver(t *testing.T, source webhook.Source) (*webhook.Receiver, *orchestrator.Orchestrator) {
+	t.Helper()
+	logger := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	orch := orchestrator.New(store.NewMemoryStore(), stubBuilder{}, stubOperator{}, logger,
+		orchestrator.WithPollInterval(time.Millisecond))
+	t.Cleanup(orch.Wait)
+	return webhook.New(orch, logger, webhook.WithSource(webhook.ProviderGitHub, source)), orch
+}
+
+func pushEvent() webhook.Event {
+	return webhook.Event{
+		Provider:      webhook.ProviderGitHub,
+		Kind:          webhook.EventPush,
+		Repository:    "acme/storefront",
+		DefaultBranch: "main",
+		Branch:        "feature/reviews",
+		Before:        beforeSHA,
+		After:         afterSHA,
+		Sender:        "srivera",
+		Changed:       []string{"packages/reviews/schema.graphql", "packages/reviews/resolvers/index.ts", "README.md"},
+	}
+}
+
+func handle(t *testing.T, r *webhook.Receiver, ev webhook.Event, want webhook.Action) webhook.Result {
+	t.Helper()
+	result, err := r.Handle(context.Background(), ev)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Action != want {
+		t.Fatalf("expected %s, got %+v", want, result)
+	}
+	return result
+}
+
+func TestReceiver_PushCreatesEnvironment(t *testing.T) {
+	r, orch := newTestReceiver(t, storefront())
+
+	result := handle(t, r, pushEvent(), webhook.ActionCreated)
+
+	env, err := orch.GetEnvironment(context.Background(), result.EnvironmentID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.Name != "acme/storefront:feature/reviews" || env.Branch != "feature/reviews" || env.CreatedBy != "srivera" {
+		t.Fatalf("unexpected environment: %+v", env)
+	}
+	if env.BaseRootPackage != "storefront" {
+		t.Fatalf("expected base storefront, got %s", env.BaseRootPackage)
+	}
+	// The README belongs to the root package, so only reviews is overridden.
+	if len(env.Overrides) != 1 || env.Overrides[0].PackageName != "storefront/reviews" ||
+		env.Overrides[0].Schema != "type Review { id: ID! rating: Int }" {
+		t.Fatalf("expected a reviews schema override, got %+v", env.Overrides)
+	}
+
+	events, err := orch.ListEvents(context.Background(), env.ID, store.EventFilter{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, ev := range events.Events {
+		if ev.Actor != "srivera" {
+			t.Fatalf("expected %s to be attributed to the sender, got %q", ev.Type, ev.Actor)
+		}
+	}
+}
+
+func TestReceiver_PushUpdatesEnvironment(t *testing.T) {
+	source := storefront()
+	r, orch := newTestReceiver(t, source)
+	created := handle(t, r, pushEvent(), webhook.ActionCreated)
+	orch.Wait()
+
+	// The same push again changes nothing.
+	handle(t, r, pushEvent(), webhook.ActionUnchanged)
+
+	// A later push changes users and deletes the reviews package.
+	const nextSHA = "1f0e2d3c4b5a69788796a5b4c3d2e1f00f1e2d3c"
+	next := map[string]string{}
+	for path, data := range source[afterSHA] {
+		next[path] = data
+	}
+	next["packages/users/schema.graphql"] = "type User { id: ID! email: String }"
+	delete(next, "packages/reviews/turbo-engine.yaml")
+	delete(next, "packages/reviews/schema.graphql")
+	source[nextSHA] = next
+
+	ev := pushEvent()
+	ev.Before, ev.After = afterSHA, nextSHA
+	ev.Changed = []string{"packages/users/schema.graphql"}
+	ev.Removed = []string{"packages/reviews/turbo-engine.yaml", "packages/reviews/schema.graphql"}
+	result := handle(t, r, ev, webhook.ActionUpdated)
+	if result.EnvironmentID != created.EnvironmentID {
+		t.Fatalf("expected %s to be updated, got %s", created.EnvironmentID, result.EnvironmentID)
+	}
+
+	env, err := orch.GetEnvironment(context.Background(), created.EnvironmentID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if env.Status != model.StatusBuilding {
+		t.Fatalf("expected a rebuild, got status %s", env.Status)
+	}
+	if len(env.Overrides) != 1 || env.Overrides[0].PackageName != "storefront/users" {
+		t.Fatalf("expected only the users override, got %+v", env.Overrides)
+	}
+}
+
+func TestReceiver_PushKeepsManualOverrideSettings(t *testing.T) {
+	r, orch := newTestReceiver(t, storefront())
+	created := handle(t, r, pushEvent(), webhook.ActionCreated)
+	orch.Wait()
+
+	// Someone points reviews at a local upstream by hand.
+	upstream := &model.UpstreamConfig{URL: "http://localhost:4003"}
+	if _, err := orch.ApplyOverrides(context.Background(), created.EnvironmentID, model.ApplyOverridesRequest{
+		Overrides: []model.PackageOverride{{PackageName: "storefront/reviews", UpstreamConfig: upstream}},
+	}); err != nil {
+		t.Fatalf("apply overrides: %v", err)
+	}
+
+	handle(t, r, pushEvent(), webhook.ActionUpdated)
+	env, err := orch.GetEnvironment(context.Background(), created.EnvironmentID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(env.Overrides) != 1 || env.Overrides[0].Schema == "" || env.Overrides[0].UpstreamConfig == nil {
+		t.Fatalf("expected the schema and upstream to be combined, got %+v", env.Overrides)
+	}
+}
+
+func TestReceiver_PullRequestLifecycle(t *testing.T) {
+	r, orch := newTestReceiver(t, storefront())
+	ev := webhook.Event{
+		Provider:      webhook.ProviderGitHub,
+		Kind:          webhook.EventPullRequestOpened,
+		Repository:    "acme/storefront",
+		DefaultBranch: "main",
+		Branch:        "feature/reviews",
+		After:         afterSHA,
+		Sender:        "srivera",
+	}
+
+	created := handle(t, r, ev, webhook.ActionCreated)
+	handle(t, r, ev, webhook.ActionUnchanged)
+
+	ev.Kind = webhook.EventPullRequestClosed
+	deleted := handle(t, r, ev, webhook.ActionDeleted)
+	if deleted.EnvironmentID != created.EnvironmentID {
+		t.Fatalf("expected %s to be deleted, got %s", created.EnvironmentID, deleted.EnvironmentID)
+	}
+	if _, err := orch.GetEnvironment(context.Background(), created.EnvironmentID); err == nil {
+		t.Fatal("expected the environment to be gone")
+	}
+
+	handle(t, r, ev, webhook.ActionIgnored)
+}
+
+func TestReceiver_BranchDeleted(t *testing.T) {
+	r, _ := newTestReceiver(t, storefront())
+	handle(t, r, pushEvent(), webhook.ActionCreated)
+
+	ev := pushEvent()
+	ev.Deleted = true
+	ev.Changed = nil
+	handle(t, r, ev, webhook.ActionDeleted)
+}
+
+func TestReceiver_KeepsProtectedAndLockedEnvironments(t *testing.T) {
+	r, orch := newTestReceiver(t, storefront())
+	created := handle(t, r, pushEvent(), webhook.ActionCreated)
+	orch.Wait()
+	ctx := orchestrator.ContextWithActor(context.Background(), "qa-team")
+
+	// A locked environment keeps its overrides and outlives its branch.
+	if _, err := orch.Lock(ctx, created.EnvironmentID, model.LockRequest{Reason: "release testing"}); err != nil {
+		t.Fatalf("lock: %v", err)
+	}
+	ev := pushEvent()
+	ev.Changed = []string{"packages/users/schema.graphql"}
+	handle(t, r, ev, webhook.ActionIgnored)
+	deleted := pushEvent()
+	deleted.Deleted = true
+	deleted.Changed = nil
+	handle(t, r, deleted, webhook.ActionIgnored)
+
+	// So does a protected one, even once unlocked.
+	if _, err := orch.Unlock(ctx, created.EnvironmentID); err != nil {
+		t.Fatalf("unlock: %v", err)
+	}
+	protected := true
+	if _, err := orch.UpdateEnvironment(ctx, created.EnvironmentID, model.UpdateEnvironmentRequest{Protected: &protected}); err != nil {
+		t.Fatalf("protect: %v", err)
+	}
+	result := handle(t, r, deleted, webhook.ActionIgnored)
+	if result.Reason != "environment is protected" {
+		t.Fatalf("expected the protected reason, got %q", result.Reason)
+	}
+	if _, err := orch.GetEnvironment(context.Background(), created.EnvironmentID); err != nil {
+		t.Fatalf("expected the environment kept, got %v", err)
+	}
+}
+
+func TestReceiver_Ignored(t *testing.T) {
+	tests := []struct {
+		name   string
+		source webhook.Source
+		event  func() webhook.Event
+	}{
+		{
+			name:   "default branch",
+			source: storefront(),
+			event: func() webhook.Event {
+				ev := pushEvent()
+				ev.Branch = "main"
+				return ev
+			},
+		},
+		{
+			name:   "no root manifest",
+			source: fakeSource{afterSHA: {"README.md": "# Storefront"}},
+			event:  pushEvent,
+		},
+		{
+			name:   "no source for provider",
+			source: storefront(),
+			event: func() webhook.Event {
+				ev := pushEvent()
+				ev.Provider = webhook.ProviderGitLab
+				return ev
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, _ := newTestReceiver(t, tt.source)
+			handle(t, r, tt.event(), webhook.ActionIgnored)
+		})
+	}
+}
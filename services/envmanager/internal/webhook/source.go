@@ -0,0 +1,128 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+)
+
+// ErrFileNotFound is returned by a Source when a file does not exist at the
+// requested commit.
+var ErrFileNotFound = errors.New("file not found")
+
+// Source reads files from a repository at a given commit.
+type Source interface {
+	ReadFile(ctx context.Context, repo, ref, path string) ([]byte, error)
+}
+
+// HTTPSource reads repository files through a Git hosting service's REST
+// API.
+type HTTPSource struct {
+	baseURL    string
+	fileURL    func(baseURL, repo, ref, path string) string
+	headers    map[string]string
+	httpClient *http.Client
+}
+
+// NewGitHubSource creates a Source for the GitHub REST API at baseURL, e.g.
+// "https://api.github.com". token may be empty for public repositories. If
+// httpClient is nil, a client with a 10s timeout and trace propagation is
+// used.
+func NewGitHubSource(baseURL, token string, httpClient *http.Client) *HTTPSource {
+	headers := map[string]string{
+		"Accept":               "application/vnd.github.raw+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	if token != "" {
+		headers["Authorization"] = "Bearer " + token
+	}
+	return newHTTPSource(baseURL, githubFileURL, headers, httpClient)
+}
+
+// NewGitLabSource creates a Source for the GitLab REST API at baseURL, e.g.
+// "https://gitlab.com/api/v4". token may be empty for public projects. If
+// httpClient is nil, a client with a 10s timeout and trace propagation is
+// used.
+func NewGitLabSource(baseURL, token string, httpClient *http.Client) *HTTPSource {
+	headers := map[string]string{}
+	if token != "" {
+		headers["PRIVATE-TOKEN"] = token
+	}
+	return newHTTPSource(baseURL, gitlabFileURL, headers, httpClient)
+}
+
+func newHTTPSource(baseURL string, fileURL func(baseURL, repo, ref, path string) string, headers map[string]string, httpClient *http.Client) *HTTPSource {
+	if httpClient == nil {
+		httpClient = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: otelhttp.NewTransport(http.DefaultTransport),
+		}
+	}
+	return &HTTPSource{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		fileURL:    fileURL,
+		headers:    headers,
+		httpClient: httpClient,
+	}
+}
+
+// githubFileURL builds a contents API URL; the raw media type makes GitHub
+// return the file itself rather than a JSON description.
+func githubFileURL(baseURL, repo, ref, path string) string {
+	return fmt.Sprintf("%s/repos/%s/contents/%s?ref=%s",
+		baseURL, repo, escapePath(path), url.QueryEscape(ref))
+}
+
+// gitlabFileURL builds a repository files API URL. GitLab identifies the
+// project and file by their URL-encoded full paths.
+func gitlabFileURL(baseURL, repo, ref, path string) string {
+	return fmt.Sprintf("%s/projects/%s/repository/files/%s/raw?ref=%s",
+		baseURL, url.PathEscape(repo), url.PathEscape(path), url.QueryEscape(ref))
+}
+
+// escapePath escapes each segment of a slash-separated path.
+func escapePath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, s := range segments {
+		segments[i] = url.PathEscape(s)
+	}
+	return strings.Join(segments, "/")
+}
+
+// ReadFile returns the contents of path in repo at ref.
+func (s *HTTPSource) ReadFile(ctx context.Context, repo, ref, path string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.fileURL(s.baseURL, repo, ref, path), nil)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	for k, v := range s.headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("read %s@%s: %w", path, ref, err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("read %s@%s: %w", path, ref, ErrFileNotFound)
+	default:
+		return nil, fmt.Errorf("read %s@%s: source returned %d", path, ref, resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read %s@%s: %w", path, ref, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,70 @@
+package webhook_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/envmanager/internal/webhook"
+)
+
+func TestHTTPSource(t *testing.T) {
+	tests := []struct {
+		name      string
+		newSource func(baseURL string) *webhook.HTTPSource
+		wantURI   string
+		header    string
+		value     string
+	}{
+		{
+			name: "github",
+			newSource: func(baseURL string) *webhook.HTTPSource {
+				return webhook.NewGitHubSource(baseURL, "gh-token", nil)
+			},
+			wantURI: "/repos/acme/storefront/contents/packages/reviews/schema.graphql?ref=feature%2Freviews",
+			header:  "Authorization",
+			value:   "Bearer gh-token",
+		},
+		{
+			name: "gitlab",
+			newSource: func(baseURL string) *webhook.HTTPSource {
+				return webhook.NewGitLabSource(baseURL, "gl-token", nil)
+			},
+			wantURI: "/projects/acme%2Fstorefront/repository/files/packages%2Freviews%2Fschema.graphql/raw?ref=feature%2Freviews",
+			header:  "PRIVATE-TOKEN",
+			value:   "gl-token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.RequestURI != tt.wantURI {
+					http.NotFound(w, r)
+					return
+				}
+				if got := r.Header.Get(tt.header); got != tt.value {
+					t.Errorf("expected %s %q, got %q", tt.header, tt.value, got)
+				}
+				_, _ = w.Write([]byte("type Review { id: ID! }"))
+			}))
+			defer srv.Close()
+			source := tt.newSource(srv.URL)
+
+			data, err := source.ReadFile(context.Background(), "acme/storefront", "feature/reviews", "packages/reviews/schema.graphql")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if string(data) != "type Review { id: ID! }" {
+				t.Fatalf("unexpected contents %q", data)
+			}
+
+			_, err = source.ReadFile(context.Background(), "acme/storefront", "feature/reviews", "missing.graphql")
+			if !errors.Is(err, webhook.ErrFileNotFound) {
+				t.Fatalf("expected ErrFileNotFound, got %v", err)
+			}
+		})
+	}
+}
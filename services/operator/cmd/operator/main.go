@@ -1,8 +1,10 @@
 // Package main is the entrypoint for the operator service.
 //
-// In a real Kubernetes cluster, this would use controller-runtime to watch
-// APIGraph CRDs. For local development, it runs as an HTTP service that
-// accepts reconciliation requests and polls the builder service for changes.
+// It always serves an HTTP API. With OPERATOR_MODE=crd it reconciles the
+// APIGraph custom resources in its namespace; otherwise it accepts
+// reconciliation requests over HTTP and polls the builder service for
+// changes, creating real resources with OPERATOR_MODE=k8s and only logging
+// them in the default dev mode.
 package main
 
 import (
@@ -25,13 +27,16 @@ import (
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.24.0"
 
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
 
 	"github.com/lennyburdette/turbo-engine/services/operator/internal/applier"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/controller"
 	"github.com/lennyburdette/turbo-engine/services/operator/internal/handler"
 	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
 	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/watcher"
 )
 
 const serviceName = "operator"
@@ -70,28 +75,46 @@ func main() {
 	operatorMode := getEnv("OPERATOR_MODE", "dev")
 	namespace := getEnv("OPERATOR_NAMESPACE", "turbo-engine-e2e")
 	var app reconciler.Applier
+	var clientset kubernetes.Interface
+	var dynamicClient dynamic.Interface
+	var recOpts []reconciler.Option
 
 	switch operatorMode {
-	case "k8s":
-		logger.Info("operator mode: k8s — will create real Kubernetes resources")
+	case "k8s", "crd":
+		logger.Info("operator mode: " + operatorMode + " — will create real Kubernetes resources")
 		config, err := rest.InClusterConfig()
 		if err != nil {
 			logger.Error("failed to get in-cluster config", "error", err)
 			os.Exit(1)
 		}
-		clientset, err := kubernetes.NewForConfig(config)
+		clientset, err = kubernetes.NewForConfig(config)
 		if err != nil {
 			logger.Error("failed to create Kubernetes client", "error", err)
 			os.Exit(1)
 		}
+		if operatorMode == "crd" {
+			dynamicClient, err = dynamic.NewForConfig(config)
+			if err != nil {
+				logger.Error("failed to create dynamic Kubernetes client", "error", err)
+				os.Exit(1)
+			}
+		}
 		app = applier.NewKubernetesApplier(clientset, logger)
+		recOpts = append(recOpts, reconciler.WithObservedReadiness())
+		if getEnv("DRIFT_REPAIR", "false") == "true" {
+			recOpts = append(recOpts, reconciler.WithDriftRepair())
+		}
 	default:
 		logger.Info("operator mode: dev — actions will be logged only")
 		app = applier.NewNoopApplier(logger)
 	}
 
-	// Create the reconciler.
-	rec := reconciler.New(logger, app, namespace)
+	// Create the reconciler and rebuild what was deployed before a restart,
+	// so that nothing is redeployed and statuses are served straight away.
+	rec := reconciler.New(logger, app, namespace, recOpts...)
+	if err := rec.Recover(ctx); err != nil {
+		logger.Warn("failed to recover deployed state, starting empty", "error", err)
+	}
 
 	// Create HTTP handler and mux.
 	h := handler.New(rec, logger)
@@ -116,17 +139,54 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
-	// Start the builder polling loop.
-	builderURL := getEnv("BUILDER_URL", "http://localhost:8082")
-	pollInterval := parseDuration(getEnv("POLL_INTERVAL", "30s"), 30*time.Second)
+	// Reconcile APIGraph resources, or else start the builder polling loop.
 	var wg sync.WaitGroup
+	if dynamicClient != nil {
+		c := controller.New(dynamicClient, namespace, rec, logger)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := c.Run(ctx); err != nil {
+				logger.Error("APIGraph controller stopped", "error", err)
+			}
+		}()
+	} else {
+		builderURL := getEnv("BUILDER_URL", "http://localhost:8082")
+		pollInterval := parseDuration(getEnv("POLL_INTERVAL", "30s"), 30*time.Second)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pollBuilder(ctx, logger, rec, builderURL, pollInterval)
+		}()
+	}
 
+	// Retry failed actions with backoff.
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		pollBuilder(ctx, logger, rec, builderURL, pollInterval)
+		rec.RunRetries(ctx)
 	}()
 
+	// Watch the cluster for each component's actual readiness and for
+	// drift, and check for drift periodically too.
+	if clientset != nil {
+		driftInterval := parseDuration(getEnv("DRIFT_CHECK_INTERVAL", "5m"), 5*time.Minute)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec.RunDriftChecks(ctx, driftInterval)
+		}()
+
+		w := watcher.New(clientset, namespace, rec, logger)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := w.Run(ctx); err != nil {
+				logger.Error("readiness watcher stopped", "error", err)
+			}
+		}()
+	}
+
 	// Start the HTTP server.
 	go func() {
 		logger.Info("listening", "addr", addr)
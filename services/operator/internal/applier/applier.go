@@ -4,14 +4,19 @@ package applier
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 
-	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	metav1ac "k8s.io/client-go/applyconfigurations/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
 	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
@@ -30,45 +35,84 @@ func componentImage(comp model.DeployedComponent) string {
 	return defaultImage
 }
 
-// labels builds standard labels for operator-managed resources.
-func labels(environmentID, componentName string) map[string]string {
-	return map[string]string{
-		"app.kubernetes.io/managed-by": "turbo-engine-operator",
-		"turboengine.io/environment":   environmentID,
-		"turboengine.io/component":     componentName,
-		"app.kubernetes.io/name":       componentName,
-		"app.kubernetes.io/instance":   environmentID,
+// Environment variables through which an upstream override reaches the
+// component. Headers are JSON-encoded.
+const (
+	upstreamURLKey     = "UPSTREAM_URL"
+	upstreamHeadersKey = "UPSTREAM_HEADERS"
+)
+
+// configData returns the ConfigMap data for a component: its runtime env
+// plus its upstream override, if any. The upstream takes precedence over
+// runtime env entries of the same name.
+func configData(comp model.DeployedComponent) map[string]string {
+	if comp.Upstream == nil {
+		return comp.Runtime.Env
+	}
+	data := make(map[string]string, len(comp.Runtime.Env)+2)
+	maps.Copy(data, comp.Runtime.Env)
+	data[upstreamURLKey] = comp.Upstream.URL
+	if len(comp.Upstream.Headers) > 0 {
+		// Marshalling a map[string]string cannot fail.
+		headers, _ := json.Marshal(comp.Upstream.Headers)
+		data[upstreamHeadersKey] = string(headers)
 	}
+	maps.Copy(data, authConfigData(comp.Upstream.Auth))
+	return data
 }
 
-// findComponent looks up a component by its deployment resource name.
-func findComponent(spec model.APIGraphSpec, resourceName string) (model.DeployedComponent, bool) {
-	for _, c := range spec.Components {
-		if fmt.Sprintf("deploy-%s", c.PackageName) == resourceName {
-			return c, true
-		}
+// Standard labels on every operator-managed resource and its pods.
+const (
+	ManagedByLabel   = "app.kubernetes.io/managed-by"
+	ManagedBy        = "turbo-engine-operator"
+	EnvironmentLabel = "turboengine.io/environment"
+	ComponentLabel   = "turboengine.io/component"
+
+	// ManagedSelector selects every operator-managed resource.
+	ManagedSelector = ManagedByLabel + "=" + ManagedBy
+)
+
+// standardLabels returns the labels the operator itself puts on a
+// component's resources.
+func standardLabels(environmentID, componentName string) map[string]string {
+	return map[string]string{
+		ManagedByLabel:               ManagedBy,
+		EnvironmentLabel:             environmentID,
+		ComponentLabel:               componentName,
+		"app.kubernetes.io/name":     componentName,
+		"app.kubernetes.io/instance": environmentID,
 	}
-	return model.DeployedComponent{}, false
 }
 
-// findComponentBySvc looks up a component by its service resource name.
-func findComponentBySvc(spec model.APIGraphSpec, resourceName string) (model.DeployedComponent, bool) {
+// labels builds the labels for operator-managed resources: the
+// environment's own labels from the spec, overlaid with the standard ones so
+// that a user label can never break the operator's selectors.
+func labels(spec model.APIGraphSpec, environmentID, componentName string) map[string]string {
+	lbls := make(map[string]string, len(spec.Labels)+5)
+	maps.Copy(lbls, spec.Labels)
+	maps.Copy(lbls, standardLabels(environmentID, componentName))
+	return lbls
+}
+
+// findComponent looks up the component whose resource, as named by name, is
+// resourceName.
+func findComponent(spec model.APIGraphSpec, envID, resourceName string, name func(environmentID, packageName string) string) (model.DeployedComponent, bool) {
 	for _, c := range spec.Components {
-		if fmt.Sprintf("svc-%s", c.PackageName) == resourceName {
+		if name(envID, c.PackageName) == resourceName {
 			return c, true
 		}
 	}
 	return model.DeployedComponent{}, false
 }
 
-// findComponentByCM looks up a component by its configmap resource name.
-func findComponentByCM(spec model.APIGraphSpec, resourceName string) (model.DeployedComponent, bool) {
-	for _, c := range spec.Components {
-		if fmt.Sprintf("cm-%s", c.PackageName) == resourceName {
-			return c, true
-		}
+// appliesResource reports whether an action creates or updates one of an
+// environment's Kubernetes resources.
+func appliesResource(action reconciler.Action) bool {
+	switch action.ResourceKind {
+	case "Deployment", "Service", "ConfigMap", "Secret":
+		return action.Type != reconciler.ActionDelete
 	}
-	return model.DeployedComponent{}, false
+	return false
 }
 
 // KubernetesApplier applies actions to a real Kubernetes cluster.
@@ -86,7 +130,20 @@ func NewKubernetesApplier(client kubernetes.Interface, logger *slog.Logger) *Kub
 }
 
 // Apply creates/updates/deletes Kubernetes resources based on the action list.
+// Resources are created and updated with server-side apply as FieldManager,
+// owned by the environment's parent ConfigMap so that deleting it deletes
+// them too. It applies every action it can and returns a
+// *reconciler.ApplyError listing those that failed; an update that would
+// change fields another manager owns fails with a *ConflictError unless the
+// action is forced.
 func (a *KubernetesApplier) Apply(ctx context.Context, namespace, environmentID string, actions []reconciler.Action, spec model.APIGraphSpec) error {
+	var opts applyOptions
+	var parentErr error
+	if slices.ContainsFunc(actions, appliesResource) {
+		opts.owner, parentErr = a.applyParent(ctx, namespace, environmentID)
+	}
+
+	var failed []reconciler.ActionError
 	for _, action := range actions {
 		a.logger.InfoContext(ctx, "applying action",
 			"type", action.Type,
@@ -95,24 +152,29 @@ func (a *KubernetesApplier) Apply(ctx context.Context, namespace, environmentID
 			"details", action.Details,
 		)
 
+		opts.force = action.Force
 		var err error
 		switch {
-		case action.ResourceKind == "Deployment" && action.Type == reconciler.ActionCreate:
-			err = a.createDeployment(ctx, namespace, environmentID, action.ResourceName, spec)
-		case action.ResourceKind == "Deployment" && action.Type == reconciler.ActionUpdate:
-			err = a.updateDeployment(ctx, namespace, environmentID, action.ResourceName, spec)
+		case parentErr != nil && appliesResource(action):
+			err = parentErr
 		case action.ResourceKind == "Deployment" && action.Type == reconciler.ActionDelete:
 			err = a.deleteDeployment(ctx, namespace, action.ResourceName)
-		case action.ResourceKind == "Service" && action.Type == reconciler.ActionCreate:
-			err = a.createService(ctx, namespace, environmentID, action.ResourceName, spec)
+		case action.ResourceKind == "Deployment" && action.Type == reconciler.ActionCreate:
+			err = a.createDeployment(ctx, namespace, environmentID, action.ResourceName, spec, opts)
+		case action.ResourceKind == "Deployment":
+			err = a.applyDeployment(ctx, namespace, environmentID, action.ResourceName, spec, opts)
 		case action.ResourceKind == "Service" && action.Type == reconciler.ActionDelete:
 			err = a.deleteService(ctx, namespace, action.ResourceName)
-		case action.ResourceKind == "ConfigMap" && action.Type == reconciler.ActionCreate:
-			err = a.createConfigMap(ctx, namespace, environmentID, action.ResourceName, spec)
-		case action.ResourceKind == "ConfigMap" && action.Type == reconciler.ActionUpdate:
-			err = a.updateConfigMap(ctx, namespace, environmentID, action.ResourceName, spec)
+		case action.ResourceKind == "Service":
+			err = a.applyService(ctx, namespace, environmentID, action.ResourceName, spec, opts)
 		case action.ResourceKind == "ConfigMap" && action.Type == reconciler.ActionDelete:
 			err = a.deleteConfigMap(ctx, namespace, action.ResourceName)
+		case action.ResourceKind == "ConfigMap":
+			err = a.applyConfigMap(ctx, namespace, environmentID, action.ResourceName, spec, opts)
+		case action.ResourceKind == "Secret" && action.Type == reconciler.ActionDelete:
+			err = a.deleteSecret(ctx, namespace, action.ResourceName)
+		case action.ResourceKind == "Secret":
+			err = a.applySecret(ctx, namespace, environmentID, action.ResourceName, spec, opts)
 		case action.ResourceKind == "Ingress":
 			// Ingress routing is handled by the gateway polling /v1/gateway-config
 			// rather than by creating K8s Ingress resources, so this is a no-op.
@@ -129,112 +191,72 @@ func (a *KubernetesApplier) Apply(ctx context.Context, namespace, environmentID
 				"name", action.ResourceName,
 				"error", err,
 			)
-			return fmt.Errorf("applying %s %s %s: %w", action.Type, action.ResourceKind, action.ResourceName, err)
+			// Keep going: the remaining actions don't depend on this one,
+			// and the reconciler retries only those that failed.
+			failed = append(failed, reconciler.ActionError{
+				Action: action,
+				Err:    fmt.Errorf("applying %s %s %s: %w", action.Type, action.ResourceKind, action.ResourceName, err),
+			})
 		}
 	}
+	if len(failed) > 0 {
+		return &reconciler.ApplyError{Failed: failed}
+	}
 	return nil
 }
 
-func (a *KubernetesApplier) createDeployment(ctx context.Context, ns, envID, name string, spec model.APIGraphSpec) error {
-	comp, ok := findComponent(spec, name)
+// applyDeployment applies a component's Deployment.
+func (a *KubernetesApplier) applyDeployment(ctx context.Context, ns, envID, name string, spec model.APIGraphSpec, opts applyOptions) error {
+	comp, ok := findComponent(spec, envID, name, reconciler.DeploymentName)
 	if !ok {
 		return fmt.Errorf("component not found for deployment %s", name)
 	}
 
-	replicas := comp.Runtime.Replicas
-	lbls := labels(envID, comp.PackageName)
-
-	deploy := &appsv1.Deployment{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: ns,
-			Labels:    lbls,
-			Annotations: map[string]string{
-				"turboengine.io/artifact-hash": comp.ArtifactHash,
-				"turboengine.io/build-id":      spec.BuildID,
-			},
-		},
-		Spec: appsv1.DeploymentSpec{
-			Replicas: &replicas,
-			Selector: &metav1.LabelSelector{
-				MatchLabels: map[string]string{
-					"app.kubernetes.io/name":     comp.PackageName,
-					"app.kubernetes.io/instance": envID,
-				},
-			},
-			Template: corev1.PodTemplateSpec{
-				ObjectMeta: metav1.ObjectMeta{
-					Labels: lbls,
-					Annotations: map[string]string{
-						"turboengine.io/artifact-hash": comp.ArtifactHash,
-					},
-				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{
-						{
-							Name:  comp.PackageName,
-							Image: componentImage(comp),
-							EnvFrom: []corev1.EnvFromSource{
-								{
-									ConfigMapRef: &corev1.ConfigMapEnvSource{
-										LocalObjectReference: corev1.LocalObjectReference{
-											Name: fmt.Sprintf("cm-%s", comp.PackageName),
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-		},
+	live, err := a.client.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
+	switch {
+	case errors.IsNotFound(err):
+		live = nil
+	case err != nil:
+		return fmt.Errorf("getting deployment %s: %w", name, err)
 	}
 
-	_, err := a.client.AppsV1().Deployments(ns).Create(ctx, deploy, metav1.CreateOptions{})
-	if errors.IsAlreadyExists(err) {
-		return a.updateDeployment(ctx, ns, envID, name, spec)
-	}
-	return err
-}
-
-func (a *KubernetesApplier) updateDeployment(ctx context.Context, ns, envID, name string, spec model.APIGraphSpec) error {
-	comp, ok := findComponent(spec, name)
-	if !ok {
-		return fmt.Errorf("component not found for deployment %s", name)
+	lbls := labels(spec, envID, comp.PackageName)
+	deploySpec := appsv1ac.DeploymentSpec()
+	if appliesReplicas(comp, live) {
+		deploySpec.WithReplicas(comp.Runtime.Replicas)
 	}
-
-	existing, err := a.client.AppsV1().Deployments(ns).Get(ctx, name, metav1.GetOptions{})
-	if err != nil {
+	deploy := appsv1ac.Deployment(name, ns).
+		WithLabels(lbls).
+		WithAnnotations(deploymentAnnotations(comp, spec.BuildID)).
+		WithOwnerReferences(opts.owner).
+		WithSpec(deploySpec.
+			WithSelector(metav1ac.LabelSelector().WithMatchLabels(map[string]string{
+				"app.kubernetes.io/name":     comp.PackageName,
+				"app.kubernetes.io/instance": envID,
+			})).
+			WithTemplate(corev1ac.PodTemplateSpec().
+				WithLabels(lbls).
+				WithAnnotations(podAnnotations(comp)).
+				WithSpec(corev1ac.PodSpec().
+					// The container reads its env vars from the ConfigMap
+					// and its secrets from the component's Secret.
+					WithContainers(corev1ac.Container().
+						WithName(comp.PackageName).
+						WithImage(componentImage(comp)).
+						WithEnvFrom(corev1ac.EnvFromSource().
+							WithConfigMapRef(corev1ac.ConfigMapEnvSource().
+								WithName(reconciler.ConfigMapName(envID, comp.PackageName)))).
+						WithEnv(secretEnv(envID, comp)...).
+						WithVolumeMounts(secretVolumeMounts(comp)...)).
+					WithVolumes(secretVolumes(envID, comp)...))))
+
+	// Scaling to zero suspends an autoscaler, so sleep takes the replicas
+	// from it rather than conflicting; waking restores them for it to scale.
+	force := opts.force || (sleeping(comp) && live != nil && replicasManagedElsewhere(live))
+	return a.apply(ctx, "Deployment", name, force, func(o metav1.ApplyOptions) error {
+		_, err := a.client.AppsV1().Deployments(ns).Apply(ctx, deploy, o)
 		return err
-	}
-
-	replicas := comp.Runtime.Replicas
-	existing.Spec.Replicas = &replicas
-	if existing.Annotations == nil {
-		existing.Annotations = make(map[string]string)
-	}
-	existing.Annotations["turboengine.io/artifact-hash"] = comp.ArtifactHash
-	existing.Annotations["turboengine.io/build-id"] = spec.BuildID
-	if existing.Spec.Template.Annotations == nil {
-		existing.Spec.Template.Annotations = make(map[string]string)
-	}
-	existing.Spec.Template.Annotations["turboengine.io/artifact-hash"] = comp.ArtifactHash
-
-	// Ensure containers reference the ConfigMap for env vars.
-	if len(existing.Spec.Template.Spec.Containers) > 0 {
-		existing.Spec.Template.Spec.Containers[0].EnvFrom = []corev1.EnvFromSource{
-			{
-				ConfigMapRef: &corev1.ConfigMapEnvSource{
-					LocalObjectReference: corev1.LocalObjectReference{
-						Name: fmt.Sprintf("cm-%s", comp.PackageName),
-					},
-				},
-			},
-		}
-	}
-
-	_, err = a.client.AppsV1().Deployments(ns).Update(ctx, existing, metav1.UpdateOptions{})
-	return err
+	})
 }
 
 func (a *KubernetesApplier) deleteDeployment(ctx context.Context, ns, name string) error {
@@ -245,40 +267,32 @@ func (a *KubernetesApplier) deleteDeployment(ctx context.Context, ns, name strin
 	return err
 }
 
-func (a *KubernetesApplier) createService(ctx context.Context, ns, envID, name string, spec model.APIGraphSpec) error {
-	comp, ok := findComponentBySvc(spec, name)
+// applyService applies a component's Service.
+func (a *KubernetesApplier) applyService(ctx context.Context, ns, envID, name string, spec model.APIGraphSpec, opts applyOptions) error {
+	comp, ok := findComponent(spec, envID, name, reconciler.ServiceName)
 	if !ok {
 		return fmt.Errorf("component not found for service %s", name)
 	}
 
-	svc := &corev1.Service{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: ns,
-			Labels:    labels(envID, comp.PackageName),
-		},
-		Spec: corev1.ServiceSpec{
-			Selector: map[string]string{
+	svc := corev1ac.Service(name, ns).
+		WithLabels(labels(spec, envID, comp.PackageName)).
+		WithOwnerReferences(opts.owner).
+		WithSpec(corev1ac.ServiceSpec().
+			WithSelector(map[string]string{
 				"app.kubernetes.io/name":     comp.PackageName,
 				"app.kubernetes.io/instance": envID,
-			},
-			Type: corev1.ServiceTypeClusterIP,
-			Ports: []corev1.ServicePort{
-				{
-					Name:       "http",
-					Port:       8080,
-					TargetPort: intstr.FromInt32(8080),
-					Protocol:   corev1.ProtocolTCP,
-				},
-			},
-		},
-	}
-
-	_, err := a.client.CoreV1().Services(ns).Create(ctx, svc, metav1.CreateOptions{})
-	if errors.IsAlreadyExists(err) {
-		return nil // Services are immutable-ish; skip update
-	}
-	return err
+			}).
+			WithType(corev1.ServiceTypeClusterIP).
+			WithPorts(corev1ac.ServicePort().
+				WithName("http").
+				WithPort(8080).
+				WithTargetPort(intstr.FromInt32(8080)).
+				WithProtocol(corev1.ProtocolTCP)))
+
+	return a.apply(ctx, "Service", name, opts.force, func(o metav1.ApplyOptions) error {
+		_, err := a.client.CoreV1().Services(ns).Apply(ctx, svc, o)
+		return err
+	})
 }
 
 func (a *KubernetesApplier) deleteService(ctx context.Context, ns, name string) error {
@@ -289,42 +303,22 @@ func (a *KubernetesApplier) deleteService(ctx context.Context, ns, name string)
 	return err
 }
 
-func (a *KubernetesApplier) createConfigMap(ctx context.Context, ns, envID, name string, spec model.APIGraphSpec) error {
-	comp, ok := findComponentByCM(spec, name)
+// applyConfigMap applies a component's ConfigMap.
+func (a *KubernetesApplier) applyConfigMap(ctx context.Context, ns, envID, name string, spec model.APIGraphSpec, opts applyOptions) error {
+	comp, ok := findComponent(spec, envID, name, reconciler.ConfigMapName)
 	if !ok {
 		return fmt.Errorf("component not found for configmap %s", name)
 	}
 
-	cm := &corev1.ConfigMap{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
-			Namespace: ns,
-			Labels:    labels(envID, comp.PackageName),
-		},
-		Data: comp.Runtime.Env,
-	}
-
-	_, err := a.client.CoreV1().ConfigMaps(ns).Create(ctx, cm, metav1.CreateOptions{})
-	if errors.IsAlreadyExists(err) {
-		return a.updateConfigMap(ctx, ns, envID, name, spec)
-	}
-	return err
-}
-
-func (a *KubernetesApplier) updateConfigMap(ctx context.Context, ns, envID, name string, spec model.APIGraphSpec) error {
-	comp, ok := findComponentByCM(spec, name)
-	if !ok {
-		return fmt.Errorf("component not found for configmap %s", name)
-	}
+	cm := corev1ac.ConfigMap(name, ns).
+		WithLabels(labels(spec, envID, comp.PackageName)).
+		WithOwnerReferences(opts.owner).
+		WithData(configData(comp))
 
-	existing, err := a.client.CoreV1().ConfigMaps(ns).Get(ctx, name, metav1.GetOptions{})
-	if err != nil {
+	return a.apply(ctx, "ConfigMap", name, opts.force, func(o metav1.ApplyOptions) error {
+		_, err := a.client.CoreV1().ConfigMaps(ns).Apply(ctx, cm, o)
 		return err
-	}
-
-	existing.Data = comp.Runtime.Env
-	_, err = a.client.CoreV1().ConfigMaps(ns).Update(ctx, existing, metav1.UpdateOptions{})
-	return err
+	})
 }
 
 func (a *KubernetesApplier) deleteConfigMap(ctx context.Context, ns, name string) error {
@@ -0,0 +1,215 @@
+package applier
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+func TestConfigData(t *testing.T) {
+	comp := model.DeployedComponent{
+		PackageName: "users-api",
+		Runtime: model.ComponentRuntime{
+			Env: map[string]string{"LOG_LEVEL": "info", "UPSTREAM_URL": "http://users.default.svc"},
+		},
+	}
+
+	if got := configData(comp); len(got) != 2 || got["UPSTREAM_URL"] != "http://users.default.svc" {
+		t.Fatalf("expected runtime env unchanged without an upstream, got %v", got)
+	}
+
+	comp.Upstream = &model.UpstreamConfig{
+		URL:     "http://dev.internal:4001",
+		Headers: map[string]string{"x-dev": "alice"},
+	}
+	got := configData(comp)
+	if got["LOG_LEVEL"] != "info" || got["UPSTREAM_URL"] != "http://dev.internal:4001" {
+		t.Fatalf("expected the upstream merged over runtime env, got %v", got)
+	}
+	if got["UPSTREAM_HEADERS"] != `{"x-dev":"alice"}` {
+		t.Fatalf("expected JSON-encoded headers, got %q", got["UPSTREAM_HEADERS"])
+	}
+	if comp.Runtime.Env["UPSTREAM_URL"] != "http://users.default.svc" {
+		t.Fatal("configData must not modify the component's runtime env")
+	}
+}
+
+func TestLabels(t *testing.T) {
+	spec := model.APIGraphSpec{Labels: map[string]string{
+		"team":                       "payments",
+		"app.kubernetes.io/name":     "spoofed",
+		"turboengine.io/environment": "spoofed",
+	}}
+
+	got := labels(spec, "env-1", "users-api")
+	if got["team"] != "payments" {
+		t.Fatalf("expected the environment's labels, got %v", got)
+	}
+	if got["app.kubernetes.io/name"] != "users-api" || got["turboengine.io/environment"] != "env-1" {
+		t.Fatalf("expected the standard labels to win, got %v", got)
+	}
+	if spec.Labels["app.kubernetes.io/name"] != "spoofed" {
+		t.Fatal("labels must not modify the spec")
+	}
+}
+
+func TestApply_Relabel(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	a := NewKubernetesApplier(client, slog.Default())
+
+	spec := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		BuildID:       "build-1",
+		Components: []model.DeployedComponent{
+			{PackageName: "users-api", ArtifactHash: "abc123", Runtime: model.ComponentRuntime{Replicas: 1}},
+		},
+		Labels: map[string]string{"team": "payments", "purpose": "demo"},
+	}
+	create := []reconciler.Action{
+		{Type: reconciler.ActionCreate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api"},
+		{Type: reconciler.ActionCreate, ResourceKind: "Service", ResourceName: "svc-env-1-users-api"},
+		{Type: reconciler.ActionCreate, ResourceKind: "ConfigMap", ResourceName: "cm-env-1-users-api"},
+	}
+	if err := a.Apply(ctx, "test-ns", "env-1", create, spec); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	spec.Labels = map[string]string{"team": "payments"}
+	update := make([]reconciler.Action, len(create))
+	for i, action := range create {
+		action.Type = reconciler.ActionUpdate
+		update[i] = action
+	}
+	if err := a.Apply(ctx, "test-ns", "env-1", update, spec); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+
+	deploy, err := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+	svc, err := client.CoreV1().Services("test-ns").Get(ctx, "svc-env-1-users-api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get service: %v", err)
+	}
+	cm, err := client.CoreV1().ConfigMaps("test-ns").Get(ctx, "cm-env-1-users-api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get configmap: %v", err)
+	}
+	for kind, l := range map[string]map[string]string{
+		"Deployment":   deploy.Labels,
+		"pod template": deploy.Spec.Template.Labels,
+		"Service":      svc.Labels,
+		"ConfigMap":    cm.Labels,
+	} {
+		if _, ok := l["purpose"]; ok || l["team"] != "payments" || l["turboengine.io/environment"] != "env-1" {
+			t.Errorf("%s: expected team and the standard labels without purpose, got %v", kind, l)
+		}
+	}
+}
+
+func TestReconcile_SamePackageInTwoEnvironments(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	a := NewKubernetesApplier(client, slog.Default())
+	r := reconciler.New(slog.Default(), a, "test-ns")
+
+	for _, env := range []struct{ id, image string }{{"env-1", "users:1"}, {"env-2", "users:2"}} {
+		spec := model.APIGraphSpec{
+			EnvironmentID: env.id,
+			BuildID:       "build-1",
+			Components: []model.DeployedComponent{{
+				PackageName: "users-api",
+				Runtime:     model.ComponentRuntime{Replicas: 1, Image: env.image, SecretEnv: map[string]string{"API_KEY": "api-key"}},
+			}},
+			Secrets: map[string]string{"api-key": env.id + "-key"},
+		}
+		if _, _, err := r.Reconcile(ctx, spec); err != nil {
+			t.Fatalf("reconcile %s: %v", env.id, err)
+		}
+	}
+
+	for _, env := range []struct{ id, image string }{{"env-1", "users:1"}, {"env-2", "users:2"}} {
+		deploy, err := client.AppsV1().Deployments("test-ns").Get(ctx, reconciler.DeploymentName(env.id, "users-api"), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("%s: get deployment: %v", env.id, err)
+		}
+		container := deploy.Spec.Template.Spec.Containers[0]
+		if container.Image != env.image {
+			t.Errorf("%s: expected image %s, got %s", env.id, env.image, container.Image)
+		}
+		if cm := container.EnvFrom[0].ConfigMapRef.Name; cm != reconciler.ConfigMapName(env.id, "users-api") {
+			t.Errorf("%s: expected the environment's ConfigMap, got %s", env.id, cm)
+		}
+		if secret := container.Env[0].ValueFrom.SecretKeyRef.Name; secret != reconciler.SecretName(env.id, "users-api") {
+			t.Errorf("%s: expected the environment's Secret, got %s", env.id, secret)
+		}
+		secret, err := client.CoreV1().Secrets("test-ns").Get(ctx, reconciler.SecretName(env.id, "users-api"), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("%s: get secret: %v", env.id, err)
+		}
+		if got := string(secret.Data["api-key"]); got != env.id+"-key" {
+			t.Errorf("%s: expected the environment's secret value, got %q", env.id, got)
+		}
+		svc, err := client.CoreV1().Services("test-ns").Get(ctx, reconciler.ServiceName(env.id, "users-api"), metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("%s: get service: %v", env.id, err)
+		}
+		if svc.Spec.Selector["app.kubernetes.io/instance"] != env.id {
+			t.Errorf("%s: expected the Service to select the environment's pods, got %v", env.id, svc.Spec.Selector)
+		}
+	}
+
+	// Tearing one down leaves the other alone.
+	if _, err := a.DeleteEnvironment(ctx, "test-ns", "env-1"); err != nil {
+		t.Fatalf("delete environment: %v", err)
+	}
+	if _, err := client.AppsV1().Deployments("test-ns").Get(ctx, reconciler.DeploymentName("env-2", "users-api"), metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected env-2's Deployment kept: %v", err)
+	}
+}
+
+func TestApply_PartialFailure(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	client.PrependReactor("patch", "services", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	a := NewKubernetesApplier(client, slog.Default())
+
+	spec := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		BuildID:       "build-1",
+		Components: []model.DeployedComponent{
+			{PackageName: "users-api", ArtifactHash: "abc123", Runtime: model.ComponentRuntime{Replicas: 1}},
+		},
+	}
+	actions := []reconciler.Action{
+		{Type: reconciler.ActionCreate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api"},
+		{Type: reconciler.ActionCreate, ResourceKind: "Service", ResourceName: "svc-env-1-users-api"},
+		{Type: reconciler.ActionCreate, ResourceKind: "ConfigMap", ResourceName: "cm-env-1-users-api"},
+	}
+	err := a.Apply(ctx, "test-ns", "env-1", actions, spec)
+	var applyErr *reconciler.ApplyError
+	if !errors.As(err, &applyErr) {
+		t.Fatalf("expected an ApplyError, got %v", err)
+	}
+	if len(applyErr.Failed) != 1 || applyErr.Failed[0].Action != actions[1] {
+		t.Fatalf("expected only the Service to fail, got %+v", applyErr.Failed)
+	}
+
+	// The actions after the failed one were still applied.
+	if _, err := client.CoreV1().ConfigMaps("test-ns").Get(ctx, "cm-env-1-users-api", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected the ConfigMap to be created: %v", err)
+	}
+}
@@ -0,0 +1,188 @@
+package applier
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+// IgnoreDriftAnnotation opts a managed resource out of drift detection and
+// repair when set to "true", so that it can be edited by hand.
+const IgnoreDriftAnnotation = "turboengine.io/ignore-drift"
+
+// Drift compares the Deployment, Service and ConfigMap of each of spec's
+// components in namespace with what spec dictates. Resources annotated with
+// IgnoreDriftAnnotation are skipped.
+func (a *KubernetesApplier) Drift(ctx context.Context, namespace string, spec model.APIGraphSpec) ([]model.ResourceDrift, error) {
+	var drift []model.ResourceDrift
+	for _, comp := range spec.Components {
+		lbls := labels(spec, spec.EnvironmentID, comp.PackageName)
+
+		name := reconciler.DeploymentName(spec.EnvironmentID, comp.PackageName)
+		d, err := a.client.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case errors.IsNotFound(err):
+			drift = append(drift, missing("Deployment", name))
+		case err != nil:
+			return nil, fmt.Errorf("getting deployment %s: %w", name, err)
+		case !ignored(d.ObjectMeta):
+			drift = append(drift, deploymentDrift(d, comp, spec.EnvironmentID, lbls)...)
+		}
+
+		name = reconciler.ServiceName(spec.EnvironmentID, comp.PackageName)
+		svc, err := a.client.CoreV1().Services(namespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case errors.IsNotFound(err):
+			drift = append(drift, missing("Service", name))
+		case err != nil:
+			return nil, fmt.Errorf("getting service %s: %w", name, err)
+		case !ignored(svc.ObjectMeta):
+			drift = append(drift, serviceDrift(svc, comp, spec.EnvironmentID, lbls)...)
+		}
+
+		name = reconciler.ConfigMapName(spec.EnvironmentID, comp.PackageName)
+		cm, err := a.client.CoreV1().ConfigMaps(namespace).Get(ctx, name, metav1.GetOptions{})
+		switch {
+		case errors.IsNotFound(err):
+			drift = append(drift, missing("ConfigMap", name))
+		case err != nil:
+			return nil, fmt.Errorf("getting configmap %s: %w", name, err)
+		case !ignored(cm.ObjectMeta):
+			drift = append(drift, configMapDrift(cm, comp, lbls)...)
+		}
+	}
+	return drift, nil
+}
+
+func deploymentDrift(d *appsv1.Deployment, comp model.DeployedComponent, envID string, lbls map[string]string) []model.ResourceDrift {
+	var drift []model.ResourceDrift
+	add := func(field, format string, args ...any) {
+		drift = append(drift, model.ResourceDrift{
+			ResourceKind: "Deployment",
+			ResourceName: d.Name,
+			Field:        field,
+			Message:      fmt.Sprintf(format, args...),
+		})
+	}
+
+	// An unset replica count defaults to one. Replicas an autoscaler owns
+	// aren't the operator's to compare.
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	if appliesReplicas(comp, d) && replicas != comp.Runtime.Replicas {
+		add("replicas", "replicas are %d, want %d", replicas, comp.Runtime.Replicas)
+	}
+
+	containers := d.Spec.Template.Spec.Containers
+	if len(containers) == 0 {
+		add("image", "no containers, want image %s", componentImage(comp))
+	} else {
+		if image := componentImage(comp); containers[0].Image != image {
+			add("image", "image is %s, want %s", containers[0].Image, image)
+		}
+		if cm := reconciler.ConfigMapName(envID, comp.PackageName); !envFromConfigMap(containers[0], cm) {
+			add("env", "environment not from ConfigMap %s", cm)
+		}
+	}
+
+	if key, ok := missingLabel(d.Labels, lbls); ok {
+		add("labels", "label %s is %q, want %q", key, d.Labels[key], lbls[key])
+	} else if key, ok := missingLabel(d.Spec.Template.Labels, lbls); ok {
+		add("labels", "pod label %s is %q, want %q", key, d.Spec.Template.Labels[key], lbls[key])
+	}
+	return drift
+}
+
+func serviceDrift(svc *corev1.Service, comp model.DeployedComponent, envID string, lbls map[string]string) []model.ResourceDrift {
+	var drift []model.ResourceDrift
+	selector := map[string]string{
+		"app.kubernetes.io/name":     comp.PackageName,
+		"app.kubernetes.io/instance": envID,
+	}
+	if !maps.Equal(svc.Spec.Selector, selector) {
+		drift = append(drift, model.ResourceDrift{
+			ResourceKind: "Service",
+			ResourceName: svc.Name,
+			Field:        "selector",
+			Message:      fmt.Sprintf("selector is %v, want %v", svc.Spec.Selector, selector),
+		})
+	}
+	if key, ok := missingLabel(svc.Labels, lbls); ok {
+		drift = append(drift, model.ResourceDrift{
+			ResourceKind: "Service",
+			ResourceName: svc.Name,
+			Field:        "labels",
+			Message:      fmt.Sprintf("label %s is %q, want %q", key, svc.Labels[key], lbls[key]),
+		})
+	}
+	return drift
+}
+
+func configMapDrift(cm *corev1.ConfigMap, comp model.DeployedComponent, lbls map[string]string) []model.ResourceDrift {
+	var drift []model.ResourceDrift
+	// maps.Equal treats nil and empty data alike.
+	if !maps.Equal(cm.Data, configData(comp)) {
+		drift = append(drift, model.ResourceDrift{
+			ResourceKind: "ConfigMap",
+			ResourceName: cm.Name,
+			Field:        "data",
+			Message:      "data differs from the component's environment",
+		})
+	}
+	if key, ok := missingLabel(cm.Labels, lbls); ok {
+		drift = append(drift, model.ResourceDrift{
+			ResourceKind: "ConfigMap",
+			ResourceName: cm.Name,
+			Field:        "labels",
+			Message:      fmt.Sprintf("label %s is %q, want %q", key, cm.Labels[key], lbls[key]),
+		})
+	}
+	return drift
+}
+
+func missing(kind, name string) model.ResourceDrift {
+	return model.ResourceDrift{
+		ResourceKind: kind,
+		ResourceName: name,
+		Field:        "missing",
+		Message:      kind + " not found",
+	}
+}
+
+// ignored reports whether a resource is opted out of drift detection.
+func ignored(m metav1.ObjectMeta) bool {
+	return m.Annotations[IgnoreDriftAnnotation] == "true"
+}
+
+// missingLabel returns a key of want whose value in got differs. Labels in
+// got that aren't wanted are left alone.
+func missingLabel(got, want map[string]string) (string, bool) {
+	for _, key := range slices.Sorted(maps.Keys(want)) {
+		if got[key] != want[key] {
+			return key, true
+		}
+	}
+	return "", false
+}
+
+// envFromConfigMap reports whether c takes its environment from the named
+// ConfigMap.
+func envFromConfigMap(c corev1.Container, name string) bool {
+	for _, src := range c.EnvFrom {
+		if src.ConfigMapRef != nil && src.ConfigMapRef.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package applier
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+func TestDrift(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	a := NewKubernetesApplier(client, slog.Default())
+
+	spec := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		BuildID:       "build-1",
+		Components: []model.DeployedComponent{{
+			PackageName:  "users-api",
+			ArtifactHash: "abc123",
+			Runtime: model.ComponentRuntime{
+				Image:    "registry.example.com/users:1",
+				Replicas: 2,
+				Env:      map[string]string{"LOG_LEVEL": "info"},
+			},
+		}},
+		Labels: map[string]string{"team": "payments"},
+	}
+	create := []reconciler.Action{
+		{Type: reconciler.ActionCreate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api"},
+		{Type: reconciler.ActionCreate, ResourceKind: "Service", ResourceName: "svc-env-1-users-api"},
+		{Type: reconciler.ActionCreate, ResourceKind: "ConfigMap", ResourceName: "cm-env-1-users-api"},
+	}
+	if err := a.Apply(ctx, "test-ns", "env-1", create, spec); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if drift, err := a.Drift(ctx, "test-ns", spec); err != nil || len(drift) != 0 {
+		t.Fatalf("expected no drift after applying, got %+v %v", drift, err)
+	}
+
+	// Someone edits the Deployment and ConfigMap and deletes the Service.
+	d, _ := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{})
+	d.Spec.Template.Spec.Containers[0].Image = "registry.example.com/users:debug"
+	replicas := int32(5)
+	d.Spec.Replicas = &replicas
+	delete(d.Labels, "team")
+	if _, err := client.AppsV1().Deployments("test-ns").Update(ctx, d, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update deployment: %v", err)
+	}
+	cm, _ := client.CoreV1().ConfigMaps("test-ns").Get(ctx, "cm-env-1-users-api", metav1.GetOptions{})
+	cm.Data["LOG_LEVEL"] = "debug"
+	if _, err := client.CoreV1().ConfigMaps("test-ns").Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update configmap: %v", err)
+	}
+	if err := client.CoreV1().Services("test-ns").Delete(ctx, "svc-env-1-users-api", metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete service: %v", err)
+	}
+
+	drift, err := a.Drift(ctx, "test-ns", spec)
+	if err != nil {
+		t.Fatalf("drift: %v", err)
+	}
+	got := make(map[string]string)
+	for _, d := range drift {
+		got[d.ResourceName+"/"+d.Field] = d.Message
+	}
+	// The replicas are left to whoever scaled them, as an autoscaler's are.
+	want := map[string]string{
+		"deploy-env-1-users-api/image":  "image is registry.example.com/users:debug, want registry.example.com/users:1",
+		"deploy-env-1-users-api/labels": `label team is "", want "payments"`,
+		"svc-env-1-users-api/missing":   "Service not found",
+		"cm-env-1-users-api/data":       "data differs from the component's environment",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d drifts, got %v", len(want), got)
+	}
+	for key, msg := range want {
+		if got[key] != msg {
+			t.Errorf("%s: expected %q, got %q", key, msg, got[key])
+		}
+	}
+
+	// Applying the spec again with force repairs everything, taking back
+	// the fields the edits took over.
+	repair := []reconciler.Action{
+		{Type: reconciler.ActionUpdate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api", Force: true},
+		{Type: reconciler.ActionCreate, ResourceKind: "Service", ResourceName: "svc-env-1-users-api", Force: true},
+		{Type: reconciler.ActionUpdate, ResourceKind: "ConfigMap", ResourceName: "cm-env-1-users-api", Force: true},
+	}
+	if err := a.Apply(ctx, "test-ns", "env-1", repair, spec); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if drift, err := a.Drift(ctx, "test-ns", spec); err != nil || len(drift) != 0 {
+		t.Fatalf("expected no drift after repairing, got %+v %v", drift, err)
+	}
+	d, _ = client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{})
+	if *d.Spec.Replicas != 5 {
+		t.Fatalf("expected the replicas left alone, got %d", *d.Spec.Replicas)
+	}
+
+	// A resource opted out of drift detection can be edited freely.
+	cm, _ = client.CoreV1().ConfigMaps("test-ns").Get(ctx, "cm-env-1-users-api", metav1.GetOptions{})
+	cm.Annotations = map[string]string{IgnoreDriftAnnotation: "true"}
+	cm.Data["LOG_LEVEL"] = "debug"
+	if _, err := client.CoreV1().ConfigMaps("test-ns").Update(ctx, cm, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update configmap: %v", err)
+	}
+	if drift, err := a.Drift(ctx, "test-ns", spec); err != nil || len(drift) != 0 {
+		t.Fatalf("expected the ignored ConfigMap not to drift, got %+v %v", drift, err)
+	}
+}
@@ -0,0 +1,95 @@
+package applier
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+// legacyName returns the name a component's resource of kind had before
+// resources were named for their environment as well as their package, or
+// "" for kinds the operator doesn't name.
+//
+// An operator upgraded past the rename doesn't recover components from
+// their legacy-named Deployments, so the next reconcile creates them under
+// their new names, and creating a component's Deployment deletes its
+// legacy-named resources.
+func legacyName(kind, packageName string) string {
+	switch kind {
+	case "Deployment":
+		return "deploy-" + packageName
+	case "Service":
+		return "svc-" + packageName
+	case "ConfigMap":
+		return "cm-" + packageName
+	case "Secret":
+		return "secret-" + packageName
+	}
+	return ""
+}
+
+// createDeployment applies a new component's Deployment, then deletes the
+// component's legacy-named resources.
+func (a *KubernetesApplier) createDeployment(ctx context.Context, ns, envID, name string, spec model.APIGraphSpec, opts applyOptions) error {
+	if err := a.applyDeployment(ctx, ns, envID, name, spec, opts); err != nil {
+		return err
+	}
+	comp, _ := findComponent(spec, envID, name, reconciler.DeploymentName)
+	return a.deleteLegacy(ctx, ns, envID, spec, comp.PackageName)
+}
+
+// deleteLegacy deletes the legacy-named resources of spec's component
+// packageName that are labelled with envID in namespace. A name that one of
+// the environment's current resources has is never legacy.
+func (a *KubernetesApplier) deleteLegacy(ctx context.Context, namespace, envID string, spec model.APIGraphSpec, packageName string) error {
+	resources, err := a.listEnvironment(ctx, namespace, envID)
+	if err != nil {
+		return err
+	}
+
+	current := make(map[string]bool)
+	for _, c := range spec.Components {
+		for _, name := range []string{
+			"Deployment/" + reconciler.DeploymentName(envID, c.PackageName),
+			"Service/" + reconciler.ServiceName(envID, c.PackageName),
+			"ConfigMap/" + reconciler.ConfigMapName(envID, c.PackageName),
+			"Secret/" + reconciler.SecretName(envID, c.PackageName),
+			"ConfigMap/" + parentName(envID),
+		} {
+			current[name] = true
+		}
+	}
+	legacy := make(map[string]bool)
+	for _, kind := range []string{"Deployment", "Service", "ConfigMap", "Secret"} {
+		if key := kind + "/" + legacyName(kind, packageName); !current[key] {
+			legacy[key] = true
+		}
+	}
+
+	for _, res := range resources {
+		if res.deleting || !legacy[res.kind+"/"+res.name] {
+			continue
+		}
+		switch res.kind {
+		case "Deployment":
+			err = a.deleteDeployment(ctx, namespace, res.name)
+		case "Service":
+			err = a.deleteService(ctx, namespace, res.name)
+		case "ConfigMap":
+			err = a.deleteConfigMap(ctx, namespace, res.name)
+		case "Secret":
+			err = a.deleteSecret(ctx, namespace, res.name)
+		}
+		if err != nil {
+			return fmt.Errorf("deleting legacy %s %s: %w", res.kind, res.name, err)
+		}
+		a.logger.InfoContext(ctx, "deleted legacy-named resource",
+			"environment_id", envID,
+			"kind", res.kind,
+			"name", res.name,
+		)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package applier
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+// legacyResources returns a component's resources as they were named
+// before the rename.
+func legacyResources(envID, pkg string) []runtime.Object {
+	meta := func(name string) metav1.ObjectMeta {
+		return metav1.ObjectMeta{Name: name, Namespace: "test-ns", Labels: standardLabels(envID, pkg)}
+	}
+	return []runtime.Object{
+		&appsv1.Deployment{ObjectMeta: meta("deploy-" + pkg)},
+		&corev1.Service{ObjectMeta: meta("svc-" + pkg)},
+		&corev1.ConfigMap{ObjectMeta: meta("cm-" + pkg)},
+		&corev1.Secret{ObjectMeta: meta("secret-" + pkg)},
+	}
+}
+
+func TestLegacyNames(t *testing.T) {
+	ctx := context.Background()
+	objects := append(legacyResources("env-1", "users-api"), legacyResources("env-2", "orders-api")...)
+	client := fake.NewClientset(objects...)
+	a := NewKubernetesApplier(client, slog.Default())
+
+	// Legacy-named Deployments aren't recovered, so a restarted operator
+	// creates their components anew.
+	specs, err := a.LoadDeployed(ctx, "test-ns")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(specs) != 0 {
+		t.Fatalf("expected no legacy environments recovered, got %+v", specs)
+	}
+	r := reconciler.New(slog.Default(), a, "test-ns")
+	if err := r.Recover(ctx); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	spec := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		BuildID:       "build-1",
+		Components: []model.DeployedComponent{
+			{PackageName: "users-api", ArtifactHash: "abc123", Runtime: model.ComponentRuntime{Replicas: 1}},
+		},
+	}
+	if _, _, err := r.Reconcile(ctx, spec); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	// Creating the component deleted its legacy-named resources, leaving
+	// those of other environments alone.
+	if _, err := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected the renamed deployment: %v", err)
+	}
+	for kind, err := range map[string]error{
+		"Deployment": get(client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-users-api", metav1.GetOptions{})),
+		"Service":    get(client.CoreV1().Services("test-ns").Get(ctx, "svc-users-api", metav1.GetOptions{})),
+		"ConfigMap":  get(client.CoreV1().ConfigMaps("test-ns").Get(ctx, "cm-users-api", metav1.GetOptions{})),
+		"Secret":     get(client.CoreV1().Secrets("test-ns").Get(ctx, "secret-users-api", metav1.GetOptions{})),
+	} {
+		if !errors.IsNotFound(err) {
+			t.Errorf("%s: expected the legacy-named resource deleted, got %v", kind, err)
+		}
+	}
+	if _, err := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-orders-api", metav1.GetOptions{}); err != nil {
+		t.Fatalf("expected env-2's legacy deployment kept: %v", err)
+	}
+
+	// A legacy-named Deployment left beside its replacement isn't counted.
+	if _, err := client.AppsV1().Deployments("test-ns").Create(ctx, legacyResources("env-1", "users-api")[0].(*appsv1.Deployment), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	specs, err = a.LoadDeployed(ctx, "test-ns")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(specs) != 1 || len(specs[0].Components) != 1 {
+		t.Fatalf("expected env-1's one component, got %+v", specs)
+	}
+}
+
+// get returns the error of a Get.
+func get[T any](_ T, err error) error { return err }
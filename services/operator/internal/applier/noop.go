@@ -32,3 +32,12 @@ func (a *NoopApplier) Apply(ctx context.Context, namespace, environmentID string
 	}
 	return nil
 }
+
+// DeleteEnvironment logs the teardown but deletes nothing.
+func (a *NoopApplier) DeleteEnvironment(ctx context.Context, namespace, environmentID string) ([]string, error) {
+	a.logger.InfoContext(ctx, "would delete environment resources (noop)",
+		"environment_id", environmentID,
+		"namespace", namespace,
+	)
+	return nil, nil
+}
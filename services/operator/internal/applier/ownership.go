@@ -0,0 +1,175 @@
+package applier
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	metav1ac "k8s.io/client-go/applyconfigurations/meta/v1"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// FieldManager is the field manager every resource is applied as.
+const FieldManager = "turbo-engine-operator"
+
+// legacyFieldManager owns the fields the operator wrote before it used
+// server-side apply, when its updates were recorded under its binary's
+// name. Conflicts with it are taken over rather than reported.
+const legacyFieldManager = "operator"
+
+// parentName returns the name of an environment's parent ConfigMap, which
+// owns all of the environment's other resources.
+func parentName(environmentID string) string {
+	return fmt.Sprintf("env-%s", environmentID)
+}
+
+// applyOptions are the options an action's resource is applied with.
+type applyOptions struct {
+	// owner references the environment's parent.
+	owner *metav1ac.OwnerReferenceApplyConfiguration
+	// force takes over fields owned by other field managers.
+	force bool
+}
+
+// applyParent applies an environment's parent ConfigMap and returns a
+// reference to it, so that deleting it deletes the whole environment.
+func (a *KubernetesApplier) applyParent(ctx context.Context, ns, envID string) (*metav1ac.OwnerReferenceApplyConfiguration, error) {
+	name := parentName(envID)
+	cm := corev1ac.ConfigMap(name, ns).
+		WithLabels(map[string]string{
+			ManagedByLabel:               ManagedBy,
+			EnvironmentLabel:             envID,
+			"app.kubernetes.io/instance": envID,
+		})
+
+	var parent metav1.Object
+	err := a.apply(ctx, "ConfigMap", name, false, func(opts metav1.ApplyOptions) error {
+		applied, err := a.client.CoreV1().ConfigMaps(ns).Apply(ctx, cm, opts)
+		parent = applied
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("applying environment parent: %w", err)
+	}
+	return metav1ac.OwnerReference().
+		WithAPIVersion("v1").
+		WithKind("ConfigMap").
+		WithName(name).
+		WithUID(parent.GetUID()), nil
+}
+
+// apply runs a server-side apply of the named resource as FieldManager.
+// Conflicts with fields other managers own are returned as a
+// *ConflictError, unless force is set.
+func (a *KubernetesApplier) apply(ctx context.Context, kind, name string, force bool, apply func(metav1.ApplyOptions) error) error {
+	err := apply(metav1.ApplyOptions{FieldManager: FieldManager, Force: force})
+	conflicts := fieldConflicts(err)
+	if len(conflicts) == 0 {
+		return err
+	}
+	if !slices.ContainsFunc(conflicts, func(c FieldConflict) bool { return c.Manager != legacyFieldManager }) {
+		a.logger.InfoContext(ctx, "taking over fields written before server-side apply",
+			"kind", kind, "name", name, "fields", len(conflicts))
+		return apply(metav1.ApplyOptions{FieldManager: FieldManager, Force: true})
+	}
+	return &ConflictError{Kind: kind, Name: name, Conflicts: conflicts, Err: err}
+}
+
+// appliesReplicas reports whether comp's Deployment is applied with its
+// replica count. It isn't when the count is unset, nor when another field
+// manager, such as a HorizontalPodAutoscaler, owns the live Deployment's
+// replicas, so that applies neither conflict with the autoscaler nor undo
+// its scaling. A sleeping component is always scaled to zero. live is nil
+// before the Deployment is created.
+func appliesReplicas(comp model.DeployedComponent, live *appsv1.Deployment) bool {
+	if sleeping(comp) {
+		return true
+	}
+	if comp.Runtime.Replicas == 0 {
+		return false
+	}
+	return live == nil || !replicasManagedElsewhere(live)
+}
+
+// sleeping reports whether comp is scaled to zero to sleep.
+func sleeping(comp model.DeployedComponent) bool {
+	return comp.Runtime.Replicas == 0 && comp.AwakeReplicas > 0
+}
+
+// replicasManagedElsewhere reports whether a field manager other than the
+// operator owns d's .spec.replicas. Fields written before server-side apply
+// count as the operator's.
+func replicasManagedElsewhere(d *appsv1.Deployment) bool {
+	for _, entry := range d.ManagedFields {
+		if entry.Manager == FieldManager || entry.Manager == legacyFieldManager || entry.FieldsV1 == nil {
+			continue
+		}
+		var fields struct {
+			Spec map[string]json.RawMessage `json:"f:spec"`
+		}
+		if err := json.Unmarshal(entry.FieldsV1.Raw, &fields); err != nil {
+			continue
+		}
+		if _, ok := fields.Spec["f:replicas"]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// FieldConflict is a field that an apply would have changed but that
+// another field manager owns.
+type FieldConflict struct {
+	Manager string
+	Field   string
+}
+
+// ConflictError reports that applying a resource would have changed fields
+// owned by other field managers, such as kubectl. Nothing
+// is changed; drift repair applies with force instead.
+type ConflictError struct {
+	Kind      string
+	Name      string
+	Conflicts []FieldConflict
+	Err       error
+}
+
+func (e *ConflictError) Error() string {
+	fields := make([]string, len(e.Conflicts))
+	for i, c := range e.Conflicts {
+		fields[i] = fmt.Sprintf("%s (managed by %q)", c.Field, c.Manager)
+	}
+	return "fields managed elsewhere: " + strings.Join(fields, ", ")
+}
+
+func (e *ConflictError) Unwrap() error { return e.Err }
+
+// fieldConflicts returns the conflicts an apply failed with, if any.
+func fieldConflicts(err error) []FieldConflict {
+	var status apierrors.APIStatus
+	if !apierrors.IsConflict(err) || !errors.As(err, &status) || status.Status().Details == nil {
+		return nil
+	}
+	var conflicts []FieldConflict
+	for _, cause := range status.Status().Details.Causes {
+		if cause.Type != metav1.CauseTypeFieldManagerConflict {
+			continue
+		}
+		// The message names the manager as `conflict with "kubectl"`,
+		// followed by the API version it used for updates.
+		var manager string
+		if _, err := fmt.Sscanf(cause.Message, "conflict with %q", &manager); err != nil {
+			manager = cause.Message
+		}
+		conflicts = append(conflicts, FieldConflict{Manager: manager, Field: cause.Field})
+	}
+	return conflicts
+}
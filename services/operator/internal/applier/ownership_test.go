@@ -0,0 +1,270 @@
+package applier
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	appsv1ac "k8s.io/client-go/applyconfigurations/apps/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+func TestApply_OwnerReferences(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	a := NewKubernetesApplier(client, slog.Default())
+
+	spec := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		BuildID:       "build-1",
+		Components: []model.DeployedComponent{
+			{PackageName: "users-api", ArtifactHash: "abc123", Runtime: model.ComponentRuntime{Replicas: 1}},
+		},
+	}
+	create := []reconciler.Action{
+		{Type: reconciler.ActionCreate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api"},
+		{Type: reconciler.ActionCreate, ResourceKind: "Service", ResourceName: "svc-env-1-users-api"},
+		{Type: reconciler.ActionCreate, ResourceKind: "ConfigMap", ResourceName: "cm-env-1-users-api"},
+	}
+	if err := a.Apply(ctx, "test-ns", "env-1", create, spec); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	parent, err := client.CoreV1().ConfigMaps("test-ns").Get(ctx, "env-env-1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the environment's parent: %v", err)
+	}
+	if parent.Labels[EnvironmentLabel] != "env-1" || parent.Labels[ManagedByLabel] != ManagedBy {
+		t.Fatalf("expected the parent labelled as the environment's, got %v", parent.Labels)
+	}
+
+	deploy, _ := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{})
+	svc, _ := client.CoreV1().Services("test-ns").Get(ctx, "svc-env-1-users-api", metav1.GetOptions{})
+	cm, _ := client.CoreV1().ConfigMaps("test-ns").Get(ctx, "cm-env-1-users-api", metav1.GetOptions{})
+	for kind, m := range map[string]metav1.ObjectMeta{
+		"Deployment": deploy.ObjectMeta,
+		"Service":    svc.ObjectMeta,
+		"ConfigMap":  cm.ObjectMeta,
+	} {
+		refs := m.OwnerReferences
+		if len(refs) != 1 || refs[0].Kind != "ConfigMap" || refs[0].Name != "env-env-1" {
+			t.Errorf("%s: expected the parent as owner, got %+v", kind, refs)
+		}
+		if len(m.ManagedFields) == 0 || m.ManagedFields[0].Manager != FieldManager {
+			t.Errorf("%s: expected fields managed by %s, got %+v", kind, FieldManager, m.ManagedFields)
+		}
+	}
+
+	// Deletes alone don't need the parent.
+	client.ClearActions()
+	remove := []reconciler.Action{{Type: reconciler.ActionDelete, ResourceKind: "Service", ResourceName: "svc-env-1-users-api"}}
+	if err := a.Apply(ctx, "test-ns", "env-1", remove, spec); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if got := len(client.Actions()); got != 1 {
+		t.Fatalf("expected only the delete, got %d actions", got)
+	}
+}
+
+func TestApply_Conflicts(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	a := NewKubernetesApplier(client, slog.Default())
+
+	spec := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		BuildID:       "build-1",
+		Components: []model.DeployedComponent{
+			{PackageName: "users-api", ArtifactHash: "abc123", Runtime: model.ComponentRuntime{Replicas: 1, Image: "users:1"}},
+		},
+	}
+	deployment := []reconciler.Action{{Type: reconciler.ActionCreate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api"}}
+	if err := a.Apply(ctx, "test-ns", "env-1", deployment, spec); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	// Someone debugging takes over the image with kubectl.
+	debug := appsv1ac.Deployment("deploy-env-1-users-api", "test-ns").
+		WithSpec(appsv1ac.DeploymentSpec().
+			WithTemplate(corev1ac.PodTemplateSpec().
+				WithSpec(corev1ac.PodSpec().
+					WithContainers(corev1ac.Container().WithName("users-api").WithImage("users:debug")))))
+	if _, err := client.AppsV1().Deployments("test-ns").Apply(ctx, debug, metav1.ApplyOptions{FieldManager: "kubectl", Force: true}); err != nil {
+		t.Fatalf("debug: %v", err)
+	}
+
+	// Changing the image conflicts with kubectl and changes nothing.
+	spec.Components[0].Runtime.Image = "users:2"
+	deployment[0].Type = reconciler.ActionUpdate
+	err := a.Apply(ctx, "test-ns", "env-1", deployment, spec)
+	var conflict *ConflictError
+	if !errors.As(err, &conflict) {
+		t.Fatalf("expected a ConflictError, got %v", err)
+	}
+	want := FieldConflict{Manager: "kubectl", Field: `.spec.template.spec.containers[name="users-api"].image`}
+	if len(conflict.Conflicts) != 1 || conflict.Conflicts[0] != want {
+		t.Fatalf("expected the image conflict, got %+v", conflict.Conflicts)
+	}
+	if !apierrors.IsConflict(err) {
+		t.Fatal("expected the API conflict to be wrapped")
+	}
+	deploy, _ := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{})
+	if image := deploy.Spec.Template.Spec.Containers[0].Image; image != "users:debug" {
+		t.Fatalf("expected the image left alone, got %s", image)
+	}
+
+	// A forced action takes it back.
+	deployment[0].Force = true
+	if err := a.Apply(ctx, "test-ns", "env-1", deployment, spec); err != nil {
+		t.Fatalf("forced apply: %v", err)
+	}
+	deploy, _ = client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{})
+	if image := deploy.Spec.Template.Spec.Containers[0].Image; image != "users:2" {
+		t.Fatalf("expected the image repaired, got %s", image)
+	}
+}
+
+func TestApply_AutoscaledReplicas(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	a := NewKubernetesApplier(client, slog.Default())
+
+	spec := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		BuildID:       "build-1",
+		Components: []model.DeployedComponent{
+			{PackageName: "users-api", ArtifactHash: "abc123", Runtime: model.ComponentRuntime{Replicas: 1, Image: "users:1"}},
+		},
+	}
+	deployment := []reconciler.Action{{Type: reconciler.ActionCreate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api"}}
+	if err := a.Apply(ctx, "test-ns", "env-1", deployment, spec); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	// An autoscaler takes over replicas and adds an annotation of its own.
+	scaled := appsv1ac.Deployment("deploy-env-1-users-api", "test-ns").
+		WithAnnotations(map[string]string{"autoscaler.example.com/enabled": "true"}).
+		WithSpec(appsv1ac.DeploymentSpec().WithReplicas(4))
+	if _, err := client.AppsV1().Deployments("test-ns").Apply(ctx, scaled, metav1.ApplyOptions{FieldManager: "autoscaler", Force: true}); err != nil {
+		t.Fatalf("autoscale: %v", err)
+	}
+
+	// A new image and replica count apply without conflicting, leaving
+	// the replicas to the autoscaler, even when forced.
+	spec.Components[0].Runtime.Image = "users:2"
+	spec.Components[0].Runtime.Replicas = 2
+	deployment[0].Type = reconciler.ActionUpdate
+	for _, force := range []bool{false, true} {
+		deployment[0].Force = force
+		if err := a.Apply(ctx, "test-ns", "env-1", deployment, spec); err != nil {
+			t.Fatalf("apply (force=%t): %v", force, err)
+		}
+		deploy, _ := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{})
+		if deploy.Spec.Template.Spec.Containers[0].Image != "users:2" || deploy.Annotations["autoscaler.example.com/enabled"] != "true" {
+			t.Fatalf("force=%t: expected the new image with the autoscaler's annotation kept, got %+v", force, deploy)
+		}
+		if *deploy.Spec.Replicas != 4 {
+			t.Fatalf("force=%t: expected the autoscaler's replicas, got %d", force, *deploy.Spec.Replicas)
+		}
+	}
+	if drift, err := a.Drift(ctx, "test-ns", spec); err != nil || slices.ContainsFunc(drift, replicasDrift) {
+		t.Fatalf("expected the autoscaler's replicas not to drift, got %+v %v", drift, err)
+	}
+
+	// Sleep still scales to zero.
+	spec.Components[0].AwakeReplicas = 2
+	spec.Components[0].Runtime.Replicas = 0
+	deployment[0].Force = false
+	if err := a.Apply(ctx, "test-ns", "env-1", deployment, spec); err != nil {
+		t.Fatalf("sleep: %v", err)
+	}
+	deploy, _ := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{})
+	if *deploy.Spec.Replicas != 0 {
+		t.Fatalf("expected the component asleep, got %d replicas", *deploy.Spec.Replicas)
+	}
+}
+
+func replicasDrift(d model.ResourceDrift) bool { return d.Field == "replicas" }
+
+func TestApply_UnsetReplicas(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	a := NewKubernetesApplier(client, slog.Default())
+
+	spec := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		BuildID:       "build-1",
+		Components:    []model.DeployedComponent{{PackageName: "users-api", ArtifactHash: "abc123"}},
+	}
+	deployment := []reconciler.Action{{Type: reconciler.ActionCreate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api"}}
+	if err := a.Apply(ctx, "test-ns", "env-1", deployment, spec); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	deploy, _ := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{})
+	if deploy.Spec.Replicas != nil {
+		t.Fatalf("expected replicas left to Kubernetes, got %d", *deploy.Spec.Replicas)
+	}
+	if drift, err := a.Drift(ctx, "test-ns", spec); err != nil || slices.ContainsFunc(drift, replicasDrift) {
+		t.Fatalf("expected unset replicas not to drift, got %+v %v", drift, err)
+	}
+}
+
+func TestApply_LegacyFieldManager(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	a := NewKubernetesApplier(client, slog.Default())
+
+	// A ConfigMap written by the operator's updates before it applied
+	// resources.
+	spec := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		Components: []model.DeployedComponent{
+			{PackageName: "users-api", Runtime: model.ComponentRuntime{Env: map[string]string{"LOG_LEVEL": "info"}}},
+		},
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cm-env-1-users-api", Namespace: "test-ns"},
+		Data:       map[string]string{"LOG_LEVEL": "debug"},
+	}
+	if _, err := client.CoreV1().ConfigMaps("test-ns").Create(ctx, cm, metav1.CreateOptions{FieldManager: legacyFieldManager}); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	update := []reconciler.Action{{Type: reconciler.ActionUpdate, ResourceKind: "ConfigMap", ResourceName: "cm-env-1-users-api"}}
+	if err := a.Apply(ctx, "test-ns", "env-1", update, spec); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	got, _ := client.CoreV1().ConfigMaps("test-ns").Get(ctx, "cm-env-1-users-api", metav1.GetOptions{})
+	if got.Data["LOG_LEVEL"] != "info" {
+		t.Fatalf("expected the legacy fields taken over, got %v", got.Data)
+	}
+}
+
+func TestFieldConflicts(t *testing.T) {
+	err := apierrors.NewApplyConflict([]metav1.StatusCause{
+		{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "kubectl-edit" using apps/v1`, Field: ".spec.replicas"},
+		{Type: metav1.CauseTypeFieldManagerConflict, Message: `conflict with "autoscaler"`, Field: ".spec.template.metadata.labels.tier"},
+	}, "Apply failed with 2 conflicts")
+
+	want := []FieldConflict{
+		{Manager: "kubectl-edit", Field: ".spec.replicas"},
+		{Manager: "autoscaler", Field: ".spec.template.metadata.labels.tier"},
+	}
+	got := fieldConflicts(err)
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+	if got := fieldConflicts(apierrors.NewConflict(appsv1.Resource("deployments"), "deploy-env-1-users-api", errors.New("stale"))); got != nil {
+		t.Fatalf("expected no field conflicts from an update conflict, got %+v", got)
+	}
+}
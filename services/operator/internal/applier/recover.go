@@ -0,0 +1,137 @@
+package applier
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+// Deployment annotations from which a restarted operator recovers what it
+// deployed. componentSpecAnnotation holds the component as JSON; it carries
+// secret names but never their values.
+const (
+	artifactHashAnnotation  = "turboengine.io/artifact-hash"
+	buildIDAnnotation       = "turboengine.io/build-id"
+	componentSpecAnnotation = "turboengine.io/component-spec"
+)
+
+// deploymentAnnotations returns the annotations for a component's
+// Deployment.
+func deploymentAnnotations(comp model.DeployedComponent, buildID string) map[string]string {
+	// Marshalling a component cannot fail.
+	spec, _ := json.Marshal(comp)
+	return map[string]string{
+		artifactHashAnnotation:  comp.ArtifactHash,
+		buildIDAnnotation:       buildID,
+		componentSpecAnnotation: string(spec),
+	}
+}
+
+// LoadDeployed returns the spec of every environment with Deployments in
+// namespace, rebuilt from the Deployments' labels and annotations. The specs
+// have no root package or ingress, which aren't kept in the cluster.
+// Legacy-named Deployments are skipped; see legacyName.
+func (a *KubernetesApplier) LoadDeployed(ctx context.Context, namespace string) ([]model.APIGraphSpec, error) {
+	list, err := a.client.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: ManagedSelector,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("listing deployments: %w", err)
+	}
+
+	specs := make(map[string]*model.APIGraphSpec)
+	buildIDs := make(map[string]map[string]int)
+	for _, d := range list.Items {
+		envID, name := d.Labels[EnvironmentLabel], d.Labels[ComponentLabel]
+		if envID == "" || name == "" {
+			continue
+		}
+		// A Deployment named before the rename is left for the next
+		// reconcile to replace, rather than counted alongside its
+		// replacement.
+		if d.Name != reconciler.DeploymentName(envID, name) {
+			continue
+		}
+		spec, ok := specs[envID]
+		if !ok {
+			spec = &model.APIGraphSpec{EnvironmentID: envID, Labels: userLabels(d.Labels)}
+			specs[envID] = spec
+			buildIDs[envID] = make(map[string]int)
+		}
+		spec.Components = append(spec.Components, a.recoverComponent(ctx, d, name))
+		if id := d.Annotations[buildIDAnnotation]; id != "" {
+			buildIDs[envID][id]++
+		}
+	}
+
+	result := make([]model.APIGraphSpec, 0, len(specs))
+	for _, envID := range slices.Sorted(maps.Keys(specs)) {
+		spec := specs[envID]
+		spec.BuildID = mostCommonBuildID(buildIDs[envID])
+		slices.SortFunc(spec.Components, func(x, y model.DeployedComponent) int {
+			return cmp.Compare(x.PackageName, y.PackageName)
+		})
+		result = append(result, *spec)
+	}
+	return result, nil
+}
+
+// recoverComponent rebuilds the component a Deployment runs. The
+// Deployment's replicas and artifact hash are taken as they are in the
+// cluster; the rest comes from its component spec annotation, or only
+// what the Deployment itself shows if it predates the annotation.
+func (a *KubernetesApplier) recoverComponent(ctx context.Context, d appsv1.Deployment, name string) model.DeployedComponent {
+	var comp model.DeployedComponent
+	if raw, ok := d.Annotations[componentSpecAnnotation]; ok {
+		if err := json.Unmarshal([]byte(raw), &comp); err != nil {
+			a.logger.WarnContext(ctx, "ignoring unreadable component spec annotation",
+				"deployment", d.Name, "error", err)
+			comp = model.DeployedComponent{}
+		}
+	}
+	comp.PackageName = name
+	comp.ArtifactHash = d.Annotations[artifactHashAnnotation]
+	comp.SecretsVersion = d.Spec.Template.Annotations[secretsVersionAnnotation]
+	comp.Runtime.Replicas = 1
+	if d.Spec.Replicas != nil {
+		comp.Runtime.Replicas = *d.Spec.Replicas
+	}
+	if comp.Runtime.Replicas > 0 {
+		comp.AwakeReplicas = 0
+	}
+	return comp
+}
+
+// userLabels returns the environment's own labels from a resource's labels.
+func userLabels(lbls map[string]string) map[string]string {
+	user := maps.Clone(lbls)
+	for k := range standardLabels("", "") {
+		delete(user, k)
+	}
+	if len(user) == 0 {
+		return nil
+	}
+	return user
+}
+
+// mostCommonBuildID returns the build ID most of an environment's
+// Deployments carry. Only the components a build changed are updated, so
+// they need not all agree.
+func mostCommonBuildID(counts map[string]int) string {
+	var best string
+	for _, id := range slices.Sorted(maps.Keys(counts)) {
+		if counts[id] > counts[best] {
+			best = id
+		}
+	}
+	return best
+}
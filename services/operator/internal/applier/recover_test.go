@@ -0,0 +1,156 @@
+package applier
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+func TestRecover_AfterRestart(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	a := NewKubernetesApplier(client, slog.Default())
+
+	users := model.DeployedComponent{
+		PackageName:  "users-api",
+		ArtifactHash: "abc123",
+		Runtime: model.ComponentRuntime{
+			Replicas:  2,
+			Env:       map[string]string{"LOG_LEVEL": "info"},
+			SecretEnv: map[string]string{"API_KEY": "api-key"},
+		},
+		Upstream: &model.UpstreamConfig{URL: "https://users.dev.example.com", Headers: map[string]string{"x-dev": "alice"}},
+	}
+	orders := model.DeployedComponent{PackageName: "orders-api", ArtifactHash: "def456", Runtime: model.ComponentRuntime{Replicas: 3}}
+	ingress := model.IngressSpec{
+		Host:   "env-1.example.com",
+		Routes: []model.IngressRoute{{Path: "/graphql", TargetComponent: "users-api", TargetPort: 4000}},
+	}
+	awake := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		BuildID:       "build-1",
+		Components:    []model.DeployedComponent{users, orders},
+		Ingress:       ingress,
+		Labels:        map[string]string{"team": "payments"},
+		Secrets:       map[string]string{"api-key": "k3y"},
+	}
+	asleep := model.APIGraphSpec{
+		EnvironmentID: "env-2",
+		BuildID:       "build-2",
+		Components:    []model.DeployedComponent{{PackageName: "products-api", ArtifactHash: "fed789", Runtime: model.ComponentRuntime{Replicas: 3}}},
+	}
+
+	before := reconciler.New(slog.Default(), a, "test-ns")
+	for _, spec := range []model.APIGraphSpec{awake, asleep} {
+		if _, _, err := before.Reconcile(ctx, spec); err != nil {
+			t.Fatalf("reconcile %s: %v", spec.EnvironmentID, err)
+		}
+	}
+	if _, _, err := before.Sleep(ctx, "env-2"); err != nil {
+		t.Fatalf("sleep: %v", err)
+	}
+
+	// A new reconciler over the same cluster, as after a restart.
+	after := reconciler.New(slog.Default(), a, "test-ns")
+	if err := after.Recover(ctx); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+
+	status, ok := after.GetStatus("env-1")
+	if !ok || status.Phase != model.PhaseRunning || len(status.ComponentStatuses) != 2 {
+		t.Fatalf("expected env-1 running with two components, got %+v", status)
+	}
+	if status, ok := after.GetStatus("env-2"); !ok || status.Phase != model.PhaseSleeping {
+		t.Fatalf("expected env-2 sleeping, got %+v", status)
+	}
+
+	// Reconciling what was already deployed, without secret values as
+	// when polled from the builder, does nothing.
+	awake.Secrets = nil
+	actions, _, err := after.Reconcile(ctx, awake)
+	if err != nil {
+		t.Fatalf("reconcile after restart: %v", err)
+	}
+	if len(actions) != 0 {
+		t.Fatalf("expected no actions after a restart, got %+v", actions)
+	}
+	if routes := after.GetAllSpecs()["env-1"].Ingress.Routes; len(routes) != 1 {
+		t.Fatalf("expected the reconciled ingress, got %+v", routes)
+	}
+
+	// The sleeping environment wakes to the replicas it had.
+	if _, _, err := after.Wake(ctx, "env-2"); err != nil {
+		t.Fatalf("wake: %v", err)
+	}
+	deploy, err := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-2-products-api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+	if *deploy.Spec.Replicas != 3 {
+		t.Fatalf("expected 3 replicas after waking, got %d", *deploy.Spec.Replicas)
+	}
+}
+
+func TestLoadDeployed(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	a := NewKubernetesApplier(client, slog.Default())
+
+	spec := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		BuildID:       "build-1",
+		Components: []model.DeployedComponent{
+			{PackageName: "users-api", ArtifactHash: "abc123", Runtime: model.ComponentRuntime{Replicas: 1}},
+			{PackageName: "orders-api", ArtifactHash: "def456", Runtime: model.ComponentRuntime{Replicas: 1}},
+		},
+		Labels: map[string]string{"team": "payments"},
+	}
+	create := []reconciler.Action{
+		{Type: reconciler.ActionCreate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api"},
+		{Type: reconciler.ActionCreate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-orders-api"},
+	}
+	if err := a.Apply(ctx, "test-ns", "env-1", create, spec); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	// A later build that only changed users-api, and a Deployment from
+	// before the component spec annotation.
+	spec.BuildID = "build-2"
+	spec.Components[0].ArtifactHash = "abc124"
+	update := []reconciler.Action{{Type: reconciler.ActionUpdate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api"}}
+	if err := a.Apply(ctx, "test-ns", "env-1", update, spec); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	deploy, _ := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-orders-api", metav1.GetOptions{})
+	delete(deploy.Annotations, componentSpecAnnotation)
+	if _, err := client.AppsV1().Deployments("test-ns").Update(ctx, deploy, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update deployment: %v", err)
+	}
+
+	specs, err := a.LoadDeployed(ctx, "test-ns")
+	if err != nil {
+		t.Fatalf("load: %v", err)
+	}
+	if len(specs) != 1 {
+		t.Fatalf("expected one environment, got %+v", specs)
+	}
+	got := specs[0]
+	if got.EnvironmentID != "env-1" || len(got.Labels) != 1 || got.Labels["team"] != "payments" {
+		t.Fatalf("expected env-1 with its own labels, got %+v", got)
+	}
+	if got.BuildID != "build-1" && got.BuildID != "build-2" {
+		t.Fatalf("expected a deployed build ID, got %q", got.BuildID)
+	}
+	if len(got.Components) != 2 || got.Components[0].PackageName != "orders-api" || got.Components[1].ArtifactHash != "abc124" {
+		t.Fatalf("expected both components sorted by name, got %+v", got.Components)
+	}
+	if got.Components[0].ArtifactHash != "def456" || got.Components[0].Runtime.Replicas != 1 {
+		t.Fatalf("expected the unannotated component from its Deployment, got %+v", got.Components[0])
+	}
+}
@@ -0,0 +1,211 @@
+package applier
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	corev1ac "k8s.io/client-go/applyconfigurations/core/v1"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+// secretsVersionAnnotation on a pod template records which secret values
+// its pods were started with, so that changing a value rolls them.
+const secretsVersionAnnotation = "turboengine.io/secrets-version"
+
+// Environment variables and files through which upstream auth reaches the
+// component. Secret values are only ever read from the component's Secret;
+// the ConfigMap holds the rest.
+const (
+	upstreamAuthTypeKey     = "UPSTREAM_AUTH_TYPE"
+	upstreamBearerTokenKey  = "UPSTREAM_BEARER_TOKEN"
+	upstreamTokenURLKey     = "UPSTREAM_OAUTH2_TOKEN_URL"
+	upstreamScopesKey       = "UPSTREAM_OAUTH2_SCOPES"
+	upstreamClientIDKey     = "UPSTREAM_OAUTH2_CLIENT_ID"
+	upstreamClientSecretKey = "UPSTREAM_OAUTH2_CLIENT_SECRET"
+	upstreamCertFileKey     = "UPSTREAM_TLS_CERT_FILE"
+	upstreamKeyFileKey      = "UPSTREAM_TLS_KEY_FILE"
+	upstreamCAFileKey       = "UPSTREAM_TLS_CA_FILE"
+
+	// upstreamTLSVolume is mounted at upstreamTLSDir with the mTLS client
+	// certificate, key and CA bundle.
+	upstreamTLSVolume = "upstream-tls"
+	upstreamTLSDir    = "/etc/turbo-engine/upstream-tls"
+)
+
+// authConfigData returns the non-secret upstream auth settings for a
+// component's ConfigMap.
+func authConfigData(auth *model.UpstreamAuth) map[string]string {
+	if auth == nil {
+		return nil
+	}
+	data := map[string]string{upstreamAuthTypeKey: string(auth.Type)}
+	switch auth.Type {
+	case model.UpstreamAuthOAuth2ClientCredentials:
+		data[upstreamTokenURLKey] = auth.TokenEndpoint
+		if len(auth.Scopes) > 0 {
+			data[upstreamScopesKey] = strings.Join(auth.Scopes, " ")
+		}
+	case model.UpstreamAuthMTLS:
+		data[upstreamCertFileKey] = upstreamTLSDir + "/" + corev1.TLSCertKey
+		data[upstreamKeyFileKey] = upstreamTLSDir + "/" + corev1.TLSPrivateKeyKey
+		if auth.CASecretRef != "" {
+			data[upstreamCAFileKey] = upstreamTLSDir + "/" + corev1.ServiceAccountRootCAKey
+		}
+	}
+	return data
+}
+
+// podAnnotations returns the annotations for a component's pod template.
+func podAnnotations(comp model.DeployedComponent) map[string]string {
+	annotations := map[string]string{artifactHashAnnotation: comp.ArtifactHash}
+	if comp.SecretsVersion != "" {
+		annotations[secretsVersionAnnotation] = comp.SecretsVersion
+	}
+	return annotations
+}
+
+// secretEnv returns the container environment variables a component reads
+// from its Secret: its runtime secret env plus its upstream's bearer token
+// or OAuth2 client credentials, which take precedence. They are sorted by
+// name so that the pod template is stable.
+func secretEnv(envID string, comp model.DeployedComponent) []*corev1ac.EnvVarApplyConfiguration {
+	refs := maps.Clone(comp.Runtime.SecretEnv)
+	if auth := upstreamAuth(comp); auth != nil {
+		if refs == nil {
+			refs = make(map[string]string, 2)
+		}
+		switch auth.Type {
+		case model.UpstreamAuthBearer:
+			refs[upstreamBearerTokenKey] = auth.TokenSecretRef
+		case model.UpstreamAuthOAuth2ClientCredentials:
+			refs[upstreamClientIDKey] = auth.ClientIDSecretRef
+			refs[upstreamClientSecretKey] = auth.ClientSecretSecretRef
+		}
+	}
+	if len(refs) == 0 {
+		return nil
+	}
+
+	env := make([]*corev1ac.EnvVarApplyConfiguration, 0, len(refs))
+	for _, name := range slices.Sorted(maps.Keys(refs)) {
+		env = append(env, corev1ac.EnvVar().
+			WithName(name).
+			WithValueFrom(corev1ac.EnvVarSource().
+				WithSecretKeyRef(corev1ac.SecretKeySelector().
+					WithName(reconciler.SecretName(envID, comp.PackageName)).
+					WithKey(refs[name]))))
+	}
+	return env
+}
+
+// secretVolumes returns the volume holding a component's mTLS client
+// certificate, if its upstream uses mTLS.
+func secretVolumes(envID string, comp model.DeployedComponent) []*corev1ac.VolumeApplyConfiguration {
+	auth := upstreamAuth(comp)
+	if auth == nil || auth.Type != model.UpstreamAuthMTLS {
+		return nil
+	}
+	items := []*corev1ac.KeyToPathApplyConfiguration{
+		corev1ac.KeyToPath().WithKey(auth.CertSecretRef).WithPath(corev1.TLSCertKey),
+		corev1ac.KeyToPath().WithKey(auth.KeySecretRef).WithPath(corev1.TLSPrivateKeyKey),
+	}
+	if auth.CASecretRef != "" {
+		items = append(items, corev1ac.KeyToPath().WithKey(auth.CASecretRef).WithPath(corev1.ServiceAccountRootCAKey))
+	}
+	return []*corev1ac.VolumeApplyConfiguration{corev1ac.Volume().
+		WithName(upstreamTLSVolume).
+		WithSecret(corev1ac.SecretVolumeSource().
+			WithSecretName(reconciler.SecretName(envID, comp.PackageName)).
+			WithItems(items...))}
+}
+
+// secretVolumeMounts mounts the volumes from secretVolumes.
+func secretVolumeMounts(comp model.DeployedComponent) []*corev1ac.VolumeMountApplyConfiguration {
+	if auth := upstreamAuth(comp); auth == nil || auth.Type != model.UpstreamAuthMTLS {
+		return nil
+	}
+	return []*corev1ac.VolumeMountApplyConfiguration{corev1ac.VolumeMount().
+		WithName(upstreamTLSVolume).
+		WithMountPath(upstreamTLSDir).
+		WithReadOnly(true)}
+}
+
+func upstreamAuth(comp model.DeployedComponent) *model.UpstreamAuth {
+	if comp.Upstream == nil {
+		return nil
+	}
+	return comp.Upstream.Auth
+}
+
+// secretData returns the values of the secrets a component references,
+// keyed by secret name.
+func secretData(comp model.DeployedComponent, values map[string]string) (map[string][]byte, error) {
+	refs := comp.SecretRefs()
+	data := make(map[string][]byte, len(refs))
+	for _, ref := range refs {
+		value, ok := values[ref]
+		if !ok {
+			return nil, fmt.Errorf("secret %s referenced by %s was not supplied", ref, comp.PackageName)
+		}
+		data[ref] = []byte(value)
+	}
+	return data, nil
+}
+
+// applySecret applies a component's Secret. A spec without secret values,
+// as polled from the builder, keeps the values the Secret already has.
+func (a *KubernetesApplier) applySecret(ctx context.Context, ns, envID, name string, spec model.APIGraphSpec, opts applyOptions) error {
+	comp, ok := findComponent(spec, envID, name, reconciler.SecretName)
+	if !ok {
+		return fmt.Errorf("component not found for secret %s", name)
+	}
+
+	// Applying the Secret without its data would remove it, so the data
+	// it has is applied again.
+	var data map[string][]byte
+	var version string
+	if spec.Secrets == nil {
+		existing, err := a.client.CoreV1().Secrets(ns).Get(ctx, name, metav1.GetOptions{})
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		if err == nil {
+			data, version = existing.Data, existing.Annotations[secretsVersionAnnotation]
+		}
+	}
+	if data == nil {
+		var err error
+		if data, err = secretData(comp, spec.Secrets); err != nil {
+			return err
+		}
+		version = comp.SecretsVersion
+	}
+
+	secret := corev1ac.Secret(name, ns).
+		WithLabels(labels(spec, envID, comp.PackageName)).
+		WithAnnotations(map[string]string{secretsVersionAnnotation: version}).
+		WithOwnerReferences(opts.owner).
+		WithType(corev1.SecretTypeOpaque).
+		WithData(data)
+
+	return a.apply(ctx, "Secret", name, opts.force, func(o metav1.ApplyOptions) error {
+		_, err := a.client.CoreV1().Secrets(ns).Apply(ctx, secret, o)
+		return err
+	})
+}
+
+func (a *KubernetesApplier) deleteSecret(ctx context.Context, ns, name string) error {
+	err := a.client.CoreV1().Secrets(ns).Delete(ctx, name, metav1.DeleteOptions{})
+	if errors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,146 @@
+package applier
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+func TestAuthConfigData(t *testing.T) {
+	oauth := authConfigData(&model.UpstreamAuth{
+		Type:                  model.UpstreamAuthOAuth2ClientCredentials,
+		TokenEndpoint:         "https://auth.example.com/token",
+		ClientIDSecretRef:     "client-id",
+		ClientSecretSecretRef: "client-secret",
+		Scopes:                []string{"read", "write"},
+	})
+	if oauth[upstreamAuthTypeKey] != "oauth2-client-credentials" || oauth[upstreamTokenURLKey] != "https://auth.example.com/token" || oauth[upstreamScopesKey] != "read write" {
+		t.Fatalf("unexpected OAuth2 config: %v", oauth)
+	}
+	if _, ok := oauth[upstreamClientSecretKey]; ok {
+		t.Fatal("client credentials must come from the Secret, not the ConfigMap")
+	}
+
+	mtls := authConfigData(&model.UpstreamAuth{Type: model.UpstreamAuthMTLS, CertSecretRef: "cert", KeySecretRef: "key"})
+	if mtls[upstreamCertFileKey] != upstreamTLSDir+"/tls.crt" || mtls[upstreamKeyFileKey] != upstreamTLSDir+"/tls.key" {
+		t.Fatalf("unexpected mTLS config: %v", mtls)
+	}
+	if _, ok := mtls[upstreamCAFileKey]; ok {
+		t.Fatal("expected no CA file without a CA secret")
+	}
+}
+
+func TestApply_Secrets(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	a := NewKubernetesApplier(client, slog.Default())
+
+	spec := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		BuildID:       "build-1",
+		Components: []model.DeployedComponent{{
+			PackageName:  "users-api",
+			ArtifactHash: "abc123",
+			Runtime: model.ComponentRuntime{
+				Replicas:  1,
+				SecretEnv: map[string]string{"API_KEY": "api-key"},
+			},
+			Upstream: &model.UpstreamConfig{
+				URL: "https://users.dev.example.com",
+				Auth: &model.UpstreamAuth{
+					Type:          model.UpstreamAuthMTLS,
+					CertSecretRef: "client-cert",
+					KeySecretRef:  "client-key",
+					CASecretRef:   "ca",
+				},
+			},
+			SecretsVersion: "v1",
+		}},
+		Secrets: map[string]string{"api-key": "k3y", "client-cert": "CERT", "client-key": "KEY", "ca": "CA", "other": "x"},
+	}
+	create := []reconciler.Action{
+		{Type: reconciler.ActionCreate, ResourceKind: "Secret", ResourceName: "secret-env-1-users-api"},
+		{Type: reconciler.ActionCreate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api"},
+	}
+	if err := a.Apply(ctx, "test-ns", "env-1", create, spec); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+
+	secret, err := client.CoreV1().Secrets("test-ns").Get(ctx, "secret-env-1-users-api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get secret: %v", err)
+	}
+	if len(secret.Data) != 4 || string(secret.Data["api-key"]) != "k3y" || secret.Type != corev1.SecretTypeOpaque {
+		t.Fatalf("expected only the referenced secrets, got %v", secret.Data)
+	}
+
+	deploy, err := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("get deployment: %v", err)
+	}
+	pod := deploy.Spec.Template
+	if pod.Annotations[secretsVersionAnnotation] != "v1" {
+		t.Fatalf("expected the secrets version on the pod template, got %v", pod.Annotations)
+	}
+	env := pod.Spec.Containers[0].Env
+	if len(env) != 1 || env[0].Name != "API_KEY" || env[0].ValueFrom.SecretKeyRef.Name != "secret-env-1-users-api" || env[0].ValueFrom.SecretKeyRef.Key != "api-key" {
+		t.Fatalf("expected API_KEY from the Secret, got %+v", env)
+	}
+	if len(pod.Spec.Volumes) != 1 || len(pod.Spec.Volumes[0].Secret.Items) != 3 {
+		t.Fatalf("expected the mTLS volume, got %+v", pod.Spec.Volumes)
+	}
+	if mounts := pod.Spec.Containers[0].VolumeMounts; len(mounts) != 1 || mounts[0].MountPath != upstreamTLSDir || !mounts[0].ReadOnly {
+		t.Fatalf("expected the mTLS volume mounted read-only, got %+v", mounts)
+	}
+
+	// Switching to bearer auth drops the volume; a spec without values
+	// leaves the Secret's data alone.
+	spec.Components[0].Upstream.Auth = &model.UpstreamAuth{Type: model.UpstreamAuthBearer, TokenSecretRef: "token"}
+	spec.Components[0].SecretsVersion = "v2"
+	spec.Secrets = nil
+	update := []reconciler.Action{
+		{Type: reconciler.ActionUpdate, ResourceKind: "Secret", ResourceName: "secret-env-1-users-api"},
+		{Type: reconciler.ActionUpdate, ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api"},
+	}
+	if err := a.Apply(ctx, "test-ns", "env-1", update, spec); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	deploy, _ = client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{})
+	if len(deploy.Spec.Template.Spec.Volumes) != 0 || len(deploy.Spec.Template.Spec.Containers[0].Env) != 2 {
+		t.Fatalf("expected bearer env and no volume, got %+v", deploy.Spec.Template.Spec)
+	}
+	secret, _ = client.CoreV1().Secrets("test-ns").Get(ctx, "secret-env-1-users-api", metav1.GetOptions{})
+	if string(secret.Data["client-cert"]) != "CERT" {
+		t.Fatal("expected the Secret's data to be kept without values")
+	}
+
+	remove := []reconciler.Action{{Type: reconciler.ActionDelete, ResourceKind: "Secret", ResourceName: "secret-env-1-users-api"}}
+	if err := a.Apply(ctx, "test-ns", "env-1", remove, spec); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := a.Apply(ctx, "test-ns", "env-1", remove, spec); err != nil {
+		t.Fatalf("delete again: %v", err)
+	}
+}
+
+func TestApply_SecretMissingValue(t *testing.T) {
+	a := NewKubernetesApplier(fake.NewClientset(), slog.Default())
+	spec := model.APIGraphSpec{
+		Components: []model.DeployedComponent{{
+			PackageName: "users-api",
+			Runtime:     model.ComponentRuntime{SecretEnv: map[string]string{"API_KEY": "api-key"}},
+		}},
+		Secrets: map[string]string{},
+	}
+	create := []reconciler.Action{{Type: reconciler.ActionCreate, ResourceKind: "Secret", ResourceName: "secret-env-1-users-api"}}
+	if err := a.Apply(context.Background(), "test-ns", "env-1", create, spec); err == nil {
+		t.Fatal("expected an error for a secret without a value")
+	}
+}
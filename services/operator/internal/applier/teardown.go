@@ -0,0 +1,115 @@
+package applier
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// environmentResource is an operator-managed resource of an environment.
+type environmentResource struct {
+	kind     string
+	name     string
+	deleting bool
+}
+
+// DeleteEnvironment deletes every operator-managed Deployment, Service,
+// ConfigMap and Secret labelled with environmentID in namespace, including
+// the environment's parent ConfigMap. Deleting the parent alone would take
+// the rest with it, but resources applied before they had owner references
+// would be left behind. It returns those still present afterwards, such as
+// ones held by finalizers, along with the environment's Pods, which go with
+// their Deployments.
+func (a *KubernetesApplier) DeleteEnvironment(ctx context.Context, namespace, environmentID string) ([]string, error) {
+	resources, err := a.listEnvironment(ctx, namespace, environmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	background := metav1.DeletePropagationBackground
+	opts := metav1.DeleteOptions{PropagationPolicy: &background}
+	for _, res := range resources {
+		if res.deleting {
+			continue
+		}
+		switch res.kind {
+		case "Deployment":
+			err = a.client.AppsV1().Deployments(namespace).Delete(ctx, res.name, opts)
+		case "Service":
+			err = a.client.CoreV1().Services(namespace).Delete(ctx, res.name, opts)
+		case "ConfigMap":
+			err = a.client.CoreV1().ConfigMaps(namespace).Delete(ctx, res.name, opts)
+		case "Secret":
+			err = a.client.CoreV1().Secrets(namespace).Delete(ctx, res.name, opts)
+		default:
+			continue
+		}
+		if err != nil && !errors.IsNotFound(err) {
+			return nil, fmt.Errorf("deleting %s %s: %w", res.kind, res.name, err)
+		}
+		a.logger.InfoContext(ctx, "deleted resource",
+			"environment_id", environmentID,
+			"kind", res.kind,
+			"name", res.name,
+		)
+	}
+
+	resources, err = a.listEnvironment(ctx, namespace, environmentID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := make([]string, 0, len(resources))
+	for _, res := range resources {
+		remaining = append(remaining, res.kind+"/"+res.name)
+	}
+	return remaining, nil
+}
+
+// listEnvironment lists the operator-managed resources labelled with
+// environmentID in namespace.
+func (a *KubernetesApplier) listEnvironment(ctx context.Context, namespace, environmentID string) ([]environmentResource, error) {
+	opts := metav1.ListOptions{LabelSelector: ManagedSelector + "," + EnvironmentLabel + "=" + environmentID}
+	var resources []environmentResource
+	add := func(kind string, m metav1.ObjectMeta) {
+		resources = append(resources, environmentResource{kind: kind, name: m.Name, deleting: m.DeletionTimestamp != nil})
+	}
+
+	deployments, err := a.client.AppsV1().Deployments(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("listing deployments: %w", err)
+	}
+	for _, d := range deployments.Items {
+		add("Deployment", d.ObjectMeta)
+	}
+	services, err := a.client.CoreV1().Services(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("listing services: %w", err)
+	}
+	for _, s := range services.Items {
+		add("Service", s.ObjectMeta)
+	}
+	configMaps, err := a.client.CoreV1().ConfigMaps(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("listing configmaps: %w", err)
+	}
+	for _, cm := range configMaps.Items {
+		add("ConfigMap", cm.ObjectMeta)
+	}
+	secrets, err := a.client.CoreV1().Secrets(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("listing secrets: %w", err)
+	}
+	for _, s := range secrets.Items {
+		add("Secret", s.ObjectMeta)
+	}
+	pods, err := a.client.CoreV1().Pods(namespace).List(ctx, opts)
+	if err != nil {
+		return nil, fmt.Errorf("listing pods: %w", err)
+	}
+	for _, p := range pods.Items {
+		add("Pod", p.ObjectMeta)
+	}
+	return resources, nil
+}
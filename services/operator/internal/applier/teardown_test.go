@@ -0,0 +1,79 @@
+package applier
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+func TestDeleteEnvironment(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewClientset()
+	a := NewKubernetesApplier(client, slog.Default())
+
+	// Resource names aren't scoped to an environment, so env-2 runs a
+	// different package.
+	users := model.APIGraphSpec{
+		EnvironmentID: "env-1",
+		BuildID:       "build-1",
+		Components: []model.DeployedComponent{{
+			PackageName: "users-api", ArtifactHash: "abc123",
+			Runtime: model.ComponentRuntime{Replicas: 1, SecretEnv: map[string]string{"API_KEY": "api-key"}},
+		}},
+		Secrets: map[string]string{"api-key": "k3y"},
+	}
+	products := model.APIGraphSpec{
+		EnvironmentID: "env-2",
+		BuildID:       "build-2",
+		Components:    []model.DeployedComponent{{PackageName: "products-api", ArtifactHash: "def456", Runtime: model.ComponentRuntime{Replicas: 1}}},
+	}
+	r := reconciler.New(slog.Default(), a, "test-ns")
+	for _, spec := range []model.APIGraphSpec{users, products} {
+		if _, _, err := r.Reconcile(ctx, spec); err != nil {
+			t.Fatalf("reconcile %s: %v", spec.EnvironmentID, err)
+		}
+	}
+	// The fake client has no controllers, so the Deployment's pod is
+	// created by hand and outlives it.
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name:      "users-api-1",
+		Namespace: "test-ns",
+		Labels:    standardLabels("env-1", "users-api"),
+	}}
+	if _, err := client.CoreV1().Pods("test-ns").Create(ctx, pod, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create pod: %v", err)
+	}
+
+	remaining, err := a.DeleteEnvironment(ctx, "test-ns", "env-1")
+	if err != nil {
+		t.Fatalf("delete environment: %v", err)
+	}
+	if !slices.Equal(remaining, []string{"Pod/users-api-1"}) {
+		t.Fatalf("expected only the pod remaining, got %v", remaining)
+	}
+	if _, err := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-1-users-api", metav1.GetOptions{}); err == nil {
+		t.Error("expected the Deployment deleted")
+	}
+	if _, err := client.CoreV1().Secrets("test-ns").Get(ctx, "secret-env-1-users-api", metav1.GetOptions{}); err == nil {
+		t.Error("expected the Secret deleted")
+	}
+	if _, err := client.AppsV1().Deployments("test-ns").Get(ctx, "deploy-env-2-products-api", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected the other environment's Deployment kept: %v", err)
+	}
+
+	if err := client.CoreV1().Pods("test-ns").Delete(ctx, pod.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete pod: %v", err)
+	}
+	remaining, err = a.DeleteEnvironment(ctx, "test-ns", "env-1")
+	if err != nil || len(remaining) != 0 {
+		t.Fatalf("expected nothing remaining, got %v %v", remaining, err)
+	}
+}
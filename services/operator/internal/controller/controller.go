@@ -0,0 +1,298 @@
+// Package controller reconciles APIGraph custom resources: it watches them,
+// deploys each through the Reconciler, writes the resulting status to the
+// resource's status subresource and, with a finalizer, tears the
+// environment down before the resource is deleted.
+package controller
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+// APIGraphResource is the APIGraph custom resource.
+var APIGraphResource = schema.GroupVersionResource{Group: "turboengine.io", Version: "v1", Resource: "apigraphs"}
+
+// Finalizer holds an APIGraph until its environment is torn down.
+const Finalizer = "turboengine.io/teardown"
+
+const (
+	// resyncPeriod is how often every APIGraph is reconciled again, which
+	// also refreshes statuses that changed in the meantime.
+	resyncPeriod = 30 * time.Second
+	// teardownPollInterval is how often a deleted APIGraph is checked while
+	// its environment's resources are being deleted.
+	teardownPollInterval = 5 * time.Second
+)
+
+// Reconciler deploys and tears down environments.
+type Reconciler interface {
+	Reconcile(ctx context.Context, spec model.APIGraphSpec) ([]reconciler.Action, model.APIGraphStatus, error)
+	Teardown(ctx context.Context, environmentID string) (model.APIGraphStatus, bool)
+}
+
+// Controller watches the APIGraphs in a namespace and reconciles them.
+type Controller struct {
+	client    dynamic.Interface
+	namespace string
+	rec       Reconciler
+	logger    *slog.Logger
+	queue     workqueue.TypedRateLimitingInterface[string]
+	lister    cache.GenericLister
+}
+
+// New creates a Controller for the APIGraphs in namespace.
+func New(client dynamic.Interface, namespace string, rec Reconciler, logger *slog.Logger) *Controller {
+	return &Controller{
+		client:    client,
+		namespace: namespace,
+		rec:       rec,
+		logger:    logger.With("component", "controller"),
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig(
+			workqueue.DefaultTypedControllerRateLimiter[string](),
+			workqueue.TypedRateLimitingQueueConfig[string]{Name: "apigraphs"},
+		),
+	}
+}
+
+// Run reconciles APIGraphs until ctx is done: each once the watch has
+// synced, again whenever its spec changes or it is deleted, and every
+// resyncPeriod.
+func (c *Controller) Run(ctx context.Context) error {
+	factory := dynamicinformer.NewFilteredDynamicSharedInformerFactory(c.client, resyncPeriod, c.namespace, nil)
+	informer := factory.ForResource(APIGraphResource)
+	c.lister = informer.Lister()
+
+	if _, err := informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: c.enqueue,
+		UpdateFunc: func(old, obj any) {
+			if needsSync(old, obj) {
+				c.enqueue(obj)
+			}
+		},
+	}); err != nil {
+		return err
+	}
+
+	factory.Start(ctx.Done())
+	defer factory.Shutdown()
+
+	c.logger.InfoContext(ctx, "waiting for APIGraph watch to sync", "namespace", c.namespace)
+	if !cache.WaitForCacheSync(ctx.Done(), informer.Informer().HasSynced) {
+		return errors.New("APIGraph watch did not sync")
+	}
+	c.logger.InfoContext(ctx, "watching APIGraphs", "namespace", c.namespace)
+
+	go func() {
+		<-ctx.Done()
+		c.queue.ShutDown()
+	}()
+	for c.processNext(ctx) {
+	}
+	return nil
+}
+
+// processNext syncs the next queued APIGraph, returning false once the
+// queue has shut down. Failed syncs are retried with backoff.
+func (c *Controller) processNext(ctx context.Context) bool {
+	key, shutdown := c.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.sync(ctx, key); err != nil {
+		c.logger.ErrorContext(ctx, "failed to sync APIGraph", "key", key, "error", err)
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// sync reconciles the APIGraph with key, or tears its environment down if it
+// is being deleted.
+func (c *Controller) sync(ctx context.Context, key string) error {
+	namespace, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return nil
+	}
+	obj, err := c.lister.ByNamespace(namespace).Get(name)
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return fmt.Errorf("unexpected object %T", obj)
+	}
+	u = u.DeepCopy()
+
+	spec, err := specOf(u)
+	if err != nil {
+		// The spec won't become valid until it changes, so don't retry.
+		c.logger.WarnContext(ctx, "invalid APIGraph spec", "key", key, "error", err)
+		return c.writeStatus(ctx, u, failedStatus(err))
+	}
+
+	if u.GetDeletionTimestamp() != nil {
+		return c.finalize(ctx, key, u, spec.EnvironmentID)
+	}
+
+	if !slices.Contains(u.GetFinalizers(), Finalizer) {
+		u.SetFinalizers(append(u.GetFinalizers(), Finalizer))
+		if u, err = c.client.Resource(APIGraphResource).Namespace(namespace).Update(ctx, u, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("adding finalizer: %w", err)
+		}
+	}
+
+	_, status, err := c.rec.Reconcile(ctx, spec)
+	switch {
+	case errors.Is(err, reconciler.ErrDeleting):
+		// An earlier APIGraph for the environment is still being torn down.
+		c.queue.AddAfter(key, teardownPollInterval)
+		return nil
+	case err != nil:
+		status = failedStatus(err)
+	}
+	return c.writeStatus(ctx, u, status)
+}
+
+// finalize tears down a deleted APIGraph's environment, reporting progress
+// in its status, and releases the APIGraph once it is gone.
+func (c *Controller) finalize(ctx context.Context, key string, u *unstructured.Unstructured, environmentID string) error {
+	if !slices.Contains(u.GetFinalizers(), Finalizer) {
+		return nil
+	}
+
+	status, done := c.rec.Teardown(ctx, environmentID)
+	if !done {
+		c.queue.AddAfter(key, teardownPollInterval)
+		return c.writeStatus(ctx, u, status)
+	}
+
+	u.SetFinalizers(slices.DeleteFunc(u.GetFinalizers(), func(f string) bool { return f == Finalizer }))
+	if _, err := c.client.Resource(APIGraphResource).Namespace(u.GetNamespace()).Update(ctx, u, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("removing finalizer: %w", err)
+	}
+	c.logger.InfoContext(ctx, "APIGraph finalized", "key", key, "environment_id", environmentID)
+	return nil
+}
+
+// writeStatus writes status to u's status subresource, unless only its
+// last reconciled time would change.
+func (c *Controller) writeStatus(ctx context.Context, u *unstructured.Unstructured, status model.APIGraphStatus) error {
+	next, err := toObject(status)
+	if err != nil {
+		return err
+	}
+	current, _, _ := unstructured.NestedMap(u.Object, "status")
+	if sameStatus(current, next) {
+		return nil
+	}
+	u.Object["status"] = next
+	_, err = c.client.Resource(APIGraphResource).Namespace(u.GetNamespace()).UpdateStatus(ctx, u, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("updating status: %w", err)
+	}
+	return nil
+}
+
+func (c *Controller) enqueue(obj any) {
+	key, err := cache.MetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	c.queue.Add(key)
+}
+
+// needsSync reports whether an update calls for a sync: a resync, or a
+// change to anything but the status, which the controller writes itself.
+func needsSync(old, obj any) bool {
+	o, ok := old.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+	n, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return true
+	}
+	return o.GetResourceVersion() == n.GetResourceVersion() ||
+		!equality.Semantic.DeepEqual(o.Object["spec"], n.Object["spec"]) ||
+		!slices.Equal(o.GetFinalizers(), n.GetFinalizers()) ||
+		!o.GetDeletionTimestamp().Equal(n.GetDeletionTimestamp())
+}
+
+// specOf decodes an APIGraph's spec.
+func specOf(u *unstructured.Unstructured) (model.APIGraphSpec, error) {
+	var spec model.APIGraphSpec
+	raw, err := json.Marshal(u.Object["spec"])
+	if err != nil {
+		return spec, fmt.Errorf("encoding spec: %w", err)
+	}
+	if err := json.Unmarshal(raw, &spec); err != nil {
+		return spec, fmt.Errorf("decoding spec: %w", err)
+	}
+	if spec.EnvironmentID == "" {
+		return spec, errors.New("spec.environmentId is required")
+	}
+	return spec, nil
+}
+
+// toObject converts status to its unstructured form.
+func toObject(status model.APIGraphStatus) (map[string]any, error) {
+	raw, err := json.Marshal(status)
+	if err != nil {
+		return nil, fmt.Errorf("encoding status: %w", err)
+	}
+	var obj map[string]any
+	if err := json.Unmarshal(raw, &obj); err != nil {
+		return nil, fmt.Errorf("decoding status: %w", err)
+	}
+	return obj, nil
+}
+
+// sameStatus reports whether two unstructured statuses differ only in their
+// last reconciled time. They are compared as JSON, in which numbers decoded
+// as integers and as floats look alike.
+func sameStatus(a, b map[string]any) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	a, b = maps.Clone(a), maps.Clone(b)
+	delete(a, "lastReconciled")
+	delete(b, "lastReconciled")
+	ra, errA := json.Marshal(a)
+	rb, errB := json.Marshal(b)
+	return errA == nil && errB == nil && bytes.Equal(ra, rb)
+}
+
+func failedStatus(err error) model.APIGraphStatus {
+	return model.APIGraphStatus{
+		Phase:             model.PhaseFailed,
+		ComponentStatuses: []model.ComponentStatus{},
+		Message:           err.Error(),
+		LastReconciled:    time.Now(),
+	}
+}
@@ -0,0 +1,243 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"slices"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/reconciler"
+)
+
+func newClient(objs ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{APIGraphResource: "APIGraphList"}, objs...)
+}
+
+func apiGraph(name, environmentID, buildID string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "turboengine.io/v1",
+		"kind":       "APIGraph",
+		"metadata":   map[string]any{"name": name, "namespace": "test-ns"},
+		"spec": map[string]any{
+			"environmentId": environmentID,
+			"buildId":       buildID,
+			"rootPackage":   "root-pkg",
+			"components": []any{map[string]any{
+				"packageName":    "users-api",
+				"packageVersion": "1.0.0",
+				"artifactHash":   "abc123",
+				"runtime":        map[string]any{"replicas": int64(2)},
+			}},
+			"ingress": map[string]any{
+				"host":   "env-1.example.com",
+				"routes": []any{map[string]any{"path": "/graphql", "targetComponent": "users-api", "targetPort": int64(4000)}},
+			},
+		},
+	}}
+}
+
+// waitFor waits for the named APIGraph to satisfy ok.
+func waitFor(t *testing.T, client *dynamicfake.FakeDynamicClient, name string, ok func(*unstructured.Unstructured) bool) *unstructured.Unstructured {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		u, err := client.Resource(APIGraphResource).Namespace("test-ns").Get(context.Background(), name, metav1.GetOptions{})
+		if err == nil && ok(u) {
+			return u
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s, last saw %v (%v)", name, u, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func phase(u *unstructured.Unstructured) string {
+	p, _, _ := unstructured.NestedString(u.Object, "status", "phase")
+	return p
+}
+
+func TestController(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := newClient(apiGraph("env-1", "env-1", "build-1"))
+	rec := reconciler.New(slog.Default(), nil, "test-ns")
+	c := New(client, "test-ns", rec, slog.Default())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+
+	u := waitFor(t, client, "env-1", func(u *unstructured.Unstructured) bool {
+		return phase(u) == string(model.PhaseRunning)
+	})
+	if !slices.Contains(u.GetFinalizers(), Finalizer) {
+		t.Fatalf("expected the finalizer added, got %v", u.GetFinalizers())
+	}
+	if url, _, _ := unstructured.NestedString(u.Object, "status", "previewUrl"); url != "http://env-1.example.com" {
+		t.Fatalf("expected the preview URL in the status, got %q", url)
+	}
+	if spec := rec.GetAllSpecs()["env-1"]; spec.BuildID != "build-1" || spec.Components[0].Runtime.Replicas != 2 {
+		t.Fatalf("expected the APIGraph reconciled, got %+v", spec)
+	}
+
+	// A new build is reconciled.
+	if err := unstructured.SetNestedField(u.Object, "build-2", "spec", "buildId"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Resource(APIGraphResource).Namespace("test-ns").Update(ctx, u, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	waitFor(t, client, "env-1", func(u *unstructured.Unstructured) bool {
+		msg, _, _ := unstructured.NestedString(u.Object, "status", "message")
+		return msg == "Reconciled build build-2"
+	})
+
+	// The fake client deletes objects outright, so mark it deleted as the
+	// API server would while the finalizer holds it.
+	u = waitFor(t, client, "env-1", func(*unstructured.Unstructured) bool { return true })
+	now := metav1.Now()
+	u.SetDeletionTimestamp(&now)
+	if _, err := client.Resource(APIGraphResource).Namespace("test-ns").Update(ctx, u, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	waitFor(t, client, "env-1", func(u *unstructured.Unstructured) bool { return len(u.GetFinalizers()) == 0 })
+	if _, ok := rec.GetStatus("env-1"); ok {
+		t.Fatal("expected the environment torn down")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("controller did not stop")
+	}
+}
+
+// stubReconciler returns canned results.
+type stubReconciler struct {
+	reconcileErr error
+	teardowns    []bool
+	torndown     []string
+}
+
+func (s *stubReconciler) Reconcile(context.Context, model.APIGraphSpec) ([]reconciler.Action, model.APIGraphStatus, error) {
+	return nil, model.APIGraphStatus{Phase: model.PhaseRunning}, s.reconcileErr
+}
+
+func (s *stubReconciler) Teardown(_ context.Context, environmentID string) (model.APIGraphStatus, bool) {
+	s.torndown = append(s.torndown, environmentID)
+	done := s.teardowns[0]
+	s.teardowns = s.teardowns[1:]
+	return model.APIGraphStatus{Phase: model.PhaseDeleting, PendingDeletion: []string{"Pod/users-api-1"}}, done
+}
+
+// newSyncController returns a Controller whose lister serves objs, for
+// calling sync directly.
+func newSyncController(t *testing.T, rec Reconciler, objs ...*unstructured.Unstructured) (*Controller, *dynamicfake.FakeDynamicClient) {
+	t.Helper()
+	runtimeObjs := make([]runtime.Object, len(objs))
+	indexer := cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{cache.NamespaceIndex: cache.MetaNamespaceIndexFunc})
+	for i, obj := range objs {
+		runtimeObjs[i] = obj
+		if err := indexer.Add(obj); err != nil {
+			t.Fatal(err)
+		}
+	}
+	client := newClient(runtimeObjs...)
+	c := New(client, "test-ns", rec, slog.Default())
+	c.lister = cache.NewGenericLister(indexer, APIGraphResource.GroupResource())
+	return c, client
+}
+
+func TestSync_Teardown(t *testing.T) {
+	ctx := context.Background()
+	u := apiGraph("env-1", "env-1", "build-1")
+	u.SetFinalizers([]string{Finalizer, "example.com/other"})
+	now := metav1.Now()
+	u.SetDeletionTimestamp(&now)
+
+	rec := &stubReconciler{teardowns: []bool{false, true}}
+	c, client := newSyncController(t, rec, u)
+
+	// While resources remain, progress is reported and the finalizer kept.
+	if err := c.sync(ctx, "test-ns/env-1"); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	got, _ := client.Resource(APIGraphResource).Namespace("test-ns").Get(ctx, "env-1", metav1.GetOptions{})
+	if phase(got) != string(model.PhaseDeleting) || !slices.Contains(got.GetFinalizers(), Finalizer) {
+		t.Fatalf("expected Deleting with the finalizer kept, got %v", got.Object)
+	}
+	pending, _, _ := unstructured.NestedStringSlice(got.Object, "status", "pendingDeletion")
+	if !slices.Equal(pending, []string{"Pod/users-api-1"}) {
+		t.Fatalf("expected the pending resources reported, got %v", pending)
+	}
+
+	// Once they are gone, only this controller's finalizer is removed.
+	if err := c.sync(ctx, "test-ns/env-1"); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	got, _ = client.Resource(APIGraphResource).Namespace("test-ns").Get(ctx, "env-1", metav1.GetOptions{})
+	if !slices.Equal(got.GetFinalizers(), []string{"example.com/other"}) {
+		t.Fatalf("expected only the finalizer removed, got %v", got.GetFinalizers())
+	}
+	if !slices.Equal(rec.torndown, []string{"env-1", "env-1"}) {
+		t.Fatalf("expected env-1 torn down, got %v", rec.torndown)
+	}
+}
+
+func TestSync_Failures(t *testing.T) {
+	ctx := context.Background()
+
+	invalid := apiGraph("invalid", "", "build-1")
+	failing := apiGraph("failing", "env-2", "build-1")
+	rec := &stubReconciler{reconcileErr: errors.New("referenced secret not supplied: api-key")}
+	c, client := newSyncController(t, rec, invalid, failing)
+
+	for name, want := range map[string]string{
+		"invalid": "spec.environmentId is required",
+		"failing": "referenced secret not supplied: api-key",
+	} {
+		if err := c.sync(ctx, "test-ns/"+name); err != nil {
+			t.Fatalf("sync %s: %v", name, err)
+		}
+		got, _ := client.Resource(APIGraphResource).Namespace("test-ns").Get(ctx, name, metav1.GetOptions{})
+		msg, _, _ := unstructured.NestedString(got.Object, "status", "message")
+		if phase(got) != string(model.PhaseFailed) || msg != want {
+			t.Errorf("%s: expected Failed with %q, got %v", name, want, got.Object["status"])
+		}
+	}
+
+	// A missing APIGraph has nothing to sync.
+	if err := c.sync(ctx, "test-ns/missing"); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+}
+
+func TestSameStatus(t *testing.T) {
+	a, _ := toObject(model.APIGraphStatus{Phase: model.PhaseRunning, LastReconciled: time.Now()})
+	b, _ := toObject(model.APIGraphStatus{Phase: model.PhaseRunning, LastReconciled: time.Now().Add(time.Minute)})
+	if !sameStatus(a, b) {
+		t.Error("expected statuses differing only in time to be the same")
+	}
+	b["phase"] = string(model.PhaseDegraded)
+	if sameStatus(a, b) {
+		t.Error("expected statuses with different phases to differ")
+	}
+	if sameStatus(nil, a) {
+		t.Error("expected a missing status to differ")
+	}
+}
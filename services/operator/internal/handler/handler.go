@@ -4,7 +4,9 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
@@ -32,9 +34,12 @@ func New(r *reconciler.Reconciler, logger *slog.Logger) *Handler {
 	}
 }
 
-// ReconcileRequest is the JSON body for POST /v1/reconcile.
+// ReconcileRequest is the JSON body for POST /v1/reconcile. Secrets holds
+// the values of the environment secrets the spec's components reference;
+// they are written to Kubernetes Secrets and never returned or logged.
 type ReconcileRequest struct {
-	Spec model.APIGraphSpec `json:"spec"`
+	Spec    model.APIGraphSpec `json:"spec"`
+	Secrets map[string]string  `json:"secrets,omitempty"`
 }
 
 // ReconcileResponse is the JSON response from POST /v1/reconcile.
@@ -49,6 +54,14 @@ type StatusResponse struct {
 	Status        model.APIGraphStatus `json:"status"`
 }
 
+// TeardownResponse is the JSON response from
+// DELETE /v1/environments/{environmentId}. Status lists the resources still
+// being deleted, if any.
+type TeardownResponse struct {
+	EnvironmentID string               `json:"environmentId"`
+	Status        model.APIGraphStatus `json:"status"`
+}
+
 // AllStatusesResponse is the JSON response from GET /v1/status.
 type AllStatusesResponse struct {
 	Environments map[string]model.APIGraphStatus `json:"environments"`
@@ -80,6 +93,9 @@ func (h *Handler) RegisterRoutes(mux *http.ServeMux) {
 	mux.HandleFunc("GET /v1/status/{environmentId}", h.handleGetStatus)
 	mux.HandleFunc("GET /v1/status", h.handleGetAllStatuses)
 	mux.HandleFunc("GET /v1/gateway-config", h.handleGatewayConfig)
+	mux.HandleFunc("POST /v1/environments/{environmentId}/sleep", h.handleSleep)
+	mux.HandleFunc("POST /v1/environments/{environmentId}/wake", h.handleWake)
+	mux.HandleFunc("DELETE /v1/environments/{environmentId}", h.handleTeardown)
 }
 
 // handleReconcile triggers reconciliation for a given APIGraphSpec.
@@ -114,7 +130,16 @@ func (h *Handler) handleReconcile(w http.ResponseWriter, r *http.Request) {
 		"build_id", req.Spec.BuildID,
 	)
 
+	req.Spec.Secrets = req.Secrets
 	actions, status, err := h.reconciler.Reconcile(ctx, req.Spec)
+	if errors.Is(err, reconciler.ErrMissingSecret) {
+		h.writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if errors.Is(err, reconciler.ErrDeleting) {
+		h.writeError(w, http.StatusConflict, err.Error())
+		return
+	}
 	if err != nil {
 		h.logger.ErrorContext(ctx, "reconciliation failed",
 			"environment_id", req.Spec.EnvironmentID,
@@ -158,6 +183,67 @@ func (h *Handler) handleGetStatus(w http.ResponseWriter, r *http.Request) {
 	h.writeJSON(w, http.StatusOK, resp)
 }
 
+// handleSleep scales an environment's components to zero.
+func (h *Handler) handleSleep(w http.ResponseWriter, r *http.Request) {
+	h.scale(w, r, "handleSleep", h.reconciler.Sleep)
+}
+
+// handleWake scales a sleeping environment's components back up.
+func (h *Handler) handleWake(w http.ResponseWriter, r *http.Request) {
+	h.scale(w, r, "handleWake", h.reconciler.Wake)
+}
+
+func (h *Handler) scale(w http.ResponseWriter, r *http.Request, name string, fn func(context.Context, string) ([]reconciler.Action, model.APIGraphStatus, error)) {
+	ctx, span := tracer.Start(r.Context(), name)
+	defer span.End()
+
+	environmentID := r.PathValue("environmentId")
+	span.SetAttributes(attribute.String("environment_id", environmentID))
+
+	actions, status, err := fn(ctx, environmentID)
+	if err != nil {
+		if errors.Is(err, reconciler.ErrNotFound) {
+			h.writeError(w, http.StatusNotFound, "environment not found: "+environmentID)
+			return
+		}
+		if errors.Is(err, reconciler.ErrDeleting) {
+			h.writeError(w, http.StatusConflict, err.Error())
+			return
+		}
+		h.logger.ErrorContext(ctx, "scaling failed",
+			"environment_id", environmentID,
+			"error", err,
+		)
+		h.writeError(w, http.StatusInternalServerError, "scaling failed: "+err.Error())
+		return
+	}
+
+	h.writeJSON(w, http.StatusOK, ReconcileResponse{Actions: actions, Status: status})
+}
+
+// handleTeardown deletes an environment's resources and stops routing to
+// it. It responds 202 Accepted while resources are still being deleted,
+// which GET /v1/status/{environmentId} keeps reporting, and 200 OK once
+// they are all gone.
+func (h *Handler) handleTeardown(w http.ResponseWriter, r *http.Request) {
+	ctx, span := tracer.Start(r.Context(), "handleTeardown")
+	defer span.End()
+
+	environmentID := r.PathValue("environmentId")
+	span.SetAttributes(attribute.String("environment_id", environmentID))
+
+	h.logger.InfoContext(ctx, "teardown request received",
+		"environment_id", environmentID,
+	)
+
+	status, done := h.reconciler.Teardown(ctx, environmentID)
+	code := http.StatusAccepted
+	if done {
+		code = http.StatusOK
+	}
+	h.writeJSON(w, code, TeardownResponse{EnvironmentID: environmentID, Status: status})
+}
+
 // handleGetAllStatuses returns status for all tracked environments.
 func (h *Handler) handleGetAllStatuses(w http.ResponseWriter, r *http.Request) {
 	ctx, span := tracer.Start(r.Context(), "handleGetAllStatuses")
@@ -187,7 +273,7 @@ func (h *Handler) handleGatewayConfig(w http.ResponseWriter, r *http.Request) {
 	for _, spec := range specs {
 		for _, route := range spec.Ingress.Routes {
 			// Map the target component to its K8s service DNS name.
-			upstreamURL := fmt.Sprintf("http://svc-%s:%d", route.TargetComponent, route.TargetPort)
+			upstreamURL := fmt.Sprintf("http://%s:%d", reconciler.ServiceName(spec.EnvironmentID, route.TargetComponent), route.TargetPort)
 
 			routes = append(routes, GatewayRoute{
 				PathPrefix:  route.Path,
@@ -281,3 +281,136 @@ func TestHandleReconcile_Idempotent(t *testing.T) {
 		t.Errorf("second reconcile should have 0 actions (idempotent), got %d", len(resp2.Actions))
 	}
 }
+
+func TestHandleSleepAndWake(t *testing.T) {
+	h, mux := setupTestHandler(t)
+
+	if _, _, err := h.reconciler.Reconcile(context.Background(), reconcileSpec()); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	for _, tc := range []struct {
+		path  string
+		phase model.Phase
+	}{
+		{"/v1/environments/env-test-1/sleep", model.PhaseSleeping},
+		{"/v1/environments/env-test-1/wake", model.PhaseRunning},
+	} {
+		req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, req)
+
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", tc.path, rec.Code, rec.Body.String())
+		}
+		var resp ReconcileResponse
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("failed to decode response: %v", err)
+		}
+		if resp.Status.Phase != tc.phase {
+			t.Errorf("%s: expected %s phase, got %s", tc.path, tc.phase, resp.Status.Phase)
+		}
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/v1/environments/nonexistent/sleep", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", rec.Code)
+	}
+}
+
+func TestHandleReconcile_Secrets(t *testing.T) {
+	_, mux := setupTestHandler(t)
+
+	spec := reconcileSpec()
+	spec.Components[0].Runtime.SecretEnv = map[string]string{"API_KEY": "api-key"}
+	post := func(secrets map[string]string) *httptest.ResponseRecorder {
+		body, err := json.Marshal(ReconcileRequest{Spec: spec, Secrets: secrets})
+		if err != nil {
+			t.Fatalf("failed to marshal request: %v", err)
+		}
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/reconcile", bytes.NewReader(body)))
+		return rec
+	}
+
+	if rec := post(nil); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 without the referenced secret, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := post(map[string]string{"api-key": "hunter2"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if bytes.Contains(rec.Body.Bytes(), []byte("hunter2")) {
+		t.Fatalf("response echoes a secret value: %s", rec.Body.String())
+	}
+}
+
+func TestHandleGatewayConfig(t *testing.T) {
+	h, mux := setupTestHandler(t)
+
+	if _, _, err := h.reconciler.Reconcile(context.Background(), reconcileSpec()); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/gateway-config", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var config GatewayConfig
+	if err := json.NewDecoder(rec.Body).Decode(&config); err != nil {
+		t.Fatalf("failed to decode gateway config: %v", err)
+	}
+	routes := config.Routing.Routes
+	if len(routes) != 1 || routes[0].PathPrefix != "/graphql" || routes[0].UpstreamURL != "http://svc-env-test-1-gateway:4000" {
+		t.Fatalf("expected a route to the environment's gateway Service, got %+v", routes)
+	}
+}
+
+func TestHandleTeardown(t *testing.T) {
+	h, mux := setupTestHandler(t)
+
+	if _, _, err := h.reconciler.Reconcile(context.Background(), reconcileSpec()); err != nil {
+		t.Fatalf("failed to reconcile: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/v1/environments/env-test-1", nil)
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp TeardownResponse
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("failed to decode response: %v", err)
+	}
+	if resp.EnvironmentID != "env-test-1" || resp.Status.Phase != model.PhaseDeleting {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/status/env-test-1", nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 once torn down, got %d", rec.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/v1/gateway-config", nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	var config GatewayConfig
+	if err := json.NewDecoder(rec.Body).Decode(&config); err != nil {
+		t.Fatalf("failed to decode gateway config: %v", err)
+	}
+	if len(config.Routing.Routes) != 0 {
+		t.Fatalf("expected the environment's routes removed, got %+v", config.Routing.Routes)
+	}
+
+	// Tearing down again is harmless.
+	req = httptest.NewRequest(http.MethodDelete, "/v1/environments/env-test-1", nil)
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
@@ -2,7 +2,10 @@
 // These represent the CRD spec that the Kubernetes operator watches.
 package model
 
-import "time"
+import (
+	"slices"
+	"time"
+)
 
 // PackageKind classifies what a package provides to the platform.
 type PackageKind string
@@ -28,6 +31,8 @@ const (
 	PhaseRunning   Phase = "Running"
 	PhaseDegraded  Phase = "Degraded"
 	PhaseFailed    Phase = "Failed"
+	PhaseSleeping  Phase = "Sleeping"
+	PhaseDeleting  Phase = "Deleting"
 )
 
 // APIGraphSpec is the top-level CRD representing a deployed dependency tree.
@@ -38,6 +43,14 @@ type APIGraphSpec struct {
 	RootPackage   string              `json:"rootPackage"`
 	Components    []DeployedComponent `json:"components"`
 	Ingress       IngressSpec         `json:"ingress"`
+	// Labels are the environment's user labels, applied to every resource
+	// alongside the operator's own.
+	Labels map[string]string `json:"labels,omitempty"`
+	// Secrets holds the values of the environment secrets the components
+	// reference, keyed by secret name. They arrive alongside a reconcile
+	// request, are written only to Kubernetes Secrets and are never
+	// serialized or kept with the spec.
+	Secrets map[string]string `json:"-"`
 }
 
 // DeployedComponent represents one package deployed as part of the graph.
@@ -47,6 +60,72 @@ type DeployedComponent struct {
 	Kind           PackageKind      `json:"kind"`
 	ArtifactHash   string           `json:"artifactHash"`
 	Runtime        ComponentRuntime `json:"runtime"`
+	// Upstream, if set, replaces the upstream the component proxies to.
+	Upstream *UpstreamConfig `json:"upstream,omitempty"`
+	// SecretsVersion is a digest of the secret values the component
+	// references, set by the operator so that changing a value rolls the
+	// component's pods.
+	SecretsVersion string `json:"secretsVersion,omitempty"`
+	// AwakeReplicas is set while the component is scaled to zero to sleep,
+	// to the replicas it wakes to.
+	AwakeReplicas int32 `json:"awakeReplicas,omitempty"`
+}
+
+// SecretRefs returns the names of the environment secrets the component
+// references, sorted and without duplicates.
+func (c DeployedComponent) SecretRefs() []string {
+	refs := make([]string, 0, len(c.Runtime.SecretEnv))
+	for _, ref := range c.Runtime.SecretEnv {
+		refs = append(refs, ref)
+	}
+	if a := c.Upstream.auth(); a != nil {
+		for _, ref := range []string{a.TokenSecretRef, a.ClientIDSecretRef, a.ClientSecretSecretRef, a.CertSecretRef, a.KeySecretRef, a.CASecretRef} {
+			if ref != "" {
+				refs = append(refs, ref)
+			}
+		}
+	}
+	slices.Sort(refs)
+	return slices.Compact(refs)
+}
+
+// UpstreamConfig is the URL, headers and auth a component uses to reach its
+// upstream API.
+type UpstreamConfig struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+	Auth    *UpstreamAuth     `json:"auth,omitempty"`
+}
+
+func (u *UpstreamConfig) auth() *UpstreamAuth {
+	if u == nil {
+		return nil
+	}
+	return u.Auth
+}
+
+// UpstreamAuthType is how a component authenticates to its upstream.
+type UpstreamAuthType string
+
+const (
+	UpstreamAuthBearer                  UpstreamAuthType = "bearer"
+	UpstreamAuthOAuth2ClientCredentials UpstreamAuthType = "oauth2-client-credentials"
+	UpstreamAuthMTLS                    UpstreamAuthType = "mtls"
+)
+
+// UpstreamAuth authenticates a component to its upstream. Each *SecretRef
+// names an environment secret; the values reach the component from a
+// Kubernetes Secret.
+type UpstreamAuth struct {
+	Type                  UpstreamAuthType `json:"type"`
+	TokenSecretRef        string           `json:"tokenSecretRef,omitempty"`
+	TokenEndpoint         string           `json:"tokenEndpoint,omitempty"`
+	ClientIDSecretRef     string           `json:"clientIdSecretRef,omitempty"`
+	ClientSecretSecretRef string           `json:"clientSecretSecretRef,omitempty"`
+	Scopes                []string         `json:"scopes,omitempty"`
+	CertSecretRef         string           `json:"certSecretRef,omitempty"`
+	KeySecretRef          string           `json:"keySecretRef,omitempty"`
+	CASecretRef           string           `json:"caSecretRef,omitempty"`
 }
 
 // ComponentRuntime holds runtime configuration for a deployed component.
@@ -56,6 +135,9 @@ type ComponentRuntime struct {
 	Resources   ResourceRequirements `json:"resources"`
 	Env         map[string]string    `json:"env,omitempty"`
 	Annotations map[string]string    `json:"annotations,omitempty"`
+	// SecretEnv maps environment variables to the environment secrets they
+	// are set from.
+	SecretEnv map[string]string `json:"secretEnv,omitempty"`
 }
 
 // ResourceRequirements defines CPU and memory limits/requests.
@@ -93,6 +175,23 @@ type APIGraphStatus struct {
 	PreviewURL        string            `json:"previewUrl,omitempty"`
 	Message           string            `json:"message,omitempty"`
 	LastReconciled    time.Time         `json:"lastReconciled"`
+	// Drift lists the managed resources last found to differ from the spec
+	// and not repaired.
+	Drift []ResourceDrift `json:"drift,omitempty"`
+	// PendingDeletion lists the resources, as "Kind/name", that an
+	// environment being deleted is waiting on.
+	PendingDeletion []string `json:"pendingDeletion,omitempty"`
+}
+
+// ResourceDrift describes how a managed resource in the cluster differs from
+// what the spec dictates.
+type ResourceDrift struct {
+	ResourceKind string `json:"resourceKind"`
+	ResourceName string `json:"resourceName"`
+	// Field is what drifted: missing, image, replicas, env, labels, selector
+	// or data.
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 // ComponentStatus reports the observed state of a single component.
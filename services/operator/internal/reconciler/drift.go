@@ -0,0 +1,174 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// DriftDetector is implemented by Appliers that can compare the resources
+// they applied with what a spec dictates.
+type DriftDetector interface {
+	Drift(ctx context.Context, namespace string, spec model.APIGraphSpec) ([]model.ResourceDrift, error)
+}
+
+// WithDriftRepair makes CheckDrift repair the drift it finds by applying
+// the spec again to the drifted resources.
+func WithDriftRepair() Option {
+	return func(r *Reconciler) { r.repairDrift = true }
+}
+
+// CheckDrift compares an environment's resources in the cluster with its
+// spec, if the Applier is a DriftDetector, and reports what drifted in its
+// status. With WithDriftRepair, drifted resources are applied again, and
+// only those that fail to be are reported. Resources with failed actions
+// are left to be retried, and environments that aren't tracked or are being
+// deleted are ignored.
+func (r *Reconciler) CheckDrift(ctx context.Context, environmentID string) error {
+	detector, ok := r.applier.(DriftDetector)
+	if !ok {
+		return nil
+	}
+
+	ctx, span := tracer.Start(ctx, "CheckDrift",
+		trace.WithAttributes(attribute.String("environment_id", environmentID)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.states[environmentID]
+	if !ok || state.Deleting {
+		return nil
+	}
+	spec := state.Spec
+	if state.Sleeping {
+		spec = asleep(spec)
+	}
+	drift, err := detector.Drift(ctx, r.namespace, spec)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("detecting drift: %w", err)
+	}
+	drift = slices.DeleteFunc(drift, func(d model.ResourceDrift) bool {
+		return slices.ContainsFunc(state.Failed, func(f ActionError) bool {
+			return f.Action.ResourceKind == d.ResourceKind && f.Action.ResourceName == d.ResourceName
+		})
+	})
+	span.SetAttributes(attribute.Int("drift", len(drift)))
+
+	for _, d := range drift {
+		r.logger.WarnContext(ctx, "resource drifted from spec",
+			"environment_id", environmentID,
+			"kind", d.ResourceKind,
+			"name", d.ResourceName,
+			"field", d.Field,
+			"message", d.Message,
+		)
+	}
+	repaired := r.repairDrift && len(drift) > 0
+	if repaired {
+		actions := withFailed(repairActions(drift), state)
+		r.logActions(ctx, environmentID, actions)
+		r.apply(ctx, state, actions)
+		// What failed to be repaired is reported as a failed action.
+		drift = nil
+		r.logger.InfoContext(ctx, "repaired drift",
+			"environment_id", environmentID,
+			"failed", len(state.Failed),
+		)
+	}
+
+	if !repaired && slices.Equal(drift, state.Drift) {
+		return nil
+	}
+	state.Drift = drift
+	lastReconciled := state.Status.LastReconciled
+	if state.Sleeping {
+		state.Status = sleepingStatus(state.Spec, state.Status)
+		state.Status.Drift = drift
+	} else {
+		state.Status = r.buildStatus(state.Spec, state)
+	}
+	state.Status.LastReconciled = lastReconciled
+	return nil
+}
+
+// RunDriftChecks checks every environment for drift each interval until ctx
+// is done.
+func (r *Reconciler) RunDriftChecks(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		for environmentID := range r.GetAllSpecs() {
+			if err := r.CheckDrift(ctx, environmentID); err != nil {
+				r.logger.ErrorContext(ctx, "drift check failed",
+					"environment_id", environmentID,
+					"error", err,
+				)
+			}
+		}
+	}
+}
+
+// keepDrift carries the drift of the resources in existing that actions
+// leave alone over to next.
+func keepDrift(existing, next *deployedState, actions []Action) {
+	if existing == nil {
+		return
+	}
+	for _, d := range existing.Drift {
+		if !slices.ContainsFunc(actions, func(a Action) bool {
+			return a.ResourceKind == d.ResourceKind && a.ResourceName == d.ResourceName
+		}) {
+			next.Drift = append(next.Drift, d)
+		}
+	}
+}
+
+// driftMessage summarizes drift for an environment's status.
+func driftMessage(drift []model.ResourceDrift) string {
+	first := drift[0]
+	msg := fmt.Sprintf("%s %s drifted from spec: %s", first.ResourceKind, first.ResourceName, first.Message)
+	if len(drift) > 1 {
+		msg += fmt.Sprintf(" (and %d more)", len(drift)-1)
+	}
+	return msg
+}
+
+// repairActions returns the actions that apply the spec again to each
+// drifted resource: creating the missing ones and updating the rest.
+func repairActions(drift []model.ResourceDrift) []Action {
+	var actions []Action
+	for _, d := range drift {
+		if slices.ContainsFunc(actions, func(a Action) bool {
+			return a.ResourceKind == d.ResourceKind && a.ResourceName == d.ResourceName
+		}) {
+			continue
+		}
+		action := Action{
+			Type:         ActionUpdate,
+			ResourceKind: d.ResourceKind,
+			ResourceName: d.ResourceName,
+			Details:      "repair drift: " + d.Message,
+			// Drift is usually someone else's change, which repair undoes.
+			Force: true,
+		}
+		if d.Field == "missing" {
+			action.Type = ActionCreate
+		}
+		actions = append(actions, action)
+	}
+	return actions
+}
@@ -0,0 +1,130 @@
+package reconciler
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// driftingApplier reports drift until the drifted resources are applied
+// again.
+type driftingApplier struct {
+	failingApplier
+	drift []model.ResourceDrift
+}
+
+func (a *driftingApplier) Apply(ctx context.Context, namespace, environmentID string, actions []Action, spec model.APIGraphSpec) error {
+	err := a.failingApplier.Apply(ctx, namespace, environmentID, actions, spec)
+	a.drift = slices.DeleteFunc(a.drift, func(d model.ResourceDrift) bool {
+		return !a.fail[d.ResourceKind] && slices.ContainsFunc(actions, func(action Action) bool {
+			return action.ResourceName == d.ResourceName
+		})
+	})
+	return err
+}
+
+func (a *driftingApplier) Drift(context.Context, string, model.APIGraphSpec) ([]model.ResourceDrift, error) {
+	return slices.Clone(a.drift), nil
+}
+
+var (
+	imageDrift = model.ResourceDrift{
+		ResourceKind: "Deployment", ResourceName: "deploy-env-1-users-api",
+		Field: "image", Message: "image is debug, want users:1",
+	}
+	missingService = model.ResourceDrift{
+		ResourceKind: "Service", ResourceName: "svc-env-1-users-api",
+		Field: "missing", Message: "Service not found",
+	}
+)
+
+func TestCheckDrift(t *testing.T) {
+	applier := &driftingApplier{}
+	r := New(slog.Default(), applier, "test-ns")
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		makeComponent("users-api", "1.0.0", "abc123", 2),
+	})
+	if _, _, err := r.Reconcile(ctx, spec); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	applier.drift = []model.ResourceDrift{imageDrift, missingService}
+	calls := len(applier.actions)
+	if err := r.CheckDrift(ctx, "env-1"); err != nil {
+		t.Fatalf("CheckDrift: %v", err)
+	}
+	if len(applier.actions) != calls {
+		t.Fatal("expected drift not to be repaired without WithDriftRepair")
+	}
+	status, _ := r.GetStatus("env-1")
+	if status.Phase != model.PhaseDegraded || len(status.Drift) != 2 {
+		t.Fatalf("expected Degraded with the drift, got %+v", status)
+	}
+	if want := "Deployment deploy-env-1-users-api drifted from spec: image is debug, want users:1 (and 1 more)"; status.Message != want {
+		t.Fatalf("expected message %q, got %q", want, status.Message)
+	}
+
+	// Polling the unchanged spec keeps the drift; a change that updates
+	// the Deployment clears its drift until it is next checked.
+	if _, status, _ = r.Reconcile(ctx, spec); len(status.Drift) != 2 {
+		t.Fatalf("expected the drift kept, got %+v", status.Drift)
+	}
+	spec.Components[0].ArtifactHash = "def456"
+	if _, status, _ = r.Reconcile(ctx, spec); len(status.Drift) != 1 || status.Drift[0] != missingService {
+		t.Fatalf("expected only the Service's drift kept, got %+v", status.Drift)
+	}
+
+	if err := r.CheckDrift(ctx, "missing"); err != nil {
+		t.Fatalf("expected an unknown environment to be ignored, got %v", err)
+	}
+}
+
+func TestCheckDrift_Repair(t *testing.T) {
+	applier := &driftingApplier{}
+	r := New(slog.Default(), applier, "test-ns", WithDriftRepair())
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		makeComponent("users-api", "1.0.0", "abc123", 2),
+	})
+	if _, _, err := r.Reconcile(ctx, spec); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	applier.drift = []model.ResourceDrift{imageDrift, missingService}
+	applier.fail = map[string]bool{"Service": true}
+	if err := r.CheckDrift(ctx, "env-1"); err != nil {
+		t.Fatalf("CheckDrift: %v", err)
+	}
+	repair := applier.actions[len(applier.actions)-1]
+	if len(repair) != 2 || repair[0].Type != ActionUpdate || repair[1].Type != ActionCreate || repair[1].ResourceName != "svc-env-1-users-api" {
+		t.Fatalf("expected the Deployment updated and the Service created, got %+v", repair)
+	}
+	if !repair[0].Force || !repair[1].Force {
+		t.Fatalf("expected repairs to be forced, got %+v", repair)
+	}
+	status, _ := r.GetStatus("env-1")
+	if status.Phase != model.PhaseFailed || len(status.Drift) != 0 {
+		t.Fatalf("expected the failed repair reported as a failed action, got %+v", status)
+	}
+
+	// The failed repair is left to be retried rather than repaired again.
+	calls := len(applier.actions)
+	if err := r.CheckDrift(ctx, "env-1"); err != nil {
+		t.Fatalf("CheckDrift: %v", err)
+	}
+	if len(applier.actions) != calls {
+		t.Fatalf("expected nothing repaired again, got %+v", applier.actions[calls:])
+	}
+
+	applier.fail = nil
+	r.retry(ctx, "env-1")
+	if status, _ := r.GetStatus("env-1"); status.Phase != model.PhaseRunning {
+		t.Fatalf("expected Running once repaired, got %+v", status)
+	}
+}
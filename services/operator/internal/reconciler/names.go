@@ -0,0 +1,44 @@
+package reconciler
+
+import (
+	"crypto/sha256"
+	"fmt"
+)
+
+// maxNameLength is the longest name a Service, and so every resource the
+// operator names alike, may have: a DNS label.
+const maxNameLength = 63
+
+// DeploymentName returns the name of a component's Deployment.
+func DeploymentName(environmentID, packageName string) string {
+	return resourceName("deploy", environmentID, packageName)
+}
+
+// ServiceName returns the name of a component's Service, which is also its
+// host name within the namespace.
+func ServiceName(environmentID, packageName string) string {
+	return resourceName("svc", environmentID, packageName)
+}
+
+// ConfigMapName returns the name of a component's ConfigMap.
+func ConfigMapName(environmentID, packageName string) string {
+	return resourceName("cm", environmentID, packageName)
+}
+
+// SecretName returns the name of a component's Secret.
+func SecretName(environmentID, packageName string) string {
+	return resourceName("secret", environmentID, packageName)
+}
+
+// resourceName names one of a component's resources. Environments share a
+// namespace, so the name includes the environment ID as well as the
+// package. Names too long for a DNS label are truncated and suffixed with a
+// digest of the whole name to keep them unique.
+func resourceName(prefix, environmentID, packageName string) string {
+	name := fmt.Sprintf("%s-%s-%s", prefix, environmentID, packageName)
+	if len(name) <= maxNameLength {
+		return name
+	}
+	digest := fmt.Sprintf("%x", sha256.Sum256([]byte(name)))[:8]
+	return name[:maxNameLength-len(digest)-1] + "-" + digest
+}
@@ -0,0 +1,29 @@
+package reconciler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestResourceNames(t *testing.T) {
+	if got := ServiceName("env-1", "users-api"); got != "svc-env-1-users-api" {
+		t.Fatalf("expected svc-env-1-users-api, got %s", got)
+	}
+	if DeploymentName("env-1", "users-api") == DeploymentName("env-2", "users-api") {
+		t.Fatal("expected environments' names to differ")
+	}
+
+	// Environment IDs are 32 hex characters, which leaves little room for
+	// the package.
+	envID := strings.Repeat("a", 32)
+	long := SecretName(envID, "a-package-with-a-rather-long-name")
+	if len(long) != maxNameLength {
+		t.Fatalf("expected a %d character name, got %q", maxNameLength, long)
+	}
+	if other := SecretName(envID, "a-package-with-a-rather-long-name-too"); other == long {
+		t.Fatalf("expected truncated names to stay unique, both are %q", long)
+	}
+	if !strings.HasPrefix(long, "secret-"+envID+"-a-package") {
+		t.Fatalf("expected the truncated name to keep its prefix, got %q", long)
+	}
+}
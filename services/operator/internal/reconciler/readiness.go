@@ -0,0 +1,111 @@
+package reconciler
+
+import (
+	"fmt"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// ObserveComponent records the status of one of an environment's components
+// as observed in the cluster and refreshes the environment's status.
+// Observations of environments or components that aren't tracked, or of
+// environments being deleted, are ignored, and a sleeping environment keeps
+// its sleeping status.
+func (r *Reconciler) ObserveComponent(environmentID string, status model.ComponentStatus) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.states[environmentID]
+	if !ok || state.Deleting {
+		return
+	}
+	cs, ok := state.Components[status.PackageName]
+	if !ok {
+		return
+	}
+	cs.Observed = &status
+	state.Components[status.PackageName] = cs
+	if state.Sleeping {
+		return
+	}
+
+	prev := state.Status
+	state.Status = r.buildStatus(state.Spec, state)
+	state.Status.LastReconciled = prev.LastReconciled
+	if state.Status.Phase != prev.Phase {
+		r.logger.Info("environment phase changed",
+			"environment_id", environmentID,
+			"from", prev.Phase,
+			"to", state.Status.Phase,
+			"message", state.Status.Message,
+		)
+	}
+}
+
+// keepObserved carries the observed statuses of the components in existing
+// whose Deployments actions leave alone over to next. The rest are pending
+// until they are observed again.
+func keepObserved(existing, next *deployedState, actions []Action) {
+	if existing == nil || existing.Sleeping {
+		return
+	}
+	touched := make(map[string]bool)
+	for _, a := range actions {
+		if a.ResourceKind == "Deployment" {
+			touched[a.ResourceName] = true
+		}
+	}
+	for name, cs := range next.Components {
+		prev, ok := existing.Components[name]
+		if !ok || prev.Observed == nil || touched[DeploymentName(next.Spec.EnvironmentID, name)] {
+			continue
+		}
+		cs.Observed = prev.Observed
+		next.Components[name] = cs
+	}
+}
+
+// summarize returns an environment's phase given its components' and, if
+// any component is failing, a message naming the worst of them. An
+// environment is Failed only if every component is; otherwise failing
+// components leave it Degraded.
+func summarize(statuses []model.ComponentStatus) (model.Phase, string) {
+	var running, failed, degraded int
+	var worst *model.ComponentStatus
+	for i := range statuses {
+		s := &statuses[i]
+		switch s.Phase {
+		case model.PhaseRunning:
+			running++
+		case model.PhaseFailed:
+			failed++
+			if worst == nil || worst.Phase != model.PhaseFailed {
+				worst = s
+			}
+		case model.PhaseDegraded:
+			degraded++
+			if worst == nil {
+				worst = s
+			}
+		}
+	}
+
+	var message string
+	if worst != nil {
+		message = fmt.Sprintf("%s is %s", worst.PackageName, worst.Phase)
+		if worst.Message != "" {
+			message = fmt.Sprintf("%s: %s", worst.PackageName, worst.Message)
+		}
+	}
+
+	switch {
+	case running == len(statuses):
+		return model.PhaseRunning, ""
+	case failed == len(statuses):
+		return model.PhaseFailed, message
+	case failed > 0 || degraded > 0:
+		return model.PhaseDegraded, message
+	default:
+		return model.PhaseDeploying, ""
+	}
+}
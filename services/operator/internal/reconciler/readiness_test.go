@@ -0,0 +1,89 @@
+package reconciler
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+func TestObservedReadiness(t *testing.T) {
+	r := New(slog.Default(), nil, "test-ns", WithObservedReadiness())
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		makeComponent("users-api", "1.0.0", "abc123", 2),
+		makeComponent("products-api", "1.0.0", "def456", 1),
+	})
+	_, status, err := r.Reconcile(ctx, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.Phase != model.PhaseDeploying || status.ComponentStatuses[0].Phase != model.PhasePending || status.ComponentStatuses[0].ReadyReplicas != 0 {
+		t.Fatalf("expected Deploying until observed, got %+v", status)
+	}
+
+	running := func(name string, replicas int32) model.ComponentStatus {
+		return model.ComponentStatus{PackageName: name, Phase: model.PhaseRunning, ReadyReplicas: replicas, DesiredReplicas: replicas}
+	}
+	r.ObserveComponent("env-1", running("users-api", 2))
+	if status, _ := r.GetStatus("env-1"); status.Phase != model.PhaseDeploying {
+		t.Fatalf("expected Deploying with one component observed, got %s", status.Phase)
+	}
+	r.ObserveComponent("env-1", running("products-api", 1))
+	if status, _ := r.GetStatus("env-1"); status.Phase != model.PhaseRunning || status.Message != "Reconciled build build-1" {
+		t.Fatalf("expected Running once every component is, got %+v", status)
+	}
+
+	// A failing component degrades the environment.
+	r.ObserveComponent("env-1", model.ComponentStatus{
+		PackageName: "products-api", Phase: model.PhaseFailed, DesiredReplicas: 1,
+		Message: "CrashLoopBackOff in pod products-api-1",
+	})
+	status, _ = r.GetStatus("env-1")
+	if status.Phase != model.PhaseDegraded || status.Message != "products-api: CrashLoopBackOff in pod products-api-1" {
+		t.Fatalf("expected Degraded naming the failing component, got %+v", status)
+	}
+	r.ObserveComponent("env-1", model.ComponentStatus{PackageName: "users-api", Phase: model.PhaseFailed, DesiredReplicas: 2})
+	if status, _ := r.GetStatus("env-1"); status.Phase != model.PhaseFailed {
+		t.Fatalf("expected Failed once every component is, got %+v", status)
+	}
+
+	// Reconciling a change resets only the changed component's observation.
+	r.ObserveComponent("env-1", running("users-api", 2))
+	spec.Components[1].ArtifactHash = "def457"
+	_, status, err = r.Reconcile(ctx, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if status.Phase != model.PhaseDeploying || status.ComponentStatuses[0].Phase != model.PhaseRunning || status.ComponentStatuses[1].Phase != model.PhasePending {
+		t.Fatalf("expected users-api kept running and products-api pending, got %+v", status)
+	}
+
+	// Untracked environments and components are ignored.
+	r.ObserveComponent("env-2", running("users-api", 2))
+	r.ObserveComponent("env-1", running("orders-api", 1))
+	if _, ok := r.GetStatus("env-2"); ok {
+		t.Fatal("expected env-2 to stay untracked")
+	}
+	if status, _ := r.GetStatus("env-1"); len(status.ComponentStatuses) != 2 {
+		t.Fatalf("expected orders-api ignored, got %+v", status.ComponentStatuses)
+	}
+
+	// A sleeping environment stays sleeping whatever is observed.
+	if _, _, err := r.Sleep(ctx, "env-1"); err != nil {
+		t.Fatalf("sleep: %v", err)
+	}
+	r.ObserveComponent("env-1", running("users-api", 0))
+	if status, _ := r.GetStatus("env-1"); status.Phase != model.PhaseSleeping {
+		t.Fatalf("expected Sleeping, got %s", status.Phase)
+	}
+	_, status, err = r.Wake(ctx, "env-1")
+	if err != nil {
+		t.Fatalf("wake: %v", err)
+	}
+	if status.Phase != model.PhaseDeploying {
+		t.Fatalf("expected Deploying after waking until observed, got %s", status.Phase)
+	}
+}
@@ -7,12 +7,16 @@ import (
 	"context"
 	"fmt"
 	"log/slog"
+	"maps"
+	"slices"
 	"sync"
 	"time"
 
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/util/workqueue"
 
 	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
 )
@@ -23,9 +27,12 @@ var tracer = otel.Tracer("operator/reconciler")
 // actual state in line with desired state.
 type Action struct {
 	Type         ActionType `json:"type"`
-	ResourceKind string     `json:"resourceKind"` // Deployment, Service, ConfigMap, Ingress
+	ResourceKind string     `json:"resourceKind"` // Deployment, Service, ConfigMap, Secret, Ingress
 	ResourceName string     `json:"resourceName"`
 	Details      string     `json:"details"`
+	// Force applies the resource even where it would change fields that
+	// something other than the operator manages, rather than failing.
+	Force bool `json:"force,omitempty"`
 }
 
 // ActionType classifies a reconciliation action.
@@ -42,6 +49,21 @@ type deployedState struct {
 	Spec       model.APIGraphSpec
 	Status     model.APIGraphStatus
 	Components map[string]deployedComponentState // keyed by package name
+	// Sleeping is set while the environment's components are scaled to zero.
+	Sleeping bool
+	// Recovered is set for state rebuilt from the cluster by Recover, which
+	// has no ingress until the environment is next reconciled.
+	Recovered bool
+	// Failed lists the actions that failed when last applied. They are
+	// retried with backoff until they succeed or are superseded.
+	Failed []ActionError
+	// Drift lists the resources last found to differ from the spec.
+	Drift []model.ResourceDrift
+	// Deleting is set while the environment is being torn down.
+	Deleting bool
+	// secrets holds the secret values a failed Secret action needs to be
+	// retried. They are never kept otherwise.
+	secrets map[string]string
 }
 
 // deployedComponentState is the in-memory representation of one deployed component.
@@ -50,6 +72,9 @@ type deployedComponentState struct {
 	DeploymentOK bool
 	ServiceOK    bool
 	ConfigMapOK  bool
+	// Observed is the component's status as last observed in the cluster,
+	// if readiness is observed and it has been since it last changed.
+	Observed *model.ComponentStatus
 }
 
 // Applier applies reconciliation actions to a target environment.
@@ -65,16 +90,49 @@ type Reconciler struct {
 	logger    *slog.Logger
 	applier   Applier
 	namespace string
+	// observeReadiness is set when component statuses come from
+	// ObserveComponent rather than being assumed from the spec.
+	observeReadiness bool
+	// repairDrift is set when CheckDrift repairs the drift it finds.
+	repairDrift bool
+
+	// retries queues environments with failed actions, keyed by ID.
+	retries    workqueue.TypedRateLimitingInterface[string]
+	retryBase  time.Duration
+	retryMax   time.Duration
+	retryCount metric.Int64Counter
+}
+
+// Option configures a Reconciler.
+type Option func(*Reconciler)
+
+// WithObservedReadiness makes the reported status of each component the one
+// last passed to ObserveComponent, rather than assuming components are
+// running as soon as their actions are applied. A component is Pending
+// until it has been observed since it last changed.
+func WithObservedReadiness() Option {
+	return func(r *Reconciler) { r.observeReadiness = true }
 }
 
 // New creates a new Reconciler. If applier is nil, actions are only logged.
-func New(logger *slog.Logger, applier Applier, namespace string) *Reconciler {
-	return &Reconciler{
+func New(logger *slog.Logger, applier Applier, namespace string, opts ...Option) *Reconciler {
+	r := &Reconciler{
 		states:    make(map[string]*deployedState),
 		logger:    logger.With("component", "reconciler"),
 		applier:   applier,
 		namespace: namespace,
+		retryBase: defaultRetryBaseDelay,
+		retryMax:  defaultRetryMaxDelay,
+	}
+	for _, opt := range opts {
+		opt(r)
 	}
+	r.retries = newRetryQueue(r.retryBase, r.retryMax)
+	// Creating a counter only fails for an invalid name, and a no-op
+	// counter is returned with the error.
+	r.retryCount, _ = meter.Int64Counter("operator.reconcile.retries",
+		metric.WithDescription("Retries of failed reconciliation actions, by environment and result."))
+	return r
 }
 
 // Reconcile takes a desired APIGraphSpec and reconciles it against the
@@ -100,25 +158,35 @@ func (r *Reconciler) Reconcile(ctx context.Context, spec model.APIGraphSpec) ([]
 	defer r.mu.Unlock()
 
 	existing := r.states[spec.EnvironmentID]
-	actions := r.computeActions(ctx, spec, existing)
+	if existing != nil && existing.Deleting {
+		return nil, model.APIGraphStatus{}, fmt.Errorf("%w: %s", ErrDeleting, spec.EnvironmentID)
+	}
+	spec, err := withSecretsVersions(spec, existing)
+	if err != nil {
+		span.RecordError(err)
+		return nil, model.APIGraphStatus{}, err
+	}
+	// Actions that failed before are retried along with the new ones.
+	actions := withFailed(r.computeActions(ctx, spec, existing), existing)
 
 	// Log all actions for observability.
 	r.logActions(ctx, spec.EnvironmentID, actions)
 
-	// Apply actions via the applier (K8s client or noop).
-	if r.applier != nil && len(actions) > 0 {
-		if err := r.applier.Apply(ctx, r.namespace, spec.EnvironmentID, actions, spec); err != nil {
-			r.logger.ErrorContext(ctx, "failed to apply actions",
-				"environment_id", spec.EnvironmentID,
-				"error", err,
-			)
-			// Continue anyway — update in-memory state so we can retry next cycle.
-		}
+	// Record the desired spec as deployed, then apply actions via the
+	// applier (K8s client or noop); the ones that fail are recorded on the
+	// state and retried. Secret values live only in the cluster's Secrets
+	// once written.
+	secrets := spec.Secrets
+	if secrets == nil && existing != nil {
+		secrets = existing.secrets
 	}
-
-	// Update in-memory state to reflect the desired spec.
+	spec.Secrets = nil
 	newState := r.buildState(spec)
+	newState.secrets = secrets
+	keepObserved(existing, newState, actions)
+	keepDrift(existing, newState, actions)
 	r.states[spec.EnvironmentID] = newState
+	r.apply(ctx, newState, actions)
 
 	status := r.buildStatus(spec, newState)
 	newState.Status = status
@@ -161,15 +229,18 @@ func (r *Reconciler) GetAllStatuses() map[string]model.APIGraphStatus {
 	return result
 }
 
-// GetAllSpecs returns the APIGraphSpec for every tracked environment.
-// Used by the gateway-config endpoint to build routing rules.
+// GetAllSpecs returns the APIGraphSpec for every tracked environment that
+// isn't being deleted. Used by the gateway-config endpoint to build routing
+// rules.
 func (r *Reconciler) GetAllSpecs() map[string]model.APIGraphSpec {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
 	result := make(map[string]model.APIGraphSpec, len(r.states))
 	for envID, state := range r.states {
-		result[envID] = state.Spec
+		if !state.Deleting {
+			result[envID] = state.Spec
+		}
 	}
 	return result
 }
@@ -191,36 +262,44 @@ func (r *Reconciler) computeActions(ctx context.Context, spec model.APIGraphSpec
 	if existing == nil {
 		// No existing state — everything is a create.
 		for _, c := range spec.Components {
-			actions = append(actions, r.createActionsForComponent(c)...)
+			actions = append(actions, r.createActionsForComponent(spec.EnvironmentID, c)...)
 		}
 		// Create ingress resources.
 		actions = append(actions, r.createActionsForIngress(spec.Ingress)...)
 	} else {
 		existingComponents := existing.Components
+		labelsChanged := !maps.Equal(existing.Spec.Labels, spec.Labels)
 
 		// Check each desired component against existing.
 		for name, desired := range desiredComponents {
 			ec, exists := existingComponents[name]
 			if !exists {
 				// New component — create.
-				actions = append(actions, r.createActionsForComponent(desired)...)
-			} else if ec.Component.ArtifactHash != desired.ArtifactHash ||
-				ec.Component.Runtime.Replicas != desired.Runtime.Replicas {
-				// Changed — update.
-				actions = append(actions, r.updateActionsForComponent(desired)...)
+				actions = append(actions, r.createActionsForComponent(spec.EnvironmentID, desired)...)
+			} else if existing.Sleeping || ec.Component.ArtifactHash != desired.ArtifactHash ||
+				ec.Component.Runtime.Replicas != desired.Runtime.Replicas ||
+				ec.Component.SecretsVersion != desired.SecretsVersion ||
+				!upstreamsEqual(ec.Component.Upstream, desired.Upstream) {
+				// Changed, or scaled to zero while sleeping — update.
+				actions = append(actions, r.updateActionsForComponent(spec.EnvironmentID, ec.Component, desired)...)
+			} else if labelsChanged {
+				// Only the environment's labels changed — relabel.
+				actions = append(actions, r.relabelActionsForComponent(spec.EnvironmentID, desired, spec.Labels)...)
 			}
 			// Otherwise unchanged — no action needed.
 		}
 
 		// Check for components that need to be deleted.
-		for name := range existingComponents {
+		for name, ec := range existingComponents {
 			if _, stillDesired := desiredComponents[name]; !stillDesired {
-				actions = append(actions, r.deleteActionsForComponent(name)...)
+				actions = append(actions, r.deleteActionsForComponent(spec.EnvironmentID, ec.Component)...)
 			}
 		}
 
-		// Reconcile ingress if host changed or routes changed.
-		if existing.Spec.Ingress.Host != spec.Ingress.Host || !routesEqual(existing.Spec.Ingress.Routes, spec.Ingress.Routes) {
+		// Reconcile ingress if host changed or routes changed. Ingress
+		// isn't kept in the cluster, so recovered state takes the desired
+		// ingress as it is.
+		if !existing.Recovered && (existing.Spec.Ingress.Host != spec.Ingress.Host || !routesEqual(existing.Spec.Ingress.Routes, spec.Ingress.Routes)) {
 			actions = append(actions, Action{
 				Type:         ActionUpdate,
 				ResourceKind: "Ingress",
@@ -234,70 +313,131 @@ func (r *Reconciler) computeActions(ctx context.Context, spec model.APIGraphSpec
 	return actions
 }
 
-// createActionsForComponent returns the actions needed to deploy a new component.
-func (r *Reconciler) createActionsForComponent(c model.DeployedComponent) []Action {
-	return []Action{
+// createActionsForComponent returns the actions needed to deploy a new
+// component. Its Secret, if any, comes first so its pods can start.
+func (r *Reconciler) createActionsForComponent(environmentID string, c model.DeployedComponent) []Action {
+	var actions []Action
+	if action, ok := secretAction(environmentID, model.DeployedComponent{}, c); ok {
+		actions = append(actions, action)
+	}
+	return append(actions, []Action{
 		{
 			Type:         ActionCreate,
 			ResourceKind: "Deployment",
-			ResourceName: deploymentName(c.PackageName),
+			ResourceName: DeploymentName(environmentID, c.PackageName),
 			Details:      fmt.Sprintf("image=artifact:%s replicas=%d", c.ArtifactHash, c.Runtime.Replicas),
 		},
 		{
 			Type:         ActionCreate,
 			ResourceKind: "Service",
-			ResourceName: serviceName(c.PackageName),
+			ResourceName: ServiceName(environmentID, c.PackageName),
 			Details:      fmt.Sprintf("selector=%s", c.PackageName),
 		},
 		{
 			Type:         ActionCreate,
 			ResourceKind: "ConfigMap",
-			ResourceName: configMapName(c.PackageName),
+			ResourceName: ConfigMapName(environmentID, c.PackageName),
 			Details:      fmt.Sprintf("env_vars=%d", len(c.Runtime.Env)),
 		},
-	}
+	}...)
 }
 
-// updateActionsForComponent returns the actions needed to update an existing component.
-func (r *Reconciler) updateActionsForComponent(c model.DeployedComponent) []Action {
-	return []Action{
+// updateActionsForComponent returns the actions needed to update an existing
+// component from prev. A Secret it now needs is written before its
+// Deployment and one it no longer needs is deleted after.
+func (r *Reconciler) updateActionsForComponent(environmentID string, prev, c model.DeployedComponent) []Action {
+	secret, changed := secretAction(environmentID, prev, c)
+	var actions []Action
+	if changed && secret.Type != ActionDelete {
+		actions = append(actions, secret)
+	}
+	actions = append(actions, []Action{
 		{
 			Type:         ActionUpdate,
 			ResourceKind: "Deployment",
-			ResourceName: deploymentName(c.PackageName),
+			ResourceName: DeploymentName(environmentID, c.PackageName),
 			Details:      fmt.Sprintf("image=artifact:%s replicas=%d", c.ArtifactHash, c.Runtime.Replicas),
 		},
 		{
 			Type:         ActionUpdate,
 			ResourceKind: "ConfigMap",
-			ResourceName: configMapName(c.PackageName),
+			ResourceName: ConfigMapName(environmentID, c.PackageName),
 			Details:      fmt.Sprintf("env_vars=%d", len(c.Runtime.Env)),
 		},
+	}...)
+	if changed && secret.Type == ActionDelete {
+		actions = append(actions, secret)
+	}
+	return actions
+}
+
+// relabelActionsForComponent returns the actions needed to bring an
+// otherwise unchanged component's resources up to date with new labels.
+func (r *Reconciler) relabelActionsForComponent(environmentID string, c model.DeployedComponent, labels map[string]string) []Action {
+	details := fmt.Sprintf("labels=%d", len(labels))
+	actions := []Action{
+		{
+			Type:         ActionUpdate,
+			ResourceKind: "Deployment",
+			ResourceName: DeploymentName(environmentID, c.PackageName),
+			Details:      details,
+		},
+		{
+			Type:         ActionUpdate,
+			ResourceKind: "Service",
+			ResourceName: ServiceName(environmentID, c.PackageName),
+			Details:      details,
+		},
+		{
+			Type:         ActionUpdate,
+			ResourceKind: "ConfigMap",
+			ResourceName: ConfigMapName(environmentID, c.PackageName),
+			Details:      details,
+		},
+	}
+	if c.SecretsVersion != "" {
+		actions = append(actions, Action{
+			Type:         ActionUpdate,
+			ResourceKind: "Secret",
+			ResourceName: SecretName(environmentID, c.PackageName),
+			Details:      details,
+		})
 	}
+	return actions
 }
 
 // deleteActionsForComponent returns the actions needed to remove a component.
-func (r *Reconciler) deleteActionsForComponent(packageName string) []Action {
-	return []Action{
+func (r *Reconciler) deleteActionsForComponent(environmentID string, c model.DeployedComponent) []Action {
+	packageName := c.PackageName
+	actions := []Action{
 		{
 			Type:         ActionDelete,
 			ResourceKind: "Deployment",
-			ResourceName: deploymentName(packageName),
+			ResourceName: DeploymentName(environmentID, packageName),
 			Details:      "removing unused component",
 		},
 		{
 			Type:         ActionDelete,
 			ResourceKind: "Service",
-			ResourceName: serviceName(packageName),
+			ResourceName: ServiceName(environmentID, packageName),
 			Details:      "removing unused component",
 		},
 		{
 			Type:         ActionDelete,
 			ResourceKind: "ConfigMap",
-			ResourceName: configMapName(packageName),
+			ResourceName: ConfigMapName(environmentID, packageName),
 			Details:      "removing unused component",
 		},
 	}
+	if c.SecretsVersion != "" {
+		actions = append(actions, Action{
+			Type:         ActionDelete,
+			ResourceKind: "Secret",
+			ResourceName: SecretName(environmentID, packageName),
+			Details:      "removing unused component",
+		})
+	}
+	return actions
 }
 
 // createActionsForIngress returns actions for setting up ingress resources.
@@ -348,29 +488,58 @@ func (r *Reconciler) buildState(spec model.APIGraphSpec) *deployedState {
 // buildStatus generates an APIGraphStatus from the current state.
 func (r *Reconciler) buildStatus(spec model.APIGraphSpec, state *deployedState) model.APIGraphStatus {
 	statuses := make([]model.ComponentStatus, 0, len(spec.Components))
-	allRunning := true
 
+	failures := failuresByComponent(spec, state.Failed)
 	for _, c := range spec.Components {
 		cs, ok := state.Components[c.PackageName]
-		phase := model.PhaseRunning
-		readyReplicas := c.Runtime.Replicas
-		if !ok || !cs.DeploymentOK {
-			phase = model.PhasePending
-			readyReplicas = 0
-			allRunning = false
+		if err, failed := failures[c.PackageName]; failed {
+			status := model.ComponentStatus{
+				PackageName:     c.PackageName,
+				Phase:           model.PhaseFailed,
+				DesiredReplicas: c.Runtime.Replicas,
+				Message:         fmt.Sprintf("apply failed: %v", err),
+			}
+			// Pods observed ready still serve, from before the change.
+			if r.observeReadiness && ok && cs.Observed != nil && cs.Observed.ReadyReplicas > 0 {
+				status.Phase = model.PhaseDegraded
+				status.ReadyReplicas = cs.Observed.ReadyReplicas
+			}
+			statuses = append(statuses, status)
+			continue
+		}
+		switch {
+		case r.observeReadiness && ok && cs.Observed != nil:
+			statuses = append(statuses, *cs.Observed)
+		case r.observeReadiness || !ok || !cs.DeploymentOK:
+			statuses = append(statuses, model.ComponentStatus{
+				PackageName:     c.PackageName,
+				Phase:           model.PhasePending,
+				DesiredReplicas: c.Runtime.Replicas,
+			})
+		default:
+			statuses = append(statuses, model.ComponentStatus{
+				PackageName:     c.PackageName,
+				Phase:           model.PhaseRunning,
+				ReadyReplicas:   c.Runtime.Replicas,
+				DesiredReplicas: c.Runtime.Replicas,
+			})
 		}
-
-		statuses = append(statuses, model.ComponentStatus{
-			PackageName:     c.PackageName,
-			Phase:           phase,
-			ReadyReplicas:   readyReplicas,
-			DesiredReplicas: c.Runtime.Replicas,
-		})
 	}
 
-	graphPhase := model.PhaseRunning
-	if !allRunning {
-		graphPhase = model.PhaseDeploying
+	graphPhase, message := summarize(statuses)
+	if len(state.Failed) > 0 && len(failures) == 0 {
+		// Only resources outside the components failed, such as those of
+		// components being removed.
+		message = fmt.Sprintf("apply failed: %v", state.Failed[0].Err)
+		if graphPhase == model.PhaseRunning {
+			graphPhase = model.PhaseDegraded
+		}
+	}
+	if len(state.Drift) > 0 && graphPhase == model.PhaseRunning {
+		graphPhase, message = model.PhaseDegraded, driftMessage(state.Drift)
+	}
+	if message == "" {
+		message = fmt.Sprintf("Reconciled build %s", spec.BuildID)
 	}
 
 	previewURL := ""
@@ -386,8 +555,9 @@ func (r *Reconciler) buildStatus(spec model.APIGraphSpec, state *deployedState)
 		Phase:             graphPhase,
 		ComponentStatuses: statuses,
 		PreviewURL:        previewURL,
-		Message:           fmt.Sprintf("Reconciled build %s", spec.BuildID),
+		Message:           message,
 		LastReconciled:    time.Now(),
+		Drift:             state.Drift,
 	}
 }
 
@@ -404,14 +574,20 @@ func routesEqual(a, b []model.IngressRoute) bool {
 	return true
 }
 
-func deploymentName(packageName string) string {
-	return fmt.Sprintf("deploy-%s", packageName)
-}
-
-func serviceName(packageName string) string {
-	return fmt.Sprintf("svc-%s", packageName)
-}
-
-func configMapName(packageName string) string {
-	return fmt.Sprintf("cm-%s", packageName)
+// upstreamsEqual compares two optional upstream configs.
+func upstreamsEqual(a, b *model.UpstreamConfig) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	if a.URL != b.URL || !maps.Equal(a.Headers, b.Headers) || (a.Auth == nil) != (b.Auth == nil) {
+		return false
+	}
+	if a.Auth == nil {
+		return true
+	}
+	x, y := a.Auth, b.Auth
+	return x.Type == y.Type && x.TokenSecretRef == y.TokenSecretRef &&
+		x.TokenEndpoint == y.TokenEndpoint && x.ClientIDSecretRef == y.ClientIDSecretRef &&
+		x.ClientSecretSecretRef == y.ClientSecretSecretRef && slices.Equal(x.Scopes, y.Scopes) &&
+		x.CertSecretRef == y.CertSecretRef && x.KeySecretRef == y.KeySecretRef && x.CASecretRef == y.CASecretRef
 }
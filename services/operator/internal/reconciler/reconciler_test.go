@@ -199,6 +199,80 @@ func TestReconcile_ScaleComponent(t *testing.T) {
 	}
 }
 
+func TestReconcile_UpstreamChange(t *testing.T) {
+	r := newTestReconciler()
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		makeComponent("users-api", "1.0.0", "abc123", 2),
+	})
+
+	_, _, err := r.Reconcile(ctx, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Point the component at a developer's upstream without a new artifact.
+	spec.Components[0].Upstream = &model.UpstreamConfig{URL: "http://dev.internal:4001"}
+
+	actions, _, err := r.Reconcile(ctx, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	foundConfigMapUpdate := false
+	for _, a := range actions {
+		if a.ResourceKind == "ConfigMap" && a.Type == ActionUpdate {
+			foundConfigMapUpdate = true
+		}
+	}
+	if !foundConfigMapUpdate {
+		t.Errorf("expected a ConfigMap Update action for the upstream change, got %+v", actions)
+	}
+}
+
+func TestReconcile_LabelChange(t *testing.T) {
+	r := newTestReconciler()
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		makeComponent("users-api", "1.0.0", "abc123", 2),
+	})
+	spec.Labels = map[string]string{"team": "payments"}
+
+	if _, _, err := r.Reconcile(ctx, spec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Re-reconciling with the same labels is a no-op.
+	actions, _, err := r.Reconcile(ctx, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(actions) != 0 {
+		t.Fatalf("expected no actions for unchanged labels, got %+v", actions)
+	}
+
+	spec.Labels = map[string]string{"team": "payments", "purpose": "demo"}
+	actions, _, err = r.Reconcile(ctx, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	updated := make(map[string]bool)
+	for _, a := range actions {
+		if a.Type != ActionUpdate {
+			t.Fatalf("expected only updates for a label change, got %+v", a)
+		}
+		updated[a.ResourceKind] = true
+	}
+	for _, kind := range []string{"Deployment", "Service", "ConfigMap"} {
+		if !updated[kind] {
+			t.Errorf("expected a %s Update action for the label change, got %+v", kind, actions)
+		}
+	}
+}
+
 func TestReconcile_AddComponent(t *testing.T) {
 	r := newTestReconciler()
 	ctx := context.Background()
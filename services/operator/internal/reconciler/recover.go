@@ -0,0 +1,77 @@
+package reconciler
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// StateLoader is implemented by an Applier that can list what it has
+// already deployed, so that a restarted operator picks up where it left off.
+type StateLoader interface {
+	// LoadDeployed returns the spec of every environment with resources in
+	// namespace, as far as the resources record it. A sleeping component
+	// has zero replicas and its AwakeReplicas set.
+	LoadDeployed(ctx context.Context, namespace string) ([]model.APIGraphSpec, error)
+}
+
+// Recover rebuilds the deployed state of every environment the applier has
+// resources for, so that after a restart environments aren't treated as
+// new and their status is served straight away. It does nothing if the
+// applier isn't a StateLoader, and keeps the state of environments already
+// reconciled. Call it before reconciling.
+func (r *Reconciler) Recover(ctx context.Context) error {
+	ctx, span := tracer.Start(ctx, "Recover")
+	defer span.End()
+
+	loader, ok := r.applier.(StateLoader)
+	if !ok {
+		return nil
+	}
+	specs, err := loader.LoadDeployed(ctx, r.namespace)
+	if err != nil {
+		span.RecordError(err)
+		return fmt.Errorf("load deployed state: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	recovered := 0
+	for _, spec := range specs {
+		if _, exists := r.states[spec.EnvironmentID]; exists {
+			continue
+		}
+		r.states[spec.EnvironmentID] = r.recoveredState(spec)
+		recovered++
+	}
+
+	r.logger.InfoContext(ctx, "recovered deployed state", "environments", recovered)
+	return nil
+}
+
+// recoveredState builds the state of an environment loaded from the
+// cluster, waking its sleeping components' replicas back into the spec so
+// that Wake and the next reconcile know what to scale them to.
+func (r *Reconciler) recoveredState(spec model.APIGraphSpec) *deployedState {
+	sleeping := false
+	for i := range spec.Components {
+		c := &spec.Components[i]
+		if c.AwakeReplicas > 0 {
+			sleeping = true
+			c.Runtime.Replicas = c.AwakeReplicas
+			c.AwakeReplicas = 0
+		}
+	}
+
+	state := r.buildState(spec)
+	state.Recovered = true
+	state.Sleeping = sleeping
+	state.Status = r.buildStatus(spec, state)
+	state.Status.Message = fmt.Sprintf("Recovered build %s", spec.BuildID)
+	if sleeping {
+		state.Status = sleepingStatus(spec, state.Status)
+	}
+	return state
+}
@@ -0,0 +1,82 @@
+package reconciler
+
+import (
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// loadingApplier is a recordingApplier that reports specs as deployed.
+type loadingApplier struct {
+	recordingApplier
+	deployed []model.APIGraphSpec
+}
+
+func (a *loadingApplier) LoadDeployed(context.Context, string) ([]model.APIGraphSpec, error) {
+	return a.deployed, nil
+}
+
+func TestRecover(t *testing.T) {
+	ctx := context.Background()
+	users := makeComponent("users-api", "1.0.0", "abc123", 2)
+	sleeping := makeComponent("orders-api", "1.0.0", "def456", 0)
+	sleeping.AwakeReplicas = 3
+
+	applier := &loadingApplier{deployed: []model.APIGraphSpec{
+		{EnvironmentID: "env-1", BuildID: "build-1", Components: []model.DeployedComponent{users}},
+		{EnvironmentID: "env-2", BuildID: "build-2", Components: []model.DeployedComponent{sleeping}},
+		{EnvironmentID: "env-3", BuildID: "build-3", Components: []model.DeployedComponent{users}},
+	}}
+	r := New(slog.Default(), applier, "test-ns")
+
+	// State already reconciled is kept.
+	if _, _, err := r.Reconcile(ctx, makeSpec("env-3", "build-4", []model.DeployedComponent{users})); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if err := r.Recover(ctx); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if specs := r.GetAllSpecs(); len(specs) != 3 || specs["env-3"].BuildID != "build-4" {
+		t.Fatalf("expected env-3 kept as reconciled, got %+v", specs["env-3"])
+	}
+
+	status, ok := r.GetStatus("env-1")
+	if !ok || status.Phase != model.PhaseRunning || status.Message != "Recovered build build-1" {
+		t.Fatalf("expected env-1 recovered and running, got %+v", status)
+	}
+
+	// Recovered state has no ingress, and the desired one is taken without
+	// an action.
+	actions, _, err := r.Reconcile(ctx, makeSpec("env-1", "build-1", []model.DeployedComponent{users}))
+	if err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(actions) != 0 {
+		t.Fatalf("expected no actions, got %+v", actions)
+	}
+	if actions, _, _ := r.Reconcile(ctx, makeSpec("env-1", "build-1", []model.DeployedComponent{users})); len(actions) != 0 {
+		t.Fatalf("expected no actions reconciling again, got %+v", actions)
+	}
+
+	if status, _ := r.GetStatus("env-2"); status.Phase != model.PhaseSleeping {
+		t.Fatalf("expected env-2 sleeping, got %+v", status)
+	}
+	if _, _, err := r.Wake(ctx, "env-2"); err != nil {
+		t.Fatalf("wake: %v", err)
+	}
+	if got := applier.replicas[len(applier.replicas)-1]; len(got) != 1 || got[0] != 3 {
+		t.Fatalf("expected env-2 woken to its awake replicas, got %v", got)
+	}
+}
+
+func TestRecover_WithoutLoader(t *testing.T) {
+	r := New(slog.Default(), &recordingApplier{}, "test-ns")
+	if err := r.Recover(context.Background()); err != nil {
+		t.Fatalf("recover: %v", err)
+	}
+	if statuses := r.GetAllStatuses(); len(statuses) != 0 {
+		t.Fatalf("expected no state, got %v", statuses)
+	}
+}
@@ -0,0 +1,220 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+var meter = otel.Meter("operator/reconciler")
+
+// Default backoff between retries of an environment's failed actions.
+const (
+	defaultRetryBaseDelay = time.Second
+	defaultRetryMaxDelay  = 5 * time.Minute
+)
+
+// ActionError is an action an Applier failed to apply, and why.
+type ActionError struct {
+	Action Action
+	Err    error
+}
+
+// ApplyError is returned by an Applier that failed to apply some of its
+// actions. Failed lists them in the order they were given; the rest were
+// applied.
+type ApplyError struct {
+	Failed []ActionError
+}
+
+func (e *ApplyError) Error() string {
+	if len(e.Failed) == 1 {
+		return e.Failed[0].Err.Error()
+	}
+	return fmt.Sprintf("%d actions failed, first: %v", len(e.Failed), e.Failed[0].Err)
+}
+
+func (e *ApplyError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, f := range e.Failed {
+		errs[i] = f.Err
+	}
+	return errs
+}
+
+// WithRetryBackoff sets the delay before an environment's failed actions
+// are first retried, doubling with each further retry up to max.
+func WithRetryBackoff(base, max time.Duration) Option {
+	return func(r *Reconciler) { r.retryBase, r.retryMax = base, max }
+}
+
+// RunRetries re-applies environments' failed actions as their backoff
+// expires, until ctx is done.
+func (r *Reconciler) RunRetries(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		r.retries.ShutDown()
+	}()
+	for {
+		environmentID, shutdown := r.retries.Get()
+		if shutdown {
+			return
+		}
+		r.retry(ctx, environmentID)
+		r.retries.Done(environmentID)
+	}
+}
+
+// retry re-applies an environment's failed actions, or deletes its
+// resources again if it is being torn down.
+func (r *Reconciler) retry(ctx context.Context, environmentID string) {
+	ctx, span := tracer.Start(ctx, "Retry",
+		trace.WithAttributes(attribute.String("environment_id", environmentID)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.states[environmentID]
+	if ok && state.Deleting {
+		r.teardown(ctx, state)
+		return
+	}
+	if !ok || len(state.Failed) == 0 {
+		r.retries.Forget(environmentID)
+		return
+	}
+	actions := make([]Action, len(state.Failed))
+	for i, f := range state.Failed {
+		actions[i] = f.Action
+	}
+	r.logger.InfoContext(ctx, "retrying failed actions",
+		"environment_id", environmentID,
+		"actions", len(actions),
+		"attempt", r.retries.NumRequeues(environmentID),
+	)
+	r.logActions(ctx, environmentID, actions)
+
+	r.apply(ctx, state, actions)
+	if !state.Sleeping {
+		lastReconciled := state.Status.LastReconciled
+		state.Status = r.buildStatus(state.Spec, state)
+		state.Status.LastReconciled = lastReconciled
+	}
+
+	result := "succeeded"
+	if len(state.Failed) > 0 {
+		result = "failed"
+		span.RecordError(state.Failed[0].Err)
+	}
+	r.retryCount.Add(ctx, 1, metric.WithAttributes(
+		attribute.String("environment_id", environmentID),
+		attribute.String("result", result),
+	))
+}
+
+// apply applies actions to state's spec, recording the ones that fail on
+// state and scheduling them to be retried with backoff. Secret values are
+// kept on state only while a Secret still needs writing.
+func (r *Reconciler) apply(ctx context.Context, state *deployedState, actions []Action) {
+	environmentID := state.Spec.EnvironmentID
+	state.Failed = nil
+	if r.applier != nil && len(actions) > 0 {
+		spec := state.Spec
+		if state.Sleeping {
+			// Retried Deployments must stay scaled to zero.
+			spec = asleep(spec)
+		}
+		spec.Secrets = state.secrets
+		if err := r.applier.Apply(ctx, r.namespace, environmentID, actions, spec); err != nil {
+			r.logger.ErrorContext(ctx, "failed to apply actions",
+				"environment_id", environmentID,
+				"error", err,
+			)
+			state.Failed = failedActions(actions, err)
+		}
+	}
+
+	if !slices.ContainsFunc(state.Failed, func(f ActionError) bool { return f.Action.ResourceKind == "Secret" }) {
+		state.secrets = nil
+	}
+	if len(state.Failed) == 0 {
+		r.retries.Forget(environmentID)
+		return
+	}
+	r.retries.AddRateLimited(environmentID)
+}
+
+// failedActions returns the actions err says failed: those in an
+// ApplyError, or all of them for any other error.
+func failedActions(actions []Action, err error) []ActionError {
+	var applyErr *ApplyError
+	if errors.As(err, &applyErr) {
+		return applyErr.Failed
+	}
+	failed := make([]ActionError, len(actions))
+	for i, a := range actions {
+		failed[i] = ActionError{Action: a, Err: err}
+	}
+	return failed
+}
+
+// withFailed returns actions preceded by the failed actions of existing on
+// resources that actions don't touch, so that they are retried.
+func withFailed(actions []Action, existing *deployedState) []Action {
+	if existing == nil || len(existing.Failed) == 0 {
+		return actions
+	}
+	type resource struct{ kind, name string }
+	touched := make(map[resource]bool, len(actions))
+	for _, a := range actions {
+		touched[resource{a.ResourceKind, a.ResourceName}] = true
+	}
+	var carried []Action
+	for _, f := range existing.Failed {
+		if !touched[resource{f.Action.ResourceKind, f.Action.ResourceName}] {
+			carried = append(carried, f.Action)
+		}
+	}
+	return append(carried, actions...)
+}
+
+// newRetryQueue returns the queue of environments with failed actions.
+func newRetryQueue(base, max time.Duration) workqueue.TypedRateLimitingInterface[string] {
+	return workqueue.NewTypedRateLimitingQueueWithConfig(
+		workqueue.NewTypedItemExponentialFailureRateLimiter[string](base, max),
+		workqueue.TypedRateLimitingQueueConfig[string]{Name: "reconciler-retries"},
+	)
+}
+
+// failuresByComponent returns the first error of each of spec's components
+// with a failed action.
+func failuresByComponent(spec model.APIGraphSpec, failed []ActionError) map[string]error {
+	if len(failed) == 0 {
+		return nil
+	}
+	byResource := make(map[string]string, 4*len(spec.Components))
+	for _, c := range spec.Components {
+		for _, name := range []string{DeploymentName(spec.EnvironmentID, c.PackageName), ServiceName(spec.EnvironmentID, c.PackageName), ConfigMapName(spec.EnvironmentID, c.PackageName), SecretName(spec.EnvironmentID, c.PackageName)} {
+			byResource[name] = c.PackageName
+		}
+	}
+	failures := make(map[string]error)
+	for _, f := range failed {
+		name, ok := byResource[f.Action.ResourceName]
+		if _, seen := failures[name]; ok && !seen {
+			failures[name] = f.Err
+		}
+	}
+	return failures
+}
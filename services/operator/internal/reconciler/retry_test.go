@@ -0,0 +1,153 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// failingApplier fails every action on a resource of a kind in fail, and
+// records the actions and spec of each call.
+type failingApplier struct {
+	fail    map[string]bool
+	actions [][]Action
+	specs   []model.APIGraphSpec
+}
+
+func (a *failingApplier) Apply(_ context.Context, _, _ string, actions []Action, spec model.APIGraphSpec) error {
+	a.actions = append(a.actions, actions)
+	a.specs = append(a.specs, spec)
+	var failed []ActionError
+	for _, action := range actions {
+		if a.fail[action.ResourceKind] {
+			failed = append(failed, ActionError{Action: action, Err: errors.New("connection refused")})
+		}
+	}
+	if len(failed) > 0 {
+		return &ApplyError{Failed: failed}
+	}
+	return nil
+}
+
+func TestReconcile_FailedApply(t *testing.T) {
+	applier := &failingApplier{fail: map[string]bool{"Deployment": true}}
+	r := New(slog.Default(), applier, "test-ns", WithRetryBackoff(time.Millisecond, time.Second))
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		makeComponent("users-api", "1.0.0", "abc123", 2),
+	})
+	_, status, err := r.Reconcile(ctx, spec)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if status.Phase != model.PhaseFailed {
+		t.Fatalf("expected Failed, got %s", status.Phase)
+	}
+	cs := status.ComponentStatuses[0]
+	if cs.Phase != model.PhaseFailed || cs.ReadyReplicas != 0 || !strings.Contains(cs.Message, "connection refused") {
+		t.Fatalf("expected the component to report the failure, got %+v", cs)
+	}
+	if got := r.retries.NumRequeues("env-1"); got != 1 {
+		t.Fatalf("expected the environment queued for retry, got %d requeues", got)
+	}
+
+	// A retry applies only the failed Deployment, and backs off further
+	// while it keeps failing.
+	r.retry(ctx, "env-1")
+	retried := applier.actions[len(applier.actions)-1]
+	if len(retried) != 1 || retried[0].ResourceKind != "Deployment" {
+		t.Fatalf("expected only the Deployment retried, got %+v", retried)
+	}
+	if got := r.retries.NumRequeues("env-1"); got != 2 {
+		t.Fatalf("expected a second retry, got %d requeues", got)
+	}
+
+	// Polling the same spec again still carries the failed action.
+	actions, _, err := r.Reconcile(ctx, spec)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if len(actions) != 1 || actions[0].ResourceKind != "Deployment" {
+		t.Fatalf("expected the failed Deployment to be carried, got %+v", actions)
+	}
+
+	applier.fail = nil
+	r.retry(ctx, "env-1")
+	status, _ = r.GetStatus("env-1")
+	if status.Phase != model.PhaseRunning || status.ComponentStatuses[0].ReadyReplicas != 2 {
+		t.Fatalf("expected Running once the retry succeeds, got %+v", status)
+	}
+	if got := r.retries.NumRequeues("env-1"); got != 0 {
+		t.Fatalf("expected the environment forgotten, got %d requeues", got)
+	}
+	calls := len(applier.actions)
+	r.retry(ctx, "env-1")
+	if len(applier.actions) != calls {
+		t.Fatal("expected nothing to retry once applied")
+	}
+}
+
+func TestReconcile_FailedSecretKeepsValues(t *testing.T) {
+	applier := &failingApplier{fail: map[string]bool{"Secret": true}}
+	r := New(slog.Default(), applier, "test-ns", WithRetryBackoff(time.Millisecond, time.Second))
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{secretComponent()})
+	spec.Secrets = map[string]string{"api-key": "k3y", "users-token": "t0ken"}
+	if _, _, err := r.Reconcile(ctx, spec); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if r.GetAllSpecs()["env-1"].Secrets != nil {
+		t.Fatal("expected secret values not to be kept with the spec")
+	}
+
+	// The retry still has the values to write.
+	applier.fail = nil
+	r.retry(ctx, "env-1")
+	if got := applier.specs[len(applier.specs)-1].Secrets["api-key"]; got != "k3y" {
+		t.Fatalf("expected the secret values on retry, got %q", got)
+	}
+	if r.states["env-1"].secrets != nil {
+		t.Fatal("expected secret values dropped once written")
+	}
+}
+
+func TestRetry_WhileSleeping(t *testing.T) {
+	applier := &failingApplier{}
+	r := New(slog.Default(), applier, "test-ns", WithRetryBackoff(time.Millisecond, time.Second))
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		makeComponent("users-api", "1.0.0", "abc123", 2),
+	})
+	// The ConfigMap and Deployment fail to apply; sleeping scales the
+	// Deployment, and the ConfigMap's retry keeps it scaled to zero.
+	applier.fail = map[string]bool{"ConfigMap": true, "Deployment": true}
+	if _, _, err := r.Reconcile(ctx, spec); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	applier.fail = map[string]bool{"ConfigMap": true}
+	if _, _, err := r.Sleep(ctx, "env-1"); err != nil {
+		t.Fatalf("Sleep: %v", err)
+	}
+	applier.fail = nil
+	r.retry(ctx, "env-1")
+	retried := applier.actions[len(applier.actions)-1]
+	if len(retried) != 1 || retried[0].ResourceKind != "ConfigMap" {
+		t.Fatalf("expected only the ConfigMap retried, got %+v", retried)
+	}
+	for _, c := range applier.specs[len(applier.specs)-1].Components {
+		if c.Runtime.Replicas != 0 {
+			t.Fatalf("expected the retry to keep %s asleep, got %d replicas", c.PackageName, c.Runtime.Replicas)
+		}
+	}
+	if status, _ := r.GetStatus("env-1"); status.Phase != model.PhaseSleeping {
+		t.Fatalf("expected Sleeping, got %s", status.Phase)
+	}
+}
@@ -0,0 +1,81 @@
+package reconciler
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// ErrMissingSecret is returned when a component references a secret whose
+// value the reconcile request didn't supply.
+var ErrMissingSecret = errors.New("referenced secret not supplied")
+
+// withSecretsVersions returns spec with each component's SecretsVersion set
+// from the secret values it references. A spec without values, as polled
+// from the builder, keeps the versions already deployed, provided the
+// component references the same secrets.
+func withSecretsVersions(spec model.APIGraphSpec, existing *deployedState) (model.APIGraphSpec, error) {
+	spec.Components = slices.Clone(spec.Components)
+	for i := range spec.Components {
+		c := &spec.Components[i]
+		refs := c.SecretRefs()
+		c.SecretsVersion = ""
+		if len(refs) == 0 {
+			continue
+		}
+		if spec.Secrets == nil && existing != nil {
+			if ec, ok := existing.Components[c.PackageName]; ok && ec.Component.SecretsVersion != "" &&
+				slices.Equal(ec.Component.SecretRefs(), refs) {
+				c.SecretsVersion = ec.Component.SecretsVersion
+				continue
+			}
+		}
+		version, err := secretsVersion(refs, spec.Secrets)
+		if err != nil {
+			return spec, fmt.Errorf("component %s: %w", c.PackageName, err)
+		}
+		c.SecretsVersion = version
+	}
+	return spec, nil
+}
+
+// secretsVersion digests the values of refs, which must all be in values.
+func secretsVersion(refs []string, values map[string]string) (string, error) {
+	sum := sha256.New()
+	for _, ref := range refs {
+		value, ok := values[ref]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrMissingSecret, ref)
+		}
+		fmt.Fprintf(sum, "%d:%s%d:%s", len(ref), ref, len(value), value)
+	}
+	return fmt.Sprintf("%x", sum.Sum(nil))[:16], nil
+}
+
+// secretAction returns the action that brings a component's Secret from
+// prev's secrets to desired's, if any. Either may be the zero component.
+func secretAction(environmentID string, prev, desired model.DeployedComponent) (Action, bool) {
+	name := desired.PackageName
+	if name == "" {
+		name = prev.PackageName
+	}
+	action := Action{ResourceKind: "Secret", ResourceName: SecretName(environmentID, name)}
+	switch {
+	case prev.SecretsVersion == desired.SecretsVersion:
+		return Action{}, false
+	case prev.SecretsVersion == "":
+		action.Type = ActionCreate
+	case desired.SecretsVersion == "":
+		action.Type = ActionDelete
+		action.Details = "component no longer references secrets"
+		return action, true
+	default:
+		action.Type = ActionUpdate
+	}
+	// Details are logged, so they never include the values.
+	action.Details = fmt.Sprintf("secrets=%d", len(desired.SecretRefs()))
+	return action, true
+}
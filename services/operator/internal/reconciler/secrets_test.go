@@ -0,0 +1,135 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+func secretComponent() model.DeployedComponent {
+	c := makeComponent("users-api", "1.0.0", "abc123", 1)
+	c.Runtime.SecretEnv = map[string]string{"API_KEY": "api-key"}
+	c.Upstream = &model.UpstreamConfig{
+		URL:  "https://users.dev.example.com",
+		Auth: &model.UpstreamAuth{Type: model.UpstreamAuthBearer, TokenSecretRef: "users-token"},
+	}
+	return c
+}
+
+// findAction returns the action on the named resource, if any.
+func findAction(actions []Action, kind, name string) (Action, bool) {
+	for _, a := range actions {
+		if a.ResourceKind == kind && a.ResourceName == name {
+			return a, true
+		}
+	}
+	return Action{}, false
+}
+
+func TestReconcile_Secrets(t *testing.T) {
+	r := newTestReconciler()
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{secretComponent()})
+	spec.Secrets = map[string]string{"api-key": "k3y", "users-token": "t0ken", "unused": "x"}
+	actions, _, err := r.Reconcile(ctx, spec)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if actions[0].ResourceKind != "Secret" || actions[0].Type != ActionCreate || actions[0].ResourceName != "secret-env-1-users-api" {
+		t.Fatalf("expected the Secret to be created first, got %+v", actions[0])
+	}
+	stored := r.GetAllSpecs()["env-1"]
+	if stored.Secrets != nil {
+		t.Fatal("expected secret values not to be kept with the spec")
+	}
+	version := stored.Components[0].SecretsVersion
+	if version == "" {
+		t.Fatal("expected a secrets version on the component")
+	}
+
+	// Polled from the builder, without values: nothing changes.
+	spec.Secrets = nil
+	actions, _, err = r.Reconcile(ctx, spec)
+	if err != nil || len(actions) != 0 {
+		t.Fatalf("expected no actions without secret values, got %+v %v", actions, err)
+	}
+	if got := r.GetAllSpecs()["env-1"].Components[0].SecretsVersion; got != version {
+		t.Fatalf("expected the deployed secrets version to be kept, got %q", got)
+	}
+
+	// A changed value updates the Secret and rolls the Deployment.
+	spec.Secrets = map[string]string{"api-key": "n3w", "users-token": "t0ken"}
+	actions, _, err = r.Reconcile(ctx, spec)
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if a, ok := findAction(actions, "Secret", "secret-env-1-users-api"); !ok || a.Type != ActionUpdate {
+		t.Fatalf("expected the Secret to be updated, got %+v", actions)
+	}
+	if _, ok := findAction(actions, "Deployment", "deploy-env-1-users-api"); !ok {
+		t.Fatalf("expected the Deployment to be updated, got %+v", actions)
+	}
+	for _, a := range actions {
+		if a.Details == "n3w" || a.Details == "t0ken" {
+			t.Fatalf("action details leak a secret value: %+v", a)
+		}
+	}
+
+	// Dropping the references deletes the Secret after the Deployment.
+	plain := makeComponent("users-api", "1.0.0", "def456", 1)
+	actions, _, err = r.Reconcile(ctx, makeSpec("env-1", "build-2", []model.DeployedComponent{plain}))
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	last := actions[len(actions)-1]
+	if last.ResourceKind != "Secret" || last.Type != ActionDelete {
+		t.Fatalf("expected the Secret to be deleted last, got %+v", actions)
+	}
+}
+
+func TestReconcile_MissingSecret(t *testing.T) {
+	r := newTestReconciler()
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{secretComponent()})
+	spec.Secrets = map[string]string{"api-key": "k3y"}
+	if _, _, err := r.Reconcile(ctx, spec); !errors.Is(err, ErrMissingSecret) {
+		t.Fatalf("expected ErrMissingSecret, got %v", err)
+	}
+
+	// Without values and nothing deployed, the references can't be met.
+	spec.Secrets = nil
+	if _, _, err := r.Reconcile(ctx, spec); !errors.Is(err, ErrMissingSecret) {
+		t.Fatalf("expected ErrMissingSecret, got %v", err)
+	}
+	if _, ok := r.GetStatus("env-1"); ok {
+		t.Fatal("expected a failed reconcile not to be recorded")
+	}
+}
+
+func TestReconcile_DeletedComponentDeletesSecret(t *testing.T) {
+	r := newTestReconciler()
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		secretComponent(),
+		makeComponent("products-api", "1.0.0", "def456", 1),
+	})
+	spec.Secrets = map[string]string{"api-key": "k3y", "users-token": "t0ken"}
+	if _, _, err := r.Reconcile(ctx, spec); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	actions, _, err := r.Reconcile(ctx, makeSpec("env-1", "build-2", []model.DeployedComponent{
+		makeComponent("products-api", "1.0.0", "def456", 1),
+	}))
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if a, ok := findAction(actions, "Secret", "secret-env-1-users-api"); !ok || a.Type != ActionDelete {
+		t.Fatalf("expected the removed component's Secret to be deleted, got %+v", actions)
+	}
+}
@@ -0,0 +1,128 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// ErrNotFound is returned for an environment the operator has not reconciled.
+var ErrNotFound = errors.New("environment not found")
+
+// Sleep scales every component of an environment to zero replicas, keeping
+// its services, config maps and ingress so that Wake can bring it straight
+// back. Reconciling the environment again also wakes it.
+func (r *Reconciler) Sleep(ctx context.Context, environmentID string) ([]Action, model.APIGraphStatus, error) {
+	return r.scale(ctx, "Sleep", environmentID, true)
+}
+
+// Wake scales a sleeping environment's components back to their desired
+// replicas.
+func (r *Reconciler) Wake(ctx context.Context, environmentID string) ([]Action, model.APIGraphStatus, error) {
+	return r.scale(ctx, "Wake", environmentID, false)
+}
+
+func (r *Reconciler) scale(ctx context.Context, op, environmentID string, sleep bool) ([]Action, model.APIGraphStatus, error) {
+	ctx, span := tracer.Start(ctx, op,
+		trace.WithAttributes(attribute.String("environment_id", environmentID)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.states[environmentID]
+	if !ok {
+		return nil, model.APIGraphStatus{}, fmt.Errorf("%w: %s", ErrNotFound, environmentID)
+	}
+	if state.Deleting {
+		return nil, model.APIGraphStatus{}, fmt.Errorf("%w: %s", ErrDeleting, environmentID)
+	}
+	if state.Sleeping == sleep {
+		return []Action{}, state.Status, nil
+	}
+
+	// Scale by updating each Deployment to a copy of the spec whose
+	// replicas are zero, or to the spec itself on wake. The copy keeps the
+	// replicas to wake to, so that they survive an operator restart.
+	spec := state.Spec
+	if sleep {
+		spec = asleep(spec)
+	}
+	actions := make([]Action, 0, len(spec.Components))
+	for _, c := range spec.Components {
+		actions = append(actions, Action{
+			Type:         ActionUpdate,
+			ResourceKind: "Deployment",
+			ResourceName: DeploymentName(environmentID, c.PackageName),
+			Details:      fmt.Sprintf("image=artifact:%s replicas=%d", c.ArtifactHash, c.Runtime.Replicas),
+		})
+	}
+	r.logActions(ctx, environmentID, actions)
+
+	if r.applier != nil && len(actions) > 0 {
+		if err := r.applier.Apply(ctx, r.namespace, environmentID, actions, spec); err != nil {
+			span.RecordError(err)
+			return nil, model.APIGraphStatus{}, fmt.Errorf("apply: %w", err)
+		}
+	}
+
+	state.Sleeping = sleep
+	// Every Deployment was just scaled, which supersedes any failed
+	// Deployment action, and nothing observed before holds.
+	state.Failed = slices.DeleteFunc(state.Failed, func(f ActionError) bool {
+		return f.Action.ResourceKind == "Deployment"
+	})
+	for name, cs := range state.Components {
+		cs.Observed = nil
+		state.Components[name] = cs
+	}
+	if sleep {
+		state.Status = sleepingStatus(state.Spec, state.Status)
+	} else {
+		state.Status = r.buildStatus(state.Spec, state)
+	}
+	span.SetAttributes(attribute.String("status.phase", string(state.Status.Phase)))
+
+	r.logger.InfoContext(ctx, "environment scaled",
+		"environment_id", environmentID,
+		"phase", state.Status.Phase,
+	)
+	return actions, state.Status, nil
+}
+
+// asleep returns a copy of spec with every component scaled to zero.
+func asleep(spec model.APIGraphSpec) model.APIGraphSpec {
+	components := make([]model.DeployedComponent, len(spec.Components))
+	for i, c := range spec.Components {
+		c.AwakeReplicas = c.Runtime.Replicas
+		if c.AwakeReplicas == 0 {
+			// An unset replica count wakes to Kubernetes' default.
+			c.AwakeReplicas = 1
+		}
+		c.Runtime.Replicas = 0
+		components[i] = c
+	}
+	spec.Components = components
+	return spec
+}
+
+// sleepingStatus reports every component of spec as scaled to zero.
+func sleepingStatus(spec model.APIGraphSpec, current model.APIGraphStatus) model.APIGraphStatus {
+	statuses := make([]model.ComponentStatus, 0, len(spec.Components))
+	for _, c := range spec.Components {
+		statuses = append(statuses, model.ComponentStatus{
+			PackageName: c.PackageName,
+			Phase:       model.PhaseSleeping,
+		})
+	}
+	current.Phase = model.PhaseSleeping
+	current.ComponentStatuses = statuses
+	current.Message = "Scaled to zero"
+	return current
+}
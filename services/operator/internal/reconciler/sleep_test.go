@@ -0,0 +1,137 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// recordingApplier records the replicas of each spec it is asked to apply.
+type recordingApplier struct {
+	replicas [][]int32
+	err      error
+}
+
+func (a *recordingApplier) Apply(_ context.Context, _, _ string, _ []Action, spec model.APIGraphSpec) error {
+	var replicas []int32
+	for _, c := range spec.Components {
+		replicas = append(replicas, c.Runtime.Replicas)
+	}
+	a.replicas = append(a.replicas, replicas)
+	return a.err
+}
+
+func TestSleepAndWake(t *testing.T) {
+	applier := &recordingApplier{}
+	r := New(slog.Default(), applier, "test-ns")
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		makeComponent("users-api", "1.0.0", "abc123", 2),
+		makeComponent("products-api", "1.0.0", "def456", 3),
+	})
+	if _, _, err := r.Reconcile(ctx, spec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	actions, status, err := r.Sleep(ctx, "env-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(actions) != 2 || actions[0].Type != ActionUpdate || actions[0].ResourceKind != "Deployment" {
+		t.Fatalf("expected a Deployment update per component, got %+v", actions)
+	}
+	if got := applier.replicas[len(applier.replicas)-1]; got[0] != 0 || got[1] != 0 {
+		t.Fatalf("expected every component scaled to zero, got %v", got)
+	}
+	if status.Phase != model.PhaseSleeping || status.ComponentStatuses[0].ReadyReplicas != 0 {
+		t.Fatalf("expected a sleeping status, got %+v", status)
+	}
+	// The spec itself keeps the desired replicas.
+	if specs := r.GetAllSpecs(); specs["env-1"].Components[0].Runtime.Replicas != 2 {
+		t.Fatalf("expected the stored spec to keep its replicas, got %+v", specs["env-1"])
+	}
+
+	// Sleeping again does nothing.
+	if actions, _, err := r.Sleep(ctx, "env-1"); err != nil || len(actions) != 0 {
+		t.Fatalf("expected no actions sleeping twice, got %+v, %v", actions, err)
+	}
+
+	_, status, err = r.Wake(ctx, "env-1")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := applier.replicas[len(applier.replicas)-1]; got[0] != 2 || got[1] != 3 {
+		t.Fatalf("expected the desired replicas restored, got %v", got)
+	}
+	if status.Phase != model.PhaseRunning {
+		t.Fatalf("expected Running, got %s", status.Phase)
+	}
+}
+
+func TestAsleep_UnsetReplicas(t *testing.T) {
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		makeComponent("users-api", "1.0.0", "abc123", 0),
+	})
+	// A component without a replica count still sleeps, and wakes to one.
+	c := asleep(spec).Components[0]
+	if c.Runtime.Replicas != 0 || c.AwakeReplicas != 1 {
+		t.Fatalf("expected zero replicas waking to one, got %d and %d", c.Runtime.Replicas, c.AwakeReplicas)
+	}
+}
+
+func TestReconcile_WakesSleepingEnvironment(t *testing.T) {
+	r := newTestReconciler()
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		makeComponent("users-api", "1.0.0", "abc123", 2),
+	})
+	if _, _, err := r.Reconcile(ctx, spec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, _, err := r.Sleep(ctx, "env-1"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// Reconciling the same spec scales the components back up.
+	actions, status, err := r.Reconcile(ctx, spec)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(actions) == 0 || actions[0].ResourceKind != "Deployment" || actions[0].Type != ActionUpdate {
+		t.Fatalf("expected a Deployment update, got %+v", actions)
+	}
+	if status.Phase != model.PhaseRunning {
+		t.Fatalf("expected Running, got %s", status.Phase)
+	}
+}
+
+func TestSleep_Errors(t *testing.T) {
+	applier := &recordingApplier{}
+	r := New(slog.Default(), applier, "test-ns")
+	ctx := context.Background()
+
+	if _, _, err := r.Sleep(ctx, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		makeComponent("users-api", "1.0.0", "abc123", 2),
+	})
+	if _, _, err := r.Reconcile(ctx, spec); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// A failed scale-down leaves the environment running.
+	applier.err = errors.New("api server unavailable")
+	if _, _, err := r.Sleep(ctx, "env-1"); err == nil {
+		t.Fatal("expected an error")
+	}
+	if status, _ := r.GetStatus("env-1"); status.Phase != model.PhaseRunning {
+		t.Fatalf("expected Running, got %s", status.Phase)
+	}
+}
@@ -0,0 +1,101 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// ErrDeleting is returned for an environment that is being torn down.
+var ErrDeleting = errors.New("environment is being deleted")
+
+// Teardowner is implemented by Appliers that can delete every resource of an
+// environment.
+type Teardowner interface {
+	// DeleteEnvironment deletes the resources labelled with environmentID in
+	// namespace and returns those still present, as "Kind/name", such as
+	// ones held by finalizers or still terminating.
+	DeleteEnvironment(ctx context.Context, namespace, environmentID string) ([]string, error)
+}
+
+// Teardown deletes every resource of an environment, if the Applier is a
+// Teardowner, and stops serving its routes. The environment reports the
+// Deleting phase and the resources still to be deleted until they are all
+// gone, checked with backoff, and is then forgotten. The returned bool is
+// true once it is. Failures to delete are reported in the status and
+// retried. Tearing down an environment that isn't tracked still deletes its
+// resources, so that Teardown is safe to repeat.
+func (r *Reconciler) Teardown(ctx context.Context, environmentID string) (model.APIGraphStatus, bool) {
+	ctx, span := tracer.Start(ctx, "Teardown",
+		trace.WithAttributes(attribute.String("environment_id", environmentID)))
+	defer span.End()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	state, ok := r.states[environmentID]
+	if !ok {
+		state = r.buildState(model.APIGraphSpec{EnvironmentID: environmentID})
+		r.states[environmentID] = state
+	}
+	if !state.Deleting {
+		r.logger.InfoContext(ctx, "tearing down environment", "environment_id", environmentID)
+	}
+	state.Deleting = true
+	state.Failed, state.Drift, state.secrets = nil, nil, nil
+
+	done := r.teardown(ctx, state)
+	span.SetAttributes(attribute.Int("remaining", len(state.Status.PendingDeletion)))
+	return state.Status, done
+}
+
+// teardown deletes the resources of an environment being torn down,
+// forgetting it once they are gone and checking again with backoff until
+// then. It reports whether they are gone.
+func (r *Reconciler) teardown(ctx context.Context, state *deployedState) bool {
+	environmentID := state.Spec.EnvironmentID
+	var remaining []string
+	if t, ok := r.applier.(Teardowner); ok {
+		var err error
+		remaining, err = t.DeleteEnvironment(ctx, r.namespace, environmentID)
+		if err != nil {
+			r.logger.ErrorContext(ctx, "failed to delete environment resources",
+				"environment_id", environmentID,
+				"error", err,
+			)
+			state.Status = deletingStatus(state.Status, state.Status.PendingDeletion,
+				fmt.Sprintf("teardown failed: %v", err))
+			r.retries.AddRateLimited(environmentID)
+			return false
+		}
+	}
+
+	if len(remaining) == 0 {
+		delete(r.states, environmentID)
+		r.retries.Forget(environmentID)
+		state.Status = deletingStatus(state.Status, nil, "All resources deleted")
+		r.logger.InfoContext(ctx, "environment torn down", "environment_id", environmentID)
+		return true
+	}
+	state.Status = deletingStatus(state.Status, remaining,
+		fmt.Sprintf("Waiting for %d resources to be deleted", len(remaining)))
+	r.retries.AddRateLimited(environmentID)
+	return false
+}
+
+// deletingStatus reports an environment as being deleted, with remaining
+// resources still to go.
+func deletingStatus(current model.APIGraphStatus, remaining []string, message string) model.APIGraphStatus {
+	return model.APIGraphStatus{
+		Phase:             model.PhaseDeleting,
+		ComponentStatuses: []model.ComponentStatus{},
+		PendingDeletion:   remaining,
+		Message:           message,
+		LastReconciled:    current.LastReconciled,
+	}
+}
@@ -0,0 +1,109 @@
+package reconciler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+	"time"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// teardownApplier returns each of its results from successive
+// DeleteEnvironment calls, then nothing remaining.
+type teardownApplier struct {
+	recordingApplier
+	results []teardownResult
+	deleted []string
+}
+
+type teardownResult struct {
+	remaining []string
+	err       error
+}
+
+func (a *teardownApplier) DeleteEnvironment(_ context.Context, _, environmentID string) ([]string, error) {
+	a.deleted = append(a.deleted, environmentID)
+	if len(a.results) == 0 {
+		return nil, nil
+	}
+	res := a.results[0]
+	a.results = a.results[1:]
+	return res.remaining, res.err
+}
+
+func TestTeardown(t *testing.T) {
+	applier := &teardownApplier{results: []teardownResult{
+		{remaining: []string{"Deployment/deploy-users-api", "Pod/users-api-1"}},
+		{err: errors.New("connection refused")},
+	}}
+	r := New(slog.Default(), applier, "test-ns", WithRetryBackoff(time.Millisecond, time.Second))
+	ctx := context.Background()
+
+	spec := makeSpec("env-1", "build-1", []model.DeployedComponent{
+		makeComponent("users-api", "1.0.0", "abc123", 2),
+	})
+	if _, _, err := r.Reconcile(ctx, spec); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+
+	status, done := r.Teardown(ctx, "env-1")
+	if done || status.Phase != model.PhaseDeleting || len(status.PendingDeletion) != 2 {
+		t.Fatalf("expected Deleting with two resources remaining, got %+v", status)
+	}
+	if status.Message != "Waiting for 2 resources to be deleted" {
+		t.Fatalf("unexpected message %q", status.Message)
+	}
+	if got, ok := r.GetStatus("env-1"); !ok || got.Phase != model.PhaseDeleting {
+		t.Fatalf("expected the progress reported, got %+v", got)
+	}
+	if _, ok := r.GetAllSpecs()["env-1"]; ok {
+		t.Fatal("expected the environment's routes to be removed")
+	}
+	if _, _, err := r.Reconcile(ctx, spec); !errors.Is(err, ErrDeleting) {
+		t.Fatalf("expected ErrDeleting from Reconcile, got %v", err)
+	}
+	if _, _, err := r.Sleep(ctx, "env-1"); !errors.Is(err, ErrDeleting) {
+		t.Fatalf("expected ErrDeleting from Sleep, got %v", err)
+	}
+	if got := r.retries.NumRequeues("env-1"); got != 1 {
+		t.Fatalf("expected the teardown queued to be checked again, got %d requeues", got)
+	}
+
+	// A failure is reported and retried.
+	r.retry(ctx, "env-1")
+	status, _ = r.GetStatus("env-1")
+	if status.Message != "teardown failed: connection refused" || len(status.PendingDeletion) != 2 {
+		t.Fatalf("expected the failure reported with the last known resources, got %+v", status)
+	}
+
+	r.retry(ctx, "env-1")
+	if _, ok := r.GetStatus("env-1"); ok {
+		t.Fatal("expected the environment forgotten once its resources are gone")
+	}
+	if got := r.retries.NumRequeues("env-1"); got != 0 {
+		t.Fatalf("expected the environment forgotten by the queue, got %d requeues", got)
+	}
+
+	// It can be deployed again afterwards.
+	if _, _, err := r.Reconcile(ctx, spec); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+}
+
+func TestTeardown_Untracked(t *testing.T) {
+	applier := &teardownApplier{}
+	r := New(slog.Default(), applier, "test-ns")
+
+	status, done := r.Teardown(context.Background(), "env-1")
+	if !done || status.Phase != model.PhaseDeleting || status.Message != "All resources deleted" {
+		t.Fatalf("expected the teardown to complete, got %+v", status)
+	}
+	if len(applier.deleted) != 1 || applier.deleted[0] != "env-1" {
+		t.Fatalf("expected the environment's resources deleted anyway, got %v", applier.deleted)
+	}
+	if _, ok := r.GetStatus("env-1"); ok {
+		t.Fatal("expected nothing tracked")
+	}
+}
@@ -0,0 +1,107 @@
+package watcher
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/applier"
+)
+
+// driftDelay is how long a drift check waits after a change, so that a
+// burst of changes to an environment's resources is checked once.
+const driftDelay = 2 * time.Second
+
+// DriftChecker is implemented by Sinks that check an environment's
+// resources for drift from its spec. The Watcher calls it when a managed
+// Deployment, Service or ConfigMap is edited or deleted.
+type DriftChecker interface {
+	CheckDrift(ctx context.Context, environmentID string) error
+}
+
+// watchDrift queues a drift check of an environment whenever one of its
+// Deployments, Services or ConfigMaps is edited or deleted.
+func (w *Watcher) watchDrift(managed informers.SharedInformerFactory) ([]cache.InformerSynced, error) {
+	handler := cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, obj any) {
+			if edited(old, obj) {
+				w.enqueueDrift(obj)
+			}
+		},
+		DeleteFunc: w.enqueueDrift,
+	}
+	var synced []cache.InformerSynced
+	for _, informer := range []cache.SharedIndexInformer{
+		managed.Apps().V1().Deployments().Informer(),
+		managed.Core().V1().Services().Informer(),
+		managed.Core().V1().ConfigMaps().Informer(),
+	} {
+		if _, err := informer.AddEventHandler(handler); err != nil {
+			return nil, err
+		}
+		synced = append(synced, informer.HasSynced)
+	}
+	return synced, nil
+}
+
+// checkDrift runs queued drift checks until the queue shuts down.
+func (w *Watcher) checkDrift(ctx context.Context, checker DriftChecker) {
+	for {
+		environmentID, shutdown := w.drift.Get()
+		if shutdown {
+			return
+		}
+		if err := checker.CheckDrift(ctx, environmentID); err != nil {
+			w.logger.ErrorContext(ctx, "drift check failed",
+				"environment_id", environmentID,
+				"error", err,
+			)
+		}
+		w.drift.Done(environmentID)
+	}
+}
+
+// enqueueDrift queues a drift check of the environment obj belongs to.
+func (w *Watcher) enqueueDrift(obj any) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	if environmentID := m.GetLabels()[applier.EnvironmentLabel]; environmentID != "" {
+		w.drift.AddAfter(environmentID, driftDelay)
+	}
+}
+
+// edited reports whether an update changed more than a resource's status.
+// Resyncs, which change nothing, and a Deployment's status updates as its
+// rollout progresses aren't edits.
+func edited(old, obj any) bool {
+	o, err := meta.Accessor(old)
+	if err != nil {
+		return false
+	}
+	n, err := meta.Accessor(obj)
+	if err != nil {
+		return false
+	}
+	if rv := n.GetResourceVersion(); rv != "" && rv == o.GetResourceVersion() {
+		return false
+	}
+	od, ok := old.(*appsv1.Deployment)
+	nd, ok2 := obj.(*appsv1.Deployment)
+	if !ok || !ok2 {
+		return true
+	}
+	return !equality.Semantic.DeepEqual(od.Spec, nd.Spec) ||
+		!maps.Equal(o.GetLabels(), n.GetLabels()) ||
+		!maps.Equal(o.GetAnnotations(), n.GetAnnotations())
+}
@@ -0,0 +1,130 @@
+package watcher
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// failureReasons are the container waiting reasons that mean a pod won't
+// become ready without a change to the component or its cluster.
+var failureReasons = sets.New(
+	"CrashLoopBackOff",
+	"ImagePullBackOff",
+	"ErrImagePull",
+	"InvalidImageName",
+	"CreateContainerConfigError",
+	"CreateContainerError",
+	"RunContainerError",
+)
+
+// componentStatus derives a component's status from its Deployment, its
+// pods and the warning events about them:
+//
+//   - Failed or Degraded, depending on whether any replica is ready, if a
+//     container is stuck in one of failureReasons, the rollout has exceeded
+//     its progress deadline or the Deployment can't create its pods;
+//   - Running once the Deployment has fully rolled out and every replica is
+//     ready;
+//   - Deploying otherwise, with the latest warning event, if any, as the
+//     message.
+func componentStatus(name string, d *appsv1.Deployment, pods []*corev1.Pod, events []*corev1.Event) model.ComponentStatus {
+	status := model.ComponentStatus{PackageName: name}
+	if d == nil {
+		status.Phase = model.PhaseFailed
+		status.Message = "Deployment not found"
+		return status
+	}
+	status.DesiredReplicas = 1
+	if d.Spec.Replicas != nil {
+		status.DesiredReplicas = *d.Spec.Replicas
+	}
+	status.ReadyReplicas = d.Status.ReadyReplicas
+
+	if message := failure(d, pods); message != "" {
+		status.Phase = model.PhaseFailed
+		if status.ReadyReplicas > 0 {
+			status.Phase = model.PhaseDegraded
+		}
+		status.Message = message
+		return status
+	}
+
+	if rolledOut(d, status.DesiredReplicas) && status.ReadyReplicas >= status.DesiredReplicas {
+		status.Phase = model.PhaseRunning
+		return status
+	}
+
+	status.Phase = model.PhaseDeploying
+	status.Message = fmt.Sprintf("%d of %d replicas ready", status.ReadyReplicas, status.DesiredReplicas)
+	if e := latest(events); e != nil {
+		status.Message = fmt.Sprintf("%s: %s", e.Reason, e.Message)
+	}
+	return status
+}
+
+// failure describes why a Deployment's pods aren't coming up, or returns
+// "" if nothing says they won't.
+func failure(d *appsv1.Deployment, pods []*corev1.Pod) string {
+	pods = slices.SortedFunc(slices.Values(pods), func(a, b *corev1.Pod) int { return cmp.Compare(a.Name, b.Name) })
+	for _, pod := range pods {
+		if pod.DeletionTimestamp != nil {
+			continue
+		}
+		for _, c := range slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses) {
+			if waiting := c.State.Waiting; waiting != nil && failureReasons.Has(waiting.Reason) {
+				if waiting.Message == "" {
+					return fmt.Sprintf("%s in pod %s", waiting.Reason, pod.Name)
+				}
+				return fmt.Sprintf("%s in pod %s: %s", waiting.Reason, pod.Name, waiting.Message)
+			}
+		}
+	}
+
+	for _, c := range d.Status.Conditions {
+		switch {
+		case c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse && c.Reason == "ProgressDeadlineExceeded":
+			return fmt.Sprintf("rollout stalled: %s", c.Message)
+		case c.Type == appsv1.DeploymentReplicaFailure && c.Status == corev1.ConditionTrue:
+			return fmt.Sprintf("%s: %s", c.Reason, c.Message)
+		}
+	}
+	return ""
+}
+
+// rolledOut reports whether a Deployment's controller has seen its latest
+// spec and replaced every old pod.
+func rolledOut(d *appsv1.Deployment, desired int32) bool {
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == desired &&
+		d.Status.Replicas == desired
+}
+
+// latest returns the most recent of events, or nil if there are none.
+func latest(events []*corev1.Event) *corev1.Event {
+	var newest *corev1.Event
+	for _, e := range events {
+		if newest == nil || eventTime(e).After(eventTime(newest)) {
+			newest = e
+		}
+	}
+	return newest
+}
+
+func eventTime(e *corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
@@ -0,0 +1,97 @@
+package watcher
+
+import (
+	"testing"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// deployment returns a Deployment wanting replicas, of which ready are
+// ready and updated run its latest spec.
+func deployment(replicas, ready, updated int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Name: "deploy-users-api", Generation: 2},
+		Spec:       appsv1.DeploymentSpec{Replicas: &replicas},
+		Status: appsv1.DeploymentStatus{
+			ObservedGeneration: 2,
+			Replicas:           replicas,
+			UpdatedReplicas:    updated,
+			ReadyReplicas:      ready,
+		},
+	}
+}
+
+func waitingPod(name, reason, message string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "users-api",
+			State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason, Message: message}},
+		}}},
+	}
+}
+
+func TestComponentStatus(t *testing.T) {
+	stalled := deployment(2, 0, 2)
+	stalled.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:    appsv1.DeploymentProgressing,
+		Status:  corev1.ConditionFalse,
+		Reason:  "ProgressDeadlineExceeded",
+		Message: `ReplicaSet "users-api-abc" has timed out progressing.`,
+	}}
+	quota := deployment(2, 1, 2)
+	quota.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type:    appsv1.DeploymentReplicaFailure,
+		Status:  corev1.ConditionTrue,
+		Reason:  "FailedCreate",
+		Message: "exceeded quota",
+	}}
+	unobserved := deployment(2, 2, 2)
+	unobserved.Generation = 3
+
+	older := &corev1.Event{Reason: "BackOff", Message: "older", LastTimestamp: metav1.NewTime(time.Now().Add(-time.Minute))}
+	newer := &corev1.Event{Reason: "FailedScheduling", Message: "0/3 nodes are available", LastTimestamp: metav1.NewTime(time.Now())}
+
+	tests := []struct {
+		name    string
+		d       *appsv1.Deployment
+		pods    []*corev1.Pod
+		events  []*corev1.Event
+		phase   model.Phase
+		ready   int32
+		message string
+	}{
+		{"missing deployment", nil, nil, nil, model.PhaseFailed, 0, "Deployment not found"},
+		{"rolled out", deployment(2, 2, 2), nil, nil, model.PhaseRunning, 2, ""},
+		{"scaled to zero", deployment(0, 0, 0), nil, nil, model.PhaseRunning, 0, ""},
+		{"starting", deployment(2, 1, 2), nil, nil, model.PhaseDeploying, 1, "1 of 2 replicas ready"},
+		{"rolling out", deployment(2, 2, 1), nil, nil, model.PhaseDeploying, 2, "2 of 2 replicas ready"},
+		{"spec not yet observed", unobserved, nil, nil, model.PhaseDeploying, 2, "2 of 2 replicas ready"},
+		{"warning event", deployment(2, 0, 2), nil, []*corev1.Event{older, newer}, model.PhaseDeploying, 0, "FailedScheduling: 0/3 nodes are available"},
+		{
+			"crash looping", deployment(2, 0, 2),
+			[]*corev1.Pod{waitingPod("users-api-1", "CrashLoopBackOff", "back-off 10s restarting failed container")}, nil,
+			model.PhaseFailed, 0, "CrashLoopBackOff in pod users-api-1: back-off 10s restarting failed container",
+		},
+		{
+			"some replicas crash looping", deployment(2, 1, 2),
+			[]*corev1.Pod{waitingPod("users-api-1", "ContainerCreating", ""), waitingPod("users-api-2", "ImagePullBackOff", "")}, nil,
+			model.PhaseDegraded, 1, "ImagePullBackOff in pod users-api-2",
+		},
+		{"progress deadline exceeded", stalled, nil, nil, model.PhaseFailed, 0, `rollout stalled: ReplicaSet "users-api-abc" has timed out progressing.`},
+		{"replica failure", quota, nil, nil, model.PhaseDegraded, 1, "FailedCreate: exceeded quota"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := componentStatus("users-api", tt.d, tt.pods, tt.events)
+			if got.PackageName != "users-api" || got.Phase != tt.phase || got.ReadyReplicas != tt.ready || got.Message != tt.message {
+				t.Fatalf("expected %s with %d ready and message %q, got %+v", tt.phase, tt.ready, tt.message, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+// Package watcher observes the Deployments and Pods the operator manages and
+// reports each component's actual readiness, so that an environment's status
+// reflects what is running rather than what was asked for.
+package watcher
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/applier"
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// resyncPeriod is how often every component is observed again even if
+// nothing about it changed.
+const resyncPeriod = 5 * time.Minute
+
+// podEventIndex indexes events by the name of the pod they are about.
+const podEventIndex = "pod"
+
+// Sink receives the observed status of components.
+type Sink interface {
+	ObserveComponent(environmentID string, status model.ComponentStatus)
+}
+
+// componentKey identifies one component of one environment.
+type componentKey struct {
+	environmentID string
+	component     string
+}
+
+// Watcher watches the Deployments, Pods and warning Events in a namespace
+// and reports the status of each component they belong to.
+type Watcher struct {
+	client    kubernetes.Interface
+	namespace string
+	sink      Sink
+	logger    *slog.Logger
+	queue     workqueue.TypedInterface[componentKey]
+	// drift queues environments to check for drift, if sink is a
+	// DriftChecker.
+	drift workqueue.TypedDelayingInterface[string]
+
+	deployments appslisters.DeploymentLister
+	pods        corelisters.PodLister
+	events      cache.Indexer
+}
+
+// New creates a Watcher that reports to sink.
+func New(client kubernetes.Interface, namespace string, sink Sink, logger *slog.Logger) *Watcher {
+	return &Watcher{
+		client:    client,
+		namespace: namespace,
+		sink:      sink,
+		logger:    logger.With("component", "watcher"),
+		queue:     workqueue.NewTyped[componentKey](),
+		drift:     workqueue.NewTypedDelayingQueueWithConfig(workqueue.TypedDelayingQueueConfig[string]{Name: "drift-checks"}),
+	}
+}
+
+// Run watches until ctx is done. Every component is reported once the
+// watches have synced, and again whenever its Deployment, one of its Pods
+// or a warning Event about one of its Pods changes. If the sink is a
+// DriftChecker, environments are also checked for drift whenever one of
+// their Deployments, Services or ConfigMaps is edited or deleted.
+func (w *Watcher) Run(ctx context.Context) error {
+	managed := informers.NewSharedInformerFactoryWithOptions(w.client, resyncPeriod,
+		informers.WithNamespace(w.namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) { o.LabelSelector = applier.ManagedSelector }))
+	// Events aren't labelled, so they are watched separately.
+	warnings := informers.NewSharedInformerFactoryWithOptions(w.client, resyncPeriod,
+		informers.WithNamespace(w.namespace),
+		informers.WithTweakListOptions(func(o *metav1.ListOptions) { o.FieldSelector = "type=" + corev1.EventTypeWarning }))
+
+	deployments := managed.Apps().V1().Deployments()
+	pods := managed.Core().V1().Pods()
+	events := warnings.Core().V1().Events()
+	if err := events.Informer().AddIndexers(cache.Indexers{podEventIndex: indexByPod}); err != nil {
+		return err
+	}
+	w.deployments = deployments.Lister()
+	w.pods = pods.Lister()
+	w.events = events.Informer().GetIndexer()
+
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.enqueue,
+		UpdateFunc: func(_, obj any) { w.enqueue(obj) },
+		DeleteFunc: w.enqueue,
+	}
+	if _, err := deployments.Informer().AddEventHandler(handler); err != nil {
+		return err
+	}
+	if _, err := pods.Informer().AddEventHandler(handler); err != nil {
+		return err
+	}
+	if _, err := events.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.enqueueEvent,
+		UpdateFunc: func(_, obj any) { w.enqueueEvent(obj) },
+	}); err != nil {
+		return err
+	}
+	synced := []cache.InformerSynced{deployments.Informer().HasSynced, pods.Informer().HasSynced, events.Informer().HasSynced}
+	checker, checkDrift := w.sink.(DriftChecker)
+	if checkDrift {
+		driftSynced, err := w.watchDrift(managed)
+		if err != nil {
+			return err
+		}
+		synced = append(synced, driftSynced...)
+	}
+
+	managed.Start(ctx.Done())
+	warnings.Start(ctx.Done())
+	defer managed.Shutdown()
+	defer warnings.Shutdown()
+
+	w.logger.InfoContext(ctx, "waiting for watches to sync", "namespace", w.namespace)
+	if !cache.WaitForCacheSync(ctx.Done(), synced...) {
+		return errors.New("watches did not sync")
+	}
+	w.logger.InfoContext(ctx, "watching component readiness", "namespace", w.namespace)
+
+	go func() {
+		<-ctx.Done()
+		w.queue.ShutDown()
+		w.drift.ShutDown()
+	}()
+	if checkDrift {
+		go w.checkDrift(ctx, checker)
+	}
+	for w.processNext() {
+	}
+	return nil
+}
+
+// processNext reports the status of the next queued component, returning
+// false once the queue has shut down.
+func (w *Watcher) processNext() bool {
+	key, shutdown := w.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer w.queue.Done(key)
+
+	status, err := w.observe(key)
+	if err != nil {
+		w.logger.Error("failed to observe component",
+			"environment_id", key.environmentID,
+			"component", key.component,
+			"error", err,
+		)
+		return true
+	}
+	w.sink.ObserveComponent(key.environmentID, status)
+	return true
+}
+
+// observe derives a component's status from the watched objects.
+func (w *Watcher) observe(key componentKey) (model.ComponentStatus, error) {
+	selector := labels.SelectorFromSet(labels.Set{
+		applier.EnvironmentLabel: key.environmentID,
+		applier.ComponentLabel:   key.component,
+	})
+	deployments, err := w.deployments.Deployments(w.namespace).List(selector)
+	if err != nil {
+		return model.ComponentStatus{}, err
+	}
+	var deployment *appsv1.Deployment
+	if len(deployments) > 0 {
+		deployment = deployments[0]
+	}
+	pods, err := w.pods.Pods(w.namespace).List(selector)
+	if err != nil {
+		return model.ComponentStatus{}, err
+	}
+
+	var events []*corev1.Event
+	for _, pod := range pods {
+		objs, err := w.events.ByIndex(podEventIndex, pod.Name)
+		if err != nil {
+			return model.ComponentStatus{}, err
+		}
+		for _, obj := range objs {
+			if e, ok := obj.(*corev1.Event); ok && e.InvolvedObject.UID == pod.UID {
+				events = append(events, e)
+			}
+		}
+	}
+	return componentStatus(key.component, deployment, pods, events), nil
+}
+
+// enqueue queues the component a Deployment or Pod belongs to.
+func (w *Watcher) enqueue(obj any) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return
+	}
+	w.enqueueLabels(m.GetLabels())
+}
+
+// enqueueEvent queues the component a warning Event's pod belongs to.
+func (w *Watcher) enqueueEvent(obj any) {
+	e, ok := obj.(*corev1.Event)
+	if !ok || e.Type != corev1.EventTypeWarning || e.InvolvedObject.Kind != "Pod" {
+		return
+	}
+	pod, err := w.pods.Pods(w.namespace).Get(e.InvolvedObject.Name)
+	if err != nil {
+		return
+	}
+	w.enqueueLabels(pod.Labels)
+}
+
+func (w *Watcher) enqueueLabels(lbls map[string]string) {
+	key := componentKey{environmentID: lbls[applier.EnvironmentLabel], component: lbls[applier.ComponentLabel]}
+	if key.environmentID == "" || key.component == "" {
+		return
+	}
+	w.queue.Add(key)
+}
+
+// indexByPod indexes an event by the pod it is about, if any.
+func indexByPod(obj any) ([]string, error) {
+	e, ok := obj.(*corev1.Event)
+	if !ok || e.InvolvedObject.Kind != "Pod" {
+		return nil, nil
+	}
+	return []string{e.InvolvedObject.Name}, nil
+}
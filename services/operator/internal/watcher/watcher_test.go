@@ -0,0 +1,217 @@
+package watcher
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/lennyburdette/turbo-engine/services/operator/internal/model"
+)
+
+// recordingSink records the latest status observed for each component.
+type recordingSink struct {
+	mu       sync.Mutex
+	statuses map[string]model.ComponentStatus
+}
+
+func (s *recordingSink) ObserveComponent(environmentID string, status model.ComponentStatus) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.statuses[environmentID+"/"+status.PackageName] = status
+}
+
+// waitFor waits for the latest status of key to satisfy ok.
+func (s *recordingSink) waitFor(t *testing.T, key string, ok func(model.ComponentStatus) bool) model.ComponentStatus {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.mu.Lock()
+		status, found := s.statuses[key]
+		s.mu.Unlock()
+		if found && ok(status) {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s, last saw %+v", key, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func managedLabels(component string) map[string]string {
+	return map[string]string{
+		"app.kubernetes.io/managed-by": "turbo-engine-operator",
+		"turboengine.io/environment":   "env-1",
+		"turboengine.io/component":     component,
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := deployment(1, 0, 1)
+	d.Namespace = "test-ns"
+	d.Labels = managedLabels("users-api")
+	pod := waitingPod("users-api-1", "ContainerCreating", "")
+	pod.Namespace = "test-ns"
+	pod.UID = "pod-1"
+	pod.Labels = managedLabels("users-api")
+	unmanaged := deployment(1, 0, 1)
+	unmanaged.Name, unmanaged.Namespace = "other", "test-ns"
+	client := fake.NewSimpleClientset(d, pod, unmanaged)
+
+	sink := &recordingSink{statuses: make(map[string]model.ComponentStatus)}
+	w := New(client, "test-ns", sink, slog.Default())
+	done := make(chan error, 1)
+	go func() { done <- w.Run(ctx) }()
+
+	sink.waitFor(t, "env-1/users-api", func(s model.ComponentStatus) bool { return s.Phase == model.PhaseDeploying })
+
+	// A warning event about the pod becomes the message.
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "users-api-1.1", Namespace: "test-ns"},
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "users-api-1", UID: "pod-1"},
+		Type:           corev1.EventTypeWarning,
+		Reason:         "FailedScheduling",
+		Message:        "0/3 nodes are available",
+		LastTimestamp:  metav1.Now(),
+	}
+	if _, err := client.CoreV1().Events("test-ns").Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("create event: %v", err)
+	}
+	sink.waitFor(t, "env-1/users-api", func(s model.ComponentStatus) bool {
+		return s.Message == "FailedScheduling: 0/3 nodes are available"
+	})
+
+	// The container crash loops.
+	pod.Status.ContainerStatuses[0].State.Waiting.Reason = "CrashLoopBackOff"
+	if _, err := client.CoreV1().Pods("test-ns").UpdateStatus(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update pod: %v", err)
+	}
+	sink.waitFor(t, "env-1/users-api", func(s model.ComponentStatus) bool { return s.Phase == model.PhaseFailed })
+
+	// It recovers and the Deployment becomes ready.
+	pod.Status.ContainerStatuses[0].State = corev1.ContainerState{Running: &corev1.ContainerStateRunning{}}
+	if _, err := client.CoreV1().Pods("test-ns").UpdateStatus(ctx, pod, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update pod: %v", err)
+	}
+	d.Status.ReadyReplicas = 1
+	if _, err := client.AppsV1().Deployments("test-ns").UpdateStatus(ctx, d, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update deployment: %v", err)
+	}
+	status := sink.waitFor(t, "env-1/users-api", func(s model.ComponentStatus) bool { return s.Phase == model.PhaseRunning })
+	if status.ReadyReplicas != 1 || status.DesiredReplicas != 1 {
+		t.Fatalf("expected 1 of 1 ready, got %+v", status)
+	}
+
+	// Deleting the Deployment is reported too.
+	if err := client.AppsV1().Deployments("test-ns").Delete(ctx, d.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete deployment: %v", err)
+	}
+	sink.waitFor(t, "env-1/users-api", func(s model.ComponentStatus) bool { return s.Message == "Deployment not found" })
+
+	sink.mu.Lock()
+	if len(sink.statuses) != 1 {
+		t.Errorf("expected only the managed component observed, got %v", sink.statuses)
+	}
+	sink.mu.Unlock()
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("run: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("watcher did not stop")
+	}
+}
+
+// driftSink records the environments checked for drift.
+type driftSink struct {
+	recordingSink
+	checked chan string
+}
+
+func (s *driftSink) CheckDrift(_ context.Context, environmentID string) error {
+	s.checked <- environmentID
+	return nil
+}
+
+func TestWatcher_Drift(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	d := deployment(1, 1, 1)
+	d.Namespace = "test-ns"
+	d.Labels = managedLabels("users-api")
+	d.Spec.Template.Spec.Containers = []corev1.Container{{Name: "users-api", Image: "users:1"}}
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "cm-users-api", Namespace: "test-ns", Labels: managedLabels("users-api")}}
+	client := fake.NewSimpleClientset(d, cm)
+
+	sink := &driftSink{
+		recordingSink: recordingSink{statuses: make(map[string]model.ComponentStatus)},
+		checked:       make(chan string, 10),
+	}
+	go func() { _ = New(client, "test-ns", sink, slog.Default()).Run(ctx) }()
+	sink.waitFor(t, "env-1/users-api", func(s model.ComponentStatus) bool { return s.Phase == model.PhaseRunning })
+
+	waitChecked := func() {
+		t.Helper()
+		select {
+		case env := <-sink.checked:
+			if env != "env-1" {
+				t.Fatalf("expected env-1 checked, got %s", env)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for a drift check")
+		}
+	}
+
+	d.Spec.Template.Spec.Containers[0].Image = "users:debug"
+	if _, err := client.AppsV1().Deployments("test-ns").Update(ctx, d, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("update deployment: %v", err)
+	}
+	waitChecked()
+
+	if err := client.CoreV1().ConfigMaps("test-ns").Delete(ctx, cm.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("delete configmap: %v", err)
+	}
+	waitChecked()
+}
+
+func TestEdited(t *testing.T) {
+	old := deployment(2, 1, 2)
+	old.ResourceVersion = "1"
+
+	resync := old.DeepCopy()
+	if edited(old, resync) {
+		t.Error("expected a resync not to be an edit")
+	}
+	rollout := old.DeepCopy()
+	rollout.ResourceVersion = "2"
+	rollout.Status.ReadyReplicas = 2
+	if edited(old, rollout) {
+		t.Error("expected a status update not to be an edit")
+	}
+	scaled := old.DeepCopy()
+	scaled.ResourceVersion = "2"
+	replicas := int32(5)
+	scaled.Spec.Replicas = &replicas
+	if !edited(old, scaled) {
+		t.Error("expected scaling to be an edit")
+	}
+	relabelled := old.DeepCopy()
+	relabelled.ResourceVersion = "2"
+	relabelled.Labels = map[string]string{"team": "other"}
+	if !edited(old, relabelled) {
+		t.Error("expected relabelling to be an edit")
+	}
+}
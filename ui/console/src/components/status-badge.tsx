@@ -14,6 +14,8 @@ const statusStyles: Record<string, string> = {
   ready: "bg-green-100 text-green-800",
   building: "bg-indigo-100 text-indigo-800",
   deleting: "bg-gray-100 text-gray-500",
+  sleeping: "bg-slate-100 text-slate-600",
+  degraded: "bg-orange-100 text-orange-800",
 };
 
 const defaultStyle = "bg-gray-100 text-gray-700";
@@ -40,6 +42,8 @@ export function StatusBadge({ status, className = "" }: StatusBadgeProps) {
             ready: "bg-green-500",
             building: "bg-indigo-500",
             deleting: "bg-gray-400",
+            sleeping: "bg-slate-400",
+            degraded: "bg-orange-500",
           }[status] ?? "bg-gray-400"
         }`}
       />
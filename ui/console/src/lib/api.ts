@@ -19,6 +19,20 @@ export interface Package {
 export interface UpstreamConfig {
   url: string;
   headers?: Record<string, string>;
+  auth?: UpstreamAuth;
+}
+
+/** Upstream authentication. Each *SecretRef names an environment secret. */
+export interface UpstreamAuth {
+  type: "bearer" | "oauth2-client-credentials" | "mtls";
+  tokenSecretRef?: string;
+  tokenEndpoint?: string;
+  clientIdSecretRef?: string;
+  clientSecretSecretRef?: string;
+  scopes?: string[];
+  certSecretRef?: string;
+  keySecretRef?: string;
+  caSecretRef?: string;
 }
 
 export interface Dependency {
@@ -42,17 +56,97 @@ export interface Environment {
   baseRootVersion: string;
   branch: string;
   createdBy: string;
-  status: "creating" | "ready" | "building" | "failed" | "deleting";
+  /** Kubernetes-style labels, also applied to cluster resources. */
+  labels?: Record<string, string>;
+  status:
+    | "creating"
+    | "ready"
+    | "building"
+    | "failed"
+    | "deleting"
+    | "sleeping"
+    | "degraded";
   overrides?: PackageOverride[];
+  /** The environment this one was forked from, if any. */
+  parentId?: string;
+  inheritedOverrides?: PackageOverride[];
+  /** The template this environment was created from, if any. */
+  template?: string;
   currentBuildId?: string;
+  /** The builds deployed to this environment, oldest first. */
+  buildHistory?: BuildRecord[];
   previewUrl?: string;
+  sleepSchedule?: SleepSchedule;
+  /** Why a sleeping environment was put to sleep. */
+  sleepReason?: "manual" | "schedule" | "idle";
+  /** Smoke tests run against previewUrl after the latest deploy. */
+  smokeTests?: SmokeTestReport;
+  /** While held, overrides, promotions and deletes need the owner's force. */
+  lock?: Lock;
+  /** Never reaped, bulk deleted or torn down with its branch. */
+  protected?: boolean;
   createdAt: string;
   updatedAt: string;
 }
 
+export interface Lock {
+  owner: string;
+  reason?: string;
+  lockedAt: string;
+  /** When the lock lapses on its own, if ever. */
+  expiresAt?: string;
+}
+
+export interface SmokeTestReport {
+  buildId?: string;
+  passed: number;
+  failed: number;
+  skipped: number;
+  results: SmokeTestResult[];
+  startedAt: string;
+  completedAt: string;
+}
+
+export interface SmokeTestResult {
+  package: string;
+  name: string;
+  method?: string;
+  url?: string;
+  outcome: "passed" | "failed" | "skipped";
+  statusCode?: number;
+  /** Why the request failed or was skipped. */
+  error?: string;
+  durationMs: number;
+}
+
+/** When an environment is scaled to zero. */
+export interface SleepSchedule {
+  /** Daily awake window as "HH:MM" in timezone. */
+  awakeFrom?: string;
+  awakeUntil?: string;
+  /** "mon" through "sun"; empty means every day. */
+  days?: string[];
+  /** IANA time zone name; defaults to UTC. */
+  timezone?: string;
+  /** Go duration, e.g. "2h". */
+  idleAfter?: string;
+}
+
 export interface PackageOverride {
   packageName: string;
-  schema: string;
+  /** Replacement schema. Mutually exclusive with version. */
+  schema?: string;
+  /** Published version to pin the package to. */
+  version?: string;
+  upstreamConfig?: UpstreamConfig;
+  runtime?: RuntimeOverride;
+}
+
+export interface RuntimeOverride {
+  replicas?: number;
+  env?: Record<string, string>;
+  /** Environment variables set from secrets, mapped to the secret's name. */
+  secretEnv?: Record<string, string>;
 }
 
 export interface ListEnvironmentsResponse {
@@ -62,11 +156,34 @@ export interface ListEnvironmentsResponse {
 
 export interface CreateEnvironmentRequest {
   name: string;
-  baseRootPackage: string;
+  /** Required unless parentId or template is set. */
+  baseRootPackage?: string;
   baseRootVersion?: string;
   branch?: string;
   createdBy?: string;
   overrides?: PackageOverride[];
+  /** Fork from this environment, inheriting its base and overrides. */
+  parentId?: string;
+  /**
+   * Start from this template's base, overrides, labels, TTL and sleep
+   * schedule, with this request's own applied on top.
+   */
+  template?: string;
+  /** Layered over the parent's labels when forking. */
+  labels?: Record<string, string>;
+  sleepSchedule?: SleepSchedule;
+  /** Secret values by name. A fork also copies its parent's secrets. */
+  secrets?: Record<string, string>;
+  /** Not inherited by forks. */
+  protected?: boolean;
+}
+
+export interface UpdateEnvironmentRequest {
+  /** Labels to set; null removes the label. */
+  labels?: Record<string, string | null>;
+  /** Replaces the sleep schedule; an empty schedule removes it. */
+  sleepSchedule?: SleepSchedule;
+  protected?: boolean;
 }
 
 export interface ApplyOverridesRequest {
@@ -74,6 +191,74 @@ export interface ApplyOverridesRequest {
   triggerBuild?: boolean;
 }
 
+export interface EnvironmentRef {
+  id: string;
+  name: string;
+  status: Environment["status"];
+  stale: boolean;
+}
+
+export interface Lineage {
+  environmentId: string;
+  parentId?: string;
+  stale: boolean;
+  /** Nearest first. */
+  ancestors: EnvironmentRef[];
+  children: EnvironmentRef[];
+}
+
+export interface BuildRecord {
+  buildId: string;
+  source: "build" | "parent" | "rollback";
+  /** The environment's overrides when the build was deployed. */
+  overrides?: PackageOverride[];
+  outcome: "running" | "succeeded" | "failed" | "cancelled";
+  error?: string;
+  startedAt: string;
+  completedAt?: string;
+}
+
+export interface EnvironmentEvent {
+  id: string;
+  environmentId: string;
+  type:
+    | "created"
+    | "overrides_applied"
+    | "rebased"
+    | "rolled_back"
+    | "build_triggered"
+    | "build_failed"
+    | "deploy_succeeded"
+    | "deploy_failed"
+    | "smoke_tests_passed"
+    | "smoke_tests_failed"
+    | "promoted"
+    | "slept"
+    | "woke"
+    | "secret_set"
+    | "secret_deleted"
+    | "locked"
+    | "unlocked"
+    | "protected"
+    | "unprotected"
+    | "deleted";
+  actor?: string;
+  message?: string;
+  buildId?: string;
+  /** The environment's status after the event. */
+  status?: Environment["status"];
+  overrides?: { added?: string[]; removed?: string[]; changed?: string[] };
+  stage?: "build" | "deploy" | "rollout";
+  error?: string;
+  time: string;
+}
+
+export interface ListEventsResponse {
+  /** Newest first. */
+  events: EnvironmentEvent[];
+  nextPageToken?: string;
+}
+
 export interface PromoteResponse {
   promotedPackages: Array<{ name: string; version: string }>;
 }
@@ -192,6 +377,12 @@ export async function publishPackage(req: PublishRequest): Promise<Package> {
 export interface ListEnvironmentsParams {
   branch?: string;
   createdBy?: string;
+  template?: string;
+  /** Label selector, e.g. "team=payments,purpose!=demo". */
+  labelSelector?: string;
+  status?: Environment["status"][];
+  /** created_at, updated_at, name or status; "-" prefix for descending. */
+  sort?: string;
   pageSize?: number;
   pageToken?: string;
 }
@@ -202,6 +393,10 @@ export async function listEnvironments(
   const qs = new URLSearchParams();
   if (params?.branch) qs.set("branch", params.branch);
   if (params?.createdBy) qs.set("created_by", params.createdBy);
+  if (params?.template) qs.set("template", params.template);
+  if (params?.labelSelector) qs.set("label_selector", params.labelSelector);
+  if (params?.status?.length) qs.set("status", params.status.join(","));
+  if (params?.sort) qs.set("sort", params.sort);
   if (params?.pageSize) qs.set("page_size", String(params.pageSize));
   if (params?.pageToken) qs.set("page_token", params.pageToken);
   const q = qs.toString();
@@ -210,12 +405,22 @@ export async function listEnvironments(
   );
 }
 
+/**
+ * Headers making a request safe to retry: a retry with the same key gets the
+ * first response instead of repeating the request.
+ */
+function idempotencyHeaders(key?: string): Record<string, string> {
+  return key ? { "Idempotency-Key": key } : {};
+}
+
 export async function createEnvironment(
   req: CreateEnvironmentRequest,
+  idempotencyKey?: string,
 ): Promise<Environment> {
   return request<Environment>("/api/envmanager/v1/environments", {
     method: "POST",
     body: JSON.stringify(req),
+    headers: idempotencyHeaders(idempotencyKey),
   });
 }
 
@@ -225,9 +430,115 @@ export async function getEnvironment(id: string): Promise<Environment> {
   );
 }
 
-export async function deleteEnvironment(id: string): Promise<void> {
-  return request<void>(
+export async function updateEnvironment(
+  id: string,
+  req: UpdateEnvironmentRequest,
+): Promise<Environment> {
+  return request<Environment>(
     `/api/envmanager/v1/environments/${encodeURIComponent(id)}`,
+    { method: "PATCH", body: JSON.stringify(req) },
+  );
+}
+
+export interface Quotas {
+  maxEnvironmentsPerUser?: number;
+  maxEnvironmentsPerTeam?: number;
+  maxComponents?: number;
+  maxReplicas?: number;
+}
+
+export interface EnvironmentUsage {
+  name: string;
+  environments: number;
+  /** Absent when unlimited. */
+  limit?: number;
+}
+
+export interface UsageResponse {
+  user?: EnvironmentUsage;
+  team?: EnvironmentUsage;
+  quotas: Quotas;
+}
+
+/** Environment usage against quotas. The user defaults to the caller. */
+export async function getUsage(params?: {
+  user?: string;
+  team?: string;
+}): Promise<UsageResponse> {
+  const qs = new URLSearchParams();
+  if (params?.user) qs.set("user", params.user);
+  if (params?.team) qs.set("team", params.team);
+  const q = qs.toString();
+  return request<UsageResponse>(`/api/envmanager/v1/usage${q ? `?${q}` : ""}`);
+}
+
+/** Query string letting a lock's owner override it. */
+function forceQuery(force?: boolean): string {
+  return force ? "?force=true" : "";
+}
+
+export async function deleteEnvironment(id: string, force?: boolean): Promise<void> {
+  return request<void>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(id)}${forceQuery(force)}`,
+    { method: "DELETE" },
+  );
+}
+
+export interface BulkDeleteResult {
+  environmentId: string;
+  name: string;
+  branch?: string;
+  /** Why a skipped environment was not deleted. */
+  reason?: string;
+}
+
+export interface BulkDeleteResponse {
+  dryRun: boolean;
+  deleted: BulkDeleteResult[];
+  skipped: BulkDeleteResult[];
+}
+
+/**
+ * Deletes every environment matching the filters, at least one of which is
+ * required. Protected and locked environments are always skipped.
+ */
+export async function bulkDeleteEnvironments(params: {
+  branch?: string;
+  createdBy?: string;
+  parentId?: string;
+  template?: string;
+  labelSelector?: string;
+  status?: Environment["status"][];
+  dryRun?: boolean;
+}): Promise<BulkDeleteResponse> {
+  const qs = new URLSearchParams();
+  if (params.branch) qs.set("branch", params.branch);
+  if (params.createdBy) qs.set("created_by", params.createdBy);
+  if (params.parentId) qs.set("parent_id", params.parentId);
+  if (params.template) qs.set("template", params.template);
+  if (params.labelSelector) qs.set("label_selector", params.labelSelector);
+  if (params.status?.length) qs.set("status", params.status.join(","));
+  if (params.dryRun) qs.set("dry_run", "true");
+  return request<BulkDeleteResponse>(`/api/envmanager/v1/environments?${qs}`, {
+    method: "DELETE",
+  });
+}
+
+/** Locks an environment for the caller, or renews their lock. */
+export async function lockEnvironment(
+  environmentId: string,
+  req: { reason?: string; expiresAt?: string; ttl?: string } = {},
+): Promise<Environment> {
+  return request<Environment>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/lock`,
+    { method: "PUT", body: JSON.stringify(req) },
+  );
+}
+
+/** Releases an environment's lock. Only its owner may. */
+export async function unlockEnvironment(environmentId: string): Promise<Environment> {
+  return request<Environment>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/lock`,
     { method: "DELETE" },
   );
 }
@@ -235,20 +546,126 @@ export async function deleteEnvironment(id: string): Promise<void> {
 export async function applyOverrides(
   environmentId: string,
   req: ApplyOverridesRequest,
+  idempotencyKey?: string,
+  force?: boolean,
 ): Promise<Environment> {
   return request<Environment>(
-    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/overrides`,
-    { method: "POST", body: JSON.stringify(req) },
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/overrides${forceQuery(force)}`,
+    {
+      method: "POST",
+      body: JSON.stringify(req),
+      headers: idempotencyHeaders(idempotencyKey),
+    },
   );
 }
 
-export async function promote(environmentId: string): Promise<PromoteResponse> {
+export async function promote(
+  environmentId: string,
+  idempotencyKey?: string,
+  force?: boolean,
+): Promise<PromoteResponse> {
   return request<PromoteResponse>(
-    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/promote`,
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/promote${forceQuery(force)}`,
+    { method: "POST", headers: idempotencyHeaders(idempotencyKey) },
+  );
+}
+
+export async function getLineage(environmentId: string): Promise<Lineage> {
+  return request<Lineage>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/lineage`,
+  );
+}
+
+export async function rebase(
+  environmentId: string,
+  triggerBuild = false,
+): Promise<Environment> {
+  return request<Environment>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/rebase`,
+    { method: "POST", body: JSON.stringify({ triggerBuild }) },
+  );
+}
+
+export async function listBuilds(environmentId: string): Promise<BuildRecord[]> {
+  const res = await request<{ builds: BuildRecord[] }>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/builds`,
+  );
+  return res.builds;
+}
+
+export async function listEvents(
+  environmentId: string,
+  params?: { pageSize?: number; pageToken?: string },
+): Promise<ListEventsResponse> {
+  const qs = new URLSearchParams();
+  if (params?.pageSize) qs.set("page_size", String(params.pageSize));
+  if (params?.pageToken) qs.set("page_token", params.pageToken);
+  const q = qs.toString();
+  return request<ListEventsResponse>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/events${q ? `?${q}` : ""}`,
+  );
+}
+
+/** Redeploys buildId, or the previous successful build if omitted. */
+export async function rollback(
+  environmentId: string,
+  buildId?: string,
+): Promise<Environment> {
+  return request<Environment>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/rollback`,
+    { method: "POST", body: JSON.stringify({ buildId }) },
+  );
+}
+
+/** Scales an environment to zero until it is woken or redeployed. */
+export async function sleepEnvironment(environmentId: string): Promise<Environment> {
+  return request<Environment>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/sleep`,
+    { method: "POST" },
+  );
+}
+
+/** Scales a sleeping environment back up. */
+export async function wakeEnvironment(environmentId: string): Promise<Environment> {
+  return request<Environment>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/wake`,
     { method: "POST" },
   );
 }
 
+/** A secret's name and timestamps; values are never returned. */
+export interface SecretMetadata {
+  name: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export async function listSecrets(environmentId: string): Promise<SecretMetadata[]> {
+  const res = await request<{ secrets: SecretMetadata[] }>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/secrets`,
+  );
+  return res.secrets;
+}
+
+/** Creates or replaces a secret; it reaches components on the next deploy. */
+export async function setSecret(
+  environmentId: string,
+  name: string,
+  value: string,
+): Promise<SecretMetadata> {
+  return request<SecretMetadata>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/secrets/${encodeURIComponent(name)}`,
+    { method: "PUT", body: JSON.stringify({ value }) },
+  );
+}
+
+export async function deleteSecret(environmentId: string, name: string): Promise<void> {
+  return request<void>(
+    `/api/envmanager/v1/environments/${encodeURIComponent(environmentId)}/secrets/${encodeURIComponent(name)}`,
+    { method: "DELETE" },
+  );
+}
+
 // ---------------------------------------------------------------------------
 // Builder API
 // ---------------------------------------------------------------------------
@@ -293,3 +710,51 @@ export function streamBuildLogs(
 
   return source;
 }
+
+/** What an environment created from a template starts with. */
+export interface TemplateSpec {
+  description?: string;
+  baseRootPackage: string;
+  baseRootVersion?: string;
+  overrides?: PackageOverride[];
+  labels?: Record<string, string>;
+  /** Go duration, e.g. "72h". */
+  ttl?: string;
+  sleepSchedule?: SleepSchedule;
+  quotas?: {
+    /** How many environments from the template may exist at once. */
+    maxEnvironments?: number;
+    maxComponents?: number;
+    maxReplicas?: number;
+  };
+}
+
+export interface Template extends TemplateSpec {
+  name: string;
+  createdBy?: string;
+  createdAt: string;
+  updatedAt: string;
+}
+
+export async function listTemplates(): Promise<Template[]> {
+  const res = await request<{ templates: Template[] }>("/api/envmanager/v1/templates");
+  return res.templates;
+}
+
+export async function getTemplate(name: string): Promise<Template> {
+  return request<Template>(`/api/envmanager/v1/templates/${encodeURIComponent(name)}`);
+}
+
+/** Creates or replaces a template. */
+export async function putTemplate(name: string, spec: TemplateSpec): Promise<Template> {
+  return request<Template>(`/api/envmanager/v1/templates/${encodeURIComponent(name)}`, {
+    method: "PUT",
+    body: JSON.stringify(spec),
+  });
+}
+
+export async function deleteTemplate(name: string): Promise<void> {
+  return request<void>(`/api/envmanager/v1/templates/${encodeURIComponent(name)}`, {
+    method: "DELETE",
+  });
+}
@@ -198,7 +198,7 @@ export function EnvironmentDetail() {
                         Package
                       </th>
                       <th className="px-4 py-2 text-left text-xs font-medium uppercase text-gray-500">
-                        Schema Preview
+                        Override
                       </th>
                     </tr>
                   </thead>
@@ -209,10 +209,27 @@ export function EnvironmentDetail() {
                           {o.packageName}
                         </td>
                         <td className="px-4 py-2">
-                          <pre className="max-h-20 overflow-auto text-xs text-gray-500">
-                            {o.schema.slice(0, 200)}
-                            {o.schema.length > 200 ? "..." : ""}
-                          </pre>
+                          {o.schema && (
+                            <pre className="max-h-20 overflow-auto text-xs text-gray-500">
+                              {o.schema.slice(0, 200)}
+                              {o.schema.length > 200 ? "..." : ""}
+                            </pre>
+                          )}
+                          {o.version && (
+                            <p className="text-xs text-gray-500">
+                              Pinned to {o.version}
+                            </p>
+                          )}
+                          {o.upstreamConfig && (
+                            <p className="text-xs text-gray-500">
+                              Upstream {o.upstreamConfig.url}
+                            </p>
+                          )}
+                          {o.runtime?.replicas !== undefined && (
+                            <p className="text-xs text-gray-500">
+                              {o.runtime.replicas} replicas
+                            </p>
+                          )}
                         </td>
                       </tr>
                     ))}
@@ -252,6 +269,7 @@ export function EnvironmentDetail() {
                 label="Base Version"
                 value={env.baseRootVersion || "-"}
               />
+              <DetailRow label="Forked From" value={env.parentId || "-"} />
               <DetailRow label="Branch" value={env.branch || "-"} />
               <DetailRow label="Created By" value={env.createdBy || "-"} />
               <DetailRow
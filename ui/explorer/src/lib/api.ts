@@ -40,6 +40,9 @@ export interface Environment {
   createdBy: string;
   status: "creating" | "ready" | "building" | "failed" | "deleting";
   overrides?: PackageOverride[];
+  /** The environment this one was forked from, if any. */
+  parentId?: string;
+  inheritedOverrides?: PackageOverride[];
   currentBuildId?: string;
   previewUrl?: string;
   createdAt: string;
@@ -48,7 +51,17 @@ export interface Environment {
 
 export interface PackageOverride {
   packageName: string;
-  schema: string;
+  /** Replacement schema. Mutually exclusive with version. */
+  schema?: string;
+  /** Published version to pin the package to. */
+  version?: string;
+  upstreamConfig?: UpstreamConfig;
+  runtime?: RuntimeOverride;
+}
+
+export interface RuntimeOverride {
+  replicas?: number;
+  env?: Record<string, string>;
 }
 
 export interface ListEnvironmentsResponse {